@@ -0,0 +1,21 @@
+//go:build !(linux || darwin || freebsd || openbsd || netbsd || dragonfly || windows)
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// checkWritable falls back to the permission bits on platforms without
+// faccessat; removal errors still surface if this is too optimistic.
+func checkWritable(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0222 == 0 {
+		return fmt.Errorf("%s is not writable", dir)
+	}
+	return nil
+}
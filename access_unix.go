@@ -0,0 +1,11 @@
+//go:build linux || darwin || freebsd || openbsd || netbsd || dragonfly
+
+package main
+
+import "golang.org/x/sys/unix"
+
+// checkWritable asks the kernel via faccessat(2) whether the effective user
+// can write to dir. Read-only mounts report EROFS.
+func checkWritable(dir string) error {
+	return unix.Faccessat(unix.AT_FDCWD, dir, unix.W_OK|unix.X_OK, unix.AT_EACCESS)
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package main
+
+import "golang.org/x/sys/windows"
+
+const (
+	fileAddFile     = 0x00000002
+	fileDeleteChild = 0x00000040
+)
+
+// checkWritable opens dir requesting the rights removal needs; the handle is
+// only granted if the directory's ACL allows it, and nothing is written.
+func checkWritable(dir string) error {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return err
+	}
+	handle, err := windows.CreateFile(
+		path,
+		fileAddFile|fileDeleteChild,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE,
+		nil,
+		windows.OPEN_EXISTING,
+		windows.FILE_FLAG_BACKUP_SEMANTICS,
+		0,
+	)
+	if err != nil {
+		return err
+	}
+	return windows.CloseHandle(handle)
+}
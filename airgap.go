@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Air-gapped networks cannot reach go.dev, so archives can come from a local
+// directory (or file:// URL) mirrored by hand. The directory holds archives
+// with their go.dev names plus checksums, either a SHA256SUMS file in
+// sha256sum format or one <archive>.sha256 file per archive as go.dev
+// publishes them.
+
+// localSourceDir turns an artifact source into a directory path.
+func localSourceDir(source string) (string, error) {
+	if strings.HasPrefix(source, "file://") {
+		u, err := url.Parse(source)
+		if err != nil {
+			return "", fmt.Errorf("invalid artifact source %q: %v", source, err)
+		}
+		if u.Host != "" && u.Host != "localhost" {
+			return "", fmt.Errorf("artifact source %q names a remote host; only local file:// URLs are supported", source)
+		}
+		return currentPlatform.fileURLPath(u.Path), nil
+	}
+	if !filepath.IsAbs(source) {
+		return "", fmt.Errorf("artifact source must be an absolute path or file:// URL, got %q", source)
+	}
+	return source, nil
+}
+
+// goArchiveName is the file name go.dev gives the archive for a platform.
+func goArchiveName(version, goos, goarch string) string {
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("%s.%s-%s%s", normalizeGoVersion(version), goos, goarch, ext)
+}
+
+// parseChecksums reads sha256sum output: "<hex>  <name>", with "*" before
+// the name for binary mode.
+func parseChecksums(data string) map[string]string {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if name := strings.TrimPrefix(fields[1], "*"); name != "" {
+			sums[name] = strings.ToLower(fields[0])
+		}
+	}
+	return sums
+}
+
+// localChecksum finds the published checksum for name in dir.
+func localChecksum(dir, name string) (string, error) {
+	if data, err := os.ReadFile(filepath.Join(dir, "SHA256SUMS")); err == nil {
+		if sum, ok := parseChecksums(string(data))[name]; ok {
+			return sum, nil
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, name+".sha256")); err == nil {
+		if fields := strings.Fields(string(data)); len(fields) > 0 {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum for %s in %s (expected SHA256SUMS or %s.sha256)", name, dir, name)
+}
+
+// localGoArchive verifies and returns the archive for version and platform
+// in source. Archives without a checksum are refused.
+func localGoArchive(source, version, goos, goarch string) (string, error) {
+	dir, err := localSourceDir(source)
+	if err != nil {
+		return "", err
+	}
+	name := goArchiveName(version, goos, goarch)
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("%s not found in %s: %v", name, dir, err)
+	}
+	expected, err := localChecksum(dir, name)
+	if err != nil {
+		return "", err
+	}
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if sum != expected {
+		return "", fmt.Errorf("checksum mismatch for %s: got %s, expected %s", path, sum, expected)
+	}
+	tracef("airgap: verified %s", path)
+	return path, nil
+}
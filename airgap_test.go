@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func writeMirror(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestLocalGoArchive(t *testing.T) {
+	archive := "pretend this is a Go tarball"
+	sum := sha256Hex([]byte(archive))
+
+	testCases := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "SHA256SUMS",
+			files: map[string]string{
+				"go1.22.5.linux-amd64.tar.gz": archive,
+				"SHA256SUMS":                  "0000  go1.21.0.linux-amd64.tar.gz\n" + strings.ToUpper(sum) + " *go1.22.5.linux-amd64.tar.gz\n",
+			},
+		},
+		{
+			name: "sha256 file",
+			files: map[string]string{
+				"go1.22.5.linux-amd64.tar.gz":        archive,
+				"go1.22.5.linux-amd64.tar.gz.sha256": sum + "\n",
+			},
+		},
+		{
+			name:    "no checksum",
+			files:   map[string]string{"go1.22.5.linux-amd64.tar.gz": archive},
+			wantErr: "no checksum",
+		},
+		{
+			name: "mismatch",
+			files: map[string]string{
+				"go1.22.5.linux-amd64.tar.gz": "tampered",
+				"SHA256SUMS":                  sum + "  go1.22.5.linux-amd64.tar.gz\n",
+			},
+			wantErr: "checksum mismatch",
+		},
+		{
+			name:    "missing archive",
+			files:   map[string]string{"SHA256SUMS": sum + "  go1.22.5.linux-amd64.tar.gz\n"},
+			wantErr: "not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeMirror(t, tc.files)
+			path, err := localGoArchive(dir, "1.22.5", "linux", "amd64")
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("localGoArchive returned error: %v", err)
+			}
+			if path != filepath.Join(dir, "go1.22.5.linux-amd64.tar.gz") {
+				t.Errorf("Unexpected path %s", path)
+			}
+		})
+	}
+}
+
+func TestLocalSourceDir(t *testing.T) {
+	abs := t.TempDir()
+	url := "file://" + filepath.ToSlash(abs)
+	if runtime.GOOS == "windows" {
+		url = "file:///" + filepath.ToSlash(abs)
+	}
+	for _, source := range []string{abs, url} {
+		if dir, err := localSourceDir(source); err != nil || dir != abs {
+			t.Errorf("localSourceDir(%q) = %q, %v; want %q", source, dir, err, abs)
+		}
+	}
+	for _, source := range []string{"relative/mirror", "file://mirror.corp/go"} {
+		if _, err := localSourceDir(source); err == nil {
+			t.Errorf("Expected error for %q", source)
+		}
+	}
+}
+
+func TestFetchGoArchiveFromMirror(t *testing.T) {
+	archive := "pretend this is a Go zip"
+	dir := writeMirror(t, map[string]string{
+		"go1.22.5.windows-arm64.zip": archive,
+		"SHA256SUMS":                 sha256Hex([]byte(archive)) + "  go1.22.5.windows-arm64.zip\n",
+	})
+	dials := countDials(t)
+
+	cfg := Config{ArtifactSource: dir}
+	path, origin, err := fetchGoArchive(cfg, artifactCache{dir: t.TempDir()}, "go1.22.5", "windows", "arm64")
+	if err != nil {
+		t.Fatalf("fetchGoArchive returned error: %v", err)
+	}
+	if origin != archiveFromLocal || path != filepath.Join(dir, "go1.22.5.windows-arm64.zip") {
+		t.Errorf("Expected the mirrored archive, got %s (from %s)", path, origin)
+	}
+	if n := dials.Load(); n != 0 {
+		t.Errorf("Expected no network connections, got %d", n)
+	}
+}
+
+// FuzzParseChecksums feeds arbitrary SHA256SUMS files to the parser, which
+// decides which archive hash a mirror download is held to.
+func FuzzParseChecksums(f *testing.F) {
+	f.Add("0123abcd  go1.22.5.linux-amd64.tar.gz\nFFEE *go1.22.5.windows-amd64.zip\n")
+	f.Add("only-one-field\n\n  \t\n")
+	f.Add("a b c\n")
+	f.Fuzz(func(t *testing.T, data string) {
+		for name, sum := range parseChecksums(data) {
+			if name == "" || strings.ContainsFunc(name, unicode.IsSpace) {
+				t.Errorf("Unusable file name %q", name)
+			}
+			if sum != strings.ToLower(sum) || sum == "" {
+				t.Errorf("Checksum %q for %s is not normalized", sum, name)
+			}
+		}
+	})
+}
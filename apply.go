@@ -0,0 +1,345 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newKeygenCmd() *cobra.Command {
+	var out string
+	cmd := &cobra.Command{
+		Use:   "keygen <name>",
+		Short: "Create a signing key for approving removal plans",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if out == "" {
+				out = args[0] + ".key"
+			}
+			key, err := generateApprovalKey(args[0])
+			if err != nil {
+				return err
+			}
+			if err := saveApprovalKey(out, key); err != nil {
+				return err
+			}
+			public, _ := key.publicKey()
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s. Add this to approval_keys in the machine policy:\n  %q: %q\n", out, key.Name, public)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&out, "out", "", "where to write the key (default <name>.key)")
+	return cmd
+}
+
+// planInstallations picks what a new plan removes: the given paths, or every
+// verified installation of the running user's that nothing blocks.
+func planInstallations(installations []GoInstallation, paths []string) ([]GoInstallation, error) {
+	var chosen []GoInstallation
+	if len(paths) == 0 {
+		for _, install := range installations {
+			if install.Verified && install.Blocked == "" && install.User == "" {
+				chosen = append(chosen, install)
+			}
+		}
+		return chosen, nil
+	}
+	for _, path := range paths {
+		found := false
+		for _, install := range installations {
+			if install.Path == path {
+				if install.Blocked != "" {
+					return nil, fmt.Errorf("cannot plan to remove %s: %s", path, install.Blocked)
+				}
+				chosen = append(chosen, install)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s is not a detected Go installation", path)
+		}
+	}
+	return chosen, nil
+}
+
+func writePlanSummary(w io.Writer, sp signedPlan, plan removalPlan) {
+	fmt.Fprintf(w, "Plan %s for %s, created %s:\n", sp.digest(), plan.Host, plan.CreatedAt.Format("2006-01-02 15:04 MST"))
+	for _, entry := range plan.Entries {
+		fmt.Fprintf(w, "  remove %s (%s, %s, %s)\n", entry.Path, entry.Version, entry.Source, formatBytes(entry.Size))
+	}
+	for _, sig := range sp.Signatures {
+		fmt.Fprintf(w, "  signed by %s\n", sig.Signer)
+	}
+}
+
+func newPlanCmd(opts *runOptions) *cobra.Command {
+	var keyPath, out string
+	var paths []string
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Write a signed removal plan for a second person to approve",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := loadApprovalKey(keyPath)
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			var installations []GoInstallation
+			if opts.simulate {
+				installations = simulatedInventory()
+			} else {
+				installations = detectGoInstallations(cfg)
+			}
+			markBlockedInstallations(installations, cfg.AllowCrossMounts)
+			chosen, err := planInstallations(installations, paths)
+			if err != nil {
+				return err
+			}
+			if len(chosen) == 0 {
+				return fmt.Errorf("nothing to remove")
+			}
+			host, _ := os.Hostname()
+			plan := newRemovalPlan(host, chosen)
+			sp, err := newSignedPlan(plan)
+			if err != nil {
+				return err
+			}
+			if err := sp.sign(key); err != nil {
+				return err
+			}
+			if err := saveSignedPlan(out, sp); err != nil {
+				return err
+			}
+			writePlanSummary(cmd.OutOrStdout(), sp, plan)
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s; a second approver runs fu-go approve %s\n", out, out)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&keyPath, "key", "", "your signing key from fu-go keygen")
+	cmd.Flags().StringVar(&out, "out", "fugo-plan.json", "where to write the plan")
+	cmd.Flags().StringArrayVar(&paths, "path", nil, "installation to include (repeatable; default every verified, unblocked one of your own)")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+func newApproveCmd() *cobra.Command {
+	var keyPath string
+	cmd := &cobra.Command{
+		Use:   "approve <plan>",
+		Short: "Countersign a removal plan written by someone else",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := loadApprovalKey(keyPath)
+			if err != nil {
+				return err
+			}
+			sp, err := loadSignedPlan(args[0])
+			if err != nil {
+				return err
+			}
+			plan, err := sp.plan()
+			if err != nil {
+				return err
+			}
+			if len(sp.Signatures) == 0 {
+				return fmt.Errorf("%s is not signed by its author", args[0])
+			}
+			if err := sp.sign(key); err != nil {
+				return err
+			}
+			if err := saveSignedPlan(args[0], sp); err != nil {
+				return err
+			}
+			writePlanSummary(cmd.OutOrStdout(), sp, plan)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&keyPath, "key", "", "your signing key from fu-go keygen")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+// applyPlan removes the plan's installations after checking that two trusted
+// people signed it for this host and that each path still holds what they
+// signed; a single mismatch fails the whole plan before anything is removed.
+// Every step, and who approved it, goes to the log. Installations already
+// gone count as done, so applying a plan twice reports no changes the second
+// time.
+func applyPlan(out eventWriter, sp signedPlan, host string, cfg Config, paths fugoPaths, logger *Logger, report *changeReport) error {
+	if !report.DryRun && (auditBuild || currentPolicy().DryRunOnly) {
+		return fmt.Errorf("live removals are disabled: %s", liveModeLock())
+	}
+	signers, err := sp.verify(currentPolicy().ApprovalKeys)
+	if err != nil {
+		return err
+	}
+	plan, err := sp.plan()
+	if err != nil {
+		return err
+	}
+	if plan.Host != host {
+		return fmt.Errorf("plan was made for %s, not %s", plan.Host, host)
+	}
+	approvedBy := strings.Join(signers[1:], ", ")
+	if logger != nil {
+		logger.Log("INFO", fmt.Sprintf("Applying plan %s: authored by %s, approved by %s", sp.digest(), signers[0], approvedBy))
+	}
+	out.emit("plan", fmt.Sprintf("Plan %s, authored by %s, approved by %s", sp.digest(), signers[0], approvedBy), "digest", sp.digest(), "author", signers[0], "approvers", strings.Join(signers[1:], ","))
+
+	var installations []GoInstallation
+	var detected []GoInstallation
+	redetected := false
+	for i, signed := range plan.installations() {
+		if _, err := os.Stat(signed.Path); os.IsNotExist(err) {
+			out.emit("skip", fmt.Sprintf("%s is already removed", signed.Path), "path", signed.Path, "reason", "missing")
+			continue
+		} else if err != nil {
+			return fmt.Errorf("%s is unreadable since the plan was made: %v", signed.Path, err)
+		}
+		if !redetected {
+			detected, redetected = redetectInstallations(cfg), true
+		}
+		install, err := matchSignedEntry(signed, detected)
+		if err != nil {
+			return fmt.Errorf("plan entry %d: %v; nothing was removed", i+1, err)
+		}
+		installations = append(installations, install)
+	}
+	markBlockedInstallations(installations, cfg.AllowCrossMounts)
+	for _, install := range installations {
+		if install.Blocked != "" {
+			return fmt.Errorf("cannot remove %s: %s", install.Path, install.Blocked)
+		}
+	}
+	home, _ := os.UserHomeDir()
+	items := make([]PlanItem, len(installations))
+	for i, install := range installations {
+		items[i] = installationPlanItem(install, home)
+	}
+	progress := startItemProgress(out.events, phaseRemove, items)
+	if report.DryRun {
+		for i, install := range installations {
+			out.emit("remove", fmt.Sprintf("Would remove %s", install.Path), "path", install.Path, "bytes", install.Size, "dry_run", true)
+			report.add("remove "+install.Path, install.Size)
+			progress.finish(itemResult{item: items[i], skipped: "dry run"})
+		}
+		return nil
+	}
+	taken, err := backupInstallations(cfg, installations, paths.Backups)
+	if logger != nil {
+		taken.logTo(logger.Log)
+	}
+	if err != nil {
+		return err
+	}
+	env := planEnv{allowCrossMounts: cfg.AllowCrossMounts, backupDir: paths.Backups, journal: paths.Journal, events: out.events, quarantined: taken.moved}
+	for i, install := range installations {
+		item := items[i]
+		start := time.Now()
+		err := item.Execute(env)
+		progress.finish(itemResult{item: item, err: err, duration: time.Since(start)})
+		if err != nil {
+			if logger != nil {
+				logger.Log("ERROR", fmt.Sprintf("Failed to remove %s: %v", install.Path, err))
+			}
+			return err
+		}
+		if logger != nil {
+			logger.Log("SUCCESS", fmt.Sprintf("Removed %s (plan %s)", install.Path, sp.digest()))
+		}
+		report.add("remove "+install.Path, install.Size)
+		out.emit("remove", fmt.Sprintf("Removed %s", install.Path), "path", install.Path, "bytes", install.Size, "dry_run", false)
+	}
+	return nil
+}
+
+// redetectInstallations is the inventory a signed plan is checked against;
+// tests replace it.
+var redetectInstallations = detectGoInstallations
+
+// matchSignedEntry returns the installation now at signed's path, provided
+// it is what the approvers signed: still a Go root, of the same version and
+// found the same way. Anything else at that path was never approved.
+func matchSignedEntry(signed GoInstallation, detected []GoInstallation) (GoInstallation, error) {
+	if isCriticalPath(signed.Path) {
+		return GoInstallation{}, fmt.Errorf("refusing to remove critical system path %s", signed.Path)
+	}
+	current, found := GoInstallation{}, false
+	for _, install := range detected {
+		if filepath.Clean(install.Path) == filepath.Clean(signed.Path) {
+			current, found = install, true
+			break
+		}
+	}
+	if !found {
+		// No detector looks where a path added by hand is
+		if signed.Source != "manual" {
+			return GoInstallation{}, fmt.Errorf("%s is no longer detected as a %s installation", signed.Path, signed.Source)
+		}
+		if info, err := os.Lstat(signed.Path); err != nil || !info.IsDir() {
+			return GoInstallation{}, fmt.Errorf("%s is no longer a directory", signed.Path)
+		}
+		if verifyGoRoot(signed.Path).passed() == 0 {
+			return GoInstallation{}, fmt.Errorf("%s is no longer a Go root: no VERSION, bin/go or pkg/tool", signed.Path)
+		}
+		current = newInstallation(signed.Path, "manual")
+	}
+	switch {
+	case current.Version != signed.Version:
+		return GoInstallation{}, fmt.Errorf("%s is now %q, the plan approved %q", signed.Path, current.Version, signed.Version)
+	case current.Source != signed.Source:
+		return GoInstallation{}, fmt.Errorf("%s now comes from %s, the plan approved %s", signed.Path, current.Source, signed.Source)
+	case current.PackageManager != signed.PackageManager || current.Package != signed.Package:
+		return GoInstallation{}, fmt.Errorf("%s now belongs to package %q of %q, the plan approved %q of %q", signed.Path, current.Package, current.PackageManager, signed.Package, signed.PackageManager)
+	}
+	return current, nil
+}
+
+func newApplyCmd(opts *runOptions) *cobra.Command {
+	var check bool
+	cmd := &cobra.Command{
+		Use:   "apply <plan>",
+		Short: "Execute a removal plan signed by two trusted approvers",
+		Long:  "apply removes the installations in a plan from fu-go plan, once it carries signatures from two different\napprovers listed in approval_keys of the machine policy. Both identities are recorded in the log.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sp, err := loadSignedPlan(args[0])
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			paths, err := resolvePaths(cfg)
+			if err != nil {
+				return err
+			}
+			logger, err := newConfiguredLogger(*opts, paths.Logs)
+			if err != nil {
+				return err
+			}
+			defer logger.Close()
+			host, _ := os.Hostname()
+			out := newEventWriter(cmd.OutOrStdout(), *opts)
+			stop := startHeadlessEvents(&out, *opts, cmd.ErrOrStderr())
+			defer stop()
+			report := newChangeReport(check, false)
+			if err := applyPlan(out, sp, host, cfg, paths, logger, report); err != nil {
+				return err
+			}
+			return report.finish(out)
+		},
+	}
+	addCheckFlag(cmd, &check)
+	return cmd
+}
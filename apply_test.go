@@ -0,0 +1,169 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyPlan(t *testing.T) {
+	skipInAuditBuild(t)
+	noRedetection(t)
+	keys := approvers(t, "alice", "bob")
+	goRoot := fakeGoRoot(t, "VERSION", "bin/go")
+	sp, _ := newSignedPlan(newRemovalPlan("build-07", []GoInstallation{{Path: goRoot, Version: "go version go1.22.5", Source: "manual"}}))
+	sp.sign(keys[0])
+	cfg := Config{BackupPolicy: "never"}
+
+	if err := applyPlan(eventWriter{w: io.Discard}, sp, "build-07", cfg, fugoPaths{Backups: t.TempDir()}, nil, newChangeReport(false, false)); err == nil || !strings.Contains(err.Error(), "required signatures") {
+		t.Fatalf("Expected an unapproved plan to be refused, got %v", err)
+	}
+	sp.sign(keys[1])
+	if err := applyPlan(eventWriter{w: io.Discard}, sp, "build-08", cfg, fugoPaths{Backups: t.TempDir()}, nil, newChangeReport(false, false)); err == nil || !strings.Contains(err.Error(), "made for build-07") {
+		t.Fatalf("Expected a plan for another host to be refused, got %v", err)
+	}
+	if _, err := os.Stat(goRoot); err != nil {
+		t.Fatalf("Expected %s to survive refused plans: %v", goRoot, err)
+	}
+
+	logger, err := NewLogger(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLogger returned error: %v", err)
+	}
+	check := newChangeReport(true, false)
+	if err := applyPlan(eventWriter{w: io.Discard}, sp, "build-07", cfg, fugoPaths{Backups: t.TempDir()}, logger, check); err != nil || !check.Changed {
+		t.Fatalf("Expected check mode to report a pending removal, got %+v, %v", check, err)
+	}
+	if _, err := os.Stat(goRoot); err != nil {
+		t.Fatalf("Expected check mode to leave %s alone: %v", goRoot, err)
+	}
+	report := newChangeReport(false, false)
+	if err := applyPlan(eventWriter{w: io.Discard}, sp, "build-07", cfg, fugoPaths{Backups: t.TempDir()}, logger, report); err != nil {
+		t.Fatalf("applyPlan returned error: %v", err)
+	}
+	logger.Close()
+	if _, err := os.Stat(goRoot); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", goRoot, err)
+	}
+	if !report.Changed || len(report.Changes) != 1 {
+		t.Errorf("Expected one change reported, got %+v", report)
+	}
+	again := newChangeReport(false, false)
+	if err := applyPlan(eventWriter{w: io.Discard}, sp, "build-07", cfg, fugoPaths{Backups: t.TempDir()}, nil, again); err != nil || again.Changed {
+		t.Errorf("Expected a second apply to change nothing, got %+v, %v", again, err)
+	}
+
+	logs, _ := filepath.Glob(filepath.Join(logger.Dir(), "*.log"))
+	if len(logs) != 1 {
+		t.Fatalf("Expected one log file, got %v", logs)
+	}
+	data, _ := os.ReadFile(logs[0])
+	if !strings.Contains(string(data), "authored by alice, approved by bob") {
+		t.Errorf("Expected both identities in the log, got:\n%s", data)
+	}
+}
+
+// noRedetection makes applyPlan find no detected installations, so only
+// paths added by hand can match.
+func noRedetection(t *testing.T) {
+	orig := redetectInstallations
+	t.Cleanup(func() { redetectInstallations = orig })
+	redetectInstallations = func(Config) []GoInstallation { return nil }
+}
+
+// approvedPlan is a plan for host signed by two approvers.
+func approvedPlan(t *testing.T, host string, installations []GoInstallation) signedPlan {
+	t.Helper()
+	sp, err := newSignedPlan(newRemovalPlan(host, installations))
+	if err != nil {
+		t.Fatalf("newSignedPlan returned error: %v", err)
+	}
+	for _, key := range approvers(t, "alice", "bob") {
+		sp.sign(key)
+	}
+	return sp
+}
+
+func TestApplyPlanRefusesReplacedPath(t *testing.T) {
+	skipInAuditBuild(t)
+	noRedetection(t)
+	goRoot := fakeGoRoot(t, "VERSION", "bin/go")
+	other := fakeGoRoot(t, "VERSION")
+	sp := approvedPlan(t, "build-07", []GoInstallation{
+		{Path: other, Version: "go version go1.22.5", Source: "manual"},
+		{Path: goRoot, Version: "go version go1.22.5", Source: "manual"},
+	})
+	// Someone's project took the place of the approved Go root
+	if err := os.RemoveAll(goRoot); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(goRoot, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{BackupPolicy: "never"}
+
+	err := applyPlan(eventWriter{w: io.Discard}, sp, "build-07", cfg, fugoPaths{Backups: t.TempDir()}, nil, newChangeReport(false, false))
+	if err == nil || !strings.Contains(err.Error(), "no longer a Go root") {
+		t.Fatalf("Expected the replaced path to be refused, got %v", err)
+	}
+	for _, path := range []string{goRoot, other} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected the refused plan to leave %s alone: %v", path, err)
+		}
+	}
+}
+
+func TestApplyPlanRefusesChangedVersion(t *testing.T) {
+	skipInAuditBuild(t)
+	goRoot := fakeGoRoot(t, "VERSION", "bin/go")
+	sp := approvedPlan(t, "build-07", []GoInstallation{{Path: goRoot, Version: "go version go1.22.5", Source: "sdk"}})
+	orig := redetectInstallations
+	defer func() { redetectInstallations = orig }()
+	redetectInstallations = func(Config) []GoInstallation {
+		return []GoInstallation{{Path: goRoot, Version: "go version go1.23.0", Source: "sdk"}}
+	}
+	cfg := Config{BackupPolicy: "never"}
+
+	err := applyPlan(eventWriter{w: io.Discard}, sp, "build-07", cfg, fugoPaths{Backups: t.TempDir()}, nil, newChangeReport(false, false))
+	if err == nil || !strings.Contains(err.Error(), `the plan approved "go version go1.22.5"`) {
+		t.Fatalf("Expected the changed version to be refused, got %v", err)
+	}
+	if _, err := os.Stat(goRoot); err != nil {
+		t.Errorf("Expected %s to survive: %v", goRoot, err)
+	}
+	redetectInstallations = func(Config) []GoInstallation {
+		return []GoInstallation{{Path: goRoot, Version: "go version go1.22.5", Source: "gvm"}}
+	}
+	if err := applyPlan(eventWriter{w: io.Discard}, sp, "build-07", cfg, fugoPaths{Backups: t.TempDir()}, nil, newChangeReport(false, false)); err == nil || !strings.Contains(err.Error(), "now comes from gvm") {
+		t.Errorf("Expected a changed source to be refused, got %v", err)
+	}
+}
+
+func TestMatchSignedEntryRefusesCriticalPaths(t *testing.T) {
+	if _, err := matchSignedEntry(GoInstallation{Path: criticalPaths[0], Source: "manual"}, nil); err == nil || !strings.Contains(err.Error(), "critical") {
+		t.Errorf("Expected a critical path to be refused, got %v", err)
+	}
+}
+
+func TestPlanInstallations(t *testing.T) {
+	installations := []GoInstallation{
+		{Path: "/usr/local/go", Verified: true},
+		{Path: "/opt/odd-go", Verified: false},
+		{Path: "/snap/go/1", Verified: true, Blocked: "read-only mount"},
+	}
+	chosen, _ := planInstallations(installations, nil)
+	if len(chosen) != 1 || chosen[0].Path != "/usr/local/go" {
+		t.Errorf("Expected only the verified, unblocked installation, got %+v", chosen)
+	}
+	if chosen, err := planInstallations(installations, []string{"/opt/odd-go"}); err != nil || len(chosen) != 1 {
+		t.Errorf("Expected an explicit path to be planned, got %+v, %v", chosen, err)
+	}
+	if _, err := planInstallations(installations, []string{"/snap/go/1"}); err == nil {
+		t.Errorf("Expected a blocked path to be refused")
+	}
+	if _, err := planInstallations(installations, []string{"/nowhere"}); err == nil {
+		t.Errorf("Expected an unknown path to be refused")
+	}
+}
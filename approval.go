@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Fleet removals can require two people: one generates and signs a plan, a
+// second reviews and countersigns it with a different key, and only then
+// will `fu-go apply` execute it. Trusted keys come from the machine policy
+// alone, so a user cannot approve their own plan by trusting a second key of
+// their own.
+
+// approvalKey is a signing identity, kept in a file readable only by its
+// owner.
+type approvalKey struct {
+	Name string `json:"name"`
+	Seed string `json:"seed"` // base64 Ed25519 seed
+}
+
+func generateApprovalKey(name string) (approvalKey, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return approvalKey{}, fmt.Errorf("failed to generate key: %v", err)
+	}
+	return approvalKey{Name: name, Seed: base64.StdEncoding.EncodeToString(private.Seed())}, nil
+}
+
+func (k approvalKey) privateKey() (ed25519.PrivateKey, error) {
+	seed, err := base64.StdEncoding.DecodeString(k.Seed)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid key for %s", k.Name)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// publicKey is the base64 public key that goes into approval_keys.
+func (k approvalKey) publicKey() (string, error) {
+	private, err := k.privateKey()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(private.Public().(ed25519.PublicKey)), nil
+}
+
+func saveApprovalKey(path string, key approvalKey) error {
+	data, err := json.MarshalIndent(key, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode key: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create key directory: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create key %s: %v", path, err)
+	}
+	_, err = file.Write(append(data, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write key %s: %v", path, err)
+	}
+	return nil
+}
+
+func loadApprovalKey(path string) (approvalKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return approvalKey{}, fmt.Errorf("failed to read key: %v", err)
+	}
+	var key approvalKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return approvalKey{}, fmt.Errorf("failed to parse key %s: %v", path, err)
+	}
+	if key.Name == "" {
+		return approvalKey{}, fmt.Errorf("key %s has no name", path)
+	}
+	if _, err := key.privateKey(); err != nil {
+		return approvalKey{}, err
+	}
+	return key, nil
+}
+
+// planEntry is one installation a plan removes.
+type planEntry struct {
+	Path           string `json:"path"`
+	Version        string `json:"version"`
+	Source         string `json:"source"`
+	Size           int64  `json:"size"`
+	PackageManager string `json:"package_manager,omitempty"`
+	Package        string `json:"package,omitempty"`
+}
+
+// removalPlan is what the signatures cover.
+type removalPlan struct {
+	Host      string      `json:"host"`
+	CreatedAt time.Time   `json:"created_at"`
+	Entries   []planEntry `json:"entries"`
+}
+
+func newRemovalPlan(host string, installations []GoInstallation) removalPlan {
+	plan := removalPlan{Host: host, CreatedAt: time.Now().UTC()}
+	installations = slices.Clone(installations)
+	sortInventory(installations)
+	for _, install := range installations {
+		plan.Entries = append(plan.Entries, planEntry{
+			Path:           install.Path,
+			Version:        install.Version,
+			Source:         install.Source,
+			Size:           install.Size,
+			PackageManager: install.PackageManager,
+			Package:        install.Package,
+		})
+	}
+	return plan
+}
+
+// installations turns the entries back into installations for removal.
+func (p removalPlan) installations() []GoInstallation {
+	var installations []GoInstallation
+	for _, entry := range p.Entries {
+		installations = append(installations, GoInstallation{
+			Path:           entry.Path,
+			Version:        entry.Version,
+			Source:         entry.Source,
+			Size:           entry.Size,
+			PackageManager: entry.PackageManager,
+			Package:        entry.Package,
+		})
+	}
+	return installations
+}
+
+type planSignature struct {
+	Signer    string `json:"signer"`
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// signedPlan keeps the plan as the exact bytes that were signed.
+type signedPlan struct {
+	Plan       json.RawMessage `json:"plan"`
+	Signatures []planSignature `json:"signatures"`
+}
+
+func newSignedPlan(plan removalPlan) (signedPlan, error) {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return signedPlan{}, fmt.Errorf("failed to encode plan: %v", err)
+	}
+	return signedPlan{Plan: data}, nil
+}
+
+func (sp signedPlan) plan() (removalPlan, error) {
+	var plan removalPlan
+	if err := json.Unmarshal(sp.Plan, &plan); err != nil {
+		return removalPlan{}, fmt.Errorf("failed to parse plan: %v", err)
+	}
+	return plan, nil
+}
+
+// digest identifies the plan in logs.
+func (sp signedPlan) digest() string {
+	sum := sha256.Sum256(sp.Plan)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// sign adds key's signature. Nobody signs twice, under either their name or
+// their key.
+func (sp *signedPlan) sign(key approvalKey) error {
+	private, err := key.privateKey()
+	if err != nil {
+		return err
+	}
+	public, _ := key.publicKey()
+	for _, existing := range sp.Signatures {
+		if existing.Signer == key.Name || existing.PublicKey == public {
+			return fmt.Errorf("%s has already signed this plan; a second person must approve it", existing.Signer)
+		}
+	}
+	sp.Signatures = append(sp.Signatures, planSignature{
+		Signer:    key.Name,
+		PublicKey: public,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(private, sp.Plan)),
+	})
+	return nil
+}
+
+// verify checks every signature against the trusted keys (name to base64
+// public key) and returns the signers in order. A plan needs signatures from
+// two different trusted people.
+func (sp signedPlan) verify(trusted map[string]string) ([]string, error) {
+	var signers []string
+	seen := make(map[string]bool)
+	for _, sig := range sp.Signatures {
+		public, ok := trusted[sig.Signer]
+		if !ok || public != sig.PublicKey {
+			return nil, fmt.Errorf("%s is not a trusted approver in the machine policy", sig.Signer)
+		}
+		key, err := base64.StdEncoding.DecodeString(public)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid approval key for %s in the machine policy", sig.Signer)
+		}
+		signature, err := base64.StdEncoding.DecodeString(sig.Signature)
+		if err != nil || !ed25519.Verify(ed25519.PublicKey(key), sp.Plan, signature) {
+			return nil, fmt.Errorf("signature by %s does not match the plan", sig.Signer)
+		}
+		if seen[public] {
+			continue
+		}
+		seen[public] = true
+		signers = append(signers, sig.Signer)
+	}
+	if len(signers) < 2 {
+		return signers, fmt.Errorf("plan has %d of the 2 required signatures", len(signers))
+	}
+	return signers, nil
+}
+
+func saveSignedPlan(path string, sp signedPlan) error {
+	// Escaping & < > would change the signed bytes of paths containing them
+	var data bytes.Buffer
+	enc := json.NewEncoder(&data)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(sp); err != nil {
+		return fmt.Errorf("failed to encode plan: %v", err)
+	}
+	if err := os.WriteFile(path, data.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write plan %s: %v", path, err)
+	}
+	return nil
+}
+
+func loadSignedPlan(path string) (signedPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return signedPlan{}, fmt.Errorf("failed to read plan: %v", err)
+	}
+	sp, err := parseSignedPlan(data)
+	if err != nil {
+		return signedPlan{}, fmt.Errorf("failed to parse plan %s: %v", path, err)
+	}
+	return sp, nil
+}
+
+// parseSignedPlan reads a plan file's contents.
+func parseSignedPlan(data []byte) (signedPlan, error) {
+	var sp signedPlan
+	if err := json.Unmarshal(data, &sp); err != nil {
+		return signedPlan{}, err
+	}
+	if len(sp.Plan) == 0 {
+		return signedPlan{}, fmt.Errorf("no plan in the file")
+	}
+	// Saving indents the plan; signatures cover its compact form
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, sp.Plan); err != nil {
+		return signedPlan{}, err
+	}
+	sp.Plan = compact.Bytes()
+	return sp, nil
+}
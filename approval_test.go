@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// approvers creates signing keys and trusts them in the machine policy.
+func approvers(t *testing.T, names ...string) []approvalKey {
+	t.Helper()
+	var keys []approvalKey
+	trusted := make(map[string]string)
+	for _, name := range names {
+		key, err := generateApprovalKey(name)
+		if err != nil {
+			t.Fatalf("generateApprovalKey returned error: %v", err)
+		}
+		trusted[name], _ = key.publicKey()
+		keys = append(keys, key)
+	}
+	withPolicy(t, Policy{ApprovalKeys: trusted})
+	return keys
+}
+
+func TestSignedPlanRoundTrip(t *testing.T) {
+	keys := approvers(t, "alice", "bob")
+	sp, err := newSignedPlan(newRemovalPlan("build-07", []GoInstallation{{Path: "/usr/local/go", Version: "go1.21.0", Source: "official"}}))
+	if err != nil {
+		t.Fatalf("newSignedPlan returned error: %v", err)
+	}
+	if err := sp.sign(keys[0]); err != nil {
+		t.Fatalf("sign returned error: %v", err)
+	}
+	if err := sp.sign(keys[0]); err == nil {
+		t.Errorf("Expected the author to be refused as their own approver")
+	}
+	if _, err := sp.verify(currentPolicy().ApprovalKeys); err == nil || !strings.Contains(err.Error(), "1 of the 2") {
+		t.Errorf("Expected one signature to be insufficient, got %v", err)
+	}
+
+	// Signatures survive saving and loading the indented file
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := saveSignedPlan(path, sp); err != nil {
+		t.Fatalf("saveSignedPlan returned error: %v", err)
+	}
+	loaded, err := loadSignedPlan(path)
+	if err != nil {
+		t.Fatalf("loadSignedPlan returned error: %v", err)
+	}
+	if err := loaded.sign(keys[1]); err != nil {
+		t.Fatalf("sign returned error: %v", err)
+	}
+	signers, err := loaded.verify(currentPolicy().ApprovalKeys)
+	if err != nil || strings.Join(signers, ",") != "alice,bob" {
+		t.Errorf("Expected alice and bob, got %v, %v", signers, err)
+	}
+}
+
+func TestSignedPlanRejectsTampering(t *testing.T) {
+	keys := approvers(t, "alice", "bob")
+	sp, _ := newSignedPlan(newRemovalPlan("build-07", []GoInstallation{{Path: "/usr/local/go"}}))
+	sp.sign(keys[0])
+	sp.sign(keys[1])
+
+	tampered := sp
+	tampered.Plan = []byte(strings.Replace(string(sp.Plan), "/usr/local/go", "/home/alice", 1))
+	if _, err := tampered.verify(currentPolicy().ApprovalKeys); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("Expected a tampered plan to fail, got %v", err)
+	}
+
+	// A key the policy does not list cannot approve, even under a trusted name
+	mallory, _ := generateApprovalKey("bob")
+	forged, _ := newSignedPlan(newRemovalPlan("build-07", []GoInstallation{{Path: "/usr/local/go"}}))
+	forged.sign(keys[0])
+	forged.sign(mallory)
+	if _, err := forged.verify(currentPolicy().ApprovalKeys); err == nil || !strings.Contains(err.Error(), "not a trusted approver") {
+		t.Errorf("Expected an untrusted key to fail, got %v", err)
+	}
+}
+
+func TestApprovalKeyFile(t *testing.T) {
+	key, _ := generateApprovalKey("alice")
+	path := filepath.Join(t.TempDir(), "keys", "alice.key")
+	if err := saveApprovalKey(path, key); err != nil {
+		t.Fatalf("saveApprovalKey returned error: %v", err)
+	}
+	if err := saveApprovalKey(path, key); err == nil {
+		t.Errorf("Expected an existing key not to be overwritten")
+	}
+	loaded, err := loadApprovalKey(path)
+	if err != nil || loaded != key {
+		t.Errorf("Expected %+v, got %+v, %v", key, loaded, err)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.key")
+	os.WriteFile(bad, []byte(`{"name": "eve", "seed": "`+base64.StdEncoding.EncodeToString([]byte("short"))+`"}`), 0600)
+	if _, err := loadApprovalKey(bad); err == nil {
+		t.Errorf("Expected a malformed key to fail")
+	}
+}
+
+// FuzzParseSignedPlan feeds arbitrary plan files to the parser. Whatever it
+// accepts must survive saving and loading again unchanged, since signatures
+// cover the exact plan bytes.
+func FuzzParseSignedPlan(f *testing.F) {
+	sp, _ := newSignedPlan(newRemovalPlan("build-01", []GoInstallation{{Path: "/usr/local/go", Version: "go1.22.5", Source: "official", Size: 1}}))
+	sp.Signatures = []planSignature{{Signer: "alice", PublicKey: "a2V5", Signature: "c2ln"}}
+	seed, _ := json.MarshalIndent(sp, "", "  ")
+	f.Add(seed)
+	f.Add([]byte(`{"plan": {"entries": [{"path": "/"}]}, "signatures": []}`))
+	f.Add([]byte(`{"plan": null}`))
+	f.Add([]byte(`{"plan": "\u0000", "signatures": [{}]}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		sp, err := parseSignedPlan(data)
+		if err != nil {
+			return
+		}
+		if plan, err := sp.plan(); err == nil {
+			plan.installations()
+		}
+		sp.verify(map[string]string{"alice": "a2V5"})
+		path := filepath.Join(t.TempDir(), "plan.json")
+		if err := saveSignedPlan(path, sp); err != nil {
+			t.Fatalf("saveSignedPlan failed on a parsed plan: %v", err)
+		}
+		again, err := loadSignedPlan(path)
+		if err != nil {
+			t.Fatalf("A saved plan does not load again: %v", err)
+		}
+		if !bytes.Equal(again.Plan, sp.Plan) || len(again.Signatures) != len(sp.Signatures) {
+			t.Errorf("Plan changed across save and load: %q became %q", sp.Plan, again.Plan)
+		}
+	})
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Go distribution archives are downloaded from go.dev, checked against the
+// published SHA-256 and kept in the cache directory, so reinstalling the same
+// version on a reimaged build agent does not fetch hundreds of megabytes
+// again. Entries live at <cache>/downloads/<version>/<goos>-<goarch>/<sha256>/.
+
+var (
+	goReleaseIndexURL = "https://go.dev/dl/?mode=json&include=all"
+	goDownloadBaseURL = "https://go.dev/dl/"
+)
+
+type goRelease struct {
+	Version string          `json:"version"`
+	Files   []goReleaseFile `json:"files"`
+}
+
+type goReleaseFile struct {
+	Filename string `json:"filename"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	SHA256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+	Kind     string `json:"kind"` // "archive", "installer" or "source"
+}
+
+// goArtifact is the archive of one Go version for one platform.
+type goArtifact struct {
+	Version  string // "go1.22.5"
+	GOOS     string
+	GOARCH   string
+	Filename string
+	SHA256   string
+	Size     int64
+}
+
+// normalizeGoVersion accepts "1.22.5" or "go1.22.5".
+func normalizeGoVersion(version string) string {
+	if strings.HasPrefix(version, "go") {
+		return version
+	}
+	return "go" + version
+}
+
+// findArtifact picks the archive for version and platform from the release
+// index.
+func findArtifact(releases []goRelease, version, goos, goarch string) (goArtifact, error) {
+	version = normalizeGoVersion(version)
+	for _, release := range releases {
+		if release.Version != version {
+			continue
+		}
+		for _, file := range release.Files {
+			if file.Kind == "archive" && file.OS == goos && file.Arch == goarch {
+				return goArtifact{
+					Version:  version,
+					GOOS:     goos,
+					GOARCH:   goarch,
+					Filename: file.Filename,
+					SHA256:   file.SHA256,
+					Size:     file.Size,
+				}, nil
+			}
+		}
+		return goArtifact{}, fmt.Errorf("%s has no archive for %s/%s", version, goos, goarch)
+	}
+	return goArtifact{}, fmt.Errorf("unknown Go version %s", version)
+}
+
+// artifactCache stores verified archives under dir.
+type artifactCache struct {
+	dir string
+}
+
+func newArtifactCache(paths fugoPaths) artifactCache {
+	return artifactCache{dir: filepath.Join(paths.Cache, "downloads")}
+}
+
+func (c artifactCache) platformDir(version, goos, goarch string) string {
+	return filepath.Join(c.dir, normalizeGoVersion(version), goos+"-"+goarch)
+}
+
+func (c artifactCache) path(a goArtifact) string {
+	return filepath.Join(c.platformDir(a.Version, a.GOOS, a.GOARCH), a.SHA256, a.Filename)
+}
+
+// fileSHA256 hashes the file at path.
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// find returns a cached archive for version and platform whose contents
+// still match the checksum it was stored under. No network is needed, so
+// cached versions can be reinstalled offline.
+func (c artifactCache) find(version, goos, goarch string) (string, bool) {
+	dir := c.platformDir(version, goos, goarch)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		files, err := os.ReadDir(filepath.Join(dir, entry.Name()))
+		if err != nil || len(files) != 1 {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name(), files[0].Name())
+		if sum, err := fileSHA256(path); err == nil && sum == entry.Name() {
+			return path, true
+		}
+		tracef("cache: %s does not match its checksum, ignoring", path)
+	}
+	return "", false
+}
+
+// store copies r into the cache, keeping it only if its SHA-256 matches.
+func (c artifactCache) store(a goArtifact, r io.Reader) (string, error) {
+	path := c.path(a)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".download-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create cache file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %v", a.Filename, err)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != a.SHA256 {
+		os.Remove(tmp.Name())
+		os.Remove(filepath.Dir(path))
+		return "", fmt.Errorf("checksum mismatch for %s: got %s, expected %s", a.Filename, sum, a.SHA256)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to store %s: %v", a.Filename, err)
+	}
+	return path, nil
+}
+
+// fetchReleaseIndex downloads the list of Go releases and their checksums.
+func fetchReleaseIndex(cfg Config) ([]goRelease, error) {
+	client, err := newHTTPClient(cfg, time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Get(goReleaseIndexURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the Go release list: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("failed to fetch the Go release list: %s", resp.Status)
+	}
+	var releases []goRelease
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("failed to parse the Go release list: %v", err)
+	}
+	return releases, nil
+}
+
+// Where fetchGoArchive found an archive.
+const (
+	archiveFromCache    = "cache"
+	archiveFromDownload = "download"
+	archiveFromLocal    = "local"
+)
+
+// fetchGoArchive returns a verified archive for version and platform: from
+// the cache when possible, otherwise from cfg.ArtifactSource if set, or
+// go.dev.
+func fetchGoArchive(cfg Config, cache artifactCache, version, goos, goarch string) (path, origin string, err error) {
+	if path, ok := cache.find(version, goos, goarch); ok {
+		tracef("cache: reusing %s", path)
+		return path, archiveFromCache, nil
+	}
+	if cfg.ArtifactSource != "" {
+		path, err := localGoArchive(cfg.ArtifactSource, version, goos, goarch)
+		return path, archiveFromLocal, err
+	}
+
+	releases, err := fetchReleaseIndex(cfg)
+	if err != nil {
+		return "", "", err
+	}
+	artifact, err := findArtifact(releases, version, goos, goarch)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Archives are large; the client timeout would cut slow links short
+	client, err := newHTTPClient(cfg, 0)
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := client.Get(goDownloadBaseURL + artifact.Filename)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to download %s: %v", artifact.Filename, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", "", fmt.Errorf("failed to download %s: %s", artifact.Filename, resp.Status)
+	}
+	path, err = cache.store(artifact, resp.Body)
+	return path, archiveFromDownload, err
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeGoDownloads serves a release index and one archive, counting archive
+// downloads.
+func fakeGoDownloads(t *testing.T, archive []byte, published string) *atomic.Int32 {
+	t.Helper()
+	var downloads atomic.Int32
+	releases := []goRelease{{
+		Version: "go1.22.5",
+		Files: []goReleaseFile{
+			{Filename: "go1.22.5.src.tar.gz", Kind: "source", SHA256: "x"},
+			{Filename: "go1.22.5.linux-amd64.tar.gz", OS: "linux", Arch: "amd64", Kind: "archive", SHA256: published, Size: int64(len(archive))},
+		},
+	}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index":
+			json.NewEncoder(w).Encode(releases)
+		case "/dl/go1.22.5.linux-amd64.tar.gz":
+			downloads.Add(1)
+			w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	indexURL, baseURL := goReleaseIndexURL, goDownloadBaseURL
+	goReleaseIndexURL, goDownloadBaseURL = server.URL+"/index", server.URL+"/dl/"
+	t.Cleanup(func() { goReleaseIndexURL, goDownloadBaseURL = indexURL, baseURL })
+	return &downloads
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestFetchGoArchiveCaches(t *testing.T) {
+	archive := []byte("pretend this is a Go tarball")
+	downloads := fakeGoDownloads(t, archive, sha256Hex(archive))
+	cache := artifactCache{dir: t.TempDir()}
+
+	path, origin, err := fetchGoArchive(Config{}, cache, "1.22.5", "linux", "amd64")
+	if err != nil {
+		t.Fatalf("fetchGoArchive returned error: %v", err)
+	}
+	if origin != archiveFromDownload || !strings.HasSuffix(path, "go1.22.5.linux-amd64.tar.gz") {
+		t.Errorf("Expected a fresh download, got %s (from %s)", path, origin)
+	}
+
+	// A second fetch is served from the cache, even offline
+	setOffline(true)
+	defer setOffline(false)
+	again, origin, err := fetchGoArchive(Config{}, cache, "go1.22.5", "linux", "amd64")
+	if err != nil || origin != archiveFromCache || again != path {
+		t.Errorf("Expected the cached %s, got %s (from %s, err %v)", path, again, origin, err)
+	}
+	if n := downloads.Load(); n != 1 {
+		t.Errorf("Expected 1 download, got %d", n)
+	}
+
+	// A corrupted entry is not reused
+	if err := os.WriteFile(path, []byte("tampered"), 0644); err != nil {
+		t.Fatalf("Failed to tamper with cache: %v", err)
+	}
+	if _, ok := cache.find("1.22.5", "linux", "amd64"); ok {
+		t.Errorf("Expected a tampered archive to be ignored")
+	}
+	if _, _, err := fetchGoArchive(Config{}, cache, "1.22.5", "linux", "amd64"); err == nil || !strings.Contains(err.Error(), errOffline.Error()) {
+		t.Errorf("Expected a re-download attempt refused offline, got %v", err)
+	}
+}
+
+func TestFetchGoArchiveChecksumMismatch(t *testing.T) {
+	fakeGoDownloads(t, []byte("archive"), sha256Hex([]byte("something else")))
+	cache := artifactCache{dir: t.TempDir()}
+
+	_, _, err := fetchGoArchive(Config{}, cache, "1.22.5", "linux", "amd64")
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("Expected a checksum mismatch, got %v", err)
+	}
+	if _, ok := cache.find("1.22.5", "linux", "amd64"); ok {
+		t.Errorf("Expected nothing cached after a mismatch")
+	}
+}
+
+func TestFindArtifact(t *testing.T) {
+	releases := []goRelease{{Version: "go1.22.5", Files: []goReleaseFile{{Filename: "go1.22.5.windows-amd64.zip", OS: "windows", Arch: "amd64", Kind: "archive"}}}}
+	tests := []struct {
+		version, goos, errPart string
+	}{
+		{"1.22.5", "windows", ""},
+		{"1.22.5", "linux", "no archive for linux/amd64"},
+		{"1.99.0", "windows", "unknown Go version go1.99.0"},
+	}
+	for _, tt := range tests {
+		_, err := findArtifact(releases, tt.version, tt.goos, "amd64")
+		if (err == nil) != (tt.errPart == "") || (err != nil && !strings.Contains(err.Error(), tt.errPart)) {
+			t.Errorf("findArtifact(%s, %s) error = %v, expected %q", tt.version, tt.goos, err, tt.errPart)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import "os"
+
+// Everything fu-go needs from the operating system it runs on, beyond what
+// the os package offers, goes through platformBackend: how to tell and get
+// elevated privileges, the registry PATH, what the system locks against
+// removal, the kernel's mount table and how executables look. Each system
+// implements it in backend_<goos>.go behind build tags, so shared code calls
+// currentPlatform instead of switching on runtime.GOOS, and supporting
+// another system means adding a file rather than a case to every function.
+//
+// Functions that take goos as a parameter, such as officialGoPaths or
+// platformDirs, are tables about other systems too (for tests, downloads and
+// schedules) and stay where they are.
+
+type platformBackend interface {
+	// isElevated reports whether fu-go runs as root or an administrator.
+	isElevated() bool
+	// elevationTool names what grants more privileges: sudo or UAC.
+	elevationTool() string
+
+	// userPath reads, and setUserPath writes, the per-user PATH kept in
+	// the registry. Systems without one return an error.
+	userPath() (string, error)
+	setUserPath(value string) error
+
+	// protection explains why the system itself guards path against
+	// removal, whatever the privileges, or returns "".
+	protection(path string) string
+	// locksRunningBinary reports whether a running executable cannot be
+	// removed or replaced, so fu-go must move itself aside first.
+	locksRunningBinary() bool
+
+	// mountPoints lists the kernel's mount table, or nil where it cannot
+	// be read. Bind mounts only show up here.
+	mountPoints() []string
+
+	// foldsCase reports whether the default file system ignores case in
+	// names, so that two spellings can name the same critical directory.
+	foldsCase() bool
+
+	// exeSuffix is appended to the names of executables.
+	exeSuffix() string
+	// isExecutable reports whether the file name with mode can be run.
+	isExecutable(name string, mode os.FileMode) bool
+
+	// defaultGoRoot is where a Go root is looked for first on this
+	// system, before the detectors run.
+	defaultGoRoot() (string, error)
+
+	// pathHint tells the user how to put dir on PATH for good.
+	pathHint(dir string) string
+	// selinux reports whether the kernel runs SELinux, so restored files
+	// need their labels.
+	selinux() bool
+	// fileURLPath turns the path of a file:// URL into a local path.
+	fileURLPath(path string) string
+	// runsAsOtherUsers reports whether an elevated fu-go can run a command
+	// as another user, with sudo -u.
+	runsAsOtherUsers() bool
+
+	// managedPolicy reads the machine policy deployed through the system's
+	// management tools, as the policy file settings it holds, and names
+	// where it was found. Systems without such a place return nil.
+	managedPolicy() (map[string]any, string, error)
+}
+
+// currentPlatform is the backend for the running system. Tests may swap it.
+var currentPlatform platformBackend = nativePlatform{}
+
+func isElevated() bool {
+	return currentPlatform.isElevated()
+}
+
+func userPathValue() (string, error) {
+	return currentPlatform.userPath()
+}
+
+func setUserPathValue(value string) error {
+	return currentPlatform.setUserPath(value)
+}
+
+func isExecutable(name string, mode os.FileMode) bool {
+	if !mode.IsRegular() {
+		return false
+	}
+	return currentPlatform.isExecutable(name, mode)
+}
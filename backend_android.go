@@ -0,0 +1,21 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+type nativePlatform struct{ unixBackend }
+
+func (nativePlatform) mountPoints() []string {
+	data, err := os.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		return nil
+	}
+	return parseMountInfo(data)
+}
+
+// Go on Android comes from Termux's own prefix.
+func (nativePlatform) defaultGoRoot() (string, error) {
+	return filepath.Join(termuxPrefix(), "lib", "go"), nil
+}
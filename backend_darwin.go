@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+const sfRestricted = 0x00080000 // SF_RESTRICTED, set on what SIP protects
+
+type nativePlatform struct{ unixBackend }
+
+// protection reports System Integrity Protection, which even root cannot
+// get past: the protected system directories and files flagged restricted.
+func (nativePlatform) protection(path string) string {
+	if isSIPProtectedPath(path) {
+		return "protected by System Integrity Protection"
+	}
+	var st syscall.Stat_t
+	if err := syscall.Lstat(path, &st); err == nil && st.Flags&sfRestricted != 0 {
+		return "protected by System Integrity Protection"
+	}
+	return ""
+}
+
+// APFS and HFS+ are case-insensitive unless formatted otherwise.
+func (nativePlatform) foldsCase() bool { return true }
+
+// defaultGoRoot prefers Homebrew's Cellar on Intel Macs over the official
+// installer's root.
+func (nativePlatform) defaultGoRoot() (string, error) {
+	if _, err := os.Stat("/usr/local/Cellar/go"); err == nil {
+		return "/usr/local/Cellar/go", nil
+	}
+	return "/usr/local/go", nil
+}
@@ -0,0 +1,54 @@
+//go:build linux && !android
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type nativePlatform struct{ unixBackend }
+
+func (nativePlatform) selinux() bool {
+	_, err := os.Stat(filepath.Join(selinuxDir, "enforce"))
+	return err == nil
+}
+
+func (nativePlatform) mountPoints() []string {
+	data, err := os.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		return nil
+	}
+	return parseMountInfo(data)
+}
+
+// defaultGoRoot follows the go on PATH when a distribution package put one
+// in /usr/bin, since that root is usually not /usr/local/go.
+func (nativePlatform) defaultGoRoot() (string, error) {
+	goPath := "/usr/local/go"
+	if _, err := os.Stat("/usr/bin/go"); err != nil {
+		return goPath, nil
+	}
+	output, err := commandOutput("which", "go")
+	if err != nil {
+		return goPath, nil
+	}
+	whichPath := strings.TrimSpace(string(output))
+	// Alpine and distro packages link /usr/bin/go into /usr/lib/go
+	if resolved, err := filepath.EvalSymlinks(whichPath); err == nil {
+		whichPath = resolved
+	}
+	if !strings.HasSuffix(whichPath, "/bin/go") {
+		return goPath, nil
+	}
+	derivedPath := strings.TrimSuffix(whichPath, "/bin/go")
+	if isCriticalPath(derivedPath) {
+		return "", fmt.Errorf("refusing to operate on critical system directory: %s", derivedPath)
+	}
+	if !strings.Contains(strings.ToLower(derivedPath), "go") {
+		return "", fmt.Errorf("derived path does not appear to be a Go installation: %s", derivedPath)
+	}
+	return derivedPath, nil
+}
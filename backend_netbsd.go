@@ -0,0 +1,13 @@
+package main
+
+import "os"
+
+type nativePlatform struct{ unixBackend }
+
+// pkgsrc installs Go under /usr/pkg.
+func (nativePlatform) defaultGoRoot() (string, error) {
+	if _, err := os.Stat("/usr/pkg/go"); err == nil {
+		return "/usr/pkg/go", nil
+	}
+	return "/usr/local/go", nil
+}
@@ -0,0 +1,43 @@
+//go:build !unix && !windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const userPathName = "user PATH"
+
+func (nativePlatform) pathHint(dir string) string {
+	return fmt.Sprintf("Add %s to PATH in your shell profile: export PATH=\"%s:$PATH\"", dir, dir)
+}
+
+// nativePlatform on Plan 9, js and wasip1 claims nothing: no privileges,
+// registry or mount table to consult.
+type nativePlatform struct{}
+
+func (nativePlatform) isElevated() bool      { return false }
+func (nativePlatform) elevationTool() string { return "" }
+
+func (nativePlatform) userPath() (string, error) {
+	return "", errors.New("no registry PATH on this platform")
+}
+
+func (nativePlatform) setUserPath(value string) error {
+	return errors.New("no registry PATH on this platform")
+}
+
+func (nativePlatform) protection(path string) string                   { return "" }
+func (nativePlatform) locksRunningBinary() bool                        { return false }
+func (nativePlatform) mountPoints() []string                           { return nil }
+func (nativePlatform) foldsCase() bool                                 { return false }
+func (nativePlatform) exeSuffix() string                               { return "" }
+func (nativePlatform) isExecutable(name string, mode os.FileMode) bool { return mode.Perm()&0111 != 0 }
+func (nativePlatform) defaultGoRoot() (string, error)                  { return "/usr/local/go", nil }
+func (nativePlatform) selinux() bool                                   { return false }
+func (nativePlatform) fileURLPath(path string) string                  { return filepath.FromSlash(path) }
+func (nativePlatform) runsAsOtherUsers() bool                          { return false }
+func (nativePlatform) managedPolicy() (map[string]any, string, error)  { return nil, "", nil }
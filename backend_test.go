@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakePlatform overrides parts of the running system's backend.
+type fakePlatform struct {
+	platformBackend
+	locks     bool
+	mounts    []string
+	protected map[string]string
+	policy    map[string]any
+}
+
+func (f fakePlatform) locksRunningBinary() bool { return f.locks }
+func (f fakePlatform) mountPoints() []string    { return f.mounts }
+func (f fakePlatform) protection(path string) string {
+	return f.protected[path]
+}
+func (f fakePlatform) managedPolicy() (map[string]any, string, error) {
+	return f.policy, `HKLM\SOFTWARE\Policies\fugo`, nil
+}
+
+func withPlatform(t *testing.T, backend platformBackend) {
+	t.Helper()
+	saved := currentPlatform
+	currentPlatform = backend
+	t.Cleanup(func() { currentPlatform = saved })
+}
+
+func TestPlatformBackend(t *testing.T) {
+	m := newListTestModel()
+	m.selfExe = "/usr/local/go/bin/fu-go"
+
+	withPlatform(t, fakePlatform{platformBackend: nativePlatform{}, locks: true})
+	if warning := m.selfRemovalWarning(); !strings.Contains(warning, "moved to the temp directory") {
+		t.Errorf("Expected a locked binary to be moved aside, got %q", warning)
+	}
+	withPlatform(t, fakePlatform{platformBackend: nativePlatform{}})
+	if warning := m.selfRemovalWarning(); !strings.Contains(warning, "removed last") {
+		t.Errorf("Expected an unlocked binary to be removed last, got %q", warning)
+	}
+
+	root := t.TempDir()
+	bind := filepath.Join(root, "pkg", "mod")
+	os.MkdirAll(bind, 0755)
+	withPlatform(t, fakePlatform{platformBackend: nativePlatform{}, mounts: []string{"/", bind, "/elsewhere"}})
+	boundaries, err := findMountBoundaries(root)
+	if err != nil {
+		t.Fatalf("findMountBoundaries returned error: %v", err)
+	}
+	if len(boundaries) != 1 || boundaries[0] != bind {
+		t.Errorf("Expected the kernel's mount under the root, got %v", boundaries)
+	}
+}
+
+func TestExecutableNaming(t *testing.T) {
+	goExec := goExecutable("/usr/local/go")
+	if !strings.HasSuffix(goExec, "go"+currentPlatform.exeSuffix()) {
+		t.Errorf("Expected the go binary to carry the platform suffix, got %s", goExec)
+	}
+	if isExecutable("dir", os.ModeDir|0755) {
+		t.Errorf("Expected a directory never to be executable")
+	}
+}
+
+func TestRemovalBlockerProtection(t *testing.T) {
+	root := t.TempDir()
+	locked := filepath.Join(root, "lib")
+	os.MkdirAll(locked, 0755)
+	withPlatform(t, fakePlatform{platformBackend: nativePlatform{}, protected: map[string]string{locked: "guarded by the system"}})
+	if runtime.GOOS == "windows" {
+		t.Skip("removal blockers are not checked on Windows")
+	}
+	if got := removalBlocker(root); got != locked+" is guarded by the system" {
+		t.Errorf("Expected the protected directory to block removal, got %q", got)
+	}
+}
@@ -0,0 +1,57 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const userPathName = "user PATH"
+
+// unixBackend is what Unix systems have in common; each system's
+// nativePlatform embeds it and overrides what differs.
+type unixBackend struct{}
+
+func (unixBackend) isElevated() bool      { return os.Geteuid() == 0 }
+func (unixBackend) elevationTool() string { return "sudo" }
+
+// The user PATH only lives in the registry on Windows; elsewhere it comes
+// from the shell profiles fu-go edits directly.
+func (unixBackend) userPath() (string, error) {
+	return "", errors.New("no registry PATH on this platform")
+}
+
+func (unixBackend) setUserPath(value string) error {
+	return errors.New("no registry PATH on this platform")
+}
+
+func (unixBackend) protection(path string) string { return "" }
+
+// Unix keeps a running binary's inode alive after it is unlinked.
+func (unixBackend) locksRunningBinary() bool { return false }
+
+func (unixBackend) mountPoints() []string { return nil }
+
+func (unixBackend) foldsCase() bool { return false }
+
+func (unixBackend) exeSuffix() string { return "" }
+
+func (unixBackend) isExecutable(name string, mode os.FileMode) bool {
+	return mode.Perm()&0111 != 0
+}
+
+func (unixBackend) defaultGoRoot() (string, error) { return "/usr/local/go", nil }
+
+func (unixBackend) pathHint(dir string) string {
+	return fmt.Sprintf("Add %s to PATH in your shell profile: export PATH=\"%s:$PATH\"", dir, dir)
+}
+
+func (unixBackend) selinux() bool                  { return false }
+func (unixBackend) fileURLPath(path string) string { return filepath.FromSlash(path) }
+func (unixBackend) runsAsOtherUsers() bool         { return true }
+
+// Unix machine policy is only ever the file in /etc/fugo.
+func (unixBackend) managedPolicy() (map[string]any, string, error) { return nil, "", nil }
@@ -0,0 +1,7 @@
+//go:build unix && !(linux || android || darwin || netbsd)
+
+package main
+
+// FreeBSD, OpenBSD, DragonFly, Solaris and the other Unix systems need
+// nothing beyond the common behaviour.
+type nativePlatform struct{ unixBackend }
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const userPathName = `HKCU\Environment\Path`
+
+type nativePlatform struct{}
+
+func (nativePlatform) isElevated() bool {
+	// Only administrators can open the raw disk device
+	f, err := os.Open(`\\.\PHYSICALDRIVE0`)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
+
+func (nativePlatform) elevationTool() string { return "UAC" }
+
+// userPath reads the per-user PATH the Go MSI and most installers add to.
+func (nativePlatform) userPath() (string, error) {
+	key, err := registry.OpenKey(registry.CURRENT_USER, "Environment", registry.QUERY_VALUE)
+	if err != nil {
+		return "", err
+	}
+	defer key.Close()
+	value, _, err := key.GetStringValue("Path")
+	return value, err
+}
+
+// setUserPath stores value as REG_EXPAND_SZ so %USERPROFILE%-style entries
+// keep expanding. New shells pick it up; open ones keep the old PATH.
+func (nativePlatform) setUserPath(value string) error {
+	key, err := registry.OpenKey(registry.CURRENT_USER, "Environment", registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+	return key.SetExpandStringValue("Path", value)
+}
+
+func (nativePlatform) protection(path string) string { return "" }
+
+// Windows refuses to delete or overwrite an executable that is running.
+func (nativePlatform) locksRunningBinary() bool { return true }
+
+func (nativePlatform) mountPoints() []string { return nil }
+
+func (nativePlatform) foldsCase() bool { return true }
+
+func (nativePlatform) exeSuffix() string { return ".exe" }
+
+func (nativePlatform) isExecutable(name string, mode os.FileMode) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".exe", ".bat", ".cmd":
+		return true
+	}
+	return false
+}
+
+func (nativePlatform) pathHint(dir string) string {
+	return fmt.Sprintf("Add %s to your user PATH in System Properties > Environment Variables", dir)
+}
+
+func (nativePlatform) selinux() bool { return false }
+
+// fileURLPath drops the slash before the drive: file:///C:/mirror parses to
+// /C:/mirror.
+func (nativePlatform) fileURLPath(path string) string {
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
+
+// There is no sudo -u; an administrator removes other users' files itself.
+func (nativePlatform) runsAsOtherUsers() bool { return false }
+
+// policyKey is where Group Policy puts fu-go's machine policy.
+const policyKey = `SOFTWARE\Policies\fugo`
+
+// managedPolicy reads HKLM\SOFTWARE\Policies\fugo. Each value is named after
+// a policy file setting: REG_DWORD for the switches, REG_MULTI_SZ for the
+// lists, and REG_SZ for the rest, holding JSON when the setting is an object.
+func (nativePlatform) managedPolicy() (map[string]any, string, error) {
+	source := `HKLM\` + policyKey
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, policyKey, registry.QUERY_VALUE)
+	if err == registry.ErrNotExist {
+		return nil, source, nil
+	}
+	if err != nil {
+		return nil, source, err
+	}
+	defer key.Close()
+	names, err := key.ReadValueNames(0)
+	if err != nil {
+		return nil, source, err
+	}
+	values := make(map[string]any, len(names))
+	for _, name := range names {
+		_, kind, err := key.GetValue(name, nil)
+		if err != nil && err != registry.ErrShortBuffer {
+			return nil, source, err
+		}
+		switch kind {
+		case registry.DWORD, registry.QWORD:
+			n, _, err := key.GetIntegerValue(name)
+			if err != nil {
+				return nil, source, err
+			}
+			values[name] = n != 0
+		case registry.MULTI_SZ:
+			list, _, err := key.GetStringsValue(name)
+			if err != nil {
+				return nil, source, err
+			}
+			values[name] = list
+		case registry.SZ, registry.EXPAND_SZ:
+			s, _, err := key.GetStringValue(name)
+			if err != nil {
+				return nil, source, err
+			}
+			if trimmed := strings.TrimSpace(s); strings.HasPrefix(trimmed, "{") {
+				values[name] = json.RawMessage(trimmed)
+			} else {
+				values[name] = s
+			}
+		default:
+			return nil, source, fmt.Errorf("%s has an unsupported type", name)
+		}
+	}
+	return values, source, nil
+}
+
+// defaultGoRoot is the MSI's per-user root, or its per-machine one.
+func (nativePlatform) defaultGoRoot() (string, error) {
+	goPath := filepath.Join(os.Getenv("USERPROFILE"), "go")
+	if _, err := os.Stat(goPath); os.IsNotExist(err) {
+		goPath = filepath.Join(os.Getenv("ProgramFiles"), "Go")
+	}
+	return goPath, nil
+}
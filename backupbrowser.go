@@ -0,0 +1,522 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+// fu-go backups opens a browser over the backup sets: pick an installation
+// and its file tree is read from the archive's tar headers (or the dedup
+// manifest) without extracting anything, so a user can check that a file is
+// there before deciding to restore. Only the archive that is opened is
+// read, and each only once.
+
+// listedFile is one file of a backed-up installation.
+type listedFile struct {
+	path    string // slash-separated, relative to the installation
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+	link    string
+}
+
+func (f listedFile) name() string {
+	return path.Base(f.path)
+}
+
+// backupListing is the file tree of a backed-up installation.
+type backupListing struct {
+	children map[string][]listedFile // by parent directory, "." for the top; directories first
+	files    int
+	bytes    int64
+}
+
+func newBackupListing(files []listedFile) backupListing {
+	l := backupListing{children: make(map[string][]listedFile)}
+	seen := map[string]bool{".": true}
+	var add func(f listedFile)
+	add = func(f listedFile) {
+		if seen[f.path] {
+			return
+		}
+		seen[f.path] = true
+		parent := path.Dir(f.path)
+		// Archives may leave out the entries of directories
+		if !seen[parent] {
+			add(listedFile{path: parent, mode: fs.ModeDir | 0755})
+		}
+		l.children[parent] = append(l.children[parent], f)
+	}
+	for _, f := range files {
+		if f.path == "." || f.path == "" {
+			continue
+		}
+		add(f)
+		if !f.mode.IsDir() {
+			l.files++
+			l.bytes += f.size
+		}
+	}
+	for _, children := range l.children {
+		sort.Slice(children, func(i, j int) bool {
+			if children[i].mode.IsDir() != children[j].mode.IsDir() {
+				return children[i].mode.IsDir()
+			}
+			return children[i].path < children[j].path
+		})
+	}
+	return l
+}
+
+// listArchive reads the file tree of a tar.gz backup from its headers.
+func listArchive(archive string) (backupListing, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return backupListing{}, fmt.Errorf("failed to open %s: %v", archive, err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return backupListing{}, fmt.Errorf("failed to read %s: %v", archive, err)
+	}
+	reader := tar.NewReader(zr)
+	var files []listedFile
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return backupListing{}, fmt.Errorf("failed to read %s: %v", archive, err)
+		}
+		// Names start with the installation's directory: go/bin/go
+		name := strings.TrimPrefix(path.Clean(header.Name), "./")
+		_, rel, _ := strings.Cut(name, "/")
+		if rel == "" {
+			continue
+		}
+		files = append(files, listedFile{path: rel, size: header.Size, mode: header.FileInfo().Mode(), modTime: header.ModTime, link: header.Linkname})
+	}
+	return newBackupListing(files), nil
+}
+
+// listManifest reads the file tree of a dedup or quarantine backup from its
+// manifest.
+func listManifest(manifestPath string) (backupListing, error) {
+	manifest, err := readTreeManifest(manifestPath)
+	if err != nil {
+		return backupListing{}, err
+	}
+	files := make([]listedFile, 0, len(manifest.Files))
+	for _, file := range manifest.Files {
+		files = append(files, listedFile{path: file.Path, size: file.Size, mode: file.Mode, modTime: file.ModTime, link: file.Link})
+	}
+	return newBackupListing(files), nil
+}
+
+func listBackupEntry(set backupSet, entry backupEntry) (backupListing, error) {
+	archive := filepath.Join(set.dir, entry.Archive)
+	if entry.Format == backupFormatDedup || entry.Format == backupFormatQuarantine {
+		return listManifest(archive)
+	}
+	return listArchive(archive)
+}
+
+// browsedEntry is an installation in one of the sets.
+type browsedEntry struct {
+	set   backupSet
+	entry backupEntry
+}
+
+func (e browsedEntry) key() string {
+	return filepath.Join(e.set.dir, e.entry.Archive)
+}
+
+// backupListed carries a listing read in the background into Update.
+type backupListed struct {
+	key     string
+	listing backupListing
+	err     error
+}
+
+// treeRow is a visible line of the file tree.
+type treeRow struct {
+	file  listedFile
+	depth int
+}
+
+// backupBrowser lists the installations of the backup sets and browses the
+// file tree of the one opened. ↑/↓ move, enter or → opens, ← closes, /
+// searches the whole tree, esc goes back and q quits.
+type backupBrowser struct {
+	entries  []browsedEntry
+	cursor   int
+	listings map[string]backupListing
+
+	opened    *browsedEntry
+	loading   bool
+	err       error
+	expanded  map[string]bool
+	rows      []treeRow
+	row       int
+	searching bool
+	query     string
+
+	height int
+}
+
+func newBackupBrowser(sets []backupSet) backupBrowser {
+	b := backupBrowser{listings: make(map[string]backupListing), height: 24}
+	// Newest set first
+	for i := len(sets) - 1; i >= 0; i-- {
+		for _, entry := range sets[i].index.Entries {
+			b.entries = append(b.entries, browsedEntry{set: sets[i], entry: entry})
+		}
+	}
+	return b
+}
+
+func (b backupBrowser) Init() tea.Cmd {
+	return nil
+}
+
+// open shows the tree of the entry under the cursor, reading it first the
+// first time.
+func (b backupBrowser) open() (backupBrowser, tea.Cmd) {
+	if len(b.entries) == 0 {
+		return b, nil
+	}
+	entry := b.entries[b.cursor]
+	b.opened, b.err = &entry, nil
+	b.expanded, b.row, b.query, b.searching = map[string]bool{}, 0, "", false
+	if _, ok := b.listings[entry.key()]; ok {
+		b.rows = b.visibleRows()
+		return b, nil
+	}
+	b.loading, b.rows = true, nil
+	return b, func() tea.Msg {
+		listing, err := listBackupEntry(entry.set, entry.entry)
+		return backupListed{key: entry.key(), listing: listing, err: err}
+	}
+}
+
+func (b backupBrowser) listing() backupListing {
+	if b.opened == nil {
+		return backupListing{}
+	}
+	return b.listings[b.opened.key()]
+}
+
+// visibleRows flattens the expanded part of the tree, or lists the files
+// matching the search.
+func (b backupBrowser) visibleRows() []treeRow {
+	listing := b.listing()
+	var rows []treeRow
+	if b.query != "" {
+		query := strings.ToLower(b.query)
+		for _, children := range listing.children {
+			for _, file := range children {
+				if strings.Contains(strings.ToLower(file.path), query) {
+					rows = append(rows, treeRow{file: file})
+				}
+			}
+		}
+		sort.Slice(rows, func(i, j int) bool { return rows[i].file.path < rows[j].file.path })
+		return rows
+	}
+	var walk func(dir string, depth int)
+	walk = func(dir string, depth int) {
+		for _, file := range listing.children[dir] {
+			rows = append(rows, treeRow{file: file, depth: depth})
+			if file.mode.IsDir() && b.expanded[file.path] {
+				walk(file.path, depth+1)
+			}
+		}
+	}
+	walk(".", 0)
+	return rows
+}
+
+func (b backupBrowser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.height = msg.Height
+	case backupListed:
+		if b.opened == nil || msg.key != b.opened.key() {
+			return b, nil
+		}
+		b.loading = false
+		if msg.err != nil {
+			b.err = msg.err
+			return b, nil
+		}
+		b.listings[msg.key] = msg.listing
+		b.rows = b.visibleRows()
+	case tea.KeyMsg:
+		if b.searching {
+			return b.updateSearch(msg), nil
+		}
+		if msg.String() == "ctrl+c" || msg.String() == "q" {
+			return b, tea.Quit
+		}
+		if b.opened == nil {
+			return b.updateEntries(msg)
+		}
+		return b.updateTree(msg), nil
+	}
+	return b, nil
+}
+
+func (b backupBrowser) updateEntries(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		return b, tea.Quit
+	case "up", "k":
+		if b.cursor > 0 {
+			b.cursor--
+		}
+	case "down", "j":
+		if b.cursor < len(b.entries)-1 {
+			b.cursor++
+		}
+	case "enter", "right", "l":
+		return b.open()
+	}
+	return b, nil
+}
+
+func (b backupBrowser) updateTree(msg tea.KeyMsg) backupBrowser {
+	switch msg.String() {
+	case "esc":
+		if b.query != "" {
+			b.query = ""
+			b.row = 0
+			b.rows = b.visibleRows()
+			return b
+		}
+		b.opened = nil
+		return b
+	case "/":
+		if !b.loading && b.err == nil {
+			b.searching = true
+		}
+		return b
+	case "up", "k":
+		if b.row > 0 {
+			b.row--
+		}
+	case "down", "j":
+		if b.row < len(b.rows)-1 {
+			b.row++
+		}
+	case "enter", "right", "l":
+		if b.row < len(b.rows) && b.rows[b.row].file.mode.IsDir() {
+			dir := b.rows[b.row].file.path
+			if b.query != "" {
+				// Jump from a search result to its place in the tree
+				b.query = ""
+				b.expandTo(dir)
+				b.rows = b.visibleRows()
+				b.row = b.rowOf(dir)
+			}
+			b.expanded[dir] = !b.expanded[dir] || msg.String() != "enter"
+			b.rows = b.visibleRows()
+		}
+	case "left", "h":
+		if b.row >= len(b.rows) || b.query != "" {
+			return b
+		}
+		file := b.rows[b.row].file
+		if file.mode.IsDir() && b.expanded[file.path] {
+			b.expanded[file.path] = false
+		} else if parent := path.Dir(file.path); parent != "." {
+			b.expanded[parent] = false
+			b.rows = b.visibleRows()
+			b.row = b.rowOf(parent)
+		}
+		b.rows = b.visibleRows()
+	}
+	return b
+}
+
+func (b backupBrowser) updateSearch(msg tea.KeyMsg) backupBrowser {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		b.searching = false
+		return b
+	case tea.KeyEnter:
+		b.searching = false
+	case tea.KeyEsc:
+		b.searching, b.query = false, ""
+	case tea.KeyBackspace:
+		if b.query != "" {
+			b.query = b.query[:len(b.query)-1]
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		b.query += string(msg.Runes)
+	}
+	b.row = 0
+	b.rows = b.visibleRows()
+	return b
+}
+
+// expandTo expands every directory above dir.
+func (b backupBrowser) expandTo(dir string) {
+	for parent := path.Dir(dir); parent != "."; parent = path.Dir(parent) {
+		b.expanded[parent] = true
+	}
+}
+
+func (b backupBrowser) rowOf(dir string) int {
+	for i, row := range b.rows {
+		if row.file.path == dir {
+			return i
+		}
+	}
+	return 0
+}
+
+func (b backupBrowser) View() string {
+	if b.opened == nil {
+		return b.entriesView()
+	}
+	return b.treeView()
+}
+
+func (b backupBrowser) entriesView() string {
+	s := highlightStyle.Render("🗄️  Backups") + "\n\n"
+	if len(b.entries) == 0 {
+		return s + infoStyle.Render("No backup sets yet. q quits") + "\n"
+	}
+	first, last := window(b.cursor, len(b.entries), b.height-6)
+	for i := first; i < last; i++ {
+		e := b.entries[i]
+		line := fmt.Sprintf("%s  %-10s  %s", e.set.index.Created.Format("2006-01-02 15:04"), e.entry.Version, e.entry.Path)
+		if i == b.cursor {
+			s += highlightStyle.Render("▸ "+line) + "\n"
+		} else {
+			s += "  " + line + "\n"
+		}
+	}
+	s += "\n" + infoStyle.Render("↑/↓ move · enter browse files · q quit") + "\n"
+	return s
+}
+
+func (b backupBrowser) treeView() string {
+	e := b.opened
+	s := highlightStyle.Render(fmt.Sprintf("📦 %s", e.entry.Path)) + "\n"
+	s += infoStyle.Render(fmt.Sprintf("%s · %s", filepath.Base(e.set.dir), e.entry.Archive)) + "\n\n"
+	switch {
+	case b.loading:
+		return s + infoStyle.Render("Reading the archive…") + "\n"
+	case b.err != nil:
+		return s + warningStyle.Render(fmt.Sprintf("⚠️  %v", b.err)) + "\n\n" + infoStyle.Render("esc back") + "\n"
+	}
+	listing := b.listing()
+	if b.searching || b.query != "" {
+		s += fmt.Sprintf("/%s", b.query)
+		if b.searching {
+			s += "█"
+		}
+		s += infoStyle.Render(fmt.Sprintf("  %d match(es)", len(b.rows))) + "\n"
+	}
+	first, last := window(b.row, len(b.rows), b.height-9)
+	for i := first; i < last; i++ {
+		row := b.rows[i]
+		line := strings.Repeat("  ", row.depth) + treeLabel(row.file, b.expanded[row.file.path], b.query != "")
+		if i == b.row {
+			s += highlightStyle.Render("▸ "+line) + "\n"
+		} else {
+			s += "  " + line + "\n"
+		}
+	}
+	if b.row < len(b.rows) {
+		f := b.rows[b.row].file
+		detail := fmt.Sprintf("%s · %s · %s", f.path, f.mode, f.modTime.Format("2006-01-02 15:04"))
+		if f.mode.IsRegular() {
+			detail += " · " + formatBytes(f.size)
+		}
+		if f.link != "" {
+			detail += " → " + f.link
+		}
+		s += "\n" + infoStyle.Render(detail)
+	}
+	s += "\n" + infoStyle.Render(fmt.Sprintf("%d files, %s · ↑/↓ move · →/enter open · ← close · / search · esc back", listing.files, formatBytes(listing.bytes))) + "\n"
+	return s
+}
+
+// treeLabel is a file's line in the tree, or its full path in search
+// results.
+func treeLabel(f listedFile, expanded, fullPath bool) string {
+	name := f.name()
+	if fullPath {
+		name = f.path
+	}
+	switch {
+	case f.mode.IsDir() && expanded:
+		return "▾ " + name + "/"
+	case f.mode.IsDir():
+		return "▸ " + name + "/"
+	case f.link != "":
+		return "  " + name + " → " + f.link
+	}
+	return "  " + name
+}
+
+// window is the range of n rows to show so that cursor stays visible in
+// height lines.
+func window(cursor, n, height int) (int, int) {
+	if height < 3 {
+		height = 3
+	}
+	if n <= height {
+		return 0, n
+	}
+	first := cursor - height/2
+	first = max(0, min(first, n-height))
+	return first, first + height
+}
+
+func newBackupsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "backups [backup-set]",
+		Short: "Browse the files in backup sets without extracting them",
+		Long:  "backups lists the installations in the backup sets (or only the named set) and shows the file tree of\nthe one picked, read from the archive's headers, so you can check a file is there before restoring.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			paths, err := resolvePaths(cfg)
+			if err != nil {
+				return err
+			}
+			var sets []backupSet
+			if len(args) > 0 {
+				set, err := findBackupSet(paths.Backups, args[0])
+				if err != nil {
+					return err
+				}
+				sets = []backupSet{set}
+			} else if sets, err = listBackupSets(paths.Backups); err != nil {
+				return err
+			}
+			applyTheme(cfg.Theme)
+			_, err = tea.NewProgram(newBackupBrowser(sets), tea.WithAltScreen()).Run()
+			return err
+		},
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func browserKey(b backupBrowser, keys ...string) (backupBrowser, tea.Cmd) {
+	var cmd tea.Cmd
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "left":
+			msg = tea.KeyMsg{Type: tea.KeyLeft}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		next, c := b.Update(msg)
+		b, cmd = next.(backupBrowser), c
+	}
+	return b, cmd
+}
+
+func rowPaths(b backupBrowser) []string {
+	var paths []string
+	for _, row := range b.rows {
+		paths = append(paths, row.file.path)
+	}
+	return paths
+}
+
+func TestListBackupEntry(t *testing.T) {
+	for _, format := range []string{backupFormatArchive, backupFormatDedup, backupFormatQuarantine} {
+		t.Run(format, func(t *testing.T) {
+			root := filepath.Join(t.TempDir(), "go")
+			os.MkdirAll(filepath.Join(root, "src", "fmt"), 0755)
+			os.WriteFile(filepath.Join(root, "VERSION"), []byte("go1.22.5\n"), 0644)
+			os.WriteFile(filepath.Join(root, "src", "fmt", "print.go"), []byte("package fmt\n"), 0644)
+			os.Symlink("src", filepath.Join(root, "source"))
+			backups := t.TempDir()
+			dir, err := writeBackupSet(backups, format, []GoInstallation{{Path: root}}, time.Now())
+			if err != nil {
+				t.Fatalf("writeBackupSet returned error: %v", err)
+			}
+			set, err := findBackupSet(backups, dir)
+			if err != nil {
+				t.Fatalf("findBackupSet returned error: %v", err)
+			}
+			listing, err := listBackupEntry(set, set.index.Entries[0])
+			if err != nil {
+				t.Fatalf("listBackupEntry returned error: %v", err)
+			}
+			if listing.files != 3 || listing.bytes != int64(len("go1.22.5\n")+len("package fmt\n")) {
+				t.Errorf("Expected 3 files, got %d files, %d bytes", listing.files, listing.bytes)
+			}
+			var top []string
+			for _, f := range listing.children["."] {
+				top = append(top, f.name())
+			}
+			if strings.Join(top, ",") != "src,VERSION,source" {
+				t.Errorf("Expected directories first, then files by name, got %v", top)
+			}
+			if files := listing.children["src/fmt"]; len(files) != 1 || files[0].size != int64(len("package fmt\n")) {
+				t.Errorf("Unexpected src/fmt %+v", files)
+			}
+			if link := listing.children["."][2]; link.link != "src" || link.mode&fs.ModeSymlink == 0 {
+				t.Errorf("Expected source to be a link to src, got %+v", link)
+			}
+		})
+	}
+}
+
+func TestBackupListingAddsMissingDirectories(t *testing.T) {
+	listing := newBackupListing([]listedFile{{path: "pkg/tool/linux_amd64/compile", size: 10}})
+	if dirs := listing.children["pkg"]; len(dirs) != 1 || dirs[0].path != "pkg/tool" || !dirs[0].mode.IsDir() {
+		t.Errorf("Expected pkg/tool to be added, got %+v", dirs)
+	}
+	if listing.files != 1 || listing.bytes != 10 {
+		t.Errorf("Expected directories not to count, got %d files, %d bytes", listing.files, listing.bytes)
+	}
+}
+
+func TestBackupBrowser(t *testing.T) {
+	_, set := removedGoRoot(t, backupFormatArchive)
+	b := newBackupBrowser([]backupSet{set})
+	if len(b.entries) != 1 || !strings.Contains(b.View(), set.index.Entries[0].Path) {
+		t.Fatalf("Expected the installation to be listed, got %q", b.View())
+	}
+
+	b, cmd := browserKey(b, "enter")
+	if !b.loading || cmd == nil {
+		t.Fatalf("Expected the archive to be read in the background")
+	}
+	next, _ := b.Update(cmd())
+	b = next.(backupBrowser)
+	if got := strings.Join(rowPaths(b), ","); got != "bin,VERSION" {
+		t.Fatalf("Expected the top of the tree, got %s", got)
+	}
+
+	b, _ = browserKey(b, "l")
+	if got := strings.Join(rowPaths(b), ","); got != "bin,bin/go,bin/gofmt,VERSION" {
+		t.Errorf("Expected bin to expand, got %s", got)
+	}
+	b, _ = browserKey(b, "j", "left")
+	if b.row != 0 || len(b.rows) != 2 {
+		t.Errorf("Expected ← on a file to close its directory, got row %d of %v", b.row, rowPaths(b))
+	}
+
+	b, _ = browserKey(b, "/", "f", "m", "t", "enter")
+	if got := strings.Join(rowPaths(b), ","); got != "bin/gofmt" {
+		t.Errorf("Expected the search to find bin/gofmt, got %s", got)
+	}
+	if !strings.Contains(b.View(), "1 match(es)") {
+		t.Errorf("Expected the match count, got %q", b.View())
+	}
+	b, _ = browserKey(b, "esc")
+	if b.query != "" || len(b.rows) != 2 {
+		t.Errorf("Expected esc to clear the search, got %q and %v", b.query, rowPaths(b))
+	}
+
+	// Reopening uses the listing already read
+	b, _ = browserKey(b, "esc")
+	if b.opened != nil {
+		t.Fatalf("Expected esc to go back to the installations")
+	}
+	if b, cmd = browserKey(b, "enter"); cmd != nil || b.loading {
+		t.Errorf("Expected the listing to be reused")
+	}
+}
+
+func TestWindow(t *testing.T) {
+	for _, tc := range []struct{ cursor, n, height, first, last int }{
+		{0, 5, 10, 0, 5},
+		{0, 50, 10, 0, 10},
+		{25, 50, 10, 20, 30},
+		{49, 50, 10, 40, 50},
+	} {
+		if first, last := window(tc.cursor, tc.n, tc.height); first != tc.first || last != tc.last {
+			t.Errorf("window(%d, %d, %d) = %d, %d, expected %d, %d", tc.cursor, tc.n, tc.height, first, last, tc.first, tc.last)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"hash/crc32"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cutting a module cache into chunks means reading and hashing every byte
+// of it, which dominates a dedup backup of a large tree. Files are hashed by
+// a pool of workers, and the rate is logged with the backup. With
+// backup_hashing "sample" (or --sample-hashing) a file that matches the last
+// dedup backup of the installation in size, time and mode keeps that
+// backup's chunks without being read again. Executables are always hashed,
+// as is a tenth of the rest, a different tenth each day, so a file changed
+// behind an unchanged time is still caught within ten days.
+
+const (
+	hashingFull   = "full"
+	hashingSample = "sample"
+
+	// sampleEvery is how many unchanged files share one re-hash a day.
+	sampleEvery = 10
+)
+
+// backupHashWorkers is how many files a backup hashes at once. Each worker
+// holds a chunk buffer of up to maxChunkSize.
+var backupHashWorkers = min(runtime.NumCPU(), 8)
+
+var sampleHashing atomic.Bool
+
+// setSampleHashing applies --sample-hashing, or backup_hashing "sample", to
+// the whole run.
+func setSampleHashing(sample bool) {
+	sampleHashing.Store(sample)
+}
+
+// storeStats is what storing a tree cost.
+type storeStats struct {
+	written int64 // bytes of new chunks
+	hashed  int64 // bytes read and hashed
+	reused  int   // files whose chunks came from the last backup
+	elapsed time.Duration
+}
+
+// hashRate is the hashing throughput in bytes per second.
+func (s storeStats) hashRate() float64 {
+	if s.elapsed <= 0 {
+		return 0
+	}
+	return float64(s.hashed) / s.elapsed.Seconds()
+}
+
+// sampleSource is the last dedup backup of an installation, whose chunks
+// unchanged files reuse in sampling mode.
+type sampleSource struct {
+	store chunkStore
+	files map[string]treeFile
+	round uint32 // which tenth of the unchanged files is hashed today
+}
+
+// lastManifest finds the newest dedup manifest of the installation at path
+// in backupDir, for sampling.
+func lastManifest(backupDir, path string, now time.Time) *sampleSource {
+	sets, err := listBackupSets(backupDir)
+	if err != nil {
+		return nil
+	}
+	for i := len(sets) - 1; i >= 0; i-- {
+		for _, entry := range sets[i].index.Entries {
+			if entry.Path != path || entry.Format != backupFormatDedup {
+				continue
+			}
+			manifest, err := readTreeManifest(filepath.Join(sets[i].dir, entry.Archive))
+			if err != nil {
+				return nil
+			}
+			source := &sampleSource{store: openChunkStore(backupDir), files: make(map[string]treeFile, len(manifest.Files)), round: uint32(now.Unix()/86400) % sampleEvery}
+			for _, file := range manifest.Files {
+				source.files[file.Path] = file
+			}
+			return source
+		}
+	}
+	return nil
+}
+
+// reuse returns the chunks the last backup stored for file, when sampling
+// lets it skip hashing the file.
+func (s *sampleSource) reuse(file treeFile) ([]string, bool) {
+	if s == nil || file.Mode.Perm()&0111 != 0 || crc32.ChecksumIEEE([]byte(file.Path))%sampleEvery == s.round {
+		return nil, false
+	}
+	last, ok := s.files[file.Path]
+	if !ok || last.HardLink != "" || last.Size != file.Size || last.Mode != file.Mode || !last.ModTime.Equal(file.ModTime) {
+		return nil, false
+	}
+	// Retention may have collected a chunk since
+	for _, sum := range last.Chunks {
+		if !s.store.has(sum) {
+			return nil, false
+		}
+	}
+	return last.Chunks, true
+}
+
+// hashJob is a file of the manifest still to be chunked.
+type hashJob struct {
+	index int // in manifest.Files
+	path  string
+}
+
+// hashFiles chunks the files of jobs into s with backupHashWorkers workers,
+// filling in their chunks in manifest. It stops at the first error.
+func hashFiles(s chunkStore, manifest *treeManifest, jobs []hashJob, stats *storeStats) error {
+	queue := make(chan hashJob)
+	var mu sync.Mutex
+	var firstErr error
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	for range max(backupHashWorkers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				if failed.Load() {
+					continue
+				}
+				n, chunks, err := storeFile(s, job.path)
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+					failed.Store(true)
+				}
+				stats.written += n
+				stats.hashed += manifest.Files[job.index].Size
+				manifest.Files[job.index].Chunks = chunks
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, job := range jobs {
+		if failed.Load() {
+			break
+		}
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+	return firstErr
+}
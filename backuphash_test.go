@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func withSampleHashing(t *testing.T, sample bool) {
+	t.Helper()
+	previous := sampleHashing.Load()
+	setSampleHashing(sample)
+	t.Cleanup(func() { setSampleHashing(previous) })
+}
+
+func TestHashFilesMatchesOneWorker(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "mod")
+	var total int64
+	for i := range 40 {
+		dir := filepath.Join(root, fmt.Sprintf("pkg%d", i%5))
+		os.MkdirAll(dir, 0755)
+		data := []byte(strings.Repeat(fmt.Sprintf("file %d ", i), 100*(i+1)))
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.go", i)), data, 0644)
+		total += int64(len(data))
+	}
+
+	manifests := make(map[int]treeManifest)
+	for _, workers := range []int{1, 4} {
+		saved := backupHashWorkers
+		backupHashWorkers = workers
+		store := openChunkStore(t.TempDir())
+		path := filepath.Join(t.TempDir(), "manifest.json")
+		stats, err := storeTree(store, root, path, nil)
+		backupHashWorkers = saved
+		if err != nil {
+			t.Fatalf("storeTree with %d workers returned error: %v", workers, err)
+		}
+		if stats.hashed != total || stats.written == 0 {
+			t.Errorf("Expected %d bytes hashed with %d workers, got %+v", total, workers, stats)
+		}
+		manifests[workers], _ = readTreeManifest(path)
+	}
+	if len(manifests[1].Files) != len(manifests[4].Files) {
+		t.Fatalf("Expected the same files, got %d and %d", len(manifests[1].Files), len(manifests[4].Files))
+	}
+	for i, file := range manifests[1].Files {
+		other := manifests[4].Files[i]
+		if file.Path != other.Path || !slices.Equal(file.Chunks, other.Chunks) {
+			t.Errorf("Expected %s to be stored the same in parallel, got %+v", file.Path, other)
+		}
+	}
+}
+
+func TestSampleHashingReusesUnchangedFiles(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "go")
+	os.MkdirAll(filepath.Join(root, "bin"), 0755)
+	os.MkdirAll(filepath.Join(root, "src"), 0755)
+	os.WriteFile(filepath.Join(root, "bin", "go"), []byte("go binary"), 0755)
+	os.WriteFile(filepath.Join(root, "src", "a.go"), []byte("package a"), 0644)
+	os.WriteFile(filepath.Join(root, "src", "b.go"), []byte("package b"), 0644)
+	// A day on which neither source file is in the tenth that is re-hashed
+	day := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	for crc32.ChecksumIEEE([]byte("src/a.go"))%sampleEvery == uint32(day.Unix()/86400)%sampleEvery ||
+		crc32.ChecksumIEEE([]byte("src/b.go"))%sampleEvery == uint32(day.Unix()/86400)%sampleEvery {
+		day = day.AddDate(0, 0, 1)
+	}
+	backups := t.TempDir()
+	if _, err := writeBackupSet(backups, backupFormatDedup, []GoInstallation{{Path: root}}, day); err != nil {
+		t.Fatalf("writeBackupSet returned error: %v", err)
+	}
+
+	withSampleHashing(t, true)
+	os.WriteFile(filepath.Join(root, "src", "b.go"), []byte("package b // changed"), 0644)
+	dir, err := writeBackupSet(backups, backupFormatDedup, []GoInstallation{{Path: root}}, day.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("writeBackupSet returned error: %v", err)
+	}
+	index, _ := readBackupIndex(dir)
+	entry := index.Entries[0]
+	if entry.Reused != 1 || entry.Hashed != int64(len("go binary")+len("package b // changed")) {
+		t.Errorf("Expected only the unchanged source file to be trusted, got %+v", entry)
+	}
+
+	// The sampled backup restores like any other
+	manifest, _ := readTreeManifest(filepath.Join(dir, entry.Archive))
+	dest := t.TempDir()
+	if err := extractTree(openChunkStore(backups), manifest, dest, nil); err != nil {
+		t.Fatalf("extractTree returned error: %v", err)
+	}
+	for file, want := range map[string]string{"src/a.go": "package a", "src/b.go": "package b // changed", "bin/go": "go binary"} {
+		if got := readString(t, filepath.Join(dest, "go", filepath.FromSlash(file))); got != want {
+			t.Errorf("Expected %s to hold %q, got %q", file, want, got)
+		}
+	}
+}
+
+func TestBackupsTakenLogsHashRate(t *testing.T) {
+	taken := backupsTaken{hashing: storeStats{hashed: 200 << 20, elapsed: 2 * time.Second, reused: 3}}
+	var lines []string
+	taken.logTo(func(level, message string) { lines = append(lines, level+" "+message) })
+	if len(lines) != 1 || !strings.Contains(lines[0], "Hashed 200.0 MB in 2s (100.0 MB/s)") || !strings.Contains(lines[0], "3 unchanged files") {
+		t.Errorf("Unexpected log %v", lines)
+	}
+	if err := (Config{BackupHashing: "quick"}).validate(); err == nil {
+		t.Errorf("Expected backup_hashing to be validated")
+	}
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Each live run archives into a backup set of its own: a directory holding
+// one archive per installation and an index.json that says what each
+// archive is, so a single installation can be restored, checked or pruned
+// without unpacking the rest. The index is rewritten after every archive,
+// so a run that fails part way still describes what it saved. Retention
+// removes whole sets, oldest first.
+
+const backupIndexFile = "index.json"
+
+// backupIndex is a backup set's index.json.
+type backupIndex struct {
+	FugoVersion string        `json:"fugo_version"`
+	Created     time.Time     `json:"created"`
+	Host        string        `json:"host,omitempty"`
+	Entries     []backupEntry `json:"entries"`
+}
+
+// backupEntry is one archived installation.
+type backupEntry struct {
+	Path        string `json:"path"`
+	Version     string `json:"version,omitempty"`
+	Source      string `json:"source,omitempty"`
+	Size        int64  `json:"size"`
+	Files       int64  `json:"files,omitempty"` // files and bytes under Path when archived, to check a restore against
+	Bytes       int64  `json:"bytes,omitempty"`
+	Format      string `json:"format,omitempty"` // "archive" (tar.gz, the default), or "dedup" or "quarantine" (a manifest)
+	Archive     string `json:"archive"`          // file name in the set
+	ArchiveSize int64  `json:"archive_size"`     // bytes written: the archive, the new chunks or the copied files
+	Tree        string `json:"tree,omitempty"`   // quarantine: directory in the set holding the files
+	Moved       bool   `json:"moved,omitempty"`  // quarantine: the installation was renamed into the set
+	SHA256      string `json:"sha256"`           // of the archive or manifest
+	Xattrs      string `json:"xattrs,omitempty"` // file name in the set of an archive's extended attributes
+	Hashed      int64  `json:"hashed,omitempty"` // dedup: bytes read and hashed, and how long storing took
+	HashMS      int64  `json:"hash_ms,omitempty"`
+	Reused      int    `json:"reused,omitempty"` // dedup: files that kept the last backup's chunks
+}
+
+// RetentionConfig says which backup sets to keep. The newest set is always
+// kept.
+type RetentionConfig struct {
+	// KeepLast keeps that many of the newest sets.
+	KeepLast int `json:"keep_last,omitempty"`
+	// MaxAge removes sets older than this: a Go duration or a number of
+	// days such as "30d".
+	MaxAge string `json:"max_age,omitempty"`
+}
+
+func (r RetentionConfig) validate() error {
+	if r.KeepLast < 0 {
+		return fmt.Errorf("backup_retention keep_last must not be negative, got %d", r.KeepLast)
+	}
+	if _, err := r.maxAge(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r RetentionConfig) enabled() bool {
+	return r.KeepLast > 0 || r.MaxAge != ""
+}
+
+func (r RetentionConfig) maxAge() (time.Duration, error) {
+	if r.MaxAge == "" {
+		return 0, nil
+	}
+	var age time.Duration
+	if days, ok := strings.CutSuffix(r.MaxAge, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("backup_retention max_age: invalid number of days %q", r.MaxAge)
+		}
+		age = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if age, err = time.ParseDuration(r.MaxAge); err != nil {
+			return 0, fmt.Errorf("backup_retention max_age: %v", err)
+		}
+	}
+	if age <= 0 {
+		return 0, fmt.Errorf("backup_retention max_age must be positive, got %s", r.MaxAge)
+	}
+	return age, nil
+}
+
+// fugoVersion is the module version fu-go was built as, "(devel)" for a
+// local build.
+func fugoVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+var unsafeArchiveChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// archiveName is the file name of the i-th archive of a set: its position,
+// so names never collide, and the installation's directory name.
+func archiveName(i int, install GoInstallation) string {
+	name := unsafeArchiveChars.ReplaceAllString(filepath.Base(install.Path), "_")
+	return fmt.Sprintf("%02d-%s.tar.gz", i+1, name)
+}
+
+// newBackupSetDir creates the set directory for a run started at now.
+func newBackupSetDir(backupDir string, now time.Time) (string, error) {
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %v", err)
+	}
+	base := filepath.Join(backupDir, "fugo_backup_"+now.Format("20060102_150405"))
+	dir := base
+	for n := 2; ; n++ {
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			return dir, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("failed to create backup set: %v", err)
+		}
+		dir = fmt.Sprintf("%s_%d", base, n)
+	}
+}
+
+// writeBackupSet archives installs into a new set under backupDir, in
+// format, and returns its directory. Installations that no longer exist are
+// left out. Should it fail, the installations a quarantine moved into the set
+// go back where they were, since nothing is removed after a failed backup.
+func writeBackupSet(backupDir, format string, installs []GoInstallation, now time.Time) (dir string, err error) {
+	if dir, err = newBackupSetDir(backupDir, now); err != nil {
+		return "", err
+	}
+	host, _ := os.Hostname()
+	index := backupIndex{FugoVersion: fugoVersion(), Created: now, Host: host, Entries: []backupEntry{}}
+	if err := writeBackupIndex(dir, index); err != nil {
+		return dir, err
+	}
+	var moved []backupEntry
+	defer func() {
+		if err != nil && len(moved) > 0 {
+			err = unmoveQuarantined(dir, index, moved, err)
+		}
+	}()
+	for i, install := range installs {
+		if _, err := os.Stat(install.Path); os.IsNotExist(err) {
+			continue
+		}
+		entry := backupEntry{Path: install.Path, Version: install.Version, Source: install.Source, Size: install.Size, Archive: archiveName(i, install)}
+		entry.Bytes, entry.Files = dirUsage(install.Path)
+		archive := filepath.Join(dir, entry.Archive)
+		switch format {
+		case backupFormatDedup:
+			entry.Format = backupFormatDedup
+			entry.Archive = strings.TrimSuffix(entry.Archive, ".tar.gz") + ".json"
+			archive = filepath.Join(dir, entry.Archive)
+			var sample *sampleSource
+			if sampleHashing.Load() {
+				sample = lastManifest(backupDir, install.Path, now)
+			}
+			stats, err := storeTree(openChunkStore(backupDir), install.Path, archive, sample)
+			if err != nil {
+				return dir, fmt.Errorf("backup of %s failed: %v", install.Path, err)
+			}
+			entry.ArchiveSize, entry.Hashed, entry.HashMS, entry.Reused = stats.written, stats.hashed, stats.elapsed.Milliseconds(), stats.reused
+		case backupFormatQuarantine:
+			entry.Format = backupFormatQuarantine
+			entry.Tree = strings.TrimSuffix(entry.Archive, ".tar.gz")
+			entry.Archive = entry.Tree + ".json"
+			archive = filepath.Join(dir, entry.Archive)
+			stats, err := quarantineInstall(install, filepath.Join(dir, entry.Tree), archive)
+			if err != nil {
+				return dir, fmt.Errorf("backup of %s failed: %v", install.Path, err)
+			}
+			entry.ArchiveSize, entry.Moved = stats.written, stats.moved
+			if entry.Moved {
+				moved = append(moved, entry)
+			}
+		default:
+			if err := archiveTree(install.Path, archive); err != nil {
+				return dir, fmt.Errorf("backup of %s failed: %v", install.Path, err)
+			}
+			if info, err := os.Stat(archive); err == nil {
+				entry.ArchiveSize = info.Size()
+			}
+			attrs, err := collectXattrs(install.Path)
+			if err != nil {
+				return dir, fmt.Errorf("backup of %s failed: %v", install.Path, err)
+			}
+			if len(attrs) > 0 {
+				entry.Xattrs = xattrSidecarName(entry.Archive)
+				if err := writeXattrSidecar(filepath.Join(dir, entry.Xattrs), attrs); err != nil {
+					return dir, fmt.Errorf("backup of %s failed: %v", install.Path, err)
+				}
+			}
+		}
+		if entry.SHA256, err = fileSHA256(archive); err != nil {
+			return dir, fmt.Errorf("failed to checksum %s: %v", archive, err)
+		}
+		index.Entries = append(index.Entries, entry)
+		if err := writeBackupIndex(dir, index); err != nil {
+			return dir, err
+		}
+	}
+	return dir, nil
+}
+
+// unmoveQuarantined renames the installations in moved, which a failed run
+// quarantined into the set at dir, back to their paths and drops them from
+// the set's index, and returns cause with any that could not go back. Those
+// keep their entry, so the set still restores them.
+func unmoveQuarantined(dir string, index backupIndex, moved []backupEntry, cause error) error {
+	back := make(map[string]bool)
+	var stranded []string
+	for _, entry := range moved {
+		tree := filepath.Join(dir, entry.Tree)
+		if err := fsys.Rename(tree, entry.Path); err != nil {
+			stranded = append(stranded, fmt.Sprintf("%s is left in %s", entry.Path, tree))
+			if !slices.ContainsFunc(index.Entries, func(e backupEntry) bool { return e.Path == entry.Path }) {
+				index.Entries = append(index.Entries, entry)
+			}
+			continue
+		}
+		back[entry.Path] = true
+		os.Remove(filepath.Join(dir, entry.Archive))
+	}
+	index.Entries = slices.DeleteFunc(index.Entries, func(e backupEntry) bool { return back[e.Path] })
+	if err := writeBackupIndex(dir, index); err != nil {
+		stranded = append(stranded, err.Error())
+	}
+	if len(stranded) == 0 {
+		return cause
+	}
+	return fmt.Errorf("%v; %s", cause, strings.Join(stranded, "; "))
+}
+
+// archiveTree writes sourcePath, if it still exists, to the tar.gz at
+// archivePath, its entries under the directory's own name. As in a dedup
+// backup, the extra names of a hard-linked file are stored as links to the
+// first, and setuid, setgid and sticky bits are kept. Sockets, devices and
+// pipes are not part of a Go installation and are left out.
+func archiveTree(sourcePath, archivePath string) error {
+	if _, err := os.Lstat(sourcePath); os.IsNotExist(err) {
+		return nil
+	}
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(f)
+	tw := tar.NewWriter(zw)
+	err = writeTreeArchive(tw, sourcePath)
+	for _, closer := range []io.Closer{tw, zw, f} {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		os.Remove(archivePath)
+	}
+	return err
+}
+
+func writeTreeArchive(tw *tar.Writer, root string) error {
+	base := filepath.Base(root)
+	links := make(map[fileKey]string) // the first name of each file with several
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var link string
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		case !info.Mode().IsRegular() && !info.IsDir():
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		header.Name = base
+		if rel != "." {
+			header.Name += "/" + filepath.ToSlash(rel)
+		}
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if info.Mode().IsRegular() {
+			if key, ok := hardLinkKey(info); ok {
+				if first, seen := links[key]; seen {
+					header.Typeflag, header.Linkname, header.Size = tar.TypeLink, first, 0
+					return tw.WriteHeader(header)
+				}
+				links[key] = header.Name
+			}
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		// Only as much as the header says, should the file grow meanwhile
+		if _, err := io.CopyN(tw, file, header.Size); err != nil {
+			return fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		return nil
+	})
+}
+
+func writeBackupIndex(dir string, index backupIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode backup index: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, backupIndexFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write backup index: %v", err)
+	}
+	return nil
+}
+
+// readBackupIndex reads the index of the set in dir.
+func readBackupIndex(dir string) (backupIndex, error) {
+	var index backupIndex
+	data, err := os.ReadFile(filepath.Join(dir, backupIndexFile))
+	if err != nil {
+		return index, fmt.Errorf("failed to read backup index: %v", err)
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return index, fmt.Errorf("failed to parse backup index %s: %v", filepath.Join(dir, backupIndexFile), err)
+	}
+	return index, nil
+}
+
+// backupSet is a set found in the backup directory.
+type backupSet struct {
+	dir   string
+	index backupIndex
+}
+
+// listBackupSets returns the sets in backupDir, oldest first. Directories
+// without an index are not fu-go's and are left out.
+func listBackupSets(backupDir string) ([]backupSet, error) {
+	entries, err := os.ReadDir(backupDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %v", err)
+	}
+	var sets []backupSet
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "fugo_backup_") {
+			continue
+		}
+		dir := filepath.Join(backupDir, entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, backupIndexFile)); os.IsNotExist(err) {
+			continue
+		}
+		index, err := readBackupIndex(dir)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, backupSet{dir: dir, index: index})
+	}
+	sort.SliceStable(sets, func(i, j int) bool { return sets[i].index.Created.Before(sets[j].index.Created) })
+	return sets, nil
+}
+
+// pruneBackupSets removes the sets retention does not keep, then the chunks
+// only they used, and returns the sets removed and the bytes freed.
+func pruneBackupSets(backupDir string, retention RetentionConfig, now time.Time) ([]string, int64, error) {
+	if !retention.enabled() {
+		return nil, 0, nil
+	}
+	maxAge, err := retention.maxAge()
+	if err != nil {
+		return nil, 0, err
+	}
+	sets, err := listBackupSets(backupDir)
+	if err != nil {
+		return nil, 0, err
+	}
+	var removed []string
+	var freed int64
+	for i, set := range sets {
+		newest := len(sets) - i // 1 for the newest set
+		if newest == 1 {
+			break
+		}
+		tooMany := retention.KeepLast > 0 && newest > retention.KeepLast
+		tooOld := maxAge > 0 && now.Sub(set.index.Created) > maxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		size := getDirSize(set.dir)
+		if err := fsys.RemoveAll(set.dir); err != nil {
+			return removed, freed, fmt.Errorf("failed to remove backup set %s: %v", set.dir, err)
+		}
+		removed = append(removed, set.dir)
+		freed += size
+	}
+	chunks, err := collectChunks(backupDir)
+	return removed, freed + chunks, err
+}
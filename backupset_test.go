@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteBackupSet(t *testing.T) {
+	a := fakeGoRoot(t, "VERSION", "bin/go")
+	b := filepath.Join(t.TempDir(), filepath.Base(a))
+	os.MkdirAll(filepath.Join(b, "bin"), 0755)
+	os.WriteFile(filepath.Join(b, "VERSION"), []byte("go1.21.0"), 0644)
+	backups := filepath.Join(t.TempDir(), "backups")
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+
+	installs := []GoInstallation{
+		{Path: a, Version: "go1.22.5", Source: "official", Size: 10},
+		{Path: filepath.Join(t.TempDir(), "gone"), Source: "manual"},
+		{Path: b, Version: "go1.21.0", Source: "gvm", Size: 20},
+	}
+	dir, err := writeBackupSet(backups, backupFormatArchive, installs, now)
+	if err != nil {
+		t.Fatalf("writeBackupSet returned error: %v", err)
+	}
+	if filepath.Dir(dir) != backups || filepath.Base(dir) != "fugo_backup_20261016_093000" {
+		t.Errorf("Unexpected backup set directory %s", dir)
+	}
+	index, err := readBackupIndex(dir)
+	if err != nil {
+		t.Fatalf("readBackupIndex returned error: %v", err)
+	}
+	if index.FugoVersion == "" || !index.Created.Equal(now) || len(index.Entries) != 2 {
+		t.Fatalf("Unexpected index %+v", index)
+	}
+	if index.Entries[0].Archive == index.Entries[1].Archive {
+		t.Errorf("Expected installations with the same name to get their own archives, got %s twice", index.Entries[0].Archive)
+	}
+	for i, want := range []GoInstallation{installs[0], installs[2]} {
+		entry := index.Entries[i]
+		if entry.Path != want.Path || entry.Version != want.Version || entry.Source != want.Source || entry.Size != want.Size {
+			t.Errorf("Entry %d is %+v, expected %s", i, entry, want.Path)
+		}
+		sum, err := fileSHA256(filepath.Join(dir, entry.Archive))
+		if err != nil || sum != entry.SHA256 || entry.ArchiveSize == 0 {
+			t.Errorf("Expected %s to match its checksum %s and size, got %s, %v", entry.Archive, entry.SHA256, sum, err)
+		}
+	}
+
+	again, err := writeBackupSet(backups, backupFormatArchive, installs[:1], now)
+	if err != nil || again == dir {
+		t.Errorf("Expected a second run in the same second to get its own set, got %s, %v", again, err)
+	}
+}
+
+func TestPruneBackupSets(t *testing.T) {
+	skipInAuditBuild(t)
+	backups := t.TempDir()
+	root := fakeGoRoot(t, "VERSION", "bin/go")
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	var sets []string
+	for _, age := range []time.Duration{40 * 24 * time.Hour, 20 * 24 * time.Hour, 10 * 24 * time.Hour, time.Hour} {
+		dir, err := writeBackupSet(backups, backupFormatArchive, []GoInstallation{{Path: root}}, now.Add(-age))
+		if err != nil {
+			t.Fatalf("writeBackupSet returned error: %v", err)
+		}
+		sets = append(sets, dir)
+	}
+	os.MkdirAll(filepath.Join(backups, "fugo_backup_mine"), 0755)
+
+	if removed, _, err := pruneBackupSets(backups, RetentionConfig{}, now); err != nil || len(removed) != 0 {
+		t.Fatalf("Expected no retention to keep everything, removed %v, %v", removed, err)
+	}
+	removed, freed, err := pruneBackupSets(backups, RetentionConfig{MaxAge: "30d"}, now)
+	if err != nil || len(removed) != 1 || removed[0] != sets[0] || freed == 0 {
+		t.Errorf("Expected the 40 day old set to be removed, got %v, %d, %v", removed, freed, err)
+	}
+	removed, _, err = pruneBackupSets(backups, RetentionConfig{KeepLast: 1, MaxAge: "1h"}, now.Add(365*24*time.Hour))
+	if err != nil || len(removed) != 2 {
+		t.Errorf("Expected all but the newest set to be removed, got %v, %v", removed, err)
+	}
+	if _, err := os.Stat(sets[3]); err != nil {
+		t.Errorf("Expected the newest set to be kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(backups, "fugo_backup_mine")); err != nil {
+		t.Errorf("Expected a directory without an index to be left alone: %v", err)
+	}
+
+	for _, bad := range []RetentionConfig{{KeepLast: -1}, {MaxAge: "soon"}, {MaxAge: "0d"}} {
+		if err := bad.validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,465 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// With backup_format "dedup", backup sets do not hold archives. Every file
+// is cut into content-defined chunks, each chunk is stored once, gzipped,
+// under store/chunks/<sha256> in the backup directory, and the set holds
+// a manifest per installation listing its files and their chunks. A module
+// cache backed up again after a few downloads costs only the new chunks.
+// Chunks no remaining set refers to are deleted when retention prunes sets.
+
+const (
+	backupFormatArchive    = "archive"
+	backupFormatDedup      = "dedup"
+	backupFormatQuarantine = "quarantine"
+
+	storeDirName = "store"
+
+	// Chunk boundaries fall where the rolling hash matches chunkMask, on
+	// average every 1 MiB, so an insertion only changes the chunks around it.
+	minChunkSize = 256 << 10
+	maxChunkSize = 8 << 20
+	chunkMask    = 1<<20 - 1
+)
+
+// gearTable drives the rolling hash. It is fixed so that chunk boundaries,
+// and with them deduplication, are the same from run to run.
+var gearTable = func() [256]uint64 {
+	var table [256]uint64
+	r := rand.New(rand.NewSource(0x66752d676f))
+	for i := range table {
+		table[i] = r.Uint64()
+	}
+	return table
+}()
+
+// chunkReader splits r into content-defined chunks.
+type chunkReader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+func newChunkReader(r io.Reader) *chunkReader {
+	return &chunkReader{r: bufio.NewReaderSize(r, 1<<20), buf: make([]byte, 0, maxChunkSize)}
+}
+
+// next returns the next chunk, valid until the following call, or io.EOF.
+func (c *chunkReader) next() ([]byte, error) {
+	c.buf = c.buf[:0]
+	var hash uint64
+	for len(c.buf) < maxChunkSize {
+		b, err := c.r.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.buf = append(c.buf, b)
+		hash = hash<<1 + gearTable[b]
+		if len(c.buf) >= minChunkSize && hash&chunkMask == 0 {
+			break
+		}
+	}
+	if len(c.buf) == 0 {
+		return nil, io.EOF
+	}
+	return c.buf, nil
+}
+
+// chunkStore is a directory of chunks named by the SHA-256 of their content.
+type chunkStore struct {
+	dir string
+}
+
+func openChunkStore(backupDir string) chunkStore {
+	return chunkStore{dir: filepath.Join(backupDir, storeDirName, "chunks")}
+}
+
+func (s chunkStore) path(sum string) string {
+	return filepath.Join(s.dir, sum[:2], sum)
+}
+
+// has reports whether the chunk named sum is stored.
+func (s chunkStore) has(sum string) bool {
+	_, err := os.Stat(s.path(sum))
+	return err == nil
+}
+
+// put stores data unless a chunk with the same content is already there,
+// and returns its name and how many bytes were written.
+func (s chunkStore) put(data []byte) (string, int64, error) {
+	hash := sha256.Sum256(data)
+	sum := hex.EncodeToString(hash[:])
+	path := s.path(sum)
+	if _, err := os.Stat(path); err == nil {
+		return sum, 0, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", 0, fmt.Errorf("failed to create chunk directory: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".chunk-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to write chunk: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	zw := gzip.NewWriter(tmp)
+	if _, err := zw.Write(data); err != nil {
+		tmp.Close()
+		return "", 0, fmt.Errorf("failed to write chunk: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return "", 0, fmt.Errorf("failed to write chunk: %v", err)
+	}
+	info, _ := tmp.Stat()
+	if err := tmp.Close(); err != nil {
+		return "", 0, fmt.Errorf("failed to write chunk: %v", err)
+	}
+	// Renamed into place only once complete, so a chunk that exists is whole
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, fmt.Errorf("failed to store chunk: %v", err)
+	}
+	return sum, info.Size(), nil
+}
+
+// get returns the content of chunk sum, checked against its name.
+func (s chunkStore) get(sum string) ([]byte, error) {
+	f, err := os.Open(s.path(sum))
+	if err != nil {
+		return nil, fmt.Errorf("missing chunk %s: %v", sum, err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt chunk %s: %v", sum, err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt chunk %s: %v", sum, err)
+	}
+	if hash := sha256.Sum256(data); hex.EncodeToString(hash[:]) != sum {
+		return nil, fmt.Errorf("corrupt chunk %s: content does not match its checksum", sum)
+	}
+	return data, nil
+}
+
+// treeManifest lists an installation's files and the chunks they are made of.
+type treeManifest struct {
+	Root  string     `json:"root"` // directory name of the installation
+	Files []treeFile `json:"files"`
+}
+
+type treeFile struct {
+	Path     string            `json:"path"` // slash-separated, relative to the root
+	Mode     fs.FileMode       `json:"mode"`
+	ModTime  time.Time         `json:"mtime"`
+	Size     int64             `json:"size,omitempty"`
+	Link     string            `json:"link,omitempty"`
+	Chunks   []string          `json:"chunks,omitempty"`
+	Xattrs   map[string][]byte `json:"xattrs,omitempty"`   // extended attributes, SELinux context included
+	HardLink string            `json:"hardlink,omitempty"` // earlier path this file is a hard link to
+}
+
+// fileKey identifies a file by device and inode, to find its hard links.
+type fileKey struct {
+	dev, ino uint64
+}
+
+// chmodBits are the parts of a mode that restoring a file sets.
+const chmodBits = fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky
+
+// insideTree reports whether a slash-separated manifest path stays within
+// the installation once cleaned.
+func insideTree(rel string) bool {
+	return rel != "" && filepath.IsLocal(filepath.FromSlash(path.Clean(rel)))
+}
+
+// validTreeRoot reports whether root, the name a manifest restores its tree
+// under, is a single plain name.
+func validTreeRoot(root string) bool {
+	return insideTree(root) && !strings.ContainsAny(root, `/\`) && root != "."
+}
+
+// storeTree chunks every file under root into s and writes its manifest to
+// manifestPath. Unchanged files keep their chunks from sample, when there is
+// one (see backuphash.go).
+func storeTree(s chunkStore, root, manifestPath string, sample *sampleSource) (storeStats, error) {
+	start := time.Now()
+	var stats storeStats
+	var jobs []hashJob
+	manifest, err := scanTree(root, func(file *treeFile, index int, path string) {
+		if chunks, ok := sample.reuse(*file); ok {
+			file.Chunks = chunks
+			stats.reused++
+			return
+		}
+		jobs = append(jobs, hashJob{index: index, path: path})
+	})
+	if err == nil {
+		err = hashFiles(s, &manifest, jobs, &stats)
+	}
+	stats.elapsed = time.Since(start)
+	if err != nil {
+		return stats, err
+	}
+	return stats, writeTreeManifest(manifestPath, manifest)
+}
+
+// scanTree lists every file under root for a manifest, without their
+// contents. content, when not nil, is called for each regular file that is
+// not a further name of a hard link, with its index in the manifest.
+func scanTree(root string, content func(file *treeFile, index int, path string)) (treeManifest, error) {
+	manifest := treeManifest{Root: filepath.Base(root)}
+	links := make(map[fileKey]string) // the first path of each file with several names
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		file := treeFile{Path: filepath.ToSlash(rel), Mode: info.Mode(), ModTime: info.ModTime()}
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			if file.Link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			file.Size = info.Size()
+			if key, ok := hardLinkKey(info); ok {
+				if first, seen := links[key]; seen {
+					file.HardLink = first
+					break
+				}
+				links[key] = file.Path
+			}
+			if content != nil {
+				content(&file, len(manifest.Files), path)
+			}
+		case !info.IsDir():
+			// Sockets, devices and pipes are not part of a Go installation
+			return nil
+		}
+		if file.Xattrs, err = readXattrs(path); err != nil {
+			return fmt.Errorf("failed to read the extended attributes of %s: %v", path, err)
+		}
+		manifest.Files = append(manifest.Files, file)
+		return nil
+	})
+	return manifest, err
+}
+
+func writeTreeManifest(path string, manifest treeManifest) error {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func storeFile(s chunkStore, path string) (int64, []string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+	var written int64
+	var chunks []string
+	reader := newChunkReader(f)
+	for {
+		data, err := reader.next()
+		if err == io.EOF {
+			return written, chunks, nil
+		}
+		if err != nil {
+			return written, nil, err
+		}
+		sum, n, err := s.put(data)
+		if err != nil {
+			return written, nil, err
+		}
+		written += n
+		chunks = append(chunks, sum)
+	}
+}
+
+func readTreeManifest(path string) (treeManifest, error) {
+	var manifest treeManifest
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read manifest: %v", err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("failed to parse manifest %s: %v", path, err)
+	}
+	if !validTreeRoot(manifest.Root) {
+		return manifest, fmt.Errorf("manifest %s restores to %q, outside the restore directory", path, manifest.Root)
+	}
+	return manifest, nil
+}
+
+// treeSource supplies the contents of the files a manifest lists.
+type treeSource interface {
+	// extract writes file to path, in the tree at root.
+	extract(file treeFile, root, path string, p *restoreProgress) error
+}
+
+// extractTree recreates the installation of manifest under parent, as
+// parent/<root>, with file contents from s. Files an earlier, stopped
+// extraction completed are kept.
+func extractTree(s treeSource, manifest treeManifest, parent string, p *restoreProgress) error {
+	if !validTreeRoot(manifest.Root) {
+		return fmt.Errorf("manifest root %q leaves the restore directory", manifest.Root)
+	}
+	root := filepath.Join(parent, manifest.Root)
+	if err := mkdirUnpacked(root); err != nil {
+		return err
+	}
+	var dirs []dirTimes
+	for _, file := range manifest.Files {
+		if err := p.stopped(); err != nil {
+			return err
+		}
+		path, err := unpackPath(root, file.Path)
+		if err != nil {
+			return fmt.Errorf("manifest %v", err)
+		}
+		switch {
+		case file.Mode.IsDir():
+			if err := mkdirUnpacked(path); err != nil {
+				return err
+			}
+			dirs = append(dirs, dirTimes{path, file.Mode & chmodBits, file.ModTime})
+		case file.Mode&fs.ModeSymlink != 0:
+			if err := clearForUnpack(path); err != nil {
+				return err
+			}
+			if err := os.Symlink(file.Link, path); err != nil {
+				return err
+			}
+			lchtimes(path, file.ModTime)
+			p.file(int64(len(file.Link)), false)
+		case file.HardLink != "":
+			linked, err := unpackPath(root, file.HardLink)
+			if err != nil {
+				return fmt.Errorf("manifest link %v", err)
+			}
+			if err := clearForUnpack(path); err != nil {
+				return err
+			}
+			// Walk order puts the first name before the others
+			if err := os.Link(linked, path); err != nil {
+				return err
+			}
+			p.file(file.Size, false)
+		case alreadyUnpacked(path, file.Size, file.ModTime):
+			p.file(file.Size, true)
+		default:
+			if err := clearForUnpack(path); err != nil {
+				return err
+			}
+			if err := s.extract(file, root, path, p); err != nil {
+				return err
+			}
+			p.file(file.Size, false)
+		}
+	}
+	setDirModes(dirs)
+	p.finish()
+	return nil
+}
+
+// extract writes file to path, in the tree at root, from its chunks.
+func (s chunkStore) extract(file treeFile, root, path string, p *restoreProgress) error {
+	return writeUnpackedFile(path, file.Mode&chmodBits, file.ModTime, func() error {
+		f, err := createUnpacked(root, path, file.Mode.Perm()|0200)
+		if err != nil {
+			return err
+		}
+		for _, sum := range file.Chunks {
+			if err := p.stopped(); err != nil {
+				f.Close()
+				return err
+			}
+			data, err := s.get(sum)
+			if err != nil {
+				f.Close()
+				return err
+			}
+			if _, err := f.Write(data); err != nil {
+				f.Close()
+				return err
+			}
+		}
+		return f.Close()
+	})
+}
+
+// collectChunks deletes the chunks no manifest of the sets in backupDir
+// refers to, and returns how many bytes that freed. A set whose manifests
+// cannot be read stops the collection, so its chunks are never lost.
+func collectChunks(backupDir string) (int64, error) {
+	store := openChunkStore(backupDir)
+	if _, err := os.Stat(store.dir); os.IsNotExist(err) {
+		return 0, nil
+	}
+	live := make(map[string]bool)
+	sets, err := listBackupSets(backupDir)
+	if err != nil {
+		return 0, err
+	}
+	for _, set := range sets {
+		for _, entry := range set.index.Entries {
+			if entry.Format != backupFormatDedup {
+				continue
+			}
+			manifest, err := readTreeManifest(filepath.Join(set.dir, entry.Archive))
+			if err != nil {
+				return 0, err
+			}
+			for _, file := range manifest.Files {
+				for _, sum := range file.Chunks {
+					live[sum] = true
+				}
+			}
+		}
+	}
+	var freed int64
+	err = filepath.WalkDir(store.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || live[d.Name()] {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := fsys.Remove(path); err != nil {
+			return err
+		}
+		freed += info.Size()
+		return nil
+	})
+	return freed, err
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestChunkReaderFindsTheSameBoundariesAfterAnInsertion(t *testing.T) {
+	data := make([]byte, 6<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	chunks := func(data []byte) map[string]bool {
+		seen := make(map[string]bool)
+		reader := newChunkReader(bytes.NewReader(data))
+		total := 0
+		for {
+			chunk, err := reader.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(chunk) > maxChunkSize {
+				t.Fatalf("Chunk of %d bytes is over the maximum", len(chunk))
+			}
+			total += len(chunk)
+			seen[string(chunk)] = true
+		}
+		if total != len(data) {
+			t.Fatalf("Chunks add up to %d bytes, expected %d", total, len(data))
+		}
+		return seen
+	}
+	before := chunks(data)
+	after := chunks(append([]byte("inserted"), data...))
+	shared := 0
+	for chunk := range after {
+		if before[chunk] {
+			shared++
+		}
+	}
+	if len(before) < 2 || shared < len(before)-1 {
+		t.Errorf("Expected all but the first of %d chunks to survive an insertion, %d did", len(before), shared)
+	}
+}
+
+func TestDedupBackupRoundTrip(t *testing.T) {
+	skipInAuditBuild(t)
+	root := fakeGoRoot(t, "VERSION", "bin/go")
+	big := make([]byte, 3<<20)
+	rand.New(rand.NewSource(2)).Read(big)
+	os.WriteFile(filepath.Join(root, "pkg.a"), big, 0644)
+	os.Symlink("bin/go", filepath.Join(root, "go"))
+	backups := t.TempDir()
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+
+	first, err := writeBackupSet(backups, backupFormatDedup, []GoInstallation{{Path: root}}, now)
+	if err != nil {
+		t.Fatalf("writeBackupSet returned error: %v", err)
+	}
+	second, err := writeBackupSet(backups, backupFormatDedup, []GoInstallation{{Path: root}}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("writeBackupSet returned error: %v", err)
+	}
+	index, _ := readBackupIndex(second)
+	if entry := index.Entries[0]; entry.Format != backupFormatDedup || entry.ArchiveSize != 0 {
+		t.Errorf("Expected an unchanged installation to store no new chunks, got %+v", entry)
+	}
+	firstIndex, _ := readBackupIndex(first)
+	if firstIndex.Entries[0].ArchiveSize < 1<<20 {
+		t.Errorf("Expected the first backup to store the chunks, wrote %d bytes", firstIndex.Entries[0].ArchiveSize)
+	}
+
+	manifest, err := readTreeManifest(filepath.Join(second, index.Entries[0].Archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := t.TempDir()
+	if err := extractTree(openChunkStore(backups), manifest, dest, nil); err != nil {
+		t.Fatalf("extractTree returned error: %v", err)
+	}
+	restored := filepath.Join(dest, filepath.Base(root))
+	if data, err := os.ReadFile(filepath.Join(restored, "pkg.a")); err != nil || !bytes.Equal(data, big) {
+		t.Errorf("Expected pkg.a to be restored byte for byte: %v", err)
+	}
+	if link, err := os.Readlink(filepath.Join(restored, "go")); err != nil || link != "bin/go" {
+		t.Errorf("Expected the link to be restored, got %q, %v", link, err)
+	}
+
+	// Pruning the first set keeps every chunk the second still needs
+	if _, _, err := pruneBackupSets(backups, RetentionConfig{KeepLast: 1}, now.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	os.RemoveAll(restored)
+	if err := extractTree(openChunkStore(backups), manifest, dest, nil); err != nil {
+		t.Errorf("Expected the remaining set to restore after pruning: %v", err)
+	}
+	os.RemoveAll(second)
+	saved := fsys
+	recorder := &recordingFS{fileSystem: saved}
+	fsys = recorder
+	defer func() { fsys = saved }()
+	if freed, err := collectChunks(backups); err != nil || freed == 0 {
+		t.Errorf("Expected unreferenced chunks to be collected, freed %d, %v", freed, err)
+	}
+	if len(recorder.changed) == 0 {
+		t.Errorf("Expected the chunks to be removed through the file system seam")
+	}
+}
+
+func TestExtractTreeRefusesEscapes(t *testing.T) {
+	outside := t.TempDir()
+	os.Chmod(outside, 0700)
+	dir := func(path string) treeFile { return treeFile{Path: path, Mode: fs.ModeDir | 0755} }
+	tests := []struct {
+		name     string
+		manifest treeManifest
+	}{
+		{"root dot dot", treeManifest{Root: "..", Files: []treeFile{{Path: "evil", Mode: 0644}}}},
+		{"root absolute", treeManifest{Root: outside, Files: []treeFile{{Path: "evil", Mode: 0644}}}},
+		{"root with a separator", treeManifest{Root: "go/../..", Files: []treeFile{{Path: "evil", Mode: 0644}}}},
+		{"uncleaned path", treeManifest{Root: "go", Files: []treeFile{dir("a"), {Path: "a/../../evil", Mode: 0644}}}},
+		{"through a symlink", treeManifest{Root: "go", Files: []treeFile{
+			{Path: "lib", Mode: fs.ModeSymlink | 0777, Link: outside},
+			{Path: "lib/evil", Mode: 0644},
+		}}},
+		{"directory through a symlink", treeManifest{Root: "go", Files: []treeFile{
+			{Path: "lib", Mode: fs.ModeSymlink | 0777, Link: outside},
+			dir("lib/sub"),
+		}}},
+		{"hard link outside", treeManifest{Root: "go", Files: []treeFile{{Path: "evil", Mode: 0644, HardLink: "../../passwd"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := t.TempDir()
+			if err := extractTree(openChunkStore(t.TempDir()), tt.manifest, dest, nil); err == nil {
+				t.Errorf("Expected the manifest to be refused")
+			}
+			if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+				t.Errorf("Expected nothing written outside, found %v", entries)
+			}
+			if _, err := os.Lstat(filepath.Join(filepath.Dir(dest), "evil")); err == nil {
+				t.Errorf("Expected nothing written next to the restore directory")
+			}
+			if info, _ := os.Stat(outside); info.Mode().Perm() != 0700 {
+				t.Errorf("Expected the outside directory mode to be left alone, got %v", info.Mode())
+			}
+		})
+	}
+}
+
+// treeMetadata describes every file under root: type, mode, time, content
+// or link target, extended attributes, and which earlier file it is a hard
+// link to.
+func treeMetadata(t *testing.T, root string) []string {
+	t.Helper()
+	var lines []string
+	links := make(map[fileKey]string)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		line := fmt.Sprintf("%s %v %s", filepath.ToSlash(rel), info.Mode(), info.ModTime().UTC().Format(time.RFC3339))
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			target, _ := os.Readlink(path)
+			line += " -> " + target
+		case info.Mode().IsRegular():
+			data, _ := os.ReadFile(path)
+			line += fmt.Sprintf(" %x", sha256.Sum256(data))
+			if key, ok := hardLinkKey(info); ok {
+				if first, seen := links[key]; seen {
+					line += " = " + first
+				}
+				links[key] = filepath.ToSlash(rel)
+			}
+		}
+		attrs, _ := readXattrs(path)
+		for _, name := range slices.Sorted(maps.Keys(attrs)) {
+			line += fmt.Sprintf(" %s=%q", name, attrs[name])
+		}
+		lines = append(lines, line)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", root, err)
+	}
+	return lines
+}
+
+func TestBackupFidelityRoundTrip(t *testing.T) {
+	for _, format := range []string{backupFormatArchive, backupFormatDedup, backupFormatQuarantine} {
+		t.Run(format, func(t *testing.T) {
+			root := filepath.Join(t.TempDir(), "go")
+			os.MkdirAll(filepath.Join(root, "pkg", "tool"), 0755)
+			os.MkdirAll(filepath.Join(root, "empty"), 0700)
+			os.WriteFile(filepath.Join(root, "VERSION"), []byte("go1.22.5\n"), 0444)
+			os.WriteFile(filepath.Join(root, "pkg", "tool", "compile"), []byte("compile"), 0755)
+			os.Link(filepath.Join(root, "pkg", "tool", "compile"), filepath.Join(root, "pkg", "tool", "compile.link"))
+			os.Symlink("tool/compile", filepath.Join(root, "pkg", "compile"))
+			os.Symlink("missing", filepath.Join(root, "pkg", "dangling"))
+			os.Chmod(filepath.Join(root, "pkg", "tool"), 0755|fs.ModeSetgid)
+			writeXattr(filepath.Join(root, "VERSION"), "user.fugo.label", []byte("usr_t"))
+
+			// Fixed times, directories last so their contents do not move them
+			mtime := time.Date(2024, 6, 4, 17, 0, 0, 0, time.UTC)
+			var dirs []string
+			filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+				switch {
+				case err != nil:
+					return err
+				case info.IsDir():
+					dirs = append(dirs, path)
+				case info.Mode()&fs.ModeSymlink != 0:
+					lchtimes(path, mtime)
+				default:
+					os.Chtimes(path, mtime, mtime)
+				}
+				return nil
+			})
+			for _, dir := range slices.Backward(dirs) {
+				os.Chtimes(dir, mtime, mtime)
+			}
+			before := treeMetadata(t, root)
+
+			backups := t.TempDir()
+			dir, err := writeBackupSet(backups, format, []GoInstallation{{Path: root}}, time.Now())
+			if err != nil {
+				t.Fatalf("writeBackupSet returned error: %v", err)
+			}
+			set, _ := findBackupSet(backups, filepath.Base(dir))
+			os.Chmod(filepath.Join(root, "VERSION"), 0644)
+			if err := os.RemoveAll(root); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := restoreBackupSet(set, restoreOptions{}, time.Now()); err != nil {
+				t.Fatalf("restoreBackupSet returned error: %v", err)
+			}
+			if after := treeMetadata(t, root); !slices.Equal(after, before) {
+				t.Errorf("Expected an identical tree.\nbacked up: %q\nrestored:  %q", before, after)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd || openbsd || netbsd || dragonfly)
+
+package main
+
+// removalBlocker has no platform checks here; failures surface at removal.
+func removalBlocker(root string) string {
+	return ""
+}
@@ -0,0 +1,39 @@
+//go:build linux || darwin || freebsd || openbsd || netbsd || dragonfly
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// removalBlocker explains why root cannot be removed at all, or returns "" if
+// nothing is in the way. Elevation does not help with any of these.
+func removalBlocker(root string) string {
+	if reason := currentPlatform.protection(root); reason != "" {
+		return reason
+	}
+	if err := unix.Faccessat(unix.AT_FDCWD, root, unix.W_OK, unix.AT_EACCESS); err == unix.EROFS {
+		return "on a read-only file system"
+	}
+
+	var blocker string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		if reason := currentPlatform.protection(path); reason != "" {
+			blocker = fmt.Sprintf("%s is %s", path, reason)
+			return filepath.SkipAll
+		}
+		if reason := fileFlagsBlocker(path); reason != "" {
+			blocker = fmt.Sprintf("%s is %s", path, reason)
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return blocker
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/filepicker"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dirBrowser picks a directory with the bubbles file picker, so paths are
+// chosen rather than typed. Enter picks the highlighted directory, s picks
+// the one being shown, . toggles hidden entries.
+type dirBrowser struct {
+	picker filepicker.Model
+	title  string
+}
+
+// browsePurpose says what the chosen directory is for.
+type browsePurpose int
+
+const (
+	browseManualPath browsePurpose = iota
+	browseBackupDir
+)
+
+func newDirBrowser(title, start string, height int) dirBrowser {
+	picker := filepicker.New()
+	picker.CurrentDirectory = browseStart(start)
+	picker.DirAllowed = true
+	picker.FileAllowed = false
+	picker.ShowPermissions = false
+	picker.AutoHeight = false
+	picker.Height = height
+	// esc leaves the browser instead of going up a level
+	picker.KeyMap.Back = key.NewBinding(key.WithKeys("h", "backspace", "left"))
+	picker.Styles.Selected = highlightStyle
+	picker.Styles.Directory = infoStyle
+	return dirBrowser{picker: picker, title: title}
+}
+
+// browseStart walks up from start to the nearest directory that exists.
+func browseStart(start string) string {
+	if start == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			start = home
+		} else {
+			start = string(filepath.Separator)
+		}
+	}
+	dir := filepath.Clean(start)
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+func (b dirBrowser) Init() tea.Cmd {
+	return b.picker.Init()
+}
+
+// Update forwards msg to the picker and reports the chosen directory, if the
+// user picked one.
+func (b dirBrowser) Update(msg tea.Msg) (dirBrowser, tea.Cmd, string) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case ".":
+			b.picker.ShowHidden = !b.picker.ShowHidden
+			return b, b.picker.Init(), ""
+		case "s":
+			return b, nil, b.picker.CurrentDirectory
+		}
+	}
+	var cmd tea.Cmd
+	b.picker, cmd = b.picker.Update(msg)
+	if ok, path := b.picker.DidSelectFile(msg); ok {
+		return b, cmd, path
+	}
+	return b, cmd, ""
+}
+
+// breadcrumbs renders the current directory one segment at a time.
+func (b dirBrowser) breadcrumbs() string {
+	dir := filepath.Clean(b.picker.CurrentDirectory)
+	volume := filepath.VolumeName(dir)
+	var segments []string
+	for _, part := range strings.Split(strings.TrimPrefix(dir, volume), string(filepath.Separator)) {
+		if part != "" {
+			segments = append(segments, part)
+		}
+	}
+	return volume + string(filepath.Separator) + strings.Join(segments, " › ")
+}
+
+func (b dirBrowser) View() string {
+	s := highlightStyle.Render(b.title) + "\n"
+	s += infoStyle.Render("📂 "+b.breadcrumbs()) + "\n\n"
+	s += b.picker.View() + "\n"
+	hidden := "show"
+	if b.picker.ShowHidden {
+		hidden = "hide"
+	}
+	s += infoStyle.Render(fmt.Sprintf("↑/↓ move · →/l open · ←/h up · enter pick highlighted · s pick this directory · . %s hidden · esc back", hidden)) + "\n"
+	return s
+}
+
+// startBrowse opens the directory browser from the confirm screen.
+func (m model) startBrowse(purpose browsePurpose) (tea.Model, tea.Cmd) {
+	title, start := "➕ Choose a Go installation to add", ""
+	if purpose == browseBackupDir {
+		title, start = "💾 Choose the backup destination", m.backupPath
+	}
+	height := m.height - 12
+	if height < 5 {
+		height = 10
+	}
+	m.browser = newDirBrowser(title, start, height)
+	m.browsePurpose = purpose
+	m.state = "browse"
+	return m, m.browser.Init()
+}
+
+func (m model) handleBrowseKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		if m.logFile != nil {
+			m.logFile.Log("INFO", "User cancelled operation")
+			m.logFile.Close()
+		}
+		return m, tea.Quit
+	case "esc":
+		m.state = "confirm"
+		return m, nil
+	case "tab":
+		// Typing stays available for paths the browser cannot reach
+		if m.browsePurpose == browseManualPath {
+			return m.startAddPath()
+		}
+	}
+
+	var cmd tea.Cmd
+	var chosen string
+	m.browser, cmd, chosen = m.browser.Update(msg)
+	if chosen == "" {
+		return m, cmd
+	}
+	return m.browseChosen(chosen)
+}
+
+// browseChosen hands the picked directory to whatever opened the browser.
+func (m model) browseChosen(dir string) (tea.Model, tea.Cmd) {
+	switch m.browsePurpose {
+	case browseBackupDir:
+		m = m.setBackupDir(dir)
+		return m, m.remoteBackupCmd()
+	default:
+		next, _ := m.startAddPath()
+		m = next.(model)
+		m.pathInput.SetValue(dir)
+		m.addPathBusy = true
+		return m, tea.Batch(m.spinner.Tick, inspectManualPathCmd(dir, m.detectedInstalls, m.config.AllowCrossMounts))
+	}
+}
+
+// setBackupDir switches the backup destination and re-checks it.
+func (m model) setBackupDir(dir string) model {
+	m.backupPath = dir
+	m.remoteBackup = nil
+	m.state = "confirm"
+	backup := runPreflight(nil, dir)[0]
+	if n := len(m.preflight); n > 0 && m.preflight[n-1].Role == "backup" {
+		m.preflight = append(append([]preflightResult{}, m.preflight[:n-1]...), backup)
+	} else {
+		m.preflight = append(m.preflight, backup)
+	}
+	if m.logFile != nil {
+		m.logFile.Log("INFO", fmt.Sprintf("Backup destination changed to %s", dir))
+	}
+	return m
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestBrowseStart(t *testing.T) {
+	tempDir := t.TempDir()
+	if got := browseStart(filepath.Join(tempDir, "missing", "deeper")); got != tempDir {
+		t.Errorf("browseStart(missing) = %s, expected %s", got, tempDir)
+	}
+	if got := browseStart(tempDir); got != tempDir {
+		t.Errorf("browseStart(%s) = %s, expected the directory itself", tempDir, got)
+	}
+}
+
+func TestDirBrowserPicksDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, dir := range []string{"go", ".hidden"} {
+		if err := os.Mkdir(filepath.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	b := newDirBrowser("Pick", tempDir, 10)
+	b, _, _ = b.Update(b.Init()())
+	if view := b.View(); strings.Contains(view, ".hidden") || !strings.Contains(view, "go") {
+		t.Errorf("Expected hidden entries to start hidden, got:\n%s", view)
+	}
+
+	var cmd tea.Cmd
+	b, cmd, _ = b.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'.'}})
+	b, _, _ = b.Update(cmd())
+	if view := b.View(); !strings.Contains(view, ".hidden") {
+		t.Errorf("Expected . to show hidden entries, got:\n%s", view)
+	}
+
+	_, _, chosen := b.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	if chosen != tempDir {
+		t.Errorf("Expected s to pick %s, got %q", tempDir, chosen)
+	}
+
+	// Hidden entries sort first, so move down to "go"
+	b, _, _ = b.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, _, chosen = b.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if expected := filepath.Join(tempDir, "go"); chosen != expected {
+		t.Errorf("Expected enter to pick %s, got %q", expected, chosen)
+	}
+}
+
+func TestSetBackupDir(t *testing.T) {
+	m := newListTestModel()
+	m.preflight = []preflightResult{{Path: "/usr/local/go", Role: "installation"}, {Path: "/old", Role: "backup"}}
+	dir := t.TempDir()
+
+	m = m.setBackupDir(dir)
+	if m.backupPath != dir || m.state != "confirm" {
+		t.Errorf("Expected backup path %s in confirm, got %s in %s", dir, m.backupPath, m.state)
+	}
+	if len(m.preflight) != 2 || m.preflight[1].Path != dir || m.preflight[1].Err != nil {
+		t.Errorf("Expected the backup preflight to be re-run for %s, got %+v", dir, m.preflight)
+	}
+}
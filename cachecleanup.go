@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Build agents fill their disks with GOCACHE and the module cache long before
+// anyone thinks of removing Go itself. clean-cache empties either cache once
+// it grows past the limit set in config.json, and never touches toolchains,
+// so it is safe to run unattended (see fu-go schedule).
+
+// parseByteSize reads sizes such as "512MB", "10GiB" or "1.5G". Units are
+// binary, matching how fu-go prints sizes.
+func parseByteSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	value = strings.TrimSuffix(strings.TrimSuffix(value, "B"), "I")
+	multiplier := int64(1)
+	if n := len(value); n > 0 {
+		if i := strings.IndexByte("KMGT", value[n-1]); i >= 0 {
+			multiplier = int64(1) << (10 * (i + 1))
+			value = value[:n-1]
+		}
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size %q (expected e.g. 512MB or 10GB)", s)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+// cacheTarget is a cache clean-cache may empty. Limit 0 means no limit was
+// configured.
+type cacheTarget struct {
+	Name  string
+	Path  string
+	Limit int64
+}
+
+func cacheTargets(cfg Config, env goEnv) []cacheTarget {
+	var targets []cacheTarget
+	if dir := env.get("GOCACHE"); dir != "" && dir != "off" {
+		limit, _ := parseByteSize(cfg.GoCacheLimit)
+		targets = append(targets, cacheTarget{Name: "GOCACHE", Path: dir, Limit: limit})
+	}
+	if dir := env.get("GOMODCACHE"); dir != "" {
+		limit, _ := parseByteSize(cfg.ModCacheLimit)
+		targets = append(targets, cacheTarget{Name: "GOMODCACHE", Path: dir, Limit: limit})
+	}
+	return targets
+}
+
+// makeTreeWritable gives the owner full access to every directory under
+// root. The go command makes module cache directories read-only, which stops
+// even their owner from removing them. Directories of other users are left
+// as they are, and show up in the removal check that follows.
+func makeTreeWritable(root string) {
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Mode().Perm()&0700 != 0700 {
+			fsys.Chmod(path, info.Mode().Perm()|0700)
+		}
+		return nil
+	})
+}
+
+// emptyCache removes everything inside dir but keeps dir itself, making the
+// module cache's read-only directories writable first.
+func emptyCache(dir string) error {
+	makeTreeWritable(dir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := fsys.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanCaches empties each target over its limit, or every target with all,
+// and adds what it freed, or would free in a dry run, to report.
+func cleanCaches(out eventWriter, targets []cacheTarget, all bool, report *changeReport) error {
+	if currentPolicy().DenyCacheCleanup {
+		return fmt.Errorf("cache cleanup is disabled by the machine policy")
+	}
+	var items []PlanItem
+	for _, target := range targets {
+		size, files := dirUsage(target.Path)
+		switch {
+		case size == 0:
+			out.emit("skip", fmt.Sprintf("%s (%s) is empty", target.Name, target.Path), "name", target.Name, "path", target.Path, "bytes", size, "reason", "empty")
+			continue
+		case !all && target.Limit == 0:
+			out.emit("skip", fmt.Sprintf("%s (%s) is %s; no limit configured, skipping", target.Name, target.Path, formatBytes(size)), "name", target.Name, "path", target.Path, "bytes", size, "reason", "no-limit")
+			continue
+		case !all && size <= target.Limit:
+			out.emit("skip", fmt.Sprintf("%s (%s) is %s, within its %s limit", target.Name, target.Path, formatBytes(size), formatBytes(target.Limit)), "name", target.Name, "path", target.Path, "bytes", size, "reason", "within-limit", "limit", target.Limit)
+			continue
+		}
+		items = append(items, cacheItem{target: target, size: size, files: files})
+	}
+
+	progress := startItemProgress(out.events, phaseClean, items)
+	for _, item := range items {
+		target, size := item.(cacheItem).target, item.Size()
+		if report.DryRun {
+			out.emit("empty", fmt.Sprintf("Would empty %s (%s), freeing %s", target.Name, target.Path, formatBytes(size)), "name", target.Name, "path", target.Path, "bytes", size, "dry_run", true)
+			report.add("empty "+target.Path, size)
+			progress.finish(itemResult{item: item, skipped: "dry run"})
+			continue
+		}
+		start := time.Now()
+		err := item.Execute(planEnv{})
+		progress.finish(itemResult{item: item, err: err, duration: time.Since(start)})
+		if err != nil {
+			return fmt.Errorf("failed to empty %s: %v", target.Path, err)
+		}
+		report.add("empty "+target.Path, size)
+		out.emit("empty", fmt.Sprintf("Emptied %s (%s), freed %s", target.Name, target.Path, formatBytes(size)), "name", target.Name, "path", target.Path, "bytes", size, "dry_run", false)
+	}
+	return nil
+}
+
+// runCacheCleanup cleans the caches as configured: by free space when
+// disk_pressure is set, otherwise by their size limits. all overrides both.
+func runCacheCleanup(out eventWriter, cfg Config, all bool, report *changeReport) error {
+	targets := cacheTargets(cfg, currentGoEnv())
+	if all || cfg.DiskPressure.FreeBelow == "" {
+		return cleanCaches(out, targets, all, report)
+	}
+	threshold, err := parseFreeThreshold(cfg.DiskPressure.FreeBelow)
+	if err != nil {
+		return err
+	}
+	volume, err := pressureVolume(cfg.DiskPressure, targets)
+	if err != nil {
+		return err
+	}
+	return relieveDiskPressure(out, targets, volume, threshold, report)
+}
+
+func newCleanCacheCmd(opts *runOptions) *cobra.Command {
+	var all, dryRun, check, eventLog bool
+	var pressure PressureConfig
+	cmd := &cobra.Command{
+		Use:   "clean-cache",
+		Short: "Empty GOCACHE and the module cache once they pass their size limits",
+		Long:  "clean-cache runs without the TUI and only ever touches GOCACHE and GOMODCACHE. Each is emptied when it is\nlarger than gocache_limit or gomodcache_limit in the config; caches without a limit are left alone unless --all\nis given. With --free-below (or disk_pressure in the config) the limits are ignored: nothing happens until the\nvolume runs low, then the largest caches on it are emptied until the threshold is met.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if pressure.FreeBelow != "" {
+				cfg.DiskPressure.FreeBelow = pressure.FreeBelow
+			}
+			if pressure.Volume != "" {
+				cfg.DiskPressure.Volume = pressure.Volume
+			}
+			if err := cfg.DiskPressure.validate(); err != nil {
+				return err
+			}
+			if lock := liveModeLock(); lock != "" && !dryRun {
+				return fmt.Errorf("live removals are disabled: %s", lock)
+			}
+			paths, err := resolvePaths(cfg)
+			if err != nil {
+				return err
+			}
+			logger, err := newConfiguredLogger(*opts, paths.Logs)
+			if err != nil {
+				return err
+			}
+			defer logger.Close()
+			var events systemLog
+			if eventLog {
+				if events, err = openSystemLog(); err != nil {
+					return err
+				}
+				defer events.Close()
+			}
+			out := newEventWriter(cmd.OutOrStdout(), *opts)
+			stop := startHeadlessEvents(&out, *opts, cmd.ErrOrStderr())
+			defer stop()
+			report := newChangeReport(check, dryRun)
+			run := cleanupRun{Started: time.Now(), Report: report}
+			err = runCacheCleanup(out, cfg, all, report)
+			if events != nil {
+				if err != nil {
+					run.Error = err.Error()
+				}
+				run.DurationMS = time.Since(run.Started).Milliseconds()
+				logRun(events, run)
+			}
+			if err != nil {
+				logger.Log("ERROR", fmt.Sprintf("Cache cleanup failed: %v", err))
+				return err
+			}
+			logger.Log("SUCCESS", fmt.Sprintf("Cache cleanup freed %s (dry run: %v)", formatBytes(report.FreedBytes), report.DryRun))
+			return report.finish(out)
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "empty every cache regardless of its limit")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "report what would be freed without deleting")
+	cmd.Flags().StringVar(&pressure.FreeBelow, "free-below", "", "only act when free space drops below this share or size, e.g. 10% or 20GB")
+	cmd.Flags().StringVar(&pressure.Volume, "volume", "", "the volume --free-below watches, by default the one holding GOCACHE")
+	cmd.MarkFlagsMutuallyExclusive("all", "free-below")
+	cmd.Flags().BoolVar(&eventLog, "event-log", false, "Windows: also report the run to the Event Log")
+	addCheckFlag(cmd, &check)
+	return cmd
+}
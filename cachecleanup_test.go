@@ -0,0 +1,95 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	testCases := map[string]int64{
+		"512":    512,
+		"512B":   512,
+		"4KB":    4 << 10,
+		"10GiB":  10 << 30,
+		"1.5G":   3 << 29,
+		" 2 tb ": 2 << 40,
+	}
+	for input, want := range testCases {
+		if got, err := parseByteSize(input); err != nil || got != want {
+			t.Errorf("parseByteSize(%q) = %d, %v; want %d", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "lots", "-1G", "GB"} {
+		if _, err := parseByteSize(input); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
+
+// fakeCache fills a cache directory with size bytes, including a read-only
+// directory like the ones the go command leaves in the module cache.
+func fakeCache(t *testing.T, size int) string {
+	t.Helper()
+	dir := t.TempDir()
+	locked := filepath.Join(dir, "golang.org", "x", "mod@v0.17.0")
+	if err := os.MkdirAll(locked, 0755); err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(locked, "go.mod"), make([]byte, size), 0444); err != nil {
+		t.Fatalf("Failed to fill cache: %v", err)
+	}
+	os.Chmod(locked, 0555)
+	t.Cleanup(func() { os.Chmod(locked, 0755) })
+	return dir
+}
+
+func TestCleanCaches(t *testing.T) {
+	skipInAuditBuild(t)
+	small, big, unlimited := fakeCache(t, 100), fakeCache(t, 5000), fakeCache(t, 5000)
+	targets := []cacheTarget{
+		{Name: "small", Path: small, Limit: 1000},
+		{Name: "big", Path: big, Limit: 1000},
+		{Name: "unlimited", Path: unlimited},
+	}
+
+	var out strings.Builder
+	dry := newChangeReport(false, true)
+	if err := cleanCaches(eventWriter{w: &out}, targets, false, dry); err != nil || dry.FreedBytes != 5000 {
+		t.Fatalf("Expected a dry run to report 5000 bytes, got %d, %v", dry.FreedBytes, err)
+	}
+	if getDirSize(big) != 5000 {
+		t.Errorf("Expected a dry run to leave %s alone", big)
+	}
+
+	report := newChangeReport(false, false)
+	if err := cleanCaches(eventWriter{w: io.Discard}, targets, false, report); err != nil || report.FreedBytes != 5000 {
+		t.Fatalf("Expected 5000 bytes freed, got %d, %v", report.FreedBytes, err)
+	}
+	if getDirSize(big) != 0 || getDirSize(small) != 100 || getDirSize(unlimited) != 5000 {
+		t.Errorf("Expected only the cache over its limit to be emptied")
+	}
+	if _, err := os.Stat(big); err != nil {
+		t.Errorf("Expected the cache directory itself to remain: %v", err)
+	}
+
+	withPolicy(t, Policy{DenyCacheCleanup: true})
+	if err := cleanCaches(eventWriter{w: io.Discard}, targets, true, newChangeReport(false, false)); err == nil {
+		t.Errorf("Expected the policy to refuse cache cleanup")
+	}
+}
+
+func TestCleanCacheHonoursLiveModeLock(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_STATE_HOME", "")
+	withPolicy(t, Policy{DryRunOnly: true})
+	cmd := newCleanCacheCmd(&runOptions{})
+	cmd.SetArgs([]string{"--all"})
+	cmd.SetOut(io.Discard)
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "live removals are disabled") {
+		t.Errorf("Expected the policy to refuse a live cleanup, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The last confirmation step is a challenge. By default it is typing
+// DESTROY, but managed machines can require proof that the operator is
+// authorised: a TOTP code from an admin-provisioned secret, an approval token
+// file dropped by a change-management system, or an operator passphrase.
+// Secrets are read only when the challenge is answered, so a missing or
+// unreadable file fails the step instead of weakening it.
+
+// ChallengeConfig selects the final challenge in config.json or the machine policy.
+type ChallengeConfig struct {
+	// Type is "destroy" (the default), "totp", "approval_token" or
+	// "passphrase".
+	Type string `json:"type,omitempty"`
+	// File is the TOTP secret (base32), the approval token, or the SHA-256
+	// of the passphrase (hex), depending on Type.
+	File string `json:"file,omitempty"`
+}
+
+var challengeTypes = []string{"destroy", "totp", "approval_token", "passphrase"}
+
+func (c ChallengeConfig) validate() error {
+	switch c.Type {
+	case "", "destroy":
+		return nil
+	case "totp", "approval_token", "passphrase":
+		if !filepath.IsAbs(c.File) {
+			return fmt.Errorf("final_challenge %s needs an absolute file, got %q", c.Type, c.File)
+		}
+		return nil
+	}
+	return fmt.Errorf("final_challenge type must be one of %s, got %q", strings.Join(challengeTypes, ", "), c.Type)
+}
+
+// challenge is the final confirmation step.
+type challenge interface {
+	// prompt is shown as the input placeholder.
+	prompt() string
+	// masked hides what is typed.
+	masked() bool
+	verify(input string, now time.Time) error
+}
+
+func newChallenge(cfg ChallengeConfig) challenge {
+	switch cfg.Type {
+	case "totp":
+		return totpChallenge{secretFile: cfg.File}
+	case "approval_token":
+		return approvalTokenChallenge{path: cfg.File, maxAge: 24 * time.Hour}
+	case "passphrase":
+		return passphraseChallenge{hashFile: cfg.File}
+	}
+	return typedWordChallenge{word: "DESTROY"}
+}
+
+type typedWordChallenge struct {
+	word string
+}
+
+func (c typedWordChallenge) prompt() string { return fmt.Sprintf("Type '%s' to proceed", c.word) }
+func (c typedWordChallenge) masked() bool   { return false }
+
+func (c typedWordChallenge) verify(input string, now time.Time) error {
+	if strings.ToUpper(input) != c.word {
+		return fmt.Errorf("expected %s", c.word)
+	}
+	return nil
+}
+
+// totpChallenge accepts an RFC 6238 code (SHA-1, 30 seconds, 6 digits) from
+// the current step or either neighbour, to allow for clock drift.
+type totpChallenge struct {
+	secretFile string
+}
+
+func (c totpChallenge) prompt() string { return "Enter the 6-digit authenticator code" }
+func (c totpChallenge) masked() bool   { return false }
+
+func (c totpChallenge) verify(input string, now time.Time) error {
+	data, err := os.ReadFile(c.secretFile)
+	if err != nil {
+		return fmt.Errorf("failed to read TOTP secret: %v", err)
+	}
+	encoded := strings.ToUpper(strings.Join(strings.Fields(string(data)), ""))
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil || len(secret) == 0 {
+		return fmt.Errorf("invalid TOTP secret in %s", c.secretFile)
+	}
+	step := now.Unix() / 30
+	for _, counter := range []int64{step - 1, step, step + 1} {
+		if subtle.ConstantTimeCompare([]byte(input), []byte(totpCode(secret, counter))) == 1 {
+			return nil
+		}
+	}
+	return fmt.Errorf("wrong authenticator code")
+}
+
+// totpCode is the HOTP value (RFC 4226) of secret at counter.
+func totpCode(secret []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+// approvalTokenChallenge passes when a change-management system has dropped
+// a non-empty token file recently enough.
+type approvalTokenChallenge struct {
+	path   string
+	maxAge time.Duration
+}
+
+func (c approvalTokenChallenge) prompt() string {
+	return "Press enter once the approval token is in place"
+}
+func (c approvalTokenChallenge) masked() bool { return false }
+
+func (c approvalTokenChallenge) verify(input string, now time.Time) error {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return fmt.Errorf("no approval token at %s", c.path)
+	}
+	if info.IsDir() || info.Size() == 0 {
+		return fmt.Errorf("approval token %s is empty", c.path)
+	}
+	if age := now.Sub(info.ModTime()); age > c.maxAge {
+		return fmt.Errorf("approval token %s expired %s ago", c.path, (age - c.maxAge).Round(time.Minute))
+	}
+	return nil
+}
+
+// passphraseChallenge compares the SHA-256 of what is typed with the hash an
+// administrator stored, so the passphrase itself is never on disk.
+type passphraseChallenge struct {
+	hashFile string
+}
+
+func (c passphraseChallenge) prompt() string { return "Enter the operator passphrase" }
+func (c passphraseChallenge) masked() bool   { return true }
+
+func (c passphraseChallenge) verify(input string, now time.Time) error {
+	data, err := os.ReadFile(c.hashFile)
+	if err != nil {
+		return fmt.Errorf("failed to read passphrase hash: %v", err)
+	}
+	expected, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(expected) != sha256.Size {
+		return fmt.Errorf("invalid passphrase hash in %s", c.hashFile)
+	}
+	sum := sha256.Sum256([]byte(input))
+	if subtle.ConstantTimeCompare(sum[:], expected) != 1 {
+		return fmt.Errorf("wrong passphrase")
+	}
+	return nil
+}
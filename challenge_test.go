@@ -0,0 +1,135 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func writeSecret(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B, SHA-1, truncated to 6 digits
+	secret := []byte("12345678901234567890")
+	testCases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range testCases {
+		if got := totpCode(secret, unix/30); got != want {
+			t.Errorf("totpCode at %d = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestTOTPChallenge(t *testing.T) {
+	// base32 of "12345678901234567890", written the way authenticator apps show it
+	path := writeSecret(t, "gezd gnbv gy3t qojq gezd gnbv gy3t qojq\n")
+	c := newChallenge(ChallengeConfig{Type: "totp", File: path})
+	now := time.Unix(1111111109, 0)
+
+	if err := c.verify("081804", now); err != nil {
+		t.Errorf("Expected the current code to pass: %v", err)
+	}
+	if err := c.verify("081804", now.Add(30*time.Second)); err != nil {
+		t.Errorf("Expected the previous code to pass: %v", err)
+	}
+	if err := c.verify("081804", now.Add(2*time.Minute)); err == nil {
+		t.Errorf("Expected a stale code to fail")
+	}
+	if err := newChallenge(ChallengeConfig{Type: "totp", File: path + ".missing"}).verify("081804", now); err == nil {
+		t.Errorf("Expected a missing secret to fail")
+	}
+}
+
+func TestApprovalTokenChallenge(t *testing.T) {
+	path := writeSecret(t, "CHG0012345 approved")
+	c := newChallenge(ChallengeConfig{Type: "approval_token", File: path})
+	now := time.Now()
+
+	if err := c.verify("", now); err != nil {
+		t.Errorf("Expected a fresh token to pass: %v", err)
+	}
+	if err := c.verify("", now.Add(25*time.Hour)); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("Expected an old token to fail, got %v", err)
+	}
+	os.Remove(path)
+	if err := c.verify("", now); err == nil {
+		t.Errorf("Expected a missing token to fail")
+	}
+}
+
+func TestPassphraseChallenge(t *testing.T) {
+	sum := sha256.Sum256([]byte("correct horse battery staple"))
+	c := newChallenge(ChallengeConfig{Type: "passphrase", File: writeSecret(t, hex.EncodeToString(sum[:])+"\n")})
+
+	if !c.masked() {
+		t.Errorf("Expected the passphrase to be masked")
+	}
+	if err := c.verify("correct horse battery staple", time.Now()); err != nil {
+		t.Errorf("Expected the passphrase to pass: %v", err)
+	}
+	if err := c.verify("DESTROY", time.Now()); err == nil {
+		t.Errorf("Expected a wrong passphrase to fail")
+	}
+}
+
+func TestChallengeConfigValidate(t *testing.T) {
+	valid := []ChallengeConfig{{}, {Type: "destroy"}, {Type: "totp", File: "/etc/fugo/totp"}}
+	invalid := []ChallengeConfig{{Type: "retina"}, {Type: "passphrase"}, {Type: "approval_token", File: "token"}}
+	for _, c := range valid {
+		if err := c.validate(); err != nil {
+			t.Errorf("Expected %+v to be valid: %v", c, err)
+		}
+	}
+	for _, c := range invalid {
+		if err := c.validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", c)
+		}
+	}
+}
+
+func TestPolicyChallengeWins(t *testing.T) {
+	withPolicy(t, Policy{FinalChallenge: &ChallengeConfig{Type: "passphrase", File: "/etc/fugo/passphrase"}})
+	cfg := Config{FinalChallenge: ChallengeConfig{Type: "destroy"}}
+	if _, ok := cfg.finalChallenge().(passphraseChallenge); !ok {
+		t.Errorf("Expected the policy challenge, got %T", cfg.finalChallenge())
+	}
+}
+
+func TestMaskedKeysNotRecorded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	recorder, err := newSessionRecorder(path)
+	if err != nil {
+		t.Fatalf("newSessionRecorder returned error: %v", err)
+	}
+	m := model{state: "confirm", confirmationStep: ConfirmationStepDestroy, textInput: textinput.New()}
+	m.textInput.EchoMode = textinput.EchoPassword
+	r := recordingModel{model: m, recorder: recorder}
+	r.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	recorder.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read recording: %v", err)
+	}
+	if strings.Contains(string(data), `"key":"s"`) {
+		t.Errorf("Expected the masked key to be redacted, got %s", data)
+	}
+}
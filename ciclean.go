@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// ci-clean is a post-job cleanup step for CI runners that are short on disk:
+// it removes the Go toolchains the job installed under the runner's home
+// (setup-go, go-sdk downloads, GOTOOLCHAIN) and empties the build and module
+// caches there. Nothing outside the home directory is ever touched, no
+// backup is taken and nothing is asked; the JSON summary on stdout says what
+// was freed, and under GitHub Actions the job summary does too.
+
+// ciCleanHome is the home directory ci-clean confines itself to.
+func ciCleanHome() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot find the home directory: %v", err)
+	}
+	if isCriticalPath(home) {
+		return "", fmt.Errorf("refusing to clean with %s as the home directory", home)
+	}
+	return home, nil
+}
+
+// ciClean removes the installations and empties the caches that lie within
+// home, reporting every one it leaves alone and why. Caches the machine
+// policy keeps are skipped like installations are, not treated as a failure.
+func ciClean(out eventWriter, home string, installations []GoInstallation, targets []cacheTarget, report *changeReport) error {
+	var inHome []cacheTarget
+	for _, target := range targets {
+		if target.Path == "" || !withinDir(target.Path, home) || target.Path == home {
+			out.emit("skip", fmt.Sprintf("Leaving %s (%s): outside-home", target.Name, target.Path), "name", target.Name, "path", target.Path, "reason", "outside-home")
+			report.skip(target.Path, "outside-home")
+			continue
+		}
+		inHome = append(inHome, target)
+	}
+
+	policy := currentPolicy()
+	var items []PlanItem
+	for _, install := range installations {
+		reason := ""
+		switch {
+		case !withinDir(install.Path, home) || install.Path == home:
+			reason = "outside-home"
+		case inCache(install.Path, inHome):
+			// Toolchains the go command downloaded go with the module cache
+			reason = "in-cache"
+		case install.PackageManager != "":
+			reason = "package-managed"
+		case install.Blocked != "":
+			reason = "blocked"
+		case policy.blocker(install) != "":
+			reason = "policy"
+		}
+		if reason != "" {
+			out.emit("skip", fmt.Sprintf("Leaving %s (%s): %s", install.Path, install.Source, reason), "path", install.Path, "source", install.Source, "reason", reason)
+			report.skip(install.Path, reason)
+			continue
+		}
+		items = append(items, installationItem{install: install, home: home})
+	}
+
+	progress := startItemProgress(out.events, phaseRemove, items)
+	for _, item := range items {
+		install := item.(installationItem).install
+		if _, err := os.Stat(install.Path); os.IsNotExist(err) {
+			progress.finish(itemResult{item: item, skipped: "already removed"})
+			continue
+		}
+		if report.DryRun {
+			out.emit("remove", fmt.Sprintf("Would remove %s (%s)", install.Path, formatBytes(install.Size)), "path", install.Path, "bytes", install.Size, "dry_run", true)
+			report.add("remove "+install.Path, install.Size)
+			progress.finish(itemResult{item: item, skipped: "dry run"})
+			continue
+		}
+		start := time.Now()
+		err := item.Execute(planEnv{})
+		progress.finish(itemResult{item: item, err: err, duration: time.Since(start)})
+		if err != nil {
+			return fmt.Errorf("failed to remove %s: %v", install.Path, err)
+		}
+		report.add("remove "+install.Path, install.Size)
+		out.emit("remove", fmt.Sprintf("Removed %s (%s)", install.Path, formatBytes(install.Size)), "path", install.Path, "bytes", install.Size, "dry_run", false)
+	}
+
+	// The toolchains inside them stay too
+	if policy.DenyCacheCleanup {
+		for _, target := range inHome {
+			out.emit("skip", fmt.Sprintf("Leaving %s (%s): policy", target.Name, target.Path), "name", target.Name, "path", target.Path, "reason", "policy")
+			report.skip(target.Path, "policy")
+		}
+		return nil
+	}
+	return cleanCaches(out, inHome, true, report)
+}
+
+// inCache reports whether path is inside one of targets.
+func inCache(path string, targets []cacheTarget) bool {
+	for _, target := range targets {
+		if withinDir(path, target.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+func newCICleanCmd(opts *runOptions) *cobra.Command {
+	var dryRun, check bool
+	cmd := &cobra.Command{
+		Use:   "ci-clean",
+		Short: "Free disk on a CI runner: remove Go toolchains and caches under the home directory",
+		Long:  "ci-clean is meant for the last step of a GitHub Actions or GitLab CI job. Without prompting or taking a\nbackup, it removes the Go installations under the runner's home directory and empties GOCACHE and GOMODCACHE\nwhen they are there too, then prints a JSON summary. System installations and package manager installs are\nnever touched.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			home, err := ciCleanHome()
+			if err != nil {
+				return err
+			}
+			if lock := liveModeLock(); lock != "" && !dryRun {
+				return fmt.Errorf("live removals are disabled: %s", lock)
+			}
+			// stdout carries only the summary, unless every line is a record
+			out := newEventWriter(cmd.ErrOrStderr(), *opts)
+			if opts.porcelain {
+				out.w = cmd.OutOrStdout()
+			}
+			report := newChangeReport(check, dryRun)
+			stop := startHeadlessEvents(&out, *opts, cmd.ErrOrStderr())
+			defer stop()
+			detection := newEventBus()
+			wait := out.follow(detection, detectionEvents...)
+			installations, _ := detectGoInstallationsWithResults(cfg, detection)
+			detection.close()
+			wait()
+			markBlockedInstallations(installations, false)
+			actions, inActions := githubActions(os.Getenv, cmd.ErrOrStderr())
+			if err := ciClean(out, home, installations, cacheTargets(cfg, currentGoEnv()), report); err != nil {
+				if inActions {
+					actions.fail(err)
+				}
+				return err
+			}
+			out.emit("freed", fmt.Sprintf("Freed %s (%d bytes)", formatBytes(report.FreedBytes), report.FreedBytes), "bytes", report.FreedBytes)
+			if inActions {
+				if err := actions.report(report); err != nil {
+					out.emit("warning", "Could not write the job summary: "+err.Error(), "error", err.Error())
+				}
+			}
+			return report.finish(newEventWriter(cmd.OutOrStdout(), *opts))
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "report what would be freed without deleting")
+	addCheckFlag(cmd, &check)
+	return cmd
+}
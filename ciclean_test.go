@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCIClean(t *testing.T) {
+	skipInAuditBuild(t)
+	home := t.TempDir()
+	setupGo := filepath.Join(home, "sdk", "go1.22.5")
+	os.MkdirAll(setupGo, 0755)
+	os.WriteFile(filepath.Join(setupGo, "VERSION"), []byte("go1.22.5\n"), 0644)
+	modCache := filepath.Join(home, "go", "pkg", "mod")
+	toolchain := filepath.Join(modCache, "golang.org", "toolchain@v0.0.1-go1.23.0.linux-amd64")
+	os.MkdirAll(toolchain, 0755)
+	os.WriteFile(filepath.Join(toolchain, "VERSION"), []byte("go1.23.0\n"), 0644)
+	system := fakeGoRoot(t, "VERSION", "bin/go")
+	otherCache := t.TempDir()
+	os.WriteFile(filepath.Join(otherCache, "entry"), []byte("cached"), 0644)
+
+	installations := []GoInstallation{
+		{Path: setupGo, Source: "setup-go", Size: 100},
+		{Path: toolchain, Source: "gotoolchain", Size: 50},
+		{Path: system, Source: "system", Size: 200},
+		{Path: filepath.Join(home, "apt-go"), Source: "snap", PackageManager: "snap", Package: "go", Size: 10},
+		{Path: filepath.Join(home, "gone"), Source: "manual"},
+	}
+	targets := []cacheTarget{
+		{Name: "GOMODCACHE", Path: modCache},
+		{Name: "GOCACHE", Path: otherCache},
+	}
+
+	var out strings.Builder
+	report := newChangeReport(false, true)
+	if err := ciClean(eventWriter{w: &out}, home, installations, targets, report); err != nil {
+		t.Fatalf("ciClean dry run returned error: %v", err)
+	}
+	if _, err := os.Stat(setupGo); err != nil {
+		t.Errorf("Expected a dry run to leave %s in place: %v", setupGo, err)
+	}
+
+	out.Reset()
+	report = newChangeReport(false, false)
+	if err := ciClean(eventWriter{w: &out}, home, installations, targets, report); err != nil {
+		t.Fatalf("ciClean returned error: %v", err)
+	}
+	if _, err := os.Stat(setupGo); !os.IsNotExist(err) {
+		t.Errorf("Expected %s under the home directory to be removed", setupGo)
+	}
+	if _, err := os.Stat(toolchain); !os.IsNotExist(err) {
+		t.Errorf("Expected the toolchain to go with the module cache")
+	}
+	if _, err := os.Stat(system); err != nil {
+		t.Errorf("Expected %s outside the home directory to survive: %v", system, err)
+	}
+	if _, err := os.Stat(filepath.Join(otherCache, "entry")); err != nil {
+		t.Errorf("Expected a cache outside the home directory to survive: %v", err)
+	}
+	for _, want := range []string{"outside-home", "in-cache", "package-managed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected a %s skip in the output, got:\n%s", want, out.String())
+		}
+	}
+	if report.FreedBytes < 100 {
+		t.Errorf("Expected the removed installation to be counted, freed %d", report.FreedBytes)
+	}
+}
+
+func TestCICleanHonoursLiveModeLock(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	setupGo := filepath.Join(home, "sdk", "go1.22.5")
+	os.MkdirAll(setupGo, 0755)
+	withPolicy(t, Policy{RequireApproval: true})
+	cmd := newCICleanCmd(&runOptions{})
+	cmd.SetArgs(nil)
+	cmd.SetOut(&strings.Builder{})
+	cmd.SetErr(&strings.Builder{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "live removals are disabled") {
+		t.Errorf("Expected the policy to refuse a live ci-clean, got %v", err)
+	}
+	if _, err := os.Stat(setupGo); err != nil {
+		t.Errorf("Expected %s to survive: %v", setupGo, err)
+	}
+}
+
+func TestCICleanSkipsCachesUnderPolicy(t *testing.T) {
+	skipInAuditBuild(t)
+	home := t.TempDir()
+	setupGo := filepath.Join(home, "sdk", "go1.22.5")
+	os.MkdirAll(setupGo, 0755)
+	modCache := filepath.Join(home, "go", "pkg", "mod")
+	toolchain := filepath.Join(modCache, "golang.org", "toolchain@v0.0.1-go1.23.0.linux-amd64")
+	os.MkdirAll(toolchain, 0755)
+	withPolicy(t, Policy{DenyCacheCleanup: true})
+
+	installations := []GoInstallation{
+		{Path: setupGo, Source: "setup-go", Size: 100},
+		{Path: toolchain, Source: "gotoolchain", Size: 50},
+	}
+	var out strings.Builder
+	report := newChangeReport(false, false)
+	if err := ciClean(eventWriter{w: &out}, home, installations, []cacheTarget{{Name: "GOMODCACHE", Path: modCache}}, report); err != nil {
+		t.Fatalf("ciClean returned error: %v", err)
+	}
+	if _, err := os.Stat(setupGo); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed despite the cache policy", setupGo)
+	}
+	if _, err := os.Stat(toolchain); err != nil {
+		t.Errorf("Expected the module cache and its toolchain to be kept: %v", err)
+	}
+	if !strings.Contains(out.String(), "GOMODCACHE ("+modCache+"): policy") {
+		t.Errorf("Expected the cache to be skipped for the policy, got:\n%s", out.String())
+	}
+}
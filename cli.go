@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// runOptions carries the persistent command line flags into every subcommand.
+type runOptions struct {
+	logLevel      string
+	trace         bool
+	simulate      bool
+	record        string
+	offline       bool
+	porcelain     bool
+	progress      string
+	sass          string
+	noLogo        bool
+	scope         string
+	allUsers      bool
+	sampleHashing bool
+
+	installDocs   bool
+	uninstallDocs bool
+	docsPrefix    string
+}
+
+func newRootCmd() *cobra.Command {
+	opts := &runOptions{}
+	root := &cobra.Command{
+		Use:           "fu-go",
+		Short:         "The Go uninstaller",
+		Long:          "fu-go finds every Go installation on this machine and removes them.\nRun without arguments to launch the interactive TUI.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := parseLogLevel(opts.logLevel); err != nil {
+				return err
+			}
+			if err := validProgress(opts.progress); err != nil {
+				return err
+			}
+			policy, err := loadPolicy()
+			if err != nil {
+				return err
+			}
+			setPolicy(policy)
+			setOffline(opts.offline || policy.Offline)
+			// A broken config is reported by whatever reads it
+			cfg, _ := loadConfig()
+			scope := opts.scope
+			if scope == "" {
+				scope = cfg.Scope
+			}
+			parsed, err := parseScope(scope)
+			if err != nil {
+				return err
+			}
+			setScope(parsed)
+			setAllUsers(opts.allUsers || cfg.AllUsers)
+			setSampleHashing(opts.sampleHashing || cfg.BackupHashing == hashingSample)
+			setRepointGoRootLinks(cfg.GoRootLinks == goRootLinksRepoint)
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch {
+			case opts.installDocs:
+				return runInstallDocs(cmd.Root(), opts.docsPrefix, cmd.OutOrStdout())
+			case opts.uninstallDocs:
+				return runUninstallDocs(cmd.OutOrStdout())
+			}
+			if opts.sass != "" {
+				if err := validSass(opts.sass); err != nil {
+					return err
+				}
+			}
+			// Only the TUI moves ~/.fugo, so list and the other read-only
+			// commands never change the disk
+			moved, err := migrateLegacyHome()
+			for _, move := range moved {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Moved %s\n", move)
+			}
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: could not finish moving ~/.fugo: %v\n", err)
+			}
+			return runTUI(*opts)
+		},
+	}
+	root.PersistentFlags().StringVar(&opts.logLevel, "log-level", "info", "minimum log level: debug, info, warn or error")
+	root.PersistentFlags().BoolVar(&opts.trace, "trace", false, "log every external command and detector decision")
+	root.PersistentFlags().BoolVar(&opts.simulate, "simulate", false, "use a fake inventory and pretend operations, for demos and training")
+	root.PersistentFlags().BoolVar(&opts.offline, "offline", false, "guarantee no network access: checksum downloads, update checks, webhooks and backups to network file systems are refused")
+	root.PersistentFlags().StringVar(&opts.scope, "scope", "", "which installations to deal with: user (home directory only), system or all (overrides the scope setting)")
+	root.PersistentFlags().BoolVar(&opts.allUsers, "all-users", false, "when run as root or an administrator, also search other users' home directories")
+	root.PersistentFlags().BoolVar(&opts.sampleHashing, "sample-hashing", false, "dedup backups: only re-hash executables and a sample of the files unchanged since the last backup")
+	root.PersistentFlags().BoolVar(&opts.porcelain, "porcelain", false, "print headless commands' output as stable key=value records")
+	root.PersistentFlags().StringVar(&opts.progress, "progress", "", "with json, stream headless commands' progress to stderr as one JSON object per line")
+	root.Flags().StringVar(&opts.sass, "sass", "", "quote attitude: professional, normal or maximum (overrides the sass setting)")
+	root.Flags().BoolVar(&opts.noLogo, "no-logo", false, "hide the banner, for small terminals")
+	root.Flags().StringVar(&opts.record, "record", "", "record every screen and state change of the session to `file`")
+	root.Flags().BoolVar(&opts.installDocs, "install-manpages", false, "install the man page and bash, zsh and fish completions, then exit")
+	root.Flags().BoolVar(&opts.uninstallDocs, "uninstall-manpages", false, "remove what --install-manpages installed, then exit")
+	root.Flags().StringVar(&opts.docsPrefix, "manpages-prefix", "", "install under `dir`/share instead of the user's directories (/usr/local when run as root)")
+	root.MarkFlagsMutuallyExclusive("install-manpages", "uninstall-manpages")
+	root.AddCommand(newListCmd(opts))
+	root.AddCommand(newDetectorsCmd(opts))
+	root.AddCommand(newReplayCmd())
+	root.AddCommand(newDownloadCmd())
+	root.AddCommand(newCleanCacheCmd(opts))
+	root.AddCommand(newScheduleCmd())
+	root.AddCommand(newCICleanCmd(opts))
+	root.AddCommand(newKeygenCmd())
+	root.AddCommand(newPlanCmd(opts))
+	root.AddCommand(newApproveCmd())
+	root.AddCommand(newApplyCmd(opts))
+	root.AddCommand(newBackupsCmd())
+	root.AddCommand(newStateCmd())
+	root.AddCommand(newDiffCmd(opts))
+	// An audit binary must not be able to replace itself with a full one,
+	// write over installations or change the environment, nor act for others
+	// who could
+	if !auditBuild {
+		root.AddCommand(newRestoreCmd(opts))
+		root.AddCommand(newSelfUpdateCmd())
+		root.AddCommand(newContainerPruneCmd(opts))
+		root.AddCommand(newUndoEnvCmd(opts))
+		root.AddCommand(newDeactivateCmd(opts))
+		root.AddCommand(newServeCmd(opts))
+		root.AddCommand(newServiceCmd())
+		root.AddCommand(newExecElevatedCmd())
+	}
+	return root
+}
@@ -0,0 +1,9 @@
+package main
+
+import "golang.org/x/sys/unix"
+
+// cloneFile makes dst, which must not exist, a clone of src sharing its
+// blocks, through clonefile(2). Only APFS supports it, within one volume.
+func cloneFile(src, dst string) error {
+	return unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW)
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package main
+
+import "errors"
+
+// cloneFile is unavailable here; files are always copied.
+func cloneFile(src, dst string) error {
+	return errors.ErrUnsupported
+}
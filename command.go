@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// All external programs are started through these helpers so that --trace
+// can record what ran, with which arguments, and for how long, and so that
+// none of them can hang fu-go: each runs under a deadline, and a command that
+// outlives it fails with a commandTimeoutError rather than freezing the TUI
+// on, say, a hung NFS mount.
+
+// Queries such as which, dpkg -S or pkg which answer in well under a second;
+// a file system snapshot, a package manager removing a Go root or archiving
+// one takes longer.
+const (
+	defaultCommandTimeout  = 30 * time.Second
+	snapshotCommandTimeout = 5 * time.Minute
+	removalCommandTimeout  = 15 * time.Minute
+	archiveCommandTimeout  = 2 * time.Hour
+)
+
+// commandWaitDelay bounds how long a killed command's output pipes may stay
+// open, e.g. held by a grandchild it started.
+const commandWaitDelay = 2 * time.Second
+
+// commandTimeoutError is returned when a command ran past its deadline, so
+// callers and logs can tell a hang from a failure.
+type commandTimeoutError struct {
+	name    string
+	args    []string
+	timeout time.Duration
+}
+
+func (e *commandTimeoutError) Error() string {
+	return fmt.Sprintf("%s %s timed out after %s", e.name, strings.Join(e.args, " "), e.timeout)
+}
+
+// isCommandTimeout reports whether err is, or wraps, a command timeout.
+func isCommandTimeout(err error) bool {
+	var timeout *commandTimeoutError
+	return errors.As(err, &timeout)
+}
+
+func commandOutput(name string, args ...string) ([]byte, error) {
+	return commandOutputContext(context.Background(), name, args...)
+}
+
+func commandOutputContext(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return runCommand(ctx, defaultCommandTimeout, false, name, args...)
+}
+
+func commandCombinedOutput(name string, args ...string) ([]byte, error) {
+	return runCommand(context.Background(), defaultCommandTimeout, true, name, args...)
+}
+
+// commandCombinedOutputTimeout is commandCombinedOutput for the commands
+// that need longer than defaultCommandTimeout.
+func commandCombinedOutputTimeout(timeout time.Duration, name string, args ...string) ([]byte, error) {
+	return runCommand(context.Background(), timeout, true, name, args...)
+}
+
+// runCommand runs name bounded by timeout as well as by parent's deadline,
+// if it has an earlier one.
+func runCommand(parent context.Context, timeout time.Duration, combined bool, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = commandWaitDelay
+	start := time.Now()
+	var output []byte
+	var err error
+	if combined {
+		output, err = cmd.CombinedOutput()
+	} else {
+		output, err = cmd.Output()
+	}
+	if err != nil && ctx.Err() == context.DeadlineExceeded && parent.Err() == nil {
+		err = &commandTimeoutError{name: name, args: args, timeout: timeout}
+	}
+	traceCommand(name, args, time.Since(start), err)
+	return output, err
+}
+
+func traceCommand(name string, args []string, duration time.Duration, err error) {
+	status := "ok"
+	if err != nil {
+		status = err.Error()
+	}
+	tracef("exec %s %s (%s): %s", name, strings.Join(args, " "), duration.Round(time.Millisecond), status)
+}
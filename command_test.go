@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestRunCommandTimeout(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+	start := time.Now()
+	_, err := runCommand(context.Background(), 50*time.Millisecond, false, "sleep", "5")
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("Expected the command to be stopped at its timeout, took %s", time.Since(start))
+	}
+	if !isCommandTimeout(err) || err.Error() != "sleep 5 timed out after 50ms" {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+	if !isCommandTimeout(fmt.Errorf("snap remove go failed: %w", err)) {
+		t.Errorf("Expected a wrapped timeout to be recognised")
+	}
+
+	// The caller's own deadline is the caller's to report
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := runCommand(ctx, time.Minute, false, "sleep", "5"); err == nil || isCommandTimeout(err) {
+		t.Errorf("Expected the parent's deadline to be reported as is, got %v", err)
+	}
+
+	if _, err := runCommand(context.Background(), time.Minute, true, "sleep", "0"); err != nil {
+		t.Errorf("Expected a quick command to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const defaultDetectorTimeout = 10 * time.Second
+
+// Config holds user preferences read from config.json in the config directory
+// (~/.config/fugo on Linux). Every field is
+// optional; a missing file yields the defaults.
+type Config struct {
+	// EnabledDetectors restricts detection to the named detectors when set.
+	EnabledDetectors []string `json:"enabled_detectors,omitempty"`
+	// DisabledDetectors are skipped even if enabled above.
+	DisabledDetectors []string `json:"disabled_detectors,omitempty"`
+	// DetectorTimeout bounds each detector, e.g. "10s".
+	DetectorTimeout string `json:"detector_timeout,omitempty"`
+	// AllowCrossMounts lets removal descend into mount points (bind mounts,
+	// NFS) found inside an installation.
+	AllowCrossMounts bool `json:"allow_cross_mounts,omitempty"`
+	// LogDir and BackupDir move logs and backups off the home directory,
+	// e.g. to a scratch volume on shared build machines. FUGO_LOG_DIR and
+	// FUGO_BACKUP_DIR take precedence.
+	LogDir    string `json:"log_dir,omitempty"`
+	BackupDir string `json:"backup_dir,omitempty"`
+	// DefaultMode is the mode the TUI starts in: "dry-run" (the default) or
+	// "live".
+	DefaultMode string `json:"default_mode,omitempty"`
+	// BackupPolicy is "always" (the default) to archive installations before
+	// a live removal, "snapshot" to take a file system snapshot instead, or
+	// "never".
+	BackupPolicy string `json:"backup_policy,omitempty"`
+	// Snapshot takes file system snapshots of the affected volumes before a
+	// live removal.
+	Snapshot SnapshotConfig `json:"snapshot,omitempty"`
+	// BackupFormat is "archive" (the default), a tar.gz per installation,
+	// "dedup" to store files as chunks shared between backup sets, or
+	// "quarantine" to move or clone the files into the set as they are.
+	BackupFormat string `json:"backup_format,omitempty"`
+	// BackupRetention prunes old backup sets after each backup, and with
+	// them the chunks only they used.
+	BackupRetention RetentionConfig `json:"backup_retention,omitempty"`
+	// BackupHashing is "full" (the default) to hash every file of a dedup
+	// backup, or "sample" to trust files unchanged since the last one,
+	// except executables and a daily tenth of the rest.
+	BackupHashing string `json:"backup_hashing,omitempty"`
+	// GoRootLinks is what happens to a stable symlink such as /usr/local/go
+	// when the installation it points at is removed: "remove" (the default)
+	// or "repoint" to point it at the newest installation kept next to it.
+	GoRootLinks string `json:"goroot_links,omitempty"`
+	// AllUsers also searches the other users' home directories when fu-go
+	// runs as root or an administrator.
+	AllUsers bool `json:"all_users,omitempty"`
+	// Scope is "user" for installations in the home directory only,
+	// "system" for the system-wide ones only, or "all" (the default).
+	Scope string `json:"scope,omitempty"`
+	// Theme is "default" or "mono" for terminals without color.
+	Theme string `json:"theme,omitempty"`
+	// SetupComplete records that the first-run wizard has been answered.
+	SetupComplete bool `json:"setup_complete,omitempty"`
+	// CABundle is a PEM file of extra root certificates for network
+	// features, for networks behind a TLS-inspecting proxy. Proxies
+	// themselves come from HTTPS_PROXY and NO_PROXY.
+	CABundle string `json:"ca_bundle,omitempty"`
+	// ArtifactSource is a local directory or file:// URL holding Go
+	// archives and their checksums, used instead of go.dev on air-gapped
+	// networks.
+	ArtifactSource string `json:"artifact_source,omitempty"`
+	// FinalChallenge replaces typing DESTROY as the last confirmation step.
+	// A challenge in the machine policy takes precedence.
+	FinalChallenge ChallengeConfig `json:"final_challenge,omitempty"`
+	// GoCacheLimit and ModCacheLimit are the sizes, e.g. "10GB", above which
+	// clean-cache empties GOCACHE and GOMODCACHE.
+	GoCacheLimit  string `json:"gocache_limit,omitempty"`
+	ModCacheLimit string `json:"gomodcache_limit,omitempty"`
+	// DiskPressure makes clean-cache wait until a volume runs low on space,
+	// then empty the largest caches first, ignoring the limits above.
+	DiskPressure PressureConfig `json:"disk_pressure,omitempty"`
+	// VulnDB turns on the vulnerability check: "default" for
+	// https://vuln.go.dev, another URL, or a local mirror directory or
+	// file:// URL. Without it only the embedded CVE table is consulted.
+	VulnDB string `json:"vuln_db,omitempty"`
+	// ProjectScan looks for Go projects under ProjectRoots (by default ~/code,
+	// ~/src, ~/projects, ~/dev, ~/work, ~/repos and ~/go/src) and summarizes
+	// them on the confirm screen.
+	ProjectScan  bool     `json:"project_scan,omitempty"`
+	ProjectRoots []string `json:"project_roots,omitempty"`
+	// Sass is how much attitude the quotes have: "professional", "normal"
+	// (the default) or "maximum". QuotePacks are JSON files of extra quotes
+	// keyed by screen.
+	Sass       string   `json:"sass,omitempty"`
+	QuotePacks []string `json:"quote_packs,omitempty"`
+	// Farewell is the animation after a successful removal: "gopher" (the
+	// default), "fireworks" or "off". The mono theme never animates.
+	Farewell string `json:"farewell,omitempty"`
+	// Logo is a text file of ASCII art to show instead of the fu-go banner,
+	// or "off" for no banner.
+	Logo string `json:"logo,omitempty"`
+}
+
+var configChoices = []struct {
+	key     string
+	value   func(Config) string
+	allowed []string
+}{
+	{"default_mode", func(c Config) string { return c.DefaultMode }, []string{"dry-run", "live"}},
+	{"backup_policy", func(c Config) string { return c.BackupPolicy }, []string{"always", "snapshot", "never"}},
+	{"backup_format", func(c Config) string { return c.BackupFormat }, []string{backupFormatArchive, backupFormatDedup, backupFormatQuarantine}},
+	{"backup_hashing", func(c Config) string { return c.BackupHashing }, []string{hashingFull, hashingSample}},
+	{"goroot_links", func(c Config) string { return c.GoRootLinks }, []string{goRootLinksRemove, goRootLinksRepoint}},
+	{"scope", func(c Config) string { return c.Scope }, scopeChoices},
+	{"theme", func(c Config) string { return c.Theme }, []string{"default", "mono"}},
+	{"farewell", func(c Config) string { return c.Farewell }, []string{farewellGopher, farewellFireworks, farewellOff}},
+	{"sass", func(c Config) string { return c.Sass }, []string{sassProfessional, sassNormal, sassMaximum}},
+}
+
+func defaultConfig() Config {
+	return Config{}
+}
+
+func configPath() (string, error) {
+	dirs, err := resolveDirs()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dirs.Config, "config.json"), nil
+}
+
+// loadConfig reads config.json, from ~/.fugo while the TUI has not yet moved
+// it. Saving always writes the new location.
+func loadConfig() (Config, error) {
+	path, err := configPath()
+	if err != nil {
+		return defaultConfig(), err
+	}
+	return loadConfigFile(unmigrated(path, "config.json"))
+}
+
+func loadConfigFile(path string) (Config, error) {
+	cfg := defaultConfig()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return defaultConfig(), fmt.Errorf("failed to parse config %s: %v", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return defaultConfig(), fmt.Errorf("invalid config %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+func (c Config) validate() error {
+	if _, err := c.detectorTimeout(); err != nil {
+		return err
+	}
+	if c.CABundle != "" && !filepath.IsAbs(c.CABundle) {
+		return fmt.Errorf("ca_bundle must be an absolute path, got %q", c.CABundle)
+	}
+	if c.ArtifactSource != "" {
+		if _, err := localSourceDir(c.ArtifactSource); err != nil {
+			return err
+		}
+	}
+	if c.VulnDB != "" && c.VulnDB != "default" && !strings.HasPrefix(c.VulnDB, "https://") && !strings.HasPrefix(c.VulnDB, "http://") {
+		if _, err := localSourceDir(c.VulnDB); err != nil {
+			return fmt.Errorf("vuln_db: %v", err)
+		}
+	}
+	for _, root := range c.ProjectRoots {
+		if root = expandHome(root); !filepath.IsAbs(root) {
+			return fmt.Errorf("project_roots must be absolute paths or start with ~, got %q", root)
+		}
+	}
+	if err := c.FinalChallenge.validate(); err != nil {
+		return err
+	}
+	if err := c.Snapshot.validate(); err != nil {
+		return err
+	}
+	if err := c.BackupRetention.validate(); err != nil {
+		return err
+	}
+	if err := c.DiskPressure.validate(); err != nil {
+		return err
+	}
+	for key, limit := range map[string]string{"gocache_limit": c.GoCacheLimit, "gomodcache_limit": c.ModCacheLimit} {
+		if limit == "" {
+			continue
+		}
+		if _, err := parseByteSize(limit); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	}
+	for _, choice := range configChoices {
+		value := choice.value(c)
+		if value == "" {
+			continue
+		}
+		valid := false
+		for _, allowed := range choice.allowed {
+			if value == allowed {
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("%s must be one of %s, got %q", choice.key, strings.Join(choice.allowed, ", "), value)
+		}
+	}
+	return nil
+}
+
+// saveConfigFile writes cfg to path, creating the config directory if needed.
+func saveConfigFile(path string, cfg Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write config %s: %v", path, err)
+	}
+	return nil
+}
+
+func saveConfig(cfg Config) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	return saveConfigFile(path, cfg)
+}
+
+func (c Config) startsInDryRun() bool {
+	return liveModeLock() != "" || c.DefaultMode != "live"
+}
+
+// finalChallenge is the last confirmation step, from the policy if it sets
+// one.
+func (c Config) finalChallenge() challenge {
+	if policy := currentPolicy(); policy.FinalChallenge != nil {
+		return newChallenge(*policy.FinalChallenge)
+	}
+	return newChallenge(c.FinalChallenge)
+}
+
+// liveModeLock says why live mode is unavailable, or "" when it is allowed.
+func liveModeLock() string {
+	if auditBuild {
+		return "read-only audit build"
+	}
+	if currentPolicy().DryRunOnly {
+		return "required by the machine policy"
+	}
+	if currentPolicy().RequireApproval {
+		return "live removals need a plan approved by two people; see fu-go plan"
+	}
+	return ""
+}
+
+// backupBeforeRemoval reports whether a live removal takes any backup.
+func (c Config) backupBeforeRemoval() bool {
+	return c.archiveBeforeRemoval() || c.snapshotBeforeRemoval()
+}
+
+// archiveBeforeRemoval reports whether installations are archived with tar.
+func (c Config) archiveBeforeRemoval() bool {
+	return currentPolicy().RequireBackup || (c.BackupPolicy != "never" && c.BackupPolicy != "snapshot")
+}
+
+// snapshotBeforeRemoval reports whether their volumes are snapshotted.
+func (c Config) snapshotBeforeRemoval() bool {
+	return c.Snapshot.Backend != "" || c.BackupPolicy == "snapshot"
+}
+
+func (c Config) detectorTimeout() (time.Duration, error) {
+	if c.DetectorTimeout == "" {
+		return defaultDetectorTimeout, nil
+	}
+	timeout, err := time.ParseDuration(c.DetectorTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("detector_timeout: %v", err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("detector_timeout must be positive, got %s", c.DetectorTimeout)
+	}
+	return timeout, nil
+}
+
+func (c Config) detectorEnabled(name string) bool {
+	for _, disabled := range c.DisabledDetectors {
+		if disabled == name {
+			return false
+		}
+	}
+	if len(c.EnabledDetectors) == 0 {
+		return true
+	}
+	for _, enabled := range c.EnabledDetectors {
+		if enabled == name {
+			return true
+		}
+	}
+	return false
+}
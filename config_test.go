@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigFileMissing(t *testing.T) {
+	cfg, err := loadConfigFile(filepath.Join(t.TempDir(), "config.json"))
+	if err != nil {
+		t.Fatalf("Expected no error for missing config, got: %v", err)
+	}
+	timeout, _ := cfg.detectorTimeout()
+	if timeout != defaultDetectorTimeout {
+		t.Errorf("Expected default timeout %s, got %s", defaultDetectorTimeout, timeout)
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{"disabled_detectors": ["snap"], "detector_timeout": "3s"}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.detectorEnabled("snap") {
+		t.Error("Expected snap detector to be disabled")
+	}
+	if !cfg.detectorEnabled("gvm") {
+		t.Error("Expected gvm detector to be enabled")
+	}
+	if timeout, _ := cfg.detectorTimeout(); timeout != 3*time.Second {
+		t.Errorf("Expected 3s timeout, got %s", timeout)
+	}
+}
+
+func TestLoadConfigFileInvalid(t *testing.T) {
+	testCases := []string{
+		`{"detector_timeout": "soon"}`,
+		`{"detector_timeout": "-1s"}`,
+		`{"default_mode": "yolo"}`,
+		`{"theme": "neon"}`,
+		`{"ca_bundle": "certs/corp.pem"}`,
+		`{"artifact_source": "mirror/go"}`,
+		`{"gocache_limit": "lots"}`,
+		`{"artifact_source": "file://mirror.corp/go"}`,
+		`not json`,
+	}
+
+	for _, content := range testCases {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := loadConfigFile(path); err == nil {
+			t.Errorf("Expected error for config %q", content)
+		}
+	}
+}
+
+func TestSaveConfigFileRoundTrip(t *testing.T) {
+	skipInAuditBuild(t)
+	path := filepath.Join(t.TempDir(), "fugo", "config.json")
+	cfg := Config{DefaultMode: "live", BackupPolicy: "never", Theme: "mono", SetupComplete: true}
+	if err := saveConfigFile(path, cfg); err != nil {
+		t.Fatalf("saveConfigFile failed: %v", err)
+	}
+
+	loaded, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load saved config: %v", err)
+	}
+	if loaded.DefaultMode != "live" || loaded.BackupPolicy != "never" || loaded.Theme != "mono" || !loaded.SetupComplete {
+		t.Errorf("Round trip lost preferences: %+v", loaded)
+	}
+	if loaded.startsInDryRun() || loaded.backupBeforeRemoval() {
+		t.Error("Expected live mode without backups")
+	}
+	if !defaultConfig().startsInDryRun() || !defaultConfig().backupBeforeRemoval() {
+		t.Error("Expected defaults to be dry-run with backups")
+	}
+}
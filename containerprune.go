@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// container-prune trims Go out of container images in multi-stage builds. It
+// asks nothing, takes no backups and prints what it freed. Those relaxed
+// guard rails are only acceptable inside a throwaway container, so it refuses
+// to run anywhere else unless told otherwise.
+
+// containerMarkers are the cgroup path fragments container runtimes use.
+var containerMarkers = []string{"docker", "kubepods", "containerd", "libpod", "lxc", "garden"}
+
+// inContainer reports whether fu-go runs inside a container, and why.
+func inContainer(getenv func(string) string, readFile func(string) ([]byte, error)) (bool, string) {
+	for _, marker := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := readFile(marker); err == nil {
+			return true, marker + " exists"
+		}
+	}
+	if kind := getenv("container"); kind != "" {
+		return true, "container=" + kind
+	}
+	if data, err := readFile("/proc/1/cgroup"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			for _, marker := range containerMarkers {
+				if strings.Contains(line, marker) {
+					return true, "/proc/1/cgroup mentions " + marker
+				}
+			}
+		}
+	}
+	return false, ""
+}
+
+// pruneRoots removes the given Go roots. Each must still look like Go and
+// not be a critical directory; this is the one guard rail kept. Roots that
+// are already gone are not changes, so a second run reports nothing.
+func pruneRoots(out eventWriter, roots []string, report *changeReport) error {
+	var items []PlanItem
+	for _, root := range roots {
+		if isCriticalPath(root) {
+			return fmt.Errorf("refusing to remove critical directory %s", root)
+		}
+		if _, err := os.Stat(root); os.IsNotExist(err) {
+			out.emit("skip", fmt.Sprintf("%s does not exist, skipping", root), "path", root, "reason", "missing")
+			continue
+		}
+		if verifyGoRoot(root).passed() == 0 {
+			return fmt.Errorf("%s does not look like a Go installation", root)
+		}
+		if reason := currentPolicy().blocker(GoInstallation{Path: root, Source: "manual"}); reason != "" {
+			return fmt.Errorf("cannot remove %s: %s", root, reason)
+		}
+		items = append(items, installationItem{install: GoInstallation{Path: root, Source: "manual", Size: getDirSize(root)}})
+	}
+
+	progress := startItemProgress(out.events, phaseRemove, items)
+	for _, item := range items {
+		root, size := item.Target(), item.Size()
+		if report.DryRun {
+			out.emit("remove", fmt.Sprintf("Would remove %s (%s)", root, formatBytes(size)), "path", root, "bytes", size, "dry_run", true)
+			report.add("remove "+root, size)
+			progress.finish(itemResult{item: item, skipped: "dry run"})
+			continue
+		}
+		start := time.Now()
+		err := removeTree(root, false)
+		progress.finish(itemResult{item: item, err: err, duration: time.Since(start)})
+		if err != nil {
+			return fmt.Errorf("failed to remove %s: %v", root, err)
+		}
+		report.add("remove "+root, size)
+		out.emit("remove", fmt.Sprintf("Removed %s (%s)", root, formatBytes(size)), "path", root, "bytes", size, "dry_run", false)
+	}
+	return nil
+}
+
+func newContainerPruneCmd(opts *runOptions) *cobra.Command {
+	var roots []string
+	var caches, force, check bool
+	cmd := &cobra.Command{
+		Use:   "container-prune",
+		Short: "Remove Go roots and caches from a container image without prompting",
+		Long:  "container-prune is meant for the last stage of a multi-stage image build. It removes each --root and, with\n--caches, empties GOCACHE and GOMODCACHE, without confirmation or backups, then prints the bytes freed. It\nrefuses to run outside a container unless --i-am-in-a-container is given.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := newEventWriter(cmd.OutOrStdout(), *opts)
+			stop := startHeadlessEvents(&out, *opts, cmd.ErrOrStderr())
+			defer stop()
+			if ok, why := inContainer(os.Getenv, os.ReadFile); ok {
+				tracef("container-prune: in a container: %s", why)
+			} else if !force {
+				return fmt.Errorf("container-prune only runs inside a container; pass --i-am-in-a-container if this is one")
+			}
+			if len(roots) == 0 && !caches {
+				return fmt.Errorf("nothing to prune; give --root and/or --caches")
+			}
+			if lock := liveModeLock(); lock != "" {
+				return fmt.Errorf("live removals are disabled: %s", lock)
+			}
+			report := newChangeReport(check, false)
+			if err := pruneRoots(out, roots, report); err != nil {
+				return err
+			}
+			if caches {
+				cfg, err := loadConfig()
+				if err != nil {
+					return err
+				}
+				if err := cleanCaches(out, cacheTargets(cfg, currentGoEnv()), true, report); err != nil {
+					return err
+				}
+			}
+			out.emit("freed", fmt.Sprintf("Freed %s (%d bytes)", formatBytes(report.FreedBytes), report.FreedBytes), "bytes", report.FreedBytes)
+			return report.finish(out)
+		},
+	}
+	cmd.Flags().StringArrayVar(&roots, "root", nil, "Go root to remove (repeatable)")
+	cmd.Flags().BoolVar(&caches, "caches", false, "also empty GOCACHE and GOMODCACHE")
+	cmd.Flags().BoolVar(&force, "i-am-in-a-container", false, "skip the container check")
+	addCheckFlag(cmd, &check)
+	return cmd
+}
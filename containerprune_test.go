@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInContainer(t *testing.T) {
+	noEnv := func(string) string { return "" }
+	files := func(contents map[string]string) func(string) ([]byte, error) {
+		return func(path string) ([]byte, error) {
+			if data, ok := contents[path]; ok {
+				return []byte(data), nil
+			}
+			return nil, errors.New("not found")
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		getenv func(string) string
+		files  map[string]string
+		want   bool
+	}{
+		{"docker marker", noEnv, map[string]string{"/.dockerenv": ""}, true},
+		{"podman marker", noEnv, map[string]string{"/run/.containerenv": ""}, true},
+		{"kubernetes cgroup", noEnv, map[string]string{"/proc/1/cgroup": "12:memory:/kubepods/burstable/pod1234\n"}, true},
+		{"systemd-nspawn", func(key string) string {
+			if key == "container" {
+				return "systemd-nspawn"
+			}
+			return ""
+		}, nil, true},
+		{"host", noEnv, map[string]string{"/proc/1/cgroup": "0::/init.scope\n"}, false},
+	}
+	for _, tc := range testCases {
+		if got, why := inContainer(tc.getenv, files(tc.files)); got != tc.want {
+			t.Errorf("%s: inContainer = %v (%s), want %v", tc.name, got, why, tc.want)
+		}
+	}
+}
+
+func TestPruneRoots(t *testing.T) {
+	skipInAuditBuild(t)
+	goRoot := fakeGoRoot(t, "VERSION", "bin/go")
+	notGo := t.TempDir()
+	os.WriteFile(filepath.Join(notGo, "data"), []byte("keep me"), 0644)
+
+	var out strings.Builder
+	if err := pruneRoots(eventWriter{w: &out}, []string{notGo}, newChangeReport(false, false)); err == nil || !strings.Contains(err.Error(), "does not look like") {
+		t.Errorf("Expected a non-Go directory to be refused, got %v", err)
+	}
+	if err := pruneRoots(eventWriter{w: &out}, []string{"/usr"}, newChangeReport(false, false)); err == nil {
+		t.Errorf("Expected a critical directory to be refused")
+	}
+
+	report := newChangeReport(false, false)
+	if err := pruneRoots(eventWriter{w: &out}, []string{goRoot, filepath.Join(notGo, "missing")}, report); err != nil {
+		t.Fatalf("pruneRoots returned error: %v", err)
+	}
+	if report.FreedBytes == 0 || len(report.Changes) != 1 {
+		t.Errorf("Expected bytes freed to be reported")
+	}
+	if _, err := os.Stat(goRoot); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed", goRoot)
+	}
+	if _, err := os.Stat(filepath.Join(notGo, "data")); err != nil {
+		t.Errorf("Expected unrelated files to survive: %v", err)
+	}
+}
+
+func TestContainerPruneHonoursLiveModeLock(t *testing.T) {
+	goRoot := fakeGoRoot(t, "VERSION", "bin/go")
+	withPolicy(t, Policy{RequireApproval: true})
+	cmd := newContainerPruneCmd(&runOptions{})
+	cmd.SetArgs([]string{"--i-am-in-a-container", "--root", goRoot})
+	cmd.SetOut(&strings.Builder{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "live removals are disabled") {
+		t.Errorf("Expected the policy to refuse the prune, got %v", err)
+	}
+	if _, err := os.Stat(goRoot); err != nil {
+		t.Errorf("Expected %s to survive: %v", goRoot, err)
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A panic anywhere in the TUI (Update, View or a background command) is
+// caught, the terminal restored, and a diagnostics bundle written to the
+// crash directory so the user has something to attach to a bug report.
+
+// panicMsg carries a panic out of a command goroutine to the event loop,
+// where it is re-raised so runTUI's recover sees every panic in one place.
+type panicMsg struct {
+	value interface{}
+	stack []byte
+}
+
+// crashContext remembers the latest model so the bundle can describe what
+// the user was doing.
+type crashContext struct {
+	last tea.Model
+}
+
+// crashGuard wraps the TUI model and every command it returns.
+type crashGuard struct {
+	model tea.Model
+	ctx   *crashContext
+}
+
+func (g crashGuard) Init() tea.Cmd {
+	return guardCmd(g.model.Init())
+}
+
+func (g crashGuard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if p, ok := msg.(panicMsg); ok {
+		panic(p)
+	}
+	next, cmd := g.model.Update(msg)
+	g.model = next
+	g.ctx.last = next
+	return g, guardCmd(cmd)
+}
+
+func (g crashGuard) View() string {
+	return g.model.View()
+}
+
+// guardCmd turns a panic in cmd into a panicMsg. Batches are guarded
+// element by element since Bubble Tea runs each in its own goroutine.
+func guardCmd(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() (msg tea.Msg) {
+		defer func() {
+			if r := recover(); r != nil {
+				msg = panicMsg{value: r, stack: debug.Stack()}
+			}
+		}()
+		msg = cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			for i := range batch {
+				batch[i] = guardCmd(batch[i])
+			}
+		}
+		return msg
+	}
+}
+
+// innerModel strips the crash guard and session recorder wrappers.
+func innerModel(m tea.Model) tea.Model {
+	for {
+		switch wrapper := m.(type) {
+		case crashGuard:
+			m = wrapper.model
+		case recordingModel:
+			m = wrapper.model
+		default:
+			return m
+		}
+	}
+}
+
+// modelDiagnostics is the part of the model worth including in a crash
+// report: enough to reproduce, without the rendering state.
+type modelDiagnostics struct {
+	State            string   `json:"state"`
+	ConfirmationStep int      `json:"confirmation_step"`
+	DryRun           bool     `json:"dry_run"`
+	Simulate         bool     `json:"simulate"`
+	Installations    []string `json:"installations"`
+	Deselected       []string `json:"deselected,omitempty"`
+	Preflight        []string `json:"preflight,omitempty"`
+	Err              string   `json:"error,omitempty"`
+	Width            int      `json:"width"`
+	Height           int      `json:"height"`
+}
+
+func (m model) diagnostics() modelDiagnostics {
+	d := modelDiagnostics{
+		State:            m.state,
+		ConfirmationStep: m.confirmationStep,
+		DryRun:           m.dryRun,
+		Simulate:         m.opts.simulate,
+		Width:            m.width,
+		Height:           m.height,
+	}
+	for _, install := range m.detectedInstalls {
+		d.Installations = append(d.Installations, fmt.Sprintf("%s (%s, %s)", install.Path, install.Source, install.Version))
+	}
+	for _, install := range m.detectedInstalls {
+		if !m.isSelected(install) {
+			d.Deselected = append(d.Deselected, install.Path)
+		}
+	}
+	for _, result := range m.preflight {
+		if result.Err != nil {
+			d.Preflight = append(d.Preflight, fmt.Sprintf("%s %s: %v", result.Role, result.Path, result.Err))
+		}
+	}
+	if m.err != nil {
+		d.Err = m.err.Error()
+	}
+	return d
+}
+
+// logTail returns the last n lines of the log file at path.
+func logTail(path string, n int) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Sprintf("log unavailable: %v\n", err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// writeCrashBundle writes a zip with the panic, stack, model state, log tail
+// and system details into dir and returns its path.
+func writeCrashBundle(dir string, value interface{}, stack []byte, m tea.Model) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create crash directory: %v", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("fugo_crash_%s.zip", time.Now().Format("20060102_150405")))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create crash bundle: %v", err)
+	}
+	defer file.Close()
+
+	hostname, _ := os.Hostname()
+	system := map[string]interface{}{
+		"time":       time.Now().Format(time.RFC3339),
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+		"go_version": runtime.Version(),
+		"num_cpu":    runtime.NumCPU(),
+		"hostname":   hostname,
+		"args":       os.Args,
+		"term":       os.Getenv("TERM"),
+	}
+
+	entries := map[string][]byte{
+		"panic.txt": []byte(fmt.Sprintf("panic: %v\n\n%s", value, stack)),
+	}
+	if data, err := json.MarshalIndent(system, "", "  "); err == nil {
+		entries["system.json"] = data
+	}
+	if inner, ok := innerModel(m).(model); ok {
+		if data, err := json.MarshalIndent(inner.diagnostics(), "", "  "); err == nil {
+			entries["state.json"] = data
+		}
+		if inner.logFile != nil {
+			entries["log_tail.txt"] = []byte(logTail(inner.logFile.path, 100))
+		}
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	archive := zip.NewWriter(file)
+	for _, name := range names {
+		w, err := archive.Create(name)
+		if err != nil {
+			return "", fmt.Errorf("failed to write crash bundle: %v", err)
+		}
+		if _, err := w.Write(entries[name]); err != nil {
+			return "", fmt.Errorf("failed to write crash bundle: %v", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return "", fmt.Errorf("failed to write crash bundle: %v", err)
+	}
+	return path, nil
+}
+
+// handleCrash records a recovered panic and returns the error runTUI reports.
+func handleCrash(r interface{}, ctx *crashContext, dir string) error {
+	value, stack := r, debug.Stack()
+	if p, ok := r.(panicMsg); ok {
+		value, stack = p.value, p.stack
+	}
+
+	if inner, ok := innerModel(ctx.last).(model); ok && inner.logFile != nil {
+		inner.logFile.Log("ERROR", fmt.Sprintf("Panic: %v", value))
+	}
+	path, err := writeCrashBundle(dir, value, stack, ctx.last)
+	if err != nil {
+		return fmt.Errorf("fu-go crashed (%v) and could not write diagnostics: %v\n%s", value, err, stack)
+	}
+	return fmt.Errorf("fu-go crashed: %v\nDiagnostics written to %s; please attach it to a bug report", value, path)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestGuardCmdCatchesPanics(t *testing.T) {
+	cmd := guardCmd(func() tea.Msg { panic("boom") })
+	msg, ok := cmd().(panicMsg)
+	if !ok {
+		t.Fatalf("Expected panicMsg, got %T", msg)
+	}
+	if msg.value != "boom" || !strings.Contains(string(msg.stack), "crash_test.go") {
+		t.Errorf("Unexpected panicMsg: %v\n%s", msg.value, msg.stack)
+	}
+
+	// Commands inside a batch run in their own goroutines and need guarding too
+	batch := guardCmd(tea.Batch(func() tea.Msg { return nil }, func() tea.Msg { panic("inner") }))
+	inner := batch().(tea.BatchMsg)
+	if _, ok := inner[1]().(panicMsg); !ok {
+		t.Error("Expected panics inside a batch to become panicMsg")
+	}
+}
+
+func TestCrashGuardReraisesInEventLoop(t *testing.T) {
+	g := crashGuard{model: stubModel{state: "start"}, ctx: &crashContext{}}
+	defer func() {
+		r := recover()
+		if _, ok := r.(panicMsg); !ok {
+			t.Errorf("Expected panicMsg to be re-raised, got %v", r)
+		}
+	}()
+	g.Update(panicMsg{value: "boom"})
+}
+
+func TestWriteCrashBundle(t *testing.T) {
+	logger, logPath := newTestLogger(t)
+	logger.Log("INFO", "last words")
+	logger.path = logPath
+
+	m := model{
+		state:            "confirm",
+		logFile:          logger,
+		err:              errors.New("earlier failure"),
+		detectedInstalls: []GoInstallation{{Path: "/usr/local/go", Source: "official", Version: "go1.22.5"}},
+	}
+	path, err := writeCrashBundle(t.TempDir(), "boom", []byte("goroutine 1 [running]"), crashGuard{model: m})
+	if err != nil {
+		t.Fatalf("writeCrashBundle failed: %v", err)
+	}
+	if filepath.Ext(path) != ".zip" {
+		t.Errorf("Expected a zip bundle, got %s", path)
+	}
+
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("Failed to open bundle: %v", err)
+	}
+	defer archive.Close()
+
+	contents := make(map[string]string)
+	for _, f := range archive.File {
+		r, _ := f.Open()
+		data, _ := io.ReadAll(r)
+		r.Close()
+		contents[f.Name] = string(data)
+	}
+	expected := map[string]string{
+		"panic.txt":    "goroutine 1 [running]",
+		"state.json":   "/usr/local/go",
+		"log_tail.txt": "last words",
+		"system.json":  "go_version",
+	}
+	for name, snippet := range expected {
+		if !strings.Contains(contents[name], snippet) {
+			t.Errorf("Expected %s to contain %q, got %q", name, snippet, contents[name])
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Sometimes Go should be gone from the environment but not from the disk.
+// `fu-go deactivate` takes an installation off PATH and leaves its files
+// alone: profile lines and the Windows user PATH entries that mention it are
+// removed, as are the symlinks into it (in the bin directories, and a stable
+// link such as /usr/local/go) and, once every Go of asdf or goenv is
+// deactivated, that manager's go and gofmt shims. Every change is recorded in
+// the environment journal, so `fu-go undo-env` turns it back on.
+
+const (
+	envChangeLink    = "link"    // a symlink removed; Link is where it pointed
+	envChangeRemoved = "removed" // a file removed; Backup is a copy of it
+)
+
+// shimNames are the shims a version manager puts on PATH for Go.
+var shimNames = []string{"go", "gofmt"}
+
+// shimDirs are the directories of shims of the managers that have them.
+func shimDirs() map[string][]string {
+	return map[string][]string{
+		"asdf":  envOrHomePaths("ASDF_DATA_DIR", ".asdf", "shims"),
+		"goenv": envOrHomePaths("GOENV_ROOT", ".goenv", "shims"),
+	}
+}
+
+// unlinkItem removes a symlink into an installation being deactivated and
+// journals where it pointed.
+type unlinkItem struct {
+	link string
+	dest string
+}
+
+func (i unlinkItem) Kind() PlanItemKind { return PlanSymlink }
+func (i unlinkItem) Target() string     { return i.link }
+func (i unlinkItem) Size() int64        { return 0 }
+func (i unlinkItem) Files() int64       { return 1 }
+func (i unlinkItem) Risk() RiskLevel    { return RiskLow }
+
+func (i unlinkItem) Describe() string {
+	return fmt.Sprintf("Unlink %s -> %s", i.link, i.dest)
+}
+
+func (i unlinkItem) Operations() []string {
+	return []string{"rm " + quoteOperand(i.link)}
+}
+
+func (i unlinkItem) Execute(env planEnv) error {
+	if dest, err := os.Readlink(i.link); err != nil || dest != i.dest {
+		return fmt.Errorf("%s no longer points at %s", i.link, i.dest)
+	}
+	if err := fsys.RemoveAll(i.link); err != nil {
+		return err
+	}
+	return appendEnvChange(env.journal, envChange{Time: time.Now(), Kind: envChangeLink, Target: i.link, Link: i.dest})
+}
+
+// shimItem removes a version manager's shim, keeping a copy to restore.
+type shimItem struct {
+	manager string
+	path    string
+}
+
+func (i shimItem) Kind() PlanItemKind { return PlanEnvEdit }
+func (i shimItem) Target() string     { return i.path }
+func (i shimItem) Size() int64        { return 0 }
+func (i shimItem) Files() int64       { return 1 }
+func (i shimItem) Risk() RiskLevel    { return RiskLow }
+
+func (i shimItem) Describe() string {
+	return fmt.Sprintf("Remove the %s shim %s", i.manager, i.path)
+}
+
+func (i shimItem) Operations() []string {
+	return []string{"rm " + quoteOperand(i.path)}
+}
+
+func (i shimItem) Execute(env planEnv) error {
+	info, err := os.Lstat(i.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(i.path)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	dir := filepath.Join(env.backupDir, "shims", i.manager)
+	change := envChange{Time: now, Kind: envChangeRemoved, Target: i.path, Backup: backupName(filepath.Join(dir, filepath.Base(i.path)), now)}
+	if err := fsys.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %v", err)
+	}
+	if err := fsys.WriteFile(change.Backup, data, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to back up %s: %v", i.path, err)
+	}
+	if err := fsys.RemoveAll(i.path); err != nil {
+		return err
+	}
+	return appendEnvChange(env.journal, change)
+}
+
+// matchDeactivations picks the installations named by paths, at their own
+// path or a link they were found through.
+func matchDeactivations(installations []GoInstallation, paths []string) ([]GoInstallation, error) {
+	var chosen []GoInstallation
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		found := false
+		for _, install := range installations {
+			if install.Path == path || slices.Contains(install.Links, path) {
+				chosen = append(chosen, install)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s is not a detected Go installation", path)
+		}
+	}
+	return chosen, nil
+}
+
+// deactivationPlan is everything that puts chosen on PATH: the links into
+// them and the links they were found through, the shims of a manager none of
+// whose Go stays active, then the profile and PATH entries mentioning them.
+func deactivationPlan(chosen, inventory []GoInstallation, goos, home string, getenv func(string) string) []PlanItem {
+	var items []PlanItem
+	var roots []string
+	for _, install := range chosen {
+		roots = append(roots, install.Path)
+		for _, link := range install.Links {
+			if dest, err := os.Readlink(link); err == nil {
+				items = append(items, unlinkItem{link: link, dest: dest})
+			}
+			roots = append(roots, link)
+		}
+	}
+	var dirs []string
+	for _, dir := range symlinkDirs(home) {
+		if currentScope().covers(pathScope(dir, home)) {
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, item := range findGoSymlinks(dirs, roots) {
+		link := item.(symlinkItem)
+		items = append(items, unlinkItem{link: link.link, dest: link.dest})
+	}
+
+	shims := shimDirs()
+	managers := make([]string, 0, len(shims))
+	for manager := range shims {
+		managers = append(managers, manager)
+	}
+	slices.Sort(managers)
+	for _, manager := range managers {
+		deactivated, active := false, false
+		for _, install := range inventory {
+			if install.Source != manager || install.User != "" {
+				continue
+			}
+			if slices.ContainsFunc(chosen, func(c GoInstallation) bool { return c.Path == install.Path }) {
+				deactivated = true
+			} else {
+				active = true
+			}
+		}
+		if !deactivated || active {
+			continue
+		}
+		for _, dir := range shims[manager] {
+			for _, name := range shimNames {
+				path := filepath.Join(dir, name)
+				if info, err := os.Lstat(path); err == nil && info.Mode().IsRegular() {
+					items = append(items, shimItem{manager: manager, path: path})
+				}
+			}
+		}
+	}
+
+	for _, edit := range proposeProfileEdits(goos, home, getenv, roots) {
+		for i := range edit.Hunks {
+			edit.Hunks[i].Accept = true
+		}
+		items = append(items, envEditItem{edit: edit})
+	}
+	return items
+}
+
+// deactivate runs items, or with dryRun only lists them, and reports on each.
+func deactivate(w io.Writer, items []PlanItem, env planEnv, dryRun bool) error {
+	if len(items) == 0 {
+		fmt.Fprintln(w, "Nothing puts it on PATH; nothing to do")
+		return nil
+	}
+	if dryRun {
+		for _, item := range items {
+			for _, op := range item.Operations() {
+				fmt.Fprintln(w, op)
+			}
+		}
+		return nil
+	}
+	failed := 0
+	for _, result := range executePlan(items, env) {
+		if result.err != nil {
+			failed++
+			fmt.Fprintf(w, "✗ %s: %v\n", result.item.Describe(), result.err)
+			continue
+		}
+		fmt.Fprintf(w, "✓ %s\n", result.item.Describe())
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d change(s) failed", failed, len(items))
+	}
+	fmt.Fprintln(w, "Run fu-go undo-env to turn it back on")
+	return nil
+}
+
+func newDeactivateCmd(opts *runOptions) *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "deactivate <path>...",
+		Short: "Take Go installations off PATH without deleting them",
+		Long:  "deactivate removes what puts the named installations on PATH and leaves their files in place: profile lines\nand Windows user PATH entries that mention them, symlinks into them from the bin directories, a stable link such\nas /usr/local/go they were found through, and the asdf or goenv shims once none of that manager's Go is left\nactive. Each change is journaled; fu-go undo-env reverts them.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if lock := liveModeLock(); lock != "" && !dryRun {
+				return fmt.Errorf("live removals are disabled: %s", lock)
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			paths, err := resolvePaths(cfg)
+			if err != nil {
+				return err
+			}
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return err
+			}
+			inventory := detectGoInstallations(cfg)
+			chosen, err := matchDeactivations(inventory, args)
+			if err != nil {
+				return err
+			}
+			items := deactivationPlan(chosen, inventory, runtime.GOOS, home, os.Getenv)
+			if dryRun {
+				return deactivate(cmd.OutOrStdout(), items, planEnv{}, true)
+			}
+			logger, err := newConfiguredLogger(*opts, paths.Logs)
+			if err != nil {
+				return err
+			}
+			defer logger.Close()
+			err = deactivate(cmd.OutOrStdout(), items, planEnv{backupDir: paths.Backups, journal: paths.Journal}, false)
+			for _, install := range chosen {
+				if err != nil {
+					logger.Log("ERROR", fmt.Sprintf("Deactivating %s: %v", install.Path, err))
+				} else {
+					logger.Log("SUCCESS", fmt.Sprintf("Deactivated %s; its files were left in place", install.Path))
+				}
+			}
+			return err
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list the changes without making them")
+	return cmd
+}
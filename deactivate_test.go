@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDeactivateAndUndo(t *testing.T) {
+	skipInAuditBuild(t)
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+	home, target, link := versionedGoRoots(t)
+	profile := filepath.Join(home, ".bashrc")
+	os.WriteFile(profile, []byte("alias ll='ls -l'\nexport PATH=$PATH:$HOME/opt/go1.22/bin\n"), 0644)
+	asdf := filepath.Join(home, ".asdf")
+	t.Setenv("ASDF_DATA_DIR", asdf)
+	os.MkdirAll(filepath.Join(asdf, "shims"), 0755)
+	shim := filepath.Join(asdf, "shims", "go")
+	os.WriteFile(shim, []byte("#!/usr/bin/env bash\nexec asdf exec \"go\" \"$@\"\n"), 0755)
+	managed := filepath.Join(asdf, "installs", "golang", "1.21.0", "go")
+	os.MkdirAll(managed, 0755)
+
+	install := GoInstallation{Path: target, Links: []string{link}, Source: "official"}
+	inventory := []GoInstallation{install, {Path: managed, Source: "asdf"}}
+	chosen, err := matchDeactivations(inventory, []string{link, managed})
+	if err != nil || len(chosen) != 2 {
+		t.Fatalf("Expected both installations, one named by its link, got %v, %v", chosen, err)
+	}
+	items := deactivationPlan(chosen, inventory, "linux", home, func(string) string { return "" })
+	var out bytes.Buffer
+	if err := deactivate(&out, items, planEnv{}, true); err != nil {
+		t.Fatalf("dry run returned error: %v", err)
+	}
+	for _, want := range []string{"rm " + quoteOperand(link), "rm " + quoteOperand(filepath.Join(home, "bin", "go")), "rm " + quoteOperand(shim), profile} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected the dry run to list %q, got:\n%s", want, out.String())
+		}
+	}
+
+	state := t.TempDir()
+	env := planEnv{backupDir: filepath.Join(state, "backups"), journal: filepath.Join(state, "env-journal.jsonl")}
+	out.Reset()
+	if err := deactivate(&out, items, env, false); err != nil {
+		t.Fatalf("deactivate returned error: %v\n%s", err, out.String())
+	}
+	for _, path := range []string{link, filepath.Join(home, "bin", "go"), shim} {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", path, err)
+		}
+	}
+	if got := readString(t, profile); strings.Contains(got, "go1.22") {
+		t.Errorf("Expected the PATH line to be removed, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(target, "bin", "go")); err != nil {
+		t.Errorf("Expected the files to be left in place: %v", err)
+	}
+
+	out.Reset()
+	saved := fsys
+	recorder := &recordingFS{fileSystem: saved}
+	fsys = recorder
+	defer func() { fsys = saved }()
+	if undone, err := undoEnv(&out, env.journal, false); err != nil || undone != 4 {
+		t.Fatalf("Expected 4 changes undone, got %d, %v\n%s", undone, err, out.String())
+	}
+	for _, want := range []string{"link " + link, "write " + shim} {
+		if !slices.Contains(recorder.changed, want) {
+			t.Errorf("Expected %q to go through the file system seam, got %v", want, recorder.changed)
+		}
+	}
+	if dest, _ := os.Readlink(link); dest != target {
+		t.Errorf("Expected %s to point at %s again, got %q", link, target, dest)
+	}
+	if _, err := os.Stat(filepath.Join(home, "bin", "go")); err != nil {
+		t.Errorf("Expected ~/bin/go to work again: %v", err)
+	}
+	if info, err := os.Stat(shim); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("Expected the shim back and executable, got %v, %v", info, err)
+	}
+	if got := readString(t, profile); !strings.Contains(got, "go1.22") {
+		t.Errorf("Expected the PATH line back, got %q", got)
+	}
+}
+
+func TestShimsStayWhileManagerHasActiveGo(t *testing.T) {
+	home := t.TempDir()
+	asdf := filepath.Join(home, ".asdf")
+	t.Setenv("ASDF_DATA_DIR", asdf)
+	os.MkdirAll(filepath.Join(asdf, "shims"), 0755)
+	os.WriteFile(filepath.Join(asdf, "shims", "go"), []byte("#!/bin/sh\n"), 0755)
+	inventory := []GoInstallation{
+		{Path: filepath.Join(asdf, "installs", "golang", "1.21.0", "go"), Source: "asdf"},
+		{Path: filepath.Join(asdf, "installs", "golang", "1.22.0", "go"), Source: "asdf"},
+	}
+	for _, item := range deactivationPlan(inventory[:1], inventory, "linux", home, func(string) string { return "" }) {
+		if _, ok := item.(shimItem); ok {
+			t.Errorf("Expected the shims to stay while asdf has another Go, got %s", item.Describe())
+		}
+	}
+	if _, err := matchDeactivations(inventory, []string{"/usr/local/go"}); err == nil {
+		t.Errorf("Expected an undetected path to be refused")
+	}
+}
+
+func TestDeactivateHonoursLiveModeLock(t *testing.T) {
+	withPolicy(t, Policy{RequireApproval: true})
+	cmd := newDeactivateCmd(&runOptions{})
+	cmd.SetArgs([]string{"/usr/local/go"})
+	cmd.SetOut(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "live removals are disabled") {
+		t.Errorf("Expected the policy to refuse a live deactivation, got %v", err)
+	}
+}
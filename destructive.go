@@ -0,0 +1,82 @@
+//go:build !audit
+
+package main
+
+import "os"
+
+// Every irreversible operation fu-go performs goes through the functions in
+// this file. Building with -tags audit swaps them for refusing stubs (see
+// destructive_audit.go), producing a binary that can detect, size and report
+// but contains no code able to remove or move anything.
+
+const auditBuild = false
+
+// removePath deletes a single file or empty directory.
+func removePath(path string) error {
+	return os.Remove(path)
+}
+
+// removeAllPaths deletes path and everything below it.
+func removeAllPaths(path string) error {
+	return os.RemoveAll(path)
+}
+
+// chmodPath changes the mode of path.
+func chmodPath(path string, mode os.FileMode) error {
+	return os.Chmod(path, mode)
+}
+
+// makeDirs creates path and any parents it lacks.
+func makeDirs(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+// writeFile replaces the contents of path, creating it with perm if needed.
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(path, data, perm)
+}
+
+// appendFile adds data to the end of path, creating it with perm if needed.
+func appendFile(path string, data []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// runRemovalCommand runs a package manager or plugin removal command line.
+func runRemovalCommand(args []string) ([]byte, error) {
+	return commandCombinedOutputTimeout(removalCommandTimeout, args[0], args[1:]...)
+}
+
+// renamePath moves path within its file system, for quarantining an
+// installation into a backup set.
+func renamePath(from, to string) error {
+	return os.Rename(from, to)
+}
+
+// moveExecutable renames a binary, for moving fu-go aside or swapping in an
+// update.
+func moveExecutable(from, to string) error {
+	return os.Rename(from, to)
+}
+
+// replaceSymlink points link at target by renaming a new link over it, so
+// the link never goes missing in between.
+func replaceSymlink(target, link string) error {
+	tmp := link + ".fugo-new"
+	os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
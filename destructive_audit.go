@@ -0,0 +1,57 @@
+//go:build audit
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// The read-only audit build, made with -tags audit. Auditors can be handed it
+// knowing it cannot delete anything: these stubs replace every destructive
+// operation, so no removal code is compiled in at all, and every change the
+// file system seam would make is refused.
+
+const auditBuild = true
+
+var errAuditBuild = errors.New("this is a read-only audit build of fu-go; it cannot remove or modify installations")
+
+func removePath(path string) error {
+	return errAuditBuild
+}
+
+func removeAllPaths(path string) error {
+	return errAuditBuild
+}
+
+func chmodPath(path string, mode os.FileMode) error {
+	return errAuditBuild
+}
+
+func makeDirs(path string, perm os.FileMode) error {
+	return errAuditBuild
+}
+
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	return errAuditBuild
+}
+
+func appendFile(path string, data []byte, perm os.FileMode) error {
+	return errAuditBuild
+}
+
+func runRemovalCommand(args []string) ([]byte, error) {
+	return nil, errAuditBuild
+}
+
+func renamePath(from, to string) error {
+	return errAuditBuild
+}
+
+func moveExecutable(from, to string) error {
+	return errAuditBuild
+}
+
+func replaceSymlink(target, link string) error {
+	return errAuditBuild
+}
@@ -0,0 +1,59 @@
+//go:build audit
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditBuildCannotRemove(t *testing.T) {
+	root := t.TempDir()
+	goRoot := filepath.Join(root, "go")
+	if err := os.MkdirAll(filepath.Join(goRoot, "bin"), 0755); err != nil {
+		t.Fatalf("Failed to create Go root: %v", err)
+	}
+
+	if err := removeTree(goRoot, true); !errors.Is(err, errAuditBuild) {
+		t.Errorf("Expected removeTree to refuse, got %v", err)
+	}
+	if err := runPackageRemoval(GoInstallation{Path: goRoot, Package: "golang", PackageManager: "apk"}); err == nil {
+		t.Errorf("Expected package removal to refuse")
+	}
+	if _, err := relocateSelf(filepath.Join(goRoot, "bin"), root); err == nil {
+		t.Errorf("Expected relocating the binary to refuse")
+	}
+	if _, err := os.Stat(goRoot); err != nil {
+		t.Errorf("Expected %s to survive: %v", goRoot, err)
+	}
+	if !(Config{DefaultMode: "live"}).startsInDryRun() {
+		t.Errorf("Expected the audit build to always start in dry-run mode")
+	}
+
+	profile := filepath.Join(root, ".profile")
+	os.WriteFile(profile, []byte("export PATH=$PATH:/usr/local/go/bin\n"), 0644)
+	for name, err := range map[string]error{
+		"Remove":     fsys.Remove(profile),
+		"Chmod":      fsys.Chmod(profile, 0600),
+		"MkdirAll":   fsys.MkdirAll(filepath.Join(root, "new"), 0755),
+		"WriteFile":  fsys.WriteFile(profile, nil, 0644),
+		"AppendFile": fsys.AppendFile(profile, []byte("x"), 0644),
+		"Symlink":    fsys.Symlink(goRoot, filepath.Join(root, "link")),
+		"Rename":     fsys.Rename(goRoot, filepath.Join(root, "moved")),
+	} {
+		if !errors.Is(err, errAuditBuild) {
+			t.Errorf("Expected %s to refuse, got %v", name, err)
+		}
+	}
+	if data, _ := os.ReadFile(profile); len(data) == 0 {
+		t.Errorf("Expected %s to be left as it was", profile)
+	}
+
+	for _, name := range []string{"restore", "self-update", "container-prune", "deactivate", "undo-env", "serve", "service", "exec-elevated"} {
+		if cmd, _, err := newRootCmd().Find([]string{name}); err == nil && cmd.Name() == name {
+			t.Errorf("Expected the audit build to have no %s command", name)
+		}
+	}
+}
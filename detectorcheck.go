@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// detectorCheck is what `fu-go detectors` reports about one detector: whether
+// it would run here, what it looks at and what a dry probe found.
+type detectorCheck struct {
+	Name     string        `json:"name"`
+	Enabled  bool          `json:"enabled"`
+	Applies  bool          `json:"applies"`
+	Probes   []string      `json:"probes,omitempty"`
+	Status   string        `json:"status"` // ok, error, timeout, disabled, not applicable or out of scope
+	Error    string        `json:"error,omitempty"`
+	Found    []string      `json:"found,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// checkDetectors describes every detector and runs the ones that would run
+// during a normal detection. Nothing is removed or written; a probe only
+// reads directories and runs the same commands detection does.
+func checkDetectors(cfg Config, detectors []Detector, goos string, timeout time.Duration) []detectorCheck {
+	checks := make([]detectorCheck, len(detectors))
+	var run []Detector
+	var runIndex []int
+	for i, detector := range detectors {
+		check := detectorCheck{Name: detector.Name(), Enabled: cfg.detectorEnabled(detector.Name()), Applies: true}
+		if probing, ok := detector.(probingDetector); ok {
+			check.Applies = probing.applies(goos)
+			check.Probes = probing.probes()
+		}
+		switch {
+		case !check.Enabled:
+			check.Status = "disabled"
+		case !check.Applies:
+			check.Status = "not applicable"
+		case !currentScope().covers(detectorScope(detector)):
+			check.Status = "out of scope"
+		default:
+			run = append(run, detector)
+			runIndex = append(runIndex, i)
+		}
+		checks[i] = check
+	}
+
+	for j, result := range runDetectors(context.Background(), run, timeout) {
+		check := &checks[runIndex[j]]
+		check.Duration = result.duration
+		for _, install := range result.installations {
+			check.Found = append(check.Found, install.Path)
+		}
+		switch {
+		case result.timedOut:
+			check.Status = "timeout"
+		case result.err != nil:
+			check.Status = "error"
+		default:
+			check.Status = "ok"
+		}
+		if result.err != nil {
+			check.Error = result.err.Error()
+		}
+	}
+	return checks
+}
+
+func writeDetectorChecks(w io.Writer, checks []detectorCheck, format string) error {
+	switch format {
+	case "table":
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(checks)
+	default:
+		return fmt.Errorf("unknown format %q (expected table or json)", format)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DETECTOR\tENABLED\tAPPLIES\tSTATUS\tFOUND\tTIME")
+	for _, check := range checks {
+		fmt.Fprintf(tw, "%s\t%t\t%t\t%s\t%d\t%s\n", check.Name, check.Enabled, check.Applies, check.Status, len(check.Found), check.Duration.Round(time.Millisecond))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, check := range checks {
+		fmt.Fprintf(w, "\n%s\n", check.Name)
+		if len(check.Probes) == 0 {
+			fmt.Fprintln(w, "  probes: nothing on this machine")
+		}
+		for _, probe := range check.Probes {
+			fmt.Fprintf(w, "  probes: %s\n", probe)
+		}
+		for _, path := range check.Found {
+			fmt.Fprintf(w, "  found:  %s\n", path)
+		}
+		if check.Error != "" {
+			fmt.Fprintf(w, "  error:  %s\n", check.Error)
+		}
+	}
+	return nil
+}
+
+func newDetectorsCmd(opts *runOptions) *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "detectors",
+		Short: "Show every detector, what it probes and what a dry probe finds",
+		Long:  "detectors lists the built-in detectors and plugins, whether each is enabled and applies on this platform,\nthe paths or commands it probes, and the result of running it once. Nothing is changed; use it to find out\nwhy an installation was not detected.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if opts.trace {
+				paths, err := resolvePaths(cfg)
+				if err != nil {
+					return err
+				}
+				logger, err := newConfiguredLogger(*opts, paths.Logs)
+				if err != nil {
+					return err
+				}
+				defer logger.Close()
+			}
+			timeout, err := cfg.detectorTimeout()
+			if err != nil {
+				return err
+			}
+			checks := checkDetectors(cfg, allDetectors(), runtime.GOOS, timeout)
+			return writeDetectorChecks(cmd.OutOrStdout(), checks, format)
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "table", "output format: table or json")
+	return cmd
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckDetectors(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "go1.22.5"), 0755)
+	detectors := []Detector{
+		dirDetector{name: "versions", parents: func() []string { return []string{root} }},
+		dirDetector{name: "elsewhere", goos: []string{"plan9"}},
+		dirDetector{name: "off"},
+		slowDetector{delay: time.Second},
+	}
+	cfg := Config{DisabledDetectors: []string{"off"}}
+	checks := checkDetectors(cfg, detectors, "linux", 50*time.Millisecond)
+
+	want := []string{"ok", "not applicable", "disabled", "timeout"}
+	for i, check := range checks {
+		if check.Status != want[i] {
+			t.Errorf("%s: expected status %q, got %q", check.Name, want[i], check.Status)
+		}
+	}
+	if len(checks[0].Probes) != 1 || checks[0].Probes[0] != filepath.Join(root, "*") {
+		t.Errorf("Expected the parent directory to be listed as a probe, got %v", checks[0].Probes)
+	}
+	if len(checks[0].Found) != 1 || checks[0].Found[0] != filepath.Join(root, "go1.22.5") {
+		t.Errorf("Expected the version directory to be found, got %v", checks[0].Found)
+	}
+	if checks[1].Applies || checks[2].Enabled || !checks[3].Applies {
+		t.Errorf("Unexpected applicability: %+v", checks)
+	}
+	if checks[3].Error == "" {
+		t.Errorf("Expected the timeout to be explained")
+	}
+}
+
+func TestWriteDetectorChecks(t *testing.T) {
+	checks := []detectorCheck{{Name: "gvm", Enabled: true, Applies: true, Probes: []string{"/home/gopher/.gvm/gos/*"}, Status: "ok"}}
+
+	var buf bytes.Buffer
+	if err := writeDetectorChecks(&buf, checks, "table"); err != nil {
+		t.Fatalf("writeDetectorChecks returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "DETECTOR") || !strings.Contains(buf.String(), "probes: /home/gopher/.gvm/gos/*") {
+		t.Errorf("Unexpected table:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeDetectorChecks(&buf, checks, "json"); err != nil {
+		t.Fatalf("writeDetectorChecks returned error: %v", err)
+	}
+	var decoded []detectorCheck
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded) != 1 || decoded[0].Status != "ok" {
+		t.Errorf("Expected the checks back from JSON, got %+v (%v)", decoded, err)
+	}
+
+	if err := writeDetectorChecks(&buf, checks, "yaml"); err == nil {
+		t.Errorf("Expected an unknown format to be rejected")
+	}
+}
@@ -0,0 +1,460 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Confidence expresses how sure a detector is that a candidate is really a Go
+// installation.
+type Confidence int
+
+const (
+	ConfidenceLow Confidence = iota
+	ConfidenceMedium
+	ConfidenceHigh
+)
+
+func (c Confidence) String() string {
+	switch c {
+	case ConfidenceHigh:
+		return "high"
+	case ConfidenceMedium:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+func (c Confidence) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Confidence) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "high":
+		*c = ConfidenceHigh
+	case "medium":
+		*c = ConfidenceMedium
+	case "low":
+		*c = ConfidenceLow
+	default:
+		return fmt.Errorf("unknown confidence level: %s", text)
+	}
+	return nil
+}
+
+// Detector finds Go installations managed by one installer or version manager.
+type Detector interface {
+	Name() string
+	Detect(ctx context.Context) ([]GoInstallation, error)
+}
+
+// probingDetector is implemented by detectors that can say, without running,
+// whether they apply to a platform and what they would look at. `fu-go
+// detectors` uses it to explain why an installation was not found.
+type probingDetector interface {
+	applies(goos string) bool
+	probes() []string
+}
+
+// detectorRegistry lists every built-in detector in the order their results
+// are presented.
+var detectorRegistry = []Detector{
+	officialDetector{},
+	dirDetector{
+		name:    "gvm",
+		parents: func() []string { return homePaths(".gvm", "gos") },
+		match:   func(name string) bool { return strings.HasPrefix(name, "go") },
+	},
+	dirDetector{
+		name:   "package_manager",
+		system: true,
+		goos:   []string{"linux"},
+		direct: func() []string { return []string{"/usr/lib/golang", "/usr/share/golang"} },
+	},
+	dirDetector{
+		name:    "brew",
+		system:  true,
+		goos:    []string{"darwin"},
+		parents: func() []string { return []string{"/usr/local/Cellar/go", "/opt/homebrew/Cellar/go"} },
+	},
+	dirDetector{
+		name:         "asdf",
+		parents:      func() []string { return envOrHomePaths("ASDF_DATA_DIR", ".asdf", "installs", "golang") },
+		goRootSubdir: "go",
+	},
+	dirDetector{
+		name:    "goenv",
+		parents: func() []string { return envOrHomePaths("GOENV_ROOT", ".goenv", "versions") },
+	},
+	dirDetector{
+		name:    "scoop",
+		goos:    []string{"windows"},
+		parents: func() []string { return envOrHomePaths("SCOOP", "scoop", "apps", "go") },
+		match:   func(name string) bool { return name != "current" },
+		manager: "scoop",
+		pkg:     "go",
+	},
+	dirDetector{
+		name:    "snap",
+		system:  true,
+		goos:    []string{"linux"},
+		parents: func() []string { return []string{"/snap/go"} },
+		match:   func(name string) bool { return name != "current" },
+		manager: "snap",
+		pkg:     "go",
+	},
+	dirDetector{
+		// golang.org/dl wrappers download toolchains to ~/sdk/go1.x.y
+		name:    "sdk",
+		parents: func() []string { return homePaths("sdk") },
+		match:   func(name string) bool { return strings.HasPrefix(name, "go1") },
+	},
+	dirDetector{
+		// GOTOOLCHAIN downloads, found through the user's GOMODCACHE
+		name:    "gotoolchain",
+		parents: moduleCacheToolchainDirs,
+		match:   func(name string) bool { return strings.HasPrefix(name, "toolchain@") },
+	},
+}
+
+// officialDetector checks the platform's canonical GOROOT locations.
+type officialDetector struct{}
+
+func (officialDetector) Name() string { return "official" }
+
+func (officialDetector) applies(goos string) bool { return true }
+
+func (officialDetector) scope() installScope { return scopeSystem }
+
+func (officialDetector) probes() []string { return officialGoPaths(runtime.GOOS) }
+
+func (officialDetector) Detect(ctx context.Context) ([]GoInstallation, error) {
+	var installations []GoInstallation
+	for _, path := range officialGoPaths(runtime.GOOS) {
+		if err := ctx.Err(); err != nil {
+			return installations, err
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			tracef("official: skip %s: not a directory", path)
+			continue
+		}
+		// On the BSDs, Alpine and Termux these paths are usually owned by
+		// the system package manager, which should do the removal
+		if pkgName, manager := packageOwner(runtime.GOOS, path); pkgName != "" {
+			tracef("official: %s is owned by %s package %s", path, manager, pkgName)
+			installations = append(installations, newPackageInstallation(path, manager, pkgName))
+			continue
+		}
+		tracef("official: accept %s", path)
+		install := newInstallation(path, "official")
+		install.Evidence = append([]string{fmt.Sprintf("official install location for %s", runtime.GOOS)}, install.Evidence...)
+		installations = append(installations, install)
+	}
+	return installations, nil
+}
+
+// dirDetector covers the common layouts: fixed GOROOT paths (direct) and
+// version-manager directories holding one GOROOT per subdirectory (parents).
+type dirDetector struct {
+	name         string
+	goos         []string // platforms the detector applies to; empty means all
+	direct       func() []string
+	parents      func() []string
+	match        func(name string) bool // filters subdirectories of parents
+	goRootSubdir string                 // GOROOT lives in <version>/<goRootSubdir>
+	manager      string                 // package manager that owns the installs
+	pkg          string
+	system       bool // looks outside the home directory
+}
+
+func (d dirDetector) Name() string { return d.name }
+
+// scope is all for the rest, as version managers can be pointed outside
+// the home directory; their finds are filtered by path instead.
+func (d dirDetector) scope() installScope {
+	if d.system {
+		return scopeSystem
+	}
+	return scopeAll
+}
+
+func (d dirDetector) applies(goos string) bool {
+	if len(d.goos) == 0 {
+		return true
+	}
+	for _, supported := range d.goos {
+		if supported == goos {
+			return true
+		}
+	}
+	return false
+}
+
+func (d dirDetector) probes() []string {
+	var probes []string
+	if d.direct != nil {
+		probes = append(probes, d.direct()...)
+	}
+	if d.parents != nil {
+		for _, parent := range d.parents() {
+			probes = append(probes, filepath.Join(parent, "*", d.goRootSubdir))
+		}
+	}
+	return probes
+}
+
+func (d dirDetector) Detect(ctx context.Context) ([]GoInstallation, error) {
+	if !d.applies(runtime.GOOS) {
+		tracef("%s: not applicable on %s", d.name, runtime.GOOS)
+		return nil, nil
+	}
+
+	type candidate struct{ path, evidence string }
+	var candidates []candidate
+	if d.direct != nil {
+		for _, path := range d.direct() {
+			candidates = append(candidates, candidate{path, fmt.Sprintf("known %s location", d.name)})
+		}
+	}
+	if d.parents != nil {
+		for _, parent := range d.parents() {
+			entries, err := os.ReadDir(parent)
+			if err != nil {
+				tracef("%s: skip %s: %v", d.name, parent, err)
+				continue
+			}
+			for _, entry := range entries {
+				if !entry.IsDir() || (d.match != nil && !d.match(entry.Name())) {
+					tracef("%s: skip %s: filtered", d.name, filepath.Join(parent, entry.Name()))
+					continue
+				}
+				candidates = append(candidates, candidate{
+					filepath.Join(parent, entry.Name(), d.goRootSubdir),
+					fmt.Sprintf("version directory %s in %s", entry.Name(), parent),
+				})
+			}
+		}
+	}
+
+	var installations []GoInstallation
+	for _, c := range candidates {
+		if err := ctx.Err(); err != nil {
+			return installations, err
+		}
+		if info, err := os.Stat(c.path); err != nil || !info.IsDir() {
+			tracef("%s: skip %s: not a directory", d.name, c.path)
+			continue
+		}
+		tracef("%s: accept %s", d.name, c.path)
+		install := newInstallation(c.path, d.name)
+		install.Evidence = append([]string{c.evidence}, install.Evidence...)
+		if d.manager != "" {
+			install.PackageManager = d.manager
+			install.Package = d.pkg
+		}
+		installations = append(installations, install)
+	}
+	return installations, nil
+}
+
+func homePaths(elem ...string) []string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{filepath.Join(append([]string{homeDir}, elem...)...)}
+}
+
+// envOrHomePaths honours a version manager's root override variable, e.g.
+// ASDF_DATA_DIR, falling back to its default location in the home directory.
+func envOrHomePaths(envVar string, elem ...string) []string {
+	if root := os.Getenv(envVar); root != "" {
+		return []string{filepath.Join(append([]string{root}, elem[1:]...)...)}
+	}
+	return homePaths(elem...)
+}
+
+type detectorResult struct {
+	name          string
+	installations []GoInstallation
+	err           error
+	timedOut      bool
+	duration      time.Duration
+}
+
+// detectorGrace is how long a detector that ran out of time gets to hand
+// back what it found before the deadline.
+const detectorGrace = 100 * time.Millisecond
+
+// detectorProgress reports one finished detector while the rest still run.
+type detectorProgress struct {
+	result detectorResult
+	done   int
+	total  int
+}
+
+// runDetectors runs every detector concurrently, each bounded by timeout. The
+// results keep the order of the detectors slice.
+func runDetectors(ctx context.Context, detectors []Detector, timeout time.Duration) []detectorResult {
+	return streamDetectors(ctx, detectors, timeout, nil)
+}
+
+// streamDetectors is runDetectors that also publishes each result on events
+// as soon as its detector finishes.
+func streamDetectors(ctx context.Context, detectors []Detector, timeout time.Duration, events *eventBus) []detectorResult {
+	results := make([]detectorResult, len(detectors))
+	done := make(chan int, len(detectors))
+	events.publish(engineEvent{Kind: eventDetectionStarted, Phase: phaseDetect, Total: len(detectors)})
+
+	for i, detector := range detectors {
+		go func(i int, detector Detector) {
+			results[i] = runDetector(ctx, detector, timeout)
+			done <- i
+		}(i, detector)
+	}
+	for n := 1; n <= len(detectors); n++ {
+		i := <-done
+		events.publish(engineEvent{Kind: eventDetectorFinished, Phase: phaseDetect, Detector: detectorProgress{result: results[i], done: n, total: len(detectors)}})
+	}
+	return results
+}
+
+func runDetector(ctx context.Context, detector Detector, timeout time.Duration) detectorResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		installations []GoInstallation
+		err           error
+	}
+	start := time.Now()
+	finished := make(chan outcome, 1)
+	go func() {
+		installations, err := detector.Detect(ctx)
+		finished <- outcome{installations, err}
+	}()
+
+	select {
+	case out := <-finished:
+		return detectorResult{name: detector.Name(), installations: out.installations, err: out.err, duration: time.Since(start)}
+	case <-ctx.Done():
+	}
+	// Detectors that watch ctx return what they had so far; keep it, but
+	// still mark the detector as timed out since it did not finish
+	result := detectorResult{
+		name:     detector.Name(),
+		err:      fmt.Errorf("detector %s timed out after %s", detector.Name(), timeout),
+		timedOut: true,
+	}
+	select {
+	case out := <-finished:
+		result.installations = out.installations
+	case <-time.After(detectorGrace):
+	}
+	result.duration = time.Since(start)
+	return result
+}
+
+// timedOutDetectors names the detectors that did not finish, i.e. where the
+// inventory may be missing installations.
+func timedOutDetectors(results []detectorResult) []string {
+	var names []string
+	for _, result := range results {
+		if result.timedOut {
+			names = append(names, result.name)
+		}
+	}
+	return names
+}
+
+// allDetectors returns the built-in detectors followed by any plugins.
+func allDetectors() []Detector {
+	detectors := append([]Detector{}, detectorRegistry...)
+	if dir, err := pluginDir(); err == nil {
+		detectors = append(detectors, discoverPlugins(dir)...)
+	}
+	return detectors
+}
+
+// enabledDetectors filters the registry and plugins through the user's config
+// and the scope. other_users only runs when asked for.
+func enabledDetectors(cfg Config) []Detector {
+	var enabled []Detector
+	detectors := allDetectors()
+	if cfg.AllUsers || searchAllUsers.Load() {
+		detectors = append(detectors, otherUsersDetector{})
+	}
+	for _, detector := range detectors {
+		if cfg.detectorEnabled(detector.Name()) && currentScope().covers(detectorScope(detector)) {
+			enabled = append(enabled, detector)
+		}
+	}
+	return enabled
+}
+
+// mergeDetectorResults flattens the per-detector results, keeping the first
+// report of any path found by more than one detector.
+func mergeDetectorResults(results []detectorResult) []GoInstallation {
+	installations := []GoInstallation{}
+	seen := make(map[string]int)
+	for _, result := range results {
+		for _, install := range result.installations {
+			install = followGoRootLink(install)
+			key := filepath.Clean(install.Path)
+			if i, ok := seen[key]; ok {
+				installations[i].Evidence = append(installations[i].Evidence, fmt.Sprintf("also reported by %s", result.name))
+				installations[i].Links = mergeLinks(installations[i].Links, install.Links)
+				continue
+			}
+			seen[key] = len(installations)
+			if install.Detector == "" {
+				install.Detector = result.name
+			}
+			installations = append(installations, install)
+		}
+	}
+	sortInventory(installations)
+	return installations
+}
+
+// renderDetectorProgress lists the detectors that have finished so far on the
+// loading screen.
+func (m model) renderDetectorProgress() string {
+	if len(m.detectorsDone) == 0 {
+		return ""
+	}
+	last := m.detectorsDone[len(m.detectorsDone)-1]
+	s := "\n" + infoStyle.Render(fmt.Sprintf("   %d of %d detectors finished", last.done, last.total)) + "\n"
+	for _, p := range m.detectorsDone {
+		result := p.result
+		switch {
+		case result.timedOut:
+			s += warningStyle.Render(fmt.Sprintf("   ⏱  %s timed out after %s", result.name, result.duration.Round(time.Millisecond))) + "\n"
+		case result.err != nil:
+			s += warningStyle.Render(fmt.Sprintf("   ⚠️  %s: %v", result.name, result.err)) + "\n"
+		default:
+			s += successStyle.Render(fmt.Sprintf("   ✅ %s (%d found, %s)", result.name, len(result.installations), result.duration.Round(time.Millisecond))) + "\n"
+		}
+	}
+	return s
+}
+
+// renderDetectorCoverage warns that the inventory may be incomplete when a
+// detector ran out of time.
+func (m model) renderDetectorCoverage() string {
+	names := timedOutDetectors(m.detectorResults)
+	if len(names) == 0 {
+		return ""
+	}
+	s := warningStyle.Render(fmt.Sprintf("⏱  Partial coverage: %d detector(s) timed out: %s", len(names), strings.Join(names, ", "))) + "\n"
+	s += infoStyle.Render("   Installations they would have found may be missing; raise detector_timeout in the config to wait longer") + "\n\n"
+	return s
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type slowDetector struct {
+	delay time.Duration
+}
+
+func (slowDetector) Name() string { return "slow" }
+
+func (d slowDetector) Detect(ctx context.Context) ([]GoInstallation, error) {
+	select {
+	case <-time.After(d.delay):
+		return []GoInstallation{{Path: "/slow/go", Source: "slow"}}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// partialDetector finds one installation straight away and then hangs until
+// its deadline.
+type partialDetector struct{}
+
+func (partialDetector) Name() string { return "partial" }
+
+func (partialDetector) Detect(ctx context.Context) ([]GoInstallation, error) {
+	<-ctx.Done()
+	return []GoInstallation{{Path: "/partial/go", Source: "partial"}}, ctx.Err()
+}
+
+func TestDirDetector(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, dir := range []string{"1.21.0/go/bin", "1.22.5/go/bin", "notes"} {
+		if err := os.MkdirAll(filepath.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "1.22.5", "go", "VERSION"), []byte("go1.22.5\ntime 2024-07-02T00:00:00Z\n"), 0644); err != nil {
+		t.Fatalf("Failed to write VERSION: %v", err)
+	}
+
+	detector := dirDetector{
+		name:         "asdf",
+		parents:      func() []string { return []string{tempDir} },
+		goRootSubdir: "go",
+	}
+	installations, err := detector.Detect(context.Background())
+	if err != nil {
+		t.Fatalf("Detect returned error: %v", err)
+	}
+	if len(installations) != 2 {
+		t.Fatalf("Expected 2 installations, got %d", len(installations))
+	}
+	for _, install := range installations {
+		if install.Source != "asdf" {
+			t.Errorf("Expected source asdf, got %s", install.Source)
+		}
+		if filepath.Base(install.Path) != "go" {
+			t.Errorf("Expected GOROOT subdirectory, got %s", install.Path)
+		}
+	}
+	if installations[1].SemVer != "1.22.5" || installations[1].Confidence != ConfidenceMedium {
+		t.Errorf("Expected 1.22.5 with medium confidence, got %s with %s", installations[1].SemVer, installations[1].Confidence)
+	}
+	if installations[0].Confidence != ConfidenceLow {
+		t.Errorf("Expected low confidence without VERSION or bin/go, got %s", installations[0].Confidence)
+	}
+
+	evidence := strings.Join(installations[1].Evidence, "\n")
+	for _, expected := range []string{"version directory 1.22.5 in " + tempDir, `reads "go1.22.5"`} {
+		if !strings.Contains(evidence, expected) {
+			t.Errorf("Expected evidence to contain %q, got:\n%s", expected, evidence)
+		}
+	}
+}
+
+func TestDirDetectorPlatformFilter(t *testing.T) {
+	detector := dirDetector{
+		name:   "other",
+		goos:   []string{"plan9"},
+		direct: func() []string { return []string{"."} },
+	}
+	installations, err := detector.Detect(context.Background())
+	if err != nil || len(installations) != 0 {
+		t.Errorf("Expected no results on unsupported platform, got %v (%v)", installations, err)
+	}
+}
+
+func TestRunDetectorsTimeout(t *testing.T) {
+	results := runDetectors(context.Background(), []Detector{
+		slowDetector{delay: time.Second},
+		dirDetector{name: "empty"},
+	}, 50*time.Millisecond)
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if !results[0].timedOut || results[0].err == nil {
+		t.Errorf("Expected slow detector to time out, got %+v", results[0])
+	}
+	if results[1].timedOut || results[1].err != nil {
+		t.Errorf("Expected empty detector to succeed, got %+v", results[1])
+	}
+}
+
+func TestStreamDetectors(t *testing.T) {
+	bus := newEventBus()
+	events := bus.subscribe()
+	results := streamDetectors(context.Background(), []Detector{
+		slowDetector{delay: time.Second},
+		partialDetector{},
+		dirDetector{name: "empty"},
+	}, 50*time.Millisecond, bus)
+	bus.close()
+
+	if started := <-events; started.Kind != eventDetectionStarted || started.Total != 3 {
+		t.Fatalf("Expected detection to start with 3 detectors, got %+v", started)
+	}
+	var order []string
+	for e := range events {
+		p := e.Detector
+		order = append(order, p.result.name)
+		if p.done != len(order) || p.total != 3 {
+			t.Errorf("Expected %d of 3, got %d of %d", len(order), p.done, p.total)
+		}
+	}
+	if len(order) != 3 || order[0] != "empty" {
+		t.Errorf("Expected the fast detector to be reported first, got %v", order)
+	}
+	if !results[1].timedOut || len(results[1].installations) != 1 {
+		t.Errorf("Expected a timed out detector to keep what it found, got %+v", results[1])
+	}
+	if names := timedOutDetectors(results); strings.Join(names, ",") != "slow,partial" {
+		t.Errorf("Expected slow and partial to have timed out, got %v", names)
+	}
+}
+
+// TestDetectorProgressStress streams many detectors, some timing out, into
+// the model through the same commands the TUI runs; run it with -race.
+func TestDetectorProgressStress(t *testing.T) {
+	var detectors []Detector
+	for i := range 120 {
+		switch i % 4 {
+		case 0:
+			detectors = append(detectors, slowDetector{delay: time.Second})
+		case 1:
+			detectors = append(detectors, partialDetector{})
+		default:
+			detectors = append(detectors, slowDetector{delay: time.Duration(i%7) * time.Millisecond})
+		}
+	}
+	bus := newEventBus()
+	events := bus.subscribe()
+	go func() {
+		defer bus.close()
+		streamDetectors(context.Background(), detectors, 50*time.Millisecond, bus)
+	}()
+
+	m := model{state: "loading"}
+	cmd := waitForEvent(events)
+	for cmd != nil {
+		msg := cmd()
+		if msg == nil {
+			break
+		}
+		updated, next := m.Update(msg)
+		m, cmd = updated.(model), next
+		m.renderDetectorProgress()
+	}
+	if len(m.detectorsDone) != len(detectors) {
+		t.Fatalf("Expected %d detectors reported, got %d", len(detectors), len(m.detectorsDone))
+	}
+	for i, p := range m.detectorsDone {
+		if p.done != i+1 || p.total != len(detectors) {
+			t.Fatalf("Expected %d of %d at position %d, got %d of %d", i+1, len(detectors), i, p.done, p.total)
+		}
+	}
+}
+
+func TestDetectorProgressView(t *testing.T) {
+	bus := newEventBus()
+	events := bus.subscribe()
+	m := model{state: "loading"}
+	finished := engineEvent{Kind: eventDetectorFinished, Detector: detectorProgress{result: detectorResult{name: "wsl", timedOut: true, duration: 10 * time.Second}, done: 1, total: 4}}
+	updated, cmd := m.Update(engineEventMsg{event: finished, next: events})
+	m = updated.(model)
+	if len(m.detectorsDone) != 1 || cmd == nil {
+		t.Fatalf("Expected the result to be kept and the next one awaited, got %+v", m.detectorsDone)
+	}
+	if view := m.renderDetectorProgress(); !strings.Contains(view, "1 of 4") || !strings.Contains(view, "wsl timed out") {
+		t.Errorf("Unexpected progress view:\n%s", view)
+	}
+	bus.close()
+	if msg := cmd(); msg != nil {
+		t.Errorf("Expected nothing once detection is over, got %#v", msg)
+	}
+
+	m.detectorResults = []detectorResult{{name: "official"}, {name: "wsl", timedOut: true}}
+	if view := m.renderDetectorCoverage(); !strings.Contains(view, "Partial coverage") || !strings.Contains(view, "wsl") {
+		t.Errorf("Expected the timed out detector to be called out, got:\n%s", view)
+	}
+}
+
+func TestMergeDetectorResults(t *testing.T) {
+	results := []detectorResult{
+		{name: "official", installations: []GoInstallation{{Path: "/usr/local/go", Source: "official"}}},
+		{name: "other", installations: []GoInstallation{{Path: "/usr/local/go/", Source: "other"}, {Path: "/opt/go", Source: "other"}}},
+	}
+
+	installations := mergeDetectorResults(results)
+	if len(installations) != 2 {
+		t.Fatalf("Expected 2 installations after dedupe, got %d", len(installations))
+	}
+	if installations[0].Source != "official" {
+		t.Errorf("Expected first detector to win, got %s", installations[0].Source)
+	}
+	if installations[0].Detector != "official" || installations[1].Detector != "other" {
+		t.Errorf("Expected detectors official and other, got %s and %s", installations[0].Detector, installations[1].Detector)
+	}
+	if len(installations[0].Evidence) != 1 || installations[0].Evidence[0] != "also reported by other" {
+		t.Errorf("Expected the duplicate report to be kept as evidence, got %v", installations[0].Evidence)
+	}
+}
+
+func TestEnabledDetectors(t *testing.T) {
+	cfg := Config{DisabledDetectors: []string{"snap"}}
+	for _, detector := range enabledDetectors(cfg) {
+		if detector.Name() == "snap" {
+			t.Error("Expected snap detector to be disabled")
+		}
+	}
+
+	cfg = Config{EnabledDetectors: []string{"gvm", "asdf"}, DisabledDetectors: []string{"asdf"}}
+	enabled := enabledDetectors(cfg)
+	if len(enabled) != 1 || enabled[0].Name() != "gvm" {
+		t.Errorf("Expected only gvm, got %d detectors", len(enabled))
+	}
+}
+
+func TestConfidenceJSON(t *testing.T) {
+	data, err := json.Marshal(GoInstallation{Path: "/usr/local/go", Confidence: ConfidenceHigh})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded GoInstallation
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.Confidence != ConfidenceHigh {
+		t.Errorf("Expected high confidence after round trip, got %s", decoded.Confidence)
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// appDirs are the per-user directories fu-go keeps its files in, following
+// the XDG base directory spec on Unix and the platform conventions elsewhere.
+type appDirs struct {
+	Config string // config.json, plugins
+	State  string // logs, reports, backups
+	Cache  string // downloads that can be fetched again
+}
+
+func (d appDirs) logs() string    { return filepath.Join(d.State, "logs") }
+func (d appDirs) reports() string { return filepath.Join(d.State, "reports") }
+func (d appDirs) backups() string { return filepath.Join(d.State, "backups") }
+func (d appDirs) plugins() string { return filepath.Join(d.Config, "plugins") }
+
+// platformDirs computes the directories for goos. XDG variables must hold
+// absolute paths; anything else is ignored as the spec requires.
+func platformDirs(goos string, getenv func(string) string, home string) appDirs {
+	xdg := func(envVar string, fallback ...string) string {
+		if dir := getenv(envVar); filepath.IsAbs(dir) {
+			return filepath.Join(dir, "fugo")
+		}
+		return filepath.Join(append(append([]string{home}, fallback...), "fugo")...)
+	}
+
+	switch goos {
+	case "darwin", "ios":
+		return appDirs{
+			Config: filepath.Join(home, "Library", "Application Support", "fugo"),
+			State:  filepath.Join(home, "Library", "Application Support", "fugo"),
+			Cache:  filepath.Join(home, "Library", "Caches", "fugo"),
+		}
+	case "windows":
+		appData := getenv("AppData")
+		if appData == "" {
+			appData = filepath.Join(home, "AppData", "Roaming")
+		}
+		localAppData := getenv("LocalAppData")
+		if localAppData == "" {
+			localAppData = filepath.Join(home, "AppData", "Local")
+		}
+		return appDirs{
+			Config: filepath.Join(appData, "fugo"),
+			State:  filepath.Join(localAppData, "fugo"),
+			Cache:  filepath.Join(localAppData, "fugo", "cache"),
+		}
+	default:
+		return appDirs{
+			Config: xdg("XDG_CONFIG_HOME", ".config"),
+			State:  xdg("XDG_STATE_HOME", ".local", "state"),
+			Cache:  xdg("XDG_CACHE_HOME", ".cache"),
+		}
+	}
+}
+
+func resolveDirs() (appDirs, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return appDirs{}, fmt.Errorf("failed to get home directory: %v", err)
+	}
+	return platformDirs(runtime.GOOS, os.Getenv, homeDir), nil
+}
+
+// legacyDir is where fu-go kept everything before it followed XDG.
+func legacyDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %v", err)
+	}
+	return filepath.Join(homeDir, ".fugo"), nil
+}
+
+// migrateLegacyDir moves the contents of an old ~/.fugo into dirs and removes
+// it once empty. Entries whose destination already exists are left in place.
+// It returns a description of every move made.
+func migrateLegacyDir(legacy string, dirs appDirs) ([]string, error) {
+	entries, err := os.ReadDir(legacy)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", legacy, err)
+	}
+
+	var moved []string
+	var errs []error
+	for _, entry := range entries {
+		name := entry.Name()
+		var dest string
+		switch {
+		case name == "config.json":
+			dest = filepath.Join(dirs.Config, name)
+		case name == "plugins":
+			dest = dirs.plugins()
+		case name == "reports":
+			dest = dirs.reports()
+		case name == "backups":
+			dest = dirs.backups()
+		case strings.HasPrefix(name, "fugo_") && strings.HasSuffix(name, ".log"):
+			dest = filepath.Join(dirs.logs(), name)
+		default:
+			continue
+		}
+		if _, err := os.Lstat(dest); err == nil {
+			continue
+		}
+
+		src := filepath.Join(legacy, name)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			errs = append(errs, fmt.Errorf("failed to create %s: %v", filepath.Dir(dest), err))
+			continue
+		}
+		if err := os.Rename(src, dest); err != nil {
+			errs = append(errs, fmt.Errorf("failed to move %s: %v", src, err))
+			continue
+		}
+		moved = append(moved, fmt.Sprintf("%s -> %s", src, dest))
+	}
+
+	// Only succeeds once nothing is left behind
+	os.Remove(legacy)
+	return moved, errors.Join(errs...)
+}
+
+// migrateLegacyHome runs the one-time move from ~/.fugo.
+func migrateLegacyHome() ([]string, error) {
+	legacy, err := legacyDir()
+	if err != nil {
+		return nil, err
+	}
+	dirs, err := resolveDirs()
+	if err != nil {
+		return nil, err
+	}
+	return migrateLegacyDir(legacy, dirs)
+}
+
+// unmigrated is where name still lives in ~/.fugo while dest does not exist
+// yet, so subcommands run before the TUI's one-time move keep using what the
+// user already has. Otherwise, or without a legacy copy, it is dest.
+func unmigrated(dest, name string) string {
+	if _, err := os.Lstat(dest); err == nil {
+		return dest
+	}
+	legacy, err := legacyDir()
+	if err != nil {
+		return dest
+	}
+	old := filepath.Join(legacy, name)
+	if _, err := os.Lstat(old); err != nil {
+		return dest
+	}
+	return old
+}
+
+// fugoPaths is the single resolved set of locations a run reads and writes.
+// Everything that creates files takes its directory from here so overrides
+// apply everywhere at once.
+type fugoPaths struct {
+	Config     string
+	Plugins    string
+	Logs       string
+	Reports    string
+	Backups    string
+	Crashes    string
+	Cache      string
+	Journal    string // environment changes undo-env can reverse
+	Throughput string // measured backup and removal speeds
+	Inventory  string // one inventory per run, for fu-go diff
+	Socket     string // where fu-go serve listens by default
+}
+
+// resolvePaths applies, in increasing precedence, the platform defaults, the
+// log_dir/backup_dir config keys and the FUGO_LOG_DIR/FUGO_BACKUP_DIR
+// environment variables. Defaults not yet moved out of ~/.fugo resolve there.
+func resolvePaths(cfg Config) (fugoPaths, error) {
+	dirs, err := resolveDirs()
+	if err != nil {
+		return fugoPaths{}, err
+	}
+	paths, err := applyPathOverrides(dirs, cfg, os.Getenv)
+	if err != nil {
+		return paths, err
+	}
+	for _, legacy := range []struct {
+		target *string
+		def    string
+		name   string
+	}{
+		{&paths.Plugins, dirs.plugins(), "plugins"},
+		{&paths.Reports, dirs.reports(), "reports"},
+		{&paths.Backups, dirs.backups(), "backups"},
+	} {
+		if *legacy.target == legacy.def {
+			*legacy.target = unmigrated(legacy.def, legacy.name)
+		}
+	}
+	return paths, nil
+}
+
+func applyPathOverrides(dirs appDirs, cfg Config, getenv func(string) string) (fugoPaths, error) {
+	paths := fugoPaths{
+		Config:     dirs.Config,
+		Plugins:    dirs.plugins(),
+		Logs:       dirs.logs(),
+		Reports:    dirs.reports(),
+		Backups:    dirs.backups(),
+		Crashes:    filepath.Join(dirs.State, "crash"),
+		Cache:      dirs.Cache,
+		Journal:    filepath.Join(dirs.State, "env-journal.jsonl"),
+		Throughput: filepath.Join(dirs.State, "throughput.json"),
+		Inventory:  filepath.Join(dirs.State, "inventory"),
+		Socket:     filepath.Join(dirs.State, "serve.sock"),
+	}
+
+	overrides := []struct {
+		target *string
+		config string
+		key    string
+		envVar string
+	}{
+		{&paths.Logs, cfg.LogDir, "log_dir", "FUGO_LOG_DIR"},
+		{&paths.Backups, cfg.BackupDir, "backup_dir", "FUGO_BACKUP_DIR"},
+	}
+	for _, override := range overrides {
+		if override.config != "" {
+			if !filepath.IsAbs(override.config) {
+				return paths, fmt.Errorf("%s must be an absolute path, got %q", override.key, override.config)
+			}
+			*override.target = filepath.Clean(override.config)
+		}
+		if dir := getenv(override.envVar); dir != "" {
+			if !filepath.IsAbs(dir) {
+				return paths, fmt.Errorf("%s must be an absolute path, got %q", override.envVar, dir)
+			}
+			*override.target = filepath.Clean(dir)
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlatformDirs(t *testing.T) {
+	home := filepath.FromSlash("/home/gopher")
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	tests := []struct {
+		name     string
+		goos     string
+		vars     map[string]string
+		expected appDirs
+	}{
+		{
+			"linux defaults", "linux", nil,
+			appDirs{
+				Config: filepath.Join(home, ".config", "fugo"),
+				State:  filepath.Join(home, ".local", "state", "fugo"),
+				Cache:  filepath.Join(home, ".cache", "fugo"),
+			},
+		},
+		{
+			"linux XDG overrides", "linux",
+			map[string]string{"XDG_CONFIG_HOME": "/xdg/config", "XDG_STATE_HOME": "/xdg/state", "XDG_CACHE_HOME": "/xdg/cache"},
+			appDirs{
+				Config: filepath.Join("/xdg/config", "fugo"),
+				State:  filepath.Join("/xdg/state", "fugo"),
+				Cache:  filepath.Join("/xdg/cache", "fugo"),
+			},
+		},
+		{
+			"relative XDG ignored", "freebsd",
+			map[string]string{"XDG_CONFIG_HOME": "relative/config"},
+			appDirs{
+				Config: filepath.Join(home, ".config", "fugo"),
+				State:  filepath.Join(home, ".local", "state", "fugo"),
+				Cache:  filepath.Join(home, ".cache", "fugo"),
+			},
+		},
+		{
+			"darwin", "darwin", map[string]string{"XDG_CONFIG_HOME": "/xdg/config"},
+			appDirs{
+				Config: filepath.Join(home, "Library", "Application Support", "fugo"),
+				State:  filepath.Join(home, "Library", "Application Support", "fugo"),
+				Cache:  filepath.Join(home, "Library", "Caches", "fugo"),
+			},
+		},
+		{
+			"windows", "windows",
+			map[string]string{"AppData": filepath.FromSlash("/Users/gopher/Roaming"), "LocalAppData": filepath.FromSlash("/Users/gopher/Local")},
+			appDirs{
+				Config: filepath.Join(filepath.FromSlash("/Users/gopher/Roaming"), "fugo"),
+				State:  filepath.Join(filepath.FromSlash("/Users/gopher/Local"), "fugo"),
+				Cache:  filepath.Join(filepath.FromSlash("/Users/gopher/Local"), "fugo", "cache"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		result := platformDirs(test.goos, env(test.vars), home)
+		if result != test.expected {
+			t.Errorf("platformDirs(%s) = %+v, expected %+v", test.name, result, test.expected)
+		}
+	}
+}
+
+func TestMigrateLegacyDir(t *testing.T) {
+	root := t.TempDir()
+	legacy := filepath.Join(root, ".fugo")
+	dirs := appDirs{
+		Config: filepath.Join(root, "config", "fugo"),
+		State:  filepath.Join(root, "state", "fugo"),
+		Cache:  filepath.Join(root, "cache", "fugo"),
+	}
+
+	for _, dir := range []string{"plugins", "backups", "reports"} {
+		if err := os.MkdirAll(filepath.Join(legacy, dir), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	for _, file := range []string{"config.json", "fugo_20240101_120000.log", filepath.Join("backups", "go_backup.tar.gz")} {
+		if err := os.WriteFile(filepath.Join(legacy, file), []byte("x"), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", file, err)
+		}
+	}
+
+	moved, err := migrateLegacyDir(legacy, dirs)
+	if err != nil {
+		t.Fatalf("migrateLegacyDir failed: %v", err)
+	}
+	if len(moved) != 5 {
+		t.Errorf("Expected 5 moves, got %d: %v", len(moved), moved)
+	}
+
+	for _, path := range []string{
+		filepath.Join(dirs.Config, "config.json"),
+		dirs.plugins(),
+		dirs.reports(),
+		filepath.Join(dirs.backups(), "go_backup.tar.gz"),
+		filepath.Join(dirs.logs(), "fugo_20240101_120000.log"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s after migration: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("Expected empty legacy directory to be removed")
+	}
+
+	// A second run has nothing to do
+	moved, err = migrateLegacyDir(legacy, dirs)
+	if err != nil || len(moved) != 0 {
+		t.Errorf("Second migration = %v, %v; expected no moves", moved, err)
+	}
+}
+
+func TestListLeavesLegacyHomeAlone(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+	legacy := filepath.Join(home, ".fugo")
+	os.MkdirAll(legacy, 0755)
+	os.WriteFile(filepath.Join(legacy, "config.json"), []byte("{}"), 0644)
+
+	cmd := newRootCmd()
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"list", "--simulate"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("list returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(legacy, "config.json")); err != nil {
+		t.Errorf("Expected list to leave ~/.fugo alone: %v", err)
+	}
+}
+
+func TestMigrateLegacyDirKeepsExisting(t *testing.T) {
+	root := t.TempDir()
+	legacy := filepath.Join(root, ".fugo")
+	dirs := appDirs{Config: filepath.Join(root, "config"), State: filepath.Join(root, "state")}
+
+	os.MkdirAll(legacy, 0755)
+	os.MkdirAll(dirs.Config, 0755)
+	os.WriteFile(filepath.Join(legacy, "config.json"), []byte("old"), 0644)
+	os.WriteFile(filepath.Join(dirs.Config, "config.json"), []byte("new"), 0644)
+
+	moved, err := migrateLegacyDir(legacy, dirs)
+	if err != nil {
+		t.Fatalf("migrateLegacyDir failed: %v", err)
+	}
+	if len(moved) != 0 {
+		t.Errorf("Expected no moves, got %v", moved)
+	}
+	data, _ := os.ReadFile(filepath.Join(dirs.Config, "config.json"))
+	if string(data) != "new" {
+		t.Errorf("Existing config was overwritten: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(legacy, "config.json")); err != nil {
+		t.Errorf("Expected legacy config to stay in place: %v", err)
+	}
+}
+
+func TestApplyPathOverrides(t *testing.T) {
+	dirs := appDirs{
+		Config: filepath.FromSlash("/home/gopher/.config/fugo"),
+		State:  filepath.FromSlash("/home/gopher/.local/state/fugo"),
+		Cache:  filepath.FromSlash("/home/gopher/.cache/fugo"),
+	}
+	scratch := filepath.FromSlash("/scratch/fugo")
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	tests := []struct {
+		name        string
+		cfg         Config
+		vars        map[string]string
+		wantLogs    string
+		wantBackups string
+		wantErr     bool
+	}{
+		{"defaults", Config{}, nil, dirs.logs(), dirs.backups(), false},
+		{"config keys", Config{LogDir: filepath.Join(scratch, "logs"), BackupDir: filepath.Join(scratch, "backups")}, nil,
+			filepath.Join(scratch, "logs"), filepath.Join(scratch, "backups"), false},
+		{"environment wins", Config{LogDir: filepath.Join(scratch, "logs")},
+			map[string]string{"FUGO_LOG_DIR": filepath.FromSlash("/tmp/logs")},
+			filepath.FromSlash("/tmp/logs"), dirs.backups(), false},
+		{"relative config", Config{BackupDir: "backups"}, nil, "", "", true},
+		{"relative environment", Config{}, map[string]string{"FUGO_BACKUP_DIR": "backups"}, "", "", true},
+	}
+
+	for _, test := range tests {
+		paths, err := applyPathOverrides(dirs, test.cfg, env(test.vars))
+		if (err != nil) != test.wantErr {
+			t.Errorf("applyPathOverrides(%s) error = %v, wantErr %v", test.name, err, test.wantErr)
+			continue
+		}
+		if test.wantErr {
+			continue
+		}
+		if paths.Logs != test.wantLogs || paths.Backups != test.wantBackups {
+			t.Errorf("applyPathOverrides(%s) = logs %s, backups %s; expected %s, %s",
+				test.name, paths.Logs, paths.Backups, test.wantLogs, test.wantBackups)
+		}
+		if paths.Reports != dirs.reports() || paths.Plugins != dirs.plugins() {
+			t.Errorf("applyPathOverrides(%s) changed unrelated paths: %+v", test.name, paths)
+		}
+	}
+}
+
+func TestSubcommandReadsLegacyConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+	legacy := filepath.Join(home, ".fugo")
+	if err := os.MkdirAll(filepath.Join(legacy, "backups"), 0755); err != nil {
+		t.Fatalf("Failed to create legacy backups: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(legacy, "config.json"), []byte(`{"enabled_detectors": ["official"]}`), 0644); err != nil {
+		t.Fatalf("Failed to write legacy config: %v", err)
+	}
+
+	var out bytes.Buffer
+	cmd := newRootCmd()
+	cmd.SetOut(&out)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"detectors", "--format", "json"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("detectors returned error: %v", err)
+	}
+	var checks []detectorCheck
+	if err := json.Unmarshal(out.Bytes(), &checks); err != nil {
+		t.Fatalf("Failed to parse detectors output: %v", err)
+	}
+	for _, check := range checks {
+		if check.Enabled != (check.Name == "official") {
+			t.Errorf("Detector %s enabled = %t; expected the legacy config to enable only official", check.Name, check.Enabled)
+		}
+	}
+
+	paths, err := resolvePaths(defaultConfig())
+	if err != nil {
+		t.Fatalf("resolvePaths failed: %v", err)
+	}
+	if paths.Backups != filepath.Join(legacy, "backups") {
+		t.Errorf("Backups = %s, expected the unmigrated %s", paths.Backups, filepath.Join(legacy, "backups"))
+	}
+}
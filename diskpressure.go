@@ -0,0 +1,205 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// With disk_pressure set, clean-cache ignores the size limits and does
+// nothing until the watched volume runs low, then empties the largest caches
+// on that volume first and stops as soon as there is enough room again.
+// Scheduled hourly it keeps build agents from filling up without throwing
+// caches away while there is space to spare.
+
+// PressureConfig is when clean-cache acts on free space instead of cache
+// size limits.
+type PressureConfig struct {
+	// Volume is any path on the volume to watch, by default GOCACHE.
+	Volume string `json:"volume,omitempty"`
+	// FreeBelow is a share of the volume, "10%", or a size, "20GB".
+	FreeBelow string `json:"free_below,omitempty"`
+}
+
+func (p PressureConfig) validate() error {
+	if p.FreeBelow == "" {
+		if p.Volume != "" {
+			return fmt.Errorf("disk_pressure: volume needs free_below")
+		}
+		return nil
+	}
+	if _, err := parseFreeThreshold(p.FreeBelow); err != nil {
+		return fmt.Errorf("disk_pressure: %v", err)
+	}
+	return nil
+}
+
+// freeThreshold is how much free space is enough: a share of the volume, or
+// a number of bytes when percent is 0.
+type freeThreshold struct {
+	percent float64
+	bytes   int64
+}
+
+func parseFreeThreshold(s string) (freeThreshold, error) {
+	if value, ok := strings.CutSuffix(strings.TrimSpace(s), "%"); ok {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || percent <= 0 || percent >= 100 {
+			return freeThreshold{}, fmt.Errorf("invalid free space threshold %q (expected e.g. 10%% or 20GB)", s)
+		}
+		return freeThreshold{percent: percent}, nil
+	}
+	bytes, err := parseByteSize(s)
+	if err != nil || bytes == 0 {
+		return freeThreshold{}, fmt.Errorf("invalid free space threshold %q (expected e.g. 10%% or 20GB)", s)
+	}
+	return freeThreshold{bytes: bytes}, nil
+}
+
+// want is the free space, in bytes, that satisfies the threshold on a
+// volume of total bytes.
+func (t freeThreshold) want(total uint64) uint64 {
+	if t.percent > 0 {
+		return uint64(float64(total) * t.percent / 100)
+	}
+	return uint64(t.bytes)
+}
+
+func (t freeThreshold) String() string {
+	if t.percent > 0 {
+		return strconv.FormatFloat(t.percent, 'f', -1, 64) + "%"
+	}
+	return formatBytes(t.bytes)
+}
+
+// volumeSpace measures a volume; tests replace it.
+var volumeSpace = diskSpace
+
+// pressureReading is the watched volume at one moment.
+type pressureReading struct {
+	volume      string
+	free, total uint64
+	threshold   freeThreshold
+}
+
+func readPressure(volume string, threshold freeThreshold) (pressureReading, error) {
+	free, total, err := volumeSpace(volume)
+	if err != nil {
+		return pressureReading{}, fmt.Errorf("failed to measure free space on %s: %v", volume, err)
+	}
+	return pressureReading{volume: volume, free: free, total: total, threshold: threshold}, nil
+}
+
+// short is how many bytes are missing to reach the threshold, 0 when there
+// is enough free space.
+func (r pressureReading) short() uint64 {
+	if want := r.threshold.want(r.total); r.free < want {
+		return want - r.free
+	}
+	return 0
+}
+
+func (r pressureReading) String() string {
+	percent := 0.0
+	if r.total > 0 {
+		percent = float64(r.free) / float64(r.total) * 100
+	}
+	return fmt.Sprintf("%s has %s free (%.0f%% of %s)", r.volume, formatBytes(int64(r.free)), percent, formatBytes(int64(r.total)))
+}
+
+// pressureVolume is the volume clean-cache watches: the configured one, or
+// the first cache.
+func pressureVolume(cfg PressureConfig, targets []cacheTarget) (string, error) {
+	if cfg.Volume != "" {
+		return expandHome(cfg.Volume), nil
+	}
+	if len(targets) == 0 {
+		return "", fmt.Errorf("disk_pressure needs a volume: no GOCACHE or GOMODCACHE to watch")
+	}
+	return targets[0].Path, nil
+}
+
+// sameVolume reports whether path is on the volume holding volume. When the
+// platform cannot tell, every cache counts.
+func sameVolume(volume, path string) bool {
+	a, errA := os.Stat(volume)
+	b, errB := os.Stat(path)
+	if errA != nil || errB != nil {
+		return errB == nil
+	}
+	devA, okA := deviceID(a)
+	devB, okB := deviceID(b)
+	return !okA || !okB || devA == devB
+}
+
+// relieveDiskPressure empties the caches on the watched volume, largest
+// first, until the threshold is met, and adds what it freed, or would free
+// in a dry run, to report. Nothing is touched while there is enough space.
+func relieveDiskPressure(out eventWriter, targets []cacheTarget, volume string, threshold freeThreshold, report *changeReport) error {
+	if currentPolicy().DenyCacheCleanup {
+		return fmt.Errorf("cache cleanup is disabled by the machine policy")
+	}
+	reading, err := readPressure(volume, threshold)
+	if err != nil {
+		return err
+	}
+	short := reading.short()
+	if short == 0 {
+		out.emit("skip", fmt.Sprintf("%s, above the %s threshold; nothing to do", reading, threshold), "volume", volume, "free", reading.free, "total", reading.total, "reason", "enough-space")
+		return nil
+	}
+	out.emit("pressure", fmt.Sprintf("%s, below the %s threshold; %s to free", reading, threshold, formatBytes(int64(short))), "volume", volume, "free", reading.free, "total", reading.total, "short", short)
+
+	var items []PlanItem
+	for _, target := range targets {
+		if !sameVolume(volume, target.Path) {
+			out.emit("skip", fmt.Sprintf("%s (%s) is on another volume", target.Name, target.Path), "name", target.Name, "path", target.Path, "reason", "other-volume")
+			continue
+		}
+		size, files := dirUsage(target.Path)
+		if size == 0 {
+			out.emit("skip", fmt.Sprintf("%s (%s) is empty", target.Name, target.Path), "name", target.Name, "path", target.Path, "bytes", size, "reason", "empty")
+			continue
+		}
+		items = append(items, cacheItem{target: target, size: size, files: files})
+	}
+	slices.SortStableFunc(items, func(a, b PlanItem) int { return cmp.Compare(b.Size(), a.Size()) })
+
+	progress := startItemProgress(out.events, phaseClean, items)
+	for _, item := range items {
+		target, size := item.(cacheItem).target, item.Size()
+		if short == 0 {
+			out.emit("skip", fmt.Sprintf("%s (%s) is not needed; the threshold is met", target.Name, target.Path), "name", target.Name, "path", target.Path, "bytes", size, "reason", "enough-space")
+			progress.finish(itemResult{item: item, skipped: "enough space"})
+			continue
+		}
+		if report.DryRun {
+			out.emit("empty", fmt.Sprintf("Would empty %s (%s), freeing %s", target.Name, target.Path, formatBytes(size)), "name", target.Name, "path", target.Path, "bytes", size, "dry_run", true)
+			report.add("empty "+target.Path, size)
+			progress.finish(itemResult{item: item, skipped: "dry run"})
+			short -= min(short, uint64(size))
+			continue
+		}
+		start := time.Now()
+		err := item.Execute(planEnv{})
+		progress.finish(itemResult{item: item, err: err, duration: time.Since(start)})
+		if err != nil {
+			return fmt.Errorf("failed to empty %s: %v", target.Path, err)
+		}
+		report.add("empty "+target.Path, size)
+		out.emit("empty", fmt.Sprintf("Emptied %s (%s), freed %s", target.Name, target.Path, formatBytes(size)), "name", target.Name, "path", target.Path, "bytes", size, "dry_run", false)
+		if reading, err = readPressure(volume, threshold); err == nil {
+			short = reading.short()
+		} else {
+			short -= min(short, uint64(size))
+		}
+	}
+	if short > 0 {
+		out.emit("pressure", fmt.Sprintf("Still %s short of the %s threshold on %s after emptying every cache", formatBytes(int64(short)), threshold, volume), "volume", volume, "short", short)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeVolume makes volumeSpace report a volume of total bytes whose free
+// space grows as the given caches are emptied.
+func fakeVolume(t *testing.T, total, free uint64, caches ...string) {
+	t.Helper()
+	used := int64(0)
+	for _, cache := range caches {
+		used += getDirSize(cache)
+	}
+	old := volumeSpace
+	volumeSpace = func(string) (uint64, uint64, error) {
+		now := int64(0)
+		for _, cache := range caches {
+			now += getDirSize(cache)
+		}
+		return free + uint64(used-now), total, nil
+	}
+	t.Cleanup(func() { volumeSpace = old })
+}
+
+func TestParseFreeThreshold(t *testing.T) {
+	for input, want := range map[string]uint64{"10%": 100, " 2.5 % ": 25, "200": 200, "1KB": 1024} {
+		threshold, err := parseFreeThreshold(input)
+		if err != nil || threshold.want(1000) != want {
+			t.Errorf("parseFreeThreshold(%q) wants %d of 1000, %v; expected %d", input, threshold.want(1000), err, want)
+		}
+	}
+	for _, input := range []string{"", "0%", "100%", "-5%", "lots", "0"} {
+		if _, err := parseFreeThreshold(input); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+	if err := (PressureConfig{Volume: "/"}).validate(); err == nil {
+		t.Errorf("Expected a volume without a threshold to be refused")
+	}
+}
+
+func TestRelieveDiskPressure(t *testing.T) {
+	skipInAuditBuild(t)
+	small, big := fakeCache(t, 1000), fakeCache(t, 5000)
+	targets := []cacheTarget{{Name: "GOCACHE", Path: small}, {Name: "GOMODCACHE", Path: big}}
+	threshold := freeThreshold{percent: 10}
+
+	// 12% free: nothing to do
+	fakeVolume(t, 100<<10, 12<<10, small, big)
+	report := newChangeReport(false, false)
+	var out strings.Builder
+	if err := relieveDiskPressure(eventWriter{w: &out}, targets, small, threshold, report); err != nil || report.FreedBytes != 0 {
+		t.Fatalf("Expected nothing to be freed, got %d, %v", report.FreedBytes, err)
+	}
+	if !strings.Contains(out.String(), "nothing to do") {
+		t.Errorf("Expected the free space to be reported, got %q", out.String())
+	}
+
+	// 6% free: emptying the larger cache is enough
+	fakeVolume(t, 100<<10, 6<<10, small, big)
+	dry := newChangeReport(false, true)
+	out.Reset()
+	if err := relieveDiskPressure(eventWriter{w: &out}, targets, small, threshold, dry); err != nil || dry.FreedBytes != 5000 {
+		t.Fatalf("Expected a dry run to report 5000 bytes, got %d, %v\n%s", dry.FreedBytes, err, out.String())
+	}
+	if !strings.Contains(out.String(), "4.0 KB to free") {
+		t.Errorf("Expected the shortfall to be reported, got %q", out.String())
+	}
+	report = newChangeReport(false, false)
+	if err := relieveDiskPressure(eventWriter{w: io.Discard}, targets, small, threshold, report); err != nil || report.FreedBytes != 5000 {
+		t.Fatalf("Expected 5000 bytes freed, got %d, %v", report.FreedBytes, err)
+	}
+	if getDirSize(big) != 0 || getDirSize(small) != 1000 {
+		t.Errorf("Expected only the largest cache to be emptied")
+	}
+
+	// 1% free: everything goes and it is still not enough
+	big = fakeCache(t, 5000)
+	targets[1].Path = big
+	fakeVolume(t, 100<<10, 1<<10, small, big)
+	out.Reset()
+	report = newChangeReport(false, false)
+	if err := relieveDiskPressure(eventWriter{w: &out}, targets, small, threshold, report); err != nil || report.FreedBytes != 6000 {
+		t.Fatalf("Expected 6000 bytes freed, got %d, %v", report.FreedBytes, err)
+	}
+	if !strings.Contains(out.String(), "Still 3.1 KB short") {
+		t.Errorf("Expected the remaining shortfall to be reported, got %q", out.String())
+	}
+
+	withPolicy(t, Policy{DenyCacheCleanup: true})
+	if err := relieveDiskPressure(eventWriter{w: io.Discard}, targets, small, threshold, newChangeReport(false, false)); err == nil {
+		t.Errorf("Expected the policy to refuse cache cleanup")
+	}
+}
@@ -0,0 +1,15 @@
+//go:build netbsd
+
+package main
+
+import "golang.org/x/sys/unix"
+
+// diskSpace reports the space available to unprivileged users on the volume
+// holding path, and the volume's size.
+func diskSpace(path string) (free, total uint64, err error) {
+	var st unix.Statvfs_t
+	if err := unix.Statvfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	return st.Bavail * st.Frsize, st.Blocks * st.Frsize, nil
+}
@@ -0,0 +1,15 @@
+//go:build openbsd
+
+package main
+
+import "golang.org/x/sys/unix"
+
+// diskSpace reports the space available to unprivileged users on the volume
+// holding path, and the volume's size.
+func diskSpace(path string) (free, total uint64, err error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	return uint64(st.F_bavail) * uint64(st.F_bsize), st.F_blocks * uint64(st.F_bsize), nil
+}
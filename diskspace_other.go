@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !dragonfly && !openbsd && !netbsd && !windows
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// diskSpace is unavailable here, so disk_pressure cannot be used.
+func diskSpace(path string) (free, total uint64, err error) {
+	return 0, 0, fmt.Errorf("free space cannot be measured on %s", runtime.GOOS)
+}
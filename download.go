@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/spf13/cobra"
+)
+
+func newDownloadCmd() *cobra.Command {
+	var goos, goarch, from string
+	cmd := &cobra.Command{
+		Use:   "download <version>",
+		Short: "Fetch and verify a Go archive into the download cache",
+		Long:  "download fetches the Go archive for version from go.dev, verifies its SHA-256 and keeps it in the cache\ndirectory, so later reinstalls of the same version reuse it instead of downloading it again. With --from or\nartifact_source it reads the archive from a local mirror instead and checks it against the mirror's SHA256SUMS.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			paths, err := resolvePaths(cfg)
+			if err != nil {
+				return err
+			}
+			if from != "" {
+				if _, err := localSourceDir(from); err != nil {
+					return err
+				}
+				cfg.ArtifactSource = from
+			}
+			path, origin, err := fetchGoArchive(cfg, newArtifactCache(paths), args[0], goos, goarch)
+			if err != nil {
+				return err
+			}
+			switch origin {
+			case archiveFromCache:
+				fmt.Fprintf(cmd.OutOrStdout(), "Using cached %s\n", path)
+			case archiveFromLocal:
+				fmt.Fprintf(cmd.OutOrStdout(), "Verified local %s\n", path)
+			default:
+				fmt.Fprintf(cmd.OutOrStdout(), "Downloaded and verified %s\n", path)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&goos, "os", runtime.GOOS, "target operating system")
+	cmd.Flags().StringVar(&goarch, "arch", runtime.GOARCH, "target architecture")
+	cmd.Flags().StringVar(&from, "from", "", "local directory or file:// URL with archives and checksums, instead of go.dev")
+	return cmd
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// People who set out to keep one Go of each kind often have two that are
+// the same: the go.dev tarball in /usr/local/go and a Homebrew keg of the
+// same release, say. Installations of the same version, size and file count
+// are compared file by file, by the SHA-256 of every file, and when they
+// are byte-identical all but one are marked as a duplicate of it, so the
+// TUI and fu-go list can suggest removing the redundant copies. The copy
+// kept is the one PATH resolves to, or else the first in inventory order.
+
+// fingerprintEntry is one file of a fingerprint.
+type fingerprintEntry struct {
+	path string // slash-separated, relative to the root
+	line string // mode, size and content hash, or link target
+}
+
+// installFingerprint is the SHA-256 over the relative path, mode and content
+// of every file under root. Files are hashed by backupHashWorkers workers.
+func installFingerprint(root string) (string, error) {
+	var entries []fingerprintEntry
+	var files []int // entries still to hash
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		entry := fingerprintEntry{path: filepath.ToSlash(rel), line: info.Mode().String()}
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			entry.line += " -> " + target
+		case info.Mode().IsRegular():
+			entry.line += fmt.Sprintf(" %d", info.Size())
+			files = append(files, len(entries))
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	queue := make(chan int)
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	for range max(backupHashWorkers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				sum, err := fileSHA256(filepath.Join(root, filepath.FromSlash(entries[i].path)))
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				entries[i].line += " " + sum
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, i := range files {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+	if firstErr != nil {
+		return "", firstErr
+	}
+
+	hash := sha256.New()
+	for _, entry := range entries {
+		fmt.Fprintf(hash, "%s\x00%s\n", entry.path, entry.line)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// duplicateKey groups the installations worth fingerprinting: only ones of
+// the same version, size and file count can be identical.
+func duplicateKey(install GoInstallation) (string, bool) {
+	if install.Size == 0 || install.Version == "" || install.User != "" || install.PackageManager == "plugin" {
+		return "", false
+	}
+	return fmt.Sprintf("%s\x00%d\x00%d", install.Version, install.Size, install.Files), true
+}
+
+// markDuplicates sets DuplicateOf on every installation that is a
+// byte-identical copy of another, pointing at the copy to keep.
+func markDuplicates(installations []GoInstallation) {
+	groups := make(map[string][]int)
+	for i := range installations {
+		installations[i].DuplicateOf = ""
+		if key, ok := duplicateKey(installations[i]); ok {
+			groups[key] = append(groups[key], i)
+		}
+	}
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		group := groups[key]
+		if len(group) < 2 {
+			continue
+		}
+		// The same tree found twice, through a symlink, is not a copy
+		byFingerprint := make(map[string][]int)
+		var order []string
+		seen := make(map[string]bool)
+		for _, i := range group {
+			real, err := filepath.EvalSymlinks(installations[i].Path)
+			if err != nil || seen[real] {
+				continue
+			}
+			seen[real] = true
+			fingerprint, err := installFingerprint(installations[i].Path)
+			if err != nil {
+				tracef("duplicates: cannot fingerprint %s: %v", installations[i].Path, err)
+				continue
+			}
+			if _, ok := byFingerprint[fingerprint]; !ok {
+				order = append(order, fingerprint)
+			}
+			byFingerprint[fingerprint] = append(byFingerprint[fingerprint], i)
+		}
+		for _, fingerprint := range order {
+			copies := byFingerprint[fingerprint]
+			if len(copies) < 2 {
+				continue
+			}
+			keep := copies[0]
+			for _, i := range copies {
+				if installations[i].OnPath {
+					keep = i
+					break
+				}
+			}
+			for _, i := range copies {
+				if i != keep {
+					installations[i].DuplicateOf = installations[keep].Path
+				}
+			}
+		}
+	}
+}
+
+// duplicateLines are the suggestions to remove the redundant copies.
+func duplicateLines(installations []GoInstallation) []string {
+	var lines []string
+	for _, install := range installations {
+		if install.DuplicateOf != "" {
+			lines = append(lines, fmt.Sprintf("%s is a byte-identical copy of %s; removing it frees %s", install.Path, install.DuplicateOf, formatBytes(install.Size)))
+		}
+	}
+	return lines
+}
+
+// renderDuplicateSummary suggests removing the duplicates left unselected,
+// for users who came to keep one Go of each kind.
+func (m model) renderDuplicateSummary() string {
+	var kept []string
+	for _, install := range m.detectedInstalls {
+		if install.DuplicateOf != "" && !m.isSelected(install) {
+			kept = append(kept, fmt.Sprintf("   %s (copy of %s, %s)", install.Path, install.DuplicateOf, formatBytes(install.Size)))
+		}
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+	return infoStyle.Render("🪞 Byte-identical copies you are keeping; select them to free the space:\n"+strings.Join(kept, "\n")) + "\n\n"
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// goTree writes a small Go root whose compiler holds compile.
+func goTree(t *testing.T, root, compile string) GoInstallation {
+	t.Helper()
+	os.MkdirAll(filepath.Join(root, "bin"), 0755)
+	os.MkdirAll(filepath.Join(root, "pkg", "tool"), 0755)
+	os.WriteFile(filepath.Join(root, "VERSION"), []byte("go1.22.5\n"), 0644)
+	os.WriteFile(filepath.Join(root, "bin", "go"), []byte("go"), 0755)
+	os.WriteFile(filepath.Join(root, "pkg", "tool", "compile"), []byte(compile), 0755)
+	os.Symlink("../pkg/tool/compile", filepath.Join(root, "bin", "compile"))
+	size, files := dirUsage(root)
+	return GoInstallation{Path: root, Version: "go1.22.5", Size: size, Files: files, Verified: true}
+}
+
+func TestMarkDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	official := goTree(t, filepath.Join(dir, "usr-local-go"), "compile")
+	keg := goTree(t, filepath.Join(dir, "Cellar", "go", "1.22.5", "libexec"), "compile")
+	keg.Source = "brew"
+	patched := goTree(t, filepath.Join(dir, "patched"), "COMPILE") // same size, other bytes
+	link := filepath.Join(dir, "go-link")
+	os.Symlink(official.Path, link)
+	alias := official
+	alias.Path = link
+
+	installations := []GoInstallation{official, keg, patched, alias}
+	markDuplicates(installations)
+	if installations[1].DuplicateOf != official.Path {
+		t.Errorf("Expected the keg to be a duplicate of %s, got %q", official.Path, installations[1].DuplicateOf)
+	}
+	for _, i := range []int{0, 2, 3} {
+		if installations[i].DuplicateOf != "" {
+			t.Errorf("Expected %s not to be a duplicate, got %q", installations[i].Path, installations[i].DuplicateOf)
+		}
+	}
+
+	// The copy PATH resolves to is the one to keep
+	keg.OnPath = true
+	installations = []GoInstallation{official, keg}
+	markDuplicates(installations)
+	if installations[0].DuplicateOf != keg.Path || installations[1].DuplicateOf != "" {
+		t.Errorf("Expected the copy on PATH to be kept, got %+v", installations)
+	}
+
+	var out bytes.Buffer
+	writeInventoryTable(&out, installations)
+	if !strings.Contains(out.String(), official.Path+" is a byte-identical copy of "+keg.Path) {
+		t.Errorf("Expected fu-go list to suggest removing the copy, got:\n%s", out.String())
+	}
+}
+
+func TestDuplicateSummaryOnlyForKeptCopies(t *testing.T) {
+	copyInstall := GoInstallation{Path: "/opt/homebrew/Cellar/go/1.22.5/libexec", Size: 250 << 20, Verified: true, DuplicateOf: "/usr/local/go"}
+	m := model{detectedInstalls: []GoInstallation{{Path: "/usr/local/go", Verified: true}, copyInstall}}
+	if summary := m.renderDuplicateSummary(); summary != "" {
+		t.Errorf("Expected no suggestion while the copy is selected, got %q", summary)
+	}
+	m.selection = map[string]bool{copyInstall.Path: false}
+	if summary := m.renderDuplicateSummary(); !strings.Contains(summary, copyInstall.Path) {
+		t.Errorf("Expected the kept copy to be suggested for removal, got %q", summary)
+	}
+	if label := supportLabel(copyInstall); !strings.Contains(label, "duplicate") {
+		t.Errorf("Expected a duplicate badge, got %q", label)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+// Items that failed for want of privileges can be run again with e: fu-go
+// starts a copy of itself through sudo, or UAC on Windows, hands it just
+// those items and reads back how each went. The TUI keeps running as the
+// user, and the results of everything that already succeeded stay as they
+// are.
+
+// elevatedItem is a plan item as handed to the elevated copy.
+type elevatedItem struct {
+	Kind    PlanItemKind   `json:"kind"`
+	Path    string         `json:"path"`
+	Dest    string         `json:"dest,omitempty"`
+	Root    string         `json:"root,omitempty"`
+	Repoint string         `json:"repoint,omitempty"`
+	Install GoInstallation `json:"install,omitempty"`
+}
+
+// elevatedResult is how one item went in the elevated copy.
+type elevatedResult struct {
+	Err        string        `json:"error,omitempty"`
+	RolledBack bool          `json:"rolled_back,omitempty"`
+	Skipped    string        `json:"skipped,omitempty"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// toElevatedItem describes item for the elevated copy. Profile and registry
+// edits are the user's own and are never elevated.
+func toElevatedItem(item PlanItem) (elevatedItem, bool) {
+	switch item := item.(type) {
+	case installationItem:
+		return elevatedItem{Kind: PlanInstallation, Path: item.install.Path, Install: item.install}, true
+	case packageUninstallItem:
+		return elevatedItem{Kind: PlanPackageUninstall, Path: item.install.Path, Install: item.install}, true
+	case symlinkItem:
+		return elevatedItem{Kind: PlanSymlink, Path: item.link, Dest: item.dest, Root: item.root, Repoint: item.repoint}, true
+	case gopathItem:
+		return elevatedItem{Kind: PlanGopath, Path: item.path}, true
+	case cacheItem:
+		return elevatedItem{Kind: PlanCache, Path: item.target.Path, Install: GoInstallation{Source: item.target.Name}}, true
+	}
+	return elevatedItem{}, false
+}
+
+func (e elevatedItem) planItem() (PlanItem, error) {
+	switch e.Kind {
+	case PlanInstallation:
+		return installationItem{install: e.Install}, nil
+	case PlanPackageUninstall:
+		return packageUninstallItem{install: e.Install}, nil
+	case PlanSymlink:
+		return symlinkItem{link: e.Path, dest: e.Dest, root: e.Root, repoint: e.Repoint}, nil
+	case PlanGopath:
+		return gopathItem{path: e.Path}, nil
+	case PlanCache:
+		return cacheItem{target: cacheTarget{Name: e.Install.Source, Path: e.Path}}, nil
+	}
+	return nil, fmt.Errorf("%s items cannot be run elevated", e.Kind)
+}
+
+// needsElevation reports whether the item failed for want of privileges
+// that sudo or UAC would grant. Read-only mounts and the like are not.
+func (r itemResult) needsElevation() bool {
+	if r.err == nil {
+		return false
+	}
+	if errors.Is(r.err, fs.ErrPermission) {
+		return true
+	}
+	if item, ok := r.item.(packageUninstallItem); ok {
+		return managerNeedsRoot(item.install.PackageManager)
+	}
+	return false
+}
+
+// canElevate reports whether e would help: fu-go is not elevated already and
+// some item failed on permissions.
+func (m model) canElevate() bool {
+	if m.opts.simulate || m.retrying || isElevated() {
+		return false
+	}
+	for _, result := range m.results {
+		if result.needsElevation() {
+			return true
+		}
+	}
+	return false
+}
+
+// elevationCommand is the command line that runs exe with args elevated on
+// goos.
+func elevationCommand(goos, exe string, args []string) []string {
+	if goos != "windows" {
+		return append([]string{"sudo", "--", exe}, args...)
+	}
+	quote := func(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quote(`"` + arg + `"`)
+	}
+	script := fmt.Sprintf("Start-Process -FilePath %s -ArgumentList %s -Verb RunAs -Wait -WindowStyle Hidden", quote(exe), strings.Join(quoted, ","))
+	return []string{"powershell", "-NoProfile", "-NonInteractive", "-Command", script}
+}
+
+// elevatedIndexes are the results e runs again: the retryable ones that an
+// elevated copy can carry out.
+func (m model) elevatedIndexes() []int {
+	var indexes []int
+	for _, i := range m.failedIndexes() {
+		if _, ok := toElevatedItem(m.results[i].item); ok {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// retryElevated hands the failed items to an elevated copy of fu-go. The
+// terminal is released while it runs so sudo can ask for a password.
+func (m model) retryElevated() (tea.Model, tea.Cmd) {
+	indexes := m.elevatedIndexes()
+	if len(indexes) == 0 {
+		return m, nil
+	}
+	items := make([]elevatedItem, len(indexes))
+	for j, i := range indexes {
+		items[j], _ = toElevatedItem(m.results[i].item)
+	}
+	dir, err := os.MkdirTemp("", "fu-go-elevate-")
+	if err == nil {
+		err = writeElevatedItems(filepath.Join(dir, "items.json"), items)
+	}
+	exe := m.selfExe
+	if exe == "" {
+		exe, _ = os.Executable()
+	}
+	if err != nil || exe == "" {
+		m.retryErr = fmt.Errorf("could not prepare the elevated retry: %v", err)
+		return m, nil
+	}
+	m.retrying = true
+	m.retryErr = nil
+	if m.logFile != nil {
+		m.logFile.Log("INFO", fmt.Sprintf("Retrying %d failed item(s) elevated", len(items)))
+	}
+	itemsPath, resultsPath := filepath.Join(dir, "items.json"), filepath.Join(dir, "results.json")
+	helperArgs := []string{"exec-elevated", itemsPath, resultsPath}
+	if m.config.AllowCrossMounts {
+		helperArgs = append(helperArgs, "--allow-cross-mounts")
+	}
+	args := elevationCommand(runtime.GOOS, exe, helperArgs)
+	return m, tea.ExecProcess(exec.Command(args[0], args[1:]...), func(err error) tea.Msg {
+		defer os.RemoveAll(dir)
+		msg := retryCompleted{indexes: indexes}
+		if err == nil {
+			msg.results, err = readElevatedResults(resultsPath, m.results, indexes)
+		}
+		if err != nil {
+			msg.err = fmt.Errorf("elevated retry failed: %v", err)
+		}
+		return msg
+	})
+}
+
+func writeElevatedItems(path string, items []elevatedItem) error {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// readElevatedResults turns the elevated copy's report back into results
+// for the entries of previous at indexes.
+func readElevatedResults(path string, previous []itemResult, indexes []int) ([]itemResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no results from the elevated copy: %v", err)
+	}
+	var reported []elevatedResult
+	if err := json.Unmarshal(data, &reported); err != nil {
+		return nil, fmt.Errorf("invalid results from the elevated copy: %v", err)
+	}
+	if len(reported) != len(indexes) {
+		return nil, fmt.Errorf("the elevated copy reported %d result(s) for %d item(s)", len(reported), len(indexes))
+	}
+	results := make([]itemResult, len(indexes))
+	for j, i := range indexes {
+		result := itemResult{item: previous[i].item, skipped: reported[j].Skipped, duration: reported[j].Duration}
+		if reported[j].Err != "" {
+			result.err = errors.New(reported[j].Err)
+			if reported[j].RolledBack {
+				result.err = rolledBackError{result.err}
+			}
+		}
+		results[j] = result
+	}
+	return results, nil
+}
+
+// runElevatedItems is the elevated side: it runs the items in itemsPath and
+// writes how each went to resultsPath.
+func runElevatedItems(itemsPath, resultsPath string, allowCrossMounts bool) error {
+	if lock := liveModeLock(); lock != "" {
+		return fmt.Errorf("live removals are disabled: %s", lock)
+	}
+	data, err := os.ReadFile(itemsPath)
+	if err != nil {
+		return err
+	}
+	var described []elevatedItem
+	if err := json.Unmarshal(data, &described); err != nil {
+		return fmt.Errorf("invalid item list %s: %v", itemsPath, err)
+	}
+	items := make([]PlanItem, len(described))
+	for i, d := range described {
+		if isCriticalPath(d.Path) {
+			return fmt.Errorf("refusing to touch critical path %s", d.Path)
+		}
+		if items[i], err = d.planItem(); err != nil {
+			return err
+		}
+	}
+	var reported []elevatedResult
+	for _, result := range executePlan(items, planEnv{allowCrossMounts: allowCrossMounts}) {
+		r := elevatedResult{Skipped: result.skipped, Duration: result.duration}
+		if result.err != nil {
+			var rolledBack rolledBackError
+			r.Err, r.RolledBack = result.reason(), errors.As(result.err, &rolledBack)
+		}
+		reported = append(reported, r)
+	}
+	out, err := json.Marshal(reported)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(resultsPath, out, 0644)
+}
+
+// newExecElevatedCmd is the elevated side of e. It takes the TUI user's
+// settings from its flags, not root's config.
+func newExecElevatedCmd() *cobra.Command {
+	var allowCrossMounts bool
+	cmd := &cobra.Command{
+		Use:    "exec-elevated ITEMS RESULTS",
+		Short:  "Run plan items handed over by the TUI with elevated privileges",
+		Hidden: true,
+		Args:   cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runElevatedItems(args[0], args[1], allowCrossMounts)
+		},
+	}
+	cmd.Flags().BoolVar(&allowCrossMounts, "allow-cross-mounts", false, "remove trees that contain mount points")
+	return cmd
+}
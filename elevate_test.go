@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNeedsElevation(t *testing.T) {
+	install := installationItem{install: GoInstallation{Path: "/usr/local/go"}}
+	testCases := []struct {
+		result itemResult
+		want   bool
+	}{
+		{itemResult{item: install}, false},
+		{itemResult{item: install, err: fmt.Errorf("no write permission for /usr/local: %w", fs.ErrPermission)}, true},
+		{itemResult{item: install, err: fmt.Errorf("read-only file system")}, false},
+		{itemResult{item: packageUninstallItem{install: GoInstallation{PackageManager: "apk", Package: "go"}}, err: fmt.Errorf("exit status 1")}, true},
+		{itemResult{item: packageUninstallItem{install: GoInstallation{PackageManager: "scoop", Package: "go"}}, err: fmt.Errorf("exit status 1")}, false},
+	}
+	for _, tc := range testCases {
+		if got := tc.result.needsElevation(); got != tc.want {
+			t.Errorf("needsElevation(%v) = %v, want %v", tc.result.err, got, tc.want)
+		}
+	}
+}
+
+func TestElevationCommand(t *testing.T) {
+	args := []string{"exec-elevated", "/tmp/items.json", "/tmp/results.json"}
+	if got := elevationCommand("linux", "/usr/local/bin/fu-go", args); !reflect.DeepEqual(got, append([]string{"sudo", "--", "/usr/local/bin/fu-go"}, args...)) {
+		t.Errorf("Unexpected sudo command %q", got)
+	}
+	got := elevationCommand("windows", `C:\Users\O'Neil\fu-go.exe`, []string{"exec-elevated", `C:\Temp\a b\items.json`})
+	if got[0] != "powershell" || !strings.Contains(got[4], `-FilePath 'C:\Users\O''Neil\fu-go.exe' -ArgumentList '"exec-elevated"','"C:\Temp\a b\items.json"' -Verb RunAs -Wait`) {
+		t.Errorf("Unexpected UAC command %q", got)
+	}
+}
+
+func TestElevatedItemsRoundTrip(t *testing.T) {
+	skipInAuditBuild(t)
+	root := fakeGoRoot(t, "VERSION", "bin/go")
+	bin := t.TempDir()
+	os.Symlink(filepath.Join(root, "bin", "go"), filepath.Join(bin, "go"))
+	previous := []itemResult{
+		{item: installationItem{install: GoInstallation{Path: "/elsewhere"}}},
+		{item: installationItem{install: GoInstallation{Path: root, Source: "manual"}}, err: fs.ErrPermission},
+		{item: symlinkItem{link: filepath.Join(bin, "go"), dest: filepath.Join(root, "bin", "go"), root: root}, skipped: root + " was not removed"},
+		{item: symlinkItem{link: filepath.Join(bin, "gone"), dest: "/nowhere"}, err: fs.ErrPermission},
+	}
+	indexes := []int{1, 2, 3}
+	var items []elevatedItem
+	for _, i := range indexes {
+		item, ok := toElevatedItem(previous[i].item)
+		if !ok {
+			t.Fatalf("Expected %s to be elevatable", previous[i].item.Describe())
+		}
+		items = append(items, item)
+	}
+	if _, ok := toElevatedItem(envEditItem{}); ok {
+		t.Errorf("Expected profile edits never to be elevated")
+	}
+
+	dir := t.TempDir()
+	itemsPath, resultsPath := filepath.Join(dir, "items.json"), filepath.Join(dir, "results.json")
+	if err := writeElevatedItems(itemsPath, items); err != nil {
+		t.Fatal(err)
+	}
+	if err := runElevatedItems(itemsPath, resultsPath, false); err != nil {
+		t.Fatalf("runElevatedItems returned error: %v", err)
+	}
+	results, err := readElevatedResults(resultsPath, previous, indexes)
+	if err != nil {
+		t.Fatalf("readElevatedResults returned error: %v", err)
+	}
+	if !results[0].ok() || !results[1].ok() || results[2].ok() {
+		t.Errorf("Expected the root and its link to go and the dangling link to fail, got %+v", results)
+	}
+	if !reflect.DeepEqual(results[0].item, previous[1].item) {
+		t.Errorf("Expected the results to keep the TUI's items")
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", root, err)
+	}
+
+	if _, err := readElevatedResults(resultsPath, previous, []int{1}); err == nil {
+		t.Errorf("Expected a mismatched report to be rejected")
+	}
+	writeElevatedItems(itemsPath, []elevatedItem{{Kind: PlanInstallation, Path: "/usr"}})
+	if err := runElevatedItems(itemsPath, resultsPath, false); err == nil {
+		t.Errorf("Expected a critical path to be refused")
+	}
+}
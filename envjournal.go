@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// File backups cover the Go roots, but fu-go also changes things that are not
+// Go: shell profiles, the Windows user PATH, the macOS installer receipt, and
+// the links and shims fu-go deactivate removes.
+// Each such change is appended to the environment journal with a copy of what
+// was there before, so `fu-go undo-env` can put it back on its own.
+
+const (
+	envChangeFile     = "file"
+	envChangeRegistry = "registry"
+	envChangeReceipt  = "pkgutil-forget"
+)
+
+// envChange is one journal entry. Backup holds the previous content (a file,
+// or for receipts a directory of them); After is the SHA-256 of what fu-go
+// wrote, so undo can tell when someone has edited the file since.
+type envChange struct {
+	Time   time.Time `json:"time"`
+	Kind   string    `json:"kind"`
+	Target string    `json:"target"` // file path, registry value or package id
+	Backup string    `json:"backup"`
+	After  string    `json:"after,omitempty"`
+	Files  []string  `json:"files,omitempty"` // receipt files the package id had
+	Link   string    `json:"link,omitempty"`  // where a removed symlink pointed
+	Undone bool      `json:"undone,omitempty"`
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// envJournalMu serialises journal access within the process: profile edits
+// run as background commands while undo-env rewrites the file in place.
+var envJournalMu sync.Mutex
+
+func appendEnvChange(journal string, change envChange) error {
+	envJournalMu.Lock()
+	defer envJournalMu.Unlock()
+	if err := fsys.MkdirAll(filepath.Dir(journal), 0755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %v", err)
+	}
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	if err := fsys.AppendFile(journal, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write journal: %v", err)
+	}
+	return nil
+}
+
+func loadEnvJournal(journal string) ([]envChange, error) {
+	envJournalMu.Lock()
+	defer envJournalMu.Unlock()
+	return readEnvJournal(journal)
+}
+
+func readEnvJournal(journal string) ([]envChange, error) {
+	file, err := os.Open(journal)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %v", err)
+	}
+	defer file.Close()
+	return parseEnvJournal(file, journal)
+}
+
+// parseEnvJournal reads journal entries from r, one JSON object per line;
+// name is used in errors.
+func parseEnvJournal(r io.Reader, name string) ([]envChange, error) {
+	var changes []envChange
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var change envChange
+		if err := json.Unmarshal(scanner.Bytes(), &change); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", name, line, err)
+		}
+		changes = append(changes, change)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return changes, nil
+}
+
+func saveEnvJournal(journal string, changes []envChange) error {
+	var data []byte
+	for _, change := range changes {
+		line, err := json.Marshal(change)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+	tmp := journal + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write journal: %v", err)
+	}
+	return os.Rename(tmp, journal)
+}
+
+// journalEnvChange records change for undo-env. Failing to do so does not
+// undo the change, so it is only logged.
+func (m model) journalEnvChange(change envChange) {
+	if err := appendEnvChange(m.paths.Journal, change); err != nil && m.logFile != nil {
+		m.logFile.Log("WARN", fmt.Sprintf("Could not journal the change to %s, undo-env will not know about it: %v", change.Target, err))
+	}
+}
+
+// undoEnvChange restores what change replaced. A file or value changed again
+// since fu-go wrote it is only overwritten with force.
+func undoEnvChange(change envChange, force bool) error {
+	if change.Kind == envChangeLink {
+		return undoUnlink(change, force)
+	}
+	backup, err := os.ReadFile(change.Backup)
+	if err != nil && change.Kind != envChangeReceipt {
+		return fmt.Errorf("backup %s is unreadable: %v", change.Backup, err)
+	}
+	switch change.Kind {
+	case envChangeFile:
+		info, err := os.Stat(change.Target)
+		if err != nil {
+			return err
+		}
+		current, err := os.ReadFile(change.Target)
+		if err != nil {
+			return err
+		}
+		if !force && contentHash(current) != change.After {
+			return fmt.Errorf("%s was edited after fu-go changed it; compare it with %s or use --force", change.Target, change.Backup)
+		}
+		return fsys.WriteFile(change.Target, backup, info.Mode().Perm())
+	case envChangeRegistry:
+		current, err := userPathValue()
+		if err != nil {
+			return err
+		}
+		if !force && contentHash([]byte(current)) != change.After {
+			return fmt.Errorf("%s was changed after fu-go edited it; use --force to restore it anyway", change.Target)
+		}
+		return setUserPathValue(string(backup))
+	case envChangeReceipt:
+		return restoreReceipts(change)
+	case envChangeRemoved:
+		if _, err := os.Lstat(change.Target); err == nil && !force {
+			return fmt.Errorf("%s was created again after fu-go removed it; use --force to restore it anyway", change.Target)
+		}
+		info, err := os.Stat(change.Backup)
+		if err != nil {
+			return err
+		}
+		return fsys.WriteFile(change.Target, backup, info.Mode().Perm())
+	}
+	return fmt.Errorf("unknown change kind %q", change.Kind)
+}
+
+// undoUnlink puts back a symlink fu-go removed. One already pointing at the
+// same place is left as it is.
+func undoUnlink(change envChange, force bool) error {
+	if dest, err := os.Readlink(change.Target); err == nil && dest == change.Link {
+		return nil
+	}
+	if _, err := os.Lstat(change.Target); err == nil {
+		if !force {
+			return fmt.Errorf("%s was created again after fu-go removed it; use --force to restore the link anyway", change.Target)
+		}
+		if err := fsys.Remove(change.Target); err != nil {
+			return err
+		}
+	}
+	return fsys.Symlink(change.Link, change.Target)
+}
+
+// undoEnv reverses every change not yet undone, newest first, and marks each
+// one in the journal as it goes. It returns how many were undone.
+func undoEnv(w io.Writer, journal string, force bool) (int, error) {
+	// Held throughout so an entry appended meanwhile is not lost on save
+	envJournalMu.Lock()
+	defer envJournalMu.Unlock()
+	changes, err := readEnvJournal(journal)
+	if err != nil {
+		return 0, err
+	}
+	undone := 0
+	for i := len(changes) - 1; i >= 0; i-- {
+		if changes[i].Undone {
+			continue
+		}
+		if err := undoEnvChange(changes[i], force); err != nil {
+			return undone, fmt.Errorf("failed to undo %s change to %s: %v", changes[i].Kind, changes[i].Target, err)
+		}
+		changes[i].Undone = true
+		if err := saveEnvJournal(journal, changes); err != nil {
+			return undone, err
+		}
+		undone++
+		if changes[i].Kind == envChangeLink {
+			fmt.Fprintf(w, "Restored link %s -> %s\n", changes[i].Target, changes[i].Link)
+		} else {
+			fmt.Fprintf(w, "Restored %s from %s\n", changes[i].Target, changes[i].Backup)
+		}
+	}
+	return undone, nil
+}
+
+func newUndoEnvCmd(opts *runOptions) *cobra.Command {
+	var list, force bool
+	cmd := &cobra.Command{
+		Use:   "undo-env",
+		Short: "Restore shell profiles, PATH, installer receipts, links and shims fu-go changed",
+		Long:  "undo-env reverses the environment changes recorded in the journal, newest first, independently of the\nbackups of the Go installations themselves. Files edited again since fu-go changed them are skipped unless\n--force is given.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			paths, err := resolvePaths(cfg)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if list {
+				changes, err := loadEnvJournal(paths.Journal)
+				if err != nil {
+					return err
+				}
+				for _, change := range changes {
+					status := "pending"
+					if change.Undone {
+						status = "undone"
+					}
+					fmt.Fprintf(out, "%s  %-14s  %-7s  %s\n", change.Time.Format("2006-01-02 15:04"), change.Kind, status, change.Target)
+				}
+				return nil
+			}
+			logger, err := newConfiguredLogger(*opts, paths.Logs)
+			if err != nil {
+				return err
+			}
+			defer logger.Close()
+			undone, err := undoEnv(out, paths.Journal, force)
+			if err != nil {
+				logger.Log("ERROR", fmt.Sprintf("Environment undo failed: %v", err))
+				return err
+			}
+			logger.Log("SUCCESS", fmt.Sprintf("Undid %d environment change(s)", undone))
+			if undone == 0 {
+				fmt.Fprintln(out, "Nothing to undo")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&list, "list", false, "show the journal instead of undoing")
+	cmd.Flags().BoolVar(&force, "force", false, "restore files even if they were edited since")
+	return cmd
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUndoEnvRestoresFiles(t *testing.T) {
+	skipInAuditBuild(t)
+	dir := t.TempDir()
+	rc := filepath.Join(dir, ".bashrc")
+	original := "export PATH=$PATH:/usr/local/go/bin\nexport EDITOR=vim\n"
+	os.WriteFile(rc, []byte(original), 0644)
+	lines := strings.Split(original, "\n")
+	edit := profileEdit{Path: rc, Lines: lines, Hunks: []profileHunk{{Start: 0, Count: 1, Accept: true}}}
+	change, err := applyProfileEdit(edit, dir, time.Now())
+	if err != nil {
+		t.Fatalf("applyProfileEdit returned error: %v", err)
+	}
+	journal := filepath.Join(dir, "state", "env-journal.jsonl")
+	if err := appendEnvChange(journal, change); err != nil {
+		t.Fatalf("appendEnvChange returned error: %v", err)
+	}
+
+	os.WriteFile(rc, []byte("export EDITOR=nano\n"), 0644)
+	if _, err := undoEnv(io.Discard, journal, false); err == nil || !strings.Contains(err.Error(), "was edited after") {
+		t.Fatalf("Expected a file edited since to be refused, got %v", err)
+	}
+	if undone, err := undoEnv(io.Discard, journal, true); err != nil || undone != 1 {
+		t.Fatalf("Expected --force to undo one change, got %d, %v", undone, err)
+	}
+	if data, _ := os.ReadFile(rc); string(data) != original {
+		t.Errorf("Expected the original profile back, got %q", data)
+	}
+
+	changes, _ := loadEnvJournal(journal)
+	if len(changes) != 1 || !changes[0].Undone {
+		t.Errorf("Expected the change to be marked undone, got %+v", changes)
+	}
+	if undone, err := undoEnv(io.Discard, journal, false); err != nil || undone != 0 {
+		t.Errorf("Expected nothing left to undo, got %d, %v", undone, err)
+	}
+}
+
+func TestUndoEnvRestoresReceipts(t *testing.T) {
+	skipInAuditBuild(t)
+	dir := t.TempDir()
+	backup := filepath.Join(dir, "receipts_backup")
+	os.MkdirAll(backup, 0700)
+	os.WriteFile(filepath.Join(backup, goReceiptID+".plist"), []byte("<plist/>"), 0644)
+	receipt := filepath.Join(dir, "receipts", goReceiptID+".plist")
+	os.MkdirAll(filepath.Dir(receipt), 0755)
+
+	journal := filepath.Join(dir, "env-journal.jsonl")
+	appendEnvChange(journal, envChange{Time: time.Now(), Kind: envChangeReceipt, Target: goReceiptID, Backup: backup, Files: []string{receipt}})
+	if undone, err := undoEnv(io.Discard, journal, false); err != nil || undone != 1 {
+		t.Fatalf("Expected the receipt to be restored, got %d, %v", undone, err)
+	}
+	if data, _ := os.ReadFile(receipt); string(data) != "<plist/>" {
+		t.Errorf("Unexpected restored receipt: %q", data)
+	}
+}
+
+func TestLoadEnvJournalMissing(t *testing.T) {
+	changes, err := loadEnvJournal(filepath.Join(t.TempDir(), "none.jsonl"))
+	if err != nil || changes != nil {
+		t.Errorf("Expected an empty journal, got %+v, %v", changes, err)
+	}
+}
+
+// TestEnvJournalConcurrentAppends appends from many goroutines while undo-env
+// rewrites the journal; every entry must survive, whole.
+func TestEnvJournalConcurrentAppends(t *testing.T) {
+	skipInAuditBuild(t)
+	dir := t.TempDir()
+	journal := filepath.Join(dir, "state", "env-journal.jsonl")
+	const appends = 64
+	var wg sync.WaitGroup
+	for i := range appends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Receipts with nothing to restore undo cleanly without a backup
+			change := envChange{Time: time.Now(), Kind: envChangeReceipt, Target: fmt.Sprintf("pkg.%d", i), Backup: filepath.Join(dir, "none")}
+			if err := appendEnvChange(journal, change); err != nil {
+				t.Errorf("appendEnvChange returned error: %v", err)
+			}
+		}()
+		if i%8 == 0 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				undoEnv(io.Discard, journal, false)
+			}()
+		}
+	}
+	wg.Wait()
+
+	changes, err := loadEnvJournal(journal)
+	if err != nil {
+		t.Fatalf("Expected a readable journal, got %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, change := range changes {
+		seen[change.Target] = true
+	}
+	if len(changes) != appends || len(seen) != appends {
+		t.Errorf("Expected %d distinct entries, got %d (%d distinct)", appends, len(changes), len(seen))
+	}
+}
+
+// FuzzParseEnvJournal feeds arbitrary journals to the parser undo-env trusts
+// to say which backup restores which file. Whatever it reads must be written
+// back the same way.
+func FuzzParseEnvJournal(f *testing.F) {
+	f.Add([]byte(`{"time":"2024-07-02T15:00:00Z","kind":"file","target":"/home/gopher/.profile","backup":"/tmp/b","after":"ab"}` + "\n"))
+	f.Add([]byte("\n\r\n{}\n"))
+	f.Add([]byte(`{"kind":"receipt","files":["a","b"],"undone":true}`))
+	f.Add([]byte("{\"kind\":1}\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		changes, err := parseEnvJournal(bytes.NewReader(data), "journal")
+		if err != nil {
+			return
+		}
+		journal := filepath.Join(t.TempDir(), "journal")
+		if err := saveEnvJournal(journal, changes); err != nil {
+			t.Fatalf("saveEnvJournal failed on a parsed journal: %v", err)
+		}
+		again, err := loadEnvJournal(journal)
+		if err != nil {
+			t.Fatalf("A saved journal does not load again: %v", err)
+		}
+		if len(again) != len(changes) {
+			t.Fatalf("Journal lost entries across save and load: %d became %d", len(changes), len(again))
+		}
+		for i := range changes {
+			if again[i].Target != changes[i].Target || again[i].Backup != changes[i].Backup || again[i].Undone != changes[i].Undone {
+				t.Errorf("Entry %d changed across save and load: %+v became %+v", i, changes[i], again[i])
+			}
+		}
+	})
+}
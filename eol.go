@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// goVersion is a parsed Go release such as 1.22.5 or 1.21rc2.
+type goVersion struct {
+	Major, Minor, Patch int
+	Pre                 string // "rc2", "beta1"; empty for releases
+}
+
+// parseSemVer parses the SemVer field of an installation, e.g. "1.22.5",
+// "1.20" or "1.21rc2".
+func parseSemVer(s string) (goVersion, bool) {
+	var v goVersion
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return v, false
+	}
+	var err error
+	if v.Major, err = strconv.Atoi(parts[0]); err != nil {
+		return v, false
+	}
+	last := parts[len(parts)-1]
+	if i := strings.IndexAny(last, "abcdefghijklmnopqrstuvwxyz"); i > 0 {
+		v.Pre = last[i:]
+		parts[len(parts)-1] = last[:i]
+	}
+	if v.Minor, err = strconv.Atoi(parts[1]); err != nil {
+		return v, false
+	}
+	if len(parts) == 3 {
+		if v.Patch, err = strconv.Atoi(parts[2]); err != nil {
+			return v, false
+		}
+	}
+	return v, true
+}
+
+// line is the release line, e.g. "1.22".
+func (v goVersion) line() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// Go supports the two most recent release lines: 1.N stops receiving
+// security fixes the day 1.N+2 is released. goReleaseDates is the embedded
+// table of first releases that the EOL check works from; lines newer than
+// its last entry are assumed supported.
+var goReleaseDates = map[int]string{
+	16: "2021-02-16",
+	17: "2021-08-16",
+	18: "2022-03-15",
+	19: "2022-08-02",
+	20: "2023-02-01",
+	21: "2023-08-08",
+	22: "2024-02-06",
+	23: "2024-08-13",
+	24: "2025-02-11",
+	25: "2025-08-12",
+}
+
+// goEOL returns the date v's release line stopped being supported, if it
+// has by now.
+func goEOL(v goVersion, now time.Time) (time.Time, bool) {
+	if v.Major != 1 {
+		return time.Time{}, false
+	}
+	if v.Minor < 16 {
+		// Long gone; the table starts where it matters
+		return time.Date(2021, 2, 16, 0, 0, 0, 0, time.UTC), true
+	}
+	date, ok := goReleaseDates[v.Minor+2]
+	if !ok {
+		return time.Time{}, false
+	}
+	eol, err := time.Parse("2006-01-02", date)
+	if err != nil || now.Before(eol) {
+		return time.Time{}, false
+	}
+	return eol, true
+}
+
+// knownCVE is a critical vulnerability in the toolchain or standard library.
+// Fixed lists the first fixed patch release per release line; affected lines
+// older than the oldest one listed never got a fix.
+type knownCVE struct {
+	ID         string
+	Summary    string
+	Introduced string // first affected version, empty for long-standing bugs
+	Fixed      []string
+}
+
+var knownCVEs = []knownCVE{
+	{ID: "CVE-2022-23806", Summary: "crypto/elliptic IsOnCurve accepts invalid points", Fixed: []string{"1.16.14", "1.17.7"}},
+	{ID: "CVE-2023-24538", Summary: "html/template does not escape backticks in JavaScript", Fixed: []string{"1.19.8", "1.20.3"}},
+	{ID: "CVE-2023-24540", Summary: "html/template mishandles JavaScript whitespace", Fixed: []string{"1.19.9", "1.20.4"}},
+	{ID: "CVE-2023-29402", Summary: "go build runs code from directory names containing newlines", Fixed: []string{"1.19.10", "1.20.5"}},
+	{ID: "CVE-2023-29404", Summary: "go build runs code through cgo LDFLAGS", Fixed: []string{"1.19.10", "1.20.5"}},
+	{ID: "CVE-2023-29405", Summary: "go build runs code through cgo LDFLAGS with spaces", Fixed: []string{"1.19.10", "1.20.5"}},
+	{ID: "CVE-2023-39320", Summary: "go runs code from a module's toolchain directive", Introduced: "1.21.0", Fixed: []string{"1.21.1"}},
+	{ID: "CVE-2024-24790", Summary: "net/netip misclassifies IPv4-mapped IPv6 addresses", Introduced: "1.18.0", Fixed: []string{"1.21.11", "1.22.4"}},
+}
+
+func cveSummary(id string) string {
+	for _, cve := range knownCVEs {
+		if cve.ID == id {
+			return cve.Summary
+		}
+	}
+	return ""
+}
+
+// affects reports whether the release v is vulnerable.
+func (c knownCVE) affects(v goVersion) bool {
+	semVer := fmt.Sprintf("%s.%d%s", v.line(), v.Patch, v.Pre)
+	if c.Introduced != "" && compareSemVer(semVer, c.Introduced) < 0 {
+		return false
+	}
+	oldest, _ := parseSemVer(c.Fixed[0])
+	if v.Major == oldest.Major && v.Minor < oldest.Minor {
+		return true
+	}
+	for _, fixed := range c.Fixed {
+		f, _ := parseSemVer(fixed)
+		if f.line() == v.line() {
+			return compareSemVer(semVer, fixed) < 0
+		}
+	}
+	// Lines released after the fix never had the bug
+	return false
+}
+
+// annotateSupport records whether install's release line is past EOL and
+// which known critical CVEs it carries.
+func annotateSupport(install *GoInstallation, now time.Time) {
+	v, ok := parseSemVer(install.SemVer)
+	if !ok {
+		return
+	}
+	if eol, ok := goEOL(v, now); ok {
+		install.EOL = eol.Format("2006-01-02")
+	}
+	install.CVEs = nil
+	for _, cve := range knownCVEs {
+		if cve.affects(v) {
+			install.CVEs = append(install.CVEs, cve.ID)
+		}
+	}
+}
+
+// supportLabel is the details badge for an installation that is safe, even
+// smart, to remove.
+func supportLabel(install GoInstallation) string {
+	var labels []string
+	if install.EOL != "" {
+		labels = append(labels, "⚰️  EOL")
+	}
+	if len(install.CVEs) > 0 {
+		labels = append(labels, fmt.Sprintf("🐞 %d CVE(s)", len(install.CVEs)))
+	}
+	if label := advisoryLabel(install); label != "" {
+		labels = append(labels, label)
+	}
+	if install.DuplicateOf != "" {
+		labels = append(labels, "🪞 duplicate")
+	}
+	return strings.Join(labels, " · ")
+}
+
+// renderSupportSummary points out the selected installations that are
+// unsupported or vulnerable, i.e. the ones to remove first.
+func (m model) renderSupportSummary() string {
+	var eol, vulnerable int
+	for _, install := range m.selectedInstalls() {
+		if install.EOL != "" {
+			eol++
+		}
+		if len(install.CVEs) > 0 || len(install.Advisories) > 0 {
+			vulnerable++
+		}
+	}
+	if eol == 0 && vulnerable == 0 {
+		return ""
+	}
+	return infoStyle.Render(fmt.Sprintf("⚰️  %d selected past end of life, 🐞 %d with known vulnerabilities: safe to remove first", eol, vulnerable)) + "\n\n"
+}
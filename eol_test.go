@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSemVer(t *testing.T) {
+	testCases := []struct {
+		in   string
+		want goVersion
+		ok   bool
+	}{
+		{"1.22.5", goVersion{1, 22, 5, ""}, true},
+		{"1.20", goVersion{1, 20, 0, ""}, true},
+		{"1.21rc2", goVersion{1, 21, 0, "rc2"}, true},
+		{"1.21.0", goVersion{1, 21, 0, ""}, true},
+		{"", goVersion{}, false},
+		{"unknown version", goVersion{}, false},
+		{"1.x.2", goVersion{}, false},
+	}
+	for _, tc := range testCases {
+		got, ok := parseSemVer(tc.in)
+		if ok != tc.ok || (ok && got != tc.want) {
+			t.Errorf("parseSemVer(%q) = %+v, %v, want %+v, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestGoEOL(t *testing.T) {
+	now := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		semVer string
+		eol    string
+	}{
+		{"1.21.13", "2024-08-13"}, // 1.23 is out
+		{"1.22.6", ""},
+		{"1.23.0", ""},
+		{"1.14.15", "2021-02-16"},
+		{"1.99.0", ""}, // newer than the table
+	}
+	for _, tc := range testCases {
+		v, _ := parseSemVer(tc.semVer)
+		eol, ok := goEOL(v, now)
+		got := ""
+		if ok {
+			got = eol.Format("2006-01-02")
+		}
+		if got != tc.eol {
+			t.Errorf("goEOL(%s) = %q, want %q", tc.semVer, got, tc.eol)
+		}
+	}
+}
+
+func TestKnownCVEAffects(t *testing.T) {
+	netip := knownCVE{ID: "netip", Introduced: "1.18.0", Fixed: []string{"1.21.11", "1.22.4"}}
+	toolchain := knownCVE{ID: "toolchain", Introduced: "1.21.0", Fixed: []string{"1.21.1"}}
+	testCases := []struct {
+		cve    knownCVE
+		semVer string
+		want   bool
+	}{
+		{netip, "1.22.3", true},
+		{netip, "1.22.4", false},
+		{netip, "1.21.10", true},
+		{netip, "1.20.14", true}, // EOL before the fix
+		{netip, "1.17.13", false},
+		{netip, "1.23.0", false},
+		{toolchain, "1.21.0", true},
+		{toolchain, "1.20.14", false},
+		{toolchain, "1.22.0", false},
+	}
+	for _, tc := range testCases {
+		v, _ := parseSemVer(tc.semVer)
+		if got := tc.cve.affects(v); got != tc.want {
+			t.Errorf("%s affects %s = %v, want %v", tc.cve.ID, tc.semVer, got, tc.want)
+		}
+	}
+}
+
+func TestAnnotateSupport(t *testing.T) {
+	install := GoInstallation{SemVer: "1.20.14"}
+	annotateSupport(&install, time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC))
+	if install.EOL != "2024-02-06" {
+		t.Errorf("Expected 1.20 to have reached EOL with 1.22, got %q", install.EOL)
+	}
+	if strings.Join(install.CVEs, ",") != "CVE-2024-24790" {
+		t.Errorf("Expected only the netip CVE, got %v", install.CVEs)
+	}
+	if label := supportLabel(install); !strings.Contains(label, "EOL") || !strings.Contains(label, "1 CVE(s)") {
+		t.Errorf("Unexpected label %q", label)
+	}
+
+	unknown := GoInstallation{SemVer: ""}
+	annotateSupport(&unknown, time.Now())
+	if unknown.EOL != "" || unknown.CVEs != nil || supportLabel(unknown) != "" {
+		t.Errorf("Expected no annotations without a version, got %+v", unknown)
+	}
+}
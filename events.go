@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Detection and plan execution report what they are doing as events on an
+// eventBus rather than as bubbletea messages. The TUI subscribes with
+// waitForEvent, one tea.Cmd per event, and headless commands print the same
+// events with an eventWriter, so neither the engine nor the CLI needs to know
+// the TUI exists.
+
+type eventKind string
+
+const (
+	eventDetectionStarted  eventKind = "detection_started"
+	eventDetectorFinished  eventKind = "detector_finished"
+	eventDetectionFinished eventKind = "detection_finished"
+	eventItemQueued        eventKind = "item_queued"
+	eventProgress          eventKind = "progress"
+	eventItemDone          eventKind = "item_done"
+	eventWarning           eventKind = "warning"
+)
+
+// Phases the events belong to.
+const (
+	phaseDetect = "detect"
+	phaseRemove = "remove"
+	phaseClean  = "clean"
+)
+
+// engineEvent is one event. Which fields are set depends on Kind.
+type engineEvent struct {
+	Kind       eventKind
+	Phase      string
+	Time       time.Time
+	Detector   detectorProgress // detector_finished
+	Found      int              // detection_finished: installations found
+	Item       PlanItem         // item_queued, item_done
+	Result     itemResult       // item_done
+	Done       int              // progress
+	Total      int              // detection_started, progress: detectors or items
+	Bytes      int64            // item_done: freed; progress: bytes of the items done
+	BytesTotal int64            // progress: bytes of every item in the phase
+	Message    string           // warning
+	Path       string           // progress of a restore: the installation
+}
+
+// eventBufferSize is how far a subscriber may fall behind before publishing
+// waits for it.
+const eventBufferSize = 64
+
+// eventBus fans events out to every subscriber. A nil *eventBus is valid and
+// drops everything, so engine code can publish unconditionally.
+type eventBus struct {
+	mu          sync.Mutex
+	subscribers []chan engineEvent
+	closed      bool
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{}
+}
+
+// subscribe returns a channel that receives every event published from now
+// on and is closed with the bus.
+func (b *eventBus) subscribe() <-chan engineEvent {
+	ch := make(chan engineEvent, eventBufferSize)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch
+	}
+	b.subscribers = append(b.subscribers, ch)
+	return ch
+}
+
+// publish hands e to every subscriber. A subscriber that falls behind holds
+// the engine up rather than missing events.
+func (b *eventBus) publish(e engineEvent) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	for _, ch := range b.subscribers {
+		ch <- e
+	}
+}
+
+// close ends every subscription once the events already published are read.
+func (b *eventBus) close() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for _, ch := range b.subscribers {
+		close(ch)
+	}
+}
+
+// itemProgress publishes the events of one phase that runs items, whether
+// through executePlan or a headless command's own loop.
+type itemProgress struct {
+	events     *eventBus
+	phase      string
+	total      int
+	done       int
+	bytesTotal int64
+	bytesDone  int64
+}
+
+// startItemProgress queues items, all of which must be finished.
+func startItemProgress(events *eventBus, phase string, items []PlanItem) *itemProgress {
+	p := &itemProgress{events: events, phase: phase, total: len(items)}
+	for _, item := range items {
+		p.bytesTotal += item.Size()
+		events.publish(engineEvent{Kind: eventItemQueued, Phase: phase, Item: item})
+	}
+	return p
+}
+
+// finish publishes how an item went and where the phase stands after it.
+// Skipped and failed items count as done, so the phase still ends at 100%.
+func (p *itemProgress) finish(result itemResult) {
+	p.done++
+	p.bytesDone += result.item.Size()
+	p.events.publish(engineEvent{Kind: eventItemDone, Phase: p.phase, Item: result.item, Result: result, Bytes: result.freed()})
+	p.events.publish(engineEvent{Kind: eventProgress, Phase: p.phase, Done: p.done, Total: p.total, Bytes: p.bytesDone, BytesTotal: p.bytesTotal})
+}
+
+// engineEventMsg carries one event into Update.
+type engineEventMsg struct {
+	event engineEvent
+	next  <-chan engineEvent
+}
+
+// waitForEvent delivers the next event; it returns nil once the bus is
+// closed.
+func waitForEvent(events <-chan engineEvent) tea.Cmd {
+	return func() tea.Msg {
+		e, ok := <-events
+		if !ok {
+			return nil
+		}
+		return engineEventMsg{event: e, next: events}
+	}
+}
+
+// handleEngineEvent folds an event into what the loading and deleting
+// screens show.
+func (m model) handleEngineEvent(e engineEvent) model {
+	switch e.Kind {
+	case eventDetectorFinished:
+		m.detectorsDone = append(m.detectorsDone, e.Detector)
+	case eventItemQueued:
+		m.itemsTotal++
+	case eventItemDone:
+		m.itemsDone++
+		m.lastItem = e.Item.Describe()
+	case eventWarning:
+		if m.logFile != nil {
+			m.logFile.Log("WARN", e.Message)
+		}
+	}
+	return m
+}
+
+// renderDeleteProgress is the deleting screen's line under the spinner.
+func (m model) renderDeleteProgress() string {
+	if m.itemsTotal == 0 {
+		return ""
+	}
+	s := "\n" + infoStyle.Render(fmt.Sprintf("   %d of %d items done", m.itemsDone, m.itemsTotal)) + "\n"
+	if m.lastItem != "" {
+		s += infoStyle.Render("   Last: "+m.lastItem) + "\n"
+	}
+	return s
+}
+
+// render prints e as a sentence, or as a record with --porcelain.
+func (e eventWriter) render(ev engineEvent) {
+	switch ev.Kind {
+	case eventDetectionStarted:
+		e.emit(string(ev.Kind), fmt.Sprintf("Running %d detectors", ev.Total), "total", ev.Total)
+	case eventDetectorFinished:
+		result := ev.Detector.result
+		status, human := "ok", fmt.Sprintf("Detector %s found %d (%s)", result.name, len(result.installations), result.duration.Round(time.Millisecond))
+		switch {
+		case result.timedOut:
+			status, human = "timeout", fmt.Sprintf("Detector %s timed out after %s", result.name, result.duration.Round(time.Millisecond))
+		case result.err != nil:
+			status, human = "error", fmt.Sprintf("Detector %s failed: %v", result.name, result.err)
+		}
+		e.emit(string(ev.Kind), human, "detector", result.name, "status", status, "found", len(result.installations), "duration_ms", result.duration.Milliseconds(), "done", ev.Detector.done, "total", ev.Detector.total)
+	case eventDetectionFinished:
+		e.emit(string(ev.Kind), fmt.Sprintf("Found %d Go installation(s)", ev.Found), "found", ev.Found)
+	case eventItemQueued:
+		e.emit(string(ev.Kind), "Queued: "+ev.Item.Describe(), "kind", ev.Item.Kind(), "target", ev.Item.Target())
+	case eventProgress:
+		e.emit(string(ev.Kind), "", "done", ev.Done, "total", ev.Total)
+	case eventItemDone:
+		human := "Done: " + ev.Item.Describe()
+		if !ev.Result.ok() {
+			human = fmt.Sprintf("%s: %s (%s)", ev.Result.status(), ev.Item.Describe(), ev.Result.reason())
+		}
+		e.emit(string(ev.Kind), human, "kind", ev.Item.Kind(), "target", ev.Item.Target(), "status", ev.Result.status(), "reason", ev.Result.reason(), "duration_ms", ev.Result.duration.Milliseconds())
+	case eventWarning:
+		e.emit(string(ev.Kind), "Warning: "+ev.Message, "message", ev.Message)
+	}
+}
+
+// follow prints the events of the given kinds published on bus until it is
+// closed; commands that report their own steps leave those kinds out. Every
+// event also goes to the --progress=json stream. The returned function waits
+// for the last of them to be printed.
+func (e eventWriter) follow(bus *eventBus, kinds ...eventKind) (wait func()) {
+	events := bus.subscribe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ev := range events {
+			e.writeProgress(ev)
+			if slices.Contains(kinds, ev.Kind) {
+				e.render(ev)
+			}
+		}
+	}()
+	return func() { <-done }
+}
+
+// detectionEvents are the kinds that report detection.
+var detectionEvents = []eventKind{eventDetectionStarted, eventDetectorFinished, eventDetectionFinished}
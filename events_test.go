@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEventBusFansOut(t *testing.T) {
+	bus := newEventBus()
+	first, second := bus.subscribe(), bus.subscribe()
+	bus.publish(engineEvent{Kind: eventWarning, Message: "one"})
+	bus.close()
+	bus.publish(engineEvent{Kind: eventWarning, Message: "after close"})
+	bus.close()
+
+	for _, events := range []<-chan engineEvent{first, second} {
+		var got []string
+		for e := range events {
+			got = append(got, e.Message)
+			if e.Time.IsZero() {
+				t.Errorf("Expected published events to be timestamped")
+			}
+		}
+		if strings.Join(got, ",") != "one" {
+			t.Errorf("Expected only the event published before close, got %v", got)
+		}
+	}
+	if _, ok := <-bus.subscribe(); ok {
+		t.Errorf("Expected a subscription to a closed bus to be closed")
+	}
+
+	// Engine code publishes without checking for a bus
+	var none *eventBus
+	none.publish(engineEvent{Kind: eventWarning})
+	none.close()
+}
+
+func TestExecutePlanPublishesProgress(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "go")
+	os.MkdirAll(root, 0755)
+	items := []PlanItem{
+		installationItem{install: GoInstallation{Path: root, Source: "test"}},
+		symlinkItem{link: filepath.Join(dir, "missing"), dest: root},
+	}
+	bus := newEventBus()
+	events := bus.subscribe()
+	executePlanCmd(items, planEnv{events: bus})()
+
+	var kinds []string
+	var last engineEvent
+	for e := range events {
+		kinds = append(kinds, string(e.Kind))
+		last = e
+	}
+	want := "item_queued,item_queued,item_done,progress,item_done,progress"
+	if strings.Join(kinds, ",") != want {
+		t.Errorf("Expected %s, got %s", want, strings.Join(kinds, ","))
+	}
+	if last.Done != 2 || last.Total != 2 {
+		t.Errorf("Expected 2 of 2 done at the end, got %d of %d", last.Done, last.Total)
+	}
+}
+
+func TestModelFollowsEngineEvents(t *testing.T) {
+	bus := newEventBus()
+	events := bus.subscribe()
+	item := installationItem{install: GoInstallation{Path: "/usr/local/go", Source: "official"}}
+	m := model{state: "deleting"}
+	for _, e := range []engineEvent{
+		{Kind: eventItemQueued, Item: item},
+		{Kind: eventItemQueued, Item: item},
+		{Kind: eventItemDone, Item: item, Result: itemResult{item: item}},
+	} {
+		updated, cmd := m.Update(engineEventMsg{event: e, next: events})
+		m = updated.(model)
+		if cmd == nil {
+			t.Fatalf("Expected the next event to be awaited after %s", e.Kind)
+		}
+	}
+	bus.close()
+	if view := m.renderDeleteProgress(); !strings.Contains(view, "1 of 2 items done") || !strings.Contains(view, "/usr/local/go") {
+		t.Errorf("Unexpected deleting view:\n%s", view)
+	}
+}
+
+func TestEventWriterRendersEvents(t *testing.T) {
+	item := cacheItem{target: cacheTarget{Name: "GOCACHE", Path: "/tmp/go build"}}
+	failed := itemResult{item: item, err: errors.New("permission denied")}
+	detector := detectorProgress{result: detectorResult{name: "wsl", timedOut: true}, done: 3, total: 9}
+	bus := newEventBus()
+
+	var human, porcelain strings.Builder
+	kinds := []eventKind{eventDetectorFinished, eventItemDone, eventProgress}
+	waitHuman := eventWriter{w: &human}.follow(bus, kinds...)
+	waitPorcelain := eventWriter{w: &porcelain, porcelain: true}.follow(bus, kinds...)
+	bus.publish(engineEvent{Kind: eventItemQueued, Item: item})
+	bus.publish(engineEvent{Kind: eventDetectorFinished, Detector: detector})
+	bus.publish(engineEvent{Kind: eventItemDone, Item: item, Result: failed})
+	bus.publish(engineEvent{Kind: eventProgress, Done: 1, Total: 1})
+	bus.close()
+	waitHuman()
+	waitPorcelain()
+
+	for _, line := range []string{"Detector wsl timed out", "failed: Empty GOCACHE (/tmp/go build) (permission denied)"} {
+		if !strings.Contains(human.String(), line) {
+			t.Errorf("Expected %q in:\n%s", line, human.String())
+		}
+	}
+	if strings.Count(human.String(), "\n") != 2 {
+		t.Errorf("Expected progress to stay out of the human output, got:\n%s", human.String())
+	}
+	for _, record := range []string{
+		"event=detector_finished detector=wsl status=timeout found=0 duration_ms=0 done=3 total=9",
+		`event=item_done kind=cache target="/tmp/go build" status=failed reason="permission denied"`,
+		"event=progress done=1 total=1",
+	} {
+		if !strings.Contains(porcelain.String(), record) {
+			t.Errorf("Expected %q in:\n%s", record, porcelain.String())
+		}
+	}
+}
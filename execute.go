@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The removal runs the reviewed plan item by item, in order, and carries on
+// past an item that fails: one locked root should not leave every other
+// selected installation in place. An item that only makes sense once an
+// earlier one succeeded, such as a link into a root that is still there, is
+// skipped instead. The outcome of each item is kept for the complete screen
+// and the log.
+
+// itemResult is how one plan item went.
+type itemResult struct {
+	item     PlanItem
+	err      error
+	skipped  string // why the item did not run, "" if it did
+	duration time.Duration
+}
+
+func (r itemResult) ok() bool { return r.err == nil && r.skipped == "" }
+
+// executePlan runs items in order and reports on every one, publishing each
+// step on env.events.
+func executePlan(items []PlanItem, env planEnv) []itemResult {
+	progress := startItemProgress(env.events, phaseRemove, items)
+	results := make([]itemResult, 0, len(items))
+	var kept []string // targets still in place after a failure or skip
+	for _, item := range items {
+		result := itemResult{item: item}
+		if link, ok := item.(symlinkItem); ok && link.root != "" {
+			if keptRoot := firstWithin(link.root, kept); keptRoot != "" {
+				result.skipped = keptRoot + " was not removed"
+			}
+		}
+		if result.skipped == "" {
+			start := time.Now()
+			result.err = item.Execute(env)
+			result.duration = time.Since(start)
+			if result.err != nil {
+				kept = append(kept, item.Target())
+			}
+		}
+		results = append(results, result)
+		progress.finish(result)
+	}
+	return results
+}
+
+// firstWithin returns the first of roots that path is in, or "".
+func firstWithin(path string, roots []string) string {
+	for _, root := range roots {
+		if withinDir(path, root) {
+			return root
+		}
+	}
+	return ""
+}
+
+// executionError summarises the items that did not succeed, or is nil when
+// they all did.
+func executionError(results []itemResult) error {
+	var failed []string
+	for _, result := range results {
+		if result.err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", result.item.Target(), result.err))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d operation(s) failed: %s", len(failed), len(results), strings.Join(failed, "; "))
+}
+
+// planBackups is what the backup before a removal archives: the
+// installations items remove and, when it is among them, GOPATH.
+func planBackups(items []PlanItem) []GoInstallation {
+	var installs []GoInstallation
+	for _, item := range items {
+		switch item := item.(type) {
+		case installationItem:
+			installs = append(installs, item.install)
+		case packageUninstallItem:
+			installs = append(installs, item.install)
+		case gopathItem:
+			installs = append(installs, GoInstallation{Path: item.Target(), Size: item.Size(), Source: "gopath"})
+		}
+	}
+	return installs
+}
+
+// backupInstalls is what the backup archives: what the reviewed plan
+// removes, or before the review what the plan would.
+func (m model) backupInstalls() []GoInstallation {
+	if m.reviewedPlan != nil {
+		return planBackups(m.reviewedPlan)
+	}
+	return planBackups(m.plan())
+}
+
+// planEnv is where the plan's items keep their backups and journal changes.
+func (m model) planEnv(events *eventBus) planEnv {
+	return planEnv{allowCrossMounts: m.config.AllowCrossMounts, backupDir: m.backupPath, journal: m.paths.Journal, events: events, quarantined: m.quarantined}
+}
+
+func (m model) backupCmd() tea.Cmd {
+	installs := m.backupInstalls()
+	if m.opts.simulate {
+		return simulatedBackupCmd(installs, m.backupPath)
+	}
+	return createBackupCmd(m.config, installs, m.backupPath)
+}
+
+func (m model) deleteCmd() tea.Cmd {
+	if m.opts.simulate {
+		return simulatedDeleteCmd(m.reviewedPlan)
+	}
+	bus := newEventBus()
+	events := bus.subscribe()
+	cmd := executePlanCmd(m.reviewedPlan, m.planEnv(bus))
+	if _, ok := planContainingSelf(m.selfExe, planBackups(m.reviewedPlan)); ok {
+		if m.logFile != nil {
+			m.logFile.Log("WARN", m.selfRemovalWarning())
+		}
+		if currentPlatform.locksRunningBinary() {
+			cmd = relocateSelfThen(m.selfExe, m.logFile, cmd)
+		}
+	}
+	return tea.Batch(cmd, waitForEvent(events))
+}
+
+// executePlanCmd runs the plan off the UI goroutine.
+func executePlanCmd(items []PlanItem, env planEnv) tea.Cmd {
+	return func() tea.Msg {
+		results := executePlan(items, env)
+		env.events.close()
+		err := executionError(results)
+		return deleteGoCompleted{success: err == nil, err: err, results: results}
+	}
+}
+
+// logResults writes one line per item: what ran, what failed and why, and
+// what was skipped.
+func (m model) logResults(results []itemResult) {
+	if m.logFile == nil {
+		return
+	}
+	for _, result := range results {
+		switch {
+		case result.skipped != "":
+			m.logFile.Log("WARN", fmt.Sprintf("Skipped %s: %s", result.item.Describe(), result.skipped))
+		case result.err != nil:
+			m.logFile.Log("ERROR", fmt.Sprintf("%s failed: %v", result.item.Describe(), result.err))
+		default:
+			m.logFile.Log("SUCCESS", fmt.Sprintf("%s (%s)", result.item.Describe(), result.duration.Round(time.Millisecond)))
+		}
+	}
+}
+
+// anyRemoved reports whether at least one item succeeded.
+func anyRemoved(results []itemResult) bool {
+	for _, result := range results {
+		if result.ok() {
+			return true
+		}
+	}
+	return false
+}
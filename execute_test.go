@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecutePlanContinuesPastFailures(t *testing.T) {
+	skipInAuditBuild(t)
+	locked := fakeGoRoot(t, "VERSION", "bin/go")
+	removable := fakeGoRoot(t, "VERSION", "bin/go")
+	gopath := filepath.Join(t.TempDir(), "go")
+	if err := os.MkdirAll(filepath.Join(gopath, "pkg", "mod"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	bin := t.TempDir()
+	os.Symlink(filepath.Join(locked, "bin", "go"), filepath.Join(bin, "go"))
+	os.Symlink(filepath.Join(removable, "bin", "go"), filepath.Join(bin, "gofmt"))
+
+	items := []PlanItem{
+		installationItem{install: GoInstallation{Path: locked, Blocked: "mount point inside"}},
+		installationItem{install: GoInstallation{Path: removable}},
+	}
+	items = append(items, findGoSymlinks([]string{bin}, []string{locked, removable})...)
+	items = append(items, gopathItem{path: gopath})
+
+	results := executePlan(items, planEnv{})
+	if len(results) != len(items) {
+		t.Fatalf("Expected a result per item, got %d", len(results))
+	}
+	if results[0].err == nil || !results[1].ok() {
+		t.Errorf("Expected the blocked root to fail and the other to be removed, got %v and %v", results[0].err, results[1].err)
+	}
+	if _, err := os.Stat(removable); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed after the earlier failure, got %v", removable, err)
+	}
+	if results[2].skipped == "" || results[3].skipped != "" || !results[3].ok() {
+		t.Errorf("Expected only the link into the kept root to be skipped, got %+v and %+v", results[2], results[3])
+	}
+	if _, err := os.Lstat(filepath.Join(bin, "go")); err != nil {
+		t.Errorf("Expected the link into the kept root to stay: %v", err)
+	}
+	if !results[4].ok() {
+		t.Errorf("Expected GOPATH to be removed, got %v", results[4].err)
+	}
+
+	err := executionError(results)
+	if err == nil || !strings.HasPrefix(err.Error(), "1 of 5 operation(s) failed: "+locked) {
+		t.Errorf("Unexpected summary %v", err)
+	}
+	if executionError(results[1:2]) != nil {
+		t.Errorf("Expected no error when every item succeeded")
+	}
+}
+
+func TestPartialFailureShownOnComplete(t *testing.T) {
+	skipInAuditBuild(t)
+	locked := fakeGoRoot(t, "VERSION", "bin/go")
+	removable := fakeGoRoot(t, "VERSION", "bin/go")
+	m := model{
+		state: "deleting",
+		opts:  runOptions{simulate: true},
+		reviewedPlan: []PlanItem{
+			installationItem{install: GoInstallation{Path: locked, Blocked: "mount point inside"}},
+			installationItem{install: GoInstallation{Path: removable}},
+		},
+	}
+	msg := executePlanCmd(m.reviewedPlan, planEnv{})()
+	updated, _ := m.Update(msg)
+	m = updated.(model)
+	if m.state != "complete" || m.deletionComplete || m.err == nil {
+		t.Fatalf("Expected a failed run, got state %s, complete %v, err %v", m.state, m.deletionComplete, m.err)
+	}
+	if len(m.results) != 2 || m.results[0].status() != statusFailed || m.results[1].status() != statusRemoved {
+		t.Errorf("Expected a result for every item, got %+v", m.results)
+	}
+	if view := m.View(); !strings.Contains(view, "1 removed, 1 failed") {
+		t.Errorf("Expected the outcome on the complete screen, got:\n%s", view)
+	}
+}
+
+func TestBackupCoversOnlyThePlan(t *testing.T) {
+	kept := fakeGoRoot(t, "VERSION", "bin/go")
+	removed := fakeGoRoot(t, "VERSION", "bin/go")
+	blocked := fakeGoRoot(t, "VERSION", "bin/go")
+	state := t.TempDir()
+	m := model{
+		detectedInstalls: []GoInstallation{
+			{Path: kept, Verified: true},
+			{Path: removed, Verified: true},
+			{Path: blocked, Verified: true, Blocked: "protected by the machine policy"},
+		},
+		selection:  map[string]bool{kept: false},
+		backupPath: filepath.Join(state, "backups"),
+		paths:      fugoPaths{Journal: filepath.Join(state, "env-journal.jsonl")},
+	}
+	m = m.startReview(ConfirmationStepInitial)
+	installs := m.backupInstalls()
+	if len(installs) != 1 || installs[0].Path != removed {
+		t.Errorf("Expected only %s to be backed up, got %+v", removed, installs)
+	}
+	if env := m.planEnv(nil); env.backupDir != m.backupPath || env.journal != m.paths.Journal {
+		t.Errorf("Expected the plan to keep its backups and journal in the state directory, got %+v", env)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// After a successful removal fu-go sees Go out: the gopher walks off the
+// screen, or fireworks go off, depending on the farewell setting. Any key
+// skips to the summary. The mono theme and dumb terminals, which is where
+// screen readers and plain logs live, never animate.
+
+const (
+	farewellGopher    = "gopher"
+	farewellFireworks = "fireworks"
+	farewellOff       = "off"
+
+	farewellFrameInterval = 80 * time.Millisecond
+)
+
+// farewellTick advances the animation by one frame.
+type farewellTick struct{}
+
+func farewellTickCmd() tea.Cmd {
+	return tea.Tick(farewellFrameInterval, func(time.Time) tea.Msg { return farewellTick{} })
+}
+
+// plainOutput reports whether the terminal should get no animation.
+func plainOutput(cfg Config) bool {
+	return cfg.Theme == "mono" || os.Getenv("TERM") == "dumb"
+}
+
+// farewellStyle is the configured animation, "" when there is none.
+func farewellStyle(cfg Config) string {
+	if plainOutput(cfg) || cfg.Farewell == farewellOff {
+		return ""
+	}
+	if cfg.Farewell == "" {
+		return farewellGopher
+	}
+	return cfg.Farewell
+}
+
+var gopherFrames = [2][]string{
+	{` ʕ◔ϖ◔ʔ `, `  /  \ `},
+	{` ʕ◔ϖ◔ʔ `, `  |  | `},
+}
+
+// gopherWalk is the gopher walking from the middle of a width-wide screen
+// off its right edge.
+func gopherWalk(width int) [][]string {
+	var frames [][]string
+	for i, x := 0, width/2; x < width; i, x = i+1, x+2 {
+		frame := make([]string, 0, 3)
+		for _, line := range gopherFrames[i%2] {
+			frame = append(frame, strings.Repeat(" ", x)+line)
+		}
+		frames = append(frames, append(frame, strings.Repeat(" ", width/2-4)+"bye, Go 👋"))
+	}
+	return frames
+}
+
+// fireworks is three bursts going off in turn across a width-wide sky.
+func fireworks(width int) [][]string {
+	const height, radius = 7, 4
+	width = min(max(width, 24), 72)
+	bursts := []struct{ x, start int }{{width / 4, 0}, {width / 2, 3}, {3 * width / 4, 6}}
+	var frames [][]string
+	for frame := 0; frame < bursts[len(bursts)-1].start+radius+1; frame++ {
+		sky := make([][]rune, height)
+		for y := range sky {
+			sky[y] = []rune(strings.Repeat(" ", width))
+		}
+		plot := func(x, y int, r rune) {
+			if y >= 0 && y < height && x >= 0 && x < width {
+				sky[y][x] = r
+			}
+		}
+		for _, burst := range bursts {
+			r := frame - burst.start
+			if r < 0 || r > radius {
+				continue
+			}
+			spark := '*'
+			if r == radius {
+				spark = '.'
+			}
+			cy := height / 2
+			if r == 0 {
+				plot(burst.x, cy, '+')
+				continue
+			}
+			// Cells are about twice as tall as wide, so spread x twice as far
+			for _, d := range [][2]int{{0, -1}, {0, 1}, {-2, 0}, {2, 0}, {-2, -1}, {2, -1}, {-2, 1}, {2, 1}} {
+				plot(burst.x+d[0]*r, cy+d[1]*r, spark)
+			}
+		}
+		lines := make([]string, height)
+		for y := range sky {
+			lines[y] = string(sky[y])
+		}
+		frames = append(frames, lines)
+	}
+	return frames
+}
+
+// startFarewell begins the animation, if any, once the summary is ready.
+func (m model) startFarewell() (model, tea.Cmd) {
+	switch farewellStyle(m.config) {
+	case farewellGopher:
+		m.farewellFrames = gopherWalk(max(m.width, 40))
+	case farewellFireworks:
+		m.farewellFrames = fireworks(m.width)
+	default:
+		return m, nil
+	}
+	m.farewellFrame = 0
+	return m, farewellTickCmd()
+}
+
+// handleFarewellTick shows the next frame, or the summary after the last.
+func (m model) handleFarewellTick() (model, tea.Cmd) {
+	if m.farewellFrames == nil {
+		return m, nil
+	}
+	m.farewellFrame++
+	if m.farewellFrame >= len(m.farewellFrames) {
+		m.farewellFrames = nil
+		return m, nil
+	}
+	return m, farewellTickCmd()
+}
+
+func (m model) renderFarewell() string {
+	frame := strings.Join(m.farewellFrames[m.farewellFrame], "\n")
+	s := successStyle.Render(frame) + "\n\n"
+	return s + lipgloss.PlaceHorizontal(m.width, lipgloss.Center, infoStyle.Render("Press any key to skip")) + "\n"
+}
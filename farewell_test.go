@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFarewellStyle(t *testing.T) {
+	t.Setenv("TERM", "xterm-256color")
+	tests := []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{}, farewellGopher},
+		{Config{Farewell: farewellFireworks}, farewellFireworks},
+		{Config{Farewell: farewellOff}, ""},
+		{Config{Farewell: farewellFireworks, Theme: "mono"}, ""},
+	}
+	for _, tt := range tests {
+		if got := farewellStyle(tt.cfg); got != tt.want {
+			t.Errorf("farewellStyle(%+v) = %q, expected %q", tt.cfg, got, tt.want)
+		}
+	}
+	t.Setenv("TERM", "dumb")
+	if got := farewellStyle(Config{}); got != "" {
+		t.Errorf("Expected no animation on a dumb terminal, got %q", got)
+	}
+	if err := (Config{Farewell: "confetti"}).validate(); err == nil {
+		t.Errorf("Expected an unknown farewell to be rejected")
+	}
+}
+
+func TestFarewellFrames(t *testing.T) {
+	walk := gopherWalk(40)
+	if len(walk) != 10 {
+		t.Fatalf("Expected the gopher to take 10 steps off a 40-column screen, got %d", len(walk))
+	}
+	if !strings.HasPrefix(walk[0][0], strings.Repeat(" ", 20)+" ʕ◔ϖ◔ʔ") || walk[0][1] == walk[1][1] {
+		t.Errorf("Expected the gopher to start in the middle and move its legs, got %q", walk[:2])
+	}
+
+	frames := fireworks(40)
+	for i, frame := range frames {
+		if len(frame) != 7 {
+			t.Fatalf("Frame %d has %d lines", i, len(frame))
+		}
+	}
+	if !strings.Contains(frames[0][3], "+") || !strings.Contains(frames[len(frames)-1][3], ".") {
+		t.Errorf("Expected the first burst to start and the last to fade, got %q and %q", frames[0], frames[len(frames)-1])
+	}
+}
+
+func TestFarewellSkip(t *testing.T) {
+	t.Setenv("TERM", "xterm-256color")
+	m := model{state: "complete", deletionComplete: true, width: 40}
+	m, cmd := m.startFarewell()
+	if m.farewellFrames == nil || cmd == nil {
+		t.Fatalf("Expected the farewell to start")
+	}
+	if !strings.Contains(m.View(), "Press any key to skip") {
+		t.Errorf("Expected the animation in place of the summary")
+	}
+	m, _ = m.handleFarewellTick()
+	if m.farewellFrame != 1 {
+		t.Errorf("Expected a tick to advance the animation, at frame %d", m.farewellFrame)
+	}
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	m = next.(model)
+	if m.farewellFrames != nil || !strings.Contains(m.View(), "Success!") {
+		t.Errorf("Expected a key to skip to the summary")
+	}
+	if m, cmd = m.handleFarewellTick(); cmd != nil {
+		t.Errorf("Expected a late tick to be ignored")
+	}
+
+	m.config.Theme = "mono"
+	if m, cmd = m.startFarewell(); m.farewellFrames != nil || cmd != nil {
+		t.Errorf("Expected no animation with the mono theme")
+	}
+}
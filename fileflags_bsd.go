@@ -0,0 +1,27 @@
+//go:build darwin || freebsd || openbsd || netbsd || dragonfly
+
+package main
+
+import "syscall"
+
+const (
+	ufImmutable = 0x00000002
+	ufAppend    = 0x00000004
+	sfImmutable = 0x00020000
+	sfAppend    = 0x00040000
+)
+
+// fileFlagsBlocker reads the file flags set by chflags(1).
+func fileFlagsBlocker(path string) string {
+	var st syscall.Stat_t
+	if err := syscall.Lstat(path, &st); err != nil {
+		return ""
+	}
+	switch {
+	case st.Flags&(ufImmutable|sfImmutable) != 0:
+		return "immutable (chflags uchg/schg)"
+	case st.Flags&(ufAppend|sfAppend) != 0:
+		return "append-only (chflags uappnd/sappnd)"
+	}
+	return ""
+}
@@ -0,0 +1,29 @@
+package main
+
+import "golang.org/x/sys/unix"
+
+const (
+	fsImmutableFlag = 0x00000010 // FS_IMMUTABLE_FL
+	fsAppendFlag    = 0x00000020 // FS_APPEND_FL
+)
+
+// fileFlagsBlocker reads the inode flags set by chattr(1).
+func fileFlagsBlocker(path string) string {
+	fd, err := unix.Open(path, unix.O_RDONLY|unix.O_NOFOLLOW|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return ""
+	}
+	defer unix.Close(fd)
+
+	flags, err := unix.IoctlGetUint32(fd, unix.FS_IOC_GETFLAGS)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case flags&fsImmutableFlag != 0:
+		return "immutable (chattr +i)"
+	case flags&fsAppendFlag != 0:
+		return "append-only (chattr +a)"
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+package main
+
+import "os"
+
+// fileSystem is the seam between fu-go's decision logic and the disk, so that
+// checks and removals can be swapped out or observed in tests. Every change a
+// plan item makes to the disk goes through it.
+type fileSystem interface {
+	Stat(path string) (os.FileInfo, error)
+	Remove(path string) error
+	RemoveAll(path string) error
+	Chmod(path string, mode os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	WriteFile(path string, data []byte, perm os.FileMode) error
+	AppendFile(path string, data []byte, perm os.FileMode) error
+	// Rename moves a file or directory within one file system.
+	Rename(from, to string) error
+	// Symlink points link at target, replacing whatever link is there in
+	// one step.
+	Symlink(target, link string) error
+	// CheckWritable reports whether the current user may create and delete
+	// entries in dir, without writing anything to it.
+	CheckWritable(dir string) error
+}
+
+type osFS struct{}
+
+// The methods that change the disk call the functions in destructive.go, so
+// the audit build compiles none of them in.
+
+func (osFS) Stat(path string) (os.FileInfo, error)        { return os.Stat(path) }
+func (osFS) Remove(path string) error                     { return removePath(path) }
+func (osFS) RemoveAll(path string) error                  { return removeAllPaths(path) }
+func (osFS) Chmod(path string, mode os.FileMode) error    { return chmodPath(path, mode) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return makeDirs(path, perm) }
+func (osFS) CheckWritable(dir string) error               { return checkWritable(dir) }
+func (osFS) Symlink(target, link string) error            { return replaceSymlink(target, link) }
+func (osFS) Rename(from, to string) error                 { return renamePath(from, to) }
+
+func (osFS) WriteFile(path string, data []byte, perm os.FileMode) error {
+	return writeFile(path, data, perm)
+}
+
+func (osFS) AppendFile(path string, data []byte, perm os.FileMode) error {
+	return appendFile(path, data, perm)
+}
+
+var fsys fileSystem = osFS{}
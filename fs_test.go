@@ -0,0 +1,279 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// skipInAuditBuild skips a test that changes files, which the audit build
+// refuses to do.
+func skipInAuditBuild(t *testing.T) {
+	t.Helper()
+	if auditBuild {
+		t.Skip("the audit build changes no files")
+	}
+}
+
+// recordingFS passes every call through to the disk and remembers each path
+// it was asked to change.
+type recordingFS struct {
+	fileSystem
+	mu      sync.Mutex
+	changed []string
+}
+
+func (r *recordingFS) record(op, path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changed = append(r.changed, op+" "+path)
+}
+
+func (r *recordingFS) Remove(path string) error {
+	r.record("remove", path)
+	return r.fileSystem.Remove(path)
+}
+
+func (r *recordingFS) RemoveAll(path string) error {
+	r.record("remove", path)
+	return r.fileSystem.RemoveAll(path)
+}
+
+func (r *recordingFS) Chmod(path string, mode os.FileMode) error {
+	r.record("chmod", path)
+	return r.fileSystem.Chmod(path, mode)
+}
+
+func (r *recordingFS) MkdirAll(path string, perm os.FileMode) error {
+	r.record("mkdir", path)
+	return r.fileSystem.MkdirAll(path, perm)
+}
+
+func (r *recordingFS) WriteFile(path string, data []byte, perm os.FileMode) error {
+	r.record("write", path)
+	return r.fileSystem.WriteFile(path, data, perm)
+}
+
+func (r *recordingFS) AppendFile(path string, data []byte, perm os.FileMode) error {
+	r.record("append", path)
+	return r.fileSystem.AppendFile(path, data, perm)
+}
+
+func (r *recordingFS) Rename(from, to string) error {
+	r.record("rename", from)
+	return r.fileSystem.Rename(from, to)
+}
+
+func (r *recordingFS) Symlink(target, link string) error {
+	r.record("link", link)
+	return r.fileSystem.Symlink(target, link)
+}
+
+// treeEntry is what a snapshot remembers about one path.
+type treeEntry struct {
+	mode fs.FileMode
+	data string // content hash of a file, target of a link
+}
+
+// snapshotTree records every path under root without following links.
+func snapshotTree(t *testing.T, root string) map[string]treeEntry {
+	t.Helper()
+	entries := make(map[string]treeEntry)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entry := treeEntry{mode: info.Mode()}
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			entry.data, err = os.Readlink(path)
+		case d.Type().IsRegular():
+			var content []byte
+			content, err = os.ReadFile(path)
+			entry.data = fmt.Sprintf("%x", sha256.Sum256(content))
+		}
+		entries[path] = entry
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to snapshot %s: %v", root, err)
+	}
+	return entries
+}
+
+// randomTree fills root with nested directories, files and links, and
+// returns the directories and files it made.
+func randomTree(t *testing.T, rng *rand.Rand, root string) (dirs, files []string) {
+	t.Helper()
+	var fill func(dir string, depth int)
+	fill = func(dir string, depth int) {
+		for i := range rng.IntN(4) + 1 {
+			path := filepath.Join(dir, fmt.Sprintf("f%d", i))
+			mode := []os.FileMode{0644, 0600, 0444, 0755}[rng.IntN(4)]
+			if err := os.WriteFile(path, []byte(strings.Repeat("x", rng.IntN(64))), mode); err != nil {
+				t.Fatal(err)
+			}
+			files = append(files, path)
+		}
+		if depth == 3 {
+			return
+		}
+		for i := range rng.IntN(4) {
+			sub := filepath.Join(dir, fmt.Sprintf("d%d", i))
+			if err := os.Mkdir(sub, 0755); err != nil {
+				t.Fatal(err)
+			}
+			dirs = append(dirs, sub)
+			fill(sub, depth+1)
+		}
+	}
+	fill(root, 0)
+	return dirs, files
+}
+
+// randomPlan builds a plan over the tree: roots and GOPATHs to remove, caches
+// to empty, links to delete and profiles to edit. Targets may nest, and links
+// anywhere may point in or out of them.
+func randomPlan(t *testing.T, rng *rand.Rand, root string, dirs, files []string) []PlanItem {
+	t.Helper()
+	pick := func(paths []string) string { return paths[rng.IntN(len(paths))] }
+	var items []PlanItem
+	var roots []string
+	if len(dirs) > 0 {
+		for range rng.IntN(4) {
+			dir := pick(dirs)
+			switch rng.IntN(3) {
+			case 0:
+				items = append(items, installationItem{install: GoInstallation{Path: dir, Source: "test"}})
+				roots = append(roots, dir)
+			case 1:
+				items = append(items, gopathItem{path: dir})
+			case 2:
+				items = append(items, cacheItem{target: cacheTarget{Name: "GOCACHE", Path: dir}})
+			}
+		}
+	}
+	everything := append(append([]string{root}, dirs...), files...)
+	for i := range rng.IntN(5) {
+		dest := pick(everything)
+		link := filepath.Join(pick(append([]string{root}, dirs...)), fmt.Sprintf("link%d", i))
+		if err := os.Symlink(dest, link); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+		if rng.IntN(2) == 0 {
+			linkRoot := ""
+			if len(roots) > 0 {
+				linkRoot = pick(roots)
+			}
+			items = append(items, symlinkItem{link: link, dest: dest, root: linkRoot})
+		}
+	}
+	for i := range rng.IntN(3) {
+		profile := filepath.Join(pick(append([]string{root}, dirs...)), fmt.Sprintf("profile%d", i))
+		lines := []string{"# profile", "export GOROOT=/usr/local/go", "export PATH=$PATH:/usr/local/go/bin", "alias ll='ls -l'"}
+		if err := os.WriteFile(profile, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+			t.Fatal(err)
+		}
+		start := rng.IntN(len(lines))
+		hunk := profileHunk{Start: start, Count: rng.IntN(len(lines)-start) + 1, Accept: rng.IntN(4) > 0}
+		items = append(items, envEditItem{edit: profileEdit{Path: profile, Lines: lines, Hunks: []profileHunk{hunk}}})
+	}
+	rng.Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
+	return items
+}
+
+// inClosure reports whether the plan may change path: a target or anything
+// below one, a link it deletes, a profile it edits with the backup next to
+// it, or the journal the edits are recorded in.
+func inClosure(path string, items []PlanItem, env planEnv) bool {
+	if path == env.journal || path == filepath.Dir(env.journal) || withinDir(path, env.backupDir) {
+		return true
+	}
+	for _, item := range items {
+		switch item := item.(type) {
+		case symlinkItem:
+			if path == item.link {
+				return true
+			}
+		case envEditItem:
+			edited := item.edit.Path
+			if path == edited || strings.HasPrefix(path, edited+".") && strings.HasSuffix(path, ".bak") {
+				return true
+			}
+		default:
+			if withinDir(path, item.Target()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TestPlanTouchesNothingOutsideItsClosure executes random plans over random
+// trees and checks that every path outside the plan is exactly as it was,
+// both as the file system seam saw it and as the disk does afterwards.
+func TestPlanTouchesNothingOutsideItsClosure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+	runs := 200
+	if testing.Short() {
+		runs = 20
+	}
+	saved := fsys
+	defer func() { fsys = saved }()
+
+	for seed := range uint64(runs) {
+		rng := rand.New(rand.NewPCG(seed, 1939))
+		root := t.TempDir()
+		dirs, files := randomTree(t, rng, root)
+		items := randomPlan(t, rng, root, dirs, files)
+		env := planEnv{backupDir: filepath.Join(root, "state", "backups"), journal: filepath.Join(root, "state", "env-journal.jsonl")}
+		before := snapshotTree(t, root)
+
+		recorder := &recordingFS{fileSystem: saved}
+		fsys = recorder
+		executePlan(items, env)
+		fsys = saved
+
+		for _, change := range recorder.changed {
+			path := change[strings.Index(change, " ")+1:]
+			if !inClosure(path, items, env) {
+				t.Errorf("seed %d: %s is outside the plan %v", seed, change, describeItems(items))
+			}
+		}
+		after := snapshotTree(t, root)
+		for path, was := range before {
+			if now, ok := after[path]; (!ok || now != was) && !inClosure(path, items, env) {
+				t.Errorf("seed %d: %s was changed or deleted outside the plan %v", seed, path, describeItems(items))
+			}
+		}
+		for path := range after {
+			if _, ok := before[path]; !ok && !inClosure(path, items, env) {
+				t.Errorf("seed %d: %s was created outside the plan %v", seed, path, describeItems(items))
+			}
+		}
+		if t.Failed() {
+			return
+		}
+	}
+}
+
+func describeItems(items []PlanItem) []string {
+	described := make([]string, len(items))
+	for i, item := range items {
+		described[i] = item.Describe()
+	}
+	return described
+}
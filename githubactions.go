@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Under GitHub Actions, ci-clean also reports to the Actions UI: a notice
+// annotation with the space reclaimed and a Markdown section in the job
+// summary listing what was removed and what was left alone. The workflow
+// commands go to stderr, which the runner reads as well, so stdout stays the
+// JSON summary.
+
+const actionsTitle = "fu-go ci-clean"
+
+// actionsReporter writes workflow commands to w and the job summary to the
+// file GITHUB_STEP_SUMMARY names.
+type actionsReporter struct {
+	w           io.Writer
+	summaryPath string
+}
+
+// githubActions returns a reporter when running in a GitHub Actions job.
+func githubActions(getenv func(string) string, w io.Writer) (actionsReporter, bool) {
+	if getenv("GITHUB_ACTIONS") != "true" {
+		return actionsReporter{}, false
+	}
+	return actionsReporter{w: w, summaryPath: getenv("GITHUB_STEP_SUMMARY")}, true
+}
+
+// workflowData escapes the message of a workflow command.
+func workflowData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// workflowProperty escapes a property value of a workflow command.
+func workflowProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+func (a actionsReporter) command(name, message string) {
+	fmt.Fprintf(a.w, "::%s title=%s::%s\n", name, workflowProperty(actionsTitle), workflowData(message))
+}
+
+// fail annotates the job with why ci-clean stopped.
+func (a actionsReporter) fail(err error) {
+	a.command("error", err.Error())
+}
+
+// report annotates the job with the space reclaimed and appends the job
+// summary.
+func (a actionsReporter) report(r *changeReport) error {
+	verb := "Freed"
+	if r.DryRun {
+		verb = "Would free"
+	}
+	a.command("notice", fmt.Sprintf("%s %s: %d change(s), %d item(s) left alone", verb, formatBytes(r.FreedBytes), len(r.Changes), len(r.skipped)))
+	if a.summaryPath == "" {
+		return nil
+	}
+	f, err := os.OpenFile(a.summaryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, stepSummary(r)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// stepSummary is the job summary section for r.
+func stepSummary(r *changeReport) string {
+	var b strings.Builder
+	b.WriteString("### 🧹 " + actionsTitle + "\n\n")
+	switch {
+	case r.DryRun:
+		fmt.Fprintf(&b, "Dry run: would free **%s**.\n\n", formatBytes(r.FreedBytes))
+	default:
+		fmt.Fprintf(&b, "Freed **%s**.\n\n", formatBytes(r.FreedBytes))
+	}
+	if len(r.Changes) > 0 {
+		b.WriteString("| Change |\n| --- |\n")
+		for _, change := range r.Changes {
+			fmt.Fprintf(&b, "| `%s` |\n", strings.ReplaceAll(change, "|", `\|`))
+		}
+		b.WriteString("\n")
+	}
+	if len(r.skipped) > 0 {
+		fmt.Fprintf(&b, "<details><summary>Left alone (%d)</summary>\n\n", len(r.skipped))
+		for _, skipped := range r.skipped {
+			fmt.Fprintf(&b, "- %s\n", skipped)
+		}
+		b.WriteString("\n</details>\n\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitHubActions(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+	if _, ok := githubActions(env(nil), nil); ok {
+		t.Errorf("Expected no reporter outside GitHub Actions")
+	}
+
+	summary := filepath.Join(t.TempDir(), "summary.md")
+	os.WriteFile(summary, []byte("earlier step\n"), 0644)
+	var out strings.Builder
+	actions, ok := githubActions(env(map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_STEP_SUMMARY": summary}), &out)
+	if !ok {
+		t.Fatalf("Expected a reporter under GitHub Actions")
+	}
+
+	report := newChangeReport(false, false)
+	report.add("remove /home/runner/sdk/go1.22.5", 2<<30)
+	report.skip("/usr/local/go", "outside-home")
+	if err := actions.report(report); err != nil {
+		t.Fatalf("report returned error: %v", err)
+	}
+	if want := "::notice title=fu-go ci-clean::Freed 2.0 GB: 1 change(s), 1 item(s) left alone\n"; out.String() != want {
+		t.Errorf("Expected notice %q, got %q", want, out.String())
+	}
+	data, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatalf("Failed to read the job summary: %v", err)
+	}
+	for _, want := range []string{"earlier step\n", "Freed **2.0 GB**", "| `remove /home/runner/sdk/go1.22.5` |", "- /usr/local/go: outside-home"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected the job summary to contain %q, got:\n%s", want, data)
+		}
+	}
+
+	out.Reset()
+	actions.fail(errors.New("100% full\nno space"))
+	if want := "::error title=fu-go ci-clean::100%25 full%0Ano space\n"; out.String() != want {
+		t.Errorf("Expected error %q, got %q", want, out.String())
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// fu-go resolves the Go environment the way the go command does, without
+// running it: the process environment wins, then the go env file written by
+// `go env -w`, then the built-in defaults. Machines with a relocated GOPATH
+// or module cache are otherwise scanned in the wrong place.
+
+var goEnvVars = []string{"GOPATH", "GOMODCACHE", "GOCACHE", "GOFLAGS", "GOTOOLCHAIN"}
+
+// goEnvValue is one resolved setting and where it came from: "environment",
+// the go env file path, or "default".
+type goEnvValue struct {
+	Value  string
+	Source string
+}
+
+type goEnv map[string]goEnvValue
+
+// goEnvFile is the file `go env -w` writes, or "" when GOENV=off.
+func goEnvFile(goos string, getenv func(string) string, home string) string {
+	if file := getenv("GOENV"); file != "" {
+		if file == "off" {
+			return ""
+		}
+		return file
+	}
+	// platformDirs puts fu-go's config next to Go's in the user config dir
+	return filepath.Join(filepath.Dir(platformDirs(goos, getenv, home).Config), "go", "env")
+}
+
+// parseGoEnvFile reads KEY=VALUE lines, skipping blanks and comments.
+func parseGoEnvFile(data []byte) map[string]string {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
+
+// userCacheBase mirrors os.UserCacheDir for goos.
+func userCacheBase(goos string, getenv func(string) string, home string) string {
+	switch goos {
+	case "darwin", "ios":
+		return filepath.Join(home, "Library", "Caches")
+	case "windows":
+		if dir := getenv("LocalAppData"); dir != "" {
+			return dir
+		}
+		return filepath.Join(home, "AppData", "Local")
+	default:
+		if dir := getenv("XDG_CACHE_HOME"); filepath.IsAbs(dir) {
+			return dir
+		}
+		return filepath.Join(home, ".cache")
+	}
+}
+
+func resolveGoEnv(goos string, getenv func(string) string, home string) goEnv {
+	file := goEnvFile(goos, getenv, home)
+	var fileValues map[string]string
+	if file != "" {
+		if data, err := os.ReadFile(file); err == nil {
+			fileValues = parseGoEnvFile(data)
+		}
+	}
+
+	env := make(goEnv)
+	lookup := func(name string) (goEnvValue, bool) {
+		if value := getenv(name); value != "" {
+			return goEnvValue{value, "environment"}, true
+		}
+		if value := fileValues[name]; value != "" {
+			return goEnvValue{value, file}, true
+		}
+		return goEnvValue{}, false
+	}
+
+	for _, name := range goEnvVars {
+		if value, ok := lookup(name); ok {
+			env[name] = value
+		}
+	}
+	if _, ok := env["GOPATH"]; !ok {
+		env["GOPATH"] = goEnvValue{filepath.Join(home, "go"), "default"}
+	}
+	if _, ok := env["GOMODCACHE"]; !ok {
+		if gopaths := env.gopaths(); len(gopaths) > 0 {
+			env["GOMODCACHE"] = goEnvValue{filepath.Join(gopaths[0], "pkg", "mod"), "default"}
+		}
+	}
+	if _, ok := env["GOCACHE"]; !ok {
+		env["GOCACHE"] = goEnvValue{filepath.Join(userCacheBase(goos, getenv, home), "go-build"), "default"}
+	}
+	if _, ok := env["GOTOOLCHAIN"]; !ok {
+		env["GOTOOLCHAIN"] = goEnvValue{"auto", "default"}
+	}
+	return env
+}
+
+func currentGoEnv() goEnv {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return goEnv{}
+	}
+	return resolveGoEnv(runtime.GOOS, os.Getenv, home)
+}
+
+func (e goEnv) get(name string) string {
+	return e[name].Value
+}
+
+// gopaths splits GOPATH into its entries; the first one is where the go
+// command puts the module cache and installed binaries.
+func (e goEnv) gopaths() []string {
+	var paths []string
+	for _, path := range filepath.SplitList(e.get("GOPATH")) {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveGoEnv(t *testing.T) {
+	home := t.TempDir()
+	configHome := filepath.Join(home, "config")
+	envFile := filepath.Join(configHome, "go", "env")
+	if err := os.MkdirAll(filepath.Dir(envFile), 0755); err != nil {
+		t.Fatalf("Failed to create go config dir: %v", err)
+	}
+	if err := os.WriteFile(envFile, []byte("# written by go env -w\nGOPATH=/data/gopath\nGOFLAGS=-modcacherw\n"), 0644); err != nil {
+		t.Fatalf("Failed to write go env file: %v", err)
+	}
+
+	vars := map[string]string{"XDG_CONFIG_HOME": configHome, "GOTOOLCHAIN": "go1.22.5+auto"}
+	env := resolveGoEnv("linux", func(name string) string { return vars[name] }, home)
+
+	tests := []struct {
+		name, value, source string
+	}{
+		{"GOPATH", "/data/gopath", envFile},
+		{"GOMODCACHE", "/data/gopath/pkg/mod", "default"},
+		{"GOFLAGS", "-modcacherw", envFile},
+		{"GOTOOLCHAIN", "go1.22.5+auto", "environment"},
+		{"GOCACHE", filepath.Join(home, ".cache", "go-build"), "default"},
+	}
+	for _, tt := range tests {
+		if got := env[tt.name]; got.Value != tt.value || got.Source != tt.source {
+			t.Errorf("resolveGoEnv %s = %+v, expected %s from %s", tt.name, got, tt.value, tt.source)
+		}
+	}
+
+	// The environment beats the file, and GOENV=off ignores the file
+	vars["GOPATH"] = "/env/gopath"
+	if got := resolveGoEnv("linux", func(name string) string { return vars[name] }, home).get("GOPATH"); got != "/env/gopath" {
+		t.Errorf("Expected GOPATH from the environment, got %s", got)
+	}
+	delete(vars, "GOPATH")
+	vars["GOENV"] = "off"
+	if got := resolveGoEnv("linux", func(name string) string { return vars[name] }, home).get("GOPATH"); got != filepath.Join(home, "go") {
+		t.Errorf("Expected the default GOPATH with GOENV=off, got %s", got)
+	}
+}
+
+func TestGoEnvFile(t *testing.T) {
+	getenv := func(vars map[string]string) func(string) string {
+		return func(name string) string { return vars[name] }
+	}
+	tests := []struct {
+		goos     string
+		vars     map[string]string
+		expected string
+	}{
+		{"linux", nil, filepath.Join("/home/u", ".config", "go", "env")},
+		{"darwin", nil, filepath.Join("/home/u", "Library", "Application Support", "go", "env")},
+		{"windows", map[string]string{"AppData": `C:\Users\u\AppData\Roaming`}, filepath.Join(`C:\Users\u\AppData\Roaming`, "go", "env")},
+		{"linux", map[string]string{"GOENV": "/etc/goenv"}, "/etc/goenv"},
+		{"linux", map[string]string{"GOENV": "off"}, ""},
+	}
+	for _, tt := range tests {
+		if got := goEnvFile(tt.goos, getenv(tt.vars), "/home/u"); got != tt.expected {
+			t.Errorf("goEnvFile(%s, %v) = %s, expected %s", tt.goos, tt.vars, got, tt.expected)
+		}
+	}
+}
+
+func TestGopaths(t *testing.T) {
+	env := goEnv{"GOPATH": {Value: "/a" + string(filepath.ListSeparator) + string(filepath.ListSeparator) + "/b"}}
+	if paths := env.gopaths(); len(paths) != 2 || paths[0] != "/a" || paths[1] != "/b" {
+		t.Errorf("gopaths() = %v, expected [/a /b]", paths)
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Removing GOPATH is opt-in (g on the confirm screen), and it can hold the
+// only copy of someone's work: checkouts under src with uncommitted changes,
+// commits that were never pushed, stashes, or no remote at all. Before it
+// joins the plan fu-go inspects every checkout and lists those prominently;
+// the backup should not be the last line of defense.
+
+const PlanGopath PlanItemKind = "gopath"
+
+// vcsCheckout is a repository under GOPATH/src and the work in it that
+// exists nowhere else.
+type vcsCheckout struct {
+	Path     string
+	VCS      string // "git" or "hg"
+	Dirty    int    // changed or untracked files
+	Unpushed int    // commits on no remote branch
+	Stashes  int
+	NoRemote bool
+	Err      error // the checkout could not be inspected, so assume the worst
+}
+
+func (c vcsCheckout) hasLocalWork() bool {
+	return c.Dirty > 0 || c.Unpushed > 0 || c.Stashes > 0 || c.NoRemote || c.Err != nil
+}
+
+// describe lists what would be lost, e.g. "3 changed files, no remote".
+func (c vcsCheckout) describe() string {
+	if c.Err != nil {
+		return fmt.Sprintf("could not inspect: %v", c.Err)
+	}
+	var parts []string
+	if c.Dirty > 0 {
+		parts = append(parts, fmt.Sprintf("%d changed file(s)", c.Dirty))
+	}
+	if c.Unpushed > 0 {
+		parts = append(parts, fmt.Sprintf("%d unpushed commit(s)", c.Unpushed))
+	}
+	if c.Stashes > 0 {
+		parts = append(parts, fmt.Sprintf("%d stash(es)", c.Stashes))
+	}
+	if c.NoRemote {
+		parts = append(parts, "no remote")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// countLines counts the non-empty lines of a command's output.
+func countLines(output []byte) int {
+	n := 0
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.TrimSpace(line) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+func inspectGitCheckout(path string) vcsCheckout {
+	c := vcsCheckout{Path: path, VCS: "git"}
+	status, err := commandOutput("git", "-C", path, "status", "--porcelain")
+	if err != nil {
+		c.Err = err
+		return c
+	}
+	c.Dirty = countLines(status)
+	if remotes, err := commandOutput("git", "-C", path, "remote"); err == nil {
+		c.NoRemote = countLines(remotes) == 0
+	}
+	// Commits on local branches that no remote-tracking branch contains;
+	// with no remote that is all of them
+	if out, err := commandOutput("git", "-C", path, "rev-list", "--count", "--branches", "--not", "--remotes"); err == nil {
+		c.Unpushed, _ = strconv.Atoi(strings.TrimSpace(string(out)))
+	}
+	if stashes, err := commandOutput("git", "-C", path, "stash", "list"); err == nil {
+		c.Stashes = countLines(stashes)
+	}
+	return c
+}
+
+// inspectHgCheckout cannot count outgoing changesets without contacting the
+// remote, so only the working copy and the configured paths are checked.
+func inspectHgCheckout(path string) vcsCheckout {
+	c := vcsCheckout{Path: path, VCS: "hg"}
+	status, err := commandOutput("hg", "--cwd", path, "status")
+	if err != nil {
+		c.Err = err
+		return c
+	}
+	c.Dirty = countLines(status)
+	if paths, err := commandOutput("hg", "--cwd", path, "paths"); err == nil {
+		c.NoRemote = countLines(paths) == 0
+	}
+	return c
+}
+
+// scanCheckouts finds the repositories under src, not descending into them,
+// and returns those with local work.
+func scanCheckouts(src string) []vcsCheckout {
+	var found []vcsCheckout
+	filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		// .git is a file in worktrees and submodules
+		if _, err := os.Lstat(filepath.Join(path, ".git")); err == nil {
+			if c := inspectGitCheckout(path); c.hasLocalWork() {
+				found = append(found, c)
+			}
+			return filepath.SkipDir
+		}
+		if info, err := os.Stat(filepath.Join(path, ".hg")); err == nil && info.IsDir() {
+			if c := inspectHgCheckout(path); c.hasLocalWork() {
+				found = append(found, c)
+			}
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return found
+}
+
+// gopathScanned carries the inspection of a GOPATH into Update.
+type gopathScanned struct {
+	path      string
+	size      int64
+	files     int64
+	checkouts []vcsCheckout
+}
+
+func scanGopathCmd(gopath string) tea.Cmd {
+	return func() tea.Msg {
+		size, files := dirUsage(gopath)
+		return gopathScanned{path: gopath, size: size, files: files, checkouts: scanCheckouts(filepath.Join(gopath, "src"))}
+	}
+}
+
+func simulatedScanGopathCmd(gopath string) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(simulatedDelay(400*time.Millisecond, 0))
+		return gopathScanned{path: gopath, size: 3 << 30, files: 90000, checkouts: []vcsCheckout{
+			{Path: filepath.Join(gopath, "src", "github.com", "gopher", "side-project"), VCS: "git", Dirty: 4, Unpushed: 2},
+			{Path: filepath.Join(gopath, "src", "scratch"), VCS: "git", NoRemote: true, Unpushed: 11},
+		}}
+	}
+}
+
+// gopathItem removes the whole GOPATH: sources, the module cache and
+// installed binaries.
+type gopathItem struct {
+	path      string
+	size      int64
+	files     int64
+	checkouts []vcsCheckout
+}
+
+func (i gopathItem) Kind() PlanItemKind { return PlanGopath }
+func (i gopathItem) Target() string     { return i.path }
+func (i gopathItem) Size() int64        { return i.size }
+func (i gopathItem) Files() int64       { return i.files }
+func (i gopathItem) Risk() RiskLevel    { return RiskHigh }
+
+func (i gopathItem) Describe() string {
+	if len(i.checkouts) > 0 {
+		return fmt.Sprintf("Remove GOPATH %s (%d checkout(s) with local work)", i.path, len(i.checkouts))
+	}
+	return fmt.Sprintf("Remove GOPATH %s", i.path)
+}
+
+func (i gopathItem) Operations() []string {
+	return []string{"rm -rf " + quoteOperand(i.path)}
+}
+
+func (i gopathItem) Execute(env planEnv) error {
+	if env.quarantined[i.path] {
+		return nil
+	}
+	if err := checkRemovable(i.path); err != nil {
+		return err
+	}
+	// The module cache in pkg/mod is read-only
+	makeTreeWritable(i.path)
+	return removeTree(i.path, env.allowCrossMounts)
+}
+
+// gopathPath is the GOPATH entry g offers to remove: the first, where the go
+// command keeps the module cache and binaries.
+func (m model) gopathPath() string {
+	if m.opts.simulate {
+		return "/home/gopher/go"
+	}
+	if paths := m.goEnv.gopaths(); len(paths) > 0 {
+		return paths[0]
+	}
+	return ""
+}
+
+// gopathRefusal explains why a GOPATH cannot be offered for removal, e.g.
+// GOPATH=$HOME.
+func gopathRefusal(gopath, home string) string {
+	switch {
+	case gopath == "":
+		return "GOPATH is not set"
+	case isCriticalPath(gopath):
+		return "it is a critical system directory"
+	case home != "" && filepath.Clean(gopath) == filepath.Clean(home):
+		return "it is your home directory"
+	}
+	if _, err := os.Stat(gopath); err != nil {
+		return "it does not exist"
+	}
+	return ""
+}
+
+// toggleGopath adds GOPATH to the plan, scanning it first, or takes it out.
+func (m model) toggleGopath() (tea.Model, tea.Cmd) {
+	if m.removeGopath {
+		m.removeGopath = false
+		return m, nil
+	}
+	gopath := m.gopathPath()
+	home, _ := os.UserHomeDir()
+	if reason := gopathRefusal(gopath, home); reason != "" && !m.opts.simulate {
+		m.gopathRefused = fmt.Sprintf("GOPATH %s cannot be removed: %s", gopath, reason)
+		if m.logFile != nil {
+			m.logFile.Log("WARN", m.gopathRefused)
+		}
+		return m, nil
+	}
+	m.removeGopath = true
+	if m.gopathScan != nil && m.gopathScan.path == gopath {
+		return m, nil
+	}
+	m.gopathScan = nil
+	if m.opts.simulate {
+		return m, simulatedScanGopathCmd(gopath)
+	}
+	return m, scanGopathCmd(gopath)
+}
+
+func (m model) handleGopathScanned(msg gopathScanned) model {
+	m.gopathScan = &msg
+	if m.logFile != nil {
+		m.logFile.Log("INFO", fmt.Sprintf("GOPATH %s: %s, %d checkout(s) with local work", msg.path, formatBytes(msg.size), len(msg.checkouts)))
+		for _, c := range msg.checkouts {
+			m.logFile.Log("WARN", fmt.Sprintf("Local work in %s: %s", c.Path, c.describe()))
+		}
+	}
+	return m
+}
+
+// gopathPlanItem is the GOPATH removal, once it is opted into and scanned.
+func (m model) gopathPlanItem() (PlanItem, bool) {
+	if !m.removeGopath || m.gopathScan == nil {
+		return nil, false
+	}
+	scan := m.gopathScan
+	return gopathItem{path: scan.path, size: scan.size, files: scan.files, checkouts: scan.checkouts}, true
+}
+
+// renderGopath shows what removing GOPATH would lose, most prominently the
+// checkouts with work that exists nowhere else.
+func (m model) renderGopath() string {
+	if m.gopathRefused != "" && !m.removeGopath {
+		return warningStyle.Render("🗂️  "+m.gopathRefused) + "\n\n"
+	}
+	if !m.removeGopath {
+		return ""
+	}
+	if m.gopathScan == nil {
+		return infoStyle.Render(fmt.Sprintf("🗂️  Checking %s for local work before it joins the plan...", m.gopathPath())) + "\n\n"
+	}
+	scan := m.gopathScan
+	s := warningStyle.Render(fmt.Sprintf("🗂️  GOPATH %s (%s) will be removed too. Press g to keep it.", scan.path, formatBytes(scan.size))) + "\n"
+	if len(scan.checkouts) == 0 {
+		return s + infoStyle.Render("   No checkout under src has uncommitted, unpushed or stashed work") + "\n\n"
+	}
+	s += warningStyle.Bold(true).Render(fmt.Sprintf("   ⚠️  %d checkout(s) hold work that exists nowhere else:", len(scan.checkouts))) + "\n"
+	for _, c := range scan.checkouts {
+		rel, err := filepath.Rel(scan.path, c.Path)
+		if err != nil {
+			rel = c.Path
+		}
+		s += warningStyle.Render(fmt.Sprintf("      • %s (%s): %s", rel, c.VCS, c.describe())) + "\n"
+	}
+	return s + "\n"
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gitRepo(t *testing.T, dir string, args ...[]string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, arg := range append([][]string{{"init", "-q"}}, args...) {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=gopher", "-c", "user.email=gopher@example.com"}, arg...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", arg, err, out)
+		}
+	}
+}
+
+func TestScanCheckouts(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("HOME", t.TempDir())
+	src := filepath.Join(t.TempDir(), "src")
+
+	// A committed repository whose remote has every commit
+	upstream := filepath.Join(t.TempDir(), "upstream")
+	gitRepo(t, upstream, []string{"commit", "-q", "--allow-empty", "-m", "init"})
+	clean := filepath.Join(src, "github.com", "gopher", "clean")
+	if out, err := exec.Command("git", "clone", "-q", upstream, clean).CombinedOutput(); err != nil {
+		t.Fatalf("git clone: %v\n%s", err, out)
+	}
+
+	// Work that exists nowhere else
+	scratch := filepath.Join(src, "scratch")
+	gitRepo(t, scratch, []string{"commit", "-q", "--allow-empty", "-m", "wip"})
+	// A nested repository belongs to its parent's checkout, and is one
+	// untracked change in it
+	gitRepo(t, filepath.Join(scratch, "nested"))
+
+	found := scanCheckouts(src)
+	if len(found) != 1 || found[0].Path != scratch {
+		t.Fatalf("Expected only the scratch checkout, got %+v", found)
+	}
+	c := found[0]
+	if c.VCS != "git" || c.Dirty != 1 || c.Unpushed != 1 || !c.NoRemote || c.Err != nil {
+		t.Errorf("Unexpected inspection %+v", c)
+	}
+	if got := c.describe(); got != "1 changed file(s), 1 unpushed commit(s), no remote" {
+		t.Errorf("Unexpected description %q", got)
+	}
+}
+
+func TestGopathRefusal(t *testing.T) {
+	home := t.TempDir()
+	gopath := filepath.Join(home, "go")
+	if err := os.Mkdir(gopath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"":                             "GOPATH is not set",
+		home:                           "it is your home directory",
+		"/":                            "it is a critical system directory",
+		filepath.Join(home, "missing"): "it does not exist",
+		gopath:                         "",
+	}
+	for path, want := range tests {
+		if got := gopathRefusal(path, home); got != want {
+			t.Errorf("gopathRefusal(%q) = %q, expected %q", path, got, want)
+		}
+	}
+}
+
+func TestRemoveGopathWithModuleCache(t *testing.T) {
+	skipInAuditBuild(t)
+	gopath := filepath.Join(t.TempDir(), "go")
+	module := filepath.Join(gopath, "pkg", "mod", "example.com", "m@v1.0.0")
+	os.MkdirAll(module, 0755)
+	os.WriteFile(filepath.Join(module, "go.mod"), []byte("module example.com/m\n"), 0444)
+	// As the go command leaves it
+	os.Chmod(module, 0555)
+	defer os.Chmod(module, 0755)
+
+	if err := (gopathItem{path: gopath}).Execute(planEnv{}); err != nil {
+		t.Fatalf("Expected GOPATH to be removed, got %v", err)
+	}
+	if _, err := os.Stat(gopath); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be gone, got %v", gopath, err)
+	}
+}
+
+func TestToggleGopath(t *testing.T) {
+	gopath := t.TempDir()
+	m := model{state: "confirm", goEnv: goEnv{"GOPATH": {Value: gopath}}}
+
+	next, cmd := m.toggleGopath()
+	m = next.(model)
+	if !m.removeGopath || cmd == nil {
+		t.Fatalf("Expected g to start a scan of %s", gopath)
+	}
+	if _, ok := m.gopathPlanItem(); ok {
+		t.Errorf("Expected GOPATH to stay out of the plan until it is scanned")
+	}
+	if !strings.Contains(m.renderGopath(), "Checking "+gopath) {
+		t.Errorf("Expected the scan to be shown, got %q", m.renderGopath())
+	}
+
+	m = m.handleGopathScanned(gopathScanned{path: gopath, size: 1 << 20, checkouts: []vcsCheckout{
+		{Path: filepath.Join(gopath, "src", "scratch"), VCS: "git", Dirty: 2},
+	}})
+	item, ok := m.gopathPlanItem()
+	if !ok || item.Kind() != PlanGopath || item.Target() != gopath {
+		t.Fatalf("Expected GOPATH in the plan, got %v", item)
+	}
+	if !m.needsFullConfirmation() {
+		t.Errorf("Expected removing GOPATH to require full confirmation")
+	}
+	view := m.renderGopath()
+	if !strings.Contains(view, "1 checkout(s) hold work") || !strings.Contains(view, "scratch (git): 2 changed file(s)") {
+		t.Errorf("Expected the checkout with local work to be listed, got %q", view)
+	}
+
+	next, _ = m.toggleGopath()
+	m = next.(model)
+	if _, ok := m.gopathPlanItem(); ok || m.renderGopath() != "" {
+		t.Errorf("Expected g again to keep GOPATH")
+	}
+
+	m = model{goEnv: goEnv{"GOPATH": {Value: filepath.Join(gopath, "missing")}}}
+	next, cmd = m.toggleGopath()
+	m = next.(model)
+	if m.removeGopath || cmd != nil || !strings.Contains(m.renderGopath(), "does not exist") {
+		t.Errorf("Expected a missing GOPATH to be refused, got %q", m.renderGopath())
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+)
+
+// Many machines keep a stable /usr/local/go that is only a symlink to a
+// versioned directory such as /opt/go1.22, switched on every upgrade. A
+// detector that reports the link would size, back up and remove nothing but
+// the link, so fu-go follows it: the link and its target are one
+// installation at the target, with the link recorded in Links. Removing the
+// installation removes the link as well, since it would dangle. With
+// goroot_links "repoint" the link is pointed at the newest installation kept
+// next to the target instead, so whatever uses the stable path still works.
+
+const (
+	goRootLinksRemove  = "remove"
+	goRootLinksRepoint = "repoint"
+)
+
+var repointGoRootLinks atomic.Bool
+
+// setRepointGoRootLinks applies goroot_links "repoint" to the whole run.
+func setRepointGoRootLinks(repoint bool) {
+	repointGoRootLinks.Store(repoint)
+}
+
+// followGoRootLink moves an installation reported at a symlink to the
+// directory the link resolves to. Package managers look after their own
+// links, so their installations are left as reported.
+func followGoRootLink(install GoInstallation) GoInstallation {
+	if install.PackageManager != "" {
+		return install
+	}
+	info, err := os.Lstat(install.Path)
+	if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		return install
+	}
+	target, err := filepath.EvalSymlinks(install.Path)
+	if err != nil {
+		return install
+	}
+	if info, err := os.Stat(target); err != nil || !info.IsDir() {
+		return install
+	}
+	link := filepath.Clean(install.Path)
+	install.Path = target
+	install.Links = append(install.Links, link)
+	install.Size, install.Files = dirUsage(target)
+	install.Evidence = append(install.Evidence, fmt.Sprintf("%s is a symlink to %s", link, target))
+	return install
+}
+
+// mergeLinks adds the links of other not already in links.
+func mergeLinks(links, other []string) []string {
+	for _, link := range other {
+		if !slices.Contains(links, link) {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// displayPath is the path of install as the user knows it: the stable link
+// and where it points, when it was found through one.
+func displayPath(install GoInstallation) string {
+	if len(install.Links) == 0 {
+		return install.Path
+	}
+	return install.Links[0] + " → " + install.Path
+}
+
+// repointTarget is the installation a link to install is pointed at in
+// repoint mode: the newest verified one of kept in the same directory as
+// install, or "" when there is none and the link is removed.
+func repointTarget(install GoInstallation, kept []GoInstallation) string {
+	var best *GoInstallation
+	for i := range kept {
+		candidate := &kept[i]
+		if !candidate.Verified || candidate.Blocked != "" || candidate.User != install.User || filepath.Dir(candidate.Path) != filepath.Dir(install.Path) {
+			continue
+		}
+		if best == nil || compareSemVer(candidate.SemVer, best.SemVer) > 0 {
+			best = candidate
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.Path
+}
+
+// goRootLinkItems are the items for the links to an installation being
+// removed: each is removed, or in repoint mode pointed at the newest kept
+// installation next to it. The items name the installation as their root, so
+// they are skipped if it is not removed.
+func goRootLinkItems(install GoInstallation, kept []GoInstallation) []PlanItem {
+	var repoint string
+	if repointGoRootLinks.Load() {
+		repoint = repointTarget(install, kept)
+	}
+	var items []PlanItem
+	for _, link := range install.Links {
+		dest, err := os.Readlink(link)
+		if err != nil {
+			continue
+		}
+		items = append(items, symlinkItem{link: link, dest: dest, root: install.Path, repoint: repoint})
+	}
+	return items
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func withRepointGoRootLinks(t *testing.T, repoint bool) {
+	t.Helper()
+	previous := repointGoRootLinks.Load()
+	setRepointGoRootLinks(repoint)
+	t.Cleanup(func() { setRepointGoRootLinks(previous) })
+}
+
+// versionedGoRoots lays out home/opt/go1.22 with home/usr-local-go linked to
+// it, and home/bin/go linked through the stable link.
+func versionedGoRoots(t *testing.T) (home, target, link string) {
+	t.Helper()
+	home = t.TempDir()
+	target = filepath.Join(home, "opt", "go1.22")
+	os.MkdirAll(filepath.Join(target, "bin"), 0755)
+	os.WriteFile(filepath.Join(target, "VERSION"), []byte("go1.22.5\n"), 0644)
+	os.WriteFile(filepath.Join(target, "bin", "go"), []byte("#!/bin/sh\n"), 0755)
+	link = filepath.Join(home, "usr-local-go")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	os.MkdirAll(filepath.Join(home, "bin"), 0755)
+	os.Symlink(filepath.Join(link, "bin", "go"), filepath.Join(home, "bin", "go"))
+	return home, target, link
+}
+
+func TestLinkAndTargetAreOneInstallation(t *testing.T) {
+	_, target, link := versionedGoRoots(t)
+	results := []detectorResult{
+		{name: "official", installations: []GoInstallation{newInstallation(link, "official")}},
+		{name: "custom", installations: []GoInstallation{newInstallation(target, "custom")}},
+	}
+	installations := mergeDetectorResults(results)
+	if len(installations) != 1 {
+		t.Fatalf("Expected the link and its target to merge, got %+v", installations)
+	}
+	install := installations[0]
+	if install.Path != target || len(install.Links) != 1 || install.Links[0] != link {
+		t.Errorf("Expected %s reached through %s, got %s %v", target, link, install.Path, install.Links)
+	}
+	if size, _ := dirUsage(target); install.Size != size || size == 0 {
+		t.Errorf("Expected the target to be sized, got %d of %d", install.Size, size)
+	}
+	if got := displayPath(install); got != link+" → "+target {
+		t.Errorf("Unexpected display path %q", got)
+	}
+}
+
+func TestRemovingLinkedInstallationRemovesLink(t *testing.T) {
+	skipInAuditBuild(t)
+	home, target, link := versionedGoRoots(t)
+	install := GoInstallation{Path: target, Links: []string{link}}
+	items := buildPlan([]GoInstallation{install}, nil, home)
+	if len(items) != 3 {
+		t.Fatalf("Expected the root, its link and the link in ~/bin, got %v", describeItems(items))
+	}
+	for _, item := range items[1:] {
+		if link, ok := item.(symlinkItem); !ok || link.root != target {
+			t.Errorf("Expected %v to depend on %s being removed", item.Describe(), target)
+		}
+	}
+	for _, result := range executePlan(items, planEnv{}) {
+		if !result.ok() {
+			t.Errorf("%s: %v %s", result.item.Describe(), result.err, result.skipped)
+		}
+	}
+	for _, path := range []string{target, link, filepath.Join(home, "bin", "go")} {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", path, err)
+		}
+	}
+}
+
+func TestRepointGoRootLink(t *testing.T) {
+	skipInAuditBuild(t)
+	home, target, link := versionedGoRoots(t)
+	newer := filepath.Join(home, "opt", "go1.23")
+	os.MkdirAll(filepath.Join(newer, "bin"), 0755)
+	os.WriteFile(filepath.Join(newer, "bin", "go"), []byte("#!/bin/sh\n"), 0755)
+	kept := []GoInstallation{
+		{Path: newer, SemVer: "1.23.0", Verified: true},
+		{Path: filepath.Join(home, "sdk", "go1.24.0"), SemVer: "1.24.0", Verified: true}, // not next to it
+	}
+	withRepointGoRootLinks(t, true)
+
+	items := buildPlan([]GoInstallation{{Path: target, Links: []string{link}}}, kept, home)
+	if len(items) != 2 {
+		t.Fatalf("Expected the root and its link only, got %v", describeItems(items))
+	}
+	if ops := items[1].Operations(); len(ops) != 1 || !strings.HasPrefix(ops[0], "ln -sfn ") {
+		t.Errorf("Expected the link to be repointed, got %v", ops)
+	}
+	for _, result := range executePlan(items, planEnv{}) {
+		if !result.ok() {
+			t.Errorf("%s: %v %s", result.item.Describe(), result.err, result.skipped)
+		}
+	}
+	if dest, _ := os.Readlink(link); dest != newer {
+		t.Errorf("Expected %s to point at %s, got %q", link, newer, dest)
+	}
+	if _, err := os.Stat(filepath.Join(home, "bin", "go")); err != nil {
+		t.Errorf("Expected ~/bin/go to work through the repointed link: %v", err)
+	}
+
+	// Nothing kept next to it: the link goes
+	if items := buildPlan([]GoInstallation{{Path: newer, Links: []string{link}}}, kept[1:], home); len(items) < 2 || items[1].Operations()[0] != "rm "+quoteOperand(link) {
+		t.Errorf("Expected the link to be removed, got %v", describeItems(items))
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"debug/buildinfo"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A bin/go found on disk is not necessarily a Go toolchain: on a shared
+// machine anyone who can write to a scanned directory can put a program
+// there. fu-go therefore reads versions instead of asking binaries for them,
+// from the build info the linker embeds or the VERSION file next to bin.
+// Running a binary is the last resort, only for one owned by root or the
+// current user, and with a timeout, an empty environment and capped output.
+
+const (
+	goVersionExecTimeout = 5 * time.Second
+	goVersionOutputLimit = 4096
+)
+
+// binaryGoVersion reads the Go version a binary was built with without
+// running it. A toolchain's go command is built by itself, so for bin/go this
+// is the toolchain's own version.
+func binaryGoVersion(exe string) (string, error) {
+	info, err := buildinfo.ReadFile(exe)
+	if err != nil {
+		return "", err
+	}
+	var goos, goarch string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "GOOS":
+			goos = setting.Value
+		case "GOARCH":
+			goarch = setting.Value
+		}
+	}
+	if goos != "" && goarch != "" {
+		return fmt.Sprintf("go version %s %s/%s", info.GoVersion, goos, goarch), nil
+	}
+	return "go version " + info.GoVersion, nil
+}
+
+// trustedBinary refuses binaries another user could have planted or
+// modified. Where ownership is unknown only the permission bits are checked.
+func trustedBinary(exe string) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf("%s is writable by other users", exe)
+	}
+	if owner, ok := fileOwnerID(info); ok && owner != "0" && owner != strconv.Itoa(os.Getuid()) {
+		return fmt.Errorf("%s is owned by uid %s", exe, owner)
+	}
+	return nil
+}
+
+// cappedBuffer keeps the first limit bytes written to it and drops the rest.
+type cappedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// sandboxedGoVersion runs exe version as a last resort. GOTOOLCHAIN=local
+// stops the go command from downloading and switching to another toolchain.
+func sandboxedGoVersion(exe string) (string, error) {
+	if err := trustedBinary(exe); err != nil {
+		return "", fmt.Errorf("not running %s: %v", exe, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), goVersionExecTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, exe, "version")
+	cmd.Env = []string{"GOTOOLCHAIN=local", "GOENV=off", "GOFLAGS="}
+	cmd.Dir = os.TempDir()
+	cmd.WaitDelay = commandWaitDelay
+	out := &cappedBuffer{limit: goVersionOutputLimit}
+	cmd.Stdout = out
+	start := time.Now()
+	err := cmd.Run()
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		err = &commandTimeoutError{name: exe, args: []string{"version"}, timeout: goVersionExecTimeout}
+	}
+	traceCommand(exe, []string{"version"}, time.Since(start), err)
+	if err != nil {
+		return "", err
+	}
+	version, _, _ := strings.Cut(out.String(), "\n")
+	version = strings.TrimSpace(version)
+	if !strings.HasPrefix(version, "go version ") {
+		return "", fmt.Errorf("%s printed %q, not a Go version", exe, version)
+	}
+	return version, nil
+}
+
+// pathGoVersion is the version of the go command on PATH, read the same way
+// as any other installation's.
+func pathGoVersion() (string, error) {
+	exe, err := exec.LookPath("go")
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	bin := filepath.Dir(exe)
+	if filepath.Base(bin) == "bin" {
+		if version, _, err := goVersionWithEvidence(filepath.Dir(bin)); err == nil {
+			return version, nil
+		}
+	}
+	return binaryGoVersion(exe)
+}
+
+func goExecutable(goPath string) string {
+	return filepath.Join(goPath, "bin", "go"+currentPlatform.exeSuffix())
+}
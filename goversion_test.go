@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBinaryGoVersion(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skipf("no executable: %v", err)
+	}
+	version, err := binaryGoVersion(exe)
+	if err != nil {
+		t.Fatalf("binaryGoVersion returned error: %v", err)
+	}
+	if !strings.HasPrefix(version, "go version "+runtime.Version()) {
+		t.Errorf("Expected the test binary's own Go version, got %q", version)
+	}
+	if _, err := binaryGoVersion(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("Expected an error for a missing binary")
+	}
+}
+
+// hostileGoRoot has a bin/go that leaves a marker behind if it is ever run.
+func hostileGoRoot(t *testing.T, script string) (root, marker string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts only")
+	}
+	root = fakeGoRoot(t)
+	marker = filepath.Join(t.TempDir(), "ran")
+	os.MkdirAll(filepath.Join(root, "bin"), 0755)
+	os.WriteFile(filepath.Join(root, "bin", "go"), []byte("#!/bin/sh\ntouch "+marker+"\n"+script), 0755)
+	return root, marker
+}
+
+func TestGoVersionDoesNotRunBinaries(t *testing.T) {
+	root, marker := hostileGoRoot(t, "echo go version go1.99.0\n")
+	os.WriteFile(filepath.Join(root, "VERSION"), []byte("go1.22.5\ntime 2024-06-04T20:06:34Z\n"), 0644)
+
+	version, evidence, err := goVersionWithEvidence(root)
+	if err != nil || version != "go version go1.22.5" {
+		t.Errorf("Expected the VERSION file to be read, got %q (%v)", version, err)
+	}
+	if !strings.Contains(evidence, "VERSION") {
+		t.Errorf("Expected the evidence to name the VERSION file, got %q", evidence)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Errorf("Expected bin/go not to be executed")
+	}
+}
+
+func TestSandboxedGoVersion(t *testing.T) {
+	root, marker := hostileGoRoot(t, "echo go version go1.99.0 $GOTOOLCHAIN $HOME\n")
+	exe := filepath.Join(root, "bin", "go")
+
+	version, _, err := goVersionWithEvidence(root)
+	if err != nil {
+		t.Fatalf("goVersionWithEvidence returned error: %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("Expected bin/go to be run as a last resort")
+	}
+	if version != "go version go1.99.0 local" {
+		t.Errorf("Expected an empty environment with GOTOOLCHAIN=local, got %q", version)
+	}
+
+	os.Chmod(exe, 0777)
+	if _, err := sandboxedGoVersion(exe); err == nil || !strings.Contains(err.Error(), "writable by other users") {
+		t.Errorf("Expected a world-writable binary to be refused, got %v", err)
+	}
+
+	os.WriteFile(exe, []byte("#!/bin/sh\necho hello\n"), 0755)
+	if _, err := sandboxedGoVersion(exe); err == nil {
+		t.Errorf("Expected output that is not a Go version to be rejected")
+	}
+}
+
+func TestCappedBuffer(t *testing.T) {
+	buf := &cappedBuffer{limit: 4}
+	if n, err := buf.Write([]byte("go version")); n != 10 || err != nil {
+		t.Errorf("Expected the write to appear complete, got %d, %v", n, err)
+	}
+	buf.Write([]byte("more"))
+	if buf.String() != "go v" {
+		t.Errorf("Expected output capped at 4 bytes, got %q", buf.String())
+	}
+}
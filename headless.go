@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+// The headless commands (clean-cache, container-prune, ci-clean and apply) end with a
+// one-line JSON summary so configuration management can register whether
+// anything changed. With --check they change nothing and exit with
+// exitChangesPending when a real run would have, like Ansible's check mode.
+//
+// With --porcelain every line they print is instead a key=value record that
+// starts with event=, for orchestration tools that scrape stdout over SSH.
+// Keys and event names are stable; new keys may be added at the end.
+
+const exitChangesPending = 2
+
+// exitCodeError ends fu-go with code and no error message.
+type exitCodeError struct {
+	code int
+}
+
+func (e exitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// changeReport collects what a headless run changed, or would change.
+type changeReport struct {
+	Changed    bool     `json:"changed"`
+	Check      bool     `json:"check"`
+	DryRun     bool     `json:"dry_run"`
+	Changes    []string `json:"changes"`
+	FreedBytes int64    `json:"freed_bytes"`
+
+	skipped []string // what was left alone and why, for the CI job summary
+}
+
+func newChangeReport(check, dryRun bool) *changeReport {
+	return &changeReport{Check: check, DryRun: check || dryRun, Changes: []string{}}
+}
+
+func (r *changeReport) add(change string, freed int64) {
+	r.Changed = true
+	r.Changes = append(r.Changes, change)
+	r.FreedBytes += freed
+}
+
+func (r *changeReport) skip(what, reason string) {
+	r.skipped = append(r.skipped, what+": "+reason)
+}
+
+// finish prints the summary and, in check mode, turns pending changes into
+// the exit code.
+func (r *changeReport) finish(out eventWriter) error {
+	if out.porcelain {
+		out.emit("result", "", "changed", r.Changed, "check", r.Check, "dry_run", r.DryRun, "changes", len(r.Changes), "freed_bytes", r.FreedBytes)
+	} else {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("failed to encode result: %v", err)
+		}
+		fmt.Fprintln(out.w, string(data))
+	}
+	if r.Check && r.Changed {
+		return exitCodeError{code: exitChangesPending}
+	}
+	return nil
+}
+
+func addCheckFlag(cmd *cobra.Command, check *bool) {
+	cmd.Flags().BoolVar(check, "check", false, fmt.Sprintf("change nothing; exit %d if a real run would change something", exitChangesPending))
+}
+
+// eventWriter prints what a headless command does, as sentences or, with
+// --porcelain, as records. Steps that run items are also published on events
+// and, with --progress=json, written as JSON to progress; quiet drops the
+// sentences while that stream is on.
+type eventWriter struct {
+	w         io.Writer
+	porcelain bool
+	quiet     bool
+	events    *eventBus
+	progress  io.Writer
+}
+
+func newEventWriter(w io.Writer, opts runOptions) eventWriter {
+	return eventWriter{w: w, porcelain: opts.porcelain}
+}
+
+// emit writes one event: human for people, or event followed by the
+// alternating keys and values in fields for --porcelain.
+func (e eventWriter) emit(event, human string, fields ...any) {
+	if e.quiet {
+		return
+	}
+	if !e.porcelain {
+		if human != "" {
+			fmt.Fprintln(e.w, human)
+		}
+		return
+	}
+	var b strings.Builder
+	b.WriteString("event=" + porcelainValue(event))
+	for i := 0; i+1 < len(fields); i += 2 {
+		fmt.Fprintf(&b, " %s=%s", fields[i], porcelainValue(fmt.Sprint(fields[i+1])))
+	}
+	fmt.Fprintln(e.w, b.String())
+}
+
+// porcelainValue quotes a value Go-style when it is empty or would otherwise
+// break the record apart.
+func porcelainValue(value string) string {
+	if value == "" || strings.ContainsFunc(value, func(r rune) bool {
+		return r == '"' || r == '=' || r == '\\' || unicode.IsSpace(r) || !unicode.IsPrint(r)
+	}) {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestChangeReportFinish(t *testing.T) {
+	var out strings.Builder
+	report := newChangeReport(false, false)
+	if err := report.finish(eventWriter{w: &out}); err != nil {
+		t.Fatalf("finish returned error: %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != `{"changed":false,"check":false,"dry_run":false,"changes":[],"freed_bytes":0}` {
+		t.Errorf("Unexpected summary for no changes: %s", got)
+	}
+
+	out.Reset()
+	report = newChangeReport(true, false)
+	report.add("remove /usr/local/go", 1024)
+	err := report.finish(eventWriter{w: &out})
+	var exit exitCodeError
+	if !errors.As(err, &exit) || exit.code != exitChangesPending {
+		t.Errorf("Expected check mode with changes to exit %d, got %v", exitChangesPending, err)
+	}
+	var decoded changeReport
+	if err := json.Unmarshal([]byte(out.String()), &decoded); err != nil {
+		t.Fatalf("Summary is not JSON: %v", err)
+	}
+	if !decoded.Changed || !decoded.DryRun || decoded.FreedBytes != 1024 || len(decoded.Changes) != 1 {
+		t.Errorf("Unexpected summary: %+v", decoded)
+	}
+
+	report = newChangeReport(false, false)
+	report.add("remove /usr/local/go", 0)
+	if err := report.finish(eventWriter{w: &out}); err != nil {
+		t.Errorf("Expected a real run to exit 0 after changes, got %v", err)
+	}
+}
+
+func TestPorcelainEvents(t *testing.T) {
+	var out strings.Builder
+	events := eventWriter{w: &out, porcelain: true}
+	events.emit("remove", "Removed /opt/go 1.21", "path", "/opt/go 1.21", "bytes", 42, "dry_run", false)
+	events.emit("skip", "", "path", "/opt/a=b", "reason", "")
+	report := newChangeReport(false, false)
+	report.add("remove /opt/go 1.21", 42)
+	report.finish(events)
+
+	want := `event=remove path="/opt/go 1.21" bytes=42 dry_run=false
+event=skip path="/opt/a=b" reason=""
+event=result changed=true check=false dry_run=false changes=1 freed_bytes=42
+`
+	if out.String() != want {
+		t.Errorf("Unexpected porcelain output:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	eventWriter{w: &out}.emit("remove", "Removed /opt/go", "path", "/opt/go")
+	if out.String() != "Removed /opt/go\n" {
+		t.Errorf("Expected the sentence without --porcelain, got %q", out.String())
+	}
+}
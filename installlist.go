@@ -0,0 +1,412 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// item is one detected installation in the confirm screen's table.
+type item struct {
+	install  GoInstallation
+	selected bool
+}
+
+// check is the selection box, with a marker for installations that cannot
+// be removed or could not be verified.
+func (i item) check() string {
+	check := "[ ]"
+	if i.selected {
+		check = "[x]"
+	}
+	if i.install.Blocked != "" {
+		return check + " 🚫"
+	}
+	if !i.install.Verified {
+		return check + " ❓"
+	}
+	return check
+}
+
+func (i item) version() string {
+	if i.install.SemVer != "" {
+		return i.install.SemVer
+	}
+	return i.install.Version
+}
+
+func (i item) FilterValue() string {
+	return i.install.Version + " " + sourceLabel(i.install) + " " + displayPath(i.install)
+}
+
+// installColumn is one column of the inventory. Columns with a sort key are
+// marked in the header while tab has them selected.
+type installColumn struct {
+	title   string
+	width   int // 0 for the path, which takes the remaining width
+	sortKey int // -1 if the column has none
+	value   func(item) string
+}
+
+var installColumns = []installColumn{
+	{"", 6, -1, item.check},
+	{"Version", 12, SortByVersion, item.version},
+	{"Source", 14, SortBySource, func(i item) string { return sourceLabel(i.install) }},
+	{"Path", 0, SortByPath, func(i item) string { return displayPath(i.install) }},
+	{"Size", 9, SortBySize, func(i item) string { return formatBytes(i.install.Size) }},
+	{"Permissions", 11, -1, func(i item) string { return i.install.Permissions }},
+	{"Risk", 10, SortByRisk, func(i item) string { return installRisk(i.install).badge() }},
+	{"Active", 6, -1, func(i item) string {
+		if i.install.OnPath {
+			return "⭐"
+		}
+		return ""
+	}},
+}
+
+const (
+	// minPathWidth is as narrow as the path column gets before the table
+	// scrolls horizontally instead.
+	minPathWidth = 20
+	// maxInstallRows keeps the confirmation prompt on screen.
+	maxInstallRows = 10
+	// cellPadding is the table's padding around every cell.
+	cellPadding = 2
+)
+
+// installTable is the inventory on the confirm screen: a table with a
+// filter, and horizontal scrolling when the terminal is too narrow for every
+// column. The first column, the selection, never scrolls away.
+type installTable struct {
+	table     table.Model
+	items     []item
+	visible   []int // indexes into items that match the filter, in order
+	filter    string
+	filtering bool
+	offset    int // scrollable columns hidden on the left
+	width     int
+	sortBy    int
+}
+
+func newInstallList() installTable {
+	t := installTable{width: 80, sortBy: -1}
+	t.table = table.New(table.WithFocused(true))
+	styles := table.DefaultStyles()
+	styles.Selected = styles.Selected.Reverse(true)
+	t.table.SetStyles(styles)
+	return t
+}
+
+func (t installTable) Items() []item { return t.items }
+
+// SetItems replaces the rows, keeping the cursor on the same installation
+// when it is still there.
+func (t *installTable) SetItems(items []item) {
+	current, hadCurrent := t.SelectedItem()
+	t.items = items
+	t.refresh()
+	if hadCurrent {
+		for idx, it := range t.items {
+			if it.install.Path == current.install.Path {
+				t.Select(idx)
+			}
+		}
+	}
+}
+
+func (t *installTable) SetItem(idx int, it item) {
+	t.items[idx] = it
+	t.refresh()
+}
+
+// SelectedItem is the installation under the cursor.
+func (t installTable) SelectedItem() (item, bool) {
+	cursor := t.table.Cursor()
+	if cursor < 0 || cursor >= len(t.visible) {
+		return item{}, false
+	}
+	return t.items[t.visible[cursor]], true
+}
+
+// Select moves the cursor to items[idx], if the filter shows it.
+func (t *installTable) Select(idx int) {
+	for row, visible := range t.visible {
+		if visible == idx {
+			t.table.SetCursor(row)
+		}
+	}
+}
+
+func (t *installTable) SetWidth(width int) {
+	t.width = width
+	t.refresh()
+}
+
+// SetSort marks the column sorted by key.
+func (t *installTable) SetSort(key int) {
+	t.sortBy = key
+	t.refresh()
+}
+
+// SettingFilter reports whether keys are going to the filter.
+func (t installTable) SettingFilter() bool { return t.filtering }
+
+// layout returns the columns to draw: those scrolled off the left and those
+// that do not fit on the right get no width, and the table skips them.
+// more reports whether any are hidden on the right.
+func (t installTable) layout() (columns []table.Column, more bool) {
+	longest := minPathWidth
+	for _, it := range t.items {
+		longest = max(longest, len(displayPath(it.install)))
+	}
+
+	used := 0
+	for i, col := range installColumns {
+		width := col.width
+		if width == 0 {
+			// The path shares the room with the columns after it, unless
+			// it was scrolled to for a full view
+			room := t.width - used - cellPadding
+			if i != t.offset+1 {
+				for _, after := range installColumns[i+1:] {
+					room -= after.width + cellPadding
+				}
+			}
+			width = min(longest, max(room, minPathWidth))
+		}
+		title := col.title
+		if col.sortKey >= 0 && col.sortKey == t.sortBy {
+			title += " ▼"
+		}
+		hidden := i > 0 && i <= t.offset
+		if !hidden && i > 0 && used+width+cellPadding > t.width {
+			hidden, more = true, true
+		}
+		if hidden {
+			width = 0
+		} else {
+			used += width + cellPadding
+		}
+		columns = append(columns, table.Column{Title: title, Width: width})
+	}
+	return columns, more
+}
+
+// refresh applies the filter and layout to the table.
+func (t *installTable) refresh() {
+	if t.table.Columns() == nil && len(t.items) == 0 && t.width == 0 {
+		return
+	}
+	filter := strings.ToLower(t.filter)
+	t.visible = t.visible[:0]
+	rows := make([]table.Row, 0, len(t.items))
+	for idx, it := range t.items {
+		if filter != "" && !strings.Contains(strings.ToLower(it.FilterValue()), filter) {
+			continue
+		}
+		t.visible = append(t.visible, idx)
+		row := make(table.Row, len(installColumns))
+		for i, col := range installColumns {
+			row[i] = col.value(it)
+		}
+		rows = append(rows, row)
+	}
+	columns, _ := t.layout()
+	// Columns first: the table renders the rows against them
+	t.table.SetRows(nil)
+	t.table.SetColumns(columns)
+	t.table.SetRows(rows)
+	t.table.SetHeight(min(max(len(rows), 1), maxInstallRows) + 1)
+	if t.table.Cursor() >= len(rows) {
+		t.table.SetCursor(max(len(rows)-1, 0))
+	}
+}
+
+// Update handles the keys the confirm screen passes to the inventory:
+// movement, horizontal scrolling and the filter.
+func (t installTable) Update(msg tea.KeyMsg) (installTable, tea.Cmd) {
+	if t.filtering {
+		switch msg.Type {
+		case tea.KeyEnter:
+			t.filtering = false
+		case tea.KeyEsc:
+			t.filtering, t.filter = false, ""
+		case tea.KeyBackspace:
+			if len(t.filter) > 0 {
+				t.filter = t.filter[:len(t.filter)-1]
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			t.filter += string(msg.Runes)
+		}
+		t.refresh()
+		return t, nil
+	}
+	switch msg.String() {
+	case "up":
+		t.table.MoveUp(1)
+	case "down":
+		t.table.MoveDown(1)
+	case "pgup":
+		t.table.MoveUp(maxInstallRows)
+	case "pgdown":
+		t.table.MoveDown(maxInstallRows)
+	case "<":
+		if t.offset > 0 {
+			t.offset--
+			t.refresh()
+		}
+	case ">":
+		if _, more := t.layout(); more {
+			t.offset++
+			t.refresh()
+		}
+	case "/":
+		t.filtering = true
+	case "esc":
+		t.filter = ""
+		t.refresh()
+	}
+	return t, nil
+}
+
+func (t installTable) View() string {
+	s := highlightStyle.Render("Go Installations to Remove") + "\n"
+	if len(t.visible) == 0 {
+		s += infoStyle.Render("   No installations match the filter") + "\n"
+	} else {
+		s += t.table.View() + "\n"
+	}
+	var status []string
+	if t.filtering || t.filter != "" {
+		status = append(status, fmt.Sprintf("filter: %s", t.filter))
+	}
+	status = append(status, fmt.Sprintf("%d of %d installations", len(t.visible), len(t.items)))
+	if _, more := t.layout(); more || t.offset > 0 {
+		status = append(status, "< > to scroll columns")
+	}
+	return s + infoStyle.Render("   "+strings.Join(status, " · "))
+}
+
+// setInstallItems fills the table from detectedInstalls in their current
+// order.
+func (m *model) setInstallItems() {
+	items := make([]item, len(m.detectedInstalls))
+	for idx, install := range m.detectedInstalls {
+		items[idx] = item{install: install, selected: m.isSelected(install)}
+	}
+	m.inventory.sortBy = m.sortBy
+	m.inventory.SetItems(items)
+}
+
+// toggleSelected flips the selection of the highlighted installation.
+func (m *model) toggleSelected() {
+	current, ok := m.inventory.SelectedItem()
+	if !ok {
+		return
+	}
+	if m.selection == nil {
+		m.selection = make(map[string]bool)
+	}
+	current.selected = !current.selected
+	m.selection[current.install.Path] = current.selected
+	for idx, it := range m.inventory.Items() {
+		if it.install.Path == current.install.Path {
+			m.inventory.SetItem(idx, current)
+			if m.logFile != nil {
+				m.logFile.Log("INFO", fmt.Sprintf("Selected %s: %v", current.install.Path, current.selected))
+			}
+			return
+		}
+	}
+}
+
+// selectedInstalls returns the installations the user kept selected, in
+// display order. Verified installations start selected, unverified ones
+// and other users' only count once the user opts them in.
+func (m model) selectedInstalls() []GoInstallation {
+	var installs []GoInstallation
+	for _, install := range m.detectedInstalls {
+		if m.isSelected(install) {
+			installs = append(installs, install)
+		}
+	}
+	return installs
+}
+
+func (m model) isSelected(install GoInstallation) bool {
+	if selected, ok := m.selection[install.Path]; ok {
+		return selected
+	}
+	return install.Verified && install.User == ""
+}
+
+// renderInstallDetails shows everything known about the highlighted
+// installation below the list.
+func renderInstallDetails(install GoInstallation) string {
+	var s string
+	s += fmt.Sprintf("  %s %s\n",
+		lipgloss.NewStyle().Foreground(lipgloss.Color("#FFCB6B")).Render("📦"),
+		install.Version)
+	if label := supportLabel(install); label != "" {
+		s += fmt.Sprintf("     %s\n", label)
+	}
+	s += fmt.Sprintf("     📍 Path: %s\n", install.Path)
+	for _, link := range install.Links {
+		fate := "removed with it"
+		if repointGoRootLinks.Load() {
+			fate = "repointed at the newest Go kept next to it, if there is one"
+		}
+		s += fmt.Sprintf("     🔗 Reached through the symlink %s, which is %s\n", link, fate)
+	}
+	s += fmt.Sprintf("     🔧 Source: %s | 💾 Size: %s\n", sourceLabel(install), formatBytes(install.Size))
+	s += fmt.Sprintf("     🖥️  Platform: %s | 📅 Installed: %s%s\n", installPlatform(install), install.InstallDate.Format("2006-01-02"), onPathLabel(install))
+	s += fmt.Sprintf("     🔐 Permissions: %s\n", install.Permissions)
+	if len(install.Owners) > 0 {
+		s += fmt.Sprintf("     👤 Owners: %s\n", formatOwners(install.Owners))
+	}
+	if install.Blocked != "" {
+		s += warningStyle.Render(fmt.Sprintf("     🚫 Cannot remove: %s", install.Blocked)) + "\n"
+	}
+	if install.EOL != "" {
+		s += fmt.Sprintf("     ⚰️  End of life since %s: no more security fixes\n", install.EOL)
+	}
+	for _, id := range install.CVEs {
+		s += warningStyle.Render(fmt.Sprintf("     🐞 %s: %s", id, cveSummary(id))) + "\n"
+	}
+	s += renderAdvisories(install.Advisories)
+	if install.DuplicateOf != "" {
+		s += fmt.Sprintf("     🪞 Byte-identical copy of %s: removing it frees %s and loses nothing\n", install.DuplicateOf, formatBytes(install.Size))
+	}
+	if !install.Verified {
+		s += warningStyle.Render(fmt.Sprintf("     ❓ Needs review: %s confidence, not removed unless selected", install.Confidence)) + "\n"
+	}
+	if install.Detector != "" {
+		s += fmt.Sprintf("     🔎 Why: found by the %s detector\n", install.Detector)
+	}
+	for _, evidence := range install.Evidence {
+		s += infoStyle.Render("        • "+evidence) + "\n"
+	}
+	return s
+}
+
+// renderNeedsReview lists the unverified candidates apart from the plan so
+// a directory that merely looks like Go is never removed by accident.
+func (m model) renderNeedsReview() string {
+	review := needsReview(m.detectedInstalls)
+	if len(review) == 0 {
+		return ""
+	}
+	s := warningStyle.Render(fmt.Sprintf("❓ Needs review: %d candidate(s) could not be verified as Go", len(review))) + "\n"
+	for _, install := range review {
+		status := "not in the plan"
+		if m.isSelected(install) {
+			status = "opted in"
+		}
+		s += infoStyle.Render(fmt.Sprintf("   • %s (%s confidence, %s)", install.Path, install.Confidence, status)) + "\n"
+	}
+	s += infoStyle.Render("   Highlight one and press space to include it") + "\n\n"
+	return s
+}
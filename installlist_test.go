@@ -0,0 +1,122 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newListTestModel() model {
+	m := model{
+		state:     "confirm",
+		inventory: newInstallList(),
+		detectedInstalls: []GoInstallation{
+			{Path: "/usr/local/go", Version: "go version go1.22.5 linux/amd64", SemVer: "1.22.5", Source: "official", Size: 300, Verified: true},
+			{Path: "/root/.gvm/gos/go1.21", Version: "go version go1.21.0 linux/amd64", SemVer: "1.21.0", Source: "gvm", Size: 100, Verified: true},
+		},
+	}
+	m.setInstallItems()
+	return m
+}
+
+func TestInstallListStartsFullySelected(t *testing.T) {
+	m := newListTestModel()
+	if len(m.inventory.Items()) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(m.inventory.Items()))
+	}
+	if len(m.selectedInstalls()) != 2 {
+		t.Errorf("Expected every installation to start selected, got %v", m.selectedInstalls())
+	}
+	if first := m.inventory.Items()[0]; first.check() != "[x]" || first.version() != "1.22.5" {
+		t.Errorf("Unexpected first row %q %q", first.check(), first.version())
+	}
+}
+
+func TestSpaceTogglesSelection(t *testing.T) {
+	m := newListTestModel()
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	result := updated.(model)
+
+	selected := result.selectedInstalls()
+	if len(selected) != 1 || selected[0].Path != "/usr/local/go" {
+		t.Errorf("Expected only /usr/local/go selected, got %v", selected)
+	}
+	if check := result.inventory.Items()[1].check(); check != "[ ]" {
+		t.Errorf("Expected a deselected row, got %q", check)
+	}
+	if result.textInput.Value() != "" {
+		t.Errorf("Expected list keys not to reach the text input, got %q", result.textInput.Value())
+	}
+
+	// Re-sorting keeps the selection
+	updated, _ = result.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if len(updated.(model).selectedInstalls()) != 1 {
+		t.Errorf("Expected selection to survive re-sorting")
+	}
+}
+
+func TestBuildReportKeepsDeselected(t *testing.T) {
+	m := newListTestModel()
+	m.dryRun = true
+	m.selection = map[string]bool{"/usr/local/go": false}
+
+	report := buildReport(m)
+	if report.TotalBytes != 100 {
+		t.Errorf("Expected 100 reclaimable bytes, got %d", report.TotalBytes)
+	}
+	if len(report.Actions) != 2 || !strings.HasPrefix(report.Actions[1], "Kept /usr/local/go") {
+		t.Errorf("Unexpected actions: %v", report.Actions)
+	}
+}
+
+func TestInstallTableScrollsColumns(t *testing.T) {
+	m := newListTestModel()
+	m.inventory.SetWidth(200)
+	if columns, more := m.inventory.layout(); more || columns[3].Width != len("/root/.gvm/gos/go1.21") {
+		t.Errorf("Expected every column and the full path on a wide terminal, got %+v", columns)
+	}
+
+	m.inventory.SetWidth(60)
+	view := m.inventory.View()
+	if !strings.Contains(view, "< > to scroll columns") || strings.Contains(view, "Permissions") {
+		t.Errorf("Expected a narrow terminal to hide columns on the right, got:\n%s", view)
+	}
+	for _, r := range "><>>" {
+		m.inventory, _ = m.inventory.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	view = m.inventory.View()
+	if strings.Contains(view, "Version") || !strings.Contains(view, "/root/.gvm/gos/go1.21 ") || !strings.Contains(view, "[x]") {
+		t.Errorf("Expected the path in full after scrolling, with the selection kept, got:\n%s", view)
+	}
+}
+
+func TestInstallTableFilterAndSort(t *testing.T) {
+	m := newListTestModel()
+	m.inventory.SetWidth(200)
+	if !strings.Contains(m.inventory.View(), "Source ▼") {
+		t.Errorf("Expected the sorted column to be marked")
+	}
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = updated.(model)
+	if view := m.inventory.View(); !strings.Contains(view, "Version ▼") || strings.Contains(view, "Source ▼") {
+		t.Errorf("Expected tab to move the sort marker, got:\n%s", view)
+	}
+
+	for _, msg := range []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune{'/'}}, {Type: tea.KeyRunes, Runes: []rune("gvm")}, {Type: tea.KeyEnter}} {
+		updated, _ = m.Update(msg)
+		m = updated.(model)
+	}
+	if current, ok := m.inventory.SelectedItem(); !ok || current.install.Source != "gvm" || !strings.Contains(m.inventory.View(), "1 of 2 installations") {
+		t.Errorf("Expected the filter to leave the gvm installation, got:\n%s", m.inventory.View())
+	}
+	if m.textInput.Value() != "" {
+		t.Errorf("Expected the filter not to reach the text input, got %q", m.textInput.Value())
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if len(updated.(model).inventory.visible) != 2 {
+		t.Errorf("Expected esc to clear the filter")
+	}
+}
@@ -0,0 +1,357 @@
+//go:build integration
+
+package main
+
+// Integration tests build disposable Go environments inside t.TempDir and
+// run detection, planning and removal against them end to end, then look at
+// what is left on disk. Run them with
+//
+//	go test -tags=integration -run TestIntegration .
+//
+// The sandbox points HOME, the XDG directories and the Go variables into the
+// temp directory and enables only the detectors that look there, so nothing
+// outside it is ever found, let alone removed.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// sandboxDetectors are the detectors that only look under HOME and the Go
+// variables.
+var sandboxDetectors = []string{"gvm", "asdf", "goenv", "sdk", "gotoolchain"}
+
+type sandbox struct {
+	t        *testing.T
+	home     string
+	modCache string
+	cfg      Config
+}
+
+func newSandbox(t *testing.T) *sandbox {
+	t.Helper()
+	home := t.TempDir()
+	sb := &sandbox{t: t, home: home, modCache: filepath.Join(home, "go", "pkg", "mod")}
+	for key, value := range map[string]string{
+		"HOME":            home,
+		"USERPROFILE":     home,
+		"XDG_CONFIG_HOME": filepath.Join(home, ".config"),
+		"XDG_STATE_HOME":  filepath.Join(home, ".local", "state"),
+		"XDG_CACHE_HOME":  filepath.Join(home, ".cache"),
+		"GOPATH":          filepath.Join(home, "go"),
+		"GOMODCACHE":      sb.modCache,
+		"GOCACHE":         filepath.Join(home, ".cache", "go-build"),
+		"GOENV":           "off",
+		"ASDF_DATA_DIR":   filepath.Join(home, ".asdf"),
+		"GOENV_ROOT":      filepath.Join(home, ".goenv"),
+	} {
+		t.Setenv(key, value)
+	}
+	sb.cfg = defaultConfig()
+	sb.cfg.EnabledDetectors = sandboxDetectors
+	// Read-only trees would otherwise outlive the test and fail its cleanup
+	t.Cleanup(func() { makeWritable(home) })
+	return sb
+}
+
+func makeWritable(root string) {
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			os.Chmod(path, 0755)
+		}
+		return nil
+	})
+}
+
+func (sb *sandbox) write(path, content string, mode os.FileMode) {
+	sb.t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		sb.t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		sb.t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+// goRoot lays out a GOROOT for version at root the way the official archive
+// does, down to a go binary that answers `go version`.
+func (sb *sandbox) goRoot(root, version string) string {
+	sb.t.Helper()
+	exe := ""
+	if runtime.GOOS == "windows" {
+		exe = ".exe"
+	}
+	platform := runtime.GOOS + "_" + runtime.GOARCH
+	sb.write(filepath.Join(root, "VERSION"), fmt.Sprintf("go%s\ntime 2024-07-02T15:00:00Z\n", version), 0644)
+	sb.write(filepath.Join(root, "bin", "go"+exe), fmt.Sprintf("#!/bin/sh\necho go version go%s %s/%s\n", version, runtime.GOOS, runtime.GOARCH), 0755)
+	sb.write(filepath.Join(root, "bin", "gofmt"+exe), "#!/bin/sh\n", 0755)
+	for _, tool := range []string{"compile", "link", "asm", "vet"} {
+		sb.write(filepath.Join(root, "pkg", "tool", platform, tool+exe), "tool", 0755)
+	}
+	sb.write(filepath.Join(root, "pkg", "include", "funcdata.h"), "// funcdata\n", 0644)
+	sb.write(filepath.Join(root, "src", "runtime", "runtime.go"), "package runtime\n", 0644)
+	sb.write(filepath.Join(root, "src", "fmt", "print.go"), "package fmt\n", 0644)
+	sb.write(filepath.Join(root, "lib", "time", "zoneinfo.zip"), strings.Repeat("z", 4096), 0644)
+	sb.write(filepath.Join(root, "api", "go1.txt"), "pkg fmt, func Println(...interface{}) (int, error)\n", 0644)
+	return root
+}
+
+// gvm installs version the way gvm does, with a pkgset next to it that fu-go
+// must leave alone.
+func (sb *sandbox) gvm(version string) string {
+	root := sb.goRoot(filepath.Join(sb.home, ".gvm", "gos", "go"+version), version)
+	sb.write(filepath.Join(sb.home, ".gvm", "pkgsets", "go"+version, "global", "src", "example.com", "lib", "lib.go"), "package lib\n", 0644)
+	sb.write(filepath.Join(sb.home, ".gvm", "scripts", "gvm"), "#!/bin/sh\n", 0755)
+	return root
+}
+
+// sdk installs version the way golang.org/dl does.
+func (sb *sandbox) sdk(version string) string {
+	return sb.goRoot(filepath.Join(sb.home, "sdk", "go"+version), version)
+}
+
+// moduleCache fills GOMODCACHE with a module and a GOTOOLCHAIN download,
+// both read-only like the go command leaves them.
+func (sb *sandbox) moduleCache(toolchain string) string {
+	module := filepath.Join(sb.modCache, "github.com", "example", "lib@v1.2.3")
+	sb.write(filepath.Join(module, "lib.go"), "package lib\n", 0444)
+	sb.write(filepath.Join(module, "go.mod"), "module github.com/example/lib\n", 0444)
+	sb.write(filepath.Join(sb.modCache, "cache", "download", "github.com", "example", "lib", "@v", "v1.2.3.zip"), "zip", 0444)
+	root := sb.goRoot(filepath.Join(sb.modCache, "golang.org", fmt.Sprintf("toolchain@v0.0.1-go%s.%s-%s", toolchain, runtime.GOOS, runtime.GOARCH)), toolchain)
+	// Directories are made read-only children first
+	var dirs []string
+	filepath.WalkDir(sb.modCache, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Chmod(dirs[i], 0555)
+	}
+	return root
+}
+
+// link puts a symlink to target in ~/.local/bin.
+func (sb *sandbox) link(name, target string) string {
+	sb.t.Helper()
+	link := filepath.Join(sb.home, ".local", "bin", name)
+	os.MkdirAll(filepath.Dir(link), 0755)
+	if err := os.Symlink(target, link); err != nil {
+		sb.t.Skipf("symlinks unavailable: %v", err)
+	}
+	return link
+}
+
+// detect runs the enabled detectors and fails if anything outside the
+// sandbox turns up.
+func (sb *sandbox) detect() []GoInstallation {
+	sb.t.Helper()
+	installations := detectGoInstallations(sb.cfg)
+	for _, install := range installations {
+		if !withinDir(install.Path, sb.home) {
+			sb.t.Fatalf("Detected %s outside the sandbox", install.Path)
+		}
+	}
+	markBlockedInstallations(installations, false)
+	return installations
+}
+
+// model is the TUI's model at the confirm screen with every installation
+// selected, the way it is after detection.
+func (sb *sandbox) model(installations []GoInstallation) model {
+	m := model{state: "confirm", config: sb.cfg, inventory: newInstallList(), detectedInstalls: installations}
+	m.setInstallItems()
+	return m
+}
+
+// run freezes the plan on the review screen and executes it as the delete
+// step does.
+func (sb *sandbox) run(m model) []itemResult {
+	m = m.startReview(ConfirmationStepReview)
+	return executePlan(m.reviewedPlan, planEnv{allowCrossMounts: sb.cfg.AllowCrossMounts})
+}
+
+func assertGone(t *testing.T, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", path)
+		}
+	}
+}
+
+func assertPresent(t *testing.T, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		if _, err := os.Lstat(path); err != nil {
+			t.Errorf("Expected %s to survive: %v", path, err)
+		}
+	}
+}
+
+func sourcesOf(installations []GoInstallation) map[string]string {
+	sources := make(map[string]string)
+	for _, install := range installations {
+		sources[install.Path] = install.Source
+	}
+	return sources
+}
+
+func TestIntegrationDetectPlanRemove(t *testing.T) {
+	sb := newSandbox(t)
+	gvmRoot := sb.gvm("1.21.13")
+	sdkRoot := sb.sdk("1.22.5")
+	toolchain := sb.moduleCache("1.23.0")
+	link := sb.link("go1.22.5", filepath.Join(sdkRoot, "bin", "go"))
+	keep := filepath.Join(sb.home, "notes.txt")
+	sb.write(keep, "not Go", 0644)
+
+	installations := sb.detect()
+	sources := sourcesOf(installations)
+	for path, source := range map[string]string{gvmRoot: "gvm", sdkRoot: "sdk", toolchain: "gotoolchain"} {
+		if sources[path] != source {
+			t.Errorf("Expected %s to be detected by %s, got %q", path, source, sources[path])
+		}
+	}
+
+	m := sb.model(installations)
+	// The toolchain is read-only; leave it to the module cache test
+	m.selection = map[string]bool{toolchain: false}
+	results := sb.run(m)
+	if err := executionError(results); err != nil {
+		t.Fatalf("Removal failed: %v", err)
+	}
+	assertGone(t, gvmRoot, sdkRoot, link)
+	assertPresent(t, keep, toolchain, filepath.Join(sb.home, ".gvm", "pkgsets"), filepath.Join(sb.home, ".gvm", "scripts", "gvm"))
+
+	if again := sb.detect(); len(again) != 1 || again[0].Path != toolchain {
+		t.Errorf("Expected only the toolchain to be found afterwards, got %v", sourcesOf(again))
+	}
+}
+
+func TestIntegrationReadOnlyModuleCache(t *testing.T) {
+	sb := newSandbox(t)
+	toolchain := sb.moduleCache("1.23.0")
+	sdkRoot := sb.sdk("1.22.5")
+
+	var out strings.Builder
+	report := newChangeReport(false, false)
+	if err := ciClean(eventWriter{w: &out}, sb.home, sb.detect(), cacheTargets(sb.cfg, currentGoEnv()), report); err != nil {
+		t.Fatalf("ciClean failed: %v", err)
+	}
+	assertGone(t, sdkRoot, toolchain, filepath.Join(sb.modCache, "github.com"))
+	assertPresent(t, sb.modCache)
+	if !strings.Contains(out.String(), "in-cache") {
+		t.Errorf("Expected the toolchain to go with the module cache, got:\n%s", out.String())
+	}
+	if report.FreedBytes == 0 {
+		t.Errorf("Expected freed bytes to be reported")
+	}
+}
+
+func TestIntegrationCICleanCommand(t *testing.T) {
+	sb := newSandbox(t)
+	sdkRoot := sb.sdk("1.22.5")
+	sb.write(filepath.Join(sb.home, ".config", "fugo", "config.json"), fmt.Sprintf(`{"enabled_detectors": ["%s"]}`, strings.Join(sandboxDetectors, `", "`)), 0644)
+
+	root := newRootCmd()
+	var stdout, stderr strings.Builder
+	root.SetOut(&stdout)
+	root.SetErr(&stderr)
+	root.SetArgs([]string{"ci-clean"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("ci-clean failed: %v\n%s", err, stderr.String())
+	}
+	var report changeReport
+	if err := json.Unmarshal([]byte(stdout.String()), &report); err != nil {
+		t.Fatalf("Expected only the JSON summary on stdout, got %q: %v", stdout.String(), err)
+	}
+	if !report.Changed || report.FreedBytes == 0 {
+		t.Errorf("Expected a change with bytes freed, got %+v", report)
+	}
+	assertGone(t, sdkRoot)
+}
+
+// faultyFS fails removals of the paths in fail and passes the rest through.
+type faultyFS struct {
+	fileSystem
+	fail map[string]error
+}
+
+func (f faultyFS) RemoveAll(path string) error {
+	if err, ok := f.fail[path]; ok {
+		return err
+	}
+	return f.fileSystem.RemoveAll(path)
+}
+
+func TestIntegrationFailureInjection(t *testing.T) {
+	sb := newSandbox(t)
+	gvmRoot := sb.gvm("1.21.13")
+	sdkRoot := sb.sdk("1.22.5")
+	link := sb.link("go1.21.13", filepath.Join(gvmRoot, "bin", "go"))
+
+	saved := fsys
+	fsys = faultyFS{fileSystem: saved, fail: map[string]error{gvmRoot: fs.ErrPermission}}
+	defer func() { fsys = saved }()
+
+	m := sb.model(sb.detect())
+	results := sb.run(m)
+	err := executionError(results)
+	if err == nil || !strings.Contains(err.Error(), "1 of") {
+		t.Fatalf("Expected exactly one failure, got %v", err)
+	}
+	byTarget := make(map[string]itemResult)
+	for _, result := range results {
+		byTarget[result.item.Target()] = result
+	}
+	if result := byTarget[gvmRoot]; !errors.Is(result.err, fs.ErrPermission) || !result.needsElevation() {
+		t.Errorf("Expected the gvm root to fail on permissions, got %v", result.err)
+	}
+	if result := byTarget[link]; result.skipped == "" {
+		t.Errorf("Expected the link into the kept root to be skipped, got %+v", result)
+	}
+	assertGone(t, sdkRoot)
+	assertPresent(t, gvmRoot, link)
+
+	// Retrying once the fault clears removes what was left
+	fsys = saved
+	m.results = results
+	var retry []PlanItem
+	for _, i := range m.failedIndexes() {
+		retry = append(retry, results[i].item)
+	}
+	if err := executionError(executePlan(retry, planEnv{})); err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	assertGone(t, gvmRoot, link)
+}
+
+func TestIntegrationBlockedRoots(t *testing.T) {
+	sb := newSandbox(t)
+	sdkRoot := sb.sdk("1.22.5")
+	gvmRoot := sb.gvm("1.21.13")
+
+	installations := sb.detect()
+	for i := range installations {
+		if installations[i].Path == gvmRoot {
+			installations[i].Blocked = "on a read-only file system"
+		}
+	}
+	results := sb.run(sb.model(installations))
+	for _, result := range results {
+		if result.item.Target() == gvmRoot {
+			t.Errorf("Expected a blocked root to stay out of the plan")
+		}
+	}
+	assertGone(t, sdkRoot)
+	assertPresent(t, gvmRoot)
+}
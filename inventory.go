@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+// Every run saves what it found, the installations and the size of GOCACHE
+// and the module cache, to the inventory directory, and fu-go diff compares
+// the machine with the last of them: new installations, ones that went, and
+// caches that grew. Run from cron it turns fu-go into a monitor instead of
+// only a one-shot cleanup.
+
+const (
+	// inventoryKeep is how many inventories are kept, oldest removed first.
+	inventoryKeep = 100
+	// inventorySizeChange is the smallest change in size worth reporting.
+	inventorySizeChange = 10 << 20
+)
+
+// inventorySnapshot is what one run found.
+type inventorySnapshot struct {
+	Taken         time.Time        `json:"taken"`
+	Installations []inventoryEntry `json:"installations"`
+	Caches        []inventoryEntry `json:"caches"`
+}
+
+// inventoryEntry is an installation or a cache.
+type inventoryEntry struct {
+	Path    string `json:"path"`
+	Name    string `json:"name,omitempty"` // GOCACHE or GOMODCACHE for caches
+	Version string `json:"version,omitempty"`
+	Source  string `json:"source,omitempty"`
+	Size    int64  `json:"size"`
+}
+
+func (e inventoryEntry) label() string {
+	if e.Name != "" {
+		return fmt.Sprintf("%s %s", e.Name, e.Path)
+	}
+	return e.Path
+}
+
+// takeInventory records installs and measures caches.
+func takeInventory(installs []GoInstallation, caches []cacheTarget, now time.Time) inventorySnapshot {
+	snapshot := inventorySnapshot{Taken: now, Installations: []inventoryEntry{}, Caches: []inventoryEntry{}}
+	for _, install := range installs {
+		snapshot.Installations = append(snapshot.Installations, inventoryEntry{Path: install.Path, Version: install.Version, Source: install.Source, Size: install.Size})
+	}
+	for _, cache := range caches {
+		if _, err := os.Stat(cache.Path); err != nil {
+			continue
+		}
+		snapshot.Caches = append(snapshot.Caches, inventoryEntry{Path: cache.Path, Name: cache.Name, Size: getDirSize(cache.Path)})
+	}
+	return snapshot
+}
+
+// saveInventory writes snapshot to dir and removes all but the newest
+// inventoryKeep inventories.
+func saveInventory(dir string, snapshot inventorySnapshot) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create inventory directory: %v", err)
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode inventory: %v", err)
+	}
+	path := filepath.Join(dir, "inventory-"+snapshot.Taken.UTC().Format("20060102-150405")+".json")
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write inventory: %v", err)
+	}
+	files, err := listInventories(dir)
+	if err != nil {
+		return path, err
+	}
+	for len(files) > inventoryKeep {
+		os.Remove(files[0])
+		files = files[1:]
+	}
+	return path, nil
+}
+
+// listInventories returns the saved inventories, oldest first. Their names
+// sort by time.
+func listInventories(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inventories: %v", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "inventory-") && strings.HasSuffix(entry.Name(), ".json") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func loadInventory(path string) (inventorySnapshot, error) {
+	var snapshot inventorySnapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read inventory: %v", err)
+	}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("failed to parse inventory %s: %v", path, err)
+	}
+	return snapshot, nil
+}
+
+// previousInventory is the newest saved inventory taken before since, or the
+// newest of all when since is zero. ok is false when there is none.
+func previousInventory(dir string, since time.Time) (inventorySnapshot, bool, error) {
+	files, err := listInventories(dir)
+	if err != nil {
+		return inventorySnapshot{}, false, err
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		snapshot, err := loadInventory(files[i])
+		if err != nil {
+			return inventorySnapshot{}, false, err
+		}
+		if since.IsZero() || snapshot.Taken.Before(since) {
+			return snapshot, true, nil
+		}
+	}
+	return inventorySnapshot{}, false, nil
+}
+
+// diffInventories describes what changed between two inventories, one line
+// per change, in a stable order: installations removed, added and changed,
+// then caches.
+func diffInventories(before, after inventorySnapshot) []string {
+	changes := diffInventoryEntries(before.Installations, after.Installations)
+	return append(changes, diffInventoryEntries(before.Caches, after.Caches)...)
+}
+
+func diffInventoryEntries(before, after []inventoryEntry) []string {
+	old := make(map[string]inventoryEntry)
+	for _, e := range before {
+		old[e.Name+e.Path] = e
+	}
+	current := make(map[string]inventoryEntry)
+	for _, e := range after {
+		current[e.Name+e.Path] = e
+	}
+	var removed, added, changed []string
+	for key, e := range old {
+		if _, ok := current[key]; !ok {
+			removed = append(removed, fmt.Sprintf("- %s%s", e.label(), describeInventoryEntry(e)))
+		}
+	}
+	for key, e := range current {
+		was, ok := old[key]
+		if !ok {
+			added = append(added, fmt.Sprintf("+ %s%s", e.label(), describeInventoryEntry(e)))
+			continue
+		}
+		if was.Version != e.Version {
+			changed = append(changed, fmt.Sprintf("~ %s: %s -> %s", e.label(), versionOrUnknown(was.Version), versionOrUnknown(e.Version)))
+		}
+		switch delta := e.Size - was.Size; {
+		case delta >= inventorySizeChange:
+			changed = append(changed, fmt.Sprintf("~ %s grew by %s (%s -> %s)", e.label(), formatBytes(delta), formatBytes(was.Size), formatBytes(e.Size)))
+		case -delta >= inventorySizeChange:
+			changed = append(changed, fmt.Sprintf("~ %s shrank by %s (%s -> %s)", e.label(), formatBytes(-delta), formatBytes(was.Size), formatBytes(e.Size)))
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+	sort.Strings(changed)
+	return append(append(removed, added...), changed...)
+}
+
+func describeInventoryEntry(e inventoryEntry) string {
+	var parts []string
+	if e.Version != "" {
+		parts = append(parts, e.Version)
+	}
+	parts = append(parts, formatBytes(e.Size))
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+func versionOrUnknown(version string) string {
+	if version == "" {
+		return "unknown"
+	}
+	return version
+}
+
+// inventorySaved reports the run's inventory being written.
+type inventorySaved struct {
+	path string
+	err  error
+}
+
+// saveInventoryCmd records what this run detected for fu-go diff.
+func (m model) saveInventoryCmd() tea.Cmd {
+	if m.opts.simulate || m.paths.Inventory == "" {
+		return nil
+	}
+	installs, caches, dir := m.detectedInstalls, cacheTargets(m.config, m.goEnv), m.paths.Inventory
+	return func() tea.Msg {
+		path, err := saveInventory(dir, takeInventory(installs, caches, time.Now()))
+		return inventorySaved{path: path, err: err}
+	}
+}
+
+func (m model) handleInventorySaved(msg inventorySaved) model {
+	if m.logFile == nil {
+		return m
+	}
+	if msg.err != nil {
+		m.logFile.Log("WARN", fmt.Sprintf("Failed to save the inventory: %v", msg.err))
+	} else {
+		m.logFile.Log("INFO", fmt.Sprintf("Inventory saved to %s", msg.path))
+	}
+	return m
+}
+
+func newDiffCmd(opts *runOptions) *cobra.Command {
+	var since string
+	var noSave, exitCode bool
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show what changed since the last run: installations and cache sizes",
+		Long:  "diff detects installations and measures GOCACHE and the module cache, compares them with the inventory\nthe last run saved (or the last one before --since) and saves the new one. With --exit-code it exits\nwith status 1 when something changed, for monitoring.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var cutoff time.Time
+			if since != "" {
+				var err error
+				if cutoff, err = time.ParseInLocation("2006-01-02", since, time.Local); err != nil {
+					return fmt.Errorf("--since must be a date like 2006-01-02, got %q", since)
+				}
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			paths, err := resolvePaths(cfg)
+			if err != nil {
+				return err
+			}
+			var installations []GoInstallation
+			if opts.simulate {
+				installations = simulatedInventory()
+			} else {
+				installations = detectGoInstallations(cfg)
+			}
+			after := takeInventory(installations, cacheTargets(cfg, currentGoEnv()), time.Now())
+			before, found, err := previousInventory(paths.Inventory, cutoff)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			var changes []string
+			if !found {
+				fmt.Fprintln(out, "No earlier inventory to compare with; this one is the baseline")
+			} else if changes = diffInventories(before, after); len(changes) == 0 {
+				fmt.Fprintf(out, "No changes since %s\n", before.Taken.Local().Format("2006-01-02 15:04"))
+			} else {
+				fmt.Fprintf(out, "Since %s:\n", before.Taken.Local().Format("2006-01-02 15:04"))
+				for _, change := range changes {
+					fmt.Fprintln(out, change)
+				}
+			}
+			if !noSave && !opts.simulate {
+				if _, err := saveInventory(paths.Inventory, after); err != nil {
+					return err
+				}
+			}
+			if exitCode && len(changes) > 0 {
+				return exitCodeError{code: 1}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&since, "since", "", "compare with the last inventory before this date (2006-01-02)")
+	cmd.Flags().BoolVar(&noSave, "no-save", false, "compare without saving this inventory")
+	cmd.Flags().BoolVar(&exitCode, "exit-code", false, "exit with status 1 when something changed")
+	return cmd
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDiffInventories(t *testing.T) {
+	before := inventorySnapshot{
+		Installations: []inventoryEntry{
+			{Path: "/usr/local/go", Version: "go1.21.0", Size: 200 << 20},
+			{Path: "/opt/go1.20", Version: "go1.20.3", Size: 190 << 20},
+		},
+		Caches: []inventoryEntry{
+			{Name: "GOCACHE", Path: "/home/gopher/.cache/go-build", Size: 1 << 30},
+			{Name: "GOMODCACHE", Path: "/home/gopher/go/pkg/mod", Size: 500 << 20},
+		},
+	}
+	after := inventorySnapshot{
+		Installations: []inventoryEntry{
+			{Path: "/usr/local/go", Version: "go1.22.5", Size: 201 << 20},
+			{Path: "/home/gopher/sdk/go1.23.0", Version: "go1.23.0", Size: 210 << 20},
+		},
+		Caches: []inventoryEntry{
+			{Name: "GOCACHE", Path: "/home/gopher/.cache/go-build", Size: 3 << 30},
+			{Name: "GOMODCACHE", Path: "/home/gopher/go/pkg/mod", Size: 505 << 20},
+		},
+	}
+	want := []string{
+		"- /opt/go1.20 (go1.20.3, 190.0 MB)",
+		"+ /home/gopher/sdk/go1.23.0 (go1.23.0, 210.0 MB)",
+		"~ /usr/local/go: go1.21.0 -> go1.22.5",
+		"~ GOCACHE /home/gopher/.cache/go-build grew by 2.0 GB (1.0 GB -> 3.0 GB)",
+	}
+	if got := diffInventories(before, after); !slices.Equal(got, want) {
+		t.Errorf("Expected\n%q\ngot\n%q", want, got)
+	}
+	if got := diffInventories(after, after); len(got) != 0 {
+		t.Errorf("Expected no changes, got %q", got)
+	}
+}
+
+func TestSaveInventory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "inventory")
+	cache := t.TempDir()
+	os.WriteFile(filepath.Join(cache, "entry"), make([]byte, 100), 0644)
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	snapshot := takeInventory([]GoInstallation{{Path: "/usr/local/go", Version: "go1.22.5"}},
+		[]cacheTarget{{Name: "GOCACHE", Path: cache}, {Name: "GOMODCACHE", Path: filepath.Join(cache, "missing")}}, start)
+	if len(snapshot.Caches) != 1 || snapshot.Caches[0].Size != 100 {
+		t.Fatalf("Expected the one cache that exists to be measured, got %+v", snapshot.Caches)
+	}
+	for i := 0; i < inventoryKeep+5; i++ {
+		snapshot.Taken = start.Add(time.Duration(i) * time.Hour)
+		if _, err := saveInventory(dir, snapshot); err != nil {
+			t.Fatalf("saveInventory returned error: %v", err)
+		}
+	}
+	files, _ := listInventories(dir)
+	if len(files) != inventoryKeep || filepath.Base(files[0]) != "inventory-20261016-140000.json" {
+		t.Errorf("Expected the oldest inventories to be removed, got %d starting with %s", len(files), files[0])
+	}
+
+	latest, ok, err := previousInventory(dir, time.Time{})
+	if err != nil || !ok || !latest.Taken.Equal(start.Add(104*time.Hour)) {
+		t.Errorf("Expected the newest inventory, got %v, %v, %v", latest.Taken, ok, err)
+	}
+	earlier, ok, err := previousInventory(dir, start.Add(20*time.Hour))
+	if err != nil || !ok || !earlier.Taken.Equal(start.Add(19*time.Hour)) {
+		t.Errorf("Expected the last inventory before --since, got %v, %v, %v", earlier.Taken, ok, err)
+	}
+	if _, ok, _ := previousInventory(t.TempDir(), time.Time{}); ok {
+		t.Errorf("Expected no inventory in an empty directory")
+	}
+	if _, _, err := previousInventory(filepath.Join(dir, fmt.Sprintf("inventory-%s.json", "x")), time.Time{}); err != nil {
+		t.Errorf("Expected a missing directory to have no inventories, got %v", err)
+	}
+}
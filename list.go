@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newListCmd(opts *runOptions) *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List detected Go installations without changing anything",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			// list stays side-effect free unless a trace was asked for
+			if opts.trace {
+				paths, err := resolvePaths(cfg)
+				if err != nil {
+					return err
+				}
+				logger, err := newConfiguredLogger(*opts, paths.Logs)
+				if err != nil {
+					return err
+				}
+				defer logger.Close()
+			}
+			var installations []GoInstallation
+			var extras []PlanItem
+			if opts.simulate {
+				installations = simulatedInventory()
+			} else {
+				installations = detectGoInstallations(cfg)
+				markDuplicates(installations)
+				if err := checkAdvisories(cfg, installations); err != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: vulnerability check failed, using the embedded CVE table: %v\n", err)
+				}
+				// Measuring the caches is slow, and only the CSV lists them
+				if format == "csv" {
+					extras = inventoryExtras(cfg, currentGoEnv())
+				}
+			}
+			return writeInventory(cmd.OutOrStdout(), installations, extras, format)
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "table", "output format: table, json or csv")
+	return cmd
+}
+
+// writeInventory writes installations in format. The CSV lists extras, the
+// caches and GOPATH, after them.
+func writeInventory(w io.Writer, installations []GoInstallation, extras []PlanItem, format string) error {
+	installations = slices.Clone(installations)
+	sortInventory(installations)
+	switch format {
+	case "table":
+		return writeInventoryTable(w, installations)
+	case "json":
+		return writeInventoryJSON(w, installations)
+	case "csv":
+		home, _ := os.UserHomeDir()
+		items := make([]PlanItem, 0, len(installations)+len(extras))
+		for _, install := range installations {
+			items = append(items, installationPlanItem(install, home))
+		}
+		return writeInventoryCSV(w, append(items, extras...))
+	}
+	return fmt.Errorf("unknown format %q (expected table, json or csv)", format)
+}
+
+func writeInventoryTable(w io.Writer, installations []GoInstallation) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tSOURCE\tSIZE\tPATH")
+	for _, install := range installations {
+		version := install.SemVer
+		if version == "" {
+			version = install.Version
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", version, sourceLabel(install), formatBytes(install.Size), displayPath(install))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if lines := duplicateLines(installations); len(lines) > 0 {
+		fmt.Fprintln(w)
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+	}
+	return nil
+}
+
+func writeInventoryJSON(w io.Writer, installations []GoInstallation) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(installations)
+}
+
+// inventoryExtras is what the CSV lists beside the installations: the
+// caches and the first GOPATH entry that exist, each as the item that would
+// remove it.
+func inventoryExtras(cfg Config, env goEnv) []PlanItem {
+	var items []PlanItem
+	for _, target := range cacheTargets(cfg, env) {
+		if _, err := os.Stat(target.Path); err != nil {
+			continue
+		}
+		size, files := dirUsage(target.Path)
+		items = append(items, cacheItem{target: target, size: size, files: files})
+	}
+	if paths := env.gopaths(); len(paths) > 0 {
+		if _, err := os.Stat(paths[0]); err == nil {
+			size, files := dirUsage(paths[0])
+			items = append(items, gopathItem{path: paths[0], size: size, files: files})
+		}
+	}
+	return items
+}
+
+var inventoryCSVHeader = []string{"path", "source", "version", "size_bytes", "last_modified", "kind"}
+
+// writeInventoryCSV emits one row per plan item for spreadsheet review. An
+// installation's row has its source and version; a cache's source is its
+// variable, GOCACHE or GOMODCACHE, and its last change the directory's.
+func writeInventoryCSV(w io.Writer, items []PlanItem) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(inventoryCSVHeader); err != nil {
+		return err
+	}
+	for _, item := range items {
+		path, source, version := item.Target(), "", ""
+		var modified time.Time
+		switch item := item.(type) {
+		case installationItem:
+			path, source, version, modified = item.install.Path, item.install.Source, item.install.Version, item.install.InstallDate
+		case packageUninstallItem:
+			path, source, version, modified = item.install.Path, item.install.Source, item.install.Version, item.install.InstallDate
+		case cacheItem:
+			source, modified = item.target.Name, dirModTime(path)
+		case gopathItem:
+			source, modified = "gopath", dirModTime(path)
+		}
+		lastModified := ""
+		if !modified.IsZero() {
+			lastModified = modified.UTC().Format(time.RFC3339)
+		}
+		record := []string{
+			path,
+			source,
+			version,
+			strconv.FormatInt(item.Size(), 10),
+			lastModified,
+			string(item.Kind()),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// dirModTime is when dir last changed, zero if it cannot be read.
+func dirModTime(dir string) time.Time {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var listTestInstallations = []GoInstallation{
+	{
+		Path:        "/usr/local/go",
+		Version:     "go version go1.22.5 linux/amd64",
+		SemVer:      "1.22.5",
+		Source:      "official",
+		Size:        2048,
+		InstallDate: time.Date(2024, 7, 2, 10, 0, 0, 0, time.UTC),
+	},
+	{Path: "/home/a, b/sdk/go1.21.0", Version: "go version go1.21.0", Source: "sdk", Size: 10},
+}
+
+func TestWriteInventoryCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeInventory(&buf, listTestInstallations, nil, "csv"); err != nil {
+		t.Fatalf("writeInventory returned error: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Output is not valid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected header plus 2 rows, got %d", len(records))
+	}
+	if strings.Join(records[0], ",") != "path,source,version,size_bytes,last_modified,kind" {
+		t.Errorf("Unexpected header: %v", records[0])
+	}
+	if records[1][3] != "2048" || records[1][4] != "2024-07-02T10:00:00Z" {
+		t.Errorf("Unexpected first row: %v", records[1])
+	}
+	if records[2][0] != "/home/a, b/sdk/go1.21.0" || records[2][4] != "" {
+		t.Errorf("Unexpected second row: %v", records[2])
+	}
+}
+
+func TestWriteInventoryCSVListsCachesAndGopath(t *testing.T) {
+	dir := t.TempDir()
+	gocache, gopath := filepath.Join(dir, "go-build"), filepath.Join(dir, "go")
+	os.MkdirAll(gocache, 0755)
+	os.WriteFile(filepath.Join(gocache, "entry"), make([]byte, 100), 0644)
+	os.MkdirAll(filepath.Join(gopath, "src"), 0755)
+	env := goEnv{"GOCACHE": {gocache, "env"}, "GOMODCACHE": {filepath.Join(dir, "missing"), "env"}, "GOPATH": {gopath, "env"}}
+
+	var buf bytes.Buffer
+	if err := writeInventory(&buf, listTestInstallations[:1], inventoryExtras(Config{}, env), "csv"); err != nil {
+		t.Fatalf("writeInventory returned error: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Output is not valid CSV: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("Expected header plus an installation, GOCACHE and GOPATH, got %v", records)
+	}
+	if got := records[1]; got[0] != "/usr/local/go" || got[5] != "installation" {
+		t.Errorf("Unexpected installation row: %v", got)
+	}
+	if got := records[2]; got[0] != gocache || got[1] != "GOCACHE" || got[3] != "100" || got[4] == "" || got[5] != "cache" {
+		t.Errorf("Unexpected cache row: %v", got)
+	}
+	if got := records[3]; got[0] != gopath || got[1] != "gopath" || got[5] != "gopath" {
+		t.Errorf("Unexpected GOPATH row: %v", got)
+	}
+}
+
+func TestWriteInventoryJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeInventory(&buf, listTestInstallations, nil, "json"); err != nil {
+		t.Fatalf("writeInventory returned error: %v", err)
+	}
+
+	var decoded []GoInstallation
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if len(decoded) != 2 || decoded[0].SemVer != "1.22.5" {
+		t.Errorf("Unexpected decoded inventory: %+v", decoded)
+	}
+}
+
+func TestWriteInventoryUnknownFormat(t *testing.T) {
+	if err := writeInventory(&bytes.Buffer{}, nil, nil, "xml"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Log levels, lowest first. SUCCESS entries are logged at info level and
+// TRACE entries only when tracing is enabled.
+const (
+	LogLevelDebug = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+var logLevelNames = map[string]int{
+	"debug": LogLevelDebug,
+	"info":  LogLevelInfo,
+	"warn":  LogLevelWarn,
+	"error": LogLevelError,
+}
+
+func parseLogLevel(name string) (int, error) {
+	level, ok := logLevelNames[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown log level %q (expected debug, info, warn or error)", name)
+	}
+	return level, nil
+}
+
+func entryLevel(level string) int {
+	switch level {
+	case "DEBUG", "TRACE":
+		return LogLevelDebug
+	case "WARN":
+		return LogLevelWarn
+	case "ERROR":
+		return LogLevelError
+	default:
+		return LogLevelInfo
+	}
+}
+
+// Logger appends timestamped entries to a log file. It is safe for use from
+// the background commands that detectors and removals run in.
+type Logger struct {
+	mu       sync.Mutex
+	file     *os.File
+	path     string
+	minLevel int
+	trace    bool
+}
+
+// NewLogger starts a new timestamped log file in logDir.
+func NewLogger(logDir string) (*Logger, error) {
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %v", err)
+	}
+
+	timestamp := time.Now().Format("20060102_150405")
+	logFile := filepath.Join(logDir, fmt.Sprintf("fugo_%s.log", timestamp))
+
+	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log file: %v", err)
+	}
+
+	return &Logger{file: file, path: logFile, minLevel: LogLevelInfo}, nil
+}
+
+func (l *Logger) Log(level, message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return
+	}
+	if level == "TRACE" && !l.trace {
+		return
+	}
+	if level != "TRACE" && entryLevel(level) < l.minLevel {
+		return
+	}
+	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	logEntry := fmt.Sprintf("[%s] %s: %s\n", timestamp, level, message)
+	l.file.WriteString(logEntry)
+	l.file.Sync()
+}
+
+// Dir returns the directory the log file is written to.
+func (l *Logger) Dir() string {
+	return filepath.Dir(l.path)
+}
+
+func (l *Logger) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}
+
+// SetLevel drops entries below the named level.
+func (l *Logger) SetLevel(name string) error {
+	level, err := parseLogLevel(name)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.minLevel = level
+	l.mu.Unlock()
+	return nil
+}
+
+// EnableTrace records TRACE entries: external commands and detector
+// file system decisions.
+func (l *Logger) EnableTrace() {
+	l.mu.Lock()
+	l.trace = true
+	l.mu.Unlock()
+}
+
+// traceLogger receives trace entries from code that has no model to hand,
+// such as detectors running in background commands. A detector that timed
+// out may still be tracing when the next run swaps the logger.
+var traceLogger atomic.Pointer[Logger]
+
+func tracef(format string, args ...interface{}) {
+	if logger := traceLogger.Load(); logger != nil {
+		logger.Log("TRACE", fmt.Sprintf(format, args...))
+	}
+}
+
+// newConfiguredLogger creates the run's logger with the command line options
+// applied and registers it for tracing.
+func newConfiguredLogger(opts runOptions, logDir string) (*Logger, error) {
+	logger, err := NewLogger(logDir)
+	if err != nil {
+		return nil, err
+	}
+	if opts.logLevel != "" {
+		if err := logger.SetLevel(opts.logLevel); err != nil {
+			logger.Close()
+			return nil, err
+		}
+	}
+	if opts.trace {
+		logger.EnableTrace()
+	}
+	traceLogger.Store(logger)
+	return logger, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newTestLogger(t *testing.T) (*Logger, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.log")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create log file: %v", err)
+	}
+	return &Logger{file: file, minLevel: LogLevelInfo}, path
+}
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected int
+		wantErr  bool
+	}{
+		{"debug", LogLevelDebug, false},
+		{"INFO", LogLevelInfo, false},
+		{"warn", LogLevelWarn, false},
+		{"error", LogLevelError, false},
+		{"verbose", 0, true},
+	}
+
+	for _, test := range tests {
+		level, err := parseLogLevel(test.name)
+		if (err != nil) != test.wantErr {
+			t.Errorf("parseLogLevel(%s) error = %v, wantErr %v", test.name, err, test.wantErr)
+			continue
+		}
+		if level != test.expected {
+			t.Errorf("parseLogLevel(%s) = %d, expected %d", test.name, level, test.expected)
+		}
+	}
+}
+
+func TestLoggerLevelFiltering(t *testing.T) {
+	logger, path := newTestLogger(t)
+	if err := logger.SetLevel("warn"); err != nil {
+		t.Fatalf("SetLevel(warn) failed: %v", err)
+	}
+	logger.Log("DEBUG", "debug entry")
+	logger.Log("INFO", "info entry")
+	logger.Log("SUCCESS", "success entry")
+	logger.Log("WARN", "warn entry")
+	logger.Log("ERROR", "error entry")
+	logger.Close()
+
+	data, _ := os.ReadFile(path)
+	content := string(data)
+	for _, dropped := range []string{"debug entry", "info entry", "success entry"} {
+		if strings.Contains(content, dropped) {
+			t.Errorf("Expected %q to be filtered at warn level", dropped)
+		}
+	}
+	for _, kept := range []string{"WARN: warn entry", "ERROR: error entry"} {
+		if !strings.Contains(content, kept) {
+			t.Errorf("Expected %q in log, got:\n%s", kept, content)
+		}
+	}
+}
+
+func TestTraceCommand(t *testing.T) {
+	logger, path := newTestLogger(t)
+	previous := traceLogger.Load()
+	traceLogger.Store(logger)
+	defer traceLogger.Store(previous)
+
+	// Trace entries are dropped until tracing is enabled
+	traceCommand("go", []string{"version"}, 0, nil)
+	logger.EnableTrace()
+	traceCommand("tar", []string{"-czf", "backup.tar.gz"}, 0, nil)
+	logger.Close()
+
+	data, _ := os.ReadFile(path)
+	content := string(data)
+	if strings.Contains(content, "exec go version") {
+		t.Errorf("Expected trace entry to be dropped before EnableTrace, got:\n%s", content)
+	}
+	if !strings.Contains(content, "TRACE: exec tar -czf backup.tar.gz") {
+		t.Errorf("Expected traced tar command, got:\n%s", content)
+	}
+}
+
+func TestLoggerClosedIsSafe(t *testing.T) {
+	logger, _ := newTestLogger(t)
+	logger.Close()
+	logger.Log("INFO", "after close")
+	logger.Close()
+}
+
+// TestLoggerConcurrentStress logs, retunes and traces from many goroutines at
+// once, the way detectors and removals do; run it with -race.
+func TestLoggerConcurrentStress(t *testing.T) {
+	logger, path := newTestLogger(t)
+	previous := traceLogger.Load()
+	traceLogger.Store(logger)
+	defer traceLogger.Store(previous)
+
+	const writers, entries = 16, 200
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range entries {
+				logger.Log("INFO", fmt.Sprintf("writer %d entry %d", w, i))
+				tracef("writer %d trace %d", w, i)
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range entries {
+			logger.SetLevel("info")
+			logger.EnableTrace()
+		}
+	}()
+	wg.Wait()
+	logger.Close()
+	// Late entries from a detector that outlived the run are dropped
+	logger.Log("INFO", "after close")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := 0
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		if !strings.HasPrefix(line, "[") || !strings.Contains(line, "] ") {
+			t.Fatalf("Expected whole entries, got interleaved line %q", line)
+		}
+		if strings.Contains(line, "] INFO: writer") {
+			info++
+		}
+	}
+	if info != writers*entries {
+		t.Errorf("Expected %d info entries, got %d", writers*entries, info)
+	}
+}
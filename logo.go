@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const fugoASCII = `
+███████╗██╗   ██╗      ██████╗  ██████╗ 
+██╔════╝██║   ██║     ██╔════╝ ██╔═══██╗
+█████╗  ██║   ██║     ██║  ███╗██║   ██║
+██╔══╝  ██║   ██║     ██║   ██║██║   ██║
+██║     ╚██████╔╝     ╚██████╔╝╚██████╔╝
+╚═╝      ╚═════╝       ╚═════╝  ╚═════╝ 
+`
+
+// The banner is the gradient logo above, a file of the user's own art, or
+// nothing: "logo": "off" or --no-logo gives its nine lines back to small
+// terminals and tmux panes.
+
+const (
+	logoOff = "off"
+	// maxLogoLines and maxLogoBytes keep a stray file from filling the
+	// screen.
+	maxLogoLines = 20
+	maxLogoBytes = 8 << 10
+)
+
+// loadLogo returns the banner art for cfg and opts, "" for none. A custom
+// banner that cannot be used falls back to the built-in one.
+func loadLogo(cfg Config, opts runOptions) (string, error) {
+	if opts.noLogo || cfg.Logo == logoOff {
+		return "", nil
+	}
+	if cfg.Logo == "" {
+		return fugoASCII, nil
+	}
+	data, err := os.ReadFile(expandHome(cfg.Logo))
+	if err != nil {
+		return fugoASCII, err
+	}
+	if len(data) > maxLogoBytes {
+		return fugoASCII, fmt.Errorf("logo %s is larger than %d bytes", cfg.Logo, maxLogoBytes)
+	}
+	art := strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if lines := strings.Count(art, "\n") + 1; lines > maxLogoLines {
+		return fugoASCII, fmt.Errorf("logo %s has %d lines, at most %d fit", cfg.Logo, lines, maxLogoLines)
+	}
+	if strings.TrimSpace(art) == "" {
+		return "", nil
+	}
+	return "\n" + art + "\n", nil
+}
+
+// paintLogo colors art with the logo gradient and frames it. It builds a
+// style per character, so View goes through renderLogo's cache instead.
+func paintLogo(art string, width int) string {
+	lines := strings.Split(art, "\n")
+	coloredLines := make([]string, len(lines))
+
+	for i, line := range lines {
+		if len(line) == 0 {
+			coloredLines[i] = line
+			continue
+		}
+
+		var coloredLine strings.Builder
+		for j, char := range line {
+			colorIndex := j % len(logoGradient)
+			style := lipgloss.NewStyle().Foreground(lipgloss.Color(logoGradient[colorIndex]))
+			coloredLine.WriteString(style.Render(string(char)))
+		}
+		coloredLines[i] = coloredLine.String()
+	}
+
+	logo := strings.Join(coloredLines, "\n")
+	styledLogo := bigTitleStyle.Render(logo)
+
+	if width > 0 {
+		styledLogo = lipgloss.PlaceHorizontal(width, lipgloss.Center, styledLogo)
+	}
+
+	return styledLogo
+}
+
+// logoCache holds the last painted logo. View runs on every spinner tick,
+// while the art and width only change on a resize or a theme switch.
+var logoCache struct {
+	sync.Mutex
+	art      string
+	width    int
+	rendered string
+}
+
+// renderLogo is paintLogo, painted once per art and width.
+func renderLogo(art string, width int) string {
+	logoCache.Lock()
+	defer logoCache.Unlock()
+	if logoCache.rendered == "" || logoCache.art != art || logoCache.width != width {
+		logoCache.art, logoCache.width = art, width
+		logoCache.rendered = paintLogo(art, width)
+	}
+	return logoCache.rendered
+}
+
+// resetLogoCache forgets the painted logo after the styles change.
+func resetLogoCache() {
+	logoCache.Lock()
+	defer logoCache.Unlock()
+	logoCache.rendered = ""
+}
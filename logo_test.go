@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadLogo(t *testing.T) {
+	dir := t.TempDir()
+	custom := filepath.Join(dir, "banner.txt")
+	if err := os.WriteFile(custom, []byte("  BYE GO\r\n\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tall := filepath.Join(dir, "tall.txt")
+	if err := os.WriteFile(tall, []byte(strings.Repeat("#\n", maxLogoLines+1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		opts    runOptions
+		want    string
+		wantErr bool
+	}{
+		{"default", Config{}, runOptions{}, fugoASCII, false},
+		{"off", Config{Logo: logoOff}, runOptions{}, "", false},
+		{"flag", Config{Logo: custom}, runOptions{noLogo: true}, "", false},
+		{"custom", Config{Logo: custom}, runOptions{}, "\n  BYE GO\n", false},
+		{"too tall", Config{Logo: tall}, runOptions{}, fugoASCII, true},
+		{"missing", Config{Logo: filepath.Join(dir, "missing.txt")}, runOptions{}, fugoASCII, true},
+	}
+	for _, tt := range tests {
+		got, err := loadLogo(tt.cfg, tt.opts)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%s: got %q, %v", tt.name, got, err)
+		}
+	}
+}
+
+func TestViewWithoutLogo(t *testing.T) {
+	m := model{width: 80, state: "loading", logo: fugoASCII}
+	withLogo := strings.Count(m.View(), "\n")
+	m.logo = ""
+	if without := strings.Count(m.View(), "\n"); withLogo-without < 9 {
+		t.Errorf("Expected hiding the logo to free at least 9 lines, freed %d", withLogo-without)
+	}
+	if strings.Contains(m.View(), "██") {
+		t.Errorf("Expected no banner")
+	}
+}
+
+func TestRenderLogoCache(t *testing.T) {
+	resetLogoCache()
+	first := renderLogo(fugoASCII, 80)
+	if first != paintLogo(fugoASCII, 80) {
+		t.Fatalf("Expected the cached logo to match a fresh one")
+	}
+	if renderLogo(fugoASCII, 80) != first {
+		t.Errorf("Expected the cached logo to be reused")
+	}
+	if wide := renderLogo(fugoASCII, 120); wide == first || wide != paintLogo(fugoASCII, 120) {
+		t.Errorf("Expected a resize to paint the logo again")
+	}
+	if custom := renderLogo("\n  BYE GO\n", 120); strings.Contains(custom, "██") {
+		t.Errorf("Expected other art to paint the logo again")
+	}
+}
+
+// BenchmarkViewLoading is View on the loading screen, which redraws on every
+// spinner tick; compare with BenchmarkPaintLogo, the cost it used to pay
+// each time.
+func BenchmarkViewLoading(b *testing.B) {
+	m := model{width: 100, state: "loading", logo: fugoASCII}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m.View()
+	}
+}
+
+func BenchmarkPaintLogo(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		paintLogo(fugoASCII, 100)
+	}
+}
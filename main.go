@@ -1,34 +1,26 @@
 package main
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
-	"os/user"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
 
-	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-const fugoASCII = `
-███████╗██╗   ██╗      ██████╗  ██████╗ 
-██╔════╝██║   ██║     ██╔════╝ ██╔═══██╗
-█████╗  ██║   ██║     ██║  ███╗██║   ██║
-██╔══╝  ██║   ██║     ██║   ██║██║   ██║
-██║     ╚██████╔╝     ╚██████╔╝╚██████╔╝
-╚═╝      ╚═════╝       ╚═════╝  ╚═════╝ 
-`
-
 var (
 	logoGradient = []string{
 		"#FF5370", "#F78C6C", "#FFCB6B", "#C3E88D", "#89DDFF", "#82AAFF", "#C792EA",
@@ -81,6 +73,8 @@ const (
 	ConfirmationStepInitial = iota
 	ConfirmationStepHash
 	ConfirmationStepDestroy
+	ConfirmationStepOwnership
+	ConfirmationStepReview
 )
 
 var criticalPaths = []string{
@@ -89,54 +83,31 @@ var criticalPaths = []string{
 }
 
 type GoInstallation struct {
-	Path        string
-	Version     string
-	Source      string // "official", "gvm", "snap", "brew", "package_manager"
-	Size        int64
-	Permissions string
-	Verified    bool
-}
-
-type Logger struct {
-	file *os.File
-}
-
-func NewLogger() (*Logger, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %v", err)
-	}
-
-	logDir := filepath.Join(homeDir, ".fugo")
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create log directory: %v", err)
-	}
-
-	timestamp := time.Now().Format("20060102_150405")
-	logFile := filepath.Join(logDir, fmt.Sprintf("fugo_%s.log", timestamp))
-
-	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create log file: %v", err)
-	}
-
-	return &Logger{file: file}, nil
-}
-
-func (l *Logger) Log(level, message string) {
-	if l.file == nil {
-		return
-	}
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	logEntry := fmt.Sprintf("[%s] %s: %s\n", timestamp, level, message)
-	l.file.WriteString(logEntry)
-	l.file.Sync()
-}
-
-func (l *Logger) Close() {
-	if l.file != nil {
-		l.file.Close()
-	}
+	Path           string         `json:"path"`
+	Version        string         `json:"version"`
+	Source         string         `json:"source"` // detector name, or "apk"/"termux" for package-owned roots
+	Size           int64          `json:"size"`
+	Files          int64          `json:"files"` // regular files under Path, for time estimates
+	Permissions    string         `json:"permissions"`
+	Verified       bool           `json:"verified"`                  // VERSION, bin/go and pkg/tool all present; unverified installs need opting in
+	PackageManager string         `json:"package_manager,omitempty"` // "pkg", "pkg_add", "pkgsrc", "apk", "termux", "snap", "scoop", "plugin"; empty when fu-go removes files itself
+	Package        string         `json:"package,omitempty"`         // package name (or plugin executable) handed the removal
+	SemVer         string         `json:"semver,omitempty"`          // "1.22.5", parsed from Version
+	GOOS           string         `json:"goos,omitempty"`
+	GOARCH         string         `json:"goarch,omitempty"`
+	InstallDate    time.Time      `json:"install_date"`           // modification time of the Go root
+	OnPath         bool           `json:"on_path"`                // whether this installation's go is the one PATH resolves to
+	Confidence     Confidence     `json:"confidence"`             // how sure the detector is that this is a Go installation
+	Blocked        string         `json:"blocked,omitempty"`      // why the installation cannot be removed, e.g. read-only mount
+	Owners         map[string]int `json:"owners,omitempty"`       // file count per owning user
+	Detector       string         `json:"detector"`               // detector that reported the installation first
+	User           string         `json:"user,omitempty"`         // whose home directory it is in, for other users' installations
+	Evidence       []string       `json:"evidence,omitempty"`     // why fu-go believes this is Go: paths probed, command output
+	EOL            string         `json:"eol,omitempty"`          // date the release line stopped getting security fixes
+	CVEs           []string       `json:"cves,omitempty"`         // known critical CVEs fixed in later releases
+	Advisories     []advisory     `json:"advisories,omitempty"`   // vulnerability database entries, with vuln_db set
+	DuplicateOf    string         `json:"duplicate_of,omitempty"` // the installation this one is a byte-identical copy of
+	Links          []string       `json:"links,omitempty"`        // symlinks detected in its place, e.g. /usr/local/go -> Path
 }
 
 func generateSecurityHash() string {
@@ -146,62 +117,32 @@ func generateSecurityHash() string {
 	return hex.EncodeToString(hash[:])[:8]
 }
 
-func checkPermissions() error {
-	currentUser, err := user.Current()
-	if err != nil {
-		return fmt.Errorf("unable to determine current user: %v", err)
-	}
-
-	if runtime.GOOS != "windows" && currentUser.Uid != "0" {
-		testPath := "/usr/local/go"
-		if _, err := os.Stat(testPath); err == nil {
-			testFile := filepath.Join(testPath, "fugo-permission-test")
-			if err := os.WriteFile(testFile, []byte("test"), 0644); err != nil {
-				return fmt.Errorf("insufficient permissions: run with sudo for system-wide Go installations")
-			}
-			os.Remove(testFile)
-		}
-	}
-
-	return nil
-}
-
-func createBackup(sourcePath, backupDir string) error {
-	if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
-		return nil
-	}
-
-	backupName := fmt.Sprintf("go_backup_%s.tar.gz", time.Now().Format("20060102_150405"))
-	backupPath := filepath.Join(backupDir, backupName)
-
-	cmd := exec.Command("tar", "-czf", backupPath, "-C", filepath.Dir(sourcePath), filepath.Base(sourcePath))
-
-	return cmd.Run()
-}
-
 func isCriticalPath(path string) bool {
-	cleanPath := filepath.Clean(path)
+	cleanPath := normalizePath(path)
 	for _, critical := range criticalPaths {
-		if cleanPath == critical {
+		if cleanPath == normalizePath(critical) {
 			return true
 		}
 	}
 	return false
 }
 
-type item struct {
-	title, desc string
+// normalizePath is path in the form two spellings of the same location
+// share: cleaned, and lower-cased where the file system ignores case (so
+// /USR is /usr on macOS and c:\windows is C:\Windows).
+func normalizePath(path string) string {
+	path = filepath.Clean(path)
+	if currentPlatform.foldsCase() {
+		path = strings.ToLower(path)
+	}
+	return path
 }
 
-func (i item) Title() string       { return i.title }
-func (i item) Description() string { return i.desc }
-func (i item) FilterValue() string { return i.title }
-
 type model struct {
 	state            string
 	goVersions       []string
 	goInstallPath    string
-	list             list.Model
+	inventory        installTable
 	spinner          spinner.Model
 	textInput        textinput.Model
 	deletionComplete bool
@@ -211,13 +152,69 @@ type model struct {
 	confirmationStep int
 	dryRun           bool
 	backupPath       string
+	quarantined      map[string]bool // what the backup moved into its set, so already removed
+	paths            fugoPaths
+	opts             runOptions
+	startupErrors    []string
+	needsSetup       bool
+	wizardStep       int
+	wizardChoice     int
 	logFile          *Logger
 	hashConfirmation string
 	detectedInstalls []GoInstallation
-	permissionCheck  bool
+	selection        map[string]bool // explicit choices; otherwise verified installs are selected
+	pathInput        textinput.Model
+	addPathBusy      bool
+	addPathErr       error
+	browser          dirBrowser
+	browsePurpose    browsePurpose
+	selfExe          string // running fu-go binary, kept out of harm's way during removal
+	goEnv            goEnv
+	toolchainPins    []toolchainPin
+	preflight        []preflightResult
+	sortBy           int
+	config           Config
+	foreignOwners    []string // other users owning files in the selection
+	ackedOwners      []string // of those, the ones ACKNOWLEDGE was typed for
+	snapshotBefore   systemSnapshot
+	snapshotDiff     []string
+	reportPath       string
+	profileEdits     []profileEdit
+	profileEdit      int // edit and hunk under review
+	profileHunk      int
+	profileView      viewport.Model
+	profileResults   []string
+	throughput       throughput
+	phaseStarted     time.Time          // start of the running backup or removal, for throughput
+	detectorsDone    []detectorProgress // streamed while loading
+	itemsDone        int                // plan items finished while deleting
+	itemsTotal       int
+	lastItem         string
+	detectorResults  []detectorResult
+	removeGopath     bool           // opted into with g
+	gopathScan       *gopathScanned // nil until the scan for local work is done
+	gopathRefused    string
+	projectScan      *projectsScanned     // nil until the project_scan walk is done
+	systemBackup     *systemBackup        // Time Machine or File History, nil if none
+	remoteBackup     *remoteBackupChecked // probe of a network backup directory, nil if local
+	quotes           quoteBook
+	quoteSeed        uint32     // picks this run's quotes
+	farewellFrames   [][]string // nil unless the farewell animation is playing
+	farewellFrame    int
+	logo             string // the banner art, "" when disabled
+	view             *viewCache
+	viewRev          uint64       // bumped by every message but spinner ticks
+	summaryCursor    int          // summary line under the cursor
+	summaryDetail    string       // summary section opened with enter, "" for none
+	reviewedPlan     []PlanItem   // the plan as shown on the review screen
+	results          []itemResult // how each item of the plan went
+	resultsTable     table.Model
+	retrySelected    map[int]bool // failed results marked for retry
+	retrying         bool
+	retryErr         error // why the last retry could not run
 }
 
-func initialModel() model {
+func initialModel(opts runOptions) model {
 	sp := spinner.New()
 	sp.Spinner = spinner.Dot
 	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
@@ -228,17 +225,21 @@ func initialModel() model {
 	ti.CharLimit = 20
 	ti.Width = 25
 
-	logger, _ := NewLogger()
-	hash := generateSecurityHash()
+	cfg, cfgErr := loadConfig()
+	paths, pathsErr := resolvePaths(cfg)
+	if pathsErr != nil {
+		cfg = defaultConfig()
+		paths, _ = resolvePaths(cfg)
+	}
 
-	homeDir, _ := os.UserHomeDir()
-	backupDir := filepath.Join(homeDir, ".fugo", "backups")
-	os.MkdirAll(backupDir, 0755)
+	hash := generateSecurityHash()
 
-	return model{
+	m := model{
 		state:            "loading",
 		goVersions:       []string{},
 		goInstallPath:    "",
+		inventory:        newInstallList(),
+		selfExe:          selfExecutable(),
 		spinner:          sp,
 		textInput:        ti,
 		deletionComplete: false,
@@ -246,201 +247,203 @@ func initialModel() model {
 		height:           24,
 		err:              nil,
 		confirmationStep: ConfirmationStepInitial,
-		dryRun:           true,
-		backupPath:       backupDir,
-		logFile:          logger,
+		dryRun:           cfg.startsInDryRun(),
+		backupPath:       paths.Backups,
+		paths:            paths,
+		opts:             opts,
 		hashConfirmation: hash,
 		detectedInstalls: []GoInstallation{},
-		permissionCheck:  false,
+		preflight:        []preflightResult{},
+		sortBy:           SortByRisk,
+		config:           cfg,
+		throughput:       loadThroughput(paths.Throughput),
+		quoteSeed:        uint32(time.Now().UnixNano()),
+		view:             newViewCache(),
+	}
+	m = m.openOutputs()
+	applyTheme(cfg.Theme)
+	logo, logoErr := loadLogo(cfg, opts)
+	m.logo = logo
+	if logoErr != nil && m.logFile != nil {
+		m.logFile.Log("WARN", fmt.Sprintf("Using the built-in logo: %v", logoErr))
+	}
+	var quoteErrs []error
+	m.quotes, quoteErrs = loadQuotes(sassLevel(cfg, opts), cfg.QuotePacks)
+	for _, err := range quoteErrs {
+		if m.logFile != nil {
+			m.logFile.Log("WARN", fmt.Sprintf("Skipping quote pack: %v", err))
+		}
+	}
+
+	if cfgErr != nil && m.logFile != nil {
+		m.logFile.Log("WARN", fmt.Sprintf("Using default configuration: %v", cfgErr))
 	}
+	if pathsErr != nil && m.logFile != nil {
+		m.logFile.Log("WARN", fmt.Sprintf("Ignoring directory overrides: %v", pathsErr))
+	}
+	// A simulation must not leave preferences behind on the demo machine
+	m.needsSetup = !cfg.SetupComplete && !opts.simulate
+	if opts.simulate && m.logFile != nil {
+		m.logFile.Log("INFO", "Simulation mode: inventory and operations are fake")
+	}
+	if policy := currentPolicy(); policy.active() && m.logFile != nil {
+		m.logFile.Log("INFO", fmt.Sprintf("Machine policy %s is in effect", policy.path))
+	}
+	if isOffline() && m.logFile != nil {
+		m.logFile.Log("INFO", "Offline mode: all network access is refused")
+	}
+	if m.needsSetup && len(m.startupErrors) == 0 {
+		m.state = "setup"
+	}
+	if len(m.startupErrors) > 0 {
+		m.state = "startup_warning"
+		m.textInput.Placeholder = "Another directory, or empty to continue"
+		m.textInput.CharLimit = 0
+		m.textInput.Width = 50
+	}
+	return m
 }
 
 func (m model) Init() tea.Cmd {
+	switch m.state {
+	case "startup_warning":
+		return textinput.Blink
+	case "setup":
+		return nil
+	}
 	return tea.Batch(
 		m.spinner.Tick,
-		findGoVersionsCmd,
+		m.detectCmd(),
 	)
 }
 
 type foundGoVersions struct {
-	versions []string
-	path     string
-	installs []GoInstallation
-	permOk   bool
-	err      error
+	versions  []string
+	path      string
+	installs  []GoInstallation
+	detectors []detectorResult
+	vulnErr   error
+	preflight []preflightResult
+	snapshot  systemSnapshot
+	goEnv     goEnv
+	pins      []toolchainPin
+	err       error
 }
 
-func detectGoInstallations() []GoInstallation {
-	var installations []GoInstallation
-
-	// Official Go installation
-	var officialPaths []string
-	switch runtime.GOOS {
-	case "windows":
-		officialPaths = []string{
-			filepath.Join(os.Getenv("USERPROFILE"), "go"),
-			filepath.Join(os.Getenv("ProgramFiles"), "Go"),
-			"C:\\Go",
-		}
-	case "darwin":
-		officialPaths = []string{
-			"/usr/local/go",
-			"/opt/go",
-		}
-	default:
-		officialPaths = []string{
-			"/usr/local/go",
-			"/opt/go",
-			"/usr/lib/go",
-		}
-	}
-
-	for _, path := range officialPaths {
-		if info, err := os.Stat(path); err == nil && info.IsDir() {
-			version, versionErr := getGoVersion(path)
-			if versionErr != nil {
-				version = "unknown version"
-			}
-			size := getDirSize(path)
-			permissions, permErr := getPermissions(path)
-			if permErr != nil {
-				permissions = "unknown"
-			}
-			installations = append(installations, GoInstallation{
-				Path:        path,
-				Version:     version,
-				Source:      "official",
-				Size:        size,
-				Permissions: permissions,
-				Verified:    true,
-			})
-		}
-	}
-
-	// GVM installations
-	homeDir, err := os.UserHomeDir()
-	if err == nil {
-		gvmPath := filepath.Join(homeDir, ".gvm", "gos")
-		if entries, err := os.ReadDir(gvmPath); err == nil {
-			for _, entry := range entries {
-				if entry.IsDir() && strings.HasPrefix(entry.Name(), "go") {
-					path := filepath.Join(gvmPath, entry.Name())
-					version, versionErr := getGoVersion(path)
-					if versionErr != nil {
-						version = "unknown version"
-					}
-					size := getDirSize(path)
-					permissions, permErr := getPermissions(path)
-					if permErr != nil {
-						permissions = "unknown"
-					}
-					installations = append(installations, GoInstallation{
-						Path:        path,
-						Version:     version,
-						Source:      "gvm",
-						Size:        size,
-						Permissions: permissions,
-						Verified:    true,
-					})
-				}
-			}
-		}
-	}
-
-	// Package manager installations (Linux)
-	if runtime.GOOS == "linux" {
-		packagePaths := []string{"/usr/lib/golang", "/usr/share/golang"}
-		for _, path := range packagePaths {
-			if info, err := os.Stat(path); err == nil && info.IsDir() {
-				version, versionErr := getGoVersion(path)
-				if versionErr != nil {
-					version = "unknown version"
-				}
-				size := getDirSize(path)
-				permissions, permErr := getPermissions(path)
-				if permErr != nil {
-					permissions = "unknown"
-				}
-				installations = append(installations, GoInstallation{
-					Path:        path,
-					Version:     version,
-					Source:      "package_manager",
-					Size:        size,
-					Permissions: permissions,
-					Verified:    true,
-				})
-			}
-		}
-	}
+func detectGoInstallations(cfg Config) []GoInstallation {
+	installations, _ := detectGoInstallationsWithResults(cfg, nil)
+	return installations
+}
 
-	// Homebrew installations (macOS)
-	if runtime.GOOS == "darwin" {
-		brewPaths := []string{"/usr/local/Cellar/go", "/opt/homebrew/Cellar/go"}
-		for _, basePath := range brewPaths {
-			if entries, err := os.ReadDir(basePath); err == nil {
-				for _, entry := range entries {
-					if entry.IsDir() {
-						path := filepath.Join(basePath, entry.Name())
-						version, versionErr := getGoVersion(path)
-						if versionErr != nil {
-							version = "unknown version"
-						}
-						size := getDirSize(path)
-						permissions, permErr := getPermissions(path)
-						if permErr != nil {
-							permissions = "unknown"
-						}
-						installations = append(installations, GoInstallation{
-							Path:        path,
-							Version:     version,
-							Source:      "brew",
-							Size:        size,
-							Permissions: permissions,
-							Verified:    true,
-						})
-					}
-				}
-			}
-		}
+// detectGoInstallationsWithResults runs the enabled detectors and also returns
+// the per-detector outcomes so failures and timeouts can be logged. Each
+// outcome is published on events as it arrives.
+func detectGoInstallationsWithResults(cfg Config, events *eventBus) ([]GoInstallation, []detectorResult) {
+	timeout, err := cfg.detectorTimeout()
+	if err != nil {
+		timeout = defaultDetectorTimeout
 	}
+	results := streamDetectors(context.Background(), enabledDetectors(cfg), timeout, events)
+	home, _ := os.UserHomeDir()
+	installations := scopeInstallations(mergeDetectorResults(results), home)
+	events.publish(engineEvent{Kind: eventDetectionFinished, Phase: phaseDetect, Found: len(installations)})
+	return installations, results
+}
 
-	return installations
+// newInstallation inspects a Go root on disk and fills in everything fu-go
+// knows about it.
+func newInstallation(path, source string) GoInstallation {
+	var evidence []string
+	version, versionEvidence, versionErr := goVersionWithEvidence(path)
+	if versionErr != nil {
+		version = "unknown version"
+		evidence = append(evidence, "no Go version found: neither bin/go nor VERSION gave one")
+	} else {
+		evidence = append(evidence, versionEvidence)
+	}
+	permissions, permErr := getPermissions(path)
+	if permErr != nil {
+		permissions = "unknown"
+	}
+	semVer, goos, goarch := parseGoVersion(version)
+
+	checks := verifyGoRoot(path)
+	evidence = append(evidence, checks.evidence()...)
+
+	size, files := dirUsage(path)
+	install := GoInstallation{
+		Path:        path,
+		Version:     version,
+		Source:      source,
+		Size:        size,
+		Files:       files,
+		Permissions: permissions,
+		Verified:    checks.verified(),
+		SemVer:      semVer,
+		GOOS:        goos,
+		GOARCH:      goarch,
+		OnPath:      isOnPath(path),
+		Confidence:  checks.confidence(),
+		Evidence:    evidence,
+	}
+	if info, err := os.Stat(path); err == nil {
+		install.InstallDate = info.ModTime()
+	}
+	annotateSupport(&install, time.Now())
+	return install
 }
 
 func getGoVersion(goPath string) (string, error) {
-	goExec := filepath.Join(goPath, "bin", "go")
-	if runtime.GOOS == "windows" {
-		goExec += ".exe"
-	}
+	version, _, err := goVersionWithEvidence(goPath)
+	return version, err
+}
 
-	if _, err := os.Stat(goExec); err == nil {
-		cmd := exec.Command(goExec, "version")
-		if output, err := cmd.Output(); err == nil {
-			return strings.TrimSpace(string(output)), nil
-		}
+// goVersionWithEvidence also says where the version came from. bin/go is
+// only executed when neither its build info nor VERSION gives the answer.
+func goVersionWithEvidence(goPath string) (string, string, error) {
+	goExec := goExecutable(goPath)
+
+	if version, err := binaryGoVersion(goExec); err == nil {
+		return version, fmt.Sprintf("%s build info records %q", goExec, strings.TrimPrefix(version, "go version ")), nil
 	}
 
-	// Fallback: try to determine version from directory structure
 	versionFile := filepath.Join(goPath, "VERSION")
 	if data, err := os.ReadFile(versionFile); err == nil {
-		return "go version " + strings.TrimSpace(string(data)), nil
+		// Since Go 1.21 VERSION has a second "time ..." line
+		firstLine, _, _ := strings.Cut(string(data), "\n")
+		firstLine = strings.TrimSpace(firstLine)
+		return "go version " + firstLine, fmt.Sprintf("%s reads %q", versionFile, firstLine), nil
 	}
 
-	return "", fmt.Errorf("unable to determine Go version for path: %s", goPath)
+	if _, err := os.Stat(goExec); err == nil {
+		version, err := sandboxedGoVersion(goExec)
+		if err == nil {
+			return version, fmt.Sprintf("%s version printed %q", goExec, version), nil
+		}
+		tracef("version: %v", err)
+	}
+
+	return "", "", fmt.Errorf("unable to determine Go version for path: %s", goPath)
 }
 
 func getDirSize(path string) int64 {
-	var size int64
+	size, _ := dirUsage(path)
+	return size
+}
+
+// dirUsage returns the bytes and number of regular files under path.
+func dirUsage(path string) (size, files int64) {
 	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil
 		}
 		if !info.IsDir() {
 			size += info.Size()
+			files++
 		}
 		return nil
 	})
-	return size
+	return size, files
 }
 
 func getPermissions(path string) (string, error) {
@@ -451,49 +454,24 @@ func getPermissions(path string) (string, error) {
 	return info.Mode().String(), nil
 }
 
-func findGoVersionsCmd() tea.Msg {
-	var goPath string
-	var versions []string
-	switch runtime.GOOS {
-	case "windows":
-		goPath = filepath.Join(os.Getenv("USERPROFILE"), "go")
-		if _, err := os.Stat(goPath); os.IsNotExist(err) {
-			goPath = filepath.Join(os.Getenv("ProgramFiles"), "Go")
-		}
-	case "darwin":
-		goPath = "/usr/local/go"
-		brewGoPath := "/usr/local/Cellar/go"
-		if _, err := os.Stat(brewGoPath); err == nil {
-			goPath = brewGoPath
-		}
-	default:
-		goPath = "/usr/local/go"
-		if _, err := os.Stat("/usr/bin/go"); err == nil {
-			cmd := exec.Command("which", "go")
-			if output, err := cmd.Output(); err == nil {
-				whichPath := strings.TrimSpace(string(output))
-				if strings.HasSuffix(whichPath, "/bin/go") {
-					derivedPath := strings.TrimSuffix(whichPath, "/bin/go")
-
-					if isCriticalPath(derivedPath) {
-						return foundGoVersions{
-							versions: []string{},
-							path:     "",
-							err:      fmt.Errorf("refusing to operate on critical system directory: %s", derivedPath),
-						}
-					}
-
-					if !strings.Contains(strings.ToLower(derivedPath), "go") {
-						return foundGoVersions{
-							versions: []string{},
-							path:     "",
-							err:      fmt.Errorf("derived path does not appear to be a Go installation: %s", derivedPath),
-						}
-					}
+// findGoVersionsCmd runs detection, publishing each detector on events as it
+// finishes so the loading screen can show what is done. The bus is closed
+// when detection is over.
+func findGoVersionsCmd(cfg Config, backupDir string, events *eventBus) tea.Cmd {
+	return func() tea.Msg {
+		defer events.close()
+		return findGoVersions(cfg, backupDir, events)
+	}
+}
 
-					goPath = derivedPath
-				}
-			}
+func findGoVersions(cfg Config, backupDir string, events *eventBus) tea.Msg {
+	var versions []string
+	goPath, err := currentPlatform.defaultGoRoot()
+	if err != nil {
+		return foundGoVersions{
+			versions: []string{},
+			path:     "",
+			err:      err,
 		}
 	}
 
@@ -507,10 +485,8 @@ func findGoVersionsCmd() tea.Msg {
 	}
 
 	if _, err := os.Stat(goPath); err == nil {
-		cmd := exec.Command("go", "version")
-		if output, err := cmd.Output(); err == nil {
-			versionStr := strings.TrimSpace(string(output))
-			versions = append(versions, versionStr)
+		if version, err := pathGoVersion(); err == nil {
+			versions = append(versions, version)
 		}
 		homeDir, err := os.UserHomeDir()
 		if err == nil {
@@ -526,81 +502,83 @@ func findGoVersionsCmd() tea.Msg {
 		}
 	}
 	if len(versions) == 0 {
-		cmd := exec.Command("go", "version")
-		if output, err := cmd.Output(); err == nil {
-			versionStr := strings.TrimSpace(string(output))
-			versions = append(versions, versionStr)
+		if version, err := pathGoVersion(); err == nil {
+			versions = append(versions, version)
 		}
 	}
-	permOk := checkPermissions() == nil
-	installations := detectGoInstallations()
+	installations, results := detectGoInstallationsWithResults(cfg, events)
+	markBlockedInstallations(installations, cfg.AllowCrossMounts)
+	summarizeOwnership(installations)
+	markDuplicates(installations)
+	vulnErr := checkAdvisories(cfg, installations)
+	env := currentGoEnv()
 
 	return foundGoVersions{
-		versions: versions,
-		path:     goPath,
-		installs: installations,
-		permOk:   permOk,
-		err:      nil,
+		versions:  versions,
+		path:      goPath,
+		installs:  installations,
+		detectors: results,
+		vulnErr:   vulnErr,
+		preflight: runPreflight(installations, backupDir),
+		snapshot:  takeSnapshot(installations),
+		goEnv:     env,
+		pins:      findToolchainPins(env),
+		err:       nil,
 	}
 }
 
 type deleteGoCompleted struct {
-	success bool
+	success bool // every item of the plan succeeded
 	err     error
+	results []itemResult
 }
 
 type backupCompleted struct {
 	success bool
 	err     error
-	path    string
+	path    string // the backup set, "" when nothing was archived
+	taken   backupsTaken
 }
 
-func createBackupCmd(installations []GoInstallation, backupDir string) tea.Cmd {
+func createBackupCmd(cfg Config, installations []GoInstallation, backupDir string) tea.Cmd {
 	return func() tea.Msg {
-		for _, install := range installations {
-			if err := createBackup(install.Path, backupDir); err != nil {
-				return backupCompleted{success: false, err: err, path: backupDir}
-			}
-		}
-		return backupCompleted{success: true, err: nil, path: backupDir}
-	}
-}
-
-func deleteGoVersionsCmd(path string) tea.Cmd {
-	return func() tea.Msg {
-		var err error
-
-		tempFile := filepath.Join(path, "fugo-test-file")
-		if err = os.WriteFile(tempFile, []byte("test"), 0644); err != nil {
-			return deleteGoCompleted{success: false, err: fmt.Errorf("no write permission: %v", err)}
-		}
-		os.Remove(tempFile)
-
-		if err = os.RemoveAll(path); err != nil {
-			return deleteGoCompleted{success: false, err: err}
-		}
-
-		homeDir, err := os.UserHomeDir()
-		if err == nil {
-			gvmPath := filepath.Join(homeDir, ".gvm", "gos")
-			if _, err := os.Stat(gvmPath); err == nil {
-				entries, _ := os.ReadDir(gvmPath)
-				for _, entry := range entries {
-					if entry.IsDir() && strings.HasPrefix(entry.Name(), "go") {
-						versionPath := filepath.Join(gvmPath, entry.Name())
-						os.RemoveAll(versionPath)
-					}
-				}
-			}
-		}
-
-		return deleteGoCompleted{success: true, err: nil}
+		taken, err := backupInstallations(cfg, installations, backupDir)
+		return backupCompleted{success: err == nil, err: err, path: taken.set, taken: taken}
 	}
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m = m.noteUpdate(msg)
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		switch m.state {
+		case "startup_warning":
+			return m.handleStartupWarningKey(msg)
+		case "setup":
+			return m.handleSetupKey(msg)
+		case "add_path":
+			return m.handleAddPathKey(msg)
+		case "browse":
+			return m.handleBrowseKey(msg)
+		case "profile_review":
+			return m.handleProfileReviewKey(msg)
+		case "summary":
+			return m.handleSummaryKey(msg)
+		case "review":
+			return m.handleReviewKey(msg)
+		}
+		if m.farewellFrames != nil && msg.String() != "ctrl+c" {
+			m.farewellFrames = nil
+			return m, nil
+		}
+		if m.state == "complete" && len(m.results) > 0 {
+			return m.handleResultsKey(msg)
+		}
+		if m.state == "confirm" && m.inventory.SettingFilter() && msg.String() != "ctrl+c" {
+			var cmd tea.Cmd
+			m.inventory, cmd = m.inventory.Update(msg)
+			return m, cmd
+		}
 		switch msg.String() {
 		case "ctrl+c", "q":
 			if m.logFile != nil {
@@ -610,12 +588,55 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		case "d":
 			if m.state == "confirm" {
+				if lock := liveModeLock(); m.dryRun && lock != "" {
+					if m.logFile != nil {
+						m.logFile.Log("WARN", fmt.Sprintf("Live mode is disabled: %s", lock))
+					}
+					return m, nil
+				}
 				m.dryRun = !m.dryRun
 				if m.logFile != nil {
 					m.logFile.Log("INFO", fmt.Sprintf("Dry run mode: %v", m.dryRun))
 				}
+				return m, m.remoteBackupCmd()
+			}
+		case "tab":
+			if m.state == "confirm" {
+				m.sortBy = (m.sortBy + 1) % len(sortKeyNames)
+				sortInstallations(m.detectedInstalls, m.sortBy)
+				m.setInstallItems()
 				return m, nil
 			}
+		case " ":
+			if m.state == "confirm" {
+				m.toggleSelected()
+				return m.checkOwnership(), nil
+			}
+		case "a", "b", "g", "l", "<", ">":
+			// Only CONFIRM is typed at this step, so these are free
+			if m.state == "confirm" && m.confirmationStep == ConfirmationStepInitial {
+				switch msg.String() {
+				case "l":
+					return m.useLocalBackup()
+				case "<", ">":
+					var cmd tea.Cmd
+					m.inventory, cmd = m.inventory.Update(msg)
+					return m, cmd
+				case "b":
+					return m.startBrowse(browseBackupDir)
+				case "g":
+					return m.toggleGopath()
+				}
+				return m.startBrowse(browseManualPath)
+			}
+		case "up", "down", "pgup", "pgdown", "/", "esc":
+			// The confirmation words never contain these, so they drive the
+			// inventory while the text input keeps focus
+			if m.state == "confirm" {
+				var cmd tea.Cmd
+				m.inventory, cmd = m.inventory.Update(msg)
+				return m, cmd
+			}
 		case "enter":
 			switch m.state {
 			case "confirm":
@@ -636,24 +657,70 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.goVersions = msg.versions
 		m.goInstallPath = msg.path
 		m.detectedInstalls = msg.installs
-		m.permissionCheck = msg.permOk
+		m.preflight = msg.preflight
+		m.snapshotBefore = msg.snapshot
+		m.goEnv = msg.goEnv
+		m.toolchainPins = msg.pins
+		m.detectorResults = msg.detectors
+		sortInstallations(m.detectedInstalls, m.sortBy)
 
 		if m.logFile != nil {
+			for _, result := range msg.detectors {
+				if result.err != nil {
+					m.logFile.Log("WARN", fmt.Sprintf("Detector %s: %v", result.name, result.err))
+				}
+			}
+			if msg.vulnErr != nil {
+				m.logFile.Log("WARN", fmt.Sprintf("Vulnerability check failed, using the embedded CVE table: %v", msg.vulnErr))
+			}
+			for _, name := range goEnvVars {
+				if value, ok := msg.goEnv[name]; ok {
+					m.logFile.Log("INFO", fmt.Sprintf("Go env %s=%s (%s)", name, value.Value, value.Source))
+				}
+			}
+			for _, pin := range msg.pins {
+				m.logFile.Log("INFO", fmt.Sprintf("Toolchain pin go%s in %s", pin.Version, pin.File))
+			}
 			m.logFile.Log("INFO", fmt.Sprintf("Found %d Go installations", len(msg.installs)))
 			for _, install := range msg.installs {
 				m.logFile.Log("INFO", fmt.Sprintf("Installation: %s (%s, %s)", install.Path, install.Version, install.Source))
 			}
+			for _, result := range msg.preflight {
+				if result.Err != nil {
+					m.logFile.Log("WARN", fmt.Sprintf("Preflight %s %s: %v", result.Role, result.Path, result.Err))
+				}
+			}
 		}
 
-		items := []list.Item{}
-		for _, v := range m.goVersions {
-			items = append(items, item{title: v, desc: "Will be removed"})
-		}
-		m.list = list.New(items, list.NewDefaultDelegate(), 80, 20)
-		m.list.Title = "Go Installations to Remove"
+		m.setInstallItems()
 
 		m.state = "confirm"
-		return m, nil
+		if len(m.detectedInstalls) > 0 {
+			m.state = "summary"
+			m.summaryCursor = len(summarySections)
+		}
+		return m, tea.Batch(m.projectScanCmd(), m.systemBackupCmd(), m.remoteBackupCmd(), m.saveInventoryCmd())
+
+	case gopathScanned:
+		return m.handleGopathScanned(msg), nil
+
+	case projectsScanned:
+		return m.handleProjectsScanned(msg), nil
+
+	case systemBackupChecked:
+		return m.handleSystemBackupChecked(msg), nil
+
+	case remoteBackupChecked:
+		return m.handleRemoteBackupChecked(msg), nil
+
+	case inventorySaved:
+		return m.handleInventorySaved(msg), nil
+
+	case engineEventMsg:
+		return m.handleEngineEvent(msg.event), waitForEvent(msg.next)
+
+	case manualPathInspected:
+		return m.addManualInstall(msg), nil
 
 	case backupCompleted:
 		if msg.err != nil {
@@ -661,32 +728,74 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.state = "complete"
 			if m.logFile != nil {
 				m.logFile.Log("ERROR", fmt.Sprintf("Backup failed: %v", msg.err))
+				if isCommandTimeout(msg.err) {
+					m.logFile.Log("ERROR", "The backup command hung and was stopped")
+				}
 			}
 			return m, nil
 		}
 		if m.logFile != nil {
-			m.logFile.Log("SUCCESS", fmt.Sprintf("Backup created at: %s", msg.path))
+			msg.taken.logTo(m.logFile.Log)
 		}
+		m.quarantined = msg.taken.moved
+		m = m.recordPhase(true)
+		m.phaseStarted = time.Now()
 		m.state = "deleting"
 		return m, tea.Batch(
 			m.spinner.Tick,
-			deleteGoVersionsCmd(m.goInstallPath),
+			m.deleteCmd(),
 		)
 
 	case deleteGoCompleted:
 		m.state = "complete"
 		m.deletionComplete = msg.success
 		m.err = msg.err
+		m.setResults(msg.results)
+		m.logResults(msg.results)
 		if m.logFile != nil {
 			if msg.success {
 				m.logFile.Log("SUCCESS", "Go uninstallation completed successfully")
 			} else {
 				m.logFile.Log("ERROR", fmt.Sprintf("Go uninstallation failed: %v", msg.err))
 			}
+		}
+		// What was removed still leaves profile lines behind, even when
+		// other items failed
+		if anyRemoved(msg.results) {
+			m = m.recordPhase(false)
+			m = m.forgetReceipt(runtime.GOOS)
+			m = m.forgetDocs(msg.results)
+			home, _ := os.UserHomeDir()
+			return m.startProfileReview(runtime.GOOS, home)
+		}
+		return m, m.snapshotCmd()
+
+	case snapshotTaken:
+		m.snapshotDiff = diffSnapshots(m.snapshotBefore, msg.snapshot)
+		if m.logFile != nil {
+			if len(m.snapshotDiff) == 0 {
+				m.logFile.Log("INFO", "System state unchanged")
+			}
+			for _, change := range m.snapshotDiff {
+				m.logFile.Log("INFO", "State change: "+change)
+			}
+		}
+		m = m.saveReport()
+		// Retries from the results table are logged too; quitting closes it
+		if m.logFile != nil && len(m.results) == 0 {
 			m.logFile.Close()
 		}
+		if m.deletionComplete {
+			return m.startFarewell()
+		}
 		return m, nil
 
+	case farewellTick:
+		return m.handleFarewellTick()
+
+	case retryCompleted:
+		return m.handleRetryCompleted(msg)
+
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
@@ -695,17 +804,30 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		if m.list.Items() != nil {
-			top, right, bottom, left := lipgloss.NewStyle().Margin(2).GetMargin()
-			m.list.SetSize(msg.Width-left-right, msg.Height-top-bottom-10)
+		_, right, _, left := lipgloss.NewStyle().Margin(2).GetMargin()
+		m.inventory.SetWidth(msg.Width - left - right)
+		m.profileView.Width = msg.Width - 4
+		m.profileView.Height = max(msg.Height-20, 8)
+		if len(m.results) > 0 {
+			m.setResults(m.results)
 		}
 	}
 
-	if m.state == "confirm" {
+	if m.state == "confirm" || m.state == "review" {
 		var cmd tea.Cmd
 		m.textInput, cmd = m.textInput.Update(msg)
 		return m, cmd
 	}
+	if m.state == "add_path" {
+		var cmd tea.Cmd
+		m.pathInput, cmd = m.pathInput.Update(msg)
+		return m, cmd
+	}
+	if m.state == "browse" {
+		var cmd tea.Cmd
+		m.browser, cmd, _ = m.browser.Update(msg)
+		return m, cmd
+	}
 
 	return m, nil
 }
@@ -714,8 +836,30 @@ func (m model) handleConfirmation() (tea.Model, tea.Cmd) {
 	input := strings.TrimSpace(m.textInput.Value())
 
 	switch m.confirmationStep {
+	case ConfirmationStepOwnership:
+		if strings.ToUpper(input) == "ACKNOWLEDGE" {
+			m.ackedOwners = m.foreignOwners
+			m.confirmationStep = ConfirmationStepInitial
+			m.textInput.SetValue("")
+			m.textInput.Placeholder = "Type 'CONFIRM' to proceed"
+			if m.logFile != nil {
+				m.logFile.Log("WARN", fmt.Sprintf("User acknowledged removing files owned by: %s", strings.Join(m.foreignOwners, ", ")))
+			}
+			return m, nil
+		}
 	case ConfirmationStepInitial:
+		if len(m.selectedInstalls()) == 0 {
+			m.textInput.SetValue("")
+			m.textInput.Placeholder = "Select at least one installation"
+			return m, nil
+		}
 		if strings.ToUpper(input) == "CONFIRM" {
+			if !m.needsFullConfirmation() {
+				if m.logFile != nil {
+					m.logFile.Log("INFO", "No high-risk items selected, confirmed with CONFIRM alone")
+				}
+				return m.startReview(ConfirmationStepReview), nil
+			}
 			m.confirmationStep = ConfirmationStepHash
 			m.textInput.SetValue("")
 			m.textInput.Placeholder = fmt.Sprintf("Type hash: %s", m.hashConfirmation)
@@ -726,191 +870,108 @@ func (m model) handleConfirmation() (tea.Model, tea.Cmd) {
 		}
 	case ConfirmationStepHash:
 		if input == m.hashConfirmation {
-			m.confirmationStep = ConfirmationStepDestroy
+			m = m.startReview(ConfirmationStepDestroy)
 			m.textInput.SetValue("")
-			m.textInput.Placeholder = "Type 'DESTROY' to proceed"
+			challenge := m.config.finalChallenge()
+			m.textInput.Placeholder = challenge.prompt()
+			if challenge.masked() {
+				m.textInput.EchoMode = textinput.EchoPassword
+				m.textInput.CharLimit = 256
+			}
 			if m.logFile != nil {
 				m.logFile.Log("INFO", "Second confirmation step passed")
 			}
 			return m, nil
 		}
 	case ConfirmationStepDestroy:
-		if strings.ToUpper(input) == "DESTROY" {
+		if err := m.config.finalChallenge().verify(input, time.Now()); err != nil {
 			if m.logFile != nil {
-				m.logFile.Log("INFO", "All confirmation steps passed, proceeding with operation")
-			}
-			if m.dryRun {
-				m.state = "dry_run_complete"
-				return m, nil
-			} else {
-				m.state = "creating_backup"
-				return m, tea.Batch(
-					m.spinner.Tick,
-					createBackupCmd(m.detectedInstalls, m.backupPath),
-				)
+				m.logFile.Log("WARN", fmt.Sprintf("Final confirmation failed: %v", err))
 			}
+			break
+		}
+		if m.logFile != nil {
+			m.logFile.Log("INFO", "All confirmation steps passed, proceeding with operation")
 		}
+		return m.proceed()
+	case ConfirmationStepReview:
+		if m.logFile != nil {
+			m.logFile.Log("INFO", "Operations reviewed, proceeding with operation")
+		}
+		return m.proceed()
 	}
 
 	return m, tea.Quit
 }
 
-func renderFuGoLogo(width int) string {
-	lines := strings.Split(fugoASCII, "\n")
-	coloredLines := make([]string, len(lines))
-
-	for i, line := range lines {
-		if len(line) == 0 {
-			coloredLines[i] = line
-			continue
-		}
-
-		var coloredLine strings.Builder
-		for j, char := range line {
-			colorIndex := j % len(logoGradient)
-			style := lipgloss.NewStyle().Foreground(lipgloss.Color(logoGradient[colorIndex]))
-			coloredLine.WriteString(style.Render(string(char)))
+// proceed starts the confirmed plan: a dry-run summary, or the backup and
+// removal.
+func (m model) proceed() (tea.Model, tea.Cmd) {
+	if m.dryRun {
+		m.state = "dry_run_complete"
+		return m.saveReport(), nil
+	}
+	if !m.config.backupBeforeRemoval() {
+		if m.logFile != nil {
+			m.logFile.Log("WARN", "Skipping backup as configured by backup_policy")
 		}
-		coloredLines[i] = coloredLine.String()
+		m.state = "deleting"
+		m.phaseStarted = time.Now()
+		return m, tea.Batch(
+			m.spinner.Tick,
+			m.deleteCmd(),
+		)
 	}
+	m.state = "creating_backup"
+	m.phaseStarted = time.Now()
+	return m, tea.Batch(
+		m.spinner.Tick,
+		m.backupCmd(),
+	)
+}
 
-	logo := strings.Join(coloredLines, "\n")
-	styledLogo := bigTitleStyle.Render(logo)
-
-	if width > 0 {
-		styledLogo = lipgloss.PlaceHorizontal(width, lipgloss.Center, styledLogo)
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		var exit exitCodeError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-
-	return styledLogo
 }
 
-func (m model) View() string {
-	var s string
-
-	s = renderFuGoLogo(m.width) + "\n"
-
-	s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, subtitleStyle.Render("The Go Uninstaller - Enhanced Security Edition")) + "\n\n"
-
-	switch m.state {
-	case "loading":
-		loadingMsg := fmt.Sprintf("%s Detecting Go installations...", m.spinner.View())
-		s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, loadingMsg) + "\n"
-
-	case "confirm":
-		if len(m.detectedInstalls) == 0 {
-			s += warningStyle.Render("No Go installations found!") + "\n"
-			s += "If you believe Go is installed but not detected, please run this tool with admin/sudo privileges.\n"
-			s += "\nPress q to quit."
-			return s
-		}
-
-		s += highlightStyle.Render(fmt.Sprintf("🔍 Detected %d Go installation(s):", len(m.detectedInstalls))) + "\n\n"
-		for _, install := range m.detectedInstalls {
-			sizeStr := fmt.Sprintf("%.1f MB", float64(install.Size)/(1024*1024))
-			s += fmt.Sprintf("  %s %s\n",
-				lipgloss.NewStyle().Foreground(lipgloss.Color("#FFCB6B")).Render("📦"),
-				install.Version)
-			s += fmt.Sprintf("     📍 Path: %s\n", install.Path)
-			s += fmt.Sprintf("     🔧 Source: %s | 💾 Size: %s\n", install.Source, sizeStr)
-			s += fmt.Sprintf("     🔐 Permissions: %s\n\n", install.Permissions)
-		}
-
-		// Security status
-		if !m.permissionCheck {
-			s += warningStyle.Render("⚠️  WARNING: Insufficient permissions detected!") + "\n"
-			s += infoStyle.Render("   Run with sudo/admin privileges for complete removal") + "\n\n"
-		} else {
-			s += successStyle.Render("✅ Permissions check passed") + "\n\n"
-		}
-
-		// Dry run status
-		if m.dryRun {
-			s += highlightStyle.Render("🔍 DRY RUN MODE ENABLED - No files will be deleted") + "\n"
-		} else {
-			s += warningStyle.Render("🔥 LIVE MODE - Files WILL be permanently deleted!") + "\n"
-		}
-
-		s += "\n" + warningStyle.Render("⚠️  CRITICAL WARNING: This will delete ALL Go installations from your system!") + "\n"
-		s += infoStyle.Render(fmt.Sprintf("📂 Backup location: %s", m.backupPath)) + "\n\n"
-
-		// Confirmation steps
-		switch m.confirmationStep {
-		case ConfirmationStepInitial:
-			s += "Step 1/3: " + m.textInput.View() + "\n"
-		case ConfirmationStepHash:
-			s += "Step 2/3: " + m.textInput.View() + "\n"
-		case ConfirmationStepDestroy:
-			s += "Step 3/3: " + m.textInput.View() + "\n"
-		}
-
-		s += "\n" + confirmButtonStyle.Render("ENTER") + " to continue, " + cancelButtonStyle.Render("d") + " toggle dry-run, " + cancelButtonStyle.Render("q") + " to quit\n"
-
-	case "creating_backup":
-		backupMsg := fmt.Sprintf("%s Creating safety backup...", m.spinner.View())
-		s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, backupMsg) + "\n"
-
-	case "deleting":
-		deletingMsg := fmt.Sprintf("%s Removing Go installations...", m.spinner.View())
-		s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, deletingMsg) + "\n"
-
-	case "dry_run_complete":
-		dryMsg := successStyle.Render("🔍 DRY RUN COMPLETED")
-		s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, dryMsg) + "\n\n"
-		s += "The following operations would be performed:\n\n"
-		for _, install := range m.detectedInstalls {
-			s += fmt.Sprintf("  ❌ Remove: %s (%s)\n", install.Path, install.Source)
-		}
-		s += "\n" + infoStyle.Render("No files were actually deleted in dry-run mode") + "\n"
-		s += "\nPress ENTER or Q to exit\n"
-
-	case "complete":
-		if m.err != nil {
-			errorMsg := warningStyle.Render("❌ Error: " + m.err.Error())
-			s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, errorMsg) + "\n"
-			s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, "You may need to run this tool with admin/sudo privileges.") + "\n"
-			s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, fmt.Sprintf("💾 Backup available at: %s", m.backupPath)) + "\n"
-		} else if m.deletionComplete {
-			successMsg := successStyle.Render("✨ Success! All Go installations have been removed. ✨")
-			confirmMsg := warningStyle.Render("Enjoy loneliness")
-			backupMsg := infoStyle.Render(fmt.Sprintf("💾 Backup created at: %s", m.backupPath))
-
-			successBox := lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(lipgloss.Color("#C3E88D")).
-				Padding(1).
-				Render(successMsg + "\n\n" + confirmMsg + "\n\n" + backupMsg)
-
-			s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, successBox) + "\n\n"
-			s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, "📋 Check logs at ~/.fugo/ for detailed information") + "\n"
-			s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, "🔧 You may need to clean up your PATH environment variable manually.") + "\n"
-			s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, "Press ENTER or Q to exit") + "\n"
-		}
-	}
-
-	if m.err != nil && m.state != "complete" {
-		s += warningStyle.Render("Error: "+m.err.Error()) + "\n"
-	}
-
-	return s
-}
+// runTUI launches the interactive uninstaller.
+func runTUI(opts runOptions) (err error) {
+	initial := initialModel(opts)
+	var start tea.Model = initial
+	if opts.record != "" {
+		recorder, err := newSessionRecorder(opts.record)
+		if err != nil {
+			return err
+		}
+		defer recorder.Close()
+		start = recordingModel{model: start, recorder: recorder}
+	}
 
-func main() {
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
+	crash := &crashContext{last: start}
+	p := tea.NewProgram(crashGuard{model: start, ctx: crash}, tea.WithAltScreen(), tea.WithoutCatchPanics())
+	defer func() {
+		if r := recover(); r != nil {
+			p.ReleaseTerminal()
+			err = handleCrash(r, crash, initial.paths.Crashes)
+		}
+	}()
 	teaModel, err := p.Run()
 
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error running application: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("running application: %v", err)
 	}
 
-	m, ok := teaModel.(model)
+	m, ok := innerModel(teaModel).(model)
 	if !ok {
-		fmt.Fprintf(os.Stderr, "Error: unexpected model type\n")
-		os.Exit(1)
+		return fmt.Errorf("unexpected model type")
 	}
 
-	if m.err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", m.err)
-		os.Exit(1)
-	}
+	return m.err
 }
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
-	"runtime"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestIsCriticalPath(t *testing.T) {
@@ -50,17 +53,8 @@ func TestGenerateSecurityHash(t *testing.T) {
 	}
 }
 
-func TestCheckPermissions(t *testing.T) {
-	// Test permission checking (this will vary by system)
-	err := checkPermissions()
-	// Should not panic or crash
-	if err != nil && runtime.GOOS != "windows" {
-		t.Logf("Permission check failed (expected on non-root): %v", err)
-	}
-}
-
 func TestDetectGoInstallations(t *testing.T) {
-	installations := detectGoInstallations()
+	installations := detectGoInstallations(defaultConfig())
 
 	// Should return a slice (may be empty)
 	if installations == nil {
@@ -121,37 +115,60 @@ func TestGetPermissions(t *testing.T) {
 }
 
 func TestCreateBackup(t *testing.T) {
-	// Test backup creation with temporary directory
-	tempDir := t.TempDir()
-	sourceDir := filepath.Join(tempDir, "source")
-	backupDir := filepath.Join(tempDir, "backup")
-
-	// Create source directory and file
-	err := os.MkdirAll(sourceDir, 0755)
-	if err != nil {
-		t.Fatalf("Failed to create source directory: %v", err)
+	source := filepath.Join(t.TempDir(), "go")
+	if err := os.MkdirAll(filepath.Join(source, "bin"), 0755); err != nil {
+		t.Fatalf("Failed to create bin: %v", err)
 	}
-
-	testFile := filepath.Join(sourceDir, "test.txt")
-	err = os.WriteFile(testFile, []byte("test content"), 0644)
-	if err != nil {
-		t.Fatalf("Failed to create test file: %v", err)
+	if err := os.MkdirAll(filepath.Join(source, "lib"), 0555); err != nil {
+		t.Fatalf("Failed to create lib: %v", err)
+	}
+	defer os.Chmod(filepath.Join(source, "lib"), 0755)
+	if err := os.WriteFile(filepath.Join(source, "bin", "go"), []byte("go"), 0755); err != nil {
+		t.Fatalf("Failed to write bin/go: %v", err)
+	}
+	if err := os.Chmod(filepath.Join(source, "bin", "go"), 0755|fs.ModeSetuid); err != nil {
+		t.Fatalf("Failed to set the setuid bit: %v", err)
+	}
+	if err := os.Link(filepath.Join(source, "bin", "go"), filepath.Join(source, "bin", "go.link")); err != nil {
+		t.Fatalf("Failed to create the hard link: %v", err)
+	}
+	if err := os.Symlink("bin/go", filepath.Join(source, "go")); err != nil {
+		t.Fatalf("Failed to create the symlink: %v", err)
+	}
+	if err := os.Chmod(filepath.Join(source, "bin"), 0755|fs.ModeSetgid); err != nil {
+		t.Fatalf("Failed to set the setgid bit: %v", err)
+	}
+	mtime := time.Date(2024, 6, 4, 17, 0, 0, 0, time.UTC)
+	for _, path := range []string{"bin/go", "bin", "lib", "."} {
+		if err := os.Chtimes(filepath.Join(source, path), mtime, mtime); err != nil {
+			t.Fatalf("Failed to set the time of %s: %v", path, err)
+		}
+	}
+	if err := lchtimes(filepath.Join(source, "go"), mtime); err != nil {
+		t.Fatalf("Failed to set the time of the symlink: %v", err)
 	}
 
-	err = os.MkdirAll(backupDir, 0755)
-	if err != nil {
-		t.Fatalf("Failed to create backup directory: %v", err)
+	archive := filepath.Join(t.TempDir(), "go.tar.gz")
+	if err := archiveTree(source, archive); err != nil {
+		t.Fatalf("archiveTree returned error: %v", err)
+	}
+	staging := t.TempDir()
+	if err := extractArchive(archive, staging, nil); err != nil {
+		t.Fatalf("extractArchive returned error: %v", err)
+	}
+	defer os.Chmod(filepath.Join(staging, "go", "lib"), 0755)
+	if before, after := treeMetadata(t, source), treeMetadata(t, filepath.Join(staging, "go")); !slices.Equal(after, before) {
+		t.Errorf("Expected an identical tree.\nbacked up: %q\nrestored:  %q", before, after)
 	}
 
-	// Test backup creation
-	err = createBackup(sourceDir, backupDir)
-	if err != nil {
-		t.Logf("Backup creation failed (may be expected if tar not available): %v", err)
+	if err := archiveTree(filepath.Join(source, "missing"), archive+".missing"); err != nil {
+		t.Errorf("Expected a tree already gone to be skipped, got %v", err)
 	}
 }
 
 func TestNewLogger(t *testing.T) {
-	logger, err := NewLogger()
+	logDir := filepath.Join(t.TempDir(), "logs")
+	logger, err := NewLogger(logDir)
 	if err != nil {
 		t.Fatalf("Failed to create logger: %v", err)
 	}
@@ -165,11 +182,12 @@ func TestNewLogger(t *testing.T) {
 	logger.Log("TEST", "This is a test message")
 
 	// Verify log file exists
-	homeDir, _ := os.UserHomeDir()
-	logDir := filepath.Join(homeDir, ".fugo")
 	if _, err := os.Stat(logDir); os.IsNotExist(err) {
 		t.Error("Log directory was not created")
 	}
+	if logger.Dir() != logDir {
+		t.Errorf("logger.Dir() = %s, expected %s", logger.Dir(), logDir)
+	}
 }
 
 func TestGoInstallationStruct(t *testing.T) {
@@ -205,7 +223,7 @@ func TestGoInstallationStruct(t *testing.T) {
 // Benchmark tests for performance-critical functions
 func BenchmarkDetectGoInstallations(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		detectGoInstallations()
+		detectGoInstallations(defaultConfig())
 	}
 }
 
@@ -223,3 +241,39 @@ func BenchmarkIsCriticalPath(b *testing.B) {
 		}
 	}
 }
+
+// FuzzIsCriticalPath checks that no spelling of a critical directory gets
+// past the guard: every path that normalizes to one is critical, however it
+// is written.
+func FuzzIsCriticalPath(f *testing.F) {
+	for _, critical := range criticalPaths {
+		f.Add(critical)
+		f.Add(critical + string(filepath.Separator))
+		f.Add(filepath.Join(critical, "go", ".."))
+	}
+	f.Add("/usr/local/go")
+	f.Add("//usr//./bin/")
+	f.Add("relative/../usr")
+	f.Fuzz(func(t *testing.T, path string) {
+		critical := isCriticalPath(path)
+		normalized := normalizePath(path)
+		if normalizePath(normalized) != normalized {
+			t.Errorf("normalizePath(%q) is not idempotent: %q", path, normalized)
+		}
+		if isCriticalPath(normalized) != critical {
+			t.Errorf("isCriticalPath(%q) = %v but the normalized %q disagrees", path, critical, normalized)
+		}
+		if !critical {
+			return
+		}
+		sep := string(filepath.Separator)
+		for _, variant := range []string{path + sep, path + sep + ".", path + sep + sep, filepath.Join(path, "x", "..")} {
+			if !isCriticalPath(variant) {
+				t.Errorf("%q is critical but %q is not", path, variant)
+			}
+		}
+		if currentPlatform.foldsCase() && (!isCriticalPath(strings.ToUpper(path)) || !isCriticalPath(strings.ToLower(path))) {
+			t.Errorf("%q is critical but a different case of it is not", path)
+		}
+	})
+}
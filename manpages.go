@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// fu-go --install-manpages writes a man page and bash, zsh and fish
+// completions where the shells and man look for them. The man page is
+// generated from the command tree of the running binary, so it always
+// matches its flags. The files written are recorded in the state directory
+// and removed again with --uninstall-manpages, or when fu-go removes the
+// directory it runs from.
+
+// docFile is one generated file and where it goes.
+type docFile struct {
+	Kind string // man, bash, zsh or fish
+	Path string
+}
+
+const docsManifestName = "installed-docs.json"
+
+// docLocations is where the man page and completions go on goos. With a
+// prefix, or as root without one, they go under prefix/share the way a
+// package would install them; otherwise into the user's XDG data and config
+// directories.
+func docLocations(goos string, getenv func(string) string, home, prefix string, elevated bool) ([]docFile, error) {
+	if goos == "windows" {
+		return nil, fmt.Errorf("man pages are not used on Windows; load completions with `fu-go completion powershell`")
+	}
+	if prefix == "" && elevated {
+		prefix = "/usr/local"
+	}
+	if prefix != "" {
+		share := filepath.Join(prefix, "share")
+		return []docFile{
+			{"man", filepath.Join(share, "man", "man1", "fu-go.1")},
+			{"bash", filepath.Join(share, "bash-completion", "completions", "fu-go")},
+			{"zsh", filepath.Join(share, "zsh", "site-functions", "_fu-go")},
+			{"fish", filepath.Join(share, "fish", "vendor_completions.d", "fu-go.fish")},
+		}, nil
+	}
+	xdg := func(envVar string, fallback ...string) string {
+		if dir := getenv(envVar); filepath.IsAbs(dir) {
+			return dir
+		}
+		return filepath.Join(append([]string{home}, fallback...)...)
+	}
+	data, config := xdg("XDG_DATA_HOME", ".local", "share"), xdg("XDG_CONFIG_HOME", ".config")
+	return []docFile{
+		{"man", filepath.Join(data, "man", "man1", "fu-go.1")},
+		{"bash", filepath.Join(data, "bash-completion", "completions", "fu-go")},
+		{"zsh", filepath.Join(data, "zsh", "site-functions", "_fu-go")},
+		{"fish", filepath.Join(config, "fish", "completions", "fu-go.fish")},
+	}, nil
+}
+
+// renderDoc generates the file of kind for root.
+func renderDoc(root *cobra.Command, kind string, w io.Writer) error {
+	switch kind {
+	case "man":
+		_, err := io.WriteString(w, manPage(root, time.Now()))
+		return err
+	case "bash":
+		return root.GenBashCompletionV2(w, true)
+	case "zsh":
+		return root.GenZshCompletion(w)
+	case "fish":
+		return root.GenFishCompletion(w, true)
+	}
+	return fmt.Errorf("unknown documentation kind %q", kind)
+}
+
+// roffEscape makes text safe to put on a roff line.
+func roffEscape(text string) string {
+	text = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(text)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// manPage is the fu-go(1) man page for root and its visible subcommands.
+func manPage(root *cobra.Command, date time.Time) string {
+	var b strings.Builder
+	name := root.Name()
+	fmt.Fprintf(&b, ".TH %s 1 \"%s\" \"%s\" \"User Commands\"\n", strings.ToUpper(name), date.Format("January 2006"), name)
+	fmt.Fprintf(&b, ".SH NAME\n%s \\- %s\n", name, roffEscape(root.Short))
+	fmt.Fprintf(&b, ".SH SYNOPSIS\n.B %s\n[\\fIflags\\fR]\n.br\n.B %s\n\\fIcommand\\fR [\\fIflags\\fR]\n", name, name)
+	fmt.Fprintf(&b, ".SH DESCRIPTION\n%s\n", strings.ReplaceAll(roffEscape(root.Long), "\n", "\n.br\n"))
+	writeManFlags(&b, "OPTIONS", root.LocalNonPersistentFlags().FlagUsages())
+	b.WriteString(".SH COMMANDS\n")
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		for _, sub := range cmd.Commands() {
+			if !sub.IsAvailableCommand() || sub.IsAdditionalHelpTopicCommand() {
+				continue
+			}
+			fmt.Fprintf(&b, ".SS \"%s\"\n", roffEscape(sub.UseLine()))
+			text := sub.Long
+			if text == "" {
+				text = sub.Short
+			}
+			fmt.Fprintf(&b, "%s\n", roffEscape(strings.ReplaceAll(text, "\n", " ")))
+			if usages := sub.LocalNonPersistentFlags().FlagUsages(); usages != "" {
+				fmt.Fprintf(&b, ".PP\n.nf\n%s.fi\n", roffEscape(usages))
+			}
+			walk(sub)
+		}
+	}
+	walk(root)
+	writeManFlags(&b, "GLOBAL OPTIONS", root.PersistentFlags().FlagUsages())
+	b.WriteString(".SH FILES\n.TP\n.I $XDG_CONFIG_HOME/fugo/config.json\nConfiguration; plugins live next to it.\n.TP\n.I $XDG_STATE_HOME/fugo\nLogs, reports and backups.\n.PP\nmacOS and Windows use their platform directories instead; see the README.\n")
+	return b.String()
+}
+
+func writeManFlags(b *strings.Builder, section, usages string) {
+	if usages == "" {
+		return
+	}
+	fmt.Fprintf(b, ".SH %s\n.nf\n%s.fi\n", section, roffEscape(usages))
+}
+
+// installDocs writes every file in files and records them in manifest.
+func installDocs(root *cobra.Command, files []docFile, manifest string) ([]string, error) {
+	var installed []string
+	for _, file := range files {
+		var buf bytes.Buffer
+		if err := renderDoc(root, file.Kind, &buf); err != nil {
+			return installed, err
+		}
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+			return installed, fmt.Errorf("failed to create %s: %w", filepath.Dir(file.Path), err)
+		}
+		if err := os.WriteFile(file.Path, buf.Bytes(), 0644); err != nil {
+			return installed, fmt.Errorf("failed to write %s: %w", file.Path, err)
+		}
+		installed = append(installed, file.Path)
+	}
+	previous, _ := readDocsManifest(manifest)
+	data, err := json.MarshalIndent(mergePaths(previous, installed), "", "  ")
+	if err != nil {
+		return installed, err
+	}
+	if err := os.MkdirAll(filepath.Dir(manifest), 0755); err != nil {
+		return installed, err
+	}
+	return installed, os.WriteFile(manifest, data, 0644)
+}
+
+// mergePaths is a followed by the entries of b it does not already hold.
+func mergePaths(a, b []string) []string {
+	merged := append([]string(nil), a...)
+	for _, path := range b {
+		if !slices.Contains(merged, path) {
+			merged = append(merged, path)
+		}
+	}
+	return merged
+}
+
+func readDocsManifest(manifest string) ([]string, error) {
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", manifest, err)
+	}
+	return paths, nil
+}
+
+// uninstallDocs removes the files recorded in manifest, then the manifest.
+// Files that are already gone are not an error.
+func uninstallDocs(manifest string) ([]string, error) {
+	paths, err := readDocsManifest(manifest)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var removed, failed []string
+	for _, path := range paths {
+		switch err := os.Remove(path); {
+		case err == nil:
+			removed = append(removed, path)
+		case !errors.Is(err, os.ErrNotExist):
+			failed = append(failed, fmt.Sprintf("%s: %v", path, err))
+		}
+	}
+	if len(failed) > 0 {
+		return removed, fmt.Errorf("could not remove %s", strings.Join(failed, "; "))
+	}
+	return removed, os.Remove(manifest)
+}
+
+func docsManifestPath() (string, error) {
+	dirs, err := resolveDirs()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dirs.State, docsManifestName), nil
+}
+
+// runInstallDocs is --install-manpages.
+func runInstallDocs(root *cobra.Command, prefix string, w io.Writer) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %v", err)
+	}
+	files, err := docLocations(runtime.GOOS, os.Getenv, home, prefix, isElevated())
+	if err != nil {
+		return err
+	}
+	manifest, err := docsManifestPath()
+	if err != nil {
+		return err
+	}
+	installed, err := installDocs(root, files, manifest)
+	for _, path := range installed {
+		fmt.Fprintf(w, "Installed %s\n", path)
+	}
+	return err
+}
+
+// runUninstallDocs is --uninstall-manpages.
+func runUninstallDocs(w io.Writer) error {
+	manifest, err := docsManifestPath()
+	if err != nil {
+		return err
+	}
+	removed, err := uninstallDocs(manifest)
+	for _, path := range removed {
+		fmt.Fprintf(w, "Removed %s\n", path)
+	}
+	return err
+}
+
+// removedSelf reports whether one of results removed the directory exe runs
+// from, which uninstalls fu-go along with it.
+func removedSelf(exe string, results []itemResult) bool {
+	if exe == "" {
+		return false
+	}
+	for _, result := range results {
+		if _, ok := result.item.(installationItem); ok && result.ok() && pathWithin(exe, result.item.Target()) {
+			return true
+		}
+	}
+	return false
+}
+
+// forgetDocs removes the installed man page and completions once fu-go has
+// removed itself.
+func (m model) forgetDocs(results []itemResult) model {
+	if m.opts.simulate || !removedSelf(m.selfExe, results) {
+		return m
+	}
+	manifest, err := docsManifestPath()
+	if err != nil {
+		return m
+	}
+	removed, err := uninstallDocs(manifest)
+	if m.logFile != nil {
+		for _, path := range removed {
+			m.logFile.Log("INFO", "Removed fu-go documentation "+path)
+		}
+		if err != nil {
+			m.logFile.Log("WARN", fmt.Sprintf("Could not remove fu-go's man page and completions: %v", err))
+		}
+	}
+	return m
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDocLocations(t *testing.T) {
+	getenv := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	files, err := docLocations("linux", getenv(nil), "/home/gopher", "", false)
+	if err != nil {
+		t.Fatalf("docLocations returned error: %v", err)
+	}
+	want := map[string]string{
+		"man":  "/home/gopher/.local/share/man/man1/fu-go.1",
+		"bash": "/home/gopher/.local/share/bash-completion/completions/fu-go",
+		"zsh":  "/home/gopher/.local/share/zsh/site-functions/_fu-go",
+		"fish": "/home/gopher/.config/fish/completions/fu-go.fish",
+	}
+	for _, file := range files {
+		if file.Path != filepath.FromSlash(want[file.Kind]) {
+			t.Errorf("%s: got %s, want %s", file.Kind, file.Path, want[file.Kind])
+		}
+	}
+
+	files, _ = docLocations("linux", getenv(map[string]string{"XDG_DATA_HOME": "/data"}), "/home/gopher", "", false)
+	if files[0].Path != filepath.FromSlash("/data/man/man1/fu-go.1") {
+		t.Errorf("Expected XDG_DATA_HOME to be honoured, got %s", files[0].Path)
+	}
+	files, _ = docLocations("darwin", getenv(nil), "/Users/gopher", "", true)
+	if files[0].Path != filepath.FromSlash("/usr/local/share/man/man1/fu-go.1") {
+		t.Errorf("Expected root to install under /usr/local, got %s", files[0].Path)
+	}
+	files, _ = docLocations("darwin", getenv(nil), "/Users/gopher", "/opt/homebrew/Cellar/fu-go/HEAD", false)
+	if files[3].Path != filepath.FromSlash("/opt/homebrew/Cellar/fu-go/HEAD/share/fish/vendor_completions.d/fu-go.fish") {
+		t.Errorf("Expected the prefix to be used, got %s", files[3].Path)
+	}
+	if _, err := docLocations("windows", getenv(nil), `C:\Users\gopher`, "", false); err == nil {
+		t.Errorf("Expected Windows to be refused")
+	}
+}
+
+func TestManPage(t *testing.T) {
+	page := manPage(newRootCmd(), time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	for _, want := range []string{
+		".TH FU-GO 1 \"October 2026\"",
+		"fu-go \\- The Go uninstaller",
+		".SS \"fu\\-go clean\\-cache [flags]\"",
+		"\\-\\-install\\-manpages",
+		".SH GLOBAL OPTIONS",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected the man page to contain %q", want)
+		}
+	}
+	if strings.Contains(page, "exec\\-elevated") {
+		t.Errorf("Expected hidden commands to be left out")
+	}
+	for _, line := range strings.Split(page, "\n") {
+		if strings.HasPrefix(line, "'") {
+			t.Errorf("Unescaped control line %q", line)
+		}
+	}
+}
+
+func TestInstallDocs(t *testing.T) {
+	dir := t.TempDir()
+	files, _ := docLocations("linux", func(string) string { return "" }, dir, filepath.Join(dir, "prefix"), false)
+	manifest := filepath.Join(dir, "state", docsManifestName)
+
+	installed, err := installDocs(newRootCmd(), files, manifest)
+	if err != nil {
+		t.Fatalf("installDocs returned error: %v", err)
+	}
+	if len(installed) != 4 {
+		t.Fatalf("Expected 4 files installed, got %v", installed)
+	}
+	if data, _ := os.ReadFile(files[1].Path); !strings.Contains(string(data), "bash completion") {
+		t.Errorf("Expected a bash completion script in %s", files[1].Path)
+	}
+	// Installing again does not record the files twice
+	installDocs(newRootCmd(), files, manifest)
+	if paths, _ := readDocsManifest(manifest); len(paths) != 4 {
+		t.Errorf("Expected 4 recorded files, got %v", paths)
+	}
+
+	os.Remove(files[2].Path)
+	removed, err := uninstallDocs(manifest)
+	if err != nil {
+		t.Fatalf("uninstallDocs returned error: %v", err)
+	}
+	if len(removed) != 3 {
+		t.Errorf("Expected the 3 remaining files removed, got %v", removed)
+	}
+	if _, err := os.Stat(manifest); !os.IsNotExist(err) {
+		t.Errorf("Expected the manifest to be removed")
+	}
+	if removed, err := uninstallDocs(manifest); err != nil || len(removed) != 0 {
+		t.Errorf("Expected nothing to do without a manifest, got %v, %v", removed, err)
+	}
+}
+
+func TestRemovedSelf(t *testing.T) {
+	install := installationItem{install: GoInstallation{Path: "/usr/local/go"}}
+	exe := "/usr/local/go/bin/fu-go"
+	if !removedSelf(exe, []itemResult{{item: install}}) {
+		t.Errorf("Expected removing the root fu-go runs from to count")
+	}
+	if removedSelf(exe, []itemResult{{item: install, skipped: "blocked"}}) {
+		t.Errorf("Expected a skipped root not to count")
+	}
+	if removedSelf("/home/gopher/go/bin/fu-go", []itemResult{{item: install}}) {
+		t.Errorf("Expected another root not to count")
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// When detection misses a custom prefix the user can press "a" on the
+// confirm screen and browse to, or type, the Go root. The path gets the
+// same checks as a detected one and joins the plan as source "manual".
+
+type manualPathInspected struct {
+	install GoInstallation
+	err     error
+}
+
+// expandHome turns a leading ~ into the home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// inspectManualPath validates a hand-entered Go root and describes it like a
+// detected one.
+func inspectManualPath(input string, existing []GoInstallation, allowCrossMounts bool) (GoInstallation, error) {
+	path := expandHome(strings.TrimSpace(input))
+	if !filepath.IsAbs(path) {
+		return GoInstallation{}, fmt.Errorf("%s is not an absolute path", input)
+	}
+	path = filepath.Clean(path)
+	if isCriticalPath(path) {
+		return GoInstallation{}, fmt.Errorf("refusing to remove critical system path %s", path)
+	}
+	if err := scopeError(path); err != nil {
+		return GoInstallation{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return GoInstallation{}, fmt.Errorf("cannot read %s: %v", path, err)
+	}
+	if !info.IsDir() {
+		return GoInstallation{}, fmt.Errorf("%s is not a directory", path)
+	}
+	for _, install := range existing {
+		if filepath.Clean(install.Path) == path {
+			return GoInstallation{}, fmt.Errorf("%s is already listed", path)
+		}
+	}
+	if checks := verifyGoRoot(path); checks.passed() == 0 {
+		return GoInstallation{}, fmt.Errorf("%s does not look like Go: no VERSION, bin/go or pkg/tool", path)
+	}
+
+	install := newInstallation(path, "manual")
+	install.Detector = "manual"
+	install.Evidence = append([]string{"added by hand on the confirm screen"}, install.Evidence...)
+	installs := []GoInstallation{install}
+	markBlockedInstallations(installs, allowCrossMounts)
+	if installs[0].Blocked != "" {
+		return GoInstallation{}, fmt.Errorf("%s cannot be removed: %s", path, installs[0].Blocked)
+	}
+	summarizeOwnership(installs)
+	return installs[0], nil
+}
+
+func inspectManualPathCmd(input string, existing []GoInstallation, allowCrossMounts bool) tea.Cmd {
+	return func() tea.Msg {
+		install, err := inspectManualPath(input, existing, allowCrossMounts)
+		return manualPathInspected{install: install, err: err}
+	}
+}
+
+func (m model) startAddPath() (tea.Model, tea.Cmd) {
+	pi := textinput.New()
+	pi.Placeholder = "/path/to/go"
+	pi.Width = 50
+	pi.Focus()
+	m.pathInput = pi
+	m.addPathErr = nil
+	m.state = "add_path"
+	return m, textinput.Blink
+}
+
+func (m model) handleAddPathKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		if m.logFile != nil {
+			m.logFile.Log("INFO", "User cancelled operation")
+			m.logFile.Close()
+		}
+		return m, tea.Quit
+	case "esc":
+		m.state = "confirm"
+		return m, nil
+	case "tab":
+		return m.startBrowse(browseManualPath)
+	case "enter":
+		if m.addPathBusy {
+			return m, nil
+		}
+		if strings.TrimSpace(m.pathInput.Value()) == "" {
+			m.state = "confirm"
+			return m, nil
+		}
+		m.addPathBusy = true
+		m.addPathErr = nil
+		return m, tea.Batch(m.spinner.Tick, inspectManualPathCmd(m.pathInput.Value(), m.detectedInstalls, m.config.AllowCrossMounts))
+	}
+	var cmd tea.Cmd
+	m.pathInput, cmd = m.pathInput.Update(msg)
+	return m, cmd
+}
+
+// addManualInstall puts a validated installation into the plan, selected,
+// and re-runs the checks that depend on the whole plan.
+func (m model) addManualInstall(msg manualPathInspected) model {
+	m.addPathBusy = false
+	if msg.err != nil {
+		m.addPathErr = msg.err
+		if m.logFile != nil {
+			m.logFile.Log("WARN", fmt.Sprintf("Manual path rejected: %v", msg.err))
+		}
+		return m
+	}
+
+	install := msg.install
+	m.detectedInstalls = append(m.detectedInstalls, install)
+	if m.selection == nil {
+		m.selection = make(map[string]bool)
+	}
+	m.selection[install.Path] = true
+	sortInstallations(m.detectedInstalls, m.sortBy)
+
+	// The backup destination stays the last preflight entry
+	added := runPreflight([]GoInstallation{install}, m.backupPath)
+	if n := len(m.preflight); n > 0 && m.preflight[n-1].Role == "backup" {
+		m.preflight = append(append(m.preflight[:n-1:n-1], added[0]), m.preflight[n-1])
+	} else {
+		m.preflight = append(m.preflight, added...)
+	}
+
+	m.setInstallItems()
+	m.state = "confirm"
+	m = m.checkOwnership()
+	if m.logFile != nil {
+		m.logFile.Log("INFO", fmt.Sprintf("Manually added %s (%s, %s)", install.Path, install.Version, formatBytes(install.Size)))
+	}
+	return m
+}
+
+func (m model) renderAddPath() string {
+	s := highlightStyle.Render("➕ Add a Go installation by path") + "\n\n"
+	s += "Path: " + m.pathInput.View() + "\n\n"
+	if m.addPathBusy {
+		s += fmt.Sprintf("%s Inspecting...\n\n", m.spinner.View())
+	}
+	if m.addPathErr != nil {
+		s += warningStyle.Render(fmt.Sprintf("❌ %v", m.addPathErr)) + "\n\n"
+	}
+	s += infoStyle.Render("The directory must contain a Go root (VERSION, bin/go or pkg/tool).") + "\n"
+	s += "\n" + confirmButtonStyle.Render("ENTER") + " to add, " + cancelButtonStyle.Render("tab") + " to browse, " + cancelButtonStyle.Render("esc") + " to go back\n"
+	return s
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestInspectManualPath(t *testing.T) {
+	goRoot := fakeGoRoot(t, "VERSION", "bin/go", "pkg/tool")
+	tests := []struct {
+		input    string
+		existing []GoInstallation
+		errPart  string
+	}{
+		{"relative/go", nil, "not an absolute path"},
+		{"/", nil, "critical system path"},
+		{t.TempDir(), nil, "does not look like Go"},
+		{goRoot + "/VERSION", nil, "not a directory"},
+		{goRoot, []GoInstallation{{Path: goRoot}}, "already listed"},
+		{goRoot, nil, ""},
+	}
+	for _, tt := range tests {
+		install, err := inspectManualPath(tt.input, tt.existing, false)
+		if tt.errPart == "" {
+			if err != nil {
+				t.Errorf("inspectManualPath(%s) returned error: %v", tt.input, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.errPart) {
+			t.Errorf("inspectManualPath(%s) error = %v, expected %q", tt.input, err, tt.errPart)
+		}
+		if install.Path != "" {
+			t.Errorf("inspectManualPath(%s) returned an installation with an error", tt.input)
+		}
+	}
+
+	install, _ := inspectManualPath(goRoot, nil, false)
+	if install.Source != "manual" || install.Detector != "manual" || !install.Verified {
+		t.Errorf("Expected a verified manual installation, got %+v", install)
+	}
+}
+
+func TestAddManualInstall(t *testing.T) {
+	m := newListTestModel()
+	m.preflight = []preflightResult{{Path: "/usr/local/go", Role: "installation"}, {Path: "/backups", Role: "backup"}}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	m = updated.(model)
+	if m.state != "browse" {
+		t.Fatalf("Expected a to open the directory browser, got %s", m.state)
+	}
+
+	added := GoInstallation{Path: "/opt/custom/go", Source: "manual", Version: "unknown version"}
+	m = m.addManualInstall(manualPathInspected{install: added})
+	if m.state != "confirm" {
+		t.Errorf("Expected to return to confirm, got %s", m.state)
+	}
+	if len(m.selectedInstalls()) != 3 {
+		t.Errorf("Expected the manual path to be selected even though unverified, got %v", m.selectedInstalls())
+	}
+	if len(m.preflight) != 3 || m.preflight[1].Path != "/opt/custom/go" || m.preflight[2].Role != "backup" {
+		t.Errorf("Expected the new path before the backup in preflight, got %+v", m.preflight)
+	}
+
+	m.state = "add_path"
+	m = m.addManualInstall(manualPathInspected{err: errors.New("nope")})
+	if m.state != "add_path" || m.addPathErr == nil {
+		t.Errorf("Expected a rejected path to stay on the add path screen with the error")
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// findMountBoundaries lists mount points inside root: directories on a
+// different device than root, plus (on Linux) bind mounts from the kernel's
+// mount table, which share the device ID and are invisible to stat.
+func findMountBoundaries(root string) ([]string, error) {
+	rootInfo, err := os.Lstat(root)
+	if err != nil {
+		return nil, err
+	}
+	rootDev, ok := deviceID(rootInfo)
+
+	found := make(map[string]bool)
+	if ok {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() || path == root {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			if dev, ok := deviceID(info); ok && dev != rootDev {
+				found[path] = true
+				return filepath.SkipDir
+			}
+			return nil
+		})
+	}
+
+	for _, mountPoint := range mountPointsUnder(currentPlatform.mountPoints(), root) {
+		found[mountPoint] = true
+	}
+
+	boundaries := make([]string, 0, len(found))
+	for path := range found {
+		boundaries = append(boundaries, path)
+	}
+	sort.Strings(boundaries)
+	return boundaries, nil
+}
+
+// parseMountInfo extracts the mount point column from /proc/self/mountinfo.
+func parseMountInfo(data []byte) []string {
+	var mountPoints []string
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		mountPoints = append(mountPoints, unescapeMountPath(fields[4]))
+	}
+	return mountPoints
+}
+
+// unescapeMountPath decodes the octal escapes (\040 for space) the kernel uses
+// in mount tables.
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			var value int
+			if _, err := fmt.Sscanf(path[i+1:i+4], "%03o", &value); err == nil {
+				b.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
+
+// mountPointsUnder returns the mount points strictly inside root.
+func mountPointsUnder(mountPoints []string, root string) []string {
+	cleanRoot := filepath.Clean(root)
+	var under []string
+	for _, mountPoint := range mountPoints {
+		if strings.HasPrefix(mountPoint, cleanRoot+string(filepath.Separator)) {
+			under = append(under, mountPoint)
+		}
+	}
+	return under
+}
+
+// removeTree deletes root, refusing to descend into other mounts unless the
+// user opted in with allow_cross_mounts.
+func removeTree(root string, allowCrossMounts bool) error {
+	if !allowCrossMounts {
+		boundaries, err := findMountBoundaries(root)
+		if err != nil {
+			return fmt.Errorf("failed to check mount points under %s: %v", root, err)
+		}
+		if len(boundaries) > 0 {
+			return fmt.Errorf("refusing to cross mount point(s) inside %s: %s", root, strings.Join(boundaries, ", "))
+		}
+	}
+	return fsys.RemoveAll(root)
+}
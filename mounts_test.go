@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseMountInfo(t *testing.T) {
+	data := []byte(`22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw
+35 22 8:1 /srv/cache /usr/local/go/pkg/mod rw,relatime shared:1 - ext4 /dev/sda1 rw
+36 22 0:45 / /mnt/my\040share rw,relatime - nfs server:/export rw
+`)
+
+	expected := []string{"/", "/usr/local/go/pkg/mod", "/mnt/my share"}
+	if result := parseMountInfo(data); !reflect.DeepEqual(result, expected) {
+		t.Errorf("parseMountInfo = %v, expected %v", result, expected)
+	}
+}
+
+func TestMountPointsUnder(t *testing.T) {
+	mountPoints := []string{"/", "/usr/local/go", "/usr/local/go/pkg/mod", "/usr/local/gopher"}
+
+	expected := []string{"/usr/local/go/pkg/mod"}
+	if result := mountPointsUnder(mountPoints, "/usr/local/go/"); !reflect.DeepEqual(result, expected) {
+		t.Errorf("mountPointsUnder = %v, expected %v", result, expected)
+	}
+}
+
+func TestRemoveTree(t *testing.T) {
+	skipInAuditBuild(t)
+	root := filepath.Join(t.TempDir(), "go")
+	if err := os.MkdirAll(filepath.Join(root, "bin"), 0755); err != nil {
+		t.Fatalf("Failed to create tree: %v", err)
+	}
+
+	if err := removeTree(root, false); err != nil {
+		t.Fatalf("removeTree returned error: %v", err)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Error("Expected tree to be removed")
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Every network request fu-go makes goes through newHTTPClient, so --offline
+// can guarantee that nothing leaves the machine: checksum downloads,
+// self-update checks and webhooks all fail with errOffline before a
+// connection is attempted, and so does a backup to a network file system.
+
+var errOffline = errors.New("network access is disabled by --offline")
+
+var offlineMode atomic.Bool
+
+func setOffline(offline bool) {
+	offlineMode.Store(offline)
+}
+
+func isOffline() bool {
+	return offlineMode.Load()
+}
+
+// netDial opens connections for the HTTP client; tests replace it to count
+// dials.
+var netDial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+
+// guardedDial refuses every connection in offline mode.
+func guardedDial(ctx context.Context, network, addr string) (net.Conn, error) {
+	if isOffline() {
+		tracef("net: refused %s %s: offline", network, addr)
+		return nil, errOffline
+	}
+	tracef("net: dial %s %s", network, addr)
+	return netDial(ctx, network, addr)
+}
+
+// offlineTransport rejects requests before they reach the dialer, so nothing
+// is resolved or proxied either.
+type offlineTransport struct {
+	next http.RoundTripper
+}
+
+func (t offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isOffline() {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Redacted(), errOffline)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// envProxy picks the proxy for a request from HTTPS_PROXY, HTTP_PROXY and
+// NO_PROXY; tests replace it because the standard library reads the
+// environment only once.
+var envProxy = http.ProxyFromEnvironment
+
+func proxyForRequest(req *http.Request) (*url.URL, error) {
+	proxy, err := envProxy(req)
+	if proxy != nil {
+		tracef("net: %s via proxy %s", req.URL.Host, proxy.Redacted())
+	}
+	return proxy, err
+}
+
+// loadCABundle adds the PEM certificates in path to the system roots.
+// Corporate proxies that inspect TLS re-sign traffic with their own CA, which
+// the system store on a developer machine often lacks.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %v", err)
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in CA bundle %s", path)
+	}
+	return pool, nil
+}
+
+// newHTTPClient is the only way fu-go code may reach the network. It honours
+// the proxy environment variables and cfg's CA bundle.
+func newHTTPClient(cfg Config, timeout time.Duration) (*http.Client, error) {
+	transport := &http.Transport{
+		Proxy:               proxyForRequest,
+		DialContext:         guardedDial,
+		TLSHandshakeTimeout: 10 * time.Second,
+		ForceAttemptHTTP2:   true,
+	}
+	if cfg.CABundle != "" {
+		pool, err := loadCABundle(cfg.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &http.Client{
+		Transport: offlineTransport{next: transport},
+		Timeout:   timeout,
+	}, nil
+}
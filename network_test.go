@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countDials swaps in a dialer that counts connections for the test.
+func countDials(t *testing.T) *atomic.Int32 {
+	t.Helper()
+	var dials atomic.Int32
+	original := netDial
+	netDial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dials.Add(1)
+		return original(ctx, network, addr)
+	}
+	t.Cleanup(func() {
+		netDial = original
+		setOffline(false)
+	})
+	return &dials
+}
+
+func TestOfflineRefusesNetwork(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	dials := countDials(t)
+
+	setOffline(true)
+	_, err := mustHTTPClient(t, Config{}).Get(server.URL)
+	if !errors.Is(err, errOffline) {
+		t.Errorf("Expected errOffline, got %v", err)
+	}
+	if _, err := guardedDial(context.Background(), "tcp", server.Listener.Addr().String()); !errors.Is(err, errOffline) {
+		t.Errorf("Expected guardedDial to refuse, got %v", err)
+	}
+	if n := dials.Load(); n != 0 {
+		t.Errorf("Expected no dials in offline mode, got %d", n)
+	}
+
+	setOffline(false)
+	resp, err := mustHTTPClient(t, Config{}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected the request to succeed online, got %v", err)
+	}
+	resp.Body.Close()
+	if n := dials.Load(); n != 1 {
+		t.Errorf("Expected 1 dial online, got %d", n)
+	}
+}
+
+func TestOfflineFlag(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_STATE_HOME", "")
+	countDials(t)
+
+	cmd := newRootCmd()
+	cmd.SetArgs([]string{"list", "--simulate", "--offline"})
+	cmd.SetOut(io.Discard)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if !isOffline() {
+		t.Errorf("Expected --offline to switch the network client off")
+	}
+}
+
+func mustHTTPClient(t *testing.T, cfg Config) *http.Client {
+	t.Helper()
+	client, err := newHTTPClient(cfg, 5*time.Second)
+	if err != nil {
+		t.Fatalf("newHTTPClient returned error: %v", err)
+	}
+	return client
+}
+
+func TestCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	if _, err := mustHTTPClient(t, Config{}).Get(server.URL); err == nil {
+		t.Fatal("Expected the test CA to be untrusted without a bundle")
+	}
+
+	bundle := filepath.Join(t.TempDir(), "corp-ca.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, pemData, 0644); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+	resp, err := mustHTTPClient(t, Config{CABundle: bundle}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected the bundle to be trusted, got %v", err)
+	}
+	resp.Body.Close()
+
+	if err := os.WriteFile(bundle, []byte("not a certificate"), 0644); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+	if _, err := newHTTPClient(Config{CABundle: bundle}, time.Second); err == nil {
+		t.Error("Expected an error for a bundle without certificates")
+	}
+}
+
+func TestProxyFromEnvironment(t *testing.T) {
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy sees the absolute URL of the target
+		if r.URL.Host == "go.dev.invalid" {
+			proxied.Add(1)
+		}
+		w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	original := envProxy
+	envProxy = func(req *http.Request) (*url.URL, error) { return proxyURL, nil }
+	defer func() { envProxy = original }()
+
+	resp, err := mustHTTPClient(t, Config{}).Get("http://go.dev.invalid/dl/")
+	if err != nil {
+		t.Fatalf("Expected the request to go through the proxy, got %v", err)
+	}
+	resp.Body.Close()
+	if proxied.Load() != 1 {
+		t.Errorf("Expected the proxy to receive the request")
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Time Machine on macOS and File History on Windows may already hold a copy
+// of an installation. The confirm screen says which paths the system's own
+// backup covers and when it last ran, and which it leaves out, so the user
+// knows what a removal can be undone from besides fu-go's backup.
+
+// systemBackup is what the system's backup tool covers of the detected
+// installations.
+type systemBackup struct {
+	Tool     string    // "Time Machine" or "File History"
+	Latest   time.Time // last completed backup, zero if unknown
+	Covered  []string
+	Excluded []string // left out of the backup
+}
+
+// checkSystemBackup is the platform's check, nil when no backup tool is set
+// up; tests replace it.
+var checkSystemBackup = nativeSystemBackup
+
+// systemBackupChecked carries the check into Update.
+type systemBackupChecked struct {
+	backup *systemBackup
+	err    error
+}
+
+// systemBackupCmd checks the detected installations against the system's
+// backup.
+func (m model) systemBackupCmd() tea.Cmd {
+	paths := make([]string, 0, len(m.detectedInstalls))
+	for _, install := range m.detectedInstalls {
+		paths = append(paths, install.Path)
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	if m.opts.simulate {
+		return func() tea.Msg {
+			time.Sleep(simulatedDelay(200*time.Millisecond, 0))
+			return systemBackupChecked{backup: &systemBackup{Tool: "Time Machine", Latest: time.Now().Add(-5 * time.Hour), Covered: paths[:1], Excluded: paths[1:]}}
+		}
+	}
+	return func() tea.Msg {
+		backup, err := checkSystemBackup(paths)
+		return systemBackupChecked{backup: backup, err: err}
+	}
+}
+
+func (m model) handleSystemBackupChecked(msg systemBackupChecked) model {
+	m.systemBackup = msg.backup
+	if m.logFile == nil {
+		return m
+	}
+	if msg.err != nil {
+		m.logFile.Log("WARN", fmt.Sprintf("Could not check the system backup: %v", msg.err))
+	}
+	if backup := msg.backup; backup != nil {
+		for _, path := range backup.Covered {
+			m.logFile.Log("INFO", fmt.Sprintf("%s covers %s (last backup %s)", backup.Tool, path, formatBackupDate(backup.Latest)))
+		}
+		for _, path := range backup.Excluded {
+			m.logFile.Log("INFO", fmt.Sprintf("%s does not back up %s", backup.Tool, path))
+		}
+	}
+	return m
+}
+
+func formatBackupDate(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Format("2 Jan 2006 15:04")
+}
+
+// renderSystemBackup lists the selected installations the system's backup
+// covers and those it does not.
+func (m model) renderSystemBackup() string {
+	backup := m.systemBackup
+	if backup == nil {
+		return ""
+	}
+	selected := make(map[string]bool)
+	for _, install := range m.selectedInstalls() {
+		selected[install.Path] = true
+	}
+	var s string
+	for _, path := range backup.Covered {
+		if !selected[path] {
+			continue
+		}
+		if backup.Latest.IsZero() {
+			s += successStyle.Render(fmt.Sprintf("🕰️  %s covers %s", backup.Tool, path)) + "\n"
+		} else {
+			s += successStyle.Render(fmt.Sprintf("🕰️  A %s backup from %s covers %s", backup.Tool, formatBackupDate(backup.Latest), path)) + "\n"
+		}
+	}
+	for _, path := range backup.Excluded {
+		if selected[path] {
+			s += infoStyle.Render(fmt.Sprintf("🕰️  %s does not back up %s", backup.Tool, path)) + "\n"
+		}
+	}
+	if s == "" {
+		return ""
+	}
+	if backup.Tool == "Time Machine" && !m.config.snapshotBeforeRemoval() {
+		s += infoStyle.Render(`   Set "snapshot": {"backend": "apfs"} to take a local Time Machine snapshot before removing`) + "\n"
+	}
+	return s + "\n"
+}
+
+// parseTimeMachineLatest reads the date from the path tmutil latestbackup
+// prints: .../2024-01-15-101010 in a backupdb, or
+// .../2024-01-15-101010.backup on APFS destinations.
+func parseTimeMachineLatest(output string) (time.Time, bool) {
+	name := filepath.Base(strings.TrimSpace(output))
+	name = strings.TrimSuffix(name, ".backup")
+	t, err := time.ParseInLocation("2006-01-02-150405", name, time.Local)
+	return t, err == nil
+}
+
+// parseTimeMachineExclusions reads tmutil isexcluded, one
+// "[Excluded]    /path" or "[Included]    /path" per line, into the
+// excluded paths.
+func parseTimeMachineExclusions(output string) map[string]bool {
+	excluded := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		if path, ok := strings.CutPrefix(strings.TrimSpace(line), "[Excluded]"); ok {
+			excluded[strings.TrimSpace(path)] = true
+		}
+	}
+	return excluded
+}
+
+// parseFileHistoryConfig reads the folders File History includes and
+// excludes from its Config1.xml: every absolute path in the file, excluded
+// when it sits in an element whose name mentions Exclude. The backup drive
+// and catalog are neither.
+func parseFileHistoryConfig(data []byte) (included, excluded []string, err error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var stack []string
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				return included, excluded, nil
+			}
+			return nil, nil, fmt.Errorf("failed to parse File History configuration: %v", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			path := strings.TrimSpace(string(t))
+			if !isWindowsAbsPath(path) {
+				continue
+			}
+			isExcluded, isStorage := false, false
+			for _, name := range stack {
+				name = strings.ToLower(name)
+				isExcluded = isExcluded || strings.Contains(name, "exclude")
+				isStorage = isStorage || strings.Contains(name, "target") || strings.Contains(name, "catalog")
+			}
+			if isStorage {
+				continue
+			}
+			if isExcluded {
+				excluded = append(excluded, path)
+			} else {
+				included = append(included, path)
+			}
+		}
+	}
+}
+
+// isWindowsAbsPath reports whether path is C:\... or \\server\share.
+func isWindowsAbsPath(path string) bool {
+	if strings.HasPrefix(path, `\\`) {
+		return true
+	}
+	return len(path) >= 3 && path[1] == ':' && path[2] == '\\'
+}
+
+// underWindowsDir reports whether path is dir or inside it, ignoring case.
+func underWindowsDir(path, dir string) bool {
+	path, dir = strings.ToLower(path), strings.ToLower(strings.TrimRight(dir, `\`))
+	return path == dir || strings.HasPrefix(path, dir+`\`)
+}
+
+// fileHistoryCoverage splits paths into those File History backs up and
+// those it does not.
+func fileHistoryCoverage(paths, included, excluded []string) (covered, notCovered []string) {
+	for _, path := range paths {
+		in := false
+		for _, dir := range included {
+			if underWindowsDir(path, dir) {
+				in = true
+			}
+		}
+		for _, dir := range excluded {
+			if underWindowsDir(path, dir) {
+				in = false
+			}
+		}
+		if in {
+			covered = append(covered, path)
+		} else {
+			notCovered = append(notCovered, path)
+		}
+	}
+	return covered, notCovered
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// nativeSystemBackup asks tmutil about Time Machine. Without a destination
+// there is nothing to report; without Full Disk Access the latest backup's
+// date is unknown.
+func nativeSystemBackup(paths []string) (*systemBackup, error) {
+	output, err := commandOutput("tmutil", "destinationinfo")
+	if err != nil || !strings.Contains(string(output), "Name") {
+		return nil, nil
+	}
+	backup := &systemBackup{Tool: "Time Machine"}
+	if output, err := commandOutput("tmutil", "latestbackup"); err == nil {
+		backup.Latest, _ = parseTimeMachineLatest(string(output))
+	}
+	output, err = commandOutput("tmutil", append([]string{"isexcluded"}, paths...)...)
+	if err != nil {
+		return nil, fmt.Errorf("tmutil isexcluded failed: %v", err)
+	}
+	excluded := parseTimeMachineExclusions(string(output))
+	for _, path := range paths {
+		if excluded[path] {
+			backup.Excluded = append(backup.Excluded, path)
+		} else {
+			backup.Covered = append(backup.Covered, path)
+		}
+	}
+	return backup, nil
+}
@@ -0,0 +1,8 @@
+//go:build !darwin && !windows
+
+package main
+
+// nativeSystemBackup finds no system backup tool to ask.
+func nativeSystemBackup(paths []string) (*systemBackup, error) {
+	return nil, nil
+}
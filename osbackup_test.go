@@ -0,0 +1,91 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimeMachineOutput(t *testing.T) {
+	for _, output := range []string{
+		"/Volumes/Backup/Backups.backupdb/Mac/2026-10-01-093000\n",
+		"/Volumes/.timemachine/ABCD/2026-10-01-093000.backup/2026-10-01-093000.backup\n",
+	} {
+		latest, ok := parseTimeMachineLatest(output)
+		if want := time.Date(2026, 10, 1, 9, 30, 0, 0, time.Local); !ok || !latest.Equal(want) {
+			t.Errorf("parseTimeMachineLatest(%q) = %v, %v, want %v", output, latest, ok, want)
+		}
+	}
+	if _, ok := parseTimeMachineLatest("No machine directory found for host.\n"); ok {
+		t.Errorf("Expected an error message not to parse as a date")
+	}
+
+	excluded := parseTimeMachineExclusions("[Excluded]    /Users/me/sdk/go1.22\n[Included]    /usr/local/go\n")
+	if !excluded["/Users/me/sdk/go1.22"] || excluded["/usr/local/go"] {
+		t.Errorf("Unexpected exclusions %v", excluded)
+	}
+}
+
+func TestFileHistoryCoverage(t *testing.T) {
+	config := `<?xml version="1.0" encoding="utf-8"?>
+<DataProtectionUserConfig>
+  <UserData>
+    <IncludedFolder>C:\Users\me\Documents</IncludedFolder>
+    <IncludedFolder>C:\Users\me\sdk</IncludedFolder>
+    <ExcludedFolders><Folder>C:\Users\me\sdk\go1.20</Folder></ExcludedFolders>
+  </UserData>
+  <LocalCatalogPath1>C:\Users\me\AppData\Local\Microsoft\Windows\FileHistory\Configuration\Catalog1.edb</LocalCatalogPath1>
+  <Target><TargetUrl>E:\</TargetUrl></Target>
+</DataProtectionUserConfig>`
+	included, excluded, err := parseFileHistoryConfig([]byte(config))
+	if err != nil {
+		t.Fatalf("parseFileHistoryConfig returned error: %v", err)
+	}
+	if want := []string{`C:\Users\me\Documents`, `C:\Users\me\sdk`}; !reflect.DeepEqual(included, want) {
+		t.Errorf("Included %q, want %q", included, want)
+	}
+	if want := []string{`C:\Users\me\sdk\go1.20`}; !reflect.DeepEqual(excluded, want) {
+		t.Errorf("Excluded %q, want %q", excluded, want)
+	}
+
+	covered, notCovered := fileHistoryCoverage([]string{`c:\users\me\SDK\go1.22`, `C:\Users\me\sdk\go1.20`, `C:\Program Files\Go`, `E:\go`}, included, excluded)
+	if want := []string{`c:\users\me\SDK\go1.22`}; !reflect.DeepEqual(covered, want) {
+		t.Errorf("Covered %q, want %q", covered, want)
+	}
+	if len(notCovered) != 3 {
+		t.Errorf("Expected three paths not covered, got %q", notCovered)
+	}
+}
+
+func TestRenderSystemBackup(t *testing.T) {
+	m := model{detectedInstalls: []GoInstallation{
+		{Path: "/usr/local/go", Verified: true},
+		{Path: "/Users/me/sdk/go1.22", Verified: true},
+		{Path: "/opt/go", Verified: true},
+	}}
+	if m.renderSystemBackup() != "" {
+		t.Fatalf("Expected nothing without a system backup")
+	}
+	latest := time.Date(2026, 10, 1, 9, 30, 0, 0, time.Local)
+	m = m.handleSystemBackupChecked(systemBackupChecked{backup: &systemBackup{
+		Tool:     "Time Machine",
+		Latest:   latest,
+		Covered:  []string{"/usr/local/go", "/opt/go"},
+		Excluded: []string{"/Users/me/sdk/go1.22"},
+	}})
+	m.selection = map[string]bool{"/opt/go": false}
+	view := m.renderSystemBackup()
+	for _, want := range []string{"A Time Machine backup from 1 Oct 2026 09:30 covers /usr/local/go", "Time Machine does not back up /Users/me/sdk/go1.22", `"backend": "apfs"`} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in %q", want, view)
+		}
+	}
+	if strings.Contains(view, "/opt/go") {
+		t.Errorf("Expected an unselected installation to be left out, got %q", view)
+	}
+	m.config.Snapshot.Backend = "apfs"
+	if strings.Contains(m.renderSystemBackup(), `"backend": "apfs"`) {
+		t.Errorf("Expected no snapshot hint once snapshots are on")
+	}
+}
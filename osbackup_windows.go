@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// fileHistoryDefaults are the folders File History backs up unless told
+// otherwise, under the user profile.
+var fileHistoryDefaults = []string{"Desktop", "Documents", "Music", "Pictures", "Videos", "Contacts", "Favorites"}
+
+// nativeSystemBackup reads File History's configuration. The catalog is
+// rewritten after every backup, so its modification time is the last one.
+func nativeSystemBackup(paths []string) (*systemBackup, error) {
+	local := os.Getenv("LOCALAPPDATA")
+	if local == "" {
+		return nil, nil
+	}
+	configDir := filepath.Join(local, "Microsoft", "Windows", "FileHistory", "Configuration")
+	data, err := os.ReadFile(filepath.Join(configDir, "Config1.xml"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read File History configuration: %v", err)
+	}
+	included, excluded, err := parseFileHistoryConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	if profile := os.Getenv("USERPROFILE"); len(included) == 0 && profile != "" {
+		for _, dir := range fileHistoryDefaults {
+			included = append(included, filepath.Join(profile, dir))
+		}
+	}
+	backup := &systemBackup{Tool: "File History"}
+	if info, err := os.Stat(filepath.Join(configDir, "Catalog1.edb")); err == nil {
+		backup.Latest = info.ModTime()
+	}
+	backup.Covered, backup.Excluded = fileHistoryCoverage(paths, included, excluded)
+	return backup, nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Run as root on a shared build box, fu-go would otherwise only look in
+// root's home and miss every gvm, goenv, asdf and golang.org/dl toolchain the
+// users installed for themselves. With all_users (or --all-users) the
+// other_users detector also searches their home directories. What it finds
+// is labelled with the user, grouped by user, never selected by default, and
+// removing it goes through the ownership acknowledgement.
+
+var searchAllUsers atomic.Bool
+
+// setAllUsers applies --all-users, or the all_users config key, to the whole
+// run.
+func setAllUsers(all bool) {
+	searchAllUsers.Store(all)
+}
+
+// userHome is another account's home directory.
+type userHome struct {
+	name string
+	home string
+}
+
+// userHomeLayouts are where the home-directory detectors look, relative to
+// a home directory.
+var userHomeLayouts = map[string][]string{
+	"gvm":         {".gvm", "gos"},
+	"asdf":        {".asdf", "installs", "golang"},
+	"goenv":       {".goenv", "versions"},
+	"sdk":         {"sdk"},
+	"gotoolchain": {"go", "pkg", "mod", "golang.org"}, // the default GOPATH's module cache
+}
+
+// passwdPath is the account database read on Linux and the BSDs.
+var passwdPath = "/etc/passwd"
+
+// listUserHomes finds the other accounts' home directories; tests replace
+// it.
+var listUserHomes = func() ([]userHome, error) {
+	self, _ := os.UserHomeDir()
+	return systemUserHomes(runtime.GOOS, self)
+}
+
+// systemUserHomes lists the home directories of the people using this
+// machine, leaving out self and system accounts.
+func systemUserHomes(goos, self string) ([]userHome, error) {
+	var homes []userHome
+	switch goos {
+	case "windows":
+		drive := os.Getenv("SystemDrive")
+		if drive == "" {
+			drive = "C:"
+		}
+		homes = homesIn(drive+`\Users`, "Public", "Default", "Default User", "All Users")
+	case "darwin":
+		homes = homesIn("/Users", "Shared", "Guest")
+	default:
+		f, err := os.Open(passwdPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the user accounts: %v", err)
+		}
+		defer f.Close()
+		homes = parsePasswd(f)
+	}
+	var others []userHome
+	for _, u := range homes {
+		if filepath.Clean(u.home) == filepath.Clean(self) {
+			continue
+		}
+		if info, err := os.Stat(u.home); err != nil || !info.IsDir() {
+			continue
+		}
+		others = append(others, u)
+	}
+	return others, nil
+}
+
+// homesIn lists the directories in dir, which are named after their users.
+func homesIn(dir string, skip ...string) []userHome {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var homes []userHome
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || strings.HasPrefix(name, ".") || containsFold(skip, name) {
+			continue
+		}
+		homes = append(homes, userHome{name: name, home: filepath.Join(dir, name)})
+	}
+	return homes
+}
+
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePasswd reads the people's accounts from passwd(5): uid 1000 and up,
+// except nobody, with a login shell.
+func parsePasswd(r io.Reader) []userHome {
+	var homes []userHome
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 7 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		uid, err := strconv.Atoi(fields[2])
+		if err != nil || uid < 1000 || uid == 65534 {
+			continue
+		}
+		if shell := filepath.Base(fields[6]); shell == "nologin" || shell == "false" {
+			continue
+		}
+		homes = append(homes, userHome{name: fields[0], home: fields[5]})
+	}
+	return homes
+}
+
+// homeDetectors are the registry's home-directory detectors pointed at home.
+func homeDetectors(home string) []dirDetector {
+	var detectors []dirDetector
+	for _, detector := range detectorRegistry {
+		dir, ok := detector.(dirDetector)
+		layout, known := userHomeLayouts[dir.name]
+		if !ok || !known {
+			continue
+		}
+		parent := filepath.Join(append([]string{home}, layout...)...)
+		dir.parents = func() []string { return []string{parent} }
+		detectors = append(detectors, dir)
+	}
+	return detectors
+}
+
+// otherUsersDetector runs the home-directory detectors over every other
+// user's home.
+type otherUsersDetector struct{}
+
+func (otherUsersDetector) Name() string { return "other_users" }
+
+func (otherUsersDetector) scope() installScope { return scopeSystem }
+
+func (otherUsersDetector) Detect(ctx context.Context) ([]GoInstallation, error) {
+	if !isElevated() {
+		return nil, fmt.Errorf("searching other users' home directories needs root or an administrator")
+	}
+	homes, err := listUserHomes()
+	if err != nil {
+		return nil, err
+	}
+	var installations []GoInstallation
+	for _, u := range homes {
+		for _, detector := range homeDetectors(u.home) {
+			found, err := detector.Detect(ctx)
+			for _, install := range found {
+				install.User = u.name
+				install.Detector = "other_users"
+				install.Evidence = append([]string{fmt.Sprintf("in %s's home directory %s", u.name, u.home)}, install.Evidence...)
+				installations = append(installations, install)
+			}
+			if err != nil {
+				return installations, err
+			}
+		}
+	}
+	return installations, nil
+}
+
+// sourceLabel is the installation's source, with the user for another
+// user's installation.
+func sourceLabel(install GoInstallation) string {
+	if install.User == "" {
+		return install.Source
+	}
+	return fmt.Sprintf("%s (%s)", install.Source, install.User)
+}
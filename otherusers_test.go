@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type elevatedPlatform struct {
+	platformBackend
+	elevated bool
+}
+
+func (p elevatedPlatform) isElevated() bool { return p.elevated }
+
+func TestParsePasswd(t *testing.T) {
+	passwd := `root:x:0:0:root:/root:/bin/bash
+daemon:x:1:1:daemon:/usr/sbin:/usr/sbin/nologin
+# comment:x:1000:1000::/home/comment:/bin/sh
+alice:x:1000:1000:Alice:/home/alice:/bin/bash
+ci:x:1001:1001::/var/lib/ci:/bin/sh
+nobody:x:65534:65534:nobody:/nonexistent:/bin/sh
+svc:x:1002:1002::/srv/svc:/bin/false
+broken line
+`
+	var got []string
+	for _, u := range parsePasswd(strings.NewReader(passwd)) {
+		got = append(got, u.name+"="+u.home)
+	}
+	if want := []string{"alice=/home/alice", "ci=/var/lib/ci"}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestSystemUserHomes(t *testing.T) {
+	dir := t.TempDir()
+	alice, bob := filepath.Join(dir, "alice"), filepath.Join(dir, "bob")
+	os.Mkdir(alice, 0755)
+	os.Mkdir(bob, 0755)
+	passwd := filepath.Join(dir, "passwd")
+	os.WriteFile(passwd, []byte("alice:x:1000:1000::"+alice+":/bin/bash\nbob:x:1001:1001::"+bob+":/bin/bash\ngone:x:1002:1002::"+filepath.Join(dir, "gone")+":/bin/bash\n"), 0644)
+	saved := passwdPath
+	passwdPath = passwd
+	t.Cleanup(func() { passwdPath = saved })
+
+	homes, err := systemUserHomes("linux", bob)
+	if err != nil || len(homes) != 1 || homes[0].name != "alice" {
+		t.Errorf("Expected only alice, leaving out the running user and missing homes, got %+v, %v", homes, err)
+	}
+}
+
+func TestOtherUsersDetector(t *testing.T) {
+	alice := t.TempDir()
+	os.MkdirAll(filepath.Join(alice, ".gvm", "gos", "go1.21.0", "bin"), 0755)
+	os.MkdirAll(filepath.Join(alice, "sdk", "go1.23.1", "bin"), 0755)
+	os.MkdirAll(filepath.Join(alice, "sdk", "notgo"), 0755)
+	saved := listUserHomes
+	listUserHomes = func() ([]userHome, error) { return []userHome{{name: "alice", home: alice}}, nil }
+	t.Cleanup(func() { listUserHomes = saved })
+
+	withPlatform(t, elevatedPlatform{platformBackend: currentPlatform})
+	if _, err := (otherUsersDetector{}).Detect(context.Background()); err == nil {
+		t.Errorf("Expected other users' homes to need root")
+	}
+
+	withPlatform(t, elevatedPlatform{platformBackend: currentPlatform, elevated: true})
+	found, err := otherUsersDetector{}.Detect(context.Background())
+	if err != nil {
+		t.Fatalf("Detect returned error: %v", err)
+	}
+	var got []string
+	for _, install := range found {
+		got = append(got, sourceLabel(install)+" "+strings.TrimPrefix(install.Path, alice))
+		if install.Detector != "other_users" || !strings.Contains(install.Evidence[0], "alice's home directory") {
+			t.Errorf("Unexpected %+v", install)
+		}
+	}
+	want := []string{"gvm (alice) " + filepath.FromSlash("/.gvm/gos/go1.21.0"), "sdk (alice) " + filepath.FromSlash("/sdk/go1.23.1")}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	var names []string
+	for _, detector := range enabledDetectors(Config{AllUsers: true}) {
+		names = append(names, detector.Name())
+	}
+	if !slices.Contains(names, "other_users") {
+		t.Errorf("Expected all_users to enable other_users, got %v", names)
+	}
+	withScope(t, scopeUser)
+	if slices.ContainsFunc(enabledDetectors(Config{AllUsers: true}), func(d Detector) bool { return d.Name() == "other_users" }) {
+		t.Errorf("Expected other_users not to run in user scope")
+	}
+}
+
+func TestOtherUsersInstallations(t *testing.T) {
+	installations := []GoInstallation{
+		{Path: "/home/bob/sdk/go1.23.1", Source: "sdk", User: "bob", Verified: true},
+		{Path: "/usr/local/go", Source: "official", Verified: true},
+		{Path: "/home/alice/.gvm/gos/go1.21.0", Source: "gvm", User: "alice", Verified: true},
+	}
+	sortInventory(installations)
+	var order []string
+	for _, install := range installations {
+		order = append(order, install.Path)
+	}
+	if want := []string{"/usr/local/go", "/home/alice/.gvm/gos/go1.21.0", "/home/bob/sdk/go1.23.1"}; !slices.Equal(order, want) {
+		t.Errorf("Expected installations grouped by user, got %v", order)
+	}
+
+	m := model{detectedInstalls: installations}
+	if selected := m.selectedInstalls(); len(selected) != 1 || selected[0].User != "" {
+		t.Errorf("Expected other users' installations to start unselected, got %+v", selected)
+	}
+	if owners := foreignOwners(installations, "root"); !slices.Equal(owners, []string{"alice", "bob"}) {
+		t.Errorf("Expected the users to need acknowledging, got %v", owners)
+	}
+	if chosen, _ := planInstallations(installations, nil); len(chosen) != 1 {
+		t.Errorf("Expected a plan to leave other users' installations out unless named, got %+v", chosen)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ownershipSummary counts the files under root per owning user name.
+func ownershipSummary(root string) map[string]int {
+	owners := make(map[string]int)
+	names := make(map[string]string)
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		uid, ok := fileOwnerID(info)
+		if !ok {
+			return filepath.SkipAll
+		}
+		name, cached := names[uid]
+		if !cached {
+			name = uid
+			if u, err := user.LookupId(uid); err == nil {
+				name = u.Username
+			}
+			names[uid] = name
+		}
+		owners[name]++
+		return nil
+	})
+	if len(owners) == 0 {
+		return nil
+	}
+	return owners
+}
+
+func summarizeOwnership(installations []GoInstallation) {
+	for i := range installations {
+		installations[i].Owners = ownershipSummary(installations[i].Path)
+	}
+}
+
+// foreignOwners lists the users other than currentUser who own files in the
+// plan, or whose home directory an installation is in, sorted by name.
+func foreignOwners(installations []GoInstallation, currentUser string) []string {
+	seen := make(map[string]bool)
+	for _, install := range installations {
+		if install.User != "" && install.User != currentUser {
+			seen[install.User] = true
+		}
+		for owner := range install.Owners {
+			if owner != currentUser {
+				seen[owner] = true
+			}
+		}
+	}
+	owners := make([]string, 0, len(seen))
+	for owner := range seen {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+	return owners
+}
+
+// checkOwnership works out which other users own files in the current
+// selection. The confirmation starts with ACKNOWLEDGE while one of them has
+// not been acknowledged, and drops that step once the selection holds none.
+func (m model) checkOwnership() model {
+	m.foreignOwners = foreignOwners(m.selectedInstalls(), currentUsername())
+	pending := false
+	for _, owner := range m.foreignOwners {
+		if !slices.Contains(m.ackedOwners, owner) {
+			pending = true
+		}
+	}
+	switch {
+	case pending && m.confirmationStep == ConfirmationStepInitial:
+		m.confirmationStep = ConfirmationStepOwnership
+		m.textInput.SetValue("")
+		m.textInput.Placeholder = "Type 'ACKNOWLEDGE' to proceed"
+	case !pending && m.confirmationStep == ConfirmationStepOwnership:
+		m.confirmationStep = ConfirmationStepInitial
+		m.textInput.SetValue("")
+		m.textInput.Placeholder = "Type 'CONFIRM' to proceed"
+	}
+	return m
+}
+
+// formatOwners renders "root (1200 files), alice (3 files)", largest first.
+func formatOwners(owners map[string]int) string {
+	names := make([]string, 0, len(owners))
+	for name := range owners {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if owners[names[i]] != owners[names[j]] {
+			return owners[names[i]] > owners[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s (%d files)", name, owners[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func currentUsername() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestOwnershipSummary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ownership summaries are not available on Windows")
+	}
+
+	root := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("go"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	owners := ownershipSummary(root)
+	if count := owners[currentUsername()]; count != 3 {
+		t.Errorf("Expected 3 entries owned by %s, got %v", currentUsername(), owners)
+	}
+}
+
+func TestForeignOwners(t *testing.T) {
+	installations := []GoInstallation{
+		{Path: "/usr/local/go", Owners: map[string]int{"root": 100}},
+		{Path: "/home/alice/.gvm/gos/go1.21", Owners: map[string]int{"alice": 10, "root": 1}},
+	}
+
+	expected := []string{"alice"}
+	if result := foreignOwners(installations, "root"); !reflect.DeepEqual(result, expected) {
+		t.Errorf("foreignOwners = %v, expected %v", result, expected)
+	}
+	if result := foreignOwners(installations[:1], "root"); len(result) != 0 {
+		t.Errorf("Expected no foreign owners, got %v", result)
+	}
+}
+
+func TestFormatOwners(t *testing.T) {
+	result := formatOwners(map[string]int{"alice": 3, "root": 1200, "bob": 3})
+	expected := "root (1200 files), alice (3 files), bob (3 files)"
+	if result != expected {
+		t.Errorf("formatOwners = %q, expected %q", result, expected)
+	}
+}
+
+func TestOwnershipAcknowledgementStep(t *testing.T) {
+	m := initialModel(runOptions{})
+	updated, _ := m.Update(foundGoVersions{
+		installs: []GoInstallation{{Path: "/srv/go", Verified: true, Owners: map[string]int{"someone-else": 5}}},
+	})
+	m = updated.(model)
+	if m.state != "summary" || !strings.Contains(m.View(), "owned by other users: someone-else") {
+		t.Fatalf("Expected the summary to mention the other owners first, got %s", m.state)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.state != "confirm" || m.confirmationStep != ConfirmationStepOwnership {
+		t.Fatalf("Expected ownership step first, got %d", m.confirmationStep)
+	}
+
+	m.textInput.SetValue("ACKNOWLEDGE")
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.confirmationStep != ConfirmationStepInitial {
+		t.Errorf("Expected CONFIRM step after acknowledgement, got %d", m.confirmationStep)
+	}
+}
+
+func TestOwnershipFollowsSelection(t *testing.T) {
+	m := initialModel(runOptions{})
+	updated, _ := m.Update(foundGoVersions{
+		installs: []GoInstallation{
+			{Path: "/usr/local/go", Verified: true},
+			{Path: "/srv/go", Owners: map[string]int{"someone-else": 5}},
+		},
+	})
+	m = updated.(model)
+	if strings.Contains(m.View(), "someone-else") {
+		t.Errorf("Expected no ownership warning for an installation that is not selected")
+	}
+	m = m.continueToConfirm()
+	if m.confirmationStep != ConfirmationStepInitial {
+		t.Fatalf("Expected no ownership step for the selection, got %d", m.confirmationStep)
+	}
+
+	m.selection = map[string]bool{"/srv/go": true}
+	m = m.checkOwnership()
+	if m.confirmationStep != ConfirmationStepOwnership || !strings.Contains(m.View(), "owned by other users: someone-else") {
+		t.Fatalf("Expected selecting /srv/go to ask for acknowledgement, got %d", m.confirmationStep)
+	}
+	m.selection["/srv/go"] = false
+	m = m.checkOwnership()
+	if m.confirmationStep != ConfirmationStepInitial || len(m.foreignOwners) != 0 {
+		t.Errorf("Expected deselecting /srv/go to drop the ownership step, got %d %v", m.confirmationStep, m.foreignOwners)
+	}
+}
@@ -0,0 +1,22 @@
+# Formula for a Homebrew tap. It builds from source and lets fu-go generate
+# its own man page and completions, so they always match the binary that was
+# built.
+class FuGo < Formula
+  desc "Finds every Go installation on the machine and removes them"
+  homepage "https://github.com/melkeydev/fu-go"
+  head "https://github.com/melkeydev/fu-go.git", branch: "main"
+
+  depends_on "go" => :build
+
+  def install
+    system "go", "build", *std_go_args(ldflags: "-s -w")
+    # Writes share/man/man1/fu-go.1 and the bash, zsh and fish completions
+    # under the keg; Homebrew links them into place
+    system bin/"fu-go", "--install-manpages", "--manpages-prefix", prefix
+  end
+
+  test do
+    assert_match "The Go uninstaller", shell_output("#{bin}/fu-go --help")
+    assert_predicate man1/"fu-go.1", :exist?
+  end
+end
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A plan is more than a list of Go roots: caches, profile lines, symlinks into
+// a root, registry values and package manager removals are all things fu-go
+// can change. Each is a PlanItem that knows its own size, risk and how to
+// carry itself out.
+
+type PlanItemKind string
+
+const (
+	PlanInstallation     PlanItemKind = "installation"
+	PlanCache            PlanItemKind = "cache"
+	PlanEnvEdit          PlanItemKind = "env_edit"
+	PlanSymlink          PlanItemKind = "symlink"
+	PlanRegistryEdit     PlanItemKind = "registry_edit"
+	PlanPackageUninstall PlanItemKind = "package_uninstall"
+)
+
+// RiskLevel is how much damage a mistaken plan item would do.
+type RiskLevel int
+
+const (
+	RiskLow    RiskLevel = iota // regenerated on demand, e.g. caches
+	RiskMedium                  // one user's files, backed up
+	RiskHigh                    // shared by the whole machine or handed to a package manager
+)
+
+var riskNames = []string{"low", "medium", "high"}
+
+func (r RiskLevel) String() string {
+	return riskNames[r]
+}
+
+var planItemIcons = map[PlanItemKind]string{
+	PlanInstallation:     "❌",
+	PlanPackageUninstall: "📦",
+	PlanCache:            "🧹",
+	PlanEnvEdit:          "📝",
+	PlanRegistryEdit:     "📝",
+	PlanSymlink:          "🔗",
+	PlanGopath:           "🗂️ ",
+}
+
+// planEnv is what executing an item may need besides the item itself.
+type planEnv struct {
+	allowCrossMounts bool
+	backupDir        string
+	journal          string
+	events           *eventBus       // progress of executePlan, nil for none
+	quarantined      map[string]bool // paths a quarantine backup moved into its set
+}
+
+type PlanItem interface {
+	Kind() PlanItemKind
+	Target() string // path, registry value or package
+	Describe() string
+	Size() int64  // bytes freed, estimated where measuring would be slow
+	Files() int64 // files removed or written, for time estimates
+	Risk() RiskLevel
+	Operations() []string // what Execute does, literally, for review
+	Execute(env planEnv) error
+}
+
+// installationItem removes a Go root fu-go deletes itself.
+type installationItem struct {
+	install GoInstallation
+	home    string
+}
+
+func (i installationItem) Kind() PlanItemKind { return PlanInstallation }
+func (i installationItem) Target() string     { return i.install.Path }
+func (i installationItem) Size() int64        { return i.install.Size }
+func (i installationItem) Files() int64       { return i.install.Files }
+
+func (i installationItem) Describe() string {
+	return fmt.Sprintf("Remove %s (%s)", i.install.Path, i.install.Source)
+}
+
+func (i installationItem) Risk() RiskLevel {
+	if withinDir(i.install.Path, i.home) {
+		return RiskMedium
+	}
+	return RiskHigh
+}
+
+func (i installationItem) Operations() []string {
+	if runsAsOwner(i.install) {
+		return []string{"sudo -u " + quoteOperand(i.install.User) + " rm -rf " + quoteOperand(i.install.Path)}
+	}
+	return []string{"rm -rf " + quoteOperand(i.install.Path)}
+}
+
+// Execute removes another user's installation as that user when fu-go runs
+// as root. One a quarantine backup moved away is already done.
+func (i installationItem) Execute(env planEnv) error {
+	if i.install.Blocked != "" {
+		return fmt.Errorf("cannot remove %s: %s", i.install.Path, i.install.Blocked)
+	}
+	if env.quarantined[i.install.Path] {
+		return nil
+	}
+	if runsAsOwner(i.install) {
+		return removeAsOwner(i.install, env.allowCrossMounts)
+	}
+	if moduleCacheTree(i.install) {
+		makeTreeWritable(i.install.Path)
+	}
+	if err := checkRemovable(i.install.Path); err != nil {
+		return err
+	}
+	return removeTree(i.install.Path, env.allowCrossMounts)
+}
+
+// packageUninstallItem hands an installation to the package manager that
+// owns it.
+type packageUninstallItem struct {
+	install GoInstallation
+}
+
+func (i packageUninstallItem) Kind() PlanItemKind { return PlanPackageUninstall }
+func (i packageUninstallItem) Target() string     { return i.install.Package }
+func (i packageUninstallItem) Size() int64        { return i.install.Size }
+func (i packageUninstallItem) Files() int64       { return i.install.Files }
+func (i packageUninstallItem) Risk() RiskLevel    { return RiskHigh }
+
+func (i packageUninstallItem) Describe() string {
+	return fmt.Sprintf("Run %s (%s)", strings.Join(packageRemovalCommand(i.install), " "), i.install.Path)
+}
+
+func (i packageUninstallItem) Operations() []string {
+	var args []string
+	for _, arg := range packageRemovalCommand(i.install) {
+		args = append(args, quoteOperand(arg))
+	}
+	return []string{strings.Join(args, " ")}
+}
+
+func (i packageUninstallItem) Execute(env planEnv) error {
+	if i.install.Blocked != "" {
+		return fmt.Errorf("cannot remove %s: %s", i.install.Path, i.install.Blocked)
+	}
+	return runPackageRemoval(i.install)
+}
+
+// cacheItem empties GOCACHE or the module cache but keeps the directory.
+type cacheItem struct {
+	target cacheTarget
+	size   int64
+	files  int64
+}
+
+func (i cacheItem) Kind() PlanItemKind { return PlanCache }
+func (i cacheItem) Target() string     { return i.target.Path }
+func (i cacheItem) Size() int64        { return i.size }
+func (i cacheItem) Files() int64       { return i.files }
+func (i cacheItem) Risk() RiskLevel    { return RiskLow }
+
+func (i cacheItem) Describe() string {
+	return fmt.Sprintf("Empty %s (%s)", i.target.Name, i.target.Path)
+}
+
+// Operations keeps the glob outside the quotes: the directory stays, its
+// entries go.
+func (i cacheItem) Operations() []string {
+	return []string{"rm -rf " + quoteOperand(i.target.Path) + string(filepath.Separator) + "*"}
+}
+
+func (i cacheItem) Execute(env planEnv) error {
+	return emptyCache(i.target.Path)
+}
+
+// envEditItem removes the accepted lines of a profile or the user PATH and
+// journals the edit for undo-env.
+type envEditItem struct {
+	edit profileEdit
+}
+
+func (i envEditItem) Kind() PlanItemKind {
+	if i.edit.Registry {
+		return PlanRegistryEdit
+	}
+	return PlanEnvEdit
+}
+
+func (i envEditItem) Target() string  { return i.edit.Path }
+func (i envEditItem) Size() int64     { return 0 }
+func (i envEditItem) Files() int64    { return 1 }
+func (i envEditItem) Risk() RiskLevel { return RiskMedium }
+
+func (i envEditItem) Describe() string {
+	return fmt.Sprintf("Edit %s (%d change(s))", i.edit.Path, i.edit.accepted())
+}
+
+// Operations is one sed per accepted hunk of a profile, or the value the
+// registry PATH is set to.
+func (i envEditItem) Operations() []string {
+	if i.edit.accepted() == 0 {
+		return nil
+	}
+	if i.edit.Registry {
+		return []string{fmt.Sprintf("set %s=%s", i.edit.Path, strings.Join(i.edit.result(), ";"))}
+	}
+	var ops []string
+	// Later hunks first, so each line number still holds when it runs
+	for j := len(i.edit.Hunks) - 1; j >= 0; j-- {
+		hunk := i.edit.Hunks[j]
+		if !hunk.Accept {
+			continue
+		}
+		lines := fmt.Sprintf("%d,%d", hunk.Start+1, hunk.Start+hunk.Count)
+		if hunk.Count == 1 {
+			lines = fmt.Sprint(hunk.Start + 1)
+		}
+		ops = append(ops, fmt.Sprintf("sed -i '%sd' %s", lines, quoteOperand(i.edit.Path)))
+	}
+	return ops
+}
+
+func (i envEditItem) Execute(env planEnv) error {
+	if i.edit.accepted() == 0 {
+		return nil
+	}
+	change, err := applyProfileEdit(i.edit, env.backupDir, time.Now())
+	if err != nil {
+		return err
+	}
+	return appendEnvChange(env.journal, change)
+}
+
+// symlinkItem removes a link, such as /usr/local/bin/go, that points into a
+// root being removed and would dangle afterwards, or points it elsewhere.
+type symlinkItem struct {
+	link    string
+	dest    string
+	root    string // the root it points into; the link stays if the root does
+	repoint string // where to point the link instead of removing it
+}
+
+func (i symlinkItem) Kind() PlanItemKind { return PlanSymlink }
+func (i symlinkItem) Target() string     { return i.link }
+func (i symlinkItem) Size() int64        { return 0 }
+func (i symlinkItem) Files() int64       { return 1 }
+func (i symlinkItem) Risk() RiskLevel    { return RiskLow }
+
+func (i symlinkItem) Describe() string {
+	if i.repoint != "" {
+		return fmt.Sprintf("Repoint link %s -> %s (was %s)", i.link, i.repoint, i.dest)
+	}
+	return fmt.Sprintf("Remove link %s -> %s", i.link, i.dest)
+}
+
+func (i symlinkItem) Operations() []string {
+	if i.repoint != "" {
+		return []string{"ln -sfn " + quoteOperand(i.repoint) + " " + quoteOperand(i.link)}
+	}
+	return []string{"rm " + quoteOperand(i.link)}
+}
+
+func (i symlinkItem) Execute(env planEnv) error {
+	// Only ever the link itself, and only if nobody repointed it meanwhile
+	if dest, err := os.Readlink(i.link); err != nil || dest != i.dest {
+		return fmt.Errorf("%s no longer points at %s", i.link, i.dest)
+	}
+	if i.repoint != "" {
+		return fsys.Symlink(i.repoint, i.link)
+	}
+	return fsys.RemoveAll(i.link)
+}
+
+// withinDir reports whether path is dir or below it.
+func withinDir(path, dir string) bool {
+	if dir == "" {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// installationPlanItem is the item that removes install.
+func installationPlanItem(install GoInstallation, home string) PlanItem {
+	if packageRemovalCommand(install) != nil {
+		return packageUninstallItem{install: install}
+	}
+	return installationItem{install: install, home: home}
+}
+
+// symlinkDirs are where installers and users typically link go and gofmt.
+func symlinkDirs(home string) []string {
+	return []string{"/usr/local/bin", "/usr/bin", "/opt/homebrew/bin", filepath.Join(home, "bin"), filepath.Join(home, ".local", "bin")}
+}
+
+// findGoSymlinks returns items for the links in dirs that resolve into any
+// of roots.
+func findGoSymlinks(dirs, roots []string) []PlanItem {
+	var items []PlanItem
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.Type()&os.ModeSymlink == 0 {
+				continue
+			}
+			link := filepath.Join(dir, entry.Name())
+			dest, err := os.Readlink(link)
+			if err != nil {
+				continue
+			}
+			resolved := dest
+			if !filepath.IsAbs(resolved) {
+				resolved = filepath.Join(dir, resolved)
+			}
+			for _, root := range roots {
+				if withinDir(filepath.Clean(resolved), root) {
+					items = append(items, symlinkItem{link: link, dest: dest, root: root})
+					break
+				}
+			}
+		}
+	}
+	return items
+}
+
+// buildPlan is every item removing installations entails: the installations
+// themselves and the links they were found through, then links into them
+// from the bin directories in scope. kept are the installations staying,
+// which links may be repointed at.
+func buildPlan(installations, kept []GoInstallation, home string) []PlanItem {
+	var items []PlanItem
+	var roots []string
+	linkRoots := make(map[string]string) // link removed with a root -> the root
+	for _, install := range installations {
+		item := installationPlanItem(install, home)
+		items = append(items, item)
+		if install.Blocked != "" {
+			continue
+		}
+		roots = append(roots, install.Path)
+		if _, ok := item.(installationItem); !ok {
+			continue
+		}
+		for _, linkItem := range goRootLinkItems(install, kept) {
+			items = append(items, linkItem)
+			// Links through a repointed link keep working
+			if link := linkItem.(symlinkItem); link.repoint == "" {
+				roots = append(roots, link.link)
+				linkRoots[link.link] = install.Path
+			}
+		}
+	}
+	var dirs []string
+	for _, dir := range symlinkDirs(home) {
+		if currentScope().covers(pathScope(dir, home)) {
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, item := range findGoSymlinks(dirs, roots) {
+		if link, ok := item.(symlinkItem); ok && linkRoots[link.root] != "" {
+			link.root = linkRoots[link.root]
+			item = link
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// planSize is the total bytes the plan frees.
+func planSize(items []PlanItem) int64 {
+	var total int64
+	for _, item := range items {
+		total += item.Size()
+	}
+	return total
+}
+
+// plan is what removing the current selection involves, plus GOPATH when it
+// was opted into, in the order it runs. Blocked installations are left out,
+// and a simulation never looks at real links.
+func (m model) plan() []PlanItem {
+	var installs []GoInstallation
+	for _, install := range deferSelfLast(m.selfExe, m.selectedInstalls()) {
+		if install.Blocked == "" {
+			installs = append(installs, install)
+		}
+	}
+	var items []PlanItem
+	if m.opts.simulate {
+		for _, install := range installs {
+			items = append(items, installationPlanItem(install, ""))
+		}
+	} else {
+		home, _ := os.UserHomeDir()
+		var kept []GoInstallation
+		for _, install := range m.detectedInstalls {
+			if !m.isSelected(install) {
+				kept = append(kept, install)
+			}
+		}
+		items = buildPlan(installs, kept, home)
+	}
+	if gopath, ok := m.gopathPlanItem(); ok {
+		items = append(items, gopath)
+	}
+	return items
+}
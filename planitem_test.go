@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstallationPlanItem(t *testing.T) {
+	home := "/home/gopher"
+	testCases := []struct {
+		install GoInstallation
+		kind    PlanItemKind
+		risk    RiskLevel
+	}{
+		{GoInstallation{Path: "/usr/local/go", Source: "manual"}, PlanInstallation, RiskHigh},
+		{GoInstallation{Path: "/home/gopher/sdk/go1.22.0", Source: "go-sdk"}, PlanInstallation, RiskMedium},
+		{GoInstallation{Path: "/usr/local/go", PackageManager: "pkg", Package: "go"}, PlanPackageUninstall, RiskHigh},
+	}
+	for _, tc := range testCases {
+		item := installationPlanItem(tc.install, home)
+		if item.Kind() != tc.kind || item.Risk() != tc.risk {
+			t.Errorf("%s: got %s at %s risk, want %s at %s risk", tc.install.Path, item.Kind(), item.Risk(), tc.kind, tc.risk)
+		}
+	}
+	if risk := (cacheItem{}).Risk(); risk != RiskLow {
+		t.Errorf("Expected caches to be low risk, got %s", risk)
+	}
+}
+
+func TestWithinDir(t *testing.T) {
+	if !withinDir("/usr/local/go/bin/go", "/usr/local/go") || !withinDir("/usr/local/go", "/usr/local/go") {
+		t.Errorf("Expected paths inside the root to match")
+	}
+	if withinDir("/usr/local/go1.22/bin/go", "/usr/local/go") || withinDir("/usr/local/go", "") {
+		t.Errorf("Expected a sibling with a common prefix not to match")
+	}
+}
+
+func TestFindGoSymlinks(t *testing.T) {
+	skipInAuditBuild(t)
+	root := fakeGoRoot(t, "VERSION", "bin/go")
+	os.WriteFile(filepath.Join(root, "bin", "gofmt"), []byte("#!/bin/sh\n"), 0755)
+	bin := t.TempDir()
+	os.Symlink(filepath.Join(root, "bin", "go"), filepath.Join(bin, "go"))
+	rel, _ := filepath.Rel(bin, filepath.Join(root, "bin", "gofmt"))
+	os.Symlink(rel, filepath.Join(bin, "gofmt"))
+	os.Symlink("/usr/bin/env", filepath.Join(bin, "env"))
+
+	items := findGoSymlinks([]string{bin, filepath.Join(bin, "missing")}, []string{root})
+	if len(items) != 2 {
+		t.Fatalf("Expected the two links into the root, got %+v", items)
+	}
+	for _, item := range items {
+		if item.Kind() != PlanSymlink {
+			t.Errorf("Unexpected item kind %s", item.Kind())
+		}
+	}
+
+	os.Remove(filepath.Join(bin, "go"))
+	os.Symlink("/usr/bin/env", filepath.Join(bin, "go"))
+	if err := items[0].Execute(planEnv{}); err == nil {
+		t.Errorf("Expected a repointed link to be left alone")
+	}
+	if err := items[1].Execute(planEnv{}); err != nil {
+		t.Fatalf("Removing the link failed: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(bin, "gofmt")); !os.IsNotExist(err) {
+		t.Errorf("Expected the link to be removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "bin", "gofmt")); err != nil {
+		t.Errorf("Expected the link target to survive: %v", err)
+	}
+}
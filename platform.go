@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// officialGoPaths returns the locations the upstream installer (or the
+// platform's canonical port) puts GOROOT for the given GOOS. Unknown
+// platforms only get /usr/local/go rather than the Linux list.
+func officialGoPaths(goos string) []string {
+	switch goos {
+	case "windows":
+		return []string{
+			filepath.Join(os.Getenv("USERPROFILE"), "go"),
+			filepath.Join(os.Getenv("ProgramFiles"), "Go"),
+			"C:\\Go",
+		}
+	case "darwin":
+		return []string{
+			"/usr/local/go",
+			"/opt/go",
+		}
+	case "linux":
+		return []string{
+			"/usr/local/go",
+			"/opt/go",
+			"/usr/lib/go",
+		}
+	case "freebsd", "dragonfly", "openbsd":
+		return []string{
+			"/usr/local/go",
+		}
+	case "netbsd":
+		return []string{
+			"/usr/pkg/go",
+			"/usr/local/go",
+		}
+	case "android":
+		return []string{
+			filepath.Join(termuxPrefix(), "lib", "go"),
+		}
+	default:
+		return []string{
+			"/usr/local/go",
+		}
+	}
+}
+
+// packageOwner asks the native package tools whether the go binary under
+// goRoot belongs to an installed package, returning the package name and the
+// package manager that owns it.
+func packageOwner(goos, goRoot string) (string, string) {
+	goBin := filepath.Join(goRoot, "bin", "go")
+
+	var args []string
+	var manager string
+	switch goos {
+	case "freebsd", "dragonfly":
+		// Prints e.g. "go122-1.22.5"
+		args = []string{"pkg", "which", "-q", goBin}
+		manager = "pkg"
+	case "openbsd":
+		args = []string{"pkg_info", "-q", "-E", goBin}
+		manager = "pkg_add"
+	case "netbsd":
+		args = []string{"pkg_info", "-Fe", goBin}
+		manager = "pkgsrc"
+	case "android":
+		// Prints e.g. "golang: /data/data/com.termux/files/usr/lib/go/bin/go"
+		output, err := commandOutput("dpkg", "-S", goBin)
+		if err != nil {
+			return "", ""
+		}
+		name, _, found := strings.Cut(string(output), ":")
+		if !found {
+			return "", ""
+		}
+		return strings.TrimSpace(name), "termux"
+	case "linux":
+		if !isAlpine() {
+			return "", ""
+		}
+		// Prints e.g. "/usr/lib/go/bin/go is owned by go-1.22.5-r0"
+		output, err := commandOutput("apk", "info", "--who-owns", goBin)
+		if err != nil {
+			return "", ""
+		}
+		_, owner, found := strings.Cut(string(output), " is owned by ")
+		if !found {
+			return "", ""
+		}
+		return stripApkVersion(strings.TrimSpace(owner)), "apk"
+	default:
+		return "", ""
+	}
+
+	output, err := commandOutput(args[0], args[1:]...)
+	if err != nil {
+		return "", ""
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) == 0 {
+		return "", ""
+	}
+	return stripPackageVersion(fields[0]), manager
+}
+
+func isAlpine() bool {
+	_, err := os.Stat("/etc/alpine-release")
+	return err == nil
+}
+
+// termuxPrefix returns the Termux installation prefix, which is exported as
+// $PREFIX inside Termux sessions.
+func termuxPrefix() string {
+	if prefix := os.Getenv("PREFIX"); prefix != "" {
+		return prefix
+	}
+	return "/data/data/com.termux/files/usr"
+}
+
+// stripPackageVersion turns "go122-1.22.5,1" into "go122".
+func stripPackageVersion(pkgVersion string) string {
+	if idx := strings.LastIndex(pkgVersion, "-"); idx > 0 {
+		return pkgVersion[:idx]
+	}
+	return pkgVersion
+}
+
+// stripApkVersion turns "go-1.22.5-r0" into "go"; apk appends a release
+// suffix after the version.
+func stripApkVersion(pkgVersion string) string {
+	return stripPackageVersion(stripPackageVersion(pkgVersion))
+}
+
+// packageSource maps a package manager to the Source label shown to users.
+func packageSource(manager string) string {
+	switch manager {
+	case "apk", "termux":
+		return manager
+	}
+	return "package_manager"
+}
+
+func newPackageInstallation(path, manager, pkgName string) GoInstallation {
+	install := newInstallation(path, packageSource(manager))
+	install.PackageManager = manager
+	install.Package = pkgName
+	install.Evidence = append(install.Evidence, fmt.Sprintf("%s package database lists %s as owner", manager, pkgName))
+	return install
+}
+
+// packageRemovalCommand returns the command line that removes a package-owned
+// installation, or nil when the files should be removed directly.
+func packageRemovalCommand(install GoInstallation) []string {
+	if install.Package == "" {
+		return nil
+	}
+	switch install.PackageManager {
+	case "pkg":
+		return []string{"pkg", "delete", "-y", install.Package}
+	case "pkg_add", "pkgsrc":
+		return []string{"pkg_delete", install.Package}
+	case "apk":
+		return []string{"apk", "del", install.Package}
+	case "termux":
+		return []string{"pkg", "uninstall", "-y", install.Package}
+	case "snap":
+		return []string{"snap", "remove", install.Package}
+	case "scoop":
+		return []string{"scoop", "uninstall", install.Package}
+	case "plugin":
+		return []string{install.Package, "--remove", install.Path}
+	}
+	return nil
+}
+
+func runPackageRemoval(install GoInstallation) error {
+	args := packageRemovalCommand(install)
+	if args == nil {
+		return fmt.Errorf("no package manager removal available for %s", install.Path)
+	}
+	output, err := runRemovalCommand(args)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOfficialGoPaths(t *testing.T) {
+	testCases := []struct {
+		goos     string
+		expected []string
+	}{
+		{"linux", []string{"/usr/local/go", "/opt/go", "/usr/lib/go"}},
+		{"freebsd", []string{"/usr/local/go"}},
+		{"openbsd", []string{"/usr/local/go"}},
+		{"netbsd", []string{"/usr/pkg/go", "/usr/local/go"}},
+		{"plan9", []string{"/usr/local/go"}},
+	}
+
+	for _, tc := range testCases {
+		result := officialGoPaths(tc.goos)
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("officialGoPaths(%s) = %v, expected %v", tc.goos, result, tc.expected)
+		}
+	}
+}
+
+func TestStripPackageVersion(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"go122-1.22.5", "go122"},
+		{"go-1.21.4", "go"},
+		{"go-1.22.5,1", "go"},
+		{"go", "go"},
+	}
+
+	for _, tc := range testCases {
+		result := stripPackageVersion(tc.input)
+		if result != tc.expected {
+			t.Errorf("stripPackageVersion(%s) = %s, expected %s", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestPackageRemovalCommand(t *testing.T) {
+	testCases := []struct {
+		install  GoInstallation
+		expected []string
+	}{
+		{GoInstallation{PackageManager: "pkg", Package: "go122"}, []string{"pkg", "delete", "-y", "go122"}},
+		{GoInstallation{PackageManager: "pkg_add", Package: "go"}, []string{"pkg_delete", "go"}},
+		{GoInstallation{PackageManager: "pkgsrc", Package: "go121"}, []string{"pkg_delete", "go121"}},
+		{GoInstallation{PackageManager: "apk", Package: "go"}, []string{"apk", "del", "go"}},
+		{GoInstallation{PackageManager: "termux", Package: "golang"}, []string{"pkg", "uninstall", "-y", "golang"}},
+		{GoInstallation{Source: "official"}, nil},
+	}
+
+	for _, tc := range testCases {
+		result := packageRemovalCommand(tc.install)
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("packageRemovalCommand(%+v) = %v, expected %v", tc.install, result, tc.expected)
+		}
+	}
+}
+
+func TestStripApkVersion(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"go-1.22.5-r0", "go"},
+		{"go-1.21.10-r1", "go"},
+	}
+
+	for _, tc := range testCases {
+		result := stripApkVersion(tc.input)
+		if result != tc.expected {
+			t.Errorf("stripApkVersion(%s) = %s, expected %s", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestTermuxPaths(t *testing.T) {
+	t.Setenv("PREFIX", "/data/data/com.termux/files/usr")
+
+	expected := []string{"/data/data/com.termux/files/usr/lib/go"}
+	if result := officialGoPaths("android"); !reflect.DeepEqual(result, expected) {
+		t.Errorf("officialGoPaths(android) = %v, expected %v", result, expected)
+	}
+}
+
+func TestPackageSource(t *testing.T) {
+	testCases := map[string]string{
+		"apk":    "apk",
+		"termux": "termux",
+		"pkg":    "package_manager",
+	}
+
+	for manager, expected := range testCases {
+		if result := packageSource(manager); result != expected {
+			t.Errorf("packageSource(%s) = %s, expected %s", manager, result, expected)
+		}
+	}
+}
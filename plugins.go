@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Plugins are executables dropped into the plugins directory next to
+// config.json (~/.config/fugo/plugins on Linux). Running one with
+// --detect must print a JSON array of candidates on stdout:
+//
+//	[{"path": "/opt/corp/go", "version": "go version go1.22.5 linux/amd64", "delegate_removal": true}]
+//
+// Candidates with delegate_removal set are removed by running the plugin with
+// --remove <path> instead of fu-go deleting the files itself.
+type pluginCandidate struct {
+	GoInstallation
+	DelegateRemoval bool `json:"delegate_removal"`
+}
+
+type pluginDetector struct {
+	path string
+}
+
+func (p pluginDetector) Name() string {
+	name := filepath.Base(p.path)
+	return "plugin:" + strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+func (p pluginDetector) applies(goos string) bool { return true }
+
+func (p pluginDetector) probes() []string { return []string{p.path + " --detect"} }
+
+func (p pluginDetector) Detect(ctx context.Context) ([]GoInstallation, error) {
+	output, err := commandOutputContext(ctx, p.path, "--detect")
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s --detect failed: %w", p.path, err)
+	}
+	return parsePluginOutput(p.path, p.Name(), output)
+}
+
+func parsePluginOutput(pluginPath, name string, output []byte) ([]GoInstallation, error) {
+	var candidates []pluginCandidate
+	if err := json.Unmarshal(output, &candidates); err != nil {
+		return nil, fmt.Errorf("plugin %s returned invalid JSON: %v", pluginPath, err)
+	}
+
+	var installations []GoInstallation
+	for _, candidate := range candidates {
+		if candidate.Path == "" || !filepath.IsAbs(candidate.Path) {
+			return installations, fmt.Errorf("plugin %s returned a non-absolute path: %q", pluginPath, candidate.Path)
+		}
+		if isCriticalPath(candidate.Path) {
+			return installations, fmt.Errorf("plugin %s returned critical system directory: %s", pluginPath, candidate.Path)
+		}
+		if info, err := os.Stat(candidate.Path); err != nil || !info.IsDir() {
+			continue
+		}
+
+		source := candidate.Source
+		if source == "" {
+			source = name
+		}
+		install := newInstallation(candidate.Path, source)
+		if candidate.Version != "" {
+			install.Version = candidate.Version
+			install.SemVer, install.GOOS, install.GOARCH = parseGoVersion(candidate.Version)
+			annotateSupport(&install, time.Now())
+		}
+		if candidate.DelegateRemoval {
+			install.PackageManager = "plugin"
+			install.Package = pluginPath
+		}
+		install.Evidence = append([]string{fmt.Sprintf("reported by %s --detect", pluginPath)}, install.Evidence...)
+		installations = append(installations, install)
+	}
+	return installations, nil
+}
+
+func pluginDir() (string, error) {
+	dirs, err := resolveDirs()
+	if err != nil {
+		return "", err
+	}
+	return dirs.plugins(), nil
+}
+
+// discoverPlugins returns a detector for every executable in dir, sorted by
+// file name so plugin results have a stable order.
+func discoverPlugins(dir string) []Detector {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !isExecutable(entry.Name(), info.Mode()) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	var plugins []Detector
+	for _, name := range names {
+		plugins = append(plugins, pluginDetector{path: filepath.Join(dir, name)})
+	}
+	return plugins
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestParsePluginOutput(t *testing.T) {
+	goRoot := t.TempDir()
+	output := []byte(`[
+		{"path": "` + filepath.ToSlash(goRoot) + `", "version": "go version go1.22.5 linux/amd64", "delegate_removal": true},
+		{"path": "/does/not/exist"}
+	]`)
+
+	installations, err := parsePluginOutput("/plugins/corp", "plugin:corp", output)
+	if err != nil {
+		t.Fatalf("parsePluginOutput returned error: %v", err)
+	}
+	if len(installations) != 1 {
+		t.Fatalf("Expected 1 installation, got %d", len(installations))
+	}
+	install := installations[0]
+	if install.Source != "plugin:corp" {
+		t.Errorf("Expected source plugin:corp, got %s", install.Source)
+	}
+	if install.SemVer != "1.22.5" {
+		t.Errorf("Expected semver 1.22.5, got %s", install.SemVer)
+	}
+	args := packageRemovalCommand(install)
+	if len(args) != 3 || args[0] != "/plugins/corp" || args[1] != "--remove" || args[2] != install.Path {
+		t.Errorf("Expected plugin removal command, got %v", args)
+	}
+}
+
+func TestParsePluginOutputRejectsUnsafePaths(t *testing.T) {
+	testCases := []string{
+		`[{"path": "/"}]`,
+		`[{"path": "relative/go"}]`,
+		`{"path": "/usr/local/go"}`,
+	}
+
+	for _, output := range testCases {
+		if _, err := parsePluginOutput("/plugins/bad", "plugin:bad", []byte(output)); err == nil {
+			t.Errorf("Expected error for plugin output %s", output)
+		}
+	}
+}
+
+func TestPluginDetector(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins are not executable on Windows")
+	}
+
+	dir := t.TempDir()
+	goRoot := t.TempDir()
+	script := "#!/bin/sh\n[ \"$1\" = \"--detect\" ] && echo '[{\"path\": \"" + goRoot + "\"}]'\n"
+	if err := os.WriteFile(filepath.Join(dir, "corp.sh"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write plugin: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a plugin"), 0644); err != nil {
+		t.Fatalf("Failed to write README: %v", err)
+	}
+
+	plugins := discoverPlugins(dir)
+	if len(plugins) != 1 {
+		t.Fatalf("Expected 1 plugin, got %d", len(plugins))
+	}
+	if plugins[0].Name() != "plugin:corp" {
+		t.Errorf("Expected name plugin:corp, got %s", plugins[0].Name())
+	}
+
+	installations, err := plugins[0].Detect(context.Background())
+	if err != nil {
+		t.Fatalf("Detect returned error: %v", err)
+	}
+	if len(installations) != 1 || installations[0].Path != goRoot {
+		t.Errorf("Expected %s, got %v", goRoot, installations)
+	}
+	if packageRemovalCommand(installations[0]) != nil {
+		t.Error("Expected no delegated removal without delegate_removal")
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Administrators can deploy a machine-wide policy.yaml that restricts what
+// fu-go may do. It lives outside the user's config directory, is read on every
+// run and always wins over config.json and command line flags. A policy.json
+// with the same settings is read instead where an older deployment put one.
+// On Windows the same settings can come from the registry instead, where
+// Group Policy puts them; the registry wins over the file when it has the
+// key. A policy that cannot be parsed stops fu-go rather than being ignored.
+
+// Policy holds the restrictions from policy.yaml. The YAML keys are the json
+// tags below.
+type Policy struct {
+	// ForbiddenSources are installation sources, such as "package_manager",
+	// that are never removed.
+	ForbiddenSources []string `json:"forbidden_sources,omitempty"`
+	// ProtectedPaths are never removed, nor is anything inside them.
+	ProtectedPaths []string `json:"protected_paths,omitempty"`
+	// RequireBackup archives installations before every live removal.
+	RequireBackup bool `json:"require_backup,omitempty"`
+	// DryRunOnly keeps fu-go from ever leaving dry-run mode.
+	DryRunOnly bool `json:"dry_run_only,omitempty"`
+	// Offline refuses all network access, as --offline does.
+	Offline bool `json:"offline,omitempty"`
+	// DisableSelfUpdate refuses self-update; updates come from the
+	// administrator's software distribution instead.
+	DisableSelfUpdate bool `json:"disable_self_update,omitempty"`
+	// DenyCacheCleanup refuses clean-cache, for build agents whose caches
+	// are managed elsewhere.
+	DenyCacheCleanup bool `json:"deny_cache_cleanup,omitempty"`
+	// FinalChallenge overrides the user's final_challenge.
+	FinalChallenge *ChallengeConfig `json:"final_challenge,omitempty"`
+	// ApprovalKeys maps approver names to the base64 Ed25519 public keys
+	// `fu-go apply` trusts.
+	ApprovalKeys map[string]string `json:"approval_keys,omitempty"`
+	// RequireApproval allows live removals only through `fu-go apply` with a
+	// plan signed by two approvers.
+	RequireApproval bool `json:"require_approval,omitempty"`
+
+	path string
+}
+
+// policyNames are the policy files fu-go reads, in the directory from
+// policyDir. Only one of them may exist.
+var policyNames = []string{"policy.yaml", "policy.json"}
+
+// policyDir is where administrators put the machine policy: /etc/fugo on
+// Unix and %ProgramData%\fugo on Windows.
+func policyDir(goos string, getenv func(string) string) string {
+	if goos == "windows" {
+		programData := getenv("ProgramData")
+		if programData == "" {
+			programData = `C:\ProgramData`
+		}
+		return filepath.Join(programData, "fugo")
+	}
+	return "/etc/fugo"
+}
+
+// policyPath is the machine policy file.
+func policyPath(goos string, getenv func(string) string) string {
+	return policyFileIn(policyDir(goos, getenv))
+}
+
+// policyFileIn is the one of policyNames that exists in dir, or policy.yaml
+// when neither does.
+func policyFileIn(dir string) string {
+	for _, name := range policyNames {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return filepath.Join(dir, name)
+		}
+	}
+	return filepath.Join(dir, policyNames[0])
+}
+
+// checkSinglePolicy refuses a policy directory holding both files, as
+// neither can be said to be the policy.
+func checkSinglePolicy(dir string) error {
+	var found []string
+	for _, name := range policyNames {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			found = append(found, name)
+		}
+	}
+	if len(found) > 1 {
+		return fmt.Errorf("both %s and %s exist in %s; keep one", found[0], found[1], dir)
+	}
+	return nil
+}
+
+// loadPolicyFile reads the policy at path. A missing file is an empty policy.
+func loadPolicyFile(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Policy{}, nil
+	}
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to read policy %s: %v", path, err)
+	}
+	return parsePolicy(data, path)
+}
+
+// parsePolicy decodes and checks the policy read from source, as YAML when
+// source is a .yaml or .yml file and as JSON otherwise.
+func parsePolicy(data []byte, path string) (Policy, error) {
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return Policy{}, fmt.Errorf("failed to parse policy %s: %v", path, err)
+		}
+		converted, err := json.Marshal(doc)
+		if err != nil {
+			return Policy{}, fmt.Errorf("failed to parse policy %s: %v", path, err)
+		}
+		data = converted
+	}
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return Policy{}, fmt.Errorf("failed to parse policy %s: %v", path, err)
+	}
+	for _, protected := range policy.ProtectedPaths {
+		if !filepath.IsAbs(protected) {
+			return Policy{}, fmt.Errorf("invalid policy %s: protected_paths must be absolute, got %q", path, protected)
+		}
+	}
+	if policy.FinalChallenge != nil {
+		if err := policy.FinalChallenge.validate(); err != nil {
+			return Policy{}, fmt.Errorf("invalid policy %s: %v", path, err)
+		}
+	}
+	policy.path = path
+	return policy, nil
+}
+
+func loadPolicy() (Policy, error) {
+	values, source, err := currentPlatform.managedPolicy()
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to read policy %s: %v", source, err)
+	}
+	if values == nil {
+		if err := checkSinglePolicy(policyDir(runtime.GOOS, os.Getenv)); err != nil {
+			return Policy{}, err
+		}
+		return loadPolicyFile(policyPath(runtime.GOOS, os.Getenv))
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to parse policy %s: %v", source, err)
+	}
+	return parsePolicy(data, source)
+}
+
+var machinePolicy atomic.Pointer[Policy]
+
+func setPolicy(policy Policy) {
+	machinePolicy.Store(&policy)
+}
+
+func currentPolicy() Policy {
+	if policy := machinePolicy.Load(); policy != nil {
+		return *policy
+	}
+	return Policy{}
+}
+
+// active reports whether a policy file was found.
+func (p Policy) active() bool {
+	return p.path != ""
+}
+
+// blocker returns why the policy forbids removing install, or "".
+func (p Policy) blocker(install GoInstallation) string {
+	for _, source := range p.ForbiddenSources {
+		if install.Source == source {
+			return fmt.Sprintf("%s installations are protected by the machine policy", source)
+		}
+	}
+	for _, protected := range p.ProtectedPaths {
+		if pathWithin(install.Path, protected) || pathWithin(protected, install.Path) {
+			return fmt.Sprintf("%s is protected by the machine policy", protected)
+		}
+	}
+	return ""
+}
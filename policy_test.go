@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func withPolicy(t *testing.T, policy Policy) {
+	t.Helper()
+	previous := currentPolicy()
+	setPolicy(policy)
+	t.Cleanup(func() { setPolicy(previous) })
+}
+
+func TestLoadPolicyFile(t *testing.T) {
+	dir := t.TempDir()
+	if policy, err := loadPolicyFile(filepath.Join(dir, "missing.json")); err != nil || policy.active() {
+		t.Errorf("Expected an inactive policy for a missing file, got %+v, %v", policy, err)
+	}
+
+	testCases := []struct {
+		content string
+		wantErr bool
+	}{
+		{`{"forbidden_sources": ["package_manager"], "require_backup": true}`, false},
+		{`{"protected_paths": ["relative/go"]}`, true},
+		{`not json`, true},
+	}
+	for _, tc := range testCases {
+		path := filepath.Join(dir, "policy.json")
+		if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+			t.Fatalf("Failed to write policy: %v", err)
+		}
+		policy, err := loadPolicyFile(path)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("Expected error for policy %q", tc.content)
+			}
+			continue
+		}
+		if err != nil || !policy.active() || !policy.RequireBackup {
+			t.Errorf("Unexpected result for %q: %+v, %v", tc.content, policy, err)
+		}
+	}
+}
+
+func TestPolicyPath(t *testing.T) {
+	getenv := func(key string) string {
+		if key == "ProgramData" {
+			return `D:\ProgramData`
+		}
+		return ""
+	}
+	if got := policyDir("linux", getenv); got != "/etc/fugo" {
+		t.Errorf("Unexpected Linux policy directory %s", got)
+	}
+	if got := policyDir("windows", getenv); got != filepath.Join(`D:\ProgramData`, "fugo") {
+		t.Errorf("Unexpected Windows policy directory %s", got)
+	}
+
+	dir := t.TempDir()
+	if got := policyFileIn(dir); got != filepath.Join(dir, "policy.yaml") {
+		t.Errorf("Expected policy.yaml without a policy, got %s", got)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "policy.json"), []byte(`{}`), 0644); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+	if got := policyFileIn(dir); got != filepath.Join(dir, "policy.json") {
+		t.Errorf("Expected an existing policy.json to be read, got %s", got)
+	}
+	if err := checkSinglePolicy(dir); err != nil {
+		t.Errorf("Expected one policy file to be accepted, got %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "policy.yaml"), []byte("{}"), 0644); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+	if err := checkSinglePolicy(dir); err == nil {
+		t.Error("Expected both policy.yaml and policy.json to be refused")
+	}
+}
+
+func TestLoadPolicyFileYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	content := `forbidden_sources:
+  - package_manager
+protected_paths: [/opt/buildtools/go]
+require_backup: true
+final_challenge:
+  type: destroy
+approval_keys:
+  alice: AAAA
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+	policy, err := loadPolicyFile(path)
+	if err != nil {
+		t.Fatalf("loadPolicyFile returned error: %v", err)
+	}
+	if !policy.RequireBackup || len(policy.ForbiddenSources) != 1 || policy.ProtectedPaths[0] != "/opt/buildtools/go" {
+		t.Errorf("Unexpected policy %+v", policy)
+	}
+	if policy.FinalChallenge == nil || policy.FinalChallenge.Type != "destroy" || policy.ApprovalKeys["alice"] != "AAAA" {
+		t.Errorf("Expected nested settings from YAML, got %+v", policy)
+	}
+
+	for _, bad := range []string{"require_backup: [", "protected_paths: [relative/go]", "require_backup: sometimes"} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatalf("Failed to write policy: %v", err)
+		}
+		if _, err := loadPolicyFile(path); err == nil {
+			t.Errorf("Expected error for policy %q", bad)
+		}
+	}
+}
+
+func TestPolicyBlocksInstallations(t *testing.T) {
+	root := t.TempDir()
+	withPolicy(t, Policy{
+		ForbiddenSources: []string{"package_manager"},
+		ProtectedPaths:   []string{filepath.Join(root, "protected")},
+	})
+
+	installations := []GoInstallation{
+		{Path: "/usr/lib/go-1.21", Source: "package_manager"},
+		{Path: filepath.Join(root, "protected", "go"), Source: "official"},
+		{Path: root, Source: "manual"},
+		{Path: filepath.Join(root, "sdk", "go1.22.5"), Source: "sdk"},
+	}
+	markBlockedInstallations(installations, true)
+
+	for i, wantBlocked := range []bool{true, true, true, false} {
+		if got := installations[i].Blocked != ""; got != wantBlocked {
+			t.Errorf("%s: blocked %v (%q), want %v", installations[i].Path, got, installations[i].Blocked, wantBlocked)
+		}
+	}
+}
+
+func TestManagedPolicy(t *testing.T) {
+	withPlatform(t, fakePlatform{platformBackend: nativePlatform{}, policy: map[string]any{
+		"require_backup":   true,
+		"protected_paths":  []string{"/opt/go"},
+		"final_challenge":  json.RawMessage(`{"type": "destroy"}`),
+		"unknown_settings": "are ignored, as in the file",
+	}})
+	policy, err := loadPolicy()
+	if err != nil {
+		t.Fatalf("loadPolicy returned error: %v", err)
+	}
+	if !policy.RequireBackup || len(policy.ProtectedPaths) != 1 || policy.FinalChallenge == nil || policy.path != `HKLM\SOFTWARE\Policies\fugo` {
+		t.Errorf("Expected the registry settings, got %+v", policy)
+	}
+
+	withPlatform(t, fakePlatform{platformBackend: nativePlatform{}, policy: map[string]any{"protected_paths": []string{"relative/go"}}})
+	if _, err := loadPolicy(); err == nil || !strings.Contains(err.Error(), "must be absolute") {
+		t.Errorf("Expected registry settings to be checked like the file, got %v", err)
+	}
+}
+
+func TestPolicyOverridesConfig(t *testing.T) {
+	skipInAuditBuild(t)
+	cfg := Config{DefaultMode: "live", BackupPolicy: "never"}
+	if cfg.startsInDryRun() || cfg.backupBeforeRemoval() {
+		t.Fatalf("Expected the config to choose live mode without backups")
+	}
+
+	withPolicy(t, Policy{DryRunOnly: true, RequireBackup: true})
+	if !cfg.startsInDryRun() || !cfg.backupBeforeRemoval() {
+		t.Errorf("Expected the policy to force dry runs and backups")
+	}
+
+	m := model{state: "confirm", dryRun: true}
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	if !updated.(model).dryRun {
+		t.Errorf("Expected d to leave dry-run mode on under a dry-run-only policy")
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// preflightResult records whether fu-go can carry out its part of the plan for
+// one path without elevated privileges.
+type preflightResult struct {
+	Path           string
+	Role           string // "installation" or "backup"
+	Err            error
+	NeedsElevation bool
+}
+
+func (r preflightResult) ok() bool {
+	return r.Err == nil && !r.NeedsElevation
+}
+
+// runPreflight checks every installation in the plan and the backup
+// destination before anything is touched.
+func runPreflight(installations []GoInstallation, backupDir string) []preflightResult {
+	elevated := isElevated()
+	var results []preflightResult
+
+	for _, install := range installations {
+		result := preflightResult{Path: install.Path, Role: "installation"}
+		if install.Blocked != "" {
+			result.Err = fmt.Errorf("cannot remove: %s", install.Blocked)
+		} else if packageRemovalCommand(install) != nil {
+			if managerNeedsRoot(install.PackageManager) && !elevated {
+				result.NeedsElevation = true
+				result.Err = fmt.Errorf("%s removal requires root", install.PackageManager)
+			}
+		} else if err := checkRemovableInstall(install); err != nil {
+			result.Err = err
+			result.NeedsElevation = !elevated
+		}
+		results = append(results, result)
+	}
+
+	backup := preflightResult{Path: backupDir, Role: "backup"}
+	if err := checkWriteAccess(backupDir); err != nil {
+		backup.Err = err
+	}
+	results = append(results, backup)
+
+	return results
+}
+
+// checkRemovable verifies that path and its parent directory are writable,
+// which is what removing the tree requires.
+func checkRemovable(path string) error {
+	if err := checkWriteAccess(path); err != nil {
+		return err
+	}
+	if err := checkWriteAccess(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("parent directory: %w", err)
+	}
+	return nil
+}
+
+// moduleCacheTree reports whether install is a toolchain the go command
+// unpacked into the module cache, where it leaves every directory read-only.
+func moduleCacheTree(install GoInstallation) bool {
+	return install.Source == "gotoolchain"
+}
+
+// checkRemovableInstall is checkRemovable for an installation. A read-only
+// module cache tree of the user's own is made writable before it is removed,
+// so only its parent has to be writable already.
+func checkRemovableInstall(install GoInstallation) error {
+	if moduleCacheTree(install) && ownedByCurrentUser(install.Path) {
+		if err := checkWriteAccess(filepath.Dir(install.Path)); err != nil {
+			return fmt.Errorf("parent directory: %w", err)
+		}
+		return nil
+	}
+	return checkRemovable(install.Path)
+}
+
+// ownedByCurrentUser reports whether the current user owns path, and so may
+// change its mode.
+func ownedByCurrentUser(path string) bool {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+	uid, ok := fileOwnerID(info)
+	return ok && uid == strconv.Itoa(os.Getuid())
+}
+
+// checkWriteAccess asks the file system whether dir is writable. Nothing is
+// written, so read-only mounts and integrity monitors are left alone.
+func checkWriteAccess(dir string) error {
+	if err := fsys.CheckWritable(dir); err != nil {
+		return fmt.Errorf("no write permission for %s: %w", dir, err)
+	}
+	return nil
+}
+
+// plannedPreflight is the preflight for what the plan still touches: the
+// selected installations and the backup destination. Deselecting a path
+// drops its problems from the permission check and the elevation advice.
+func (m model) plannedPreflight() []preflightResult {
+	selected := map[string]bool{}
+	for _, install := range m.selectedInstalls() {
+		selected[install.Path] = true
+	}
+	var results []preflightResult
+	for _, result := range m.preflight {
+		if result.Role != "installation" || selected[result.Path] {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// preflightNeedsElevation reports whether any installation in the plan can
+// only be removed with sudo/admin rights.
+func preflightNeedsElevation(results []preflightResult) bool {
+	for _, result := range results {
+		if result.NeedsElevation {
+			return true
+		}
+	}
+	return false
+}
+
+func preflightPassed(results []preflightResult) bool {
+	for _, result := range results {
+		if !result.ok() {
+			return false
+		}
+	}
+	return true
+}
+
+// managerNeedsRoot reports whether a delegated package manager removal has
+// to run as root.
+func managerNeedsRoot(manager string) bool {
+	switch manager {
+	case "pkg", "pkg_add", "pkgsrc", "apk", "snap":
+		return true
+	}
+	return false
+}
+
+// markBlockedInstallations records, for every installation fu-go would remove
+// itself, anything that makes removal impossible: read-only mounts, immutable
+// files, SIP, or mount points inside the tree. Package managers handle their
+// own trees.
+func markBlockedInstallations(installations []GoInstallation, allowCrossMounts bool) {
+	policy := currentPolicy()
+	for i := range installations {
+		if reason := policy.blocker(installations[i]); reason != "" {
+			installations[i].Blocked = reason
+			continue
+		}
+		if packageRemovalCommand(installations[i]) != nil {
+			continue
+		}
+		installations[i].Blocked = removalBlocker(installations[i].Path)
+		if installations[i].Blocked != "" || allowCrossMounts {
+			continue
+		}
+		if boundaries, err := findMountBoundaries(installations[i].Path); err == nil && len(boundaries) > 0 {
+			installations[i].Blocked = fmt.Sprintf("contains mount point %s (set allow_cross_mounts to override)", strings.Join(boundaries, ", "))
+		}
+	}
+}
+
+// isSIPProtectedPath reports whether macOS System Integrity Protection guards
+// path. /usr/local is the one SIP-free part of /usr.
+func isSIPProtectedPath(path string) bool {
+	cleanPath := filepath.Clean(path)
+	if cleanPath == "/usr/local" || strings.HasPrefix(cleanPath, "/usr/local/") {
+		return false
+	}
+	for _, protected := range []string{"/System", "/usr", "/bin", "/sbin"} {
+		if cleanPath == protected || strings.HasPrefix(cleanPath, protected+"/") {
+			return true
+		}
+	}
+	return false
+}
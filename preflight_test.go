@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRunPreflight(t *testing.T) {
+	tempDir := t.TempDir()
+	goRoot := filepath.Join(tempDir, "go")
+	backupDir := filepath.Join(tempDir, "backups")
+	for _, dir := range []string{goRoot, backupDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	results := runPreflight([]GoInstallation{{Path: goRoot, Source: "official"}}, backupDir)
+	if len(results) != 2 {
+		t.Fatalf("Expected results for installation and backup, got %d", len(results))
+	}
+	if results[0].Role != "installation" || results[1].Role != "backup" {
+		t.Errorf("Unexpected roles: %s, %s", results[0].Role, results[1].Role)
+	}
+	if !preflightPassed(results) {
+		t.Errorf("Expected preflight to pass for writable temp dirs, got %+v", results)
+	}
+	if preflightNeedsElevation(results) {
+		t.Error("Expected no elevation for writable temp dirs")
+	}
+}
+
+func TestRunPreflightReadOnly(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced for this user")
+	}
+
+	tempDir := t.TempDir()
+	goRoot := filepath.Join(tempDir, "go")
+	if err := os.MkdirAll(goRoot, 0755); err != nil {
+		t.Fatalf("Failed to create %s: %v", goRoot, err)
+	}
+	if err := os.Chmod(goRoot, 0555); err != nil {
+		t.Fatalf("Failed to chmod: %v", err)
+	}
+	defer os.Chmod(goRoot, 0755)
+
+	results := runPreflight([]GoInstallation{{Path: goRoot, Source: "official"}}, tempDir)
+	if results[0].Err == nil {
+		t.Error("Expected read-only installation to fail preflight")
+	}
+	if !preflightNeedsElevation(results) {
+		t.Error("Expected read-only installation to need elevation")
+	}
+}
+
+func TestReadOnlyModuleCacheToolchain(t *testing.T) {
+	skipInAuditBuild(t)
+	if runtime.GOOS == "windows" {
+		t.Skip("directory modes do not stop removal on Windows")
+	}
+	toolchain := filepath.Join(t.TempDir(), "golang.org", "toolchain@v0.0.1-go1.23.0.linux-amd64")
+	os.MkdirAll(filepath.Join(toolchain, "bin"), 0755)
+	os.WriteFile(filepath.Join(toolchain, "bin", "go"), []byte("go"), 0555)
+	// As the go command leaves it
+	os.Chmod(filepath.Join(toolchain, "bin"), 0555)
+	os.Chmod(toolchain, 0555)
+	defer os.Chmod(toolchain, 0755)
+	install := GoInstallation{Path: toolchain, Source: "gotoolchain"}
+
+	if results := runPreflight([]GoInstallation{install}, filepath.Dir(toolchain)); !preflightPassed(results) {
+		t.Errorf("Expected the user's own toolchain to pass preflight, got %+v", results[0])
+	}
+	if err := installationPlanItem(install, "").Execute(planEnv{}); err != nil {
+		t.Fatalf("Expected the toolchain to be removed, got %v", err)
+	}
+	if _, err := os.Stat(toolchain); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be gone, got %v", toolchain, err)
+	}
+}
+
+func TestRunPreflightPackageManager(t *testing.T) {
+	install := GoInstallation{Path: "/usr/lib/go", Source: "apk", PackageManager: "apk", Package: "go"}
+	results := runPreflight([]GoInstallation{install}, t.TempDir())
+
+	if isElevated() {
+		if !results[0].ok() {
+			t.Errorf("Expected apk removal to pass as root, got %v", results[0].Err)
+		}
+	} else if !results[0].NeedsElevation {
+		t.Error("Expected apk removal to need elevation")
+	}
+}
+
+func TestCheckWriteAccessLeavesNoFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := checkWriteAccess(dir); err != nil {
+		t.Fatalf("Expected temp dir to be writable, got: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected write check to leave no files behind, found %d", len(entries))
+	}
+}
+
+func TestIsSIPProtectedPath(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected bool
+	}{
+		{"/usr/local/go", false},
+		{"/usr/local", false},
+		{"/usr/bin/go", true},
+		{"/usr/libexec/go", true},
+		{"/System/Library/go", true},
+		{"/opt/homebrew/Cellar/go", false},
+		{"/usrlocal/go", false},
+	}
+
+	for _, tc := range testCases {
+		if result := isSIPProtectedPath(tc.path); result != tc.expected {
+			t.Errorf("isSIPProtectedPath(%s) = %v, expected %v", tc.path, result, tc.expected)
+		}
+	}
+}
+
+func TestMarkBlockedInstallations(t *testing.T) {
+	installations := []GoInstallation{
+		{Path: t.TempDir(), Source: "official"},
+		{Path: "/snap/go/10", Source: "snap", PackageManager: "snap", Package: "go"},
+	}
+	markBlockedInstallations(installations, false)
+
+	if installations[0].Blocked != "" {
+		t.Errorf("Expected writable temp dir to be removable, got %q", installations[0].Blocked)
+	}
+	if installations[1].Blocked != "" {
+		t.Errorf("Expected package-managed install to be left to its manager, got %q", installations[1].Blocked)
+	}
+
+	results := runPreflight([]GoInstallation{{Path: "/ro/go", Blocked: "on a read-only file system"}}, t.TempDir())
+	if results[0].Err == nil || results[0].NeedsElevation {
+		t.Errorf("Expected blocked install to fail without elevation, got %+v", results[0])
+	}
+}
+
+func TestPlannedPreflightFollowsSelection(t *testing.T) {
+	m := newListTestModel()
+	m.preflight = []preflightResult{
+		{Path: "/usr/local/go", Role: "installation"},
+		{Path: "/root/.gvm/gos/go1.21", Role: "installation", Err: errors.New("no write permission"), NeedsElevation: true},
+		{Path: "/backups", Role: "backup"},
+	}
+	if !strings.Contains(m.renderPermissions(), "sudo") {
+		t.Errorf("Expected the unwritable selected path to ask for elevation, got %q", m.renderPermissions())
+	}
+
+	m.selection = map[string]bool{"/root/.gvm/gos/go1.21": false}
+	results := m.plannedPreflight()
+	if len(results) != 2 || results[0].Path != "/usr/local/go" || results[1].Role != "backup" {
+		t.Errorf("Expected the deselected path to leave the preflight, got %+v", results)
+	}
+	if view := m.renderPermissions(); !strings.Contains(view, "passed") || strings.Contains(view, "go1.21") {
+		t.Errorf("Expected the check to pass once the only unwritable path is deselected, got %q", view)
+	}
+}
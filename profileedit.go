@@ -0,0 +1,359 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// After a live removal, shell profiles, /etc/paths.d entries and the Windows
+// user PATH often still point at the removed roots. fu-go proposes deleting
+// those lines, shows each hunk as a unified diff and only writes what the user
+// accepts, keeping a .bak copy of everything it changes.
+
+const profileDiffContext = 3
+
+// profileHunk is a run of adjacent lines that mention a removed root.
+type profileHunk struct {
+	Start  int // index of the first line to delete
+	Count  int
+	Accept bool
+}
+
+// profileEdit is the proposed change to one file, or to the user PATH in the
+// Windows registry (one entry per line).
+type profileEdit struct {
+	Path     string
+	Registry bool
+	Lines    []string
+	Hunks    []profileHunk
+}
+
+// profileCandidates are the files that commonly put Go on PATH on goos.
+func profileCandidates(goos, home string, getenv func(string) string) []string {
+	if goos == "windows" {
+		return nil
+	}
+	files := []string{
+		filepath.Join(home, ".profile"),
+		filepath.Join(home, ".bashrc"),
+		filepath.Join(home, ".bash_profile"),
+		filepath.Join(home, ".zshrc"),
+		filepath.Join(home, ".zprofile"),
+		filepath.Join(home, ".zshenv"),
+	}
+	configHome := getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	files = append(files, filepath.Join(configHome, "fish", "config.fish"))
+	switch goos {
+	case "darwin":
+		files = append(files, "/etc/paths.d/go")
+	case "linux":
+		files = append(files, "/etc/profile.d/go.sh")
+	}
+	return files
+}
+
+// rootSpellings are the ways a profile may refer to root: literally, or
+// relative to the home directory.
+func rootSpellings(root, home string) []string {
+	spellings := []string{root}
+	if rel, err := filepath.Rel(home, root); err == nil && !strings.HasPrefix(rel, "..") && rel != "." {
+		rel = filepath.ToSlash(rel)
+		spellings = append(spellings, "~/"+rel, "$HOME/"+rel, "${HOME}/"+rel)
+	}
+	return spellings
+}
+
+// mentionsRoot reports whether line refers to root or something inside it,
+// as opposed to a longer path that merely starts with the same characters.
+func mentionsRoot(line, root, home string, foldCase bool) bool {
+	if foldCase {
+		line, root, home = strings.ToLower(line), strings.ToLower(root), strings.ToLower(home)
+	}
+	for _, spelling := range rootSpellings(root, home) {
+		for offset := 0; ; {
+			i := strings.Index(line[offset:], spelling)
+			if i < 0 {
+				break
+			}
+			start, end := offset+i, offset+i+len(spelling)
+			before := start == 0 || strings.ContainsRune("=:;\"' \t", rune(line[start-1]))
+			after := end == len(line) || strings.ContainsRune("/\\:;\"' \t\r", rune(line[end]))
+			if before && after {
+				return true
+			}
+			offset = end
+		}
+	}
+	return false
+}
+
+// findProfileHunks groups the lines mentioning any of roots into hunks.
+func findProfileHunks(lines, roots []string, home string, foldCase bool) []profileHunk {
+	var hunks []profileHunk
+	for i, line := range lines {
+		mentioned := false
+		for _, root := range roots {
+			if mentionsRoot(line, root, home, foldCase) {
+				mentioned = true
+				break
+			}
+		}
+		if !mentioned {
+			continue
+		}
+		if n := len(hunks); n > 0 && hunks[n-1].Start+hunks[n-1].Count == i {
+			hunks[n-1].Count++
+			continue
+		}
+		hunks = append(hunks, profileHunk{Start: i, Count: 1})
+	}
+	return hunks
+}
+
+// proposeProfileEdits reads every candidate profile and returns the ones that
+// still mention a removed root. Unreadable files are skipped.
+func proposeProfileEdits(goos, home string, getenv func(string) string, roots []string) []profileEdit {
+	var edits []profileEdit
+	for _, path := range profileCandidates(goos, home, getenv) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(string(data), "\n")
+		if hunks := findProfileHunks(lines, roots, home, false); len(hunks) > 0 {
+			edits = append(edits, profileEdit{Path: path, Lines: lines, Hunks: hunks})
+		}
+	}
+	if goos == "windows" {
+		if value, err := userPathValue(); err == nil && value != "" {
+			lines := strings.Split(value, ";")
+			if hunks := findProfileHunks(lines, roots, home, true); len(hunks) > 0 {
+				edits = append(edits, profileEdit{Path: userPathName, Registry: true, Lines: lines, Hunks: hunks})
+			}
+		}
+	}
+	return edits
+}
+
+// hunkDiff renders hunk i as a unified diff against the original lines.
+func (e profileEdit) hunkDiff(i int) string {
+	hunk := e.Hunks[i]
+	from := max(hunk.Start-profileDiffContext, 0)
+	to := min(hunk.Start+hunk.Count+profileDiffContext, len(e.Lines))
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", e.Path, e.Path)
+	fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", from+1, to-from, from+1, to-from-hunk.Count)
+	for j := from; j < to; j++ {
+		prefix := " "
+		if j >= hunk.Start && j < hunk.Start+hunk.Count {
+			prefix = "-"
+		}
+		b.WriteString(prefix + strings.TrimRight(e.Lines[j], "\r") + "\n")
+	}
+	return b.String()
+}
+
+func (e profileEdit) accepted() int {
+	n := 0
+	for _, hunk := range e.Hunks {
+		if hunk.Accept {
+			n++
+		}
+	}
+	return n
+}
+
+// result is the content with every accepted hunk removed.
+func (e profileEdit) result() []string {
+	drop := make(map[int]bool)
+	for _, hunk := range e.Hunks {
+		for j := hunk.Start; hunk.Accept && j < hunk.Start+hunk.Count; j++ {
+			drop[j] = true
+		}
+	}
+	var kept []string
+	for j, line := range e.Lines {
+		if !drop[j] {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
+
+// backupName picks path.bak, or a timestamped name when an earlier .bak
+// would otherwise be overwritten.
+func backupName(path string, now time.Time) string {
+	if _, err := os.Lstat(path + ".bak"); os.IsNotExist(err) {
+		return path + ".bak"
+	}
+	return fmt.Sprintf("%s.%s.bak", path, now.Format("20060102_150405"))
+}
+
+// applyProfileEdit writes the accepted hunks, saving the original first. A
+// registry value is backed up to backupDir. The returned journal entry says
+// how to reverse the edit.
+func applyProfileEdit(e profileEdit, backupDir string, now time.Time) (envChange, error) {
+	if e.Registry {
+		content := strings.Join(e.result(), ";")
+		change := envChange{Time: now, Kind: envChangeRegistry, Target: e.Path, After: contentHash([]byte(content))}
+		change.Backup = backupName(filepath.Join(backupDir, "user-path"), now)
+		if err := fsys.MkdirAll(backupDir, 0755); err != nil {
+			return change, fmt.Errorf("failed to create backup directory: %v", err)
+		}
+		if err := fsys.WriteFile(change.Backup, []byte(strings.Join(e.Lines, ";")), 0600); err != nil {
+			return change, fmt.Errorf("failed to back up %s: %v", e.Path, err)
+		}
+		return change, setUserPathValue(content)
+	}
+	content := []byte(strings.Join(e.result(), "\n"))
+	change := envChange{Time: now, Kind: envChangeFile, Target: e.Path, After: contentHash(content)}
+	info, err := os.Stat(e.Path)
+	if err != nil {
+		return change, err
+	}
+	original, err := os.ReadFile(e.Path)
+	if err != nil {
+		return change, err
+	}
+	if string(original) != strings.Join(e.Lines, "\n") {
+		return change, fmt.Errorf("%s changed while it was being reviewed", e.Path)
+	}
+	change.Backup = backupName(e.Path, now)
+	if err := fsys.WriteFile(change.Backup, original, info.Mode().Perm()); err != nil {
+		return change, fmt.Errorf("failed to back up %s: %v", e.Path, err)
+	}
+	// Writing in place keeps symlinks from dotfile managers intact
+	if err := fsys.WriteFile(e.Path, content, info.Mode().Perm()); err != nil {
+		err = fmt.Errorf("failed to write %s: %v", e.Path, err)
+		// A short write can leave the file truncated; put the original back
+		if fsys.WriteFile(e.Path, original, info.Mode().Perm()) == nil {
+			return change, rolledBackError{err}
+		}
+		return change, err
+	}
+	return change, nil
+}
+
+// removedRoots are the installation paths a finished live run deleted, and
+// the links they were reached through unless those were repointed.
+func (m model) removedRoots() []string {
+	var roots []string
+	for _, install := range m.selectedInstalls() {
+		if install.Blocked == "" && pathRemoved(install.Path) {
+			roots = append(roots, install.Path)
+			for _, link := range install.Links {
+				if pathRemoved(link) {
+					roots = append(roots, link)
+				}
+			}
+		}
+	}
+	return roots
+}
+
+// startProfileReview opens the diff viewer when profiles still mention the
+// removed roots, and otherwise carries on to the final snapshot.
+func (m model) startProfileReview(goos, home string) (tea.Model, tea.Cmd) {
+	if !m.opts.simulate {
+		m.profileEdits = proposeProfileEdits(goos, home, os.Getenv, m.removedRoots())
+	}
+	if len(m.profileEdits) == 0 {
+		return m, m.snapshotCmd()
+	}
+	m.state = "profile_review"
+	m.profileEdit, m.profileHunk = 0, 0
+	m.profileView = viewport.New(m.width-4, max(m.height-20, 8))
+	m.profileView.SetContent(m.profileEdits[0].hunkDiff(0))
+	if m.logFile != nil {
+		for _, edit := range m.profileEdits {
+			m.logFile.Log("INFO", fmt.Sprintf("Proposed %d edit(s) to %s", len(edit.Hunks), edit.Path))
+		}
+	}
+	return m, nil
+}
+
+// handleProfileReviewKey steps through the hunks: y accepts, n skips and the
+// arrow keys scroll the diff.
+func (m model) handleProfileReviewKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q":
+		if m.logFile != nil {
+			m.logFile.Log("INFO", "Profile review cancelled, no profiles were changed")
+		}
+		m.profileEdits = nil
+		m.state = "complete"
+		return m, m.snapshotCmd()
+	case "y", "n", "s":
+		m.profileEdits[m.profileEdit].Hunks[m.profileHunk].Accept = msg.String() == "y"
+		m.profileHunk++
+		if m.profileHunk == len(m.profileEdits[m.profileEdit].Hunks) {
+			m.profileEdit, m.profileHunk = m.profileEdit+1, 0
+		}
+		if m.profileEdit == len(m.profileEdits) {
+			return m.finishProfileReview(), m.snapshotCmd()
+		}
+		m.profileView.SetContent(m.profileEdits[m.profileEdit].hunkDiff(m.profileHunk))
+		m.profileView.GotoTop()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.profileView, cmd = m.profileView.Update(msg)
+	return m, cmd
+}
+
+// finishProfileReview writes the accepted edits and records the outcome.
+func (m model) finishProfileReview() model {
+	m.state = "complete"
+	now := time.Now()
+	for _, edit := range m.profileEdits {
+		accepted := edit.accepted()
+		if accepted == 0 {
+			m.profileResults = append(m.profileResults, fmt.Sprintf("Left %s unchanged", edit.Path))
+			continue
+		}
+		change, err := applyProfileEdit(edit, m.backupPath, now)
+		if err != nil {
+			m.profileResults = append(m.profileResults, fmt.Sprintf("Could not edit %s: %v", edit.Path, err))
+			if m.logFile != nil {
+				m.logFile.Log("ERROR", fmt.Sprintf("Failed to edit %s: %v", edit.Path, err))
+			}
+			continue
+		}
+		m.journalEnvChange(change)
+		m.profileResults = append(m.profileResults, fmt.Sprintf("Edited %s (%d of %d change(s), backup %s)", edit.Path, accepted, len(edit.Hunks), change.Backup))
+		if m.logFile != nil {
+			m.logFile.Log("SUCCESS", fmt.Sprintf("Edited %s, backup at %s", edit.Path, change.Backup))
+		}
+	}
+	return m
+}
+
+func (m model) renderProfileReview() string {
+	edit := m.profileEdits[m.profileEdit]
+	var s string
+	s += highlightStyle.Render(fmt.Sprintf("📝 %s still refers to removed Go (%d/%d)", edit.Path, m.profileHunk+1, len(edit.Hunks))) + "\n\n"
+	var diff []string
+	for _, line := range strings.Split(m.profileView.View(), "\n") {
+		switch {
+		case strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---"):
+			line = warningStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			line = infoStyle.Render(line)
+		}
+		diff = append(diff, line)
+	}
+	s += lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Render(strings.Join(diff, "\n")) + "\n\n"
+	s += confirmButtonStyle.Render("y") + " remove these lines, " + cancelButtonStyle.Render("n") + " keep them, ↑/↓ to scroll, " + cancelButtonStyle.Render("q") + " to leave every profile unchanged\n"
+	s += infoStyle.Render("Nothing is written until every change has been reviewed; originals are kept as .bak") + "\n"
+	return s
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestMentionsRoot(t *testing.T) {
+	home := "/home/gopher"
+	testCases := []struct {
+		line string
+		root string
+		want bool
+	}{
+		{"export PATH=$PATH:/usr/local/go/bin", "/usr/local/go", true},
+		{"export GOROOT=/usr/local/go", "/usr/local/go", true},
+		{`export PATH="/usr/local/go/bin:$PATH"`, "/usr/local/go", true},
+		{"/usr/local/go/bin", "/usr/local/go", true},
+		{"export PATH=$PATH:/usr/local/go1.22/bin", "/usr/local/go", false},
+		{"export PATH=$PATH:/opt/usr/local/go/bin", "/usr/local/go", false},
+		{"export PATH=$PATH:$HOME/sdk/go1.21.0/bin", "/home/gopher/sdk/go1.21.0", true},
+		{"set -gx PATH ~/sdk/go1.21.0/bin $PATH", "/home/gopher/sdk/go1.21.0", true},
+		{"export GOPATH=$HOME/go", "/home/gopher/sdk/go1.21.0", false},
+	}
+	for _, tc := range testCases {
+		if got := mentionsRoot(tc.line, tc.root, home, false); got != tc.want {
+			t.Errorf("mentionsRoot(%q, %q) = %v, want %v", tc.line, tc.root, got, tc.want)
+		}
+	}
+	if !mentionsRoot(`C:\Program Files\Go\bin`, `c:\program files\go`, `C:\Users\gopher`, true) {
+		t.Errorf("Expected registry entries to match case-insensitively")
+	}
+}
+
+func TestProfileEditDiffAndResult(t *testing.T) {
+	lines := strings.Split("# go\nexport GOROOT=/usr/local/go\nexport PATH=$PATH:$GOROOT/bin:/usr/local/go/bin\nalias ll='ls -l'\n\nexport EDITOR=vim\nexport PATH=$PATH:/usr/local/go/bin\n", "\n")
+	hunks := findProfileHunks(lines, []string{"/usr/local/go"}, "/home/gopher", false)
+	if len(hunks) != 2 || hunks[0].Start != 1 || hunks[0].Count != 2 || hunks[1].Start != 6 {
+		t.Fatalf("Unexpected hunks: %+v", hunks)
+	}
+	edit := profileEdit{Path: ".bashrc", Lines: lines, Hunks: hunks}
+
+	want := "--- .bashrc\n+++ .bashrc\n@@ -1,6 +1,4 @@\n" +
+		" # go\n" +
+		"-export GOROOT=/usr/local/go\n" +
+		"-export PATH=$PATH:$GOROOT/bin:/usr/local/go/bin\n" +
+		" alias ll='ls -l'\n" +
+		" \n" +
+		" export EDITOR=vim\n"
+	if got := edit.hunkDiff(0); got != want {
+		t.Errorf("Unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+
+	edit.Hunks[1].Accept = true
+	if got := strings.Join(edit.result(), "\n"); got != "# go\nexport GOROOT=/usr/local/go\nexport PATH=$PATH:$GOROOT/bin:/usr/local/go/bin\nalias ll='ls -l'\n\nexport EDITOR=vim\n" {
+		t.Errorf("Expected only the accepted hunk to be removed, got:\n%s", got)
+	}
+}
+
+func TestApplyProfileEdit(t *testing.T) {
+	skipInAuditBuild(t)
+	home := t.TempDir()
+	rc := filepath.Join(home, ".zshrc")
+	original := "export PATH=$PATH:/usr/local/go/bin\nexport EDITOR=vim\n"
+	os.WriteFile(rc, []byte(original), 0640)
+	os.WriteFile(rc+".bak", []byte("an older backup"), 0600)
+
+	edits := proposeProfileEdits("freebsd", home, func(string) string { return "" }, []string{"/usr/local/go"})
+	if len(edits) != 1 || edits[0].Path != rc {
+		t.Fatalf("Expected one proposed edit to %s, got %+v", rc, edits)
+	}
+	edits[0].Hunks[0].Accept = true
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	change, err := applyProfileEdit(edits[0], t.TempDir(), now)
+	if err != nil {
+		t.Fatalf("applyProfileEdit returned error: %v", err)
+	}
+	if change.Backup != rc+".20240501_120000.bak" || change.Kind != envChangeFile || change.Target != rc {
+		t.Errorf("Expected the existing .bak to be kept, got %+v", change)
+	}
+	if data, _ := os.ReadFile(change.Backup); string(data) != original {
+		t.Errorf("Expected the backup to hold the original, got %q", data)
+	}
+	if data, _ := os.ReadFile(rc); string(data) != "export EDITOR=vim\n" {
+		t.Errorf("Unexpected edited profile: %q", data)
+	}
+	if info, _ := os.Stat(rc); info.Mode().Perm() != 0640 {
+		t.Errorf("Expected the profile's mode to be kept, got %v", info.Mode().Perm())
+	}
+
+	os.WriteFile(rc, []byte("changed meanwhile\n"), 0640)
+	if _, err := applyProfileEdit(edits[0], t.TempDir(), now); err == nil {
+		t.Errorf("Expected a profile changed during review to be left alone")
+	}
+}
+
+func TestProfileReviewKeys(t *testing.T) {
+	skipInAuditBuild(t)
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	root := filepath.Join(home, "sdk", "go")
+	rc := filepath.Join(home, ".profile")
+	os.WriteFile(rc, []byte("export PATH=$PATH:$HOME/sdk/go/bin\n\n\n\n\n\n\nexport GOROOT=$HOME/sdk/go\n"), 0644)
+	journal := filepath.Join(t.TempDir(), "env-journal.jsonl")
+	m := model{width: 80, height: 40, backupPath: t.TempDir(), paths: fugoPaths{Journal: journal}, detectedInstalls: []GoInstallation{{Path: root, Verified: true}}}
+	updated, _ := m.startProfileReview("freebsd", home)
+	m = updated.(model)
+	if m.state != "profile_review" {
+		t.Fatalf("Expected the review to start, got state %q", m.state)
+	}
+
+	updated, _ = m.handleProfileReviewKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m = updated.(model)
+	if m.state != "profile_review" || m.profileHunk != 1 {
+		t.Fatalf("Expected the second hunk to be shown, got %q hunk %d", m.state, m.profileHunk)
+	}
+	updated, _ = m.handleProfileReviewKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	m = updated.(model)
+	if m.state != "complete" || len(m.profileResults) != 1 {
+		t.Fatalf("Expected the review to finish with one result, got %q %v", m.state, m.profileResults)
+	}
+	if data, _ := os.ReadFile(rc); string(data) != "export PATH=$PATH:$HOME/sdk/go/bin\n\n\n\n\n\n\n" {
+		t.Errorf("Expected only the accepted hunk to be removed, got %q", data)
+	}
+	if changes, err := loadEnvJournal(journal); err != nil || len(changes) != 1 || changes[0].Target != rc {
+		t.Errorf("Expected the edit to be journaled, got %+v, %v", changes, err)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// With --progress=json the headless commands also write every engine event
+// to stderr as one JSON object per line, so a wrapper or GUI can draw its own
+// progress bar while stdout still carries only the final report. Field names
+// are stable; new fields may be added.
+
+// progressRecord is one line of the --progress=json stream.
+type progressRecord struct {
+	Time       time.Time `json:"time"`
+	Phase      string    `json:"phase"`
+	Event      string    `json:"event"`
+	Item       string    `json:"item,omitempty"`
+	Kind       string    `json:"kind,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	Size       int64     `json:"size,omitempty"`
+	Freed      int64     `json:"freed,omitempty"`
+	Done       int       `json:"done"`
+	Total      int       `json:"total"`
+	BytesDone  int64     `json:"bytes_done"`
+	BytesTotal int64     `json:"bytes_total"`
+	Percent    float64   `json:"percent"`
+	Message    string    `json:"message,omitempty"`
+}
+
+// newProgressRecord describes e. Percent follows bytes when the phase knows
+// its sizes, the item count otherwise.
+func newProgressRecord(e engineEvent) progressRecord {
+	r := progressRecord{Time: e.Time, Phase: e.Phase, Event: string(e.Kind), Done: e.Done, Total: e.Total, BytesDone: e.Bytes, BytesTotal: e.BytesTotal, Message: e.Message}
+	if e.Item != nil {
+		r.Item, r.Kind, r.Size = e.Item.Target(), string(e.Item.Kind()), e.Item.Size()
+	}
+	if e.Path != "" {
+		r.Item = e.Path
+	}
+	switch e.Kind {
+	case eventDetectorFinished:
+		r.Item, r.Done, r.Total = e.Detector.result.name, e.Detector.done, e.Detector.total
+		switch {
+		case e.Detector.result.timedOut:
+			r.Status = "timeout"
+		case e.Detector.result.err != nil:
+			r.Status, r.Reason = "error", e.Detector.result.err.Error()
+		default:
+			r.Status = "ok"
+		}
+	case eventDetectionFinished:
+		r.Done, r.Total = e.Found, e.Found
+	case eventItemDone:
+		r.Status, r.Reason = e.Result.status(), e.Result.reason()
+		r.Freed, r.BytesDone = e.Bytes, 0
+	}
+	switch {
+	case r.BytesTotal > 0:
+		r.Percent = percentOf(r.BytesDone, r.BytesTotal)
+	case r.Total > 0:
+		r.Percent = percentOf(int64(r.Done), int64(r.Total))
+	}
+	return r
+}
+
+func percentOf(done, total int64) float64 {
+	return float64(min(done, total)*1000/total) / 10
+}
+
+// validProgress checks the value of --progress.
+func validProgress(format string) error {
+	if format != "" && format != "json" {
+		return fmt.Errorf("unknown progress format %q (expected json)", format)
+	}
+	return nil
+}
+
+// writeProgress writes e to the --progress=json stream, if there is one.
+func (e eventWriter) writeProgress(ev engineEvent) {
+	if e.progress == nil {
+		return
+	}
+	if data, err := json.Marshal(newProgressRecord(ev)); err == nil {
+		fmt.Fprintln(e.progress, string(data))
+	}
+}
+
+// startHeadlessEvents gives out a bus for the steps a headless command runs
+// and, with --progress=json, streams everything followed through out to
+// stderr. The sentences the command would print are then dropped, so the
+// stream is all stderr carries and stdout only the final report. The returned
+// function closes the bus and waits for the stream.
+func startHeadlessEvents(out *eventWriter, opts runOptions, stderr io.Writer) (stop func()) {
+	if opts.progress == "json" {
+		out.progress = stderr
+		out.quiet = !out.porcelain
+	}
+	out.events = newEventBus()
+	wait := out.follow(out.events)
+	return func() {
+		out.events.close()
+		wait()
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewProgressRecord(t *testing.T) {
+	item := cacheItem{target: cacheTarget{Name: "GOCACHE", Path: "/tmp/go-build"}, size: 300}
+	tests := []struct {
+		name  string
+		event engineEvent
+		want  progressRecord
+	}{
+		{"bytes", engineEvent{Kind: eventProgress, Phase: phaseClean, Done: 1, Total: 4, Bytes: 300, BytesTotal: 400},
+			progressRecord{Phase: "clean", Event: "progress", Done: 1, Total: 4, BytesDone: 300, BytesTotal: 400, Percent: 75}},
+		{"count without sizes", engineEvent{Kind: eventProgress, Phase: phaseRemove, Done: 1, Total: 3},
+			progressRecord{Phase: "remove", Event: "progress", Done: 1, Total: 3, Percent: 33.3}},
+		{"item done", engineEvent{Kind: eventItemDone, Phase: phaseClean, Item: item, Result: itemResult{item: item}, Bytes: 300},
+			progressRecord{Phase: "clean", Event: "item_done", Item: "/tmp/go-build", Kind: "cache", Status: statusRemoved, Size: 300, Freed: 300}},
+		{"detector", engineEvent{Kind: eventDetectorFinished, Phase: phaseDetect, Detector: detectorProgress{result: detectorResult{name: "wsl", timedOut: true}, done: 1, total: 2}},
+			progressRecord{Phase: "detect", Event: "detector_finished", Item: "wsl", Status: "timeout", Done: 1, Total: 2, Percent: 50}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newProgressRecord(tt.event); got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestProgressJSONKeepsStdoutForTheReport(t *testing.T) {
+	skipInAuditBuild(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_STATE_HOME", "")
+	roots := []string{fakeGoRoot(t, "VERSION", "bin/go"), fakeGoRoot(t, "VERSION", "bin/go")}
+
+	var stdout, stderr strings.Builder
+	cmd := newRootCmd()
+	cmd.SetArgs([]string{"container-prune", "--i-am-in-a-container", "--root", roots[0], "--root", roots[1], "--progress=json"})
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+
+	var report changeReport
+	if err := json.Unmarshal([]byte(stdout.String()), &report); err != nil || strings.Count(stdout.String(), "\n") != 1 {
+		t.Fatalf("Expected only the JSON report on stdout, got %q", stdout.String())
+	}
+	var events []string
+	var last progressRecord
+	for _, line := range strings.Split(strings.TrimSpace(stderr.String()), "\n") {
+		var record progressRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Expected every stderr line to be JSON, got %q", line)
+		}
+		events = append(events, record.Event)
+		if record.Event == "progress" {
+			last = record
+		}
+	}
+	want := "item_queued,item_queued,item_done,progress,item_done,progress"
+	if strings.Join(events, ",") != want {
+		t.Errorf("Expected %s, got %s", want, strings.Join(events, ","))
+	}
+	if last.Phase != phaseRemove || last.Done != 2 || last.Total != 2 || last.Percent != 100 || last.BytesDone != report.FreedBytes {
+		t.Errorf("Expected the last progress to be complete, got %+v", last)
+	}
+}
+
+func TestProgressRejectsUnknownFormats(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cmd := newRootCmd()
+	cmd.SetArgs([]string{"clean-cache", "--progress=xml"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "unknown progress format") {
+		t.Errorf("Expected --progress=xml to be refused, got %v", err)
+	}
+}
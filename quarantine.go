@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// With backup_format "quarantine", a backup set keeps each installation as
+// plain files in a directory of its own, next to a manifest like a dedup
+// backup's, so backing up a huge cache need not double its disk usage. An
+// installation on the same file system as the backup directory is renamed
+// into the set, which costs neither time nor space, and the removal that
+// follows finds nothing left to delete. Elsewhere, and for installations a
+// package manager or another user removes, each file is cloned where the
+// file system can share its blocks (FICLONE on Linux, clonefile on macOS)
+// and copied otherwise, leaving runs of zeros as holes. Restore clones or
+// copies the files back the same way, so the set stays whole.
+
+// sparseBlock is the size of the zero runs a copy leaves as holes.
+const sparseBlock = 4096
+
+// quarantineStats is what quarantining an installation took.
+type quarantineStats struct {
+	moved   bool  // renamed into the set rather than copied
+	written int64 // bytes copied; renamed and cloned files take none
+}
+
+// quarantineInstall puts install into treeDir, a new directory of the set,
+// and writes its manifest to manifestPath.
+func quarantineInstall(install GoInstallation, treeDir, manifestPath string) (quarantineStats, error) {
+	var stats quarantineStats
+	if movableToQuarantine(install, filepath.Dir(treeDir)) {
+		// A rename that fails, for a busy directory say, falls back to a copy
+		stats.moved = fsys.Rename(install.Path, treeDir) == nil
+	}
+	source := install.Path
+	if stats.moved {
+		source = treeDir
+	}
+	manifest, err := scanTree(source, nil)
+	if err == nil {
+		manifest.Root = filepath.Base(install.Path)
+		if stats.moved {
+			// Module cache directories are read-only, and retention must be
+			// able to remove the set; the manifest keeps their modes
+			makeTreeWritable(treeDir)
+		} else {
+			stats.written, err = copyQuarantined(manifest, install.Path, treeDir)
+		}
+	}
+	if err == nil {
+		err = writeTreeManifest(manifestPath, manifest)
+	}
+	if err != nil && stats.moved {
+		// Without a manifest the set cannot restore it, so it goes back
+		if rerr := fsys.Rename(treeDir, install.Path); rerr != nil {
+			return stats, fmt.Errorf("%v; %s is left in %s", err, install.Path, treeDir)
+		}
+		stats.moved = false
+	}
+	return stats, err
+}
+
+// movableToQuarantine reports whether install can be renamed into the set
+// at setDir: a directory fu-go removes itself, as the current user, on the
+// same file system as the set and with nothing mounted inside it.
+func movableToQuarantine(install GoInstallation, setDir string) bool {
+	if install.Blocked != "" || packageRemovalCommand(install) != nil || runsAsOwner(install) {
+		return false
+	}
+	info, err := os.Lstat(install.Path)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	set, err := os.Stat(setDir)
+	if err != nil {
+		return false
+	}
+	dev, ok := deviceID(info)
+	setDev, setOK := deviceID(set)
+	if !ok || !setOK || dev != setDev {
+		return false
+	}
+	boundaries, err := findMountBoundaries(install.Path)
+	return err == nil && len(boundaries) == 0
+}
+
+// copyQuarantined copies the files manifest lists from src into dst and
+// returns the bytes it wrote. Directories are made owner-writable whatever
+// their mode, so retention can remove them; the manifest keeps the modes
+// and times a restore sets.
+func copyQuarantined(manifest treeManifest, src, dst string) (int64, error) {
+	var written int64
+	for _, file := range manifest.Files {
+		from := filepath.Join(src, filepath.FromSlash(file.Path))
+		to := filepath.Join(dst, filepath.FromSlash(file.Path))
+		switch {
+		case file.Mode.IsDir():
+			if err := os.MkdirAll(to, file.Mode.Perm()|0700); err != nil {
+				return written, err
+			}
+		case file.Mode&fs.ModeSymlink != 0:
+			if err := os.Symlink(file.Link, to); err != nil {
+				return written, err
+			}
+		case file.HardLink != "":
+			if err := os.Link(filepath.Join(dst, filepath.FromSlash(file.HardLink)), to); err != nil {
+				return written, err
+			}
+		default:
+			n, err := copyFileData(from, to)
+			written += n
+			if err != nil {
+				return written, fmt.Errorf("failed to quarantine %s: %v", from, err)
+			}
+		}
+	}
+	return written, nil
+}
+
+// copyFileData creates to with the contents of the regular file from: a
+// clone where the file system can make one, or else a sparse-aware copy. It
+// returns the bytes written, none for a clone.
+func copyFileData(from, to string) (int64, error) {
+	if err := cloneFile(from, to); err == nil {
+		return 0, nil
+	}
+	in, err := os.Open(from)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return 0, err
+	}
+	n, err := copySparse(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+// copySparse copies src to dst, an empty file, seeking over whole blocks of
+// zeros instead of writing them so the file system can leave holes there,
+// and returns the bytes written.
+func copySparse(dst *os.File, src io.Reader) (int64, error) {
+	buf := make([]byte, 256*sparseBlock)
+	zeros := make([]byte, sparseBlock)
+	var size, written, hole int64
+	write := func(data []byte) error {
+		if len(data) == 0 {
+			return nil
+		}
+		if hole > 0 {
+			if _, err := dst.Seek(hole, io.SeekCurrent); err != nil {
+				return err
+			}
+			hole = 0
+		}
+		n, err := dst.Write(data)
+		written += int64(n)
+		return err
+	}
+	for {
+		n, err := io.ReadFull(src, buf)
+		start := 0 // of the data not written yet
+		for off := 0; off < n; off += sparseBlock {
+			end := min(off+sparseBlock, n)
+			if !bytes.Equal(buf[off:end], zeros[:end-off]) {
+				continue
+			}
+			if err := write(buf[start:off]); err != nil {
+				return written, err
+			}
+			hole += int64(end - off)
+			start = end
+		}
+		if err := write(buf[start:n]); err != nil {
+			return written, err
+		}
+		size += int64(n)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+	// A file that ends in a hole has not reached its size yet
+	return written, dst.Truncate(size)
+}
+
+// quarantineSource restores files from the directory of a quarantined
+// installation.
+type quarantineSource struct {
+	dir string
+}
+
+func (q quarantineSource) extract(file treeFile, root, path string, p *restoreProgress) error {
+	if err := p.stopped(); err != nil {
+		return err
+	}
+	from := filepath.Join(q.dir, filepath.FromSlash(file.Path))
+	if info, err := os.Lstat(from); err != nil || !info.Mode().IsRegular() {
+		return fmt.Errorf("the quarantine is missing %s", file.Path)
+	}
+	return writeUnpackedFile(path, file.Mode&chmodBits, file.ModTime, func() error {
+		if err := cloneFile(from, path); err == nil {
+			return nil
+		}
+		in, err := os.Open(from)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := createUnpacked(root, path, file.Mode.Perm()|0200)
+		if err != nil {
+			return err
+		}
+		_, err = copySparse(out, in)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	})
+}
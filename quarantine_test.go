@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQuarantineMovesOnSameFileSystem(t *testing.T) {
+	skipInAuditBuild(t)
+	root := filepath.Join(t.TempDir(), "go")
+	os.MkdirAll(filepath.Join(root, "bin"), 0755)
+	os.WriteFile(filepath.Join(root, "bin", "go"), []byte("go binary"), 0755)
+	backups := filepath.Join(filepath.Dir(root), "backups")
+
+	dir, err := writeBackupSet(backups, backupFormatQuarantine, []GoInstallation{{Path: root}}, time.Now())
+	if err != nil {
+		t.Fatalf("writeBackupSet returned error: %v", err)
+	}
+	index, _ := readBackupIndex(dir)
+	if len(index.Entries) != 1 || !index.Entries[0].Moved || index.Entries[0].ArchiveSize != 0 {
+		t.Fatalf("Expected the installation to be moved into the set, got %+v", index.Entries)
+	}
+	if _, err := os.Lstat(root); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be gone, got %v", root, err)
+	}
+	if got := readString(t, filepath.Join(dir, index.Entries[0].Tree, "bin", "go")); got != "go binary" {
+		t.Errorf("Expected bin/go in the set, got %q", got)
+	}
+	if err := (installationItem{install: GoInstallation{Path: root}}).Execute(planEnv{quarantined: map[string]bool{root: true}}); err != nil {
+		t.Errorf("Expected removing a quarantined installation to succeed, got %v", err)
+	}
+}
+
+func TestFailedBackupPutsQuarantinedInstallsBack(t *testing.T) {
+	skipInAuditBuild(t)
+	root := filepath.Join(t.TempDir(), "go")
+	os.MkdirAll(filepath.Join(root, "bin"), 0755)
+	os.WriteFile(filepath.Join(root, "bin", "go"), []byte("go binary"), 0755)
+	dir, err := writeBackupSet(filepath.Join(filepath.Dir(root), "backups"), backupFormatQuarantine, []GoInstallation{{Path: root}}, time.Now())
+	if err != nil {
+		t.Fatalf("writeBackupSet returned error: %v", err)
+	}
+	index, _ := readBackupIndex(dir)
+
+	cause := errors.New("backup of the next installation failed")
+	if err := unmoveQuarantined(dir, index, index.Entries, cause); err != cause {
+		t.Errorf("Expected the failure itself, got %v", err)
+	}
+	if got := readString(t, filepath.Join(root, "bin", "go")); got != "go binary" {
+		t.Errorf("Expected bin/go back in place, got %q", got)
+	}
+	if index, _ := readBackupIndex(dir); len(index.Entries) != 0 {
+		t.Errorf("Expected the index to drop the installation, got %+v", index.Entries)
+	}
+}
+
+func TestQuarantineCopiesPackageInstalls(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "go")
+	os.MkdirAll(filepath.Join(root, "pkg"), 0755)
+	sparse := append(append([]byte("head"), make([]byte, 1<<20)...), []byte("tail")...)
+	os.WriteFile(filepath.Join(root, "pkg", "big.a"), sparse, 0644)
+	os.Chmod(filepath.Join(root, "pkg"), 0555)
+	defer os.Chmod(filepath.Join(root, "pkg"), 0755)
+	install := GoInstallation{Path: root, Package: "go", PackageManager: "apk"}
+	backups := t.TempDir()
+
+	dir, err := writeBackupSet(backups, backupFormatQuarantine, []GoInstallation{install}, time.Now())
+	if err != nil {
+		t.Fatalf("writeBackupSet returned error: %v", err)
+	}
+	index, _ := readBackupIndex(dir)
+	if len(index.Entries) != 1 || index.Entries[0].Moved {
+		t.Fatalf("Expected the package manager's installation to be copied, got %+v", index.Entries)
+	}
+	if _, err := os.Stat(filepath.Join(root, "pkg", "big.a")); err != nil {
+		t.Errorf("Expected the installation to stay for the package manager: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, index.Entries[0].Tree, "pkg", "big.a"))
+	if err != nil || !bytes.Equal(data, sparse) {
+		t.Errorf("Expected an identical copy of pkg/big.a, got %d bytes, %v", len(data), err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Errorf("Expected the copied read-only directory to be removable: %v", err)
+	}
+}
+
+func TestCopySparse(t *testing.T) {
+	dir := t.TempDir()
+	data := append(append([]byte("start"), make([]byte, 64*sparseBlock)...), []byte("middle")...)
+	data = append(data, make([]byte, 3*sparseBlock)...)
+	src := filepath.Join(dir, "src")
+	os.WriteFile(src, data, 0644)
+
+	in, _ := os.Open(src)
+	defer in.Close()
+	out, err := os.Create(filepath.Join(dir, "dst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	written, err := copySparse(out, in)
+	out.Close()
+	if err != nil {
+		t.Fatalf("copySparse returned error: %v", err)
+	}
+	if written >= int64(len(data)) {
+		t.Errorf("Expected the zero blocks to be skipped, wrote %d of %d bytes", written, len(data))
+	}
+	got, _ := os.ReadFile(filepath.Join(dir, "dst"))
+	if !bytes.Equal(got, data) {
+		t.Errorf("Expected an identical copy, got %d bytes for %d", len(got), len(data))
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// fu-go has opinions about Go, and shares one per screen. How loudly is the
+// sass setting: "professional" keeps every screen fit for a screenshot sent
+// to management, "normal" is the default, and "maximum" holds nothing back.
+// quote_packs add JSON files of the form {"complete": ["...", ...]} keyed by
+// screen; they are ignored in professional mode.
+
+const (
+	sassProfessional = "professional"
+	sassNormal       = "normal"
+	sassMaximum      = "maximum"
+)
+
+// quoteStates are the screens that carry a quote. "failed" is the complete
+// screen after an error.
+var quoteStates = []string{"loading", "confirm", "creating_backup", "deleting", "dry_run_complete", "complete", "failed"}
+
+// quoteBook maps a screen to the quotes it rotates through.
+type quoteBook map[string][]string
+
+var builtinQuotes = map[string]quoteBook{
+	sassProfessional: {
+		"complete": {"Go has been removed from this machine."},
+		"failed":   {"The removal did not complete. The backup and logs are listed above."},
+	},
+	sassNormal: {
+		"loading":          {"Looking for every last gopher...", "Checking under the bed for Go installs..."},
+		"confirm":          {"It's not you, it's Go.", "Take your time. Closure matters."},
+		"creating_backup":  {"Keeping a photo, just in case.", "Boxing up its things."},
+		"deleting":         {"Deleting the number...", "Returning the hoodie..."},
+		"dry_run_complete": {"Just looking. No hearts were broken.", "A rehearsal. Nobody got hurt."},
+		"complete":         {"Enjoy loneliness", "It's over. You deserve better.", "No more if err != nil."},
+		"failed":           {"Go is clingy. Who knew.", "It's complicated."},
+	},
+	sassMaximum: {
+		"loading":          {"Hunting down every gopher that ever lived here...", "Gathering evidence of the relationship..."},
+		"confirm":          {"Generics took ten years. This takes ten seconds.", "Last chance to say goodbye. Or don't."},
+		"creating_backup":  {"Keeping the receipts.", "Saving the screenshots for the group chat."},
+		"deleting":         {"Burning the letters...", "Unfollowing, unfriending, uninstalling..."},
+		"dry_run_complete": {"All talk, no rm -rf.", "Cold feet? Understandable. Go has none."},
+		"complete":         {"Enjoy loneliness", "Go is gone. So is your excuse not to learn Rust.", "You're free. Your error handling is not."},
+		"failed":           {"Go refused to leave. Classic.", "It changed the locks on you."},
+	},
+}
+
+// sassLevel is the configured level, --sass winning over the config.
+func sassLevel(cfg Config, opts runOptions) string {
+	if opts.sass != "" {
+		return opts.sass
+	}
+	if cfg.Sass != "" {
+		return cfg.Sass
+	}
+	return sassNormal
+}
+
+// validSass reports whether level is one fu-go knows.
+func validSass(level string) error {
+	if _, ok := builtinQuotes[level]; !ok {
+		return fmt.Errorf("sass must be one of %s, %s or %s, got %q", sassProfessional, sassNormal, sassMaximum, level)
+	}
+	return nil
+}
+
+// loadQuotePack reads a custom pack, refusing screens fu-go does not show.
+func loadQuotePack(path string) (quoteBook, error) {
+	data, err := os.ReadFile(expandHome(path))
+	if err != nil {
+		return nil, err
+	}
+	var pack quoteBook
+	if err := json.Unmarshal(data, &pack); err != nil {
+		return nil, fmt.Errorf("invalid quote pack %s: %v", path, err)
+	}
+	for state := range pack {
+		known := false
+		for _, s := range quoteStates {
+			known = known || s == state
+		}
+		if !known {
+			return nil, fmt.Errorf("quote pack %s: unknown screen %q", path, state)
+		}
+	}
+	return pack, nil
+}
+
+// loadQuotes builds the book for level from the built-in quotes and, unless
+// level is professional, the custom packs. Packs that cannot be read are
+// skipped and reported.
+func loadQuotes(level string, packs []string) (quoteBook, []error) {
+	book := make(quoteBook)
+	for state, quotes := range builtinQuotes[level] {
+		book[state] = append([]string(nil), quotes...)
+	}
+	if level == sassProfessional {
+		return book, nil
+	}
+	var errs []error
+	for _, path := range packs {
+		pack, err := loadQuotePack(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for state, quotes := range pack {
+			book[state] = append(book[state], quotes...)
+		}
+	}
+	return book, errs
+}
+
+// pick returns the quote for state this run. The seed is fixed when fu-go
+// starts, so a screen's quote stays put while it is shown and changes from
+// one run to the next.
+func (b quoteBook) pick(state string, seed uint32) string {
+	quotes := b[state]
+	if len(quotes) == 0 {
+		return ""
+	}
+	h := fnv.New32a()
+	h.Write([]byte(state))
+	return quotes[(h.Sum32()+seed)%uint32(len(quotes))]
+}
+
+// quote is the current screen's quote, or "" when it has none.
+func (m model) quote(state string) string {
+	return m.quotes.pick(state, m.quoteSeed)
+}
+
+// renderQuote shows the quote for screens that have no place of their own
+// for one.
+func (m model) renderQuote() string {
+	if m.state == "complete" {
+		return ""
+	}
+	quote := m.quote(m.state)
+	if quote == "" {
+		return ""
+	}
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Center, infoStyle.Italic(true).Render(quote)) + "\n\n"
+}
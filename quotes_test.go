@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadQuotes(t *testing.T) {
+	dir := t.TempDir()
+	pack := filepath.Join(dir, "pack.json")
+	if err := os.WriteFile(pack, []byte(`{"complete": ["So long, and thanks for all the goroutines"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"lunch": ["?"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	book, errs := loadQuotes(sassNormal, []string{pack, bad, filepath.Join(dir, "missing.json")})
+	if len(errs) != 2 || !strings.Contains(errs[0].Error(), `unknown screen "lunch"`) {
+		t.Errorf("Expected the unknown screen and the missing pack to be reported, got %v", errs)
+	}
+	quotes := book["complete"]
+	if quotes[len(quotes)-1] != "So long, and thanks for all the goroutines" || len(quotes) != len(builtinQuotes[sassNormal]["complete"])+1 {
+		t.Errorf("Expected the pack to add to the built-in quotes, got %v", quotes)
+	}
+	if len(builtinQuotes[sassNormal]["complete"]) == len(quotes) {
+		t.Errorf("Expected the built-in quotes to be left alone")
+	}
+
+	// Screenshots for management never carry a custom quote
+	book, errs = loadQuotes(sassProfessional, []string{pack})
+	if len(errs) != 0 || len(book["complete"]) != 1 || book["complete"][0] != "Go has been removed from this machine." {
+		t.Errorf("Expected only the professional quotes, got %v (%v)", book, errs)
+	}
+	if book.pick("loading", 7) != "" {
+		t.Errorf("Expected no quote while loading in professional mode")
+	}
+}
+
+func TestQuotePick(t *testing.T) {
+	book := quoteBook{"complete": {"a", "b", "c"}}
+	seen := make(map[string]bool)
+	for seed := uint32(0); seed < 3; seed++ {
+		if book.pick("complete", seed) != book.pick("complete", seed) {
+			t.Fatalf("Expected a stable quote for one seed")
+		}
+		seen[book.pick("complete", seed)] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("Expected the quotes to rotate across runs, got %v", seen)
+	}
+}
+
+func TestSassLevel(t *testing.T) {
+	if got := sassLevel(Config{}, runOptions{}); got != sassNormal {
+		t.Errorf("Expected normal by default, got %q", got)
+	}
+	if got := sassLevel(Config{Sass: sassMaximum}, runOptions{sass: sassProfessional}); got != sassProfessional {
+		t.Errorf("Expected --sass to win over the config, got %q", got)
+	}
+	if err := validSass("spicy"); err == nil {
+		t.Errorf("Expected an unknown level to be rejected")
+	}
+	if err := (Config{Sass: "spicy"}).validate(); err == nil {
+		t.Errorf("Expected an unknown sass setting to be rejected")
+	}
+}
+
+func TestRenderQuote(t *testing.T) {
+	m := model{width: 80, state: "loading", quotes: quoteBook{"loading": {"Looking..."}, "complete": {"Bye"}}}
+	if !strings.Contains(m.renderQuote(), "Looking...") {
+		t.Errorf("Expected the loading quote, got %q", m.renderQuote())
+	}
+	// The complete screen shows its quote in the success box
+	m.state = "complete"
+	if m.renderQuote() != "" {
+		t.Errorf("Expected no header quote on the complete screen")
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// The macOS .pkg installer leaves a receipt for org.golang.go that keeps
+// claiming /usr/local/go after the files are gone. fu-go forgets it, keeping
+// a copy of the receipt files so undo-env can put them back.
+
+const goReceiptID = "org.golang.go"
+
+var receiptsDir = "/var/db/receipts"
+
+// forgetGoReceipt backs up and forgets the Go receipt. It reports false when
+// there is no receipt to forget.
+func forgetGoReceipt(backupDir string, now time.Time) (envChange, bool, error) {
+	files, _ := filepath.Glob(filepath.Join(receiptsDir, goReceiptID+".*"))
+	if len(files) == 0 {
+		return envChange{}, false, nil
+	}
+	backup := filepath.Join(backupDir, fmt.Sprintf("receipts_%s", now.Format("20060102_150405")))
+	if err := fsys.MkdirAll(backup, 0700); err != nil {
+		return envChange{}, false, fmt.Errorf("failed to create receipt backup: %v", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return envChange{}, false, fmt.Errorf("failed to back up %s: %v", file, err)
+		}
+		if err := fsys.WriteFile(filepath.Join(backup, filepath.Base(file)), data, 0644); err != nil {
+			return envChange{}, false, fmt.Errorf("failed to back up %s: %v", file, err)
+		}
+	}
+	if output, err := runRemovalCommand([]string{"pkgutil", "--forget", goReceiptID}); err != nil {
+		return envChange{}, false, fmt.Errorf("pkgutil --forget failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return envChange{Time: now, Kind: envChangeReceipt, Target: goReceiptID, Backup: backup, Files: files}, true, nil
+}
+
+// restoreReceipts copies the saved receipt files back into place.
+func restoreReceipts(change envChange) error {
+	for _, file := range change.Files {
+		data, err := os.ReadFile(filepath.Join(change.Backup, filepath.Base(file)))
+		if err != nil {
+			return fmt.Errorf("backup of %s is unreadable: %v", file, err)
+		}
+		if err := fsys.WriteFile(file, data, 0644); err != nil {
+			return fmt.Errorf("failed to restore %s: %v", file, err)
+		}
+	}
+	return nil
+}
+
+// forgetReceipt forgets the installer receipt once /usr/local/go, where the
+// .pkg installs, has been removed.
+func (m model) forgetReceipt(goos string) model {
+	if goos != "darwin" || m.opts.simulate || !slices.Contains(m.removedRoots(), "/usr/local/go") {
+		return m
+	}
+	change, forgotten, err := forgetGoReceipt(m.backupPath, time.Now())
+	if err != nil {
+		m.profileResults = append(m.profileResults, fmt.Sprintf("Could not forget the %s receipt: %v", goReceiptID, err))
+		if m.logFile != nil {
+			m.logFile.Log("WARN", fmt.Sprintf("Forgetting receipt %s failed: %v", goReceiptID, err))
+		}
+		return m
+	}
+	if forgotten {
+		m.journalEnvChange(change)
+		m.profileResults = append(m.profileResults, fmt.Sprintf("Forgot the %s installer receipt (backup %s)", goReceiptID, change.Backup))
+		if m.logFile != nil {
+			m.logFile.Log("SUCCESS", fmt.Sprintf("Forgot receipt %s, backup at %s", goReceiptID, change.Backup))
+		}
+	}
+	return m
+}
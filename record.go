@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sessionEvent is one line of a session recording (--record). Recordings are
+// JSON lines so they can be attached to bug reports and inspected by hand.
+type sessionEvent struct {
+	OffsetMS int64  `json:"offset_ms"`
+	Kind     string `json:"kind"` // "key", "transition" or "frame"
+	State    string `json:"state"`
+	From     string `json:"from,omitempty"`
+	Msg      string `json:"msg,omitempty"`
+	Key      string `json:"key,omitempty"`
+	Frame    string `json:"frame,omitempty"`
+}
+
+type sessionRecorder struct {
+	mu        sync.Mutex
+	file      *os.File
+	enc       *json.Encoder
+	start     time.Time
+	lastFrame string
+}
+
+func newSessionRecorder(path string) (*sessionRecorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %v", err)
+	}
+	return &sessionRecorder{file: file, enc: json.NewEncoder(file), start: time.Now()}, nil
+}
+
+func (r *sessionRecorder) record(event sessionEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return
+	}
+	if event.Kind == "frame" {
+		// Most renders repeat the previous frame; keep only changes
+		if event.Frame == r.lastFrame {
+			return
+		}
+		r.lastFrame = event.Frame
+	}
+	event.OffsetMS = time.Since(r.start).Milliseconds()
+	r.enc.Encode(event)
+}
+
+func (r *sessionRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// stateOf names the screen a model is on, for models that expose it.
+func stateOf(m tea.Model) string {
+	if named, ok := m.(interface{ stateName() string }); ok {
+		return named.stateName()
+	}
+	return ""
+}
+
+func (m model) stateName() string { return m.state }
+
+// typingSecret reports whether m is reading a masked input, whose key
+// presses must not be recorded.
+func typingSecret(m tea.Model) bool {
+	if masked, ok := m.(interface{ inputMasked() bool }); ok {
+		return masked.inputMasked()
+	}
+	return false
+}
+
+func (m model) inputMasked() bool { return m.textInput.EchoMode == textinput.EchoPassword }
+
+// recordingModel wraps the TUI model and writes key presses, state
+// transitions and every distinct rendered frame to a recorder.
+type recordingModel struct {
+	model    tea.Model
+	recorder *sessionRecorder
+}
+
+func (r recordingModel) Init() tea.Cmd {
+	return r.model.Init()
+}
+
+func (r recordingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	before := stateOf(r.model)
+	if key, ok := msg.(tea.KeyMsg); ok {
+		name := key.String()
+		if key.Type == tea.KeyRunes && typingSecret(r.model) {
+			name = "•"
+		}
+		r.recorder.record(sessionEvent{Kind: "key", State: before, Key: name})
+	}
+
+	next, cmd := r.model.Update(msg)
+	r.model = next
+	if after := stateOf(next); after != before {
+		r.recorder.record(sessionEvent{Kind: "transition", State: after, From: before, Msg: fmt.Sprintf("%T", msg)})
+	}
+	return r, cmd
+}
+
+func (r recordingModel) View() string {
+	frame := r.model.View()
+	r.recorder.record(sessionEvent{Kind: "frame", State: stateOf(r.model), Frame: frame})
+	return frame
+}
+
+// loadSession reads a recording written by --record.
+func loadSession(path string) ([]sessionEvent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %v", err)
+	}
+	defer file.Close()
+
+	var events []sessionEvent
+	scanner := bufio.NewScanner(file)
+	// Frames of large terminals easily exceed the default line limit
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var event sessionEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid event: %v", path, line, err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recording: %v", err)
+	}
+	return events, nil
+}
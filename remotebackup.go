@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A backup directory on a network file system (NFS, SMB, sshfs, a UNC
+// share) sends every byte of the archive over the network, and 40 GB over a
+// hotel connection takes all night. fu-go has no S3 or SFTP client of its
+// own; such a destination is a mount (s3fs, rclone, sshfs) like any other.
+// Once the user switches to a live run that archives, fu-go writes a small
+// probe file there to measure the link, and the confirm screen warns when
+// the backup would take longer than remoteBackupWarnAfter, offering the
+// local backup directory instead. Offline mode sends nothing: it backs up to
+// the local directory, and without one the backup is refused.
+
+const (
+	remoteProbeSize       = 4 << 20
+	remoteBackupWarnAfter = 10 * time.Minute
+)
+
+// networkFileSystems are the mount table types that are a network away.
+var networkFileSystems = map[string]bool{
+	"nfs": true, "nfs4": true, "cifs": true, "smbfs": true, "smb3": true,
+	"afpfs": true, "webdav": true, "davfs": true, "9p": true,
+	"fuse.sshfs": true, "sshfs": true, "fuse.rclone": true, "fuse.s3fs": true, "macfuse": true,
+}
+
+// probeThroughput measures how fast dir takes writes; tests replace it.
+var probeThroughput = probeWriteThroughput
+
+// networkFileSystem is the network file system dir is on, or "" for a local
+// disk.
+func networkFileSystem(dir string) string {
+	if strings.HasPrefix(dir, `\\`) {
+		return "network share"
+	}
+	data, err := mountTable()
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(existingAncestor(dir)); err == nil {
+		dir = resolved
+	}
+	if v, ok := volumeOf(parseMountTable(data), dir); ok && networkFileSystems[v.fsType] {
+		return v.fsType
+	}
+	return ""
+}
+
+// existingAncestor is dir or the closest directory above it that exists.
+func existingAncestor(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// probeWriteThroughput writes and syncs remoteProbeSize bytes in dir and
+// returns the rate in bytes per second.
+func probeWriteThroughput(dir string) (float64, error) {
+	f, err := os.CreateTemp(existingAncestor(dir), ".fugo-probe-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to write a probe file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	start := time.Now()
+	if _, err := f.Write(make([]byte, remoteProbeSize)); err != nil {
+		return 0, fmt.Errorf("failed to write a probe file: %v", err)
+	}
+	if err := f.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to write a probe file: %v", err)
+	}
+	elapsed := time.Since(start)
+	if elapsed <= 0 {
+		elapsed = time.Microsecond
+	}
+	return float64(remoteProbeSize) / elapsed.Seconds(), nil
+}
+
+// remoteBackupChecked carries the probe of a network backup directory into
+// Update.
+type remoteBackupChecked struct {
+	dir     string
+	fsType  string
+	rate    float64 // bytes per second, 0 when the probe failed or was not run
+	err     error
+	offline bool   // offline mode, so there was no probe
+	local   string // the local backup directory to fall back to
+}
+
+// remoteBackupCmd probes the backup directory when it is on a network file
+// system. Only a live run that archives needs the estimate, so a dry run,
+// backup_policy never or a directory already probed writes nothing.
+func (m model) remoteBackupCmd() tea.Cmd {
+	if m.opts.simulate || m.dryRun || !m.config.archiveBeforeRemoval() {
+		return nil
+	}
+	if m.remoteBackup != nil && m.remoteBackup.dir == m.backupPath {
+		return nil
+	}
+	dir, offline := m.backupPath, isOffline()
+	return func() tea.Msg {
+		fsType := networkFileSystem(dir)
+		if fsType == "" {
+			return remoteBackupChecked{dir: dir}
+		}
+		check := remoteBackupChecked{dir: dir, fsType: fsType, offline: offline}
+		if !offline {
+			check.rate, check.err = probeThroughput(dir)
+		}
+		if dirs, err := resolveDirs(); err == nil && dirs.backups() != dir && networkFileSystem(dirs.backups()) == "" {
+			check.local = dirs.backups()
+		}
+		return check
+	}
+}
+
+func (m model) handleRemoteBackupChecked(msg remoteBackupChecked) model {
+	if msg.dir != m.backupPath {
+		return m
+	}
+	if msg.fsType == "" {
+		m.remoteBackup = nil
+		return m
+	}
+	if msg.offline && msg.local != "" {
+		// setBackupDir is for the confirm screen; this may arrive elsewhere
+		state := m.state
+		m = m.setBackupDir(msg.local)
+		m.state = state
+		if m.logFile != nil {
+			m.logFile.Log("WARN", fmt.Sprintf("Offline mode: %s is on %s, backing up to %s instead", msg.dir, msg.fsType, msg.local))
+		}
+		return m
+	}
+	m.remoteBackup = &msg
+	if m.logFile != nil {
+		if msg.offline {
+			m.logFile.Log("WARN", fmt.Sprintf("Backup destination %s is on %s, which offline mode refuses", msg.dir, msg.fsType))
+		} else if msg.err != nil {
+			m.logFile.Log("WARN", fmt.Sprintf("Backup destination %s is on %s; probe failed: %v", msg.dir, msg.fsType, msg.err))
+		} else {
+			m.logFile.Log("INFO", fmt.Sprintf("Backup destination %s is on %s, writing at %s/s", msg.dir, msg.fsType, formatBytes(int64(msg.rate))))
+		}
+	}
+	return m
+}
+
+// remoteBackupTime is how long the backup takes over the probed link, and
+// whether that is long enough to warn about.
+func (m model) remoteBackupTime() (size int64, eta time.Duration, slow bool) {
+	check := m.remoteBackup
+	if check == nil || check.rate <= 0 {
+		return 0, 0, false
+	}
+	for _, install := range m.backupInstalls() {
+		size += install.Size
+	}
+	eta = time.Duration(float64(size) / check.rate * float64(time.Second))
+	return size, eta, eta > remoteBackupWarnAfter
+}
+
+// renderRemoteBackup estimates the upload to a network backup directory.
+func (m model) renderRemoteBackup() string {
+	check := m.remoteBackup
+	if check == nil || m.dryRun || !m.config.archiveBeforeRemoval() {
+		return ""
+	}
+	if check.offline {
+		return warningStyle.Render(fmt.Sprintf("⚠️  %s is on %s, and offline mode will refuse to back up there; press b to choose a local directory", check.dir, check.fsType)) + "\n\n"
+	}
+	if check.err != nil {
+		return infoStyle.Render(fmt.Sprintf("📡 %s is on %s and its speed could not be measured: %v", check.dir, check.fsType, check.err)) + "\n\n"
+	}
+	size, eta, slow := m.remoteBackupTime()
+	line := fmt.Sprintf("📡 The backup sends up to %s to %s (%s) at %s/s, %s", formatBytes(size), check.dir, check.fsType, formatBytes(int64(check.rate)), formatEstimate(eta))
+	if !slow {
+		return infoStyle.Render(line) + "\n\n"
+	}
+	s := warningStyle.Render("⚠️  "+line) + "\n"
+	if check.local != "" {
+		s += infoStyle.Render(fmt.Sprintf("   Press l to back up to %s instead", check.local)) + "\n"
+	}
+	return s + "\n"
+}
+
+// useLocalBackup switches a slow network backup to the local directory.
+func (m model) useLocalBackup() (tea.Model, tea.Cmd) {
+	if _, _, slow := m.remoteBackupTime(); !slow || m.remoteBackup.local == "" {
+		return m, nil
+	}
+	return m.setBackupDir(m.remoteBackup.local), nil
+}
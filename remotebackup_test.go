@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNetworkFileSystem(t *testing.T) {
+	nas := t.TempDir()
+	nas, _ = filepath.EvalSymlinks(nas)
+	fakeSnapshots(t, "/dev/sda1 on / type ext4 (rw)\nnas:/export on "+nas+" type nfs4 (rw)\n")
+	if got := networkFileSystem(filepath.Join(nas, "fugo", "backups")); got != "nfs4" {
+		t.Errorf("Expected a directory still to be created on the NFS mount to be nfs4, got %q", got)
+	}
+	if got := networkFileSystem("/var/backups"); got != "" {
+		t.Errorf("Expected a local disk, got %q", got)
+	}
+	if got := networkFileSystem(`\\nas\backups`); got != "network share" {
+		t.Errorf("Expected a UNC path to be a network share, got %q", got)
+	}
+}
+
+func TestProbeWriteThroughput(t *testing.T) {
+	dir := t.TempDir()
+	rate, err := probeWriteThroughput(filepath.Join(dir, "missing"))
+	if err != nil || rate <= 0 {
+		t.Fatalf("probeWriteThroughput returned %v, %v", rate, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected the probe file to be removed, found %d entries", len(entries))
+	}
+}
+
+func TestRemoteBackupWarnsAndFallsBack(t *testing.T) {
+	m := model{
+		backupPath:       "/mnt/nas/backups",
+		detectedInstalls: []GoInstallation{{Path: "/usr/local/go", Verified: true, Size: 40 << 30}},
+	}
+	m = m.handleRemoteBackupChecked(remoteBackupChecked{dir: "/mnt/other", fsType: "nfs", rate: 1 << 20})
+	if m.remoteBackup != nil {
+		t.Fatalf("Expected a probe of an earlier destination to be ignored")
+	}
+	m = m.handleRemoteBackupChecked(remoteBackupChecked{dir: m.backupPath, fsType: "cifs", rate: 1 << 20, local: "/home/me/.local/state/fugo/backups"})
+	view := m.renderRemoteBackup()
+	for _, want := range []string{"sends up to 40.0 GB to /mnt/nas/backups (cifs) at 1.0 MB/s, ~11h22m", "Press l to back up to /home/me/.local/state/fugo/backups"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in %q", want, view)
+		}
+	}
+	m.dryRun = true
+	if m.renderRemoteBackup() != "" {
+		t.Errorf("Expected no estimate in a dry run")
+	}
+
+	next, _ := m.useLocalBackup()
+	m = next.(model)
+	if m.backupPath != "/home/me/.local/state/fugo/backups" || m.remoteBackup != nil {
+		t.Errorf("Expected l to switch to the local directory, got %s", m.backupPath)
+	}
+
+	m.detectedInstalls[0].Size = 100 << 20
+	m = m.handleRemoteBackupChecked(remoteBackupChecked{dir: m.backupPath, fsType: "nfs", rate: 50 << 20})
+	m.dryRun = false
+	if view := m.renderRemoteBackup(); strings.Contains(view, "Press l") || !strings.Contains(view, "~2s") {
+		t.Errorf("Expected a fast link only to be noted, got %q", view)
+	}
+}
+
+func TestRemoteBackupProbesOnlyLiveArchivingRuns(t *testing.T) {
+	nas := t.TempDir()
+	nas, _ = filepath.EvalSymlinks(nas)
+	fakeSnapshots(t, "/dev/sda1 on / type ext4 (rw)\nnas:/export on "+nas+" type nfs4 (rw)\n")
+	probed := 0
+	defer func(orig func(string) (float64, error)) { probeThroughput = orig }(probeThroughput)
+	probeThroughput = func(string) (float64, error) { probed++; return 1 << 20, nil }
+
+	m := model{backupPath: nas, dryRun: true}
+	if m.remoteBackupCmd() != nil {
+		t.Errorf("Expected no probe in a dry run")
+	}
+	m.dryRun, m.config.BackupPolicy = false, "never"
+	if m.remoteBackupCmd() != nil {
+		t.Errorf("Expected no probe with backup_policy never")
+	}
+	m.config.BackupPolicy = ""
+	if check, ok := m.remoteBackupCmd()().(remoteBackupChecked); !ok || check.fsType != "nfs4" || probed != 1 {
+		t.Errorf("Expected a live run to probe the NFS directory, got %+v after %d probe(s)", check, probed)
+	}
+}
+
+func TestOfflineBackupStaysOffTheNetwork(t *testing.T) {
+	nas := t.TempDir()
+	nas, _ = filepath.EvalSymlinks(nas)
+	fakeSnapshots(t, "/dev/sda1 on / type ext4 (rw)\nnas:/export on "+nas+" type nfs4 (rw)\n")
+	defer func(orig func(string) (float64, error)) { probeThroughput = orig }(probeThroughput)
+	probeThroughput = func(string) (float64, error) {
+		t.Error("Expected no probe offline")
+		return 0, nil
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+	setOffline(true)
+	defer setOffline(false)
+
+	m := model{state: "summary", backupPath: filepath.Join(nas, "backups")}
+	m = m.handleRemoteBackupChecked(m.remoteBackupCmd()().(remoteBackupChecked))
+	if dirs, _ := resolveDirs(); m.backupPath != dirs.backups() || m.state != "summary" {
+		t.Errorf("Expected offline mode to fall back to the local directory, got %s in %s", m.backupPath, m.state)
+	}
+
+	m = m.handleRemoteBackupChecked(remoteBackupChecked{dir: m.backupPath, fsType: "nfs4", offline: true})
+	if view := m.renderRemoteBackup(); !strings.Contains(view, "offline mode will refuse") {
+		t.Errorf("Expected a warning without a local directory, got %q", view)
+	}
+	goRoot := fakeGoRoot(t, "VERSION")
+	if _, err := backupInstallations(Config{}, []GoInstallation{{Path: goRoot}}, filepath.Join(nas, "backups")); !errors.Is(err, errOffline) {
+		t.Errorf("Expected an offline backup to the NFS directory to be refused, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(nas, "backups")); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing written to the NFS directory: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+func newReplayCmd() *cobra.Command {
+	var speed float64
+	cmd := &cobra.Command{
+		Use:   "replay <file>",
+		Short: "Play back a session recorded with --record",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if speed <= 0 {
+				return fmt.Errorf("--speed must be positive, got %v", speed)
+			}
+			events, err := loadSession(args[0])
+			if err != nil {
+				return err
+			}
+			replay := newReplayModel(events, speed)
+			if len(replay.frames) == 0 {
+				return fmt.Errorf("%s contains no frames", args[0])
+			}
+			_, err = tea.NewProgram(replay, tea.WithAltScreen()).Run()
+			return err
+		},
+	}
+	cmd.Flags().Float64Var(&speed, "speed", 1, "playback speed multiplier")
+	return cmd
+}
+
+type replayTick struct{ index int }
+
+// replayModel shows recorded frames with their original timing. Space
+// pauses, ←/→ step through frames, q quits.
+type replayModel struct {
+	frames []sessionEvent
+	events []sessionEvent
+	index  int
+	speed  float64
+	paused bool
+}
+
+func newReplayModel(events []sessionEvent, speed float64) replayModel {
+	r := replayModel{events: events, speed: speed}
+	for _, event := range events {
+		if event.Kind == "frame" {
+			r.frames = append(r.frames, event)
+		}
+	}
+	return r
+}
+
+func (r replayModel) Init() tea.Cmd {
+	return r.scheduleNext()
+}
+
+// scheduleNext waits as long as the recording did between the current frame
+// and the next one.
+func (r replayModel) scheduleNext() tea.Cmd {
+	if r.paused || r.index+1 >= len(r.frames) {
+		return nil
+	}
+	gap := time.Duration(r.frames[r.index+1].OffsetMS-r.frames[r.index].OffsetMS) * time.Millisecond
+	next := r.index + 1
+	return tea.Tick(time.Duration(float64(gap)/r.speed), func(time.Time) tea.Msg {
+		return replayTick{index: next}
+	})
+}
+
+func (r replayModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return r, tea.Quit
+		case " ":
+			r.paused = !r.paused
+			return r, r.scheduleNext()
+		case "right", "l":
+			if r.index+1 < len(r.frames) {
+				r.index++
+			}
+			r.paused = true
+		case "left", "h":
+			if r.index > 0 {
+				r.index--
+			}
+			r.paused = true
+		}
+	case replayTick:
+		// Ticks scheduled before a pause or a manual step are stale
+		if r.paused || msg.index != r.index+1 {
+			return r, nil
+		}
+		r.index = msg.index
+		return r, r.scheduleNext()
+	}
+	return r, nil
+}
+
+// lastKeyBefore finds the key the user pressed most recently before offset,
+// so the status bar shows what led to each frame.
+func (r replayModel) lastKeyBefore(offset int64) string {
+	last := ""
+	for _, event := range r.events {
+		if event.OffsetMS > offset {
+			break
+		}
+		if event.Kind == "key" {
+			last = event.Key
+		}
+	}
+	return last
+}
+
+func (r replayModel) View() string {
+	frame := r.frames[r.index]
+	status := fmt.Sprintf("⏯  replay %d/%d · %s · state %s", r.index+1, len(r.frames),
+		time.Duration(frame.OffsetMS)*time.Millisecond, frame.State)
+	if k := r.lastKeyBefore(frame.OffsetMS); k != "" {
+		status += fmt.Sprintf(" · last key %q", k)
+	}
+	if r.paused {
+		status += " · paused"
+	}
+	status += " · space pause, ←/→ step, q quit"
+	return frame.Frame + "\n" + infoStyle.Render(status)
+}
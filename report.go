@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+)
+
+// runReport summarizes one fu-go run for people who were not watching the
+// TUI, e.g. as an attachment to a change ticket.
+type runReport struct {
+	Generated     time.Time
+	Hostname      string
+	DryRun        bool
+	Simulated     bool
+	Installations []GoInstallation
+	Actions       []string
+	Reclaimed     []categoryBytes
+	TotalBytes    int64
+	Errors        []string
+	BackupDir     string
+	Changes       []string
+}
+
+type categoryBytes struct {
+	Category string
+	Bytes    int64
+	Percent  float64 // share of the largest category, for bar widths
+}
+
+// buildReport derives the report from the model. In a live run only the
+// plan items that succeeded count as done; in a dry run every item of the
+// plan counts. Space reclaimed is broken down by the kind of item, so caches
+// and GOPATH show beside installations.
+func buildReport(m model) runReport {
+	hostname, _ := os.Hostname()
+	// Reports are compared across runs, so not in the order the TUI shows
+	installations := slices.Clone(m.detectedInstalls)
+	sortInventory(installations)
+	report := runReport{
+		Generated:     time.Now(),
+		Hostname:      hostname,
+		DryRun:        m.dryRun,
+		Simulated:     m.opts.simulate,
+		Installations: installations,
+		BackupDir:     m.backupPath,
+		Changes:       m.snapshotDiff,
+	}
+
+	verb := "Removed"
+	if m.dryRun {
+		verb = "Would remove"
+	}
+	removed := make(map[string]bool) // installations the plan took out
+	var others []PlanItem
+	perCategory := make(map[string]int64)
+	for _, item := range m.reportItems() {
+		switch item := item.(type) {
+		case installationItem:
+			removed[item.install.Path] = true
+		case packageUninstallItem:
+			removed[item.install.Path] = true
+		default:
+			others = append(others, item)
+		}
+		if item.Size() > 0 {
+			perCategory[string(item.Kind())] += item.Size()
+		}
+		report.TotalBytes += item.Size()
+	}
+	for _, install := range installations {
+		if !m.isSelected(install) {
+			reason := "not selected"
+			if !install.Verified {
+				reason = "unverified, not selected"
+			}
+			report.Actions = append(report.Actions, fmt.Sprintf("Kept %s (%s)", install.Path, reason))
+			continue
+		}
+		if install.Blocked != "" {
+			report.Actions = append(report.Actions, fmt.Sprintf("Skipped %s: %s", install.Path, install.Blocked))
+			continue
+		}
+		if removed[install.Path] {
+			report.Actions = append(report.Actions, fmt.Sprintf("%s %s (%s)", verb, install.Path, install.Source))
+		}
+	}
+	for _, item := range others {
+		if m.dryRun {
+			report.Actions = append(report.Actions, "Would: "+item.Describe())
+		} else {
+			report.Actions = append(report.Actions, "Done: "+item.Describe())
+		}
+	}
+	report.Reclaimed = categoryBreakdown(perCategory)
+
+	if m.err != nil {
+		report.Errors = append(report.Errors, m.err.Error())
+	}
+	for _, result := range m.plannedPreflight() {
+		if result.Err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("Preflight %s %s: %v", result.Role, result.Path, result.Err))
+		}
+	}
+	return report
+}
+
+// reportItems is what the run did: in a dry run the plan, otherwise the
+// items that succeeded.
+func (m model) reportItems() []PlanItem {
+	if m.dryRun {
+		if m.reviewedPlan != nil {
+			return m.reviewedPlan
+		}
+		return m.plan()
+	}
+	var items []PlanItem
+	for _, result := range m.results {
+		if result.ok() {
+			items = append(items, result.item)
+		}
+	}
+	return items
+}
+
+func pathRemoved(path string) bool {
+	_, err := os.Stat(path)
+	return os.IsNotExist(err)
+}
+
+func categoryBreakdown(perCategory map[string]int64) []categoryBytes {
+	var categories []categoryBytes
+	var largest int64
+	for category, bytes := range perCategory {
+		categories = append(categories, categoryBytes{Category: category, Bytes: bytes})
+		if bytes > largest {
+			largest = bytes
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Bytes != categories[j].Bytes {
+			return categories[i].Bytes > categories[j].Bytes
+		}
+		return categories[i].Category < categories[j].Category
+	})
+	for i := range categories {
+		if largest > 0 {
+			categories[i].Percent = float64(categories[i].Bytes) / float64(largest) * 100
+		}
+	}
+	return categories
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. "1.5 GB".
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// writeHTMLReport renders the report into dir and returns the file path.
+func writeHTMLReport(dir string, report runReport) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create report directory: %v", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("fugo_report_%s.html", report.Generated.Format("20060102_150405")))
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create report: %v", err)
+	}
+	defer file.Close()
+
+	if err := htmlReportTemplate.Execute(file, report); err != nil {
+		return "", fmt.Errorf("failed to render report: %v", err)
+	}
+	return path, nil
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"bytes": formatBytes,
+	"date":  func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>fu-go report {{date .Generated}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem auto; max-width: 960px; color: #222; }
+h1 { color: #7D56F4; }
+h2 { border-bottom: 2px solid #C792EA; padding-bottom: .25rem; }
+table { border-collapse: collapse; width: 100%; font-size: .9rem; }
+th, td { text-align: left; padding: .4rem .6rem; border-bottom: 1px solid #ddd; }
+th { background: #f4f0ff; }
+.badge { display: inline-block; padding: .1rem .5rem; border-radius: .5rem; color: #fff; font-weight: bold; }
+.dry { background: #82AAFF; }
+.live { background: #FF5370; }
+.error { color: #FF5370; }
+.muted { color: #888; }
+.chart .row { display: flex; align-items: center; gap: .75rem; margin: .3rem 0; }
+.chart .label { width: 10rem; }
+.chart .bar { flex: 1; background: #f4f0ff; border-radius: .25rem; }
+.chart .bar div { height: 1.3rem; background: #C792EA; border-radius: .25rem; }
+.chart .value { width: 6rem; text-align: right; }
+</style>
+</head>
+<body>
+<h1>fu-go run report</h1>
+<p>
+{{if .DryRun}}<span class="badge dry">DRY RUN</span>{{else}}<span class="badge live">LIVE RUN</span>{{end}}
+{{if .Simulated}}<span class="badge dry">SIMULATION</span>{{end}}
+generated {{date .Generated}} on <strong>{{.Hostname}}</strong>
+</p>
+
+<h2>Space {{if .DryRun}}reclaimable{{else}}reclaimed{{end}}: {{bytes .TotalBytes}}</h2>
+{{if .Reclaimed}}
+<div class="chart">
+{{range .Reclaimed}}
+<div class="row"><span class="label">{{.Category}}</span><div class="bar"><div style="width: {{printf "%.1f" .Percent}}%"></div></div><span class="value">{{bytes .Bytes}}</span></div>
+{{end}}
+</div>
+{{else}}
+<p class="muted">Nothing was removed.</p>
+{{end}}
+
+<h2>Detected inventory</h2>
+<table>
+<tr><th>Version</th><th>Source</th><th>Path</th><th>Size</th><th>Permissions</th></tr>
+{{range .Installations}}
+<tr><td>{{.Version}}</td><td>{{.Source}}</td><td><code>{{.Path}}</code></td><td>{{bytes .Size}}</td><td><code>{{.Permissions}}</code></td></tr>
+{{else}}
+<tr><td colspan="5" class="muted">No Go installations detected.</td></tr>
+{{end}}
+</table>
+
+<h2>Actions</h2>
+<ul>
+{{range .Actions}}<li>{{.}}</li>{{else}}<li class="muted">No actions taken.</li>{{end}}
+</ul>
+
+{{if .Changes}}
+<h2>System changes</h2>
+<ul>{{range .Changes}}<li><code>{{.}}</code></li>{{end}}</ul>
+{{end}}
+
+<h2>Errors</h2>
+<ul>
+{{range .Errors}}<li class="error">{{.}}</li>{{else}}<li class="muted">None.</li>{{end}}
+</ul>
+
+<h2>Backups</h2>
+<p><code>{{.BackupDir}}</code></p>
+</body>
+</html>
+`))
+
+// saveReport writes the HTML report for the finished run and logs where it
+// went; failures only cost the report, not the run.
+func (m model) saveReport() model {
+	var err error
+	m.reportPath, err = writeHTMLReport(m.paths.Reports, buildReport(m))
+	if m.logFile != nil {
+		if err != nil {
+			m.logFile.Log("WARN", fmt.Sprintf("Report not written: %v", err))
+		} else {
+			m.logFile.Log("INFO", fmt.Sprintf("Report written to: %s", m.reportPath))
+		}
+	}
+	return m
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFormatBytes(t *testing.T) {
+	testCases := []struct {
+		bytes    int64
+		expected string
+	}{
+		{512, "512 B"},
+		{1536, "1.5 KB"},
+		{250 * 1024 * 1024, "250.0 MB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GB"},
+	}
+
+	for _, tc := range testCases {
+		if result := formatBytes(tc.bytes); result != tc.expected {
+			t.Errorf("formatBytes(%d) = %s, expected %s", tc.bytes, result, tc.expected)
+		}
+	}
+}
+
+func TestBuildReportDryRun(t *testing.T) {
+	installs := []GoInstallation{
+		{Path: "/usr/local/go", Source: "official", Size: 300, Verified: true},
+		{Path: "/root/.gvm/gos/go1.21", Source: "gvm", Size: 100, Verified: true},
+		{Path: "/ro/go", Source: "official", Size: 50, Blocked: "on a read-only file system", Verified: true},
+	}
+	m := model{
+		dryRun:           true,
+		backupPath:       "/backups",
+		err:              errors.New("boom"),
+		detectedInstalls: installs,
+		reviewedPlan: []PlanItem{
+			installationItem{install: installs[0]},
+			installationItem{install: installs[1]},
+			cacheItem{target: cacheTarget{Name: "build cache", Path: "/root/.cache/go-build"}, size: 200},
+			gopathItem{path: "/root/go", size: 600},
+		},
+	}
+
+	report := buildReport(m)
+	if report.TotalBytes != 1200 {
+		t.Errorf("Expected 1200 reclaimable bytes, got %d", report.TotalBytes)
+	}
+	want := []categoryBytes{{Category: "gopath", Bytes: 600}, {Category: "installation", Bytes: 400}, {Category: "cache", Bytes: 200}}
+	if len(report.Reclaimed) != len(want) || report.Reclaimed[0].Percent != 100 {
+		t.Fatalf("Unexpected category breakdown: %+v", report.Reclaimed)
+	}
+	for i, category := range want {
+		if got := report.Reclaimed[i]; got.Category != category.Category || got.Bytes != category.Bytes {
+			t.Errorf("Expected %s with %d bytes in the breakdown, got %+v", category.Category, category.Bytes, got)
+		}
+	}
+	// Canonical order: by source, then path for versions alike
+	if len(report.Actions) != 5 || !strings.Contains(report.Actions[0], "(gvm)") || !strings.HasPrefix(report.Actions[1], "Skipped /ro/go") {
+		t.Errorf("Unexpected actions: %v", report.Actions)
+	}
+	if !strings.Contains(report.Actions[4], "Remove GOPATH /root/go") {
+		t.Errorf("Expected the GOPATH removal among the actions, got %v", report.Actions)
+	}
+	if len(report.Errors) != 1 {
+		t.Errorf("Expected 1 error, got %v", report.Errors)
+	}
+}
+
+func TestWriteHTMLReport(t *testing.T) {
+	report := buildReport(model{
+		dryRun:           true,
+		detectedInstalls: []GoInstallation{{Path: "/usr/local/go", Version: "go1.22 <script>", Source: "official", Size: 1024, Verified: true}},
+	})
+
+	path, err := writeHTMLReport(t.TempDir(), report)
+	if err != nil {
+		t.Fatalf("writeHTMLReport returned error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+	html := string(data)
+	for _, expected := range []string{"DRY RUN", "/usr/local/go", "1.0 KB", "width: 100.0%"} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("Expected report to contain %q", expected)
+		}
+	}
+	if strings.Contains(html, "<script>") {
+		t.Error("Expected version string to be escaped")
+	}
+}
@@ -0,0 +1,534 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// fu-go restore puts installations back from a backup set. Each one is
+// unpacked into a staging directory next to where it belongs and only
+// renamed into place once complete (see restoreprogress.go). A target that already holds files (Go
+// was reinstalled since) is a conflict, and nothing is restored until the
+// user picks what to do about it: skip it, overwrite it (the existing tree
+// is moved aside, not deleted), restore to another directory, or merge,
+// asking about each file that differs.
+
+const (
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictAlternate = "alternate"
+	conflictMerge     = "merge"
+)
+
+var conflictStrategies = []string{conflictSkip, conflictOverwrite, conflictAlternate, conflictMerge}
+
+// restoreOptions say how restoreBackupSet treats targets that hold files.
+type restoreOptions struct {
+	conflict string   // one of conflictStrategies, or "" to refuse
+	to       string   // directory for conflictAlternate
+	paths    []string // only these installations; all when empty
+	in       io.Reader
+	out      io.Writer // merge prompts
+	ctx      context.Context
+	events   *eventBus // unpacking progress
+}
+
+// restoreOutcome is what happened to one entry of the set.
+type restoreOutcome struct {
+	entry    backupEntry
+	target   string
+	action   string // "restored", "skipped", "replaced" or "merged"
+	aside    string // where overwrite moved the existing tree
+	added    int    // merge counts
+	replaced int
+	kept     int
+	resumed  int      // files an earlier, stopped restore had unpacked
+	warnings []string // extended attributes or SELinux labels not restored
+}
+
+func (o restoreOutcome) String() string {
+	s := o.describe()
+	if o.resumed > 0 {
+		s += fmt.Sprintf(" (resumed; %d files were already unpacked)", o.resumed)
+	}
+	return s
+}
+
+func (o restoreOutcome) describe() string {
+	switch o.action {
+	case "skipped":
+		return fmt.Sprintf("Skipped %s: it already holds files", o.target)
+	case "replaced":
+		return fmt.Sprintf("Restored %s; the tree that was there is now %s", o.target, o.aside)
+	case "merged":
+		return fmt.Sprintf("Merged into %s: %d added, %d replaced, %d kept", o.target, o.added, o.replaced, o.kept)
+	}
+	if o.target != o.entry.Path {
+		return fmt.Sprintf("Restored %s to %s", o.entry.Path, o.target)
+	}
+	return fmt.Sprintf("Restored %s", o.target)
+}
+
+// findBackupSet returns the set name refers to: a set directory, the name of
+// one in backupDir, or "" or "latest" for the newest.
+func findBackupSet(backupDir, name string) (backupSet, error) {
+	if name == "" || name == "latest" {
+		sets, err := listBackupSets(backupDir)
+		if err != nil {
+			return backupSet{}, err
+		}
+		if len(sets) == 0 {
+			return backupSet{}, fmt.Errorf("no backup sets in %s", backupDir)
+		}
+		return sets[len(sets)-1], nil
+	}
+	dir := name
+	if _, err := os.Stat(filepath.Join(dir, backupIndexFile)); err != nil {
+		dir = filepath.Join(backupDir, name)
+	}
+	index, err := readBackupIndex(dir)
+	if err != nil {
+		return backupSet{}, err
+	}
+	return backupSet{dir: dir, index: index}, nil
+}
+
+// targetOccupied reports whether path exists and is not an empty directory.
+func targetOccupied(path string) (bool, error) {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !info.IsDir() {
+		return true, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return false, err
+	}
+	return len(entries) > 0, nil
+}
+
+// restoreEntries are the entries of set that paths selects.
+func restoreEntries(set backupSet, paths []string) ([]backupEntry, error) {
+	if len(paths) == 0 {
+		return set.index.Entries, nil
+	}
+	var entries []backupEntry
+	for _, path := range paths {
+		i := slices.IndexFunc(set.index.Entries, func(e backupEntry) bool { return normalizePath(e.Path) == normalizePath(path) })
+		if i < 0 {
+			return nil, fmt.Errorf("%s is not in backup set %s", path, filepath.Base(set.dir))
+		}
+		entries = append(entries, set.index.Entries[i])
+	}
+	return entries, nil
+}
+
+// restoreBackupSet restores the entries of set. Conflicts are found before
+// anything is written, so without a strategy a conflict restores nothing.
+func restoreBackupSet(set backupSet, opts restoreOptions, now time.Time) ([]restoreOutcome, error) {
+	if opts.conflict != "" && !slices.Contains(conflictStrategies, opts.conflict) {
+		return nil, fmt.Errorf("--on-conflict must be one of %s, got %q", strings.Join(conflictStrategies, ", "), opts.conflict)
+	}
+	if opts.conflict == conflictAlternate && opts.to == "" {
+		return nil, fmt.Errorf("--on-conflict alternate needs --to")
+	}
+	entries, err := restoreEntries(set, opts.paths)
+	if err != nil {
+		return nil, err
+	}
+	var conflicts []string
+	occupied := make([]bool, len(entries))
+	for i, entry := range entries {
+		if occupied[i], err = targetOccupied(entry.Path); err != nil {
+			return nil, fmt.Errorf("failed to check %s: %v", entry.Path, err)
+		}
+		if occupied[i] {
+			conflicts = append(conflicts, entry.Path)
+		}
+	}
+	if len(conflicts) > 0 && opts.conflict == "" {
+		return nil, fmt.Errorf("%s already hold files; choose --on-conflict %s", strings.Join(conflicts, ", "), strings.Join(conflictStrategies, "|"))
+	}
+	if opts.in == nil {
+		opts.in = strings.NewReader("")
+	}
+	if opts.out == nil {
+		opts.out = io.Discard
+	}
+	prompt := &mergePrompt{in: bufio.NewReader(opts.in), out: opts.out}
+	var outcomes []restoreOutcome
+	for i, entry := range entries {
+		outcome, err := restoreEntry(set, entry, occupied[i], opts, prompt, now)
+		if err != nil {
+			return outcomes, err
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes, nil
+}
+
+func restoreEntry(set backupSet, entry backupEntry, occupied bool, opts restoreOptions, prompt *mergePrompt, now time.Time) (restoreOutcome, error) {
+	outcome := restoreOutcome{entry: entry, target: entry.Path, action: "restored"}
+	if occupied {
+		switch opts.conflict {
+		case conflictSkip:
+			outcome.action = "skipped"
+			return outcome, nil
+		case conflictOverwrite:
+			outcome.action = "replaced"
+		case conflictMerge:
+			outcome.action = "merged"
+		case conflictAlternate:
+			outcome.target = filepath.Join(opts.to, filepath.Base(entry.Path))
+			busy, err := targetOccupied(outcome.target)
+			if err != nil {
+				return outcome, fmt.Errorf("failed to check %s: %v", outcome.target, err)
+			}
+			if busy {
+				return outcome, fmt.Errorf("%s already holds files too", outcome.target)
+			}
+		}
+	}
+	archive := filepath.Join(set.dir, entry.Archive)
+	sum, err := fileSHA256(archive)
+	if err != nil {
+		return outcome, fmt.Errorf("failed to read %s: %v", archive, err)
+	}
+	if sum != entry.SHA256 {
+		return outcome, fmt.Errorf("%s does not match its checksum in the index; it is damaged", archive)
+	}
+	parent := filepath.Dir(outcome.target)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return outcome, fmt.Errorf("failed to create %s: %v", parent, err)
+	}
+	staging := restoreStagingDir(set, entry, outcome.target)
+	if _, err := openStaging(staging, set, entry, now); err != nil {
+		return outcome, err
+	}
+	progress := newRestoreProgress(opts.ctx, opts.events, entry)
+	staged, attrs, err := stageEntry(set, entry, staging, progress)
+	if err != nil {
+		// What was unpacked stays for the next restore to pick up
+		if errors.Is(err, context.Canceled) {
+			return outcome, fmt.Errorf("restore of %s stopped; run fu-go restore again to resume from %s", entry.Path, staging)
+		}
+		return outcome, fmt.Errorf("failed to unpack %s: %v (run fu-go restore again to resume from %s)", entry.Archive, err, staging)
+	}
+	defer os.RemoveAll(staging)
+	outcome.resumed = progress.kept
+	if failed := applyXattrs(staged, attrs); len(failed) > 0 {
+		outcome.warnings = append(outcome.warnings, fmt.Sprintf("%d extended attributes could not be restored (restore as root to set SELinux contexts); first: %s", len(failed), failed[0]))
+	}
+
+	switch outcome.action {
+	case "merged":
+		outcome.added, outcome.replaced, outcome.kept, err = mergeTree(staged, outcome.target, prompt)
+		if err == nil {
+			outcome.relabel(attrs)
+		}
+		return outcome, err
+	case "replaced":
+		outcome.aside = fmt.Sprintf("%s.fugo-replaced-%s", outcome.target, now.Format("20060102-150405"))
+		if err := moveExecutable(outcome.target, outcome.aside); err != nil {
+			return outcome, fmt.Errorf("failed to move %s aside: %v", outcome.target, err)
+		}
+	default:
+		// An empty directory is in the way of the rename but holds nothing
+		os.Remove(outcome.target)
+	}
+	if err := os.Rename(staged, outcome.target); err != nil {
+		return outcome, fmt.Errorf("failed to move the restored tree into place: %v", err)
+	}
+	outcome.relabel(attrs)
+	return outcome, nil
+}
+
+// relabel has restorecon label the target when SELinux is on and the backup
+// holds no contexts to restore.
+func (o *restoreOutcome) relabel(attrs treeXattrs) {
+	if attrs.labelled() || !selinuxEnabled() {
+		return
+	}
+	if err := relabelTree(o.target); err != nil {
+		o.warnings = append(o.warnings, err.Error())
+	}
+}
+
+// stageEntry unpacks entry into staging and returns the installation's
+// directory there, with the extended attributes the backup recorded.
+func stageEntry(set backupSet, entry backupEntry, staging string, progress *restoreProgress) (string, treeXattrs, error) {
+	archive := filepath.Join(set.dir, entry.Archive)
+	if entry.Format == backupFormatDedup || entry.Format == backupFormatQuarantine {
+		manifest, err := readTreeManifest(archive)
+		if err != nil {
+			return "", nil, err
+		}
+		// Sets live directly in the backup directory, which holds the store
+		var source treeSource = openChunkStore(filepath.Dir(set.dir))
+		if entry.Format == backupFormatQuarantine {
+			if !validTreeRoot(entry.Tree) {
+				return "", nil, fmt.Errorf("the index puts the files of %s at %q, outside the set", entry.Path, entry.Tree)
+			}
+			source = quarantineSource{dir: filepath.Join(set.dir, entry.Tree)}
+		}
+		if err := extractTree(source, manifest, staging, progress); err != nil {
+			return "", nil, err
+		}
+		return filepath.Join(staging, manifest.Root), manifestXattrs(manifest), nil
+	}
+	if err := extractArchive(archive, staging, progress); err != nil {
+		return "", nil, err
+	}
+	staged := filepath.Join(staging, filepath.Base(entry.Path))
+	if _, err := os.Lstat(staged); err != nil {
+		return "", nil, fmt.Errorf("the archive does not hold %s", filepath.Base(entry.Path))
+	}
+	if entry.Xattrs == "" {
+		return staged, nil, nil
+	}
+	attrs, err := readXattrSidecar(filepath.Join(set.dir, entry.Xattrs))
+	return staged, attrs, err
+}
+
+var errRestoreStopped = errors.New("restore stopped")
+
+// mergePrompt asks whether to replace each file that differs. "a" and "s"
+// answer for every remaining file; the end of the input keeps them all.
+type mergePrompt struct {
+	in  *bufio.Reader
+	out io.Writer
+	all string // "y" or "n" once answered for all
+}
+
+func (p *mergePrompt) replace(path string) (bool, error) {
+	for p.all == "" {
+		fmt.Fprintf(p.out, "%s differs from the backup. Replace it? [y]es, [n]o, [a]ll, [s]kip all, [q]uit: ", path)
+		line, err := p.in.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(p.out)
+			p.all = "n"
+			break
+		}
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no", "":
+			return false, nil
+		case "a", "all":
+			p.all = "y"
+		case "s":
+			p.all = "n"
+		case "q", "quit":
+			return false, errRestoreStopped
+		}
+	}
+	return p.all == "y", nil
+}
+
+// mergeTree moves the files of staged that target lacks into it, and those
+// that differ when prompt says so. Identical files and directories already
+// there are kept.
+func mergeTree(staged, target string, prompt *mergePrompt) (added, replaced, kept int, err error) {
+	err = filepath.WalkDir(staged, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(staged, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		dst := filepath.Join(target, rel)
+		existing, statErr := os.Lstat(dst)
+		if os.IsNotExist(statErr) {
+			// Renaming a directory brings everything below it
+			if err := os.Rename(path, dst); err != nil {
+				return err
+			}
+			if d.IsDir() {
+				added += countFiles(dst)
+				return filepath.SkipDir
+			}
+			added++
+			return nil
+		}
+		if statErr != nil {
+			return statErr
+		}
+		if d.IsDir() || existing.IsDir() {
+			if !d.IsDir() || !existing.IsDir() {
+				kept++ // a file where the backup has a directory, or the reverse
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if sameContent(path, dst) {
+			kept++
+			return nil
+		}
+		ok, err := prompt.replace(dst)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			kept++
+			return nil
+		}
+		if err := os.Remove(dst); err != nil {
+			return err
+		}
+		if err := os.Rename(path, dst); err != nil {
+			return err
+		}
+		replaced++
+		return nil
+	})
+	return added, replaced, kept, err
+}
+
+// countFiles counts the files and links below dir.
+func countFiles(dir string) int {
+	n := 0
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			n++
+		}
+		return nil
+	})
+	return n
+}
+
+// sameContent reports whether a and b are links to the same place or files
+// with the same bytes.
+func sameContent(a, b string) bool {
+	infoA, errA := os.Lstat(a)
+	infoB, errB := os.Lstat(b)
+	if errA != nil || errB != nil || infoA.Mode().Type() != infoB.Mode().Type() {
+		return false
+	}
+	if infoA.Mode()&fs.ModeSymlink != 0 {
+		linkA, errA := os.Readlink(a)
+		linkB, errB := os.Readlink(b)
+		return errA == nil && errB == nil && linkA == linkB
+	}
+	if infoA.Size() != infoB.Size() {
+		return false
+	}
+	dataA, errA := os.ReadFile(a)
+	dataB, errB := os.ReadFile(b)
+	return errA == nil && errB == nil && bytes.Equal(dataA, dataB)
+}
+
+func newRestoreCmd(opts *runOptions) *cobra.Command {
+	var list bool
+	var restore restoreOptions
+	cmd := &cobra.Command{
+		Use:   "restore [backup-set]",
+		Short: "Put installations back from a backup set",
+		Long:  "restore unpacks the installations of a backup set (the newest unless named) back where they were.\nA target that already holds files is a conflict and nothing is restored until --on-conflict says\nwhether to skip it, overwrite it (the existing tree is moved aside), restore to the --to directory\ninstead, or merge, asking about each file that differs.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			paths, err := resolvePaths(cfg)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if list {
+				sets, err := listBackupSets(paths.Backups)
+				if err != nil {
+					return err
+				}
+				for _, set := range sets {
+					fmt.Fprintf(out, "%s  %s\n", filepath.Base(set.dir), set.index.Created.Format("2006-01-02 15:04"))
+					for _, entry := range set.index.Entries {
+						status := "free"
+						if occupied, _ := targetOccupied(entry.Path); occupied {
+							status = "occupied"
+						}
+						fmt.Fprintf(out, "  %-8s  %-10s  %s\n", status, entry.Version, entry.Path)
+					}
+				}
+				return nil
+			}
+			name := ""
+			if len(args) > 0 {
+				name = args[0]
+			}
+			set, err := findBackupSet(paths.Backups, name)
+			if err != nil {
+				return err
+			}
+			if restore.to != "" {
+				if restore.to, err = filepath.Abs(restore.to); err != nil {
+					return err
+				}
+			}
+			logger, err := newConfiguredLogger(*opts, paths.Logs)
+			if err != nil {
+				return err
+			}
+			defer logger.Close()
+			events := newEventWriter(out, *opts)
+			stop := startHeadlessEvents(&events, *opts, cmd.ErrOrStderr())
+			defer stop()
+			ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+			restore.in, restore.out, restore.ctx, restore.events = cmd.InOrStdin(), out, ctx, events.events
+			outcomes, err := restoreBackupSet(set, restore, time.Now())
+			for _, outcome := range outcomes {
+				logger.Log("SUCCESS", outcome.String())
+				fmt.Fprintln(out, outcome)
+				for _, warning := range outcome.warnings {
+					logger.Log("WARN", fmt.Sprintf("Restore of %s: %s", outcome.target, warning))
+					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", warning)
+				}
+				if outcome.action == "skipped" {
+					continue
+				}
+				validation := validateRestore(outcome, os.Getenv)
+				for _, line := range validation.lines() {
+					fmt.Fprintln(out, line)
+				}
+				level := "SUCCESS"
+				if validation.confidence() != ConfidenceHigh {
+					level = "WARN"
+				}
+				logger.Log(level, fmt.Sprintf("Restore of %s: %s", outcome.target, validation.verdict()))
+			}
+			if err != nil {
+				logger.Log("ERROR", fmt.Sprintf("Restore from %s failed: %v", set.dir, err))
+				return err
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&list, "list", false, "show the backup sets and whether their targets hold files")
+	cmd.Flags().StringVar(&restore.conflict, "on-conflict", "", "what to do with targets that hold files: "+strings.Join(conflictStrategies, ", "))
+	cmd.Flags().StringVar(&restore.to, "to", "", "directory to restore conflicting installations to with --on-conflict alternate")
+	cmd.Flags().StringSliceVar(&restore.paths, "path", nil, "restore only this installation (repeatable)")
+	return cmd
+}
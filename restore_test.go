@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// removedGoRoot backs up a Go root in format and removes it, as a live run
+// does.
+func removedGoRoot(t *testing.T, format string) (string, backupSet) {
+	t.Helper()
+	root := filepath.Join(t.TempDir(), "go")
+	os.MkdirAll(filepath.Join(root, "bin"), 0755)
+	os.WriteFile(filepath.Join(root, "VERSION"), []byte("go1.22.5\n"), 0644)
+	os.WriteFile(filepath.Join(root, "bin", "go"), []byte("old go"), 0755)
+	os.WriteFile(filepath.Join(root, "bin", "gofmt"), []byte("old gofmt"), 0755)
+	backups := t.TempDir()
+	dir, err := writeBackupSet(backups, format, []GoInstallation{{Path: root, Version: "go1.22.5"}}, time.Now())
+	if err != nil {
+		t.Fatalf("writeBackupSet returned error: %v", err)
+	}
+	set, err := findBackupSet(backups, filepath.Base(dir))
+	if err != nil {
+		t.Fatalf("findBackupSet returned error: %v", err)
+	}
+	if err := os.RemoveAll(root); err != nil {
+		t.Fatalf("Failed to remove %s: %v", root, err)
+	}
+	return root, set
+}
+
+// reinstall puts a newer Go where the removed one was.
+func reinstall(t *testing.T, root string) {
+	t.Helper()
+	os.MkdirAll(filepath.Join(root, "bin"), 0755)
+	os.WriteFile(filepath.Join(root, "VERSION"), []byte("go1.23.0\n"), 0644)
+	os.WriteFile(filepath.Join(root, "bin", "go"), []byte("new go"), 0755)
+}
+
+func readString(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestRestoreBackupSet(t *testing.T) {
+	for _, format := range []string{backupFormatArchive, backupFormatDedup, backupFormatQuarantine} {
+		t.Run(format, func(t *testing.T) {
+			root, set := removedGoRoot(t, format)
+			os.MkdirAll(root, 0755) // an empty directory is not a conflict
+			outcomes, err := restoreBackupSet(set, restoreOptions{}, time.Now())
+			if err != nil {
+				t.Fatalf("restoreBackupSet returned error: %v", err)
+			}
+			if len(outcomes) != 1 || outcomes[0].action != "restored" || outcomes[0].target != root {
+				t.Fatalf("Unexpected outcomes %+v", outcomes)
+			}
+			if got := readString(t, filepath.Join(root, "bin", "go")); got != "old go" {
+				t.Errorf("Expected bin/go to be restored, got %q", got)
+			}
+			leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(root), ".fugo-restore-*"))
+			if len(leftovers) != 0 {
+				t.Errorf("Expected the staging directory to be removed, found %v", leftovers)
+			}
+		})
+	}
+}
+
+func TestRestoreRefusesConflicts(t *testing.T) {
+	root, set := removedGoRoot(t, backupFormatArchive)
+	reinstall(t, root)
+	_, err := restoreBackupSet(set, restoreOptions{}, time.Now())
+	if err == nil || !strings.Contains(err.Error(), root) || !strings.Contains(err.Error(), "--on-conflict") {
+		t.Fatalf("Expected a conflict naming %s, got %v", root, err)
+	}
+	if got := readString(t, filepath.Join(root, "VERSION")); got != "go1.23.0\n" {
+		t.Errorf("Expected the reinstalled Go to be untouched, got VERSION %q", got)
+	}
+	if _, err := restoreBackupSet(set, restoreOptions{conflict: "clobber"}, time.Now()); err == nil {
+		t.Errorf("Expected an unknown strategy to be refused")
+	}
+	if _, err := restoreBackupSet(set, restoreOptions{conflict: conflictAlternate}, time.Now()); err == nil {
+		t.Errorf("Expected alternate without --to to be refused")
+	}
+}
+
+func TestRestoreConflictStrategies(t *testing.T) {
+	skipInAuditBuild(t)
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+
+	t.Run("skip", func(t *testing.T) {
+		root, set := removedGoRoot(t, backupFormatArchive)
+		reinstall(t, root)
+		outcomes, err := restoreBackupSet(set, restoreOptions{conflict: conflictSkip}, now)
+		if err != nil || len(outcomes) != 1 || outcomes[0].action != "skipped" {
+			t.Fatalf("Expected the installation to be skipped, got %+v, %v", outcomes, err)
+		}
+		if got := readString(t, filepath.Join(root, "bin", "go")); got != "new go" {
+			t.Errorf("Expected the reinstalled Go to be untouched, got %q", got)
+		}
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		root, set := removedGoRoot(t, backupFormatArchive)
+		reinstall(t, root)
+		outcomes, err := restoreBackupSet(set, restoreOptions{conflict: conflictOverwrite}, now)
+		if err != nil || len(outcomes) != 1 {
+			t.Fatalf("restoreBackupSet returned %+v, %v", outcomes, err)
+		}
+		if got := readString(t, filepath.Join(root, "bin", "go")); got != "old go" {
+			t.Errorf("Expected bin/go to be restored, got %q", got)
+		}
+		aside := root + ".fugo-replaced-20261016-093000"
+		if outcomes[0].aside != aside || readString(t, filepath.Join(aside, "bin", "go")) != "new go" {
+			t.Errorf("Expected the reinstalled Go to be moved to %s, got %+v", aside, outcomes[0])
+		}
+	})
+
+	t.Run("alternate", func(t *testing.T) {
+		root, set := removedGoRoot(t, backupFormatDedup)
+		reinstall(t, root)
+		to := t.TempDir()
+		outcomes, err := restoreBackupSet(set, restoreOptions{conflict: conflictAlternate, to: to}, now)
+		if err != nil || len(outcomes) != 1 || outcomes[0].target != filepath.Join(to, "go") {
+			t.Fatalf("Expected a restore to %s, got %+v, %v", to, outcomes, err)
+		}
+		if got := readString(t, filepath.Join(to, "go", "bin", "go")); got != "old go" {
+			t.Errorf("Expected bin/go in the alternate directory, got %q", got)
+		}
+		if got := readString(t, filepath.Join(root, "bin", "go")); got != "new go" {
+			t.Errorf("Expected the reinstalled Go to be untouched, got %q", got)
+		}
+		if _, err := restoreBackupSet(set, restoreOptions{conflict: conflictAlternate, to: to}, now); err == nil {
+			t.Errorf("Expected an occupied alternate directory to be refused")
+		}
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		root, set := removedGoRoot(t, backupFormatArchive)
+		reinstall(t, root)
+		var prompts strings.Builder
+		// VERSION is asked about first, then bin/go
+		opts := restoreOptions{conflict: conflictMerge, in: strings.NewReader("n\ny\n"), out: &prompts}
+		outcomes, err := restoreBackupSet(set, opts, now)
+		if err != nil || len(outcomes) != 1 {
+			t.Fatalf("restoreBackupSet returned %+v, %v", outcomes, err)
+		}
+		if o := outcomes[0]; o.added != 1 || o.replaced != 1 || o.kept != 1 {
+			t.Errorf("Expected 1 added, 1 replaced and 1 kept, got %+v", o)
+		}
+		if got := readString(t, filepath.Join(root, "VERSION")); got != "go1.23.0\n" {
+			t.Errorf("Expected VERSION to be kept, got %q", got)
+		}
+		if got := readString(t, filepath.Join(root, "bin", "go")); got != "old go" {
+			t.Errorf("Expected bin/go to be replaced, got %q", got)
+		}
+		if got := readString(t, filepath.Join(root, "bin", "gofmt")); got != "old gofmt" {
+			t.Errorf("Expected bin/gofmt to be added, got %q", got)
+		}
+		if strings.Count(prompts.String(), "Replace it?") != 2 {
+			t.Errorf("Expected a prompt per differing file, got %q", prompts.String())
+		}
+	})
+}
+
+func TestMergePromptEndOfInputKeeps(t *testing.T) {
+	var out strings.Builder
+	prompt := &mergePrompt{in: bufio.NewReader(strings.NewReader("")), out: &out}
+	for i := 0; i < 2; i++ {
+		if ok, err := prompt.replace("/usr/local/go/VERSION"); ok || err != nil {
+			t.Errorf("Expected the end of input to keep the file, got %v, %v", ok, err)
+		}
+	}
+	prompt = &mergePrompt{in: bufio.NewReader(strings.NewReader("q\n")), out: &out}
+	if _, err := prompt.replace("/usr/local/go/VERSION"); err != errRestoreStopped {
+		t.Errorf("Expected q to stop the restore, got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// An archive that unpacks is not yet a toolchain that works. After each
+// restore fu-go runs the restored bin/go, compares VERSION and the file
+// count and bytes with what the index recorded at backup time, and says
+// how confident it is that the restore produced a working Go, along with
+// what PATH needs for the shell to find it.
+
+// runGoVersion runs a restored go command; tests replace it.
+var runGoVersion = sandboxedGoVersion
+
+// restoreCheck is one check of a restored installation.
+type restoreCheck struct {
+	name   string
+	ok     bool
+	ran    bool // false when the check does not apply
+	detail string
+}
+
+// restoreValidation is the checks of one restored installation.
+type restoreValidation struct {
+	target    string
+	toolchain bool // the installation is a Go root rather than, say, a GOPATH
+	checks    []restoreCheck
+	guidance  []string
+}
+
+// validateRestore checks the installation outcome restored.
+func validateRestore(outcome restoreOutcome, getenv func(string) string) restoreValidation {
+	entry, target := outcome.entry, outcome.target
+	v := restoreValidation{target: target}
+	_, err := os.Stat(filepath.Join(target, "VERSION"))
+	v.toolchain = entry.Version != "" || err == nil
+	want, _, _ := parseGoVersion(entry.Version)
+
+	if v.toolchain {
+		check := restoreCheck{name: "bin/go version", ran: true}
+		output, err := runGoVersion(goExecutable(target))
+		switch {
+		case err != nil:
+			check.detail = fmt.Sprintf("does not run: %v", err)
+		case want != "" && !versionIs(output, want):
+			check.detail = fmt.Sprintf("reports %q, the backup was go%s", output, want)
+		default:
+			check.ok, check.detail = true, output
+		}
+		v.checks = append(v.checks, check)
+
+		check = restoreCheck{name: "VERSION", ran: want != ""}
+		data, err := os.ReadFile(filepath.Join(target, "VERSION"))
+		first, _, _ := strings.Cut(string(data), "\n")
+		first = strings.TrimSpace(first)
+		switch {
+		case !check.ran:
+			check.detail = "the index records no version"
+		case err != nil:
+			check.detail = fmt.Sprintf("cannot be read: %v", err)
+		case !versionIs(first, want):
+			check.detail = fmt.Sprintf("says %s, the index says go%s", first, want)
+		default:
+			check.ok, check.detail = true, first
+		}
+		v.checks = append(v.checks, check)
+	}
+
+	check := restoreCheck{name: "files", ran: entry.Files > 0 && outcome.action != "merged"}
+	size, files := dirUsage(target)
+	switch {
+	case outcome.action == "merged":
+		check.detail = "not compared after a merge"
+	case !check.ran:
+		check.detail = "the index records no totals"
+	case files != entry.Files || size != entry.Bytes:
+		check.detail = fmt.Sprintf("%d files, %s restored; %d files, %s backed up", files, formatBytes(size), entry.Files, formatBytes(entry.Bytes))
+	default:
+		check.ok, check.detail = true, fmt.Sprintf("%d files, %s", files, formatBytes(size))
+	}
+	v.checks = append(v.checks, check)
+
+	if v.toolchain {
+		v.guidance = pathGuidance(target, getenv)
+	}
+	return v
+}
+
+// versionIs reports whether text ("go1.22.5" or go version's output) names
+// Go release semVer.
+func versionIs(text, semVer string) bool {
+	got, _, _ := parseGoVersion(text)
+	return got == semVer
+}
+
+// confidence is high when every check that ran passed, and low when the
+// restored go does not run or nothing could be checked.
+func (v restoreValidation) confidence() Confidence {
+	ran, failed := 0, 0
+	for _, check := range v.checks {
+		if !check.ran {
+			continue
+		}
+		ran++
+		if !check.ok {
+			failed++
+			if check.name == "bin/go version" {
+				return ConfidenceLow
+			}
+		}
+	}
+	switch {
+	case ran == 0:
+		return ConfidenceLow
+	case failed > 0:
+		return ConfidenceMedium
+	default:
+		return ConfidenceHigh
+	}
+}
+
+func (v restoreValidation) verdict() string {
+	switch v.confidence() {
+	case ConfidenceHigh:
+		if v.toolchain {
+			return "high confidence: the restored toolchain runs and matches the backup"
+		}
+		return "high confidence: the restored files match the backup"
+	case ConfidenceMedium:
+		return "medium confidence: the restore differs from the backup, see above"
+	}
+	if v.toolchain {
+		return "low confidence: the restored toolchain does not work"
+	}
+	return "low confidence: nothing could be compared with the backup"
+}
+
+// lines is the validation as printed after a restore.
+func (v restoreValidation) lines() []string {
+	var lines []string
+	for _, check := range v.checks {
+		mark := "✓"
+		if !check.ran {
+			mark = "-"
+		} else if !check.ok {
+			mark = "✗"
+		}
+		lines = append(lines, fmt.Sprintf("  %s %s: %s", mark, check.name, check.detail))
+	}
+	lines = append(lines, "  Verdict: "+v.verdict())
+	for _, line := range v.guidance {
+		lines = append(lines, "  "+line)
+	}
+	return lines
+}
+
+// pathGuidance says what the environment needs for the restored Go root to
+// be the go a shell runs.
+func pathGuidance(target string, getenv func(string) string) []string {
+	var guidance []string
+	bin := filepath.Join(target, "bin")
+	onPath := false
+	for _, dir := range filepath.SplitList(getenv("PATH")) {
+		if dir != "" && normalizePath(dir) == normalizePath(bin) {
+			onPath = true
+			break
+		}
+	}
+	if !onPath {
+		guidance = append(guidance, currentPlatform.pathHint(bin))
+	}
+	if goroot := getenv("GOROOT"); goroot != "" && normalizePath(goroot) != normalizePath(target) {
+		guidance = append(guidance, fmt.Sprintf("GOROOT is set to %s; unset it, or set it to %s", goroot, target))
+	}
+	return guidance
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fakeGoVersion(t *testing.T, output string, err error) {
+	t.Helper()
+	saved := runGoVersion
+	runGoVersion = func(string) (string, error) { return output, err }
+	t.Cleanup(func() { runGoVersion = saved })
+}
+
+func restoredGoRoot(t *testing.T) restoreOutcome {
+	t.Helper()
+	_, set := removedGoRoot(t, backupFormatArchive)
+	outcomes, err := restoreBackupSet(set, restoreOptions{}, time.Now())
+	if err != nil || len(outcomes) != 1 {
+		t.Fatalf("restoreBackupSet returned %+v, %v", outcomes, err)
+	}
+	return outcomes[0]
+}
+
+func TestValidateRestore(t *testing.T) {
+	env := func(string) string { return "" }
+
+	t.Run("working", func(t *testing.T) {
+		fakeGoVersion(t, "go version go1.22.5 linux/amd64", nil)
+		outcome := restoredGoRoot(t)
+		if outcome.entry.Files != 3 || outcome.entry.Bytes == 0 {
+			t.Fatalf("Expected the index to record 3 files and their bytes, got %+v", outcome.entry)
+		}
+		v := validateRestore(outcome, env)
+		if v.confidence() != ConfidenceHigh {
+			t.Errorf("Expected high confidence, got %s: %v", v.confidence(), v.lines())
+		}
+	})
+
+	t.Run("go does not run", func(t *testing.T) {
+		fakeGoVersion(t, "", errors.New("exec format error"))
+		v := validateRestore(restoredGoRoot(t), env)
+		if v.confidence() != ConfidenceLow || !strings.Contains(v.verdict(), "does not work") {
+			t.Errorf("Expected low confidence, got %s: %v", v.confidence(), v.lines())
+		}
+	})
+
+	t.Run("go reports another version", func(t *testing.T) {
+		fakeGoVersion(t, "go version go1.23.0 linux/amd64", nil)
+		v := validateRestore(restoredGoRoot(t), env)
+		if v.confidence() != ConfidenceLow {
+			t.Errorf("Expected low confidence, got %s: %v", v.confidence(), v.lines())
+		}
+	})
+
+	t.Run("differs from the backup", func(t *testing.T) {
+		fakeGoVersion(t, "go version go1.22.5 linux/amd64", nil)
+		outcome := restoredGoRoot(t)
+		os.WriteFile(filepath.Join(outcome.target, "VERSION"), []byte("go1.22.6\n"), 0644)
+		os.WriteFile(filepath.Join(outcome.target, "extra"), []byte("x"), 0644)
+		v := validateRestore(outcome, env)
+		if v.confidence() != ConfidenceMedium {
+			t.Errorf("Expected medium confidence, got %s: %v", v.confidence(), v.lines())
+		}
+		failed := 0
+		for _, check := range v.checks {
+			if check.ran && !check.ok {
+				failed++
+			}
+		}
+		if failed != 2 {
+			t.Errorf("Expected VERSION and the file totals to fail, got %v", v.lines())
+		}
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		fakeGoVersion(t, "go version go1.22.5 linux/amd64", nil)
+		outcome := restoredGoRoot(t)
+		outcome.action = "merged"
+		os.WriteFile(filepath.Join(outcome.target, "extra"), []byte("x"), 0644)
+		if v := validateRestore(outcome, env); v.confidence() != ConfidenceHigh {
+			t.Errorf("Expected totals not to count after a merge, got %v", v.lines())
+		}
+	})
+
+	t.Run("not a toolchain", func(t *testing.T) {
+		fakeGoVersion(t, "", errors.New("should not run"))
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module x\n"), 0644)
+		outcome := restoreOutcome{entry: backupEntry{Path: dir, Files: 1, Bytes: 9}, target: dir}
+		v := validateRestore(outcome, env)
+		if v.toolchain || v.confidence() != ConfidenceHigh || len(v.guidance) != 0 {
+			t.Errorf("Expected only the totals to be checked, got %v", v.lines())
+		}
+	})
+}
+
+func TestPathGuidance(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "opt", "go")
+	bin := filepath.Join(root, "bin")
+	env := map[string]string{"PATH": strings.Join([]string{"/usr/bin", bin}, string(os.PathListSeparator))}
+	if got := pathGuidance(root, func(k string) string { return env[k] }); len(got) != 0 {
+		t.Errorf("Expected no guidance with %s on PATH, got %v", bin, got)
+	}
+
+	env = map[string]string{"PATH": "/usr/bin", "GOROOT": "/usr/local/go"}
+	got := pathGuidance(root, func(k string) string { return env[k] })
+	if len(got) != 2 || !strings.Contains(got[0], bin) || !strings.Contains(got[1], "GOROOT is set to /usr/local/go") {
+		t.Errorf("Expected PATH and GOROOT guidance, got %v", got)
+	}
+	if runtime.GOOS != "windows" && !strings.Contains(got[0], "export PATH=") {
+		t.Errorf("Expected an export line, got %q", got[0])
+	}
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Restoring a multi-GB module cache takes a while, so restore reports its
+// progress like removal does, as progress events in the "restore" phase, and
+// can be stopped with Ctrl-C. Each entry is unpacked into a staging
+// directory named after the set and the entry, which a stopped or failed
+// restore leaves behind with a restore-state.json saying what it is for. The
+// next restore of the same entry picks it up and only unpacks the files that
+// are not already there with the size and time the backup recorded.
+
+const (
+	phaseRestore = "restore"
+
+	restoreStateFile = "restore-state.json"
+	// restoreProgressInterval is how often progress is published while
+	// files are unpacked.
+	restoreProgressInterval = 100 * time.Millisecond
+)
+
+// restoreState identifies what a staging directory is unpacking.
+type restoreState struct {
+	Set     string    `json:"set"`
+	Path    string    `json:"path"`
+	SHA256  string    `json:"sha256"` // of the archive or manifest
+	Started time.Time `json:"started"`
+}
+
+// restoreStagingDir is where entry of set is unpacked, next to target.
+func restoreStagingDir(set backupSet, entry backupEntry, target string) string {
+	sum := entry.SHA256
+	if len(sum) > 12 {
+		sum = sum[:12]
+	}
+	return filepath.Join(filepath.Dir(target), fmt.Sprintf(".fugo-restore-%s-%s", filepath.Base(target), sum))
+}
+
+// openStaging prepares staging for entry, keeping what an earlier restore of
+// the same entry unpacked there. It reports whether that happened.
+func openStaging(staging string, set backupSet, entry backupEntry, now time.Time) (bool, error) {
+	want := restoreState{Set: filepath.Base(set.dir), Path: entry.Path, SHA256: entry.SHA256}
+	var state restoreState
+	if data, err := os.ReadFile(filepath.Join(staging, restoreStateFile)); err == nil && json.Unmarshal(data, &state) == nil {
+		if state.Set == want.Set && state.Path == want.Path && state.SHA256 == want.SHA256 {
+			return true, nil
+		}
+	}
+	if err := os.RemoveAll(staging); err != nil {
+		return false, fmt.Errorf("failed to clear %s: %v", staging, err)
+	}
+	if err := os.Mkdir(staging, 0700); err != nil {
+		return false, fmt.Errorf("failed to create a staging directory: %v", err)
+	}
+	want.Started = now
+	data, err := json.MarshalIndent(want, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	return false, os.WriteFile(filepath.Join(staging, restoreStateFile), append(data, '\n'), 0644)
+}
+
+// restoreProgress publishes how far the unpacking of one entry is and stops
+// it once ctx is cancelled. A nil *restoreProgress does neither.
+type restoreProgress struct {
+	ctx        context.Context
+	events     *eventBus
+	path       string
+	files      int
+	filesTotal int
+	bytes      int64
+	bytesTotal int64
+	kept       int // files an earlier restore already unpacked
+	last       time.Time
+}
+
+func newRestoreProgress(ctx context.Context, events *eventBus, entry backupEntry) *restoreProgress {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p := &restoreProgress{ctx: ctx, events: events, path: entry.Path, filesTotal: int(entry.Files), bytesTotal: entry.Bytes}
+	p.publish()
+	return p
+}
+
+// stopped returns the reason to stop unpacking, if there is one.
+func (p *restoreProgress) stopped() error {
+	if p == nil {
+		return nil
+	}
+	return p.ctx.Err()
+}
+
+// file counts a file or link unpacked, or kept when an earlier restore had
+// already unpacked it.
+func (p *restoreProgress) file(size int64, kept bool) {
+	if p == nil {
+		return
+	}
+	p.files++
+	p.bytes += size
+	if kept {
+		p.kept++
+	}
+	if time.Since(p.last) >= restoreProgressInterval {
+		p.publish()
+	}
+}
+
+// finish publishes that the entry is completely unpacked.
+func (p *restoreProgress) finish() {
+	if p == nil {
+		return
+	}
+	p.files, p.bytes = max(p.files, p.filesTotal), max(p.bytes, p.bytesTotal)
+	p.publish()
+}
+
+func (p *restoreProgress) publish() {
+	p.last = time.Now()
+	p.events.publish(engineEvent{Kind: eventProgress, Phase: phaseRestore, Path: p.path, Done: p.files, Total: p.filesTotal, Bytes: p.bytes, BytesTotal: p.bytesTotal})
+}
+
+// copy copies r to w, checking between blocks whether to stop.
+func (p *restoreProgress) copy(w io.Writer, r io.Reader) error {
+	buf := make([]byte, 1<<20)
+	for {
+		if err := p.stopped(); err != nil {
+			return err
+		}
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// alreadyUnpacked reports whether an earlier restore finished unpacking the
+// file at path: files get their recorded time only once complete.
+func alreadyUnpacked(path string, size int64, modTime time.Time) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.Mode().IsRegular() && info.Size() == size && info.ModTime().Equal(modTime)
+}
+
+// clearForUnpack removes what an earlier restore left at path, unless it is
+// a directory.
+func clearForUnpack(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) || (err == nil && info.IsDir()) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// unpackPath is where the slash-separated name from a backup goes under dir.
+// A name that would leave dir is refused: an absolute or .. path, or one
+// through a symlink or file an earlier entry of the same backup created, so
+// nothing is ever written through a link.
+func unpackPath(dir, name string) (string, error) {
+	rel := path.Clean(name)
+	if !insideTree(rel) {
+		return "", fmt.Errorf("path %q leaves the installation", name)
+	}
+	parent := dir
+	parts := strings.Split(rel, "/")
+	for _, part := range parts[:len(parts)-1] {
+		parent = filepath.Join(parent, part)
+		info, err := os.Lstat(parent)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("path %q leaves the installation through %s", name, parent)
+		}
+	}
+	return filepath.Join(dir, filepath.FromSlash(rel)), nil
+}
+
+// mkdirUnpacked creates the directory target, refusing a symlink or file
+// already in its place.
+func mkdirUnpacked(target string) error {
+	if info, err := os.Lstat(target); err == nil && !info.IsDir() {
+		return fmt.Errorf("%s is in the way of a directory", target)
+	}
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return err
+	}
+	return os.Chmod(target, 0755)
+}
+
+// createUnpacked creates the file target in dir through an os.Root, so it
+// cannot end up outside dir whatever links are in the way.
+func createUnpacked(dir, target string, perm fs.FileMode) (*os.File, error) {
+	rel, err := filepath.Rel(dir, target)
+	if err != nil {
+		return nil, err
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	return root.OpenFile(rel, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+}
+
+// setDirModes gives directories their recorded mode and time, last, so
+// read-only ones do not stop their contents. One replaced by something else
+// since is left alone.
+func setDirModes(dirs []dirTimes) {
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if info, err := os.Lstat(dirs[i].path); err != nil || !info.IsDir() {
+			continue
+		}
+		os.Chmod(dirs[i].path, dirs[i].mode)
+		os.Chtimes(dirs[i].path, dirs[i].modTime, dirs[i].modTime)
+	}
+}
+
+// dirTimes is a directory whose mode and time are set once it is filled.
+type dirTimes struct {
+	path    string
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// extractArchive unpacks a tar.gz backup into staging. It handles what tar
+// -czf writes for a Go installation: directories, files, symlinks and hard
+// links, with their modes and times.
+func extractArchive(archive, staging string, p *restoreProgress) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	reader := tar.NewReader(zr)
+	var dirs []dirTimes
+	for {
+		if err := p.stopped(); err != nil {
+			return err
+		}
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		target, err := unpackPath(staging, strings.TrimPrefix(header.Name, "./"))
+		if err != nil {
+			return fmt.Errorf("archive %v", err)
+		}
+		mode := header.FileInfo().Mode() & chmodBits
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := mkdirUnpacked(target); err != nil {
+				return err
+			}
+			dirs = append(dirs, dirTimes{target, mode, header.ModTime})
+		case tar.TypeSymlink:
+			if err := clearForUnpack(target); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+			lchtimes(target, header.ModTime)
+			p.file(int64(len(header.Linkname)), false)
+		case tar.TypeLink:
+			linked, err := unpackPath(staging, strings.TrimPrefix(header.Linkname, "./"))
+			if err != nil {
+				return fmt.Errorf("archive link %v", err)
+			}
+			if err := clearForUnpack(target); err != nil {
+				return err
+			}
+			if err := os.Link(linked, target); err != nil {
+				return err
+			}
+			p.file(0, false)
+		case tar.TypeReg, tar.TypeGNUSparse:
+			if alreadyUnpacked(target, header.Size, header.ModTime) {
+				p.file(header.Size, true)
+				continue
+			}
+			if err := clearForUnpack(target); err != nil {
+				return err
+			}
+			if err := unpackFile(reader, staging, target, mode, header.ModTime, p); err != nil {
+				return err
+			}
+			p.file(header.Size, false)
+		default:
+			// Devices, pipes and sockets are not part of a Go installation
+		}
+	}
+	setDirModes(dirs)
+	p.finish()
+	return nil
+}
+
+// unpackFile writes r to a new file at path in dir and gives it its mode and
+// time.
+func unpackFile(r io.Reader, dir, path string, mode fs.FileMode, modTime time.Time, p *restoreProgress) error {
+	return writeUnpackedFile(path, mode, modTime, func() error {
+		f, err := createUnpacked(dir, path, mode.Perm()|0200)
+		if err != nil {
+			return err
+		}
+		if err := p.copy(f, r); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	})
+}
+
+// writeUnpackedFile runs write to put the file at path in place, then gives
+// it mode and modTime. The time goes last, so a file cut short is never
+// taken for a complete one when the restore is resumed.
+func writeUnpackedFile(path string, mode fs.FileMode, modTime time.Time, write func() error) error {
+	if err := write(); err != nil {
+		return err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		return err
+	}
+	return os.Chtimes(path, modTime, modTime)
+}
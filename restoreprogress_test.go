@@ -0,0 +1,161 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRestorePublishesProgress(t *testing.T) {
+	for _, format := range []string{backupFormatArchive, backupFormatDedup, backupFormatQuarantine} {
+		t.Run(format, func(t *testing.T) {
+			root, set := removedGoRoot(t, format)
+			bus := newEventBus()
+			events := bus.subscribe()
+			var got []engineEvent
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for e := range events {
+					got = append(got, e)
+				}
+			}()
+			_, err := restoreBackupSet(set, restoreOptions{events: bus}, time.Now())
+			bus.close()
+			<-done
+			if err != nil {
+				t.Fatalf("restoreBackupSet returned error: %v", err)
+			}
+			if len(got) < 2 {
+				t.Fatalf("Expected a first and a last progress event, got %+v", got)
+			}
+			last := got[len(got)-1]
+			if last.Kind != eventProgress || last.Phase != phaseRestore || last.Path != root {
+				t.Errorf("Unexpected event %+v", last)
+			}
+			if last.Done != last.Total || last.Bytes != last.BytesTotal || last.Total != 3 {
+				t.Errorf("Expected the restore to end at 3 of 3 files, got %+v", last)
+			}
+			if record := newProgressRecord(last); record.Item != root || record.Percent != 100 {
+				t.Errorf("Expected the JSON stream to name the installation at 100%%, got %+v", record)
+			}
+		})
+	}
+}
+
+func TestRestoreResumesAfterStop(t *testing.T) {
+	for _, format := range []string{backupFormatArchive, backupFormatDedup, backupFormatQuarantine} {
+		t.Run(format, func(t *testing.T) {
+			root, set := removedGoRoot(t, format)
+			entry := set.index.Entries[0]
+			staging := restoreStagingDir(set, entry, root)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := restoreBackupSet(set, restoreOptions{ctx: ctx}, time.Now())
+			if err == nil || !strings.Contains(err.Error(), "resume from "+staging) {
+				t.Fatalf("Expected a stopped restore to say how to resume, got %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(staging, restoreStateFile)); err != nil {
+				t.Fatalf("Expected the staging directory to be kept: %v", err)
+			}
+			if _, err := os.Stat(root); !os.IsNotExist(err) {
+				t.Errorf("Expected nothing to be moved into place, got %v", err)
+			}
+
+			// Stopped later: two files complete and one cut short
+			if _, _, err := stageEntry(set, entry, staging, nil); err != nil {
+				t.Fatalf("stageEntry returned error: %v", err)
+			}
+			os.WriteFile(filepath.Join(staging, "go", "bin", "gofmt"), []byte("old"), 0755)
+
+			outcomes, err := restoreBackupSet(set, restoreOptions{}, time.Now())
+			if err != nil {
+				t.Fatalf("restoreBackupSet returned error: %v", err)
+			}
+			if len(outcomes) != 1 || outcomes[0].resumed != 2 || !strings.Contains(outcomes[0].String(), "resumed") {
+				t.Errorf("Expected the two complete files to be kept, got %+v", outcomes)
+			}
+			if got := readString(t, filepath.Join(root, "bin", "gofmt")); got != "old gofmt" {
+				t.Errorf("Expected the file cut short to be unpacked again, got %q", got)
+			}
+			if _, err := os.Stat(staging); !os.IsNotExist(err) {
+				t.Errorf("Expected the staging directory to be removed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOpenStagingClearsAnotherRestore(t *testing.T) {
+	staging := filepath.Join(t.TempDir(), ".fugo-restore-go-abc")
+	set := backupSet{dir: "/backups/fugo_backup_20261016_093000"}
+	now := time.Now()
+	if resumed, err := openStaging(staging, set, backupEntry{Path: "/usr/local/go", SHA256: "abc"}, now); err != nil || resumed {
+		t.Fatalf("Expected a fresh staging directory, got %v, %v", resumed, err)
+	}
+	os.WriteFile(filepath.Join(staging, "leftover"), []byte("x"), 0644)
+	if resumed, _ := openStaging(staging, set, backupEntry{Path: "/usr/local/go", SHA256: "abc"}, now); !resumed {
+		t.Errorf("Expected the same entry to resume")
+	}
+	if resumed, _ := openStaging(staging, set, backupEntry{Path: "/usr/local/go", SHA256: "def"}, now); resumed {
+		t.Errorf("Expected another archive to start over")
+	}
+	if _, err := os.Stat(filepath.Join(staging, "leftover")); !os.IsNotExist(err) {
+		t.Errorf("Expected the other restore's files to be cleared, got %v", err)
+	}
+}
+
+func TestExtractArchiveRefusesEscapes(t *testing.T) {
+	outside := t.TempDir()
+	before, _ := os.Stat(outside)
+	cases := map[string][]tar.Header{
+		"dot dot": {{Name: "go/../../evil", Typeflag: tar.TypeReg, Mode: 0644, Size: 1}},
+		"through a symlink": {
+			{Name: "go/", Typeflag: tar.TypeDir, Mode: 0755},
+			{Name: "go/lib", Typeflag: tar.TypeSymlink, Linkname: outside},
+			{Name: "go/lib/evil", Typeflag: tar.TypeReg, Mode: 0644, Size: 1},
+		},
+		"directory through a symlink": {
+			{Name: "go/", Typeflag: tar.TypeDir, Mode: 0755},
+			{Name: "go/lib", Typeflag: tar.TypeSymlink, Linkname: outside},
+			{Name: "go/lib/", Typeflag: tar.TypeDir, Mode: 0777},
+		},
+		"hard link outside": {{Name: "go/evil", Typeflag: tar.TypeLink, Linkname: "../../../etc/passwd"}},
+	}
+	for name, headers := range cases {
+		t.Run(name, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), "evil.tar.gz")
+			f, _ := os.Create(archive)
+			zw := gzip.NewWriter(f)
+			tw := tar.NewWriter(zw)
+			for _, header := range headers {
+				tw.WriteHeader(&header)
+				if header.Size > 0 {
+					tw.Write([]byte("x"))
+				}
+			}
+			tw.Close()
+			zw.Close()
+			f.Close()
+
+			staging := filepath.Join(t.TempDir(), "staging")
+			os.Mkdir(staging, 0755)
+			if err := extractArchive(archive, staging, nil); err == nil || !strings.Contains(err.Error(), "leaves the installation") && !strings.Contains(err.Error(), "in the way") {
+				t.Errorf("Expected a path outside the staging directory to be refused, got %v", err)
+			}
+			for _, escaped := range []string{filepath.Join(filepath.Dir(staging), "evil"), filepath.Join(outside, "evil")} {
+				if _, err := os.Stat(escaped); !os.IsNotExist(err) {
+					t.Errorf("Expected nothing to be written outside, got %v", err)
+				}
+			}
+			if info, _ := os.Stat(outside); info.Mode() != before.Mode() {
+				t.Errorf("Expected the directory outside to keep its mode, got %v", info.Mode())
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// After a live run the complete screen lists every plan item with what
+// happened to it, the space it freed and how long it took. Failed items can
+// be marked with space and run again with r; the items that already
+// succeeded are left as they are.
+
+const (
+	statusRemoved    = "removed"
+	statusSkipped    = "skipped"
+	statusFailed     = "failed"
+	statusRolledBack = "rolled back"
+)
+
+// rolledBackError is a failure the item undid before returning, so nothing
+// was changed.
+type rolledBackError struct{ err error }
+
+func (e rolledBackError) Error() string { return e.err.Error() + " (rolled back)" }
+func (e rolledBackError) Unwrap() error { return e.err }
+
+func (r itemResult) status() string {
+	var rolledBack rolledBackError
+	switch {
+	case r.skipped != "":
+		return statusSkipped
+	case errors.As(r.err, &rolledBack):
+		return statusRolledBack
+	case r.err != nil:
+		return statusFailed
+	}
+	return statusRemoved
+}
+
+// reason is why the item did not succeed, "" if it did.
+func (r itemResult) reason() string {
+	if r.skipped != "" {
+		return r.skipped
+	}
+	var rolledBack rolledBackError
+	if errors.As(r.err, &rolledBack) {
+		return rolledBack.err.Error()
+	}
+	if r.err != nil {
+		return r.err.Error()
+	}
+	return ""
+}
+
+// freed is the space the item gave back.
+func (r itemResult) freed() int64 {
+	if !r.ok() {
+		return 0
+	}
+	return r.item.Size()
+}
+
+var resultStatusIcons = map[string]string{
+	statusRemoved:    "✅",
+	statusSkipped:    "⏭️ ",
+	statusFailed:     "❌",
+	statusRolledBack: "↩️ ",
+}
+
+// resultColumns are the fixed-width columns; the item takes the rest of the
+// width and the reason whatever is left after that.
+var resultColumns = []table.Column{
+	{Title: "", Width: 3},
+	{Title: "Status", Width: 14},
+	{Title: "Item", Width: 0},
+	{Title: "Freed", Width: 9},
+	{Title: "Time", Width: 8},
+	{Title: "Reason", Width: 0},
+}
+
+const maxResultRows = 12
+
+// retryCompleted carries the outcome of running the failed items again.
+// indexes says which entries of results they replace.
+type retryCompleted struct {
+	indexes []int
+	results []itemResult
+	err     error // the retry could not run at all
+}
+
+// newResultsTable builds the table for results, width columns wide.
+func newResultsTable(results []itemResult, retry map[int]bool, width int) table.Model {
+	columns := append([]table.Column(nil), resultColumns...)
+	room := width
+	for _, col := range columns {
+		room -= col.Width + cellPadding
+	}
+	room -= 2 * cellPadding
+	columns[2].Width = max(room*3/5, minPathWidth)
+	columns[5].Width = max(room-columns[2].Width, minPathWidth)
+
+	rows := make([]table.Row, 0, len(results))
+	for i, result := range results {
+		mark := ""
+		if !result.ok() {
+			mark = "[ ]"
+			if retry[i] {
+				mark = "[x]"
+			}
+		}
+		status := result.status()
+		took := "-"
+		if result.duration >= 10*time.Millisecond {
+			took = result.duration.Round(10 * time.Millisecond).String()
+		} else if result.duration > 0 {
+			took = "<10ms"
+		}
+		rows = append(rows, table.Row{mark, resultStatusIcons[status] + " " + status, result.item.Describe(), formatBytes(result.freed()), took, result.reason()})
+	}
+	t := table.New(table.WithColumns(columns), table.WithRows(rows), table.WithFocused(true), table.WithHeight(min(max(len(rows), 1), maxResultRows)+1))
+	styles := table.DefaultStyles()
+	styles.Selected = styles.Selected.Reverse(true)
+	t.SetStyles(styles)
+	return t
+}
+
+// setResults shows results in the table, keeping the cursor where it was.
+func (m *model) setResults(results []itemResult) {
+	cursor := m.resultsTable.Cursor()
+	m.results = results
+	m.resultsTable = newResultsTable(results, m.retrySelected, m.width-4)
+	m.resultsTable.SetCursor(min(cursor, max(len(results)-1, 0)))
+}
+
+// failedIndexes are the results to retry: the marked ones, or every item
+// that failed or was skipped when none is marked.
+func (m model) failedIndexes() []int {
+	var marked, failed []int
+	for i, result := range m.results {
+		if result.ok() {
+			continue
+		}
+		failed = append(failed, i)
+		if m.retrySelected[i] {
+			marked = append(marked, i)
+		}
+	}
+	if len(marked) > 0 {
+		return marked
+	}
+	return failed
+}
+
+// handleResultsKey moves through the results, marks failed items with space
+// and retries them with r.
+func (m model) handleResultsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q", "enter":
+		if m.logFile != nil {
+			m.logFile.Close()
+		}
+		return m, tea.Quit
+	case "up", "k":
+		m.resultsTable.MoveUp(1)
+	case "down", "j":
+		m.resultsTable.MoveDown(1)
+	case " ":
+		i := m.resultsTable.Cursor()
+		if m.retrying || i >= len(m.results) || m.results[i].ok() {
+			return m, nil
+		}
+		if m.retrySelected == nil {
+			m.retrySelected = make(map[int]bool)
+		}
+		m.retrySelected[i] = !m.retrySelected[i]
+		m.setResults(m.results)
+	case "r":
+		if m.retrying {
+			return m, nil
+		}
+		return m.retryFailed()
+	case "e":
+		if !m.canElevate() {
+			return m, nil
+		}
+		return m.retryElevated()
+	}
+	return m, nil
+}
+
+// retryFailed runs the failed items again.
+func (m model) retryFailed() (tea.Model, tea.Cmd) {
+	indexes := m.failedIndexes()
+	if len(indexes) == 0 {
+		return m, nil
+	}
+	items := make([]PlanItem, len(indexes))
+	for j, i := range indexes {
+		items[j] = m.results[i].item
+	}
+	m.retrying = true
+	if m.logFile != nil {
+		m.logFile.Log("INFO", fmt.Sprintf("Retrying %d failed item(s)", len(items)))
+	}
+	env := m.planEnv(nil)
+	return m, func() tea.Msg {
+		return retryCompleted{indexes: indexes, results: executePlan(items, env)}
+	}
+}
+
+// handleRetryCompleted merges the retried items into the results.
+func (m model) handleRetryCompleted(msg retryCompleted) (model, tea.Cmd) {
+	m.retrying = false
+	m.retryErr = msg.err
+	if msg.err != nil {
+		if m.logFile != nil {
+			m.logFile.Log("ERROR", msg.err.Error())
+		}
+		return m, nil
+	}
+	m.logResults(msg.results)
+	m = m.forgetDocs(msg.results)
+	results := append([]itemResult(nil), m.results...)
+	for j, i := range msg.indexes {
+		results[i] = msg.results[j]
+		delete(m.retrySelected, i)
+	}
+	m.setResults(results)
+	m.err = executionError(results)
+	m.deletionComplete = m.err == nil
+	if m.deletionComplete && m.logFile != nil {
+		m.logFile.Log("SUCCESS", "Go uninstallation completed successfully after retrying")
+	}
+	return m, nil
+}
+
+// renderResults is the results table, with what was freed in total.
+func (m model) renderResults() string {
+	if len(m.results) == 0 {
+		return ""
+	}
+	var freed int64
+	counts := make(map[string]int)
+	for _, result := range m.results {
+		freed += result.freed()
+		counts[result.status()]++
+	}
+	s := highlightStyle.Render(fmt.Sprintf("📋 %d removed, %d failed, %d skipped, %d rolled back; %s freed",
+		counts[statusRemoved], counts[statusFailed], counts[statusSkipped], counts[statusRolledBack], formatBytes(freed))) + "\n"
+	s += m.resultsTable.View() + "\n"
+	switch {
+	case m.retrying:
+		s += infoStyle.Render("   ⏳ Retrying...") + "\n"
+	case counts[statusRemoved] < len(m.results):
+		s += infoStyle.Render("   ↑/↓ to move, space to mark a failed item, r to retry the marked (or all failed) items") + "\n"
+		if m.canElevate() {
+			s += warningStyle.Render(fmt.Sprintf("   🔑 Some items need more privileges: e to retry them with %s", currentPlatform.elevationTool())) + "\n"
+		}
+	}
+	if m.retryErr != nil {
+		s += warningStyle.Render("   "+m.retryErr.Error()) + "\n"
+	}
+	return lipgloss.NewStyle().MarginLeft(2).Render(s) + "\n"
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestItemResultStatus(t *testing.T) {
+	item := installationItem{install: GoInstallation{Path: "/usr/local/go", Size: 300}}
+	failure := errors.New("permission denied")
+	testCases := []struct {
+		result itemResult
+		status string
+		reason string
+		freed  int64
+	}{
+		{itemResult{item: item}, statusRemoved, "", 300},
+		{itemResult{item: item, err: failure}, statusFailed, "permission denied", 0},
+		{itemResult{item: item, err: rolledBackError{failure}}, statusRolledBack, "permission denied", 0},
+		{itemResult{item: item, skipped: "/usr/local/go was not removed"}, statusSkipped, "/usr/local/go was not removed", 0},
+	}
+	for _, tc := range testCases {
+		if got := tc.result.status(); got != tc.status {
+			t.Errorf("status() = %q, want %q", got, tc.status)
+		}
+		if got := tc.result.reason(); got != tc.reason {
+			t.Errorf("reason() = %q, want %q", got, tc.reason)
+		}
+		if got := tc.result.freed(); got != tc.freed {
+			t.Errorf("freed() = %d, want %d", got, tc.freed)
+		}
+	}
+}
+
+func TestRetryFailedItems(t *testing.T) {
+	skipInAuditBuild(t)
+	dir := t.TempDir()
+	link := filepath.Join(dir, "go")
+	os.Symlink("/somewhere/else", link)
+	removable := fakeGoRoot(t, "VERSION", "bin/go")
+	items := []PlanItem{
+		installationItem{install: GoInstallation{Path: removable}},
+		symlinkItem{link: link, dest: "/usr/local/go/bin/go"},
+	}
+
+	m := model{state: "complete"}
+	m.setResults(executePlan(items, planEnv{}))
+	m.err = executionError(m.results)
+	if m.results[1].ok() || !strings.Contains(m.results[1].reason(), "no longer points at") {
+		t.Fatalf("Expected the repointed link to fail, got %+v", m.results[1])
+	}
+
+	// Only failed items can be marked
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeySpace})
+	m = updated.(model)
+	if len(m.retrySelected) != 0 {
+		t.Errorf("Expected a removed item not to be marked, got %v", m.retrySelected)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = updated.(model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace})
+	m = updated.(model)
+	if !m.retrySelected[1] {
+		t.Fatalf("Expected the failed link to be marked")
+	}
+
+	os.Remove(link)
+	os.Symlink("/usr/local/go/bin/go", link)
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	m = updated.(model)
+	if !m.retrying || cmd == nil {
+		t.Fatalf("Expected r to start a retry")
+	}
+	msg := cmd().(retryCompleted)
+	if len(msg.indexes) != 1 || msg.indexes[0] != 1 {
+		t.Errorf("Expected only the failed item to run again, got %v", msg.indexes)
+	}
+	updated, _ = m.Update(msg)
+	m = updated.(model)
+	if m.retrying || m.err != nil || !m.deletionComplete || !m.results[0].ok() || !m.results[1].ok() {
+		t.Errorf("Expected every item to have succeeded after the retry, got %+v (err %v)", m.results, m.err)
+	}
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Errorf("Expected the link to be removed on retry, got %v", err)
+	}
+}
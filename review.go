@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The last confirmation step happens on a review screen that spells out the
+// operations the plan runs: the paths handed to the remover, the package
+// manager commands and the profile and registry edits. It is the dry-run
+// summary, taken from the same plan items that execute, and the plan is
+// frozen when the screen opens so nothing changes between reading and
+// confirming.
+
+// quoteOperand single-quotes s for a shell if it needs quoting.
+func quoteOperand(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`*?[]{}()<>|&;#~!") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// startReview freezes the plan and moves to the review screen, where step is
+// the confirmation still to come.
+func (m model) startReview(step int) model {
+	m.reviewedPlan = m.plan()
+	m.state = "review"
+	m.confirmationStep = step
+	m.textInput.SetValue("")
+	m.textInput.Placeholder = "Press ENTER to run these operations"
+	if m.logFile != nil {
+		for _, op := range planOperations(m.reviewedPlan) {
+			m.logFile.Log("INFO", "Reviewed operation: "+op)
+		}
+	}
+	return m
+}
+
+// planOperations lists every operation the plan runs, in order.
+func planOperations(items []PlanItem) []string {
+	var ops []string
+	for _, item := range items {
+		ops = append(ops, item.Operations()...)
+	}
+	return ops
+}
+
+// handleReviewKey runs the plan on the final confirmation; esc goes back to
+// the confirm screen to change it, starting the confirmation over.
+func (m model) handleReviewKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		if m.logFile != nil {
+			m.logFile.Log("INFO", "User cancelled operation")
+			m.logFile.Close()
+		}
+		return m, tea.Quit
+	case "esc":
+		m.state = "confirm"
+		m.confirmationStep = ConfirmationStepInitial
+		m.reviewedPlan = nil
+		m.textInput.SetValue("")
+		m.textInput.Placeholder = "Type 'CONFIRM' to proceed"
+		m.textInput.EchoMode = textinput.EchoNormal
+		m.textInput.CharLimit = 20
+		m = m.checkOwnership()
+		if m.logFile != nil {
+			m.logFile.Log("INFO", "Left the review screen to change the plan")
+		}
+		return m, nil
+	case "enter":
+		return m.handleConfirmation()
+	}
+	if m.confirmationStep == ConfirmationStepReview {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+// renderOperations lists the skipped installations and then each plan item
+// with the operations it runs.
+func (m model) renderOperations(items []PlanItem) string {
+	var s string
+	for _, install := range m.selectedInstalls() {
+		if install.Blocked != "" {
+			s += fmt.Sprintf("  🚫 Skip: %s (%s)\n", install.Path, install.Blocked)
+		}
+	}
+	for _, item := range items {
+		s += fmt.Sprintf("  %s %s\n", planItemIcons[item.Kind()], item.Describe())
+		for _, op := range item.Operations() {
+			s += infoStyle.Render("     $ "+op) + "\n"
+		}
+	}
+	return s
+}
+
+func (m model) renderReview() string {
+	s := highlightStyle.Render(fmt.Sprintf("📜 Review: exactly these %d operation(s) will run", len(planOperations(m.reviewedPlan)))) + "\n\n"
+	s += m.renderOperations(m.reviewedPlan) + "\n"
+	s += m.renderMode() + "\n"
+	s += m.renderConfirmationStep()
+	return s + "\n" + confirmButtonStyle.Render("ENTER") + " to continue, " + cancelButtonStyle.Render("esc") + " to change the plan, " + cancelButtonStyle.Render("ctrl+c") + " to quit\n"
+}
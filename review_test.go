@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestQuoteOperand(t *testing.T) {
+	testCases := map[string]string{
+		"/usr/local/go":        "/usr/local/go",
+		"/Users/Go Fan/sdk/go": "'/Users/Go Fan/sdk/go'",
+		"/tmp/it's":            `'/tmp/it'\''s'`,
+		`C:\Program Files\Go`:  `'C:\Program Files\Go'`,
+		"":                     "''",
+	}
+	for in, want := range testCases {
+		if got := quoteOperand(in); got != want {
+			t.Errorf("quoteOperand(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPlanItemOperations(t *testing.T) {
+	edit := profileEdit{Path: "/home/gopher/.bashrc", Lines: []string{"a", "b", "c", "d", "e"}, Hunks: []profileHunk{
+		{Start: 0, Count: 1, Accept: true},
+		{Start: 2, Count: 1},
+		{Start: 3, Count: 2, Accept: true},
+	}}
+	testCases := []struct {
+		item PlanItem
+		want []string
+	}{
+		{installationItem{install: GoInstallation{Path: "/usr/local/go"}}, []string{"rm -rf /usr/local/go"}},
+		{packageUninstallItem{install: GoInstallation{Path: "/snap/go", PackageManager: "snap", Package: "go"}}, []string{"snap remove go"}},
+		{symlinkItem{link: "/usr/local/bin/go", dest: "/usr/local/go/bin/go"}, []string{"rm /usr/local/bin/go"}},
+		{gopathItem{path: "/home/gopher/go"}, []string{"rm -rf /home/gopher/go"}},
+		{envEditItem{edit: edit}, []string{"sed -i '4,5d' /home/gopher/.bashrc", "sed -i '1d' /home/gopher/.bashrc"}},
+		{envEditItem{edit: profileEdit{Path: userPathName, Registry: true, Lines: []string{`C:\Go\bin`, `C:\tools`}, Hunks: []profileHunk{{Start: 0, Count: 1, Accept: true}}}}, []string{`set ` + userPathName + `=C:\tools`}},
+		{envEditItem{edit: profileEdit{Path: "/home/gopher/.zshrc", Lines: []string{"a"}, Hunks: []profileHunk{{Start: 0, Count: 1}}}}, nil},
+	}
+	for _, tc := range testCases {
+		if got := tc.item.Operations(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.item.Describe(), got, tc.want)
+		}
+	}
+}
+
+func TestReviewFreezesPlanAndHoldsFinalStep(t *testing.T) {
+	m := model{
+		state:            "confirm",
+		dryRun:           true,
+		textInput:        textinput.New(),
+		hashConfirmation: "abc123",
+		opts:             runOptions{simulate: true},
+		detectedInstalls: []GoInstallation{{Path: "/usr/local/go", Source: "official", Verified: true}},
+	}
+	m.textInput.SetValue("CONFIRM")
+	updated, _ := m.handleConfirmation()
+	m = updated.(model)
+	m.textInput.SetValue("abc123")
+	updated, _ = m.handleConfirmation()
+	m = updated.(model)
+	if m.state != "review" || m.confirmationStep != ConfirmationStepDestroy {
+		t.Fatalf("Expected the final step on the review screen, got state %q step %d", m.state, m.confirmationStep)
+	}
+	if len(m.reviewedPlan) != 1 || !strings.Contains(m.View(), "$ rm -rf /usr/local/go") {
+		t.Errorf("Expected the review to list the removal, got:\n%s", m.View())
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(model)
+	if m.state != "confirm" || m.confirmationStep != ConfirmationStepInitial || m.reviewedPlan != nil {
+		t.Errorf("Expected esc to start the confirmation over, got state %q step %d", m.state, m.confirmationStep)
+	}
+}
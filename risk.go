@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The confirm screen groups the plan by risk and only asks for the hash and
+// the final challenge when something high-risk is selected; removing a
+// toolchain from your own home directory needs no more than CONFIRM.
+
+var riskColors = []string{"#C3E88D", "#FFCB6B", "#F07178"}
+
+func (r RiskLevel) badge() string {
+	icons := []string{"🟢", "🟡", "🔴"}
+	return icons[r] + " " + r.String()
+}
+
+func (r RiskLevel) render(text string) string {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(riskColors[r])).Render(text)
+}
+
+// installRisk is the risk of the plan item that would remove install.
+func installRisk(install GoInstallation) RiskLevel {
+	home, _ := os.UserHomeDir()
+	return installationPlanItem(install, home).Risk()
+}
+
+// planRisk is the highest risk among items, low for an empty plan.
+func planRisk(items []PlanItem) RiskLevel {
+	risk := RiskLow
+	for _, item := range items {
+		risk = max(risk, item.Risk())
+	}
+	return risk
+}
+
+// needsFullConfirmation reports whether the hash and final challenge steps
+// apply. A challenge configured by the user or the machine policy always
+// applies.
+func (m model) needsFullConfirmation() bool {
+	if currentPolicy().FinalChallenge != nil || m.config.FinalChallenge.Type != "" {
+		return true
+	}
+	return planRisk(m.plan()) == RiskHigh
+}
+
+// renderRiskSummary groups the selected plan by risk, highest first.
+func (m model) renderRiskSummary() string {
+	items := m.plan()
+	if len(items) == 0 {
+		return ""
+	}
+	var groups []string
+	for risk := RiskHigh; risk >= RiskLow; risk-- {
+		count := 0
+		var size int64
+		for _, item := range items {
+			if item.Risk() == risk {
+				count++
+				size += item.Size()
+			}
+		}
+		if count > 0 {
+			groups = append(groups, risk.render(fmt.Sprintf("%s: %d item(s), %s", risk.badge(), count, formatBytes(size))))
+		}
+	}
+	s := "📊 Plan by risk: " + strings.Join(groups, " · ") + "\n"
+	if !m.needsFullConfirmation() {
+		s += infoStyle.Render("   Nothing high-risk is selected, so CONFIRM is the only step") + "\n"
+	}
+	return s
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+func TestNeedsFullConfirmation(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	userLocal := GoInstallation{Path: filepath.Join(home, "sdk", "go1.22.5"), Verified: true}
+	system := GoInstallation{Path: "/usr/local/go", Verified: true}
+
+	m := model{detectedInstalls: []GoInstallation{userLocal}}
+	if m.needsFullConfirmation() {
+		t.Errorf("Expected a user-local toolchain alone to need only CONFIRM")
+	}
+	m.config.FinalChallenge = ChallengeConfig{Type: "typed"}
+	if !m.needsFullConfirmation() {
+		t.Errorf("Expected a configured final challenge to always apply")
+	}
+	m = model{detectedInstalls: []GoInstallation{userLocal, system}}
+	if !m.needsFullConfirmation() {
+		t.Errorf("Expected a system toolchain to need the full confirmation")
+	}
+	m.selection = map[string]bool{system.Path: false}
+	if m.needsFullConfirmation() {
+		t.Errorf("Expected deselecting the system toolchain to lower the risk")
+	}
+}
+
+func TestConfirmSkipsHashForLowRiskPlans(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	m := model{
+		state:            "confirm",
+		dryRun:           true,
+		textInput:        textinput.New(),
+		paths:            fugoPaths{Reports: t.TempDir()},
+		detectedInstalls: []GoInstallation{{Path: filepath.Join(home, "go1.21.0"), Verified: true}},
+	}
+	m.textInput.SetValue("CONFIRM")
+	updated, _ := m.handleConfirmation()
+	got := updated.(model)
+	if got.state != "review" || got.confirmationStep != ConfirmationStepReview {
+		t.Fatalf("Expected CONFIRM alone to lead to the review of a medium-risk plan, got state %q step %d", got.state, got.confirmationStep)
+	}
+	if updated, _ = got.handleConfirmation(); updated.(model).state != "dry_run_complete" {
+		t.Errorf("Expected ENTER on the review to run the plan, got state %q", updated.(model).state)
+	}
+
+	m.detectedInstalls = append(m.detectedInstalls, GoInstallation{Path: "/usr/local/go", Verified: true})
+	updated, _ = m.handleConfirmation()
+	if got := updated.(model); got.confirmationStep != ConfirmationStepHash {
+		t.Errorf("Expected a high-risk plan to ask for the hash, got state %q step %d", got.state, got.confirmationStep)
+	}
+}
+
+func TestSortByRisk(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	installations := []GoInstallation{
+		{Path: filepath.Join(home, "go")},
+		{Path: "/usr/local/go"},
+		{Path: "/usr/lib/go", PackageManager: "pkg", Package: "go"},
+	}
+	sortInstallations(installations, SortByRisk)
+	if installRisk(installations[0]) != RiskHigh || installRisk(installations[2]) != RiskMedium {
+		t.Errorf("Expected high-risk installations first, got %+v", installations)
+	}
+}
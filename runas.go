@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Removing another user's toolchain as root works, but the audit log then
+// says root did it, and anything root puts back in that home, such as a
+// tree restored after a failed removal, belongs to root. So when fu-go runs
+// as root and an installation is in another user's home directory, it hands
+// the removal to a copy of itself started with sudo -u as that user, the same
+// way e hands failed items to an elevated copy. Windows has no sudo -u; there
+// the administrator removes the files.
+
+// sudoRun runs a sudo command line; tests replace it.
+var sudoRun = func(args []string) ([]byte, error) {
+	return commandCombinedOutput(args[0], args[1:]...)
+}
+
+// runsAsOwner reports whether install is removed as the user whose home it
+// is in rather than by this process.
+func runsAsOwner(install GoInstallation) bool {
+	return install.User != "" && currentPlatform.runsAsOtherUsers() && isElevated() && install.User != currentUsername()
+}
+
+// sudoUserCommand is the command line that runs exe with args as name.
+func sudoUserCommand(name, exe string, args []string) []string {
+	return append([]string{"sudo", "-n", "-H", "-u", name, "--", exe}, args...)
+}
+
+// removeAsOwner removes install as install.User through sudo -u, with the
+// same checks as any other removal.
+func removeAsOwner(install GoInstallation, allowCrossMounts bool) error {
+	account, err := user.Lookup(install.User)
+	if err != nil {
+		return fmt.Errorf("cannot remove %s as %s: %v", install.Path, install.User, err)
+	}
+	uid, _ := strconv.Atoi(account.Uid)
+	gid, _ := strconv.Atoi(account.Gid)
+	exe := selfExecutable()
+	if exe == "" {
+		return fmt.Errorf("cannot remove %s as %s: cannot locate the running fu-go binary", install.Path, install.User)
+	}
+
+	// The handover files live in a directory only the user and root can read
+	dir, err := os.MkdirTemp("", "fu-go-runas-")
+	if err != nil {
+		return fmt.Errorf("cannot remove %s as %s: %v", install.Path, install.User, err)
+	}
+	defer os.RemoveAll(dir)
+	itemsPath, resultsPath := filepath.Join(dir, "items.json"), filepath.Join(dir, "results.json")
+	owned := install
+	owned.User = ""
+	if err := writeElevatedItems(itemsPath, []elevatedItem{{Kind: PlanInstallation, Path: install.Path, Install: owned}}); err != nil {
+		return fmt.Errorf("cannot remove %s as %s: %v", install.Path, install.User, err)
+	}
+	for _, path := range []string{dir, itemsPath} {
+		if err := os.Chown(path, uid, gid); err != nil {
+			return fmt.Errorf("cannot remove %s as %s: %v", install.Path, install.User, err)
+		}
+	}
+
+	args := []string{"exec-elevated", itemsPath, resultsPath}
+	if allowCrossMounts {
+		args = append(args, "--allow-cross-mounts")
+	}
+	tracef("runas: removing %s as %s", install.Path, install.User)
+	if output, err := sudoRun(sudoUserCommand(install.User, exe, args)); err != nil {
+		return fmt.Errorf("removing %s as %s failed: %v: %s (%s must be able to run %s)", install.Path, install.User, err, strings.TrimSpace(string(output)), install.User, exe)
+	}
+	data, err := os.ReadFile(resultsPath)
+	if err != nil {
+		return fmt.Errorf("no result from removing %s as %s: %v", install.Path, install.User, err)
+	}
+	var results []elevatedResult
+	if err := json.Unmarshal(data, &results); err != nil || len(results) != 1 {
+		return fmt.Errorf("invalid result from removing %s as %s", install.Path, install.User)
+	}
+	if results[0].Err != "" {
+		err := fmt.Errorf("as %s: %s", install.User, results[0].Err)
+		if results[0].RolledBack {
+			return rolledBackError{err}
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRunsAsOwner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sudo -u is not available on Windows")
+	}
+	install := GoInstallation{Path: "/home/alice/sdk/go1.23.1", User: "alice"}
+	withPlatform(t, elevatedPlatform{platformBackend: currentPlatform})
+	if runsAsOwner(install) {
+		t.Errorf("Expected an unelevated fu-go to remove everything itself")
+	}
+	withPlatform(t, elevatedPlatform{platformBackend: currentPlatform, elevated: true})
+	if !runsAsOwner(install) || runsAsOwner(GoInstallation{Path: "/usr/local/go"}) {
+		t.Errorf("Expected only other users' installations to be removed as their owner")
+	}
+	if ops := (installationItem{install: install}).Operations(); !slices.Equal(ops, []string{"sudo -u alice rm -rf /home/alice/sdk/go1.23.1"}) {
+		t.Errorf("Expected the review to show the removal as alice, got %v", ops)
+	}
+	want := []string{"sudo", "-n", "-H", "-u", "alice", "--", "/usr/local/bin/fu-go", "exec-elevated", "a", "b"}
+	if got := sudoUserCommand("alice", "/usr/local/bin/fu-go", []string{"exec-elevated", "a", "b"}); !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestRemoveAsOwner(t *testing.T) {
+	skipInAuditBuild(t)
+	if runtime.GOOS == "windows" {
+		t.Skip("sudo -u is not available on Windows")
+	}
+	self := currentUsername()
+	if self == "" {
+		t.Skip("cannot look up the current user")
+	}
+	var ran []string
+	saved := sudoRun
+	sudoRun = func(args []string) ([]byte, error) {
+		ran = args
+		// Stand in for the copy sudo would start
+		i := slices.Index(args, "exec-elevated")
+		return nil, runElevatedItems(args[i+1], args[i+2], false)
+	}
+	t.Cleanup(func() { sudoRun = saved })
+
+	root := filepath.Join(t.TempDir(), "sdk", "go1.23.1")
+	os.MkdirAll(filepath.Join(root, "bin"), 0755)
+	os.WriteFile(filepath.Join(root, "bin", "go"), []byte("go"), 0755)
+	if err := removeAsOwner(GoInstallation{Path: root, User: self}, false); err != nil {
+		t.Fatalf("removeAsOwner returned error: %v", err)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", root, err)
+	}
+	if !slices.Contains(ran, self) {
+		t.Errorf("Expected sudo -u %s, got %v", self, ran)
+	}
+
+	sudoRun = func(args []string) ([]byte, error) {
+		return []byte("sudo: unable to execute /root/fu-go: Permission denied"), errors.New("exit status 1")
+	}
+	if err := removeAsOwner(GoInstallation{Path: t.TempDir(), User: self}, false); err == nil || !strings.Contains(err.Error(), "must be able to run") {
+		t.Errorf("Expected a failed sudo to be explained, got %v", err)
+	}
+	if err := removeAsOwner(GoInstallation{Path: t.TempDir(), User: "no-such-user-fugo"}, false); err == nil {
+		t.Errorf("Expected an unknown user to be refused")
+	}
+}
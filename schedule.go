@@ -0,0 +1,252 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// fu-go schedule installs a per-user job that runs `fu-go clean-cache`
+// periodically: a systemd user timer on Linux, a launchd agent on macOS and a
+// Task Scheduler task on Windows. The job only ever cleans caches; toolchain
+// removal always needs a person at the keyboard.
+
+const scheduleJobName = "fugo-clean-cache"
+
+var scheduleIntervals = map[string]struct {
+	systemd string        // OnCalendar
+	seconds int           // launchd StartInterval
+	schtask string        // schtasks /SC
+	period  time.Duration // fu-go service
+}{
+	"hourly": {"hourly", 3600, "HOURLY", time.Hour},
+	"daily":  {"daily", 86400, "DAILY", 24 * time.Hour},
+	"weekly": {"weekly", 604800, "WEEKLY", 7 * 24 * time.Hour},
+}
+
+// scheduleRun runs the scheduler's own tools; tests replace it.
+var scheduleRun = func(args ...string) error {
+	output, err := commandCombinedOutput(args[0], args[1:]...)
+	if err != nil {
+		return fmt.Errorf("%s failed: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func systemdUnits(exe, interval string) (service, timer string) {
+	service = fmt.Sprintf(`[Unit]
+Description=fu-go Go cache cleanup
+
+[Service]
+Type=oneshot
+ExecStart="%s" clean-cache
+`, exe)
+	timer = fmt.Sprintf(`[Unit]
+Description=Run fu-go Go cache cleanup %s
+
+[Timer]
+OnCalendar=%s
+Persistent=true
+RandomizedDelaySec=15m
+
+[Install]
+WantedBy=timers.target
+`, interval, scheduleIntervals[interval].systemd)
+	return service, timer
+}
+
+func launchdPlist(exe, interval string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>com.melkeydev.%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>%s</string>
+		<string>clean-cache</string>
+	</array>
+	<key>StartInterval</key>
+	<integer>%d</integer>
+</dict>
+</plist>
+`, scheduleJobName, xmlEscape(exe), scheduleIntervals[interval].seconds)
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// schtasksArgs registers the task; under a service account it reports to
+// the Event Log, as nobody sees its output.
+func schtasksArgs(exe, interval string, job windowsJob) []string {
+	if job.account == "" {
+		return []string{"schtasks", "/Create", "/F", "/SC", scheduleIntervals[interval].schtask, "/TN", scheduleJobName, "/TR", fmt.Sprintf(`"%s" clean-cache`, exe)}
+	}
+	return []string{"schtasks", "/Create", "/F", "/SC", scheduleIntervals[interval].schtask, "/TN", scheduleJobName, "/RU", job.account, "/TR", fmt.Sprintf(`"%s" clean-cache --event-log`, exe)}
+}
+
+// scheduleFiles are the files the job consists of on goos, keyed by path.
+func scheduleFiles(goos, exe, interval string, getenv func(string) string, home string) map[string]string {
+	switch goos {
+	case "darwin":
+		return map[string]string{
+			filepath.Join(home, "Library", "LaunchAgents", "com.melkeydev."+scheduleJobName+".plist"): launchdPlist(exe, interval),
+		}
+	case "windows":
+		return nil
+	}
+	configHome := getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	dir := filepath.Join(configHome, "systemd", "user")
+	service, timer := systemdUnits(exe, interval)
+	return map[string]string{
+		filepath.Join(dir, scheduleJobName+".service"): service,
+		filepath.Join(dir, scheduleJobName+".timer"):   timer,
+	}
+}
+
+// installSchedule writes the job files and registers the job. job only
+// applies on Windows.
+func installSchedule(goos, exe, interval string, job windowsJob, getenv func(string) string, home string) ([]string, error) {
+	if _, ok := scheduleIntervals[interval]; !ok {
+		return nil, fmt.Errorf("unknown interval %q (expected hourly, daily or weekly)", interval)
+	}
+	if goos != "linux" && goos != "darwin" && goos != "windows" {
+		return nil, fmt.Errorf("scheduling is not supported on %s; run fu-go clean-cache from cron instead", goos)
+	}
+	if goos != "windows" && (job.account != "" || job.service) {
+		return nil, fmt.Errorf("--account and --service are only available on Windows")
+	}
+	if goos == "windows" && (job.account != "" || job.service) {
+		if err := installEventSource(); err != nil {
+			return nil, err
+		}
+	}
+	if goos == "windows" && job.service {
+		return nil, installService(exe, interval, cmp.Or(job.account, defaultSvcAccount))
+	}
+	files := scheduleFiles(goos, exe, interval, getenv, home)
+	var written []string
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		content := files[path]
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return written, fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %v", path, err)
+		}
+		written = append(written, path)
+	}
+	switch goos {
+	case "linux":
+		if err := scheduleRun("systemctl", "--user", "daemon-reload"); err != nil {
+			return written, err
+		}
+		return written, scheduleRun("systemctl", "--user", "enable", "--now", scheduleJobName+".timer")
+	case "darwin":
+		return written, scheduleRun("launchctl", "load", "-w", written[0])
+	case "windows":
+		return written, scheduleRun(schtasksArgs(exe, interval, job)...)
+	}
+	return written, nil
+}
+
+// removeSchedule unregisters the job and deletes its files.
+func removeSchedule(goos string, getenv func(string) string, home string) error {
+	var err error
+	switch goos {
+	case "linux":
+		err = scheduleRun("systemctl", "--user", "disable", "--now", scheduleJobName+".timer")
+	case "darwin":
+		for path := range scheduleFiles(goos, "", "daily", getenv, home) {
+			err = scheduleRun("launchctl", "unload", "-w", path)
+		}
+	case "windows":
+		// Whichever of the task and the service is there
+		err = scheduleRun("schtasks", "/Delete", "/F", "/TN", scheduleJobName)
+		if serviceErr := removeService(); serviceErr == nil {
+			err = nil
+		} else if err == nil && !errors.Is(serviceErr, errNoService) {
+			err = serviceErr
+		}
+	default:
+		return fmt.Errorf("scheduling is not supported on %s", goos)
+	}
+	for path := range scheduleFiles(goos, "", "daily", getenv, home) {
+		os.Remove(path)
+	}
+	return err
+}
+
+func newScheduleCmd() *cobra.Command {
+	var interval string
+	var remove, status bool
+	var job windowsJob
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Run clean-cache periodically with systemd, launchd or Task Scheduler",
+		Long:  "schedule installs a per-user job that runs fu-go clean-cache, so build agents keep their Go caches under the\nlimits in the config. The job never removes toolchains.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if status {
+				current, err := queryServiceStatus()
+				if err != nil {
+					return err
+				}
+				writeServiceStatus(cmd.OutOrStdout(), current)
+				return nil
+			}
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return fmt.Errorf("failed to find home directory: %v", err)
+			}
+			if remove {
+				if err := removeSchedule(runtime.GOOS, os.Getenv, home); err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), "Removed the scheduled cache cleanup")
+				return nil
+			}
+			exe := selfExecutable()
+			if exe == "" {
+				return fmt.Errorf("cannot locate the running fu-go binary")
+			}
+			written, err := installSchedule(runtime.GOOS, exe, interval, job, os.Getenv, home)
+			for _, path := range written {
+				fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s\n", path)
+			}
+			if err != nil {
+				return err
+			}
+			switch {
+			case job.service:
+				fmt.Fprintf(cmd.OutOrStdout(), "Installed the %s service running %s as %s, logging to the Event Log\n", serviceName, interval, cmp.Or(job.account, defaultSvcAccount))
+			case job.account != "":
+				fmt.Fprintf(cmd.OutOrStdout(), "Scheduled %s clean-cache %s as %s, logging to the Event Log\n", exe, interval, job.account)
+			default:
+				fmt.Fprintf(cmd.OutOrStdout(), "Scheduled %s clean-cache %s\n", exe, interval)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&interval, "every", "daily", "how often to run: hourly, daily or weekly")
+	cmd.Flags().BoolVar(&remove, "remove", false, "remove the scheduled job")
+	cmd.Flags().StringVar(&job.account, "account", "", "Windows: run the job as this service account, e.g. \"NT AUTHORITY\\LocalService\"")
+	cmd.Flags().BoolVar(&job.service, "service", false, "Windows: install a service instead of a Task Scheduler task")
+	cmd.Flags().BoolVar(&status, "status", false, "Windows: show the service's last run")
+	cmd.MarkFlagsMutuallyExclusive("remove", "status")
+	return cmd
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func recordScheduleRuns(t *testing.T) *[]string {
+	t.Helper()
+	var runs []string
+	original := scheduleRun
+	scheduleRun = func(args ...string) error {
+		runs = append(runs, strings.Join(args, " "))
+		return nil
+	}
+	t.Cleanup(func() { scheduleRun = original })
+	return &runs
+}
+
+func TestInstallScheduleSystemd(t *testing.T) {
+	runs := recordScheduleRuns(t)
+	home := t.TempDir()
+	getenv := func(string) string { return "" }
+
+	written, err := installSchedule("linux", "/home/ci/go/bin/fu-go", "weekly", windowsJob{}, getenv, home)
+	if err != nil {
+		t.Fatalf("installSchedule returned error: %v", err)
+	}
+	dir := filepath.Join(home, ".config", "systemd", "user")
+	if len(written) != 2 || written[0] != filepath.Join(dir, "fugo-clean-cache.service") {
+		t.Fatalf("Unexpected files %v", written)
+	}
+	service, _ := os.ReadFile(written[0])
+	timer, _ := os.ReadFile(written[1])
+	if !strings.Contains(string(service), `ExecStart="/home/ci/go/bin/fu-go" clean-cache`) {
+		t.Errorf("Expected the service to run clean-cache only, got:\n%s", service)
+	}
+	if !strings.Contains(string(timer), "OnCalendar=weekly") {
+		t.Errorf("Expected a weekly timer, got:\n%s", timer)
+	}
+	if len(*runs) != 2 || (*runs)[1] != "systemctl --user enable --now fugo-clean-cache.timer" {
+		t.Errorf("Unexpected commands %v", *runs)
+	}
+
+	if err := removeSchedule("linux", getenv, home); err != nil {
+		t.Fatalf("removeSchedule returned error: %v", err)
+	}
+	if _, err := os.Stat(written[0]); !os.IsNotExist(err) {
+		t.Errorf("Expected the unit files to be removed")
+	}
+}
+
+func TestInstallScheduleOtherPlatforms(t *testing.T) {
+	runs := recordScheduleRuns(t)
+	home := t.TempDir()
+	getenv := func(string) string { return "" }
+
+	written, err := installSchedule("darwin", "/usr/local/bin/fu-go", "hourly", windowsJob{}, getenv, home)
+	if err != nil || len(written) != 1 {
+		t.Fatalf("installSchedule returned %v, %v", written, err)
+	}
+	plist, _ := os.ReadFile(written[0])
+	if !strings.Contains(string(plist), "<integer>3600</integer>") {
+		t.Errorf("Expected an hourly launchd agent, got:\n%s", plist)
+	}
+
+	if _, err := installSchedule("windows", `C:\tools\fu-go.exe`, "daily", windowsJob{}, getenv, home); err != nil {
+		t.Fatalf("installSchedule returned error: %v", err)
+	}
+	if last := (*runs)[len(*runs)-1]; !strings.HasPrefix(last, "schtasks /Create /F /SC DAILY /TN fugo-clean-cache") {
+		t.Errorf("Unexpected schtasks command %q", last)
+	}
+
+	if _, err := installSchedule("freebsd", "/usr/local/bin/fu-go", "daily", windowsJob{}, getenv, home); err == nil {
+		t.Errorf("Expected unsupported platforms to be refused")
+	}
+	if _, err := installSchedule("linux", "/usr/local/bin/fu-go", "fortnightly", windowsJob{}, getenv, home); err == nil {
+		t.Errorf("Expected an unknown interval to be refused")
+	}
+}
+
+func TestInstallScheduleServiceAccount(t *testing.T) {
+	runs := recordScheduleRuns(t)
+	home := t.TempDir()
+	getenv := func(string) string { return "" }
+	var registered []string
+	savedService, savedSource, savedRemove := installService, installEventSource, removeService
+	installService = func(exe, interval, account string) error {
+		registered = append(registered, strings.Join([]string{exe, interval, account}, " "))
+		return nil
+	}
+	installEventSource = func() error { return nil }
+	removeService = func() error { return errNoService }
+	t.Cleanup(func() { installService, installEventSource, removeService = savedService, savedSource, savedRemove })
+
+	job := windowsJob{account: `NT AUTHORITY\NetworkService`}
+	if _, err := installSchedule("windows", `C:\tools\fu-go.exe`, "daily", job, getenv, home); err != nil {
+		t.Fatalf("installSchedule returned error: %v", err)
+	}
+	want := `schtasks /Create /F /SC DAILY /TN fugo-clean-cache /RU NT AUTHORITY\NetworkService /TR "C:\tools\fu-go.exe" clean-cache --event-log`
+	if last := (*runs)[len(*runs)-1]; last != want {
+		t.Errorf("Expected %q, got %q", want, last)
+	}
+
+	if _, err := installSchedule("windows", `C:\tools\fu-go.exe`, "hourly", windowsJob{service: true}, getenv, home); err != nil {
+		t.Fatalf("installSchedule returned error: %v", err)
+	}
+	if len(registered) != 1 || registered[0] != `C:\tools\fu-go.exe hourly NT AUTHORITY\LocalService` {
+		t.Errorf("Expected a service running as LocalService, got %v", registered)
+	}
+	if err := removeSchedule("windows", getenv, home); err != nil {
+		t.Errorf("Expected removing without a service to succeed, got %v", err)
+	}
+
+	if _, err := installSchedule("linux", "/usr/local/bin/fu-go", "daily", job, getenv, home); err == nil {
+		t.Errorf("Expected a service account to be refused outside Windows")
+	}
+}
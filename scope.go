@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// The scope decides which installations fu-go deals with at all: the user's
+// own, under the home directory, the system-wide ones, or both. In user scope
+// the system detectors never run and system paths never show up, so someone
+// without admin rights sees only what they can remove instead of a list of
+// permission warnings.
+
+type installScope string
+
+const (
+	scopeUser   installScope = "user"
+	scopeSystem installScope = "system"
+	scopeAll    installScope = "all"
+)
+
+var scopeChoices = []string{string(scopeUser), string(scopeSystem), string(scopeAll)}
+
+func parseScope(s string) (installScope, error) {
+	switch installScope(s) {
+	case "", scopeAll:
+		return scopeAll, nil
+	case scopeUser, scopeSystem:
+		return installScope(s), nil
+	}
+	return "", fmt.Errorf("unknown scope %q (expected user, system or all)", s)
+}
+
+// covers reports whether a run in scope s deals with things in scope other.
+// Detectors that look in both places are covered by every scope.
+func (s installScope) covers(other installScope) bool {
+	return s == scopeAll || other == scopeAll || s == other
+}
+
+var activeScope atomic.Value // installScope
+
+// setScope applies --scope, or the scope config key, to the whole run.
+func setScope(scope installScope) {
+	activeScope.Store(scope)
+}
+
+func currentScope() installScope {
+	if scope, ok := activeScope.Load().(installScope); ok {
+		return scope
+	}
+	return scopeAll
+}
+
+// scopedDetector is implemented by detectors that only ever look in one
+// scope, so they need not run outside it. Plugins don't say, and are
+// filtered by what they report.
+type scopedDetector interface {
+	scope() installScope
+}
+
+func detectorScope(detector Detector) installScope {
+	if scoped, ok := detector.(scopedDetector); ok {
+		return scoped.scope()
+	}
+	return scopeAll
+}
+
+// pathScope is user for paths in the home directory and system for the rest.
+func pathScope(path, home string) installScope {
+	if home != "" && withinDir(filepath.Clean(path), filepath.Clean(home)) {
+		return scopeUser
+	}
+	return scopeSystem
+}
+
+// inScope reports whether path belongs to the current scope.
+func inScope(path string) bool {
+	home, _ := os.UserHomeDir()
+	return currentScope().covers(pathScope(path, home))
+}
+
+// scopeInstallations drops the installations outside the current scope;
+// home is where user installations live.
+func scopeInstallations(installations []GoInstallation, home string) []GoInstallation {
+	scope := currentScope()
+	kept := installations[:0]
+	for _, install := range installations {
+		if scope.covers(pathScope(install.Path, home)) {
+			kept = append(kept, install)
+		} else {
+			tracef("scope: skip %s: outside %s scope", install.Path, scope)
+		}
+	}
+	return kept
+}
+
+// scopeError is why path cannot be added in the current scope, or nil.
+func scopeError(path string) error {
+	if inScope(path) {
+		return nil
+	}
+	return fmt.Errorf("%s is outside the %s scope (see --scope)", path, currentScope())
+}
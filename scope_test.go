@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func withScope(t *testing.T, scope installScope) {
+	t.Helper()
+	previous := currentScope()
+	setScope(scope)
+	t.Cleanup(func() { setScope(previous) })
+}
+
+func TestParseScope(t *testing.T) {
+	for input, want := range map[string]installScope{"": scopeAll, "all": scopeAll, "user": scopeUser, "system": scopeSystem} {
+		if got, err := parseScope(input); err != nil || got != want {
+			t.Errorf("parseScope(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := parseScope("both"); err == nil {
+		t.Errorf("Expected an unknown scope to be refused")
+	}
+	if err := (Config{Scope: "mine"}).validate(); err == nil {
+		t.Errorf("Expected the scope config key to be validated")
+	}
+}
+
+func TestScopeInstallations(t *testing.T) {
+	home := "/home/gopher"
+	installations := []GoInstallation{{Path: "/usr/local/go"}, {Path: "/home/gopher/sdk/go1.23.1"}, {Path: "/home/gopherette/go"}}
+	for scope, want := range map[installScope][]string{
+		scopeAll:    {"/usr/local/go", "/home/gopher/sdk/go1.23.1", "/home/gopherette/go"},
+		scopeUser:   {"/home/gopher/sdk/go1.23.1"},
+		scopeSystem: {"/usr/local/go", "/home/gopherette/go"},
+	} {
+		withScope(t, scope)
+		var got []string
+		for _, install := range scopeInstallations(slices.Clone(installations), home) {
+			got = append(got, install.Path)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s scope kept %v, expected %v", scope, got, want)
+		}
+	}
+}
+
+func TestScopeFiltersDetectors(t *testing.T) {
+	withScope(t, scopeUser)
+	var names []string
+	for _, detector := range enabledDetectors(Config{}) {
+		names = append(names, detector.Name())
+	}
+	for _, system := range []string{"official", "package_manager", "brew", "snap"} {
+		if slices.Contains(names, system) {
+			t.Errorf("Expected %s not to run in user scope, got %v", system, names)
+		}
+	}
+	if !slices.Contains(names, "gvm") || !slices.Contains(names, "sdk") {
+		t.Errorf("Expected the version managers to run in user scope, got %v", names)
+	}
+
+	withScope(t, scopeSystem)
+	names = nil
+	for _, detector := range enabledDetectors(Config{}) {
+		names = append(names, detector.Name())
+	}
+	if !slices.Contains(names, "official") || !slices.Contains(names, "gvm") {
+		t.Errorf("Expected system scope to run every detector and filter by path, got %v", names)
+	}
+}
+
+func TestScopeFiltersPlan(t *testing.T) {
+	home := t.TempDir()
+	root := filepath.Join(home, "sdk", "go1.23.1")
+	os.MkdirAll(filepath.Join(root, "bin"), 0755)
+	os.MkdirAll(filepath.Join(home, "bin"), 0755)
+	os.Symlink(filepath.Join(root, "bin", "go"), filepath.Join(home, "bin", "go"))
+
+	withScope(t, scopeSystem)
+	if items := buildPlan([]GoInstallation{{Path: root}}, nil, home); len(items) != 1 {
+		t.Errorf("Expected links in the home directory to be left out of system scope, got %d items", len(items))
+	}
+	withScope(t, scopeUser)
+	if items := buildPlan([]GoInstallation{{Path: root}}, nil, home); len(items) != 2 {
+		t.Errorf("Expected the link in ~/bin in user scope, got %d items", len(items))
+	}
+
+	if _, err := inspectManualPath("/usr/local/go", nil, false); err == nil || !strings.Contains(err.Error(), "outside the user scope") {
+		t.Errorf("Expected a system path to be refused in user scope, got %v", err)
+	}
+}
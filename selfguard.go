@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fu-go is often installed with `go install`, so it can live inside GOPATH/bin
+// or a Go root the user is about to remove. Unix keeps a running binary's
+// inode alive, but Windows refuses to delete it and the removal fails half
+// way. The entry containing the binary is therefore removed last, and on
+// Windows the binary is first moved to the temp directory.
+
+// selfExecutable is the running binary with symlinks resolved, or "" if it
+// cannot be determined.
+func selfExecutable() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return exe
+}
+
+// pathWithin reports whether path is root or inside it.
+func pathWithin(path, root string) bool {
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// planContainingSelf returns the installation that contains exe.
+func planContainingSelf(exe string, installations []GoInstallation) (GoInstallation, bool) {
+	if exe == "" {
+		return GoInstallation{}, false
+	}
+	for _, install := range installations {
+		if pathWithin(exe, install.Path) {
+			return install, true
+		}
+	}
+	return GoInstallation{}, false
+}
+
+// deferSelfLast moves the installation containing exe to the end of the
+// plan, keeping the order of everything else.
+func deferSelfLast(exe string, installations []GoInstallation) []GoInstallation {
+	self, ok := planContainingSelf(exe, installations)
+	if !ok {
+		return installations
+	}
+	ordered := make([]GoInstallation, 0, len(installations))
+	for _, install := range installations {
+		if install.Path != self.Path {
+			ordered = append(ordered, install)
+		}
+	}
+	return append(ordered, self)
+}
+
+// relocateSelf moves the running binary into dir so the tree it came from can
+// be removed.
+func relocateSelf(exe, dir string) (string, error) {
+	target := filepath.Join(dir, fmt.Sprintf("fu-go-%d%s", os.Getpid(), filepath.Ext(exe)))
+	if err := moveExecutable(exe, target); err != nil {
+		return "", fmt.Errorf("failed to move %s out of the way: %v", exe, err)
+	}
+	return target, nil
+}
+
+// relocateSelfThen moves the binary to the temp directory before running
+// cmd. If that fails the removal still runs, with the entry already last.
+func relocateSelfThen(exe string, logger *Logger, cmd tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		target, err := relocateSelf(exe, os.TempDir())
+		if logger != nil {
+			if err != nil {
+				logger.Log("WARN", err.Error())
+			} else {
+				logger.Log("INFO", fmt.Sprintf("Moved the running fu-go binary to %s", target))
+			}
+		}
+		return cmd()
+	}
+}
+
+// selfRemovalWarning explains what will happen to the running binary, or
+// returns "" when the plan does not touch it.
+func (m model) selfRemovalWarning() string {
+	self, ok := planContainingSelf(m.selfExe, m.selectedInstalls())
+	if !ok {
+		return ""
+	}
+	how := "it is removed last"
+	if currentPlatform.locksRunningBinary() {
+		how = "it is moved to the temp directory first and its folder removed last"
+	}
+	return fmt.Sprintf("🪚 fu-go itself runs from %s, inside %s; %s", m.selfExe, self.Path, how)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPathWithin(t *testing.T) {
+	tests := []struct {
+		path, root string
+		expected   bool
+	}{
+		{"/home/gopher/go/bin/fu-go", "/home/gopher/go", true},
+		{"/home/gopher/go", "/home/gopher/go/", true},
+		{"/home/gopher/gopath/bin/fu-go", "/home/gopher/go", false},
+		{"/usr/local/bin/fu-go", "/usr/local/go", false},
+		{"/usr/local/go/../bin/fu-go", "/usr/local/go", false},
+	}
+	for _, tt := range tests {
+		if got := pathWithin(tt.path, tt.root); got != tt.expected {
+			t.Errorf("pathWithin(%s, %s) = %v, expected %v", tt.path, tt.root, got, tt.expected)
+		}
+	}
+}
+
+func TestDeferSelfLast(t *testing.T) {
+	installs := []GoInstallation{{Path: "/home/gopher/go"}, {Path: "/usr/local/go"}, {Path: "/opt/go"}}
+	ordered := deferSelfLast("/home/gopher/go/bin/fu-go", installs)
+	var paths []string
+	for _, install := range ordered {
+		paths = append(paths, install.Path)
+	}
+	if got := strings.Join(paths, " "); got != "/usr/local/go /opt/go /home/gopher/go" {
+		t.Errorf("deferSelfLast order = %s", got)
+	}
+	if unchanged := deferSelfLast("/usr/bin/fu-go", installs); unchanged[0].Path != "/home/gopher/go" {
+		t.Errorf("Expected the order to be kept when fu-go is outside the plan")
+	}
+	if _, ok := planContainingSelf("", installs); ok {
+		t.Errorf("Expected an unknown executable never to match")
+	}
+}
+
+func TestRelocateSelf(t *testing.T) {
+	skipInAuditBuild(t)
+	tempDir := t.TempDir()
+	exe := filepath.Join(tempDir, "go", "bin", "fu-go")
+	if err := os.MkdirAll(filepath.Dir(exe), 0755); err != nil {
+		t.Fatalf("Failed to create bin: %v", err)
+	}
+	if err := os.WriteFile(exe, []byte("binary"), 0755); err != nil {
+		t.Fatalf("Failed to write binary: %v", err)
+	}
+
+	target, err := relocateSelf(exe, tempDir)
+	if err != nil {
+		t.Fatalf("relocateSelf returned error: %v", err)
+	}
+	if _, err := os.Stat(exe); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be moved away", exe)
+	}
+	if data, err := os.ReadFile(target); err != nil || string(data) != "binary" {
+		t.Errorf("Expected the binary at %s, got %q, %v", target, data, err)
+	}
+}
+
+func TestSelfRemovalWarning(t *testing.T) {
+	m := newListTestModel()
+	m.selfExe = "/usr/local/go/bin/fu-go"
+	if warning := m.selfRemovalWarning(); !strings.Contains(warning, "inside /usr/local/go") {
+		t.Errorf("Expected a warning about /usr/local/go, got %q", warning)
+	}
+	m.selection = map[string]bool{"/usr/local/go": false}
+	if warning := m.selfRemovalWarning(); warning != "" {
+		t.Errorf("Expected no warning once the installation is deselected, got %q", warning)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// A tool whose job is mass deletion must not run code it cannot trace back to
+// its maintainers, so self-update only installs release binaries carrying an
+// Ed25519 signature from the release key. The key is compiled in by release
+// builds with
+//
+//	-ldflags "-X main.releaseSigningKey=<base64 public key>"
+//
+// and builds without one refuse to update at all. Each binary is published
+// next to <binary>.sig, which holds the release version on its first line and
+// the base64 signature of releaseSignedMessage on its second. The version is
+// signed too, so an old release cannot be served as the latest one, and only
+// a release newer than the running binary is installed. A build that does not
+// know its own version, such as one from a plain `go build`, takes any signed
+// release.
+
+var (
+	releaseSigningKey = ""
+	selfUpdateBaseURL = "https://github.com/melkeydev/fu-go/releases/latest/download/"
+
+	// runningVersion is the version of this binary, replaced in tests.
+	runningVersion = fugoVersion
+)
+
+// releaseAssetName is the name of the release binary for a platform.
+func releaseAssetName(goos, goarch string) string {
+	name := fmt.Sprintf("fu-go_%s_%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// releaseSignedMessage is what the release key signs for an asset. It names
+// the asset so a signed binary for one platform cannot be served as another,
+// and the version so an older release cannot be served as a newer one.
+func releaseSignedMessage(asset, version string, data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return []byte(fmt.Sprintf("fu-go release %s\nversion %s\nsha256 %s\n", asset, version, hex.EncodeToString(sum[:])))
+}
+
+// parseReleaseSignature splits a .sig file into the release version and the
+// signature.
+func parseReleaseSignature(asset, content string) (version, signature string, err error) {
+	fields := strings.Fields(content)
+	if len(fields) != 2 || !strings.HasPrefix(fields[0], "v") {
+		return "", "", fmt.Errorf("malformed signature file for %s", asset)
+	}
+	return fields[0], fields[1], nil
+}
+
+// newerRelease reports whether version is newer than current. Every release
+// is newer than a build that does not know its version.
+func newerRelease(version, current string) bool {
+	if !strings.HasPrefix(current, "v") {
+		return true
+	}
+	return compareSemVer(strings.TrimPrefix(version, "v"), strings.TrimPrefix(current, "v")) > 0
+}
+
+func parseReleaseKey(encoded string) (ed25519.PublicKey, error) {
+	if encoded == "" {
+		return nil, fmt.Errorf("this build has no release signing key, so self-update is disabled; reinstall fu-go to upgrade")
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid release signing key compiled into this build")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// verifyRelease checks the detached signature of a downloaded asset.
+func verifyRelease(key ed25519.PublicKey, asset, version string, data []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("malformed signature for %s: %v", asset, err)
+	}
+	if !ed25519.Verify(key, releaseSignedMessage(asset, version, data), sig) {
+		return fmt.Errorf("signature verification failed for %s; refusing to update", asset)
+	}
+	return nil
+}
+
+// fetchRelease downloads a URL into memory; release binaries are small.
+func fetchRelease(cfg Config, url string) ([]byte, error) {
+	client, err := newHTTPClient(cfg, 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %v", url, err)
+	}
+	return data, nil
+}
+
+// fetchSignedRelease downloads the release binary for a platform and returns
+// it and its version only if its signature verifies against the compiled-in
+// key and it is newer than the running binary.
+func fetchSignedRelease(cfg Config, goos, goarch string) ([]byte, string, error) {
+	key, err := parseReleaseKey(releaseSigningKey)
+	if err != nil {
+		return nil, "", err
+	}
+	asset := releaseAssetName(goos, goarch)
+	content, err := fetchRelease(cfg, selfUpdateBaseURL+asset+".sig")
+	if err != nil {
+		return nil, "", err
+	}
+	version, signature, err := parseReleaseSignature(asset, string(content))
+	if err != nil {
+		return nil, "", err
+	}
+	if current := runningVersion(); !newerRelease(version, current) {
+		return nil, "", fmt.Errorf("the latest release is %s and this is %s; nothing to update", version, current)
+	}
+	data, err := fetchRelease(cfg, selfUpdateBaseURL+asset)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := verifyRelease(key, asset, version, data, signature); err != nil {
+		return nil, "", err
+	}
+	return data, version, nil
+}
+
+// replaceExecutable swaps exe for data. The new binary is written next to exe
+// and renamed over it, so exe is never left half written. Windows cannot
+// replace a running binary, so the old one is first renamed to exe.old.
+func replaceExecutable(exe string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".fu-go-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to stage update: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stage update: %v", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return fmt.Errorf("failed to stage update: %v", err)
+	}
+	if currentPlatform.locksRunningBinary() {
+		old := exe + ".old"
+		os.Remove(old)
+		if err := moveExecutable(exe, old); err != nil {
+			return fmt.Errorf("failed to move %s aside: %v", exe, err)
+		}
+	}
+	if err := moveExecutable(tmp.Name(), exe); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", exe, err)
+	}
+	return nil
+}
+
+func newSelfUpdateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "self-update",
+		Short: "Replace this binary with the latest signed release",
+		Long:  "self-update downloads the latest fu-go release for this platform, verifies its Ed25519 signature against\nthe key compiled into this build and only then replaces the running binary. Unsigned or tampered\nreleases, and releases not newer than this binary, are refused.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if currentPolicy().DisableSelfUpdate {
+				return fmt.Errorf("self-update is disabled by the machine policy")
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			exe := selfExecutable()
+			if exe == "" {
+				return fmt.Errorf("cannot locate the running fu-go binary")
+			}
+			data, version, err := fetchSignedRelease(cfg, runtime.GOOS, runtime.GOARCH)
+			if err != nil {
+				return err
+			}
+			if err := replaceExecutable(exe, data); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Verified signature and updated %s to %s\n", exe, version)
+			return nil
+		},
+	}
+}
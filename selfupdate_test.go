@@ -0,0 +1,171 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeRelease serves a release binary and its signature for version, signed
+// with a fresh key that is installed as the release key for the test. The
+// running binary is v1.4.0.
+func fakeRelease(t *testing.T, version string, binary []byte, sign func(ed25519.PrivateKey, string) []byte) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	asset := releaseAssetName("linux", "amd64")
+	signature := base64.StdEncoding.EncodeToString(sign(private, asset))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/" + asset:
+			w.Write(binary)
+		case "/" + asset + ".sig":
+			w.Write([]byte(version + "\n" + signature + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	key, baseURL, running := releaseSigningKey, selfUpdateBaseURL, runningVersion
+	releaseSigningKey = base64.StdEncoding.EncodeToString(public)
+	selfUpdateBaseURL = server.URL + "/"
+	runningVersion = func() string { return "v1.4.0" }
+	t.Cleanup(func() { releaseSigningKey, selfUpdateBaseURL, runningVersion = key, baseURL, running })
+}
+
+func TestFetchSignedRelease(t *testing.T) {
+	binary := []byte("new fu-go")
+	testCases := []struct {
+		name    string
+		version string
+		sign    func(ed25519.PrivateKey, string) []byte
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			version: "v1.5.0",
+			sign: func(key ed25519.PrivateKey, asset string) []byte {
+				return ed25519.Sign(key, releaseSignedMessage(asset, "v1.5.0", binary))
+			},
+		},
+		{
+			name:    "tampered binary",
+			version: "v1.5.0",
+			sign: func(key ed25519.PrivateKey, asset string) []byte {
+				return ed25519.Sign(key, releaseSignedMessage(asset, "v1.5.0", []byte("old fu-go")))
+			},
+			wantErr: "signature verification failed",
+		},
+		{
+			name:    "other platform",
+			version: "v1.5.0",
+			sign: func(key ed25519.PrivateKey, asset string) []byte {
+				return ed25519.Sign(key, releaseSignedMessage(releaseAssetName("darwin", "arm64"), "v1.5.0", binary))
+			},
+			wantErr: "signature verification failed",
+		},
+		{
+			name:    "old release served as new",
+			version: "v1.5.0",
+			sign: func(key ed25519.PrivateKey, asset string) []byte {
+				return ed25519.Sign(key, releaseSignedMessage(asset, "v1.3.0", binary))
+			},
+			wantErr: "signature verification failed",
+		},
+		{
+			name:    "same version",
+			version: "v1.4.0",
+			sign: func(key ed25519.PrivateKey, asset string) []byte {
+				return ed25519.Sign(key, releaseSignedMessage(asset, "v1.4.0", binary))
+			},
+			wantErr: "nothing to update",
+		},
+		{
+			name:    "older version",
+			version: "v1.3.0",
+			sign: func(key ed25519.PrivateKey, asset string) []byte {
+				return ed25519.Sign(key, releaseSignedMessage(asset, "v1.3.0", binary))
+			},
+			wantErr: "nothing to update",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeRelease(t, tc.version, binary, tc.sign)
+			data, version, err := fetchSignedRelease(Config{}, "linux", "amd64")
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil || string(data) != string(binary) || version != tc.version {
+				t.Fatalf("Expected the release binary of %s, got %q of %s, %v", tc.version, data, version, err)
+			}
+		})
+	}
+}
+
+func TestFetchSignedReleaseWithoutKey(t *testing.T) {
+	key := releaseSigningKey
+	releaseSigningKey = ""
+	defer func() { releaseSigningKey = key }()
+	dials := countDials(t)
+
+	if _, _, err := fetchSignedRelease(Config{}, "linux", "amd64"); err == nil || !strings.Contains(err.Error(), "self-update is disabled") {
+		t.Errorf("Expected unsigned builds to refuse updates, got %v", err)
+	}
+	if n := dials.Load(); n != 0 {
+		t.Errorf("Expected no network connections, got %d", n)
+	}
+}
+
+func TestNewerRelease(t *testing.T) {
+	testCases := []struct {
+		version, current string
+		want             bool
+	}{
+		{"v1.5.0", "v1.4.0", true},
+		{"v1.10.0", "v1.9.3", true},
+		{"v1.4.0", "v1.4.0", false},
+		{"v1.4.0", "v1.4.1-0.20260101000000-abcdef123456", false},
+		{"v1.4.1", "v1.4.1-0.20260101000000-abcdef123456", true},
+		{"v1.0.0", "(devel)", true},
+	}
+	for _, tc := range testCases {
+		if got := newerRelease(tc.version, tc.current); got != tc.want {
+			t.Errorf("newerRelease(%q, %q) = %v, want %v", tc.version, tc.current, got, tc.want)
+		}
+	}
+}
+
+func TestReplaceExecutable(t *testing.T) {
+	skipInAuditBuild(t)
+	exe := filepath.Join(t.TempDir(), "fu-go")
+	if err := os.WriteFile(exe, []byte("old"), 0755); err != nil {
+		t.Fatalf("Failed to write binary: %v", err)
+	}
+	if err := replaceExecutable(exe, []byte("new")); err != nil {
+		t.Fatalf("replaceExecutable returned error: %v", err)
+	}
+	data, err := os.ReadFile(exe)
+	if err != nil || string(data) != "new" {
+		t.Errorf("Expected the new binary, got %q, %v", data, err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(exe))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".fu-go-update-") {
+			t.Errorf("Staging file %s left behind", entry.Name())
+		}
+	}
+}
@@ -0,0 +1,557 @@
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// fu-go serve lets other frontends drive detection, planning and removal
+// over a local socket, with JSON-RPC 2.0: one request or response per line.
+// It enforces what the TUI does: the machine policy, the blockers, and the
+// confirmation steps, so a client must echo a plan's confirmation token and
+// answer the final challenge before anything high-risk is removed. Engine
+// events arrive as "event" notifications in the --progress=json format.
+//
+// The socket is created readable only by its owner, and nothing is served
+// over the network.
+
+// JSON-RPC error codes; the negative ones are the spec's.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcRefused        = 1 // the policy or a blocker forbids it
+	rpcUnconfirmed    = 2 // a confirmation step failed
+	rpcFailed         = 3 // the operation ran and failed
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+func rpcErrorf(code int, format string, args ...any) *rpcError {
+	return &rpcError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// servedPlan is a plan handed to a client and not yet executed.
+type servedPlan struct {
+	items    []PlanItem
+	installs []GoInstallation
+	token    string   // what the TUI shows as the hash to type
+	full     bool     // whether the token and final challenge are required
+	owners   []string // other users owning files in the plan, to be acknowledged
+}
+
+// rpcServer holds what clients share: the last inventory and the plans made
+// from it. Executions run one at a time.
+type rpcServer struct {
+	cfg    Config
+	opts   runOptions
+	paths  fugoPaths
+	logger *Logger
+
+	mu        sync.Mutex
+	inventory []GoInstallation
+	plans     map[string]*servedPlan
+
+	executing sync.Mutex
+}
+
+func newRPCServer(cfg Config, opts runOptions, paths fugoPaths, logger *Logger) *rpcServer {
+	return &rpcServer{cfg: cfg, opts: opts, paths: paths, logger: logger, plans: make(map[string]*servedPlan)}
+}
+
+func (s *rpcServer) log(level, message string) {
+	if s.logger != nil {
+		s.logger.Log(level, message)
+	}
+}
+
+// serve answers every connection on l until it is closed, then hangs up on
+// the clients once their requests in progress are answered.
+func (s *rpcServer) serve(l net.Listener) error {
+	var mu sync.Mutex
+	var running sync.WaitGroup
+	open := make(map[net.Conn]bool)
+	defer func() {
+		mu.Lock()
+		for conn := range open {
+			if c, ok := conn.(interface{ CloseRead() error }); ok {
+				c.CloseRead()
+			} else {
+				conn.Close()
+			}
+		}
+		mu.Unlock()
+		running.Wait()
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to accept a connection: %v", err)
+		}
+		mu.Lock()
+		open[conn] = true
+		mu.Unlock()
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			s.serveConn(conn)
+			mu.Lock()
+			delete(open, conn)
+			mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+// serveConn answers the requests on one connection in order.
+func (s *rpcServer) serveConn(conn io.ReadWriter) {
+	var mu sync.Mutex
+	encoder := json.NewEncoder(conn)
+	send := func(v any) {
+		mu.Lock()
+		defer mu.Unlock()
+		encoder.Encode(v)
+	}
+	notify := func(method string, params any) {
+		send(rpcNotification{JSONRPC: "2.0", Method: method, Params: params})
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var req rpcRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			send(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: rpcErrorf(rpcParseError, "invalid JSON: %v", err)})
+			continue
+		}
+		if req.JSONRPC != "2.0" {
+			send(rpcResponse{JSONRPC: "2.0", ID: req.ID, Error: rpcErrorf(rpcInvalidRequest, `jsonrpc must be "2.0"`)})
+			continue
+		}
+		result, rpcErr := s.handle(req, notify)
+		if req.ID == nil {
+			continue // a notification wants no answer
+		}
+		send(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr})
+	}
+}
+
+// handle runs one request; notify sends notifications to its client.
+func (s *rpcServer) handle(req rpcRequest, notify func(string, any)) (any, *rpcError) {
+	switch req.Method {
+	case "detect":
+		return s.detect(notify), nil
+	case "plan":
+		var params struct {
+			Paths []string `json:"paths"`
+		}
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.plan(params.Paths)
+	case "execute":
+		var params executeParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.execute(params, notify)
+	}
+	return nil, rpcErrorf(rpcMethodNotFound, "unknown method %q (expected detect, plan or execute)", req.Method)
+}
+
+func decodeParams(raw json.RawMessage, params any) *rpcError {
+	if len(raw) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(params); err != nil {
+		return rpcErrorf(rpcInvalidParams, "invalid params: %v", err)
+	}
+	return nil
+}
+
+// forwardEvents sends every event on bus to the client until it is closed.
+func forwardEvents(bus *eventBus, notify func(string, any)) (wait func()) {
+	events := bus.subscribe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range events {
+			notify("event", newProgressRecord(e))
+		}
+	}()
+	return func() { <-done }
+}
+
+type detectResult struct {
+	Installations []GoInstallation `json:"installations"`
+}
+
+// detect takes a new inventory; plans made from the previous one are
+// dropped.
+func (s *rpcServer) detect(notify func(string, any)) detectResult {
+	var installations []GoInstallation
+	if s.opts.simulate {
+		installations = simulatedInventory()
+	} else {
+		bus := newEventBus()
+		wait := forwardEvents(bus, notify)
+		installations, _ = detectGoInstallationsWithResults(s.cfg, bus)
+		bus.close()
+		wait()
+	}
+	markBlockedInstallations(installations, s.cfg.AllowCrossMounts)
+	sortInventory(installations)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inventory = installations
+	clear(s.plans)
+	return detectResult{Installations: installations}
+}
+
+type planResultItem struct {
+	Kind       PlanItemKind `json:"kind"`
+	Target     string       `json:"target"`
+	Describe   string       `json:"describe"`
+	Size       int64        `json:"size"`
+	Risk       string       `json:"risk"`
+	Operations []string     `json:"operations"`
+}
+
+type planResult struct {
+	ID           string           `json:"id"`
+	Items        []planResultItem `json:"items"`
+	Size         int64            `json:"size"`
+	Risk         string           `json:"risk"`
+	Confirmation confirmationInfo `json:"confirmation"`
+}
+
+// confirmationInfo is what execute will ask for: always "CONFIRM" as
+// confirm, "ACKNOWLEDGE" as acknowledge when other users own files in the
+// plan, and for high-risk plans or when a challenge is configured, Token
+// echoed back as token and the answer to Challenge as challenge.
+type confirmationInfo struct {
+	Full      bool     `json:"full"`
+	Owners    []string `json:"owners,omitempty"`
+	Token     string   `json:"token,omitempty"`
+	Challenge string   `json:"challenge,omitempty"`
+}
+
+// plan builds the removal plan for paths, or for every verified, unblocked
+// installation, from the last inventory.
+func (s *rpcServer) plan(paths []string) (any, *rpcError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inventory == nil {
+		return nil, rpcErrorf(rpcInvalidRequest, "call detect first")
+	}
+	chosen, err := planInstallations(s.inventory, paths)
+	if err != nil {
+		return nil, rpcErrorf(rpcRefused, "%v", err)
+	}
+	if len(chosen) == 0 {
+		return nil, rpcErrorf(rpcRefused, "nothing to remove")
+	}
+	var items []PlanItem
+	if s.opts.simulate {
+		for _, install := range chosen {
+			items = append(items, installationPlanItem(install, ""))
+		}
+	} else {
+		home, _ := os.UserHomeDir()
+		var kept []GoInstallation
+		for _, install := range s.inventory {
+			if !slices.ContainsFunc(chosen, func(c GoInstallation) bool { return c.Path == install.Path }) {
+				kept = append(kept, install)
+			}
+		}
+		items = buildPlan(chosen, kept, home)
+	}
+
+	plan := &servedPlan{items: items, installs: chosen, token: generateSecurityHash(), owners: foreignOwners(chosen, currentUsername())}
+	plan.full = currentPolicy().FinalChallenge != nil || s.cfg.FinalChallenge.Type != "" || planRisk(items) == RiskHigh
+	id := generateSecurityHash()
+	s.plans[id] = plan
+
+	result := planResult{ID: id, Items: []planResultItem{}, Size: planSize(items), Risk: planRisk(items).String()}
+	for _, item := range items {
+		result.Items = append(result.Items, planResultItem{Kind: item.Kind(), Target: item.Target(), Describe: item.Describe(), Size: item.Size(), Risk: item.Risk().String(), Operations: item.Operations()})
+	}
+	result.Confirmation.Full = plan.full
+	result.Confirmation.Owners = plan.owners
+	if plan.full {
+		result.Confirmation.Token = plan.token
+		result.Confirmation.Challenge = s.cfg.finalChallenge().prompt()
+	}
+	return result, nil
+}
+
+type executeParams struct {
+	Plan        string `json:"plan"`
+	Confirm     string `json:"confirm"`
+	Acknowledge string `json:"acknowledge"`
+	Token       string `json:"token"`
+	Challenge   string `json:"challenge"`
+	DryRun      bool   `json:"dry_run"`
+}
+
+type executeResultItem struct {
+	Kind   PlanItemKind `json:"kind"`
+	Target string       `json:"target"`
+	Status string       `json:"status"`
+	Reason string       `json:"reason,omitempty"`
+	Freed  int64        `json:"freed"`
+}
+
+type executeResult struct {
+	DryRun     bool                `json:"dry_run"`
+	Results    []executeResultItem `json:"results"`
+	FreedBytes int64               `json:"freed_bytes"`
+}
+
+// confirm checks the steps the TUI would have asked for. Plans are used
+// once: a failed confirmation discards the plan, so answers cannot be
+// guessed.
+func (s *rpcServer) confirm(params executeParams) (*servedPlan, *rpcError) {
+	s.mu.Lock()
+	plan, ok := s.plans[params.Plan]
+	delete(s.plans, params.Plan)
+	s.mu.Unlock()
+	if !ok {
+		return nil, rpcErrorf(rpcInvalidParams, "unknown plan %q; plans can be executed once, and detect discards them", params.Plan)
+	}
+	if strings.ToUpper(params.Confirm) != "CONFIRM" {
+		return nil, rpcErrorf(rpcUnconfirmed, `confirm must be "CONFIRM"`)
+	}
+	if len(plan.owners) > 0 && strings.ToUpper(params.Acknowledge) != "ACKNOWLEDGE" {
+		return nil, rpcErrorf(rpcUnconfirmed, `the plan removes files owned by %s; acknowledge must be "ACKNOWLEDGE"`, strings.Join(plan.owners, ", "))
+	}
+	if !plan.full {
+		return plan, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(params.Token), []byte(plan.token)) != 1 {
+		return nil, rpcErrorf(rpcUnconfirmed, "wrong confirmation token")
+	}
+	if err := s.cfg.finalChallenge().verify(params.Challenge, time.Now()); err != nil {
+		return nil, rpcErrorf(rpcUnconfirmed, "final challenge failed: %v", err)
+	}
+	return plan, nil
+}
+
+// execute runs a confirmed plan, backing up the installations first when
+// the config asks for it. Dry runs and simulations only report.
+func (s *rpcServer) execute(params executeParams, notify func(string, any)) (any, *rpcError) {
+	plan, rpcErr := s.confirm(params)
+	if rpcErr != nil {
+		s.log("WARN", fmt.Sprintf("Refused to execute plan %s: %s", params.Plan, rpcErr.Message))
+		return nil, rpcErr
+	}
+	dryRun := params.DryRun || s.opts.simulate
+	if lock := liveModeLock(); lock != "" && !dryRun {
+		return nil, rpcErrorf(rpcRefused, "live removals are disabled: %s", lock)
+	}
+
+	s.executing.Lock()
+	defer s.executing.Unlock()
+	bus := newEventBus()
+	wait := forwardEvents(bus, notify)
+	defer wait()
+	defer bus.close()
+
+	var results []itemResult
+	if dryRun {
+		reason := "dry run"
+		if s.opts.simulate {
+			reason = "simulation"
+		}
+		progress := startItemProgress(bus, phaseRemove, plan.items)
+		for _, item := range plan.items {
+			result := itemResult{item: item, skipped: reason}
+			progress.finish(result)
+			results = append(results, result)
+		}
+	} else {
+		s.log("INFO", fmt.Sprintf("Executing plan %s for a client: %d item(s)", params.Plan, len(plan.items)))
+		taken, err := backupInstallations(s.cfg, plan.installs, s.paths.Backups)
+		taken.logTo(s.log)
+		if err != nil {
+			s.log("ERROR", err.Error())
+			return nil, rpcErrorf(rpcFailed, "%v", err)
+		}
+		results = executePlan(plan.items, planEnv{allowCrossMounts: s.cfg.AllowCrossMounts, backupDir: s.paths.Backups, journal: s.paths.Journal, events: bus, quarantined: taken.moved})
+	}
+
+	out := executeResult{DryRun: dryRun, Results: []executeResultItem{}}
+	for _, result := range results {
+		if !dryRun {
+			s.logResult(result)
+		}
+		out.Results = append(out.Results, executeResultItem{Kind: result.item.Kind(), Target: result.item.Target(), Status: result.status(), Reason: result.reason(), Freed: result.freed()})
+		out.FreedBytes += result.freed()
+	}
+	return out, nil
+}
+
+func (s *rpcServer) logResult(result itemResult) {
+	switch {
+	case result.skipped != "":
+		s.log("WARN", fmt.Sprintf("Skipped %s: %s", result.item.Describe(), result.skipped))
+	case result.err != nil:
+		s.log("ERROR", fmt.Sprintf("%s failed: %v", result.item.Describe(), result.err))
+	default:
+		s.log("SUCCESS", fmt.Sprintf("%s (%s)", result.item.Describe(), result.duration.Round(time.Millisecond)))
+	}
+}
+
+// listenLocal listens on a unix socket at path that only its owner can use.
+// A socket left behind by a server that is gone is replaced; a live one, or
+// anything that is not a socket, is not. The directory must not let other
+// users swap the socket for their own.
+func listenLocal(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %v", err)
+	}
+	if err := checkSocketDir(dir); err != nil {
+		return nil, err
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another fu-go serve is listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %v", err)
+		}
+	}
+	l, err := listenPrivate(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %v", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("failed to restrict %s: %v", path, err)
+	}
+	return l, nil
+}
+
+// checkSocketDir refuses a socket directory that belongs to another user, or
+// that others may write to without the sticky bit keeping them from
+// replacing files they do not own.
+func checkSocketDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to inspect socket directory: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	if uid, ok := fileOwnerID(info); ok && uid != strconv.Itoa(os.Getuid()) {
+		return fmt.Errorf("socket directory %s belongs to another user", dir)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0022 != 0 && info.Mode()&os.ModeSticky == 0 {
+		return fmt.Errorf("socket directory %s is writable by other users (mode %v)", dir, info.Mode().Perm())
+	}
+	return nil
+}
+
+func newServeCmd(opts *runOptions) *cobra.Command {
+	var socket string
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve detection, planning and removal to other frontends over a local socket",
+		Long:  "serve answers JSON-RPC 2.0 requests, one per line, on a unix socket only its owner can use: detect, plan and\nexecute. Execution needs the same confirmations as the TUI and obeys the machine policy. Stop it with Ctrl+C.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			paths, err := resolvePaths(cfg)
+			if err != nil {
+				return err
+			}
+			if socket == "" {
+				socket = paths.Socket
+			}
+			logger, err := newConfiguredLogger(*opts, paths.Logs)
+			if err != nil {
+				return err
+			}
+			defer logger.Close()
+
+			l, err := listenLocal(socket)
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				l.Close()
+			}()
+			logger.Log("INFO", "Serving on "+socket)
+			fmt.Fprintf(cmd.ErrOrStderr(), "Serving on %s\n", socket)
+			err = newRPCServer(cfg, *opts, paths, logger).serve(l)
+			logger.Log("INFO", "Stopped serving on "+socket)
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&socket, "socket", "", "unix socket to listen on (default serve.sock in the state directory)")
+	return cmd
+}
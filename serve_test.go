@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// rpcClient talks to a test server one request at a time and keeps the
+// notifications that arrive before each response.
+type rpcClient struct {
+	t       *testing.T
+	conn    net.Conn
+	scanner *bufio.Scanner
+	nextID  int
+	events  []progressRecord
+}
+
+func (c *rpcClient) call(method string, params any, result any) *rpcError {
+	c.t.Helper()
+	c.nextID++
+	if err := json.NewEncoder(c.conn).Encode(map[string]any{"jsonrpc": "2.0", "id": c.nextID, "method": method, "params": params}); err != nil {
+		c.t.Fatalf("Failed to send %s: %v", method, err)
+	}
+	for c.scanner.Scan() {
+		var msg struct {
+			Method string          `json:"method"`
+			Params progressRecord  `json:"params"`
+			ID     int             `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  *rpcError       `json:"error"`
+		}
+		if err := json.Unmarshal(c.scanner.Bytes(), &msg); err != nil {
+			c.t.Fatalf("Invalid line from the server %q: %v", c.scanner.Text(), err)
+		}
+		if msg.Method == "event" {
+			c.events = append(c.events, msg.Params)
+			continue
+		}
+		if msg.ID != c.nextID {
+			c.t.Fatalf("Expected the response to %d, got %s", c.nextID, c.scanner.Text())
+		}
+		if msg.Error == nil && result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatalf("Invalid %s result %s: %v", method, msg.Result, err)
+			}
+		}
+		return msg.Error
+	}
+	c.t.Fatalf("Connection closed waiting for %s: %v", method, c.scanner.Err())
+	return nil
+}
+
+// startTestServer serves s on a socket in a short temporary directory, as
+// socket paths are limited to about a hundred bytes.
+func startTestServer(t *testing.T, s *rpcServer) *rpcClient {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "fugo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "serve.sock")
+	l, err := listenLocal(socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	done := make(chan error)
+	go func() { done <- s.serve(l) }()
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() {
+		l.Close()
+		if err := <-done; err != nil {
+			t.Errorf("serve returned error: %v", err)
+		}
+		conn.Close()
+	})
+	if info, err := os.Stat(socket); err == nil && info.Mode().Perm()&0077 != 0 {
+		t.Errorf("Expected the socket to be private, got %v", info.Mode())
+	}
+	return &rpcClient{t: t, conn: conn, scanner: bufio.NewScanner(conn)}
+}
+
+func TestServeEnforcesConfirmation(t *testing.T) {
+	client := startTestServer(t, newRPCServer(Config{}, runOptions{simulate: true}, fugoPaths{}, nil))
+
+	if err := client.call("plan", nil, nil); err == nil || !strings.Contains(err.Message, "detect first") {
+		t.Errorf("Expected plan before detect to be refused, got %v", err)
+	}
+	var detected detectResult
+	if err := client.call("detect", nil, &detected); err != nil || len(detected.Installations) == 0 {
+		t.Fatalf("detect returned %v, %d installation(s)", err, len(detected.Installations))
+	}
+	path := detected.Installations[0].Path
+	var plan planResult
+	if err := client.call("plan", map[string]any{"paths": []string{path}}, &plan); err != nil {
+		t.Fatalf("plan returned error: %v", err)
+	}
+	if err := client.call("execute", map[string]any{"plan": plan.ID, "confirm": "yes"}, nil); err == nil || err.Code != rpcUnconfirmed {
+		t.Errorf("Expected a wrong confirm to be refused, got %v", err)
+	}
+	if err := client.call("execute", map[string]any{"plan": plan.ID, "confirm": "CONFIRM"}, nil); err == nil || !strings.Contains(err.Message, "unknown plan") {
+		t.Errorf("Expected a refused plan to be discarded, got %v", err)
+	}
+
+	if err := client.call("plan", map[string]any{"paths": []string{path}}, &plan); err != nil {
+		t.Fatalf("plan returned error: %v", err)
+	}
+	params := map[string]any{"plan": plan.ID, "confirm": "CONFIRM", "token": plan.Confirmation.Token, "challenge": "DESTROY"}
+	var result executeResult
+	if err := client.call("execute", params, &result); err != nil {
+		t.Fatalf("execute returned error: %v", err)
+	}
+	if !result.DryRun || len(result.Results) == 0 || result.Results[0].Reason != "simulation" {
+		t.Errorf("Expected a simulation to only report, got %+v", result)
+	}
+	if err := client.call("undo", nil, nil); err == nil || err.Code != rpcMethodNotFound {
+		t.Errorf("Expected an unknown method to be refused, got %v", err)
+	}
+}
+
+func TestServeExecutesConfirmedPlan(t *testing.T) {
+	skipInAuditBuild(t)
+	root := fakeGoRoot(t, "VERSION", "bin/go")
+	backups := t.TempDir()
+	s := newRPCServer(Config{}, runOptions{}, fugoPaths{Backups: backups}, nil)
+	s.inventory = []GoInstallation{{Path: root, Source: "manual", Verified: true, Size: 1}}
+	client := startTestServer(t, s)
+
+	var plan planResult
+	if err := client.call("plan", nil, &plan); err != nil {
+		t.Fatalf("plan returned error: %v", err)
+	}
+	if len(plan.Items) != 1 || plan.Items[0].Target != root {
+		t.Fatalf("Expected a plan removing %s, got %+v", root, plan.Items)
+	}
+	params := map[string]any{"plan": plan.ID, "confirm": "CONFIRM"}
+	if plan.Confirmation.Full {
+		params["token"], params["challenge"] = plan.Confirmation.Token, "DESTROY"
+	}
+	var result executeResult
+	if err := client.call("execute", params, &result); err != nil {
+		t.Fatalf("execute returned error: %v", err)
+	}
+	if result.DryRun || len(result.Results) != 1 || result.Results[0].Status != statusRemoved {
+		t.Errorf("Expected %s to be removed, got %+v", root, result)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be gone", root)
+	}
+	if entries, _ := os.ReadDir(backups); len(entries) == 0 {
+		t.Errorf("Expected a backup before the removal")
+	}
+	var kinds []string
+	for _, e := range client.events {
+		kinds = append(kinds, e.Event)
+	}
+	if strings.Join(kinds, ",") != "item_queued,item_done,progress" {
+		t.Errorf("Expected the removal's events as notifications, got %v", kinds)
+	}
+}
+
+func TestListenLocalRefusesLiveSockets(t *testing.T) {
+	dir, err := os.MkdirTemp("", "fugo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "serve.sock")
+	l, err := listenLocal(socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	if _, err := listenLocal(socket); err == nil || !strings.Contains(err.Error(), "another fu-go serve") {
+		t.Errorf("Expected a second server to be refused, got %v", err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	if l, err = listenLocal(socket); err != nil {
+		t.Errorf("Expected a stale socket to be replaced, got %v", err)
+	} else {
+		l.Close()
+	}
+
+	file := filepath.Join(dir, "file")
+	os.WriteFile(file, nil, 0644)
+	if _, err := listenLocal(file); err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Errorf("Expected a regular file to be left alone, got %v", err)
+	}
+}
+
+func TestServeRequiresAcknowledgement(t *testing.T) {
+	root := fakeGoRoot(t, "VERSION", "bin/go")
+	s := newRPCServer(Config{}, runOptions{}, fugoPaths{Backups: t.TempDir()}, nil)
+	s.inventory = []GoInstallation{{Path: root, Source: "manual", Verified: true, Size: 1, Owners: map[string]int{"someone-else": 3}}}
+	client := startTestServer(t, s)
+
+	var plan planResult
+	if err := client.call("plan", nil, &plan); err != nil {
+		t.Fatalf("plan returned error: %v", err)
+	}
+	if len(plan.Confirmation.Owners) != 1 || plan.Confirmation.Owners[0] != "someone-else" {
+		t.Fatalf("Expected the plan to name the other owner, got %+v", plan.Confirmation)
+	}
+	params := map[string]any{"plan": plan.ID, "confirm": "CONFIRM", "dry_run": true}
+	if plan.Confirmation.Full {
+		params["token"], params["challenge"] = plan.Confirmation.Token, "DESTROY"
+	}
+	if err := client.call("execute", params, nil); err == nil || err.Code != rpcUnconfirmed || !strings.Contains(err.Message, "someone-else") {
+		t.Errorf("Expected execute without acknowledge to be refused, got %v", err)
+	}
+
+	if err := client.call("plan", nil, &plan); err != nil {
+		t.Fatalf("plan returned error: %v", err)
+	}
+	params["plan"], params["acknowledge"] = plan.ID, "ACKNOWLEDGE"
+	if plan.Confirmation.Full {
+		params["token"] = plan.Confirmation.Token
+	}
+	if err := client.call("execute", params, nil); err != nil {
+		t.Errorf("Expected an acknowledged plan to run, got %v", err)
+	}
+}
+
+func TestListenLocalRefusesSharedDirectory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("directory modes are not enforced on Windows")
+	}
+	dir, err := os.MkdirTemp("", "fugo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chmod(dir, 0777); err != nil {
+		t.Fatalf("Failed to chmod: %v", err)
+	}
+	if _, err := listenLocal(filepath.Join(dir, "serve.sock")); err == nil || !strings.Contains(err.Error(), "writable by other users") {
+		t.Errorf("Expected a world-writable socket directory to be refused, got %v", err)
+	}
+}
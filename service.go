@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// On Windows the scheduled cache cleanup can run under a service account
+// instead of the current user: as a Task Scheduler task with --account, or as
+// a service with --service. Either way every run is written to the Event Log.
+// The service also keeps its last run, which the interactive fu-go reads over
+// a named pipe with `fu-go schedule --status`, since the service account's
+// files are not the user's to read.
+
+const (
+	serviceName       = "fugo-clean-cache"
+	eventSource       = "fu-go"
+	statusPipeName    = `\\.\pipe\fugo-clean-cache`
+	defaultSvcAccount = `NT AUTHORITY\LocalService`
+)
+
+// Event Log event IDs.
+const (
+	eventIDCleanup = 1
+	eventIDFailure = 2
+	eventIDService = 3
+)
+
+// errNoService is what removing the service returns when it is not
+// installed.
+var errNoService = errors.New("the service is not installed")
+
+// The service manager and the Event Log; tests replace them.
+var (
+	installService     = registerService
+	removeService      = unregisterService
+	installEventSource = registerEventSource
+)
+
+// systemLog is where a run under a service account reports: the Event Log
+// on Windows.
+type systemLog interface {
+	Info(id uint32, message string) error
+	Error(id uint32, message string) error
+	Close() error
+}
+
+// windowsJob is how the scheduled job runs on Windows.
+type windowsJob struct {
+	account string // service account, "" for the current user
+	service bool   // a service rather than a Task Scheduler task
+}
+
+// cleanupRun is one scheduled cleanup.
+type cleanupRun struct {
+	Started    time.Time     `json:"started"`
+	DurationMS int64         `json:"duration_ms"`
+	Report     *changeReport `json:"report"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// runCleanup cleans the caches as clean-cache does.
+func runCleanup(cfg Config) cleanupRun {
+	run := cleanupRun{Started: time.Now(), Report: newChangeReport(false, auditBuild)}
+	if err := runCacheCleanup(eventWriter{w: io.Discard}, cfg, false, run.Report); err != nil {
+		run.Error = err.Error()
+	}
+	run.DurationMS = time.Since(run.Started).Milliseconds()
+	return run
+}
+
+// summary is the run as one Event Log message.
+func (r cleanupRun) summary() string {
+	if r.Error != "" {
+		return "Go cache cleanup failed: " + r.Error
+	}
+	if !r.Report.Changed {
+		return "Go cache cleanup: every cache is within its limit"
+	}
+	return fmt.Sprintf("Go cache cleanup freed %s: %s", formatBytes(r.Report.FreedBytes), strings.Join(r.Report.Changes, ", "))
+}
+
+// logRun writes run to log.
+func logRun(log systemLog, run cleanupRun) {
+	if run.Error != "" {
+		log.Error(eventIDFailure, run.summary())
+		return
+	}
+	log.Info(eventIDCleanup, run.summary())
+}
+
+// serviceStatus is what the service answers on its pipe.
+type serviceStatus struct {
+	Interval string      `json:"interval"`
+	Since    time.Time   `json:"since"`
+	Runs     int         `json:"runs"`
+	LastRun  *cleanupRun `json:"last_run,omitempty"`
+	NextRun  time.Time   `json:"next_run"`
+}
+
+// serviceState is the status shared between the cleanup loop and the pipe.
+type serviceState struct {
+	mu     sync.Mutex
+	status serviceStatus
+}
+
+func (s *serviceState) record(run cleanupRun, next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Runs++
+	s.status.LastRun = &run
+	s.status.NextRun = next
+}
+
+func (s *serviceState) snapshot() serviceStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// cleanupLoop runs cleanup now and then every period until stop is closed,
+// logging and recording each run.
+func cleanupLoop(period time.Duration, cleanup func() cleanupRun, log systemLog, state *serviceState, stop <-chan struct{}) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		run := cleanup()
+		logRun(log, run)
+		state.record(run, time.Now().Add(period))
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// writeServiceStatus prints status for `fu-go schedule --status`.
+func writeServiceStatus(w io.Writer, status serviceStatus) {
+	fmt.Fprintf(w, "Service %s running %s since %s, %d run(s)\n", serviceName, status.Interval, status.Since.Format(time.RFC3339), status.Runs)
+	if run := status.LastRun; run != nil {
+		fmt.Fprintf(w, "Last run %s (%s): %s\n", run.Started.Format(time.RFC3339), (time.Duration(run.DurationMS) * time.Millisecond).Round(time.Millisecond), run.summary())
+	}
+	if !status.NextRun.IsZero() {
+		fmt.Fprintf(w, "Next run %s\n", status.NextRun.Format(time.RFC3339))
+	}
+}
+
+// readServiceStatus decodes what the pipe sent.
+func readServiceStatus(r io.Reader) (serviceStatus, error) {
+	var status serviceStatus
+	if err := json.NewDecoder(r).Decode(&status); err != nil {
+		return status, fmt.Errorf("invalid status from the service: %v", err)
+	}
+	return status, nil
+}
+
+func newServiceCmd() *cobra.Command {
+	var interval string
+	cmd := &cobra.Command{
+		Use:    "service",
+		Short:  "Run the scheduled cache cleanup as a Windows service",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			period, ok := scheduleIntervals[interval]
+			if !ok {
+				return fmt.Errorf("unknown interval %q (expected hourly, daily or weekly)", interval)
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			return runService(interval, period.period, func() cleanupRun { return runCleanup(cfg) })
+		},
+	}
+	cmd.Flags().StringVar(&interval, "every", "daily", "how often to run: hourly, daily or weekly")
+	return cmd
+}
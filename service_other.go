@@ -0,0 +1,32 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+func registerEventSource() error {
+	return fmt.Errorf("the Event Log is only available on Windows")
+}
+
+func openSystemLog() (systemLog, error) {
+	return nil, fmt.Errorf("the Event Log is only available on Windows")
+}
+
+func registerService(exe, interval, account string) error {
+	return fmt.Errorf("services are only available on Windows")
+}
+
+func unregisterService() error {
+	return errNoService
+}
+
+func queryServiceStatus() (serviceStatus, error) {
+	return serviceStatus{}, fmt.Errorf("--status reads the Windows service; elsewhere see the scheduler's own logs")
+}
+
+func runService(interval string, period time.Duration, cleanup func() cleanupRun) error {
+	return fmt.Errorf("fu-go service only runs on Windows; use fu-go schedule")
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingLog keeps what would have gone to the Event Log.
+type recordingLog struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *recordingLog) add(level string, id uint32, message string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, fmt.Sprintf("%s %d %s", level, id, message))
+	return nil
+}
+
+func (l *recordingLog) Info(id uint32, message string) error  { return l.add("info", id, message) }
+func (l *recordingLog) Error(id uint32, message string) error { return l.add("error", id, message) }
+func (l *recordingLog) Close() error                          { return nil }
+
+func TestCleanupLoopLogsAndRecordsRuns(t *testing.T) {
+	log := &recordingLog{}
+	state := &serviceState{status: serviceStatus{Interval: "hourly"}}
+	stop := make(chan struct{})
+	runs := 0
+	cleanup := func() cleanupRun {
+		runs++
+		run := cleanupRun{Started: time.Now(), Report: newChangeReport(false, false)}
+		switch runs {
+		case 1:
+			run.Report.add("empty /cache/go-build", 2048)
+		case 2:
+			run.Error = "cache cleanup is disabled by the machine policy"
+		default:
+			close(stop)
+		}
+		return run
+	}
+	cleanupLoop(time.Millisecond, cleanup, log, state, stop)
+
+	want := []string{
+		"info 1 Go cache cleanup freed 2.0 KB: empty /cache/go-build",
+		"error 2 Go cache cleanup failed: cache cleanup is disabled by the machine policy",
+		"info 1 Go cache cleanup: every cache is within its limit",
+	}
+	if strings.Join(log.entries, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected Event Log entries:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(log.entries, "\n"))
+	}
+	if status := state.snapshot(); status.Runs != 3 || status.LastRun == nil || status.NextRun.IsZero() {
+		t.Errorf("Expected three recorded runs, got %+v", status)
+	}
+}
+
+func TestServiceStatusRoundTrip(t *testing.T) {
+	started := time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC)
+	report := newChangeReport(false, false)
+	report.add("empty /cache/go-build", 3<<30)
+	status := serviceStatus{Interval: "daily", Since: started.Add(-time.Hour), Runs: 2, LastRun: &cleanupRun{Started: started, DurationMS: 1500, Report: report}, NextRun: started.Add(24 * time.Hour)}
+
+	data, _ := json.Marshal(status)
+	got, err := readServiceStatus(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("readServiceStatus returned error: %v", err)
+	}
+	var out strings.Builder
+	writeServiceStatus(&out, got)
+	for _, line := range []string{
+		"Service fugo-clean-cache running daily since 2026-10-16T02:00:00Z, 2 run(s)",
+		"Last run 2026-10-16T03:00:00Z (1.5s): Go cache cleanup freed 3.0 GB: empty /cache/go-build",
+		"Next run 2026-10-17T03:00:00Z",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("Expected %q in:\n%s", line, out.String())
+		}
+	}
+	if _, err := readServiceStatus(strings.NewReader("not json")); err == nil {
+		t.Errorf("Expected garbage from the pipe to be an error")
+	}
+}
@@ -0,0 +1,193 @@
+//go:build windows
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// registerEventSource lets fu-go write to the Application log. It needs an
+// administrator, once; an existing source is kept.
+func registerEventSource() error {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\EventLog\Application\`+eventSource, registry.QUERY_VALUE)
+	if err == nil {
+		key.Close()
+		return nil
+	}
+	if err := eventlog.InstallAsEventCreate(eventSource, eventlog.Error|eventlog.Warning|eventlog.Info); err != nil {
+		return fmt.Errorf("failed to register the %s Event Log source (run as administrator): %v", eventSource, err)
+	}
+	return nil
+}
+
+func openSystemLog() (systemLog, error) {
+	log, err := eventlog.Open(eventSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the Event Log: %v", err)
+	}
+	return log, nil
+}
+
+// registerService installs fu-go as an automatically started service that
+// runs as account.
+func registerService(exe, interval, account string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager (run as administrator): %v", err)
+	}
+	defer m.Disconnect()
+	if s, err := m.OpenService(serviceName); err == nil {
+		s.Close()
+		return fmt.Errorf("the %s service is already installed; remove it with fu-go schedule --remove first", serviceName)
+	}
+	s, err := m.CreateService(serviceName, exe, mgr.Config{
+		DisplayName:      "fu-go Go cache cleanup",
+		Description:      "Empties the Go build and module caches once they pass their size limits.",
+		StartType:        mgr.StartAutomatic,
+		ServiceStartName: account,
+	}, "service", "--every", interval)
+	if err != nil {
+		return fmt.Errorf("failed to create the %s service: %v", serviceName, err)
+	}
+	defer s.Close()
+	if err := s.Start(); err != nil {
+		return fmt.Errorf("installed the %s service but could not start it: %v", serviceName, err)
+	}
+	return nil
+}
+
+// unregisterService stops and deletes the service, or returns errNoService.
+func unregisterService() error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager (run as administrator): %v", err)
+	}
+	defer m.Disconnect()
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+			return errNoService
+		}
+		return fmt.Errorf("failed to open the %s service: %v", serviceName, err)
+	}
+	defer s.Close()
+	s.Control(svc.Stop)
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("failed to delete the %s service: %v", serviceName, err)
+	}
+	return nil
+}
+
+// queryServiceStatus asks the running service for its last run.
+func queryServiceStatus() (serviceStatus, error) {
+	pipe, err := os.Open(statusPipeName)
+	if err != nil {
+		return serviceStatus{}, fmt.Errorf("the %s service is not running: %v", serviceName, err)
+	}
+	defer pipe.Close()
+	return readServiceStatus(pipe)
+}
+
+// runService runs under the service manager until it is told to stop.
+func runService(interval string, period time.Duration, cleanup func() cleanupRun) error {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return err
+	}
+	if !isService {
+		return fmt.Errorf("fu-go service is started by the service manager; install it with fu-go schedule --service")
+	}
+	log, err := openSystemLog()
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+	return svc.Run(serviceName, &cleanupService{interval: interval, period: period, cleanup: cleanup, log: log})
+}
+
+type cleanupService struct {
+	interval string
+	period   time.Duration
+	cleanup  func() cleanupRun
+	log      systemLog
+}
+
+func (s *cleanupService) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+	state := &serviceState{status: serviceStatus{Interval: s.interval, Since: time.Now()}}
+	stop := make(chan struct{})
+	pipeDone := make(chan struct{})
+	var stopping atomic.Bool
+	go func() {
+		defer close(pipeDone)
+		if err := serveStatusPipe(state, &stopping); err != nil {
+			s.log.Error(eventIDService, err.Error())
+		}
+	}()
+	loopDone := make(chan struct{})
+	go func() {
+		defer close(loopDone)
+		cleanupLoop(s.period, s.cleanup, s.log, state, stop)
+	}()
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	s.log.Info(eventIDService, fmt.Sprintf("Started; cleaning the Go caches %s", s.interval))
+
+	for request := range requests {
+		switch request.Cmd {
+		case svc.Interrogate:
+			changes <- request.CurrentStatus
+		case svc.Stop, svc.Shutdown:
+			changes <- svc.Status{State: svc.StopPending}
+			close(stop)
+			stopping.Store(true)
+			// Wake the pipe server blocked waiting for a client
+			if pipe, err := os.Open(statusPipeName); err == nil {
+				pipe.Close()
+			}
+			<-loopDone
+			<-pipeDone
+			s.log.Info(eventIDService, "Stopped")
+			return false, 0
+		}
+	}
+	return false, 0
+}
+
+// serveStatusPipe answers every client of the pipe with the current status
+// until stopping is set. The pipe only sends, and refuses remote clients.
+func serveStatusPipe(state *serviceState, stopping *atomic.Bool) error {
+	name, err := windows.UTF16PtrFromString(statusPipeName)
+	if err != nil {
+		return err
+	}
+	for !stopping.Load() {
+		pipe, err := windows.CreateNamedPipe(name, windows.PIPE_ACCESS_OUTBOUND, windows.PIPE_TYPE_BYTE|windows.PIPE_WAIT|windows.PIPE_REJECT_REMOTE_CLIENTS, windows.PIPE_UNLIMITED_INSTANCES, 64*1024, 0, 0, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %v", statusPipeName, err)
+		}
+		if err := windows.ConnectNamedPipe(pipe, nil); err != nil && !errors.Is(err, windows.ERROR_PIPE_CONNECTED) {
+			windows.CloseHandle(pipe)
+			continue
+		}
+		if !stopping.Load() {
+			data, _ := json.Marshal(state.snapshot())
+			var written uint32
+			windows.WriteFile(pipe, append(data, '\n'), &written, nil)
+			windows.FlushFileBuffers(pipe)
+		}
+		windows.DisconnectNamedPipe(pipe)
+		windows.CloseHandle(pipe)
+	}
+	return nil
+}
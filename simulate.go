@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Simulation mode (--simulate) runs the whole TUI against a made-up inventory
+// and only pretends to back up and remove it, for demos, training and
+// screencasts on machines where nothing should be destroyed.
+
+// simulationTimeScale stretches or shrinks every simulated delay; tests set it
+// to zero.
+var simulationTimeScale = 1.0
+
+const simulatedBytesPerSecond = 200 * 1024 * 1024
+
+// simulatedDelay is how long a pretend operation over bytes takes: a fixed
+// startup cost plus a realistic disk throughput.
+func simulatedDelay(base time.Duration, bytes int64) time.Duration {
+	d := base + time.Duration(float64(bytes)/simulatedBytesPerSecond*float64(time.Second))
+	return time.Duration(float64(d) * simulationTimeScale)
+}
+
+// simulatedInventory returns a varied set of installations covering the
+// common sources, with sizes typical of real toolchains.
+func simulatedInventory() []GoInstallation {
+	const mb = 1024 * 1024
+	now := time.Now()
+	fake := []struct {
+		path, version, source, manager, pkg string
+		size                                int64
+		age                                 time.Duration
+		onPath                              bool
+	}{
+		{"/usr/local/go", "go version go1.22.5 linux/amd64", "official", "", "", 256 * mb, 90 * 24 * time.Hour, true},
+		{"/home/gopher/.gvm/gos/go1.21.13", "go version go1.21.13 linux/amd64", "gvm", "", "", 231 * mb, 300 * 24 * time.Hour, false},
+		{"/home/gopher/.gvm/gos/go1.20.14", "go version go1.20.14 linux/amd64", "gvm", "", "", 218 * mb, 500 * 24 * time.Hour, false},
+		{"/home/gopher/sdk/go1.23.1", "go version go1.23.1 linux/amd64", "sdk", "", "", 262 * mb, 20 * 24 * time.Hour, false},
+		{"/home/gopher/.asdf/installs/golang/1.19.13/go", "go version go1.19.13 linux/amd64", "asdf", "", "", 204 * mb, 700 * 24 * time.Hour, false},
+		{"/snap/go/10660", "go version go1.22.4 linux/amd64", "snap", "snap", "go", 248 * mb, 120 * 24 * time.Hour, false},
+	}
+
+	installations := make([]GoInstallation, len(fake))
+	for i, f := range fake {
+		semver, goos, goarch := parseGoVersion(f.version)
+		installations[i] = GoInstallation{
+			Path:           f.path,
+			Version:        f.version,
+			Source:         f.source,
+			Size:           f.size,
+			Permissions:    "drwxr-xr-x",
+			Verified:       true,
+			PackageManager: f.manager,
+			Package:        f.pkg,
+			SemVer:         semver,
+			GOOS:           goos,
+			GOARCH:         goarch,
+			InstallDate:    now.Add(-f.age),
+			OnPath:         f.onPath,
+			Confidence:     ConfidenceHigh,
+			Detector:       f.source,
+			Evidence: append([]string{fmt.Sprintf("%s/bin/go version printed %q", f.path, f.version)},
+				goRootChecks{versionFile: true, binGo: true, toolDir: "pkg/tool/linux_amd64"}.evidence()...),
+		}
+		annotateSupport(&installations[i], now)
+	}
+	return scopeInstallations(installations, "/home/gopher")
+}
+
+func simulatedSnapshot(installations []GoInstallation) systemSnapshot {
+	snapshot := systemSnapshot{
+		Taken:         time.Now(),
+		Env:           map[string]string{"GOPATH": "/home/gopher/go", "PATH": "/usr/local/go/bin:/usr/bin:/bin"},
+		Installations: make(map[string]string),
+	}
+	for _, install := range installations {
+		snapshot.Installations[install.Path] = install.Version
+		if install.OnPath {
+			snapshot.GoOnPath = install.Path + "/bin/go"
+		}
+	}
+	return snapshot
+}
+
+func simulatedFindGoVersionsCmd(backupDir string) tea.Cmd {
+	return func() tea.Msg {
+		installations := simulatedInventory()
+		time.Sleep(simulatedDelay(1500*time.Millisecond, 0))
+
+		var versions []string
+		var preflight []preflightResult
+		for _, install := range installations {
+			versions = append(versions, install.Version)
+			preflight = append(preflight, preflightResult{Path: install.Path, Role: "installation"})
+		}
+		preflight = append(preflight, preflightResult{Path: backupDir, Role: "backup"})
+
+		return foundGoVersions{
+			versions:  versions,
+			path:      installations[0].Path,
+			installs:  installations,
+			preflight: preflight,
+			snapshot:  simulatedSnapshot(installations),
+		}
+	}
+}
+
+func simulatedBackupCmd(installations []GoInstallation, backupDir string) tea.Cmd {
+	return func() tea.Msg {
+		var total int64
+		for _, install := range installations {
+			total += install.Size
+		}
+		// Compression is slower than deletion
+		time.Sleep(simulatedDelay(time.Second, total*3))
+		return backupCompleted{success: true, path: backupDir}
+	}
+}
+
+func simulatedDeleteCmd(items []PlanItem) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(simulatedDelay(500*time.Millisecond, planSize(items)))
+		results := make([]itemResult, 0, len(items))
+		for _, item := range items {
+			results = append(results, itemResult{item: item})
+		}
+		return deleteGoCompleted{success: true, results: results}
+	}
+}
+
+func simulatedSnapshotCmd(remaining []GoInstallation) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(simulatedDelay(300*time.Millisecond, 0))
+		return snapshotTaken{snapshot: simulatedSnapshot(remaining)}
+	}
+}
+
+// The model dispatches every side effect through these so --simulate swaps
+// them all at once.
+
+func (m model) detectCmd() tea.Cmd {
+	if m.opts.simulate {
+		return simulatedFindGoVersionsCmd(m.backupPath)
+	}
+	bus := newEventBus()
+	return tea.Batch(findGoVersionsCmd(m.config, m.backupPath, bus), waitForEvent(bus.subscribe()))
+}
+
+func (m model) snapshotCmd() tea.Cmd {
+	if !m.opts.simulate {
+		return takeSnapshotCmd(m.config)
+	}
+	var remaining []GoInstallation
+	for _, install := range m.detectedInstalls {
+		if !m.isSelected(install) || install.Blocked != "" {
+			remaining = append(remaining, install)
+		}
+	}
+	return simulatedSnapshotCmd(remaining)
+}
+
+func simulationBanner() string {
+	return warningStyle.Render("🎭 SIMULATION - inventory and operations are fake; nothing on this machine is touched") + "\n\n"
+}
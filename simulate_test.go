@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSimulatedInventory(t *testing.T) {
+	installations := simulatedInventory()
+	sources := make(map[string]bool)
+	for _, install := range installations {
+		sources[install.Source] = true
+		if install.SemVer == "" || install.Size == 0 {
+			t.Errorf("Expected realistic version and size for %s, got %+v", install.Path, install)
+		}
+	}
+	if len(sources) < 4 {
+		t.Errorf("Expected several sources, got %v", sources)
+	}
+}
+
+func TestSimulatedRunTouchesNothing(t *testing.T) {
+	previous := simulationTimeScale
+	simulationTimeScale = 0
+	defer func() { simulationTimeScale = previous }()
+
+	m := model{
+		state:      "loading",
+		inventory:  newInstallList(),
+		opts:       runOptions{simulate: true},
+		backupPath: t.TempDir(),
+		paths:      fugoPaths{Reports: t.TempDir()},
+	}
+
+	updated, _ := m.Update(m.detectCmd()())
+	m = updated.(model)
+	if m.state != "summary" || len(m.detectedInstalls) != len(simulatedInventory()) {
+		t.Fatalf("Expected simulated inventory in the summary, got %s with %d installs", m.state, len(m.detectedInstalls))
+	}
+
+	m.dryRun = false
+	m.selection = map[string]bool{"/snap/go/10660": false}
+	m.reviewedPlan = m.plan()
+	for _, cmd := range []func() interface{}{
+		func() interface{} { return m.backupCmd()() },
+		func() interface{} { return m.deleteCmd()() },
+		func() interface{} { return m.snapshotCmd()() },
+	} {
+		updated, _ = m.Update(cmd())
+		m = updated.(model)
+	}
+
+	if m.state != "complete" || !m.deletionComplete {
+		t.Errorf("Expected a successful pretend removal, got state %s err %v", m.state, m.err)
+	}
+	diff := strings.Join(m.snapshotDiff, "\n")
+	if !strings.Contains(diff, "- /usr/local/go (") {
+		t.Errorf("Expected /usr/local/go to disappear from the snapshot, got:\n%s", diff)
+	}
+	if strings.Contains(diff, "/snap/go/10660") {
+		t.Errorf("Expected the deselected snap install to remain, got:\n%s", diff)
+	}
+	if report := buildReport(m); !report.Simulated || report.TotalBytes == 0 {
+		t.Errorf("Expected a simulated report with reclaimed bytes, got %+v", report)
+	}
+}
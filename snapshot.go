@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// snapshotEnvVars are the environment variables that influence which Go
+// toolchain a shell ends up using.
+var snapshotEnvVars = []string{"GOROOT", "GOPATH", "GOBIN", "GOTOOLCHAIN", "GOFLAGS", "PATH"}
+
+// systemSnapshot captures the Go-related state of the machine so the effect of
+// a run can be audited.
+type systemSnapshot struct {
+	Taken         time.Time         `json:"taken"`
+	GoOnPath      string            `json:"go_on_path"`
+	Env           map[string]string `json:"env"`
+	Installations map[string]string `json:"installations"` // path -> version
+}
+
+func takeSnapshot(installations []GoInstallation) systemSnapshot {
+	snapshot := systemSnapshot{
+		Taken:         time.Now(),
+		Env:           make(map[string]string),
+		Installations: make(map[string]string),
+	}
+	if goPath, err := exec.LookPath("go"); err == nil {
+		if resolved, err := filepath.EvalSymlinks(goPath); err == nil {
+			goPath = resolved
+		}
+		snapshot.GoOnPath = goPath
+	}
+	for _, name := range snapshotEnvVars {
+		if value, ok := os.LookupEnv(name); ok {
+			snapshot.Env[name] = value
+		}
+	}
+	for _, install := range installations {
+		snapshot.Installations[install.Path] = install.Version
+	}
+	return snapshot
+}
+
+// diffSnapshots describes what changed between two snapshots, one line per
+// change, in a stable order.
+func diffSnapshots(before, after systemSnapshot) []string {
+	var changes []string
+
+	if before.GoOnPath != after.GoOnPath {
+		changes = append(changes, fmt.Sprintf("~ go on PATH: %s -> %s", orNone(before.GoOnPath), orNone(after.GoOnPath)))
+	}
+	for _, name := range snapshotEnvVars {
+		if before.Env[name] != after.Env[name] {
+			changes = append(changes, fmt.Sprintf("~ %s: %s -> %s", name, orNone(before.Env[name]), orNone(after.Env[name])))
+		}
+	}
+
+	var removed, added []string
+	for path, version := range before.Installations {
+		if _, ok := after.Installations[path]; !ok {
+			removed = append(removed, fmt.Sprintf("- %s (%s)", path, version))
+		}
+	}
+	for path, version := range after.Installations {
+		if _, ok := before.Installations[path]; !ok {
+			added = append(added, fmt.Sprintf("+ %s (%s)", path, version))
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+	changes = append(changes, removed...)
+	return append(changes, added...)
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+type snapshotTaken struct {
+	snapshot systemSnapshot
+}
+
+// takeSnapshotCmd re-runs detection after execution to capture the "after"
+// state.
+func takeSnapshotCmd(cfg Config) tea.Cmd {
+	return func() tea.Msg {
+		return snapshotTaken{snapshot: takeSnapshot(detectGoInstallations(cfg))}
+	}
+}
+
+func renderSnapshotDiff(changes []string) string {
+	if len(changes) == 0 {
+		return ""
+	}
+	s := highlightStyle.Render("📊 Changes since the run started:") + "\n"
+	for _, change := range changes {
+		s += "   " + change + "\n"
+	}
+	return s + "\n"
+}
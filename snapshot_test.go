@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	before := systemSnapshot{
+		GoOnPath: "/usr/local/go/bin/go",
+		Env:      map[string]string{"GOROOT": "/usr/local/go", "PATH": "/usr/bin"},
+		Installations: map[string]string{
+			"/usr/local/go":           "go version go1.22.5 linux/amd64",
+			"/root/.gvm/gos/go1.21.0": "go version go1.21.0 linux/amd64",
+		},
+	}
+	after := systemSnapshot{
+		Env:           map[string]string{"PATH": "/usr/bin"},
+		Installations: map[string]string{"/opt/go": "go version go1.23.0 linux/amd64"},
+	}
+
+	expected := []string{
+		"~ go on PATH: /usr/local/go/bin/go -> (none)",
+		"~ GOROOT: /usr/local/go -> (none)",
+		"- /root/.gvm/gos/go1.21.0 (go version go1.21.0 linux/amd64)",
+		"- /usr/local/go (go version go1.22.5 linux/amd64)",
+		"+ /opt/go (go version go1.23.0 linux/amd64)",
+	}
+	if result := diffSnapshots(before, after); !reflect.DeepEqual(result, expected) {
+		t.Errorf("diffSnapshots =\n%v\nexpected\n%v", result, expected)
+	}
+
+	if result := diffSnapshots(before, before); len(result) != 0 {
+		t.Errorf("Expected no changes for identical snapshots, got %v", result)
+	}
+}
+
+func TestTakeSnapshot(t *testing.T) {
+	t.Setenv("GOROOT", "/custom/go")
+	snapshot := takeSnapshot([]GoInstallation{{Path: "/usr/local/go", Version: "go version go1.22.5"}})
+
+	if snapshot.Env["GOROOT"] != "/custom/go" {
+		t.Errorf("Expected GOROOT to be captured, got %q", snapshot.Env["GOROOT"])
+	}
+	if snapshot.Installations["/usr/local/go"] != "go version go1.22.5" {
+		t.Errorf("Expected installation to be captured, got %v", snapshot.Installations)
+	}
+}
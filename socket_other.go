@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "net"
+
+// listenPrivate binds a unix socket; there is no umask here, so the socket
+// directory check and the chmod afterwards are all there is.
+func listenPrivate(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package main
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// listenPrivate binds a unix socket under a umask that leaves it to its
+// owner from the moment it exists, not only once it is chmodded.
+func listenPrivate(path string) (net.Listener, error) {
+	old := unix.Umask(0177)
+	defer unix.Umask(old)
+	return net.Listen("unix", path)
+}
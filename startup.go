@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openOutputs creates the log file and backup directory. Failures are
+// collected in startupErrors rather than ignored, so the user can decide
+// whether to go on without an audit trail.
+func (m model) openOutputs() model {
+	m.startupErrors = nil
+
+	if m.logFile == nil {
+		logger, err := newConfiguredLogger(m.opts, m.paths.Logs)
+		if err != nil {
+			m.startupErrors = append(m.startupErrors, fmt.Sprintf("Logging disabled: %v", err))
+		}
+		m.logFile = logger
+	}
+
+	if err := os.MkdirAll(m.paths.Backups, 0755); err != nil {
+		m.startupErrors = append(m.startupErrors, fmt.Sprintf("Backups unavailable: failed to create backup directory: %v", err))
+	}
+	m.backupPath = m.paths.Backups
+	return m
+}
+
+// handleStartupWarningKey lets the user retry with another directory for logs
+// and backups, or continue with what could be opened.
+func (m model) handleStartupWarningKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "esc":
+		if m.logFile != nil {
+			m.logFile.Close()
+		}
+		return m, tea.Quit
+	case "enter":
+		dir := strings.TrimSpace(m.textInput.Value())
+		m.textInput.Reset()
+		if dir == "" {
+			return m.startDetection()
+		}
+		if !filepath.IsAbs(dir) {
+			m.startupErrors = []string{fmt.Sprintf("%s is not an absolute path", dir)}
+			return m, nil
+		}
+		m.paths.Logs = filepath.Join(dir, "logs")
+		m.paths.Backups = filepath.Join(dir, "backups")
+		m = m.openOutputs()
+		if len(m.startupErrors) == 0 {
+			return m.startDetection()
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+// startDetection leaves the startup screens and begins the normal run,
+// running the first-run wizard first if it has not been answered.
+func (m model) startDetection() (tea.Model, tea.Cmd) {
+	if m.logFile != nil {
+		for _, warning := range m.startupErrors {
+			m.logFile.Log("WARN", warning)
+		}
+	}
+	m.startupErrors = nil
+	if m.needsSetup {
+		m.state = "setup"
+		return m, nil
+	}
+	m.state = "loading"
+	m.textInput.Placeholder = "Type 'CONFIRM' to proceed"
+	m.textInput.CharLimit = 20
+	m.textInput.Width = 25
+	return m, tea.Batch(
+		m.spinner.Tick,
+		m.detectCmd(),
+	)
+}
+
+func (m model) renderStartupWarning() string {
+	var s string
+	s += warningStyle.Render("⚠️  fu-go could not set up its audit trail") + "\n\n"
+	for _, warning := range m.startupErrors {
+		s += "  • " + warning + "\n"
+	}
+	s += "\n"
+	if m.logFile == nil {
+		s += infoStyle.Render("Continuing means nothing this run does will be logged.") + "\n"
+	}
+	s += infoStyle.Render("Enter a directory for logs and backups, or press Enter to continue anyway.") + "\n\n"
+	s += m.textInput.View() + "\n\n"
+	s += "Press Esc to quit\n"
+	return s
+}
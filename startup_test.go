@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestOpenOutputsReportsFailures(t *testing.T) {
+	root := t.TempDir()
+	// A regular file where a directory is expected makes MkdirAll fail even as root
+	blocker := filepath.Join(root, "file")
+	if err := os.WriteFile(blocker, []byte("x"), 0644); err != nil {
+		t.Fatalf("Failed to create blocker file: %v", err)
+	}
+
+	m := model{paths: fugoPaths{Logs: filepath.Join(blocker, "logs"), Backups: filepath.Join(blocker, "backups")}}
+	m = m.openOutputs()
+	if len(m.startupErrors) != 2 {
+		t.Fatalf("Expected 2 startup errors, got %v", m.startupErrors)
+	}
+	if m.logFile != nil {
+		t.Error("Expected no logger when the log directory cannot be created")
+	}
+}
+
+func TestStartupWarningRetryWithAnotherDir(t *testing.T) {
+	root := t.TempDir()
+	blocker := filepath.Join(root, "file")
+	os.WriteFile(blocker, []byte("x"), 0644)
+
+	m := model{paths: fugoPaths{Logs: filepath.Join(blocker, "logs"), Backups: filepath.Join(blocker, "backups")}}
+	m = m.openOutputs()
+	m.state = "startup_warning"
+
+	scratch := filepath.Join(root, "scratch")
+	m.textInput.SetValue(scratch)
+	updated, cmd := m.handleStartupWarningKey(tea.KeyMsg{Type: tea.KeyEnter})
+	result := updated.(model)
+	defer func() {
+		if result.logFile != nil {
+			result.logFile.Close()
+		}
+	}()
+
+	if result.state != "loading" {
+		t.Errorf("Expected loading state after a successful retry, got %s", result.state)
+	}
+	if cmd == nil {
+		t.Error("Expected detection to start")
+	}
+	if result.logFile == nil || result.logFile.Dir() != filepath.Join(scratch, "logs") {
+		t.Errorf("Expected logger in %s", filepath.Join(scratch, "logs"))
+	}
+	if result.backupPath != filepath.Join(scratch, "backups") {
+		t.Errorf("backupPath = %s, expected %s", result.backupPath, filepath.Join(scratch, "backups"))
+	}
+}
+
+func TestStartupWarningContinueWithoutLogging(t *testing.T) {
+	m := model{state: "startup_warning", startupErrors: []string{"Logging disabled"}}
+	updated, _ := m.handleStartupWarningKey(tea.KeyMsg{Type: tea.KeyEnter})
+	result := updated.(model)
+	if result.state != "loading" {
+		t.Errorf("Expected loading state, got %s", result.state)
+	}
+	if result.logFile != nil {
+		t.Error("Expected to continue without a logger")
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !unix
+
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// deviceID is unavailable here; only the mount table checks apply.
+func deviceID(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// fileOwnerID is unavailable here, so no ownership summaries are shown.
+func fileOwnerID(info os.FileInfo) (string, bool) {
+	return "", false
+}
+
+// hardLinkKey is unavailable here, so hard links are backed up as copies.
+func hardLinkKey(info os.FileInfo) (fileKey, bool) {
+	return fileKey{}, false
+}
+
+// lchtimes leaves symlink times alone here.
+func lchtimes(path string, mtime time.Time) error {
+	return nil
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// fu-go state export bundles what decides how fu-go behaves on a machine,
+// the config, the machine policy, signed plans and the reports, into one
+// tar.gz that fu-go state import unpacks on another, so a team's laptops
+// clean up the same way. Backups, logs and the environment journal belong
+// to the machine and stay behind, as do detector plugins, which importing
+// would run.
+
+const (
+	stateManifestName = "manifest.json"
+	// stateFileLimit caps each file read from a bundle.
+	stateFileLimit = 64 << 20
+)
+
+// stateManifest describes a bundle.
+type stateManifest struct {
+	FugoVersion string    `json:"fugo_version"`
+	Created     time.Time `json:"created"`
+	Host        string    `json:"host,omitempty"`
+	GOOS        string    `json:"goos"`
+	Files       []string  `json:"files"`
+}
+
+// stateSources are the files an export reads.
+type stateSources struct {
+	config  string
+	policy  string
+	reports string
+	plans   []string
+}
+
+// exportState writes the bundle to out and returns the names of the files in
+// it. Sources that do not exist are left out.
+func exportState(out string, src stateSources, now time.Time) ([]string, error) {
+	files := make(map[string]string) // name in the bundle -> file
+	add := func(name, file string) {
+		if info, err := os.Stat(file); err == nil && info.Mode().IsRegular() {
+			files[name] = file
+		}
+	}
+	add("config/config.json", src.config)
+	add("policy/"+filepath.Base(src.policy), src.policy)
+	for _, plan := range src.plans {
+		if _, err := os.Stat(plan); err != nil {
+			return nil, fmt.Errorf("plan %s: %v", plan, err)
+		}
+		add("plans/"+filepath.Base(plan), plan)
+	}
+	if src.reports != "" {
+		filepath.WalkDir(src.reports, func(file string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if rel, err := filepath.Rel(src.reports, file); err == nil {
+				add("reports/"+filepath.ToSlash(rel), file)
+			}
+			return nil
+		})
+	}
+
+	host, _ := os.Hostname()
+	manifest := stateManifest{FugoVersion: fugoVersion(), Created: now, Host: host, GOOS: runtime.GOOS, Files: []string{}}
+	for name := range files {
+		manifest.Files = append(manifest.Files, name)
+	}
+	sort.Strings(manifest.Files)
+
+	f, err := os.Create(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", out, err)
+	}
+	zw := gzip.NewWriter(f)
+	tw := tar.NewWriter(zw)
+	data, _ := json.MarshalIndent(manifest, "", "  ")
+	err = writeStateFile(tw, stateManifestName, data, 0644, now)
+	for _, name := range manifest.Files {
+		if err != nil {
+			break
+		}
+		var content []byte
+		if content, err = os.ReadFile(files[name]); err == nil {
+			err = writeStateFile(tw, name, content, 0644, now)
+		}
+	}
+	for _, closer := range []io.Closer{tw, zw, f} {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		os.Remove(out)
+		return nil, fmt.Errorf("failed to write %s: %v", out, err)
+	}
+	return manifest.Files, nil
+}
+
+func writeStateFile(tw *tar.Writer, name string, data []byte, mode int64, now time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: mode, Size: int64(len(data)), ModTime: now, Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// readStateBundle reads every file of the bundle at path. Names outside the
+// bundle's directories, links and oversized files are refused.
+func readStateBundle(bundle string) (stateManifest, map[string][]byte, error) {
+	var manifest stateManifest
+	f, err := os.Open(bundle)
+	if err != nil {
+		return manifest, nil, fmt.Errorf("failed to open %s: %v", bundle, err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return manifest, nil, fmt.Errorf("%s is not a fu-go state bundle: %v", bundle, err)
+	}
+	reader := tar.NewReader(zr)
+	files := make(map[string][]byte)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return manifest, nil, fmt.Errorf("failed to read %s: %v", bundle, err)
+		}
+		name := path.Clean(header.Name)
+		dir, _, _ := strings.Cut(name, "/")
+		known := name == stateManifestName || dir == "config" || dir == "policy" || dir == "plans" || dir == "reports"
+		if !known || strings.HasPrefix(name, "../") || path.IsAbs(name) || header.Typeflag != tar.TypeReg {
+			return manifest, nil, fmt.Errorf("%s holds %q, which a state bundle does not", bundle, header.Name)
+		}
+		if header.Size > stateFileLimit {
+			return manifest, nil, fmt.Errorf("%s: %s is too large (%s)", bundle, name, formatBytes(header.Size))
+		}
+		data, err := io.ReadAll(io.LimitReader(reader, stateFileLimit))
+		if err != nil {
+			return manifest, nil, fmt.Errorf("failed to read %s: %v", bundle, err)
+		}
+		files[name] = data
+	}
+	data, ok := files[stateManifestName]
+	if !ok {
+		return manifest, nil, fmt.Errorf("%s has no %s; it is not a fu-go state bundle", bundle, stateManifestName)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, nil, fmt.Errorf("failed to parse the manifest of %s: %v", bundle, err)
+	}
+	delete(files, stateManifestName)
+	return manifest, files, nil
+}
+
+// stateTargets say where an import puts each part of a bundle. An empty
+// policy leaves the machine policy alone.
+type stateTargets struct {
+	config  string
+	policy  string
+	reports string
+	plans   string
+}
+
+// importState installs the files of the bundle at path into targets and
+// returns what it did. A config or policy that differs from the one in
+// place is only replaced with force, and the old one is kept next to it.
+// Reports and plans never replace files.
+func importState(bundle string, targets stateTargets, force bool, now time.Time) ([]string, error) {
+	manifest, files, err := readStateBundle(bundle)
+	if err != nil {
+		return nil, err
+	}
+	var done []string
+	if data, ok := files["config/config.json"]; ok {
+		// A config this fu-go cannot load must not replace a working one
+		cfg := defaultConfig()
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("the bundle's config.json does not parse: %v", err)
+		}
+		if err := cfg.validate(); err != nil {
+			return nil, fmt.Errorf("the bundle's config.json is invalid: %v", err)
+		}
+		line, err := installStateFile(targets.config, data, force, now)
+		if err != nil {
+			return done, err
+		}
+		done = append(done, line)
+		if cfg.LogDir != "" || cfg.BackupDir != "" {
+			done = append(done, "The imported config sets log_dir or backup_dir; check those paths exist on this machine")
+		}
+	}
+	for _, name := range policyNames {
+		data, ok := files["policy/"+name]
+		if !ok {
+			continue
+		}
+		if targets.policy == "" {
+			done = append(done, "Skipped the machine policy; rerun with --policy as an administrator to install it")
+			continue
+		}
+		if _, err := parsePolicy(data, name); err != nil {
+			return done, fmt.Errorf("the bundle's %s does not parse: %v", name, err)
+		}
+		// Installed under its own name, which must not leave two policies
+		target := filepath.Join(filepath.Dir(targets.policy), name)
+		if _, err := os.Stat(targets.policy); err == nil && target != targets.policy {
+			return done, fmt.Errorf("%s is the machine policy here; remove it to install the bundle's %s", targets.policy, name)
+		}
+		line, err := installStateFile(target, data, force, now)
+		if err != nil {
+			return done, err
+		}
+		done = append(done, line)
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		dir, rel, _ := strings.Cut(name, "/")
+		var target string
+		switch dir {
+		case "reports":
+			target = filepath.Join(targets.reports, filepath.FromSlash(rel))
+		case "plans":
+			target = filepath.Join(targets.plans, path.Base(rel))
+		default:
+			continue
+		}
+		if _, err := os.Stat(target); err == nil {
+			done = append(done, fmt.Sprintf("Kept %s, which already exists", target))
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return done, fmt.Errorf("failed to create %s: %v", filepath.Dir(target), err)
+		}
+		if err := os.WriteFile(target, files[name], 0644); err != nil {
+			return done, fmt.Errorf("failed to write %s: %v", target, err)
+		}
+		done = append(done, fmt.Sprintf("Wrote %s", target))
+	}
+	if manifest.GOOS != "" && manifest.GOOS != runtime.GOOS {
+		done = append(done, fmt.Sprintf("The bundle comes from %s; paths in it may not exist here", manifest.GOOS))
+	}
+	return done, nil
+}
+
+// installStateFile writes data to target. A different file already there
+// is refused without force, and kept as target.before-import-<time> with it.
+func installStateFile(target string, data []byte, force bool, now time.Time) (string, error) {
+	existing, err := os.ReadFile(target)
+	switch {
+	case err == nil && bytes.Equal(existing, data):
+		return fmt.Sprintf("%s is already the same", target), nil
+	case err == nil && !force:
+		return "", fmt.Errorf("%s differs from the bundle's; rerun with --force to replace it", target)
+	case err != nil && !os.IsNotExist(err):
+		return "", fmt.Errorf("failed to read %s: %v", target, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %v", filepath.Dir(target), err)
+	}
+	line := fmt.Sprintf("Wrote %s", target)
+	if err == nil {
+		kept := fmt.Sprintf("%s.before-import-%s", target, now.Format("20060102-150405"))
+		if err := os.WriteFile(kept, existing, 0644); err != nil {
+			return "", fmt.Errorf("failed to keep %s: %v", target, err)
+		}
+		line = fmt.Sprintf("Replaced %s; the old one is %s", target, kept)
+	}
+	if err := os.WriteFile(target, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %v", target, err)
+	}
+	return line, nil
+}
+
+func newStateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "state",
+		Short: "Move fu-go's config, policy, plans and reports between machines",
+		Long:  "state export bundles the config, the machine policy, signed plans and reports into one archive that\nstate import installs on another machine. Backups, logs and plugins stay behind.",
+	}
+	cmd.AddCommand(newStateExportCmd(), newStateImportCmd())
+	return cmd
+}
+
+func newStateExportCmd() *cobra.Command {
+	var out string
+	var plans []string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Bundle the config, policy, plans and reports into an archive",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			paths, err := resolvePaths(cfg)
+			if err != nil {
+				return err
+			}
+			config, err := configPath()
+			if err != nil {
+				return err
+			}
+			src := stateSources{config: config, policy: policyPath(runtime.GOOS, os.Getenv), reports: paths.Reports, plans: plans}
+			names, err := exportState(out, src, time.Now())
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", name)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s with %d file(s); import it with fu-go state import %s\n", out, len(names), out)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&out, "out", "fugo-state.tar.gz", "where to write the bundle")
+	cmd.Flags().StringArrayVar(&plans, "plan", nil, "signed plan from fu-go plan to include (repeatable)")
+	return cmd
+}
+
+func newStateImportCmd() *cobra.Command {
+	var force, policy bool
+	var plansDir string
+	cmd := &cobra.Command{
+		Use:   "import <bundle>",
+		Short: "Install the config, policy, plans and reports from a bundle",
+		Long:  "import installs the files of a bundle from fu-go state export. A config that differs from the one in\nplace is only replaced with --force, and the old one is kept next to it. The machine policy is only\ninstalled with --policy, which usually needs an administrator. Plans go to --plans-dir.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			paths, err := resolvePaths(cfg)
+			if err != nil {
+				return err
+			}
+			config, err := configPath()
+			if err != nil {
+				return err
+			}
+			targets := stateTargets{config: config, reports: paths.Reports, plans: plansDir}
+			if policy {
+				targets.policy = policyPath(runtime.GOOS, os.Getenv)
+			}
+			done, err := importState(args[0], targets, force, time.Now())
+			for _, line := range done {
+				fmt.Fprintln(cmd.OutOrStdout(), line)
+			}
+			return err
+		},
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "replace a config or policy that differs from the bundle's")
+	cmd.Flags().BoolVar(&policy, "policy", false, "install the bundle's machine policy too")
+	cmd.Flags().StringVar(&plansDir, "plans-dir", ".", "directory to put the bundle's plans in")
+	return cmd
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportImportState(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	from := t.TempDir()
+	src := stateSources{
+		config:  filepath.Join(from, "config.json"),
+		policy:  filepath.Join(from, "missing-policy.json"),
+		reports: filepath.Join(from, "reports"),
+		plans:   []string{filepath.Join(from, "fugo-plan.json")},
+	}
+	os.WriteFile(src.config, []byte(`{"theme": "mono"}`), 0644)
+	os.MkdirAll(filepath.Join(src.reports, "2026"), 0755)
+	os.WriteFile(filepath.Join(src.reports, "2026", "report.html"), []byte("<html>"), 0644)
+	os.WriteFile(src.plans[0], []byte(`{"plan": {}}`), 0644)
+
+	bundle := filepath.Join(t.TempDir(), "state.tar.gz")
+	names, err := exportState(bundle, src, now)
+	if err != nil {
+		t.Fatalf("exportState returned error: %v", err)
+	}
+	want := []string{"config/config.json", "plans/fugo-plan.json", "reports/2026/report.html"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Expected %v in the bundle, got %v", want, names)
+	}
+
+	to := t.TempDir()
+	targets := stateTargets{
+		config:  filepath.Join(to, "config", "config.json"),
+		reports: filepath.Join(to, "reports"),
+		plans:   filepath.Join(to, "plans"),
+	}
+	if _, err := importState(bundle, targets, false, now); err != nil {
+		t.Fatalf("importState returned error: %v", err)
+	}
+	for _, file := range []string{targets.config, filepath.Join(targets.reports, "2026", "report.html"), filepath.Join(targets.plans, "fugo-plan.json")} {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("Expected %s to be imported: %v", file, err)
+		}
+	}
+
+	// Importing the same bundle again changes nothing
+	done, err := importState(bundle, targets, false, now)
+	if err != nil || !strings.Contains(strings.Join(done, "\n"), "already the same") {
+		t.Errorf("Expected a second import to find everything in place, got %v, %v", done, err)
+	}
+}
+
+func TestImportStateConflicts(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	from := t.TempDir()
+	src := stateSources{config: filepath.Join(from, "config.json"), policy: filepath.Join(from, "policy.json")}
+	os.WriteFile(src.config, []byte(`{"theme": "mono"}`), 0644)
+	os.WriteFile(src.policy, []byte(`{"require_backup": true}`), 0644)
+	bundle := filepath.Join(t.TempDir(), "state.tar.gz")
+	if _, err := exportState(bundle, src, now); err != nil {
+		t.Fatalf("exportState returned error: %v", err)
+	}
+
+	to := t.TempDir()
+	targets := stateTargets{config: filepath.Join(to, "config.json"), reports: to, plans: to}
+	os.WriteFile(targets.config, []byte(`{}`), 0644)
+	if _, err := importState(bundle, targets, false, now); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("Expected a different config to be refused without --force, got %v", err)
+	}
+
+	done, err := importState(bundle, targets, true, now)
+	if err != nil {
+		t.Fatalf("importState returned error: %v", err)
+	}
+	if data, _ := os.ReadFile(targets.config); string(data) != `{"theme": "mono"}` {
+		t.Errorf("Expected the config to be replaced, got %s", data)
+	}
+	if data, _ := os.ReadFile(targets.config + ".before-import-20261016-093000"); string(data) != `{}` {
+		t.Errorf("Expected the old config to be kept, got %q", data)
+	}
+	if !strings.Contains(strings.Join(done, "\n"), "Skipped the machine policy") {
+		t.Errorf("Expected the policy to be skipped without a target, got %v", done)
+	}
+
+	targets.policy = filepath.Join(to, "policy.json")
+	if _, err := importState(bundle, targets, false, now); err != nil {
+		t.Fatalf("importState returned error: %v", err)
+	}
+	if policy, err := loadPolicyFile(targets.policy); err != nil || !policy.RequireBackup {
+		t.Errorf("Expected the policy to be installed, got %+v, %v", policy, err)
+	}
+}
+
+func TestImportStateRejectsBadBundles(t *testing.T) {
+	write := func(name string, typeflag byte) string {
+		bundle := filepath.Join(t.TempDir(), "bad.tar.gz")
+		f, _ := os.Create(bundle)
+		zw := gzip.NewWriter(f)
+		tw := tar.NewWriter(zw)
+		writeStateFile(tw, stateManifestName, []byte(`{"files": []}`), 0644, time.Now())
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Typeflag: typeflag, Linkname: "/etc/passwd"})
+		tw.Close()
+		zw.Close()
+		f.Close()
+		return bundle
+	}
+	to := t.TempDir()
+	targets := stateTargets{config: filepath.Join(to, "config.json"), reports: to, plans: to}
+	for _, bundle := range []string{
+		write("reports/../../escape", tar.TypeReg),
+		write("bin/fu-go", tar.TypeReg),
+		write("reports/link", tar.TypeSymlink),
+	} {
+		if _, err := importState(bundle, targets, true, time.Now()); err == nil {
+			t.Errorf("Expected %s to be refused", bundle)
+		}
+	}
+
+	bundle := filepath.Join(t.TempDir(), "config.tar.gz")
+	src := stateSources{config: filepath.Join(t.TempDir(), "config.json")}
+	os.WriteFile(src.config, []byte(`{"backup_policy": "sometimes"}`), 0644)
+	exportState(bundle, src, time.Now())
+	if _, err := importState(bundle, targets, true, time.Now()); err == nil {
+		t.Errorf("Expected an invalid config to be refused")
+	}
+	if _, err := os.Stat(targets.config); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be written, got %v", err)
+	}
+}
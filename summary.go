@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The summary sits between detection and the confirm screen: what was found
+// per source, how much space the plan frees, how risky it is and how long
+// the backup will take, each of which opens into a detail view. Users see
+// the shape of the plan before they face a confirmation prompt.
+
+// summarySections are the summary lines that open into a detail view, in
+// order; the line after them continues to the confirm screen.
+var summarySections = []string{"sources", "risk", "backup"}
+
+// sourceTotal is what one source contributes to the inventory.
+type sourceTotal struct {
+	source string
+	count  int
+	size   int64
+}
+
+// sourceTotals groups installations by source, largest first.
+func sourceTotals(installs []GoInstallation) []sourceTotal {
+	index := make(map[string]int)
+	var totals []sourceTotal
+	for _, install := range installs {
+		i, ok := index[install.Source]
+		if !ok {
+			i = len(totals)
+			index[install.Source] = i
+			totals = append(totals, sourceTotal{source: install.Source})
+		}
+		totals[i].count++
+		totals[i].size += install.Size
+	}
+	sort.SliceStable(totals, func(i, j int) bool { return totals[i].size > totals[j].size })
+	return totals
+}
+
+// planEstimate is the time the plan's backup and removal take.
+func (m model) planEstimate(items []PlanItem) (backup bool, backupTime, deleteTime time.Duration) {
+	backup = !m.dryRun && m.config.archiveBeforeRemoval()
+	for _, item := range items {
+		b, d := m.throughput.estimate(item, backup)
+		backupTime += b
+		deleteTime += d
+	}
+	return backup, backupTime, deleteTime
+}
+
+// handleSummaryKey moves between the sections with ↑/↓, opens one with
+// enter and closes it with esc. Enter on the last line, or c anywhere,
+// continues to the confirm screen.
+func (m model) handleSummaryKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q":
+		if m.logFile != nil {
+			m.logFile.Log("INFO", "User cancelled operation")
+			m.logFile.Close()
+		}
+		return m, tea.Quit
+	case "up", "k":
+		if m.summaryDetail == "" && m.summaryCursor > 0 {
+			m.summaryCursor--
+		}
+	case "down", "j":
+		if m.summaryDetail == "" && m.summaryCursor < len(summarySections) {
+			m.summaryCursor++
+		}
+	case "esc", "backspace":
+		m.summaryDetail = ""
+	case "c":
+		return m.continueToConfirm(), nil
+	case "enter":
+		if m.summaryDetail != "" {
+			m.summaryDetail = ""
+			return m, nil
+		}
+		if m.summaryCursor == len(summarySections) {
+			return m.continueToConfirm(), nil
+		}
+		m.summaryDetail = summarySections[m.summaryCursor]
+	}
+	return m, nil
+}
+
+func (m model) continueToConfirm() model {
+	m.state = "confirm"
+	m.summaryDetail = ""
+	m = m.checkOwnership()
+	if m.logFile != nil {
+		m.logFile.Log("INFO", "Summary reviewed, showing the confirm screen")
+	}
+	return m
+}
+
+func (m model) renderSummary() string {
+	if m.summaryDetail != "" {
+		return m.renderSummaryDetail() + "\n" + infoStyle.Render("esc to go back, c to continue, q to quit") + "\n"
+	}
+	items := m.plan()
+	s := highlightStyle.Render(fmt.Sprintf("📋 Found %d Go installation(s); the plan frees %s", len(m.detectedInstalls), formatBytes(planSize(items)))) + "\n\n"
+
+	lines := []string{
+		m.summarySourcesLine(),
+		m.summaryRiskLine(items),
+		m.summaryBackupLine(items),
+		"Continue to confirmation →",
+	}
+	for i, line := range lines {
+		if i == m.summaryCursor {
+			s += highlightStyle.Render("▸ "+line) + "\n"
+		} else {
+			s += "  " + line + "\n"
+		}
+	}
+	if owners := foreignOwners(m.selectedInstalls(), currentUsername()); len(owners) > 0 {
+		s += "\n" + warningStyle.Render(fmt.Sprintf("👤 The plan removes files owned by other users: %s", strings.Join(owners, ", "))) + "\n"
+	}
+	return s + "\n" + infoStyle.Render("↑/↓ to move, enter to open, c to continue, q to quit") + "\n"
+}
+
+func (m model) summarySourcesLine() string {
+	var parts []string
+	for _, total := range sourceTotals(m.detectedInstalls) {
+		parts = append(parts, fmt.Sprintf("%s %d", total.source, total.count))
+	}
+	return "🔧 By source: " + strings.Join(parts, " · ")
+}
+
+func (m model) summaryRiskLine(items []PlanItem) string {
+	var parts []string
+	for risk := RiskHigh; risk >= RiskLow; risk-- {
+		count := 0
+		for _, item := range items {
+			if item.Risk() == risk {
+				count++
+			}
+		}
+		if count > 0 {
+			parts = append(parts, risk.render(fmt.Sprintf("%s %d", risk.badge(), count)))
+		}
+	}
+	if len(parts) == 0 {
+		return "📊 Plan by risk: nothing selected"
+	}
+	return "📊 Plan by risk: " + strings.Join(parts, " · ")
+}
+
+func (m model) summaryBackupLine(items []PlanItem) string {
+	backup, backupTime, deleteTime := m.planEstimate(items)
+	if !backup {
+		return fmt.Sprintf("💾 No backup; removal takes %s", formatEstimate(deleteTime))
+	}
+	return fmt.Sprintf("💾 Backup to %s takes %s, removal %s", m.backupPath, formatEstimate(backupTime), formatEstimate(deleteTime))
+}
+
+func (m model) renderSummaryDetail() string {
+	items := m.plan()
+	var s string
+	switch m.summaryDetail {
+	case "sources":
+		s = highlightStyle.Render("🔧 Installations by source") + "\n\n"
+		for _, total := range sourceTotals(m.detectedInstalls) {
+			s += fmt.Sprintf("%s: %d installation(s), %s\n", total.source, total.count, formatBytes(total.size))
+			for _, install := range m.detectedInstalls {
+				if install.Source != total.source {
+					continue
+				}
+				status := "selected"
+				if !m.isSelected(install) {
+					status = "kept"
+				}
+				s += infoStyle.Render(fmt.Sprintf("   • %s %s, %s (%s)", item{install: install}.version(), install.Path, formatBytes(install.Size), status)) + "\n"
+			}
+		}
+	case "risk":
+		s = highlightStyle.Render("📊 Plan by risk") + "\n\n"
+		for risk := RiskHigh; risk >= RiskLow; risk-- {
+			var group []PlanItem
+			for _, item := range items {
+				if item.Risk() == risk {
+					group = append(group, item)
+				}
+			}
+			if len(group) == 0 {
+				continue
+			}
+			s += risk.render(fmt.Sprintf("%s: %d item(s), %s", risk.badge(), len(group), formatBytes(planSize(group)))) + "\n"
+			for _, item := range group {
+				s += infoStyle.Render(fmt.Sprintf("   %s %s", planItemIcons[item.Kind()], item.Describe())) + "\n"
+			}
+		}
+	case "backup":
+		backup, backupTime, deleteTime := m.planEstimate(items)
+		s = highlightStyle.Render("💾 Backup and time estimate") + "\n\n"
+		if backup {
+			s += fmt.Sprintf("Backup location: %s\n", m.backupPath)
+		} else if m.dryRun {
+			s += "Dry run: nothing is backed up or removed\n"
+		} else {
+			s += "No backup: backup_policy is never\n"
+		}
+		for _, item := range items {
+			b, d := m.throughput.estimate(item, backup)
+			s += infoStyle.Render(fmt.Sprintf("   %s %s: %s", planItemIcons[item.Kind()], item.Target(), formatEstimate(b+d))) + "\n"
+		}
+		s += fmt.Sprintf("\nTotal: %s", formatEstimate(backupTime+deleteTime))
+		if backup {
+			s += fmt.Sprintf(" (backup %s, removal %s)", formatEstimate(backupTime), formatEstimate(deleteTime))
+		}
+		s += "\n"
+	}
+	return s
+}
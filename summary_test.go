@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSourceTotals(t *testing.T) {
+	totals := sourceTotals([]GoInstallation{
+		{Path: "/root/.gvm/gos/go1.20", Source: "gvm", Size: 100},
+		{Path: "/usr/local/go", Source: "official", Size: 300},
+		{Path: "/root/.gvm/gos/go1.21", Source: "gvm", Size: 100},
+	})
+	if len(totals) != 2 {
+		t.Fatalf("Expected 2 sources, got %+v", totals)
+	}
+	if totals[0] != (sourceTotal{source: "official", count: 1, size: 300}) || totals[1] != (sourceTotal{source: "gvm", count: 2, size: 200}) {
+		t.Errorf("Expected official then gvm, largest first, got %+v", totals)
+	}
+}
+
+func TestSummaryNavigation(t *testing.T) {
+	m := newListTestModel()
+	m.state = "summary"
+	m.dryRun = true
+
+	view := m.renderSummary()
+	for _, want := range []string{"Found 2 Go installation(s)", "official 1", "gvm 1", "Plan by risk", "No backup"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected the summary to contain %q, got:\n%s", want, view)
+		}
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.summaryDetail != "sources" || !strings.Contains(m.renderSummary(), "/root/.gvm/gos/go1.21") {
+		t.Fatalf("Expected enter to open the sources detail, got %q", m.summaryDetail)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(model)
+	if m.summaryDetail != "" {
+		t.Fatalf("Expected esc to close the detail, got %q", m.summaryDetail)
+	}
+
+	for range summarySections {
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+		m = updated.(model)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = updated.(model)
+	if m.summaryCursor != len(summarySections) {
+		t.Fatalf("Expected the cursor to stop on Continue, got %d", m.summaryCursor)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.state != "confirm" {
+		t.Errorf("Expected enter on Continue to show the confirm screen, got %s", m.state)
+	}
+}
+
+func TestSummaryContinueFromDetail(t *testing.T) {
+	m := newListTestModel()
+	m.state = "summary"
+	m.summaryDetail = "risk"
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	m = updated.(model)
+	if m.state != "confirm" || m.summaryDetail != "" {
+		t.Errorf("Expected c to continue to the confirm screen, got %s with detail %q", m.state, m.summaryDetail)
+	}
+}
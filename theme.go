@@ -0,0 +1,21 @@
+package main
+
+// applyTheme switches the package styles to the named theme. "default" keeps
+// the colors defined in main.go; "mono" drops every color for terminals and
+// screen readers that do badly with them.
+func applyTheme(name string) {
+	styleGeneration.Add(1)
+	if name != "mono" {
+		return
+	}
+	logoGradient = []string{""}
+	bigTitleStyle = bigTitleStyle.UnsetForeground().UnsetBorderForeground()
+	subtitleStyle = subtitleStyle.UnsetForeground()
+	infoStyle = infoStyle.UnsetForeground().Faint(true)
+	warningStyle = warningStyle.UnsetForeground().Bold(true)
+	successStyle = successStyle.UnsetForeground()
+	confirmButtonStyle = confirmButtonStyle.UnsetForeground().UnsetBackground().Reverse(true)
+	cancelButtonStyle = cancelButtonStyle.UnsetForeground().UnsetBackground().Reverse(true)
+	highlightStyle = highlightStyle.UnsetForeground()
+	resetLogoCache()
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Backups are bound by bytes and removals by file count, so fu-go remembers
+// how fast each went on this machine and estimates the next plan from that.
+// Until a run has been measured it falls back to conservative defaults.
+
+const (
+	defaultBackupBytesPerSec = 50 << 20
+	defaultDeleteFilesPerSec = 5000
+)
+
+// throughput is stored as throughput.json in the state directory.
+type throughput struct {
+	BackupBytesPerSec float64 `json:"backup_bytes_per_sec"`
+	DeleteFilesPerSec float64 `json:"delete_files_per_sec"`
+	BackupSamples     int     `json:"backup_samples"`
+	DeleteSamples     int     `json:"delete_samples"`
+}
+
+func loadThroughput(path string) throughput {
+	var t throughput
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &t)
+	}
+	return t
+}
+
+func saveThroughput(path string, t throughput) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// blend folds a new measurement into the running rate, weighting recent runs
+// as much as all earlier ones so a faster disk shows up quickly.
+func blend(rate float64, samples int, measured float64) float64 {
+	if samples == 0 || rate <= 0 {
+		return measured
+	}
+	return (rate + measured) / 2
+}
+
+// recordBackup and recordDelete add a measured run. Runs too short to time
+// meaningfully are ignored.
+func (t throughput) recordBackup(bytes int64, elapsed time.Duration) throughput {
+	if bytes <= 0 || elapsed < 100*time.Millisecond {
+		return t
+	}
+	t.BackupBytesPerSec = blend(t.BackupBytesPerSec, t.BackupSamples, float64(bytes)/elapsed.Seconds())
+	t.BackupSamples++
+	return t
+}
+
+func (t throughput) recordDelete(files int64, elapsed time.Duration) throughput {
+	if files <= 0 || elapsed < 100*time.Millisecond {
+		return t
+	}
+	t.DeleteFilesPerSec = blend(t.DeleteFilesPerSec, t.DeleteSamples, float64(files)/elapsed.Seconds())
+	t.DeleteSamples++
+	return t
+}
+
+func (t throughput) backupRate() float64 {
+	if t.BackupSamples == 0 {
+		return defaultBackupBytesPerSec
+	}
+	return t.BackupBytesPerSec
+}
+
+func (t throughput) deleteRate() float64 {
+	if t.DeleteSamples == 0 {
+		return defaultDeleteFilesPerSec
+	}
+	return t.DeleteFilesPerSec
+}
+
+// estimate is how long backing up (if backup) and removing item should take.
+func (t throughput) estimate(item PlanItem, backup bool) (backupTime, deleteTime time.Duration) {
+	if backup && (item.Kind() == PlanInstallation || item.Kind() == PlanPackageUninstall || item.Kind() == PlanGopath) {
+		backupTime = time.Duration(float64(item.Size()) / t.backupRate() * float64(time.Second))
+	}
+	deleteTime = time.Duration(float64(item.Files()) / t.deleteRate() * float64(time.Second))
+	return backupTime, deleteTime
+}
+
+// formatEstimate rounds a duration the way people talk about waiting.
+func formatEstimate(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return "under a second"
+	case d < time.Minute:
+		return fmt.Sprintf("~%ds", int(d.Round(time.Second).Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("~%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("~%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// renderEstimate totals the time estimate for the selected plan.
+func (m model) renderEstimate() string {
+	items := m.plan()
+	if len(items) == 0 {
+		return ""
+	}
+	backup, backupTime, deleteTime := m.planEstimate(items)
+	s := fmt.Sprintf("⏱️  Estimated time: %s", formatEstimate(backupTime+deleteTime))
+	if backup {
+		s += fmt.Sprintf(" (backup %s, removal %s)", formatEstimate(backupTime), formatEstimate(deleteTime))
+	}
+	if samples := m.throughput.BackupSamples + m.throughput.DeleteSamples; samples == 0 {
+		s += ", a rough guess until fu-go has timed a run on this machine"
+	} else {
+		s += fmt.Sprintf(", from %d measured run(s)", samples)
+	}
+	return infoStyle.Render(s) + "\n\n"
+}
+
+// itemEstimate is the estimate for one installation, for the details panel.
+func (m model) itemEstimate(install GoInstallation) string {
+	b, d := m.throughput.estimate(installationPlanItem(install, ""), !m.dryRun && m.config.archiveBeforeRemoval())
+	return infoStyle.Render(fmt.Sprintf("     ⏱️  %d files, %s to remove", install.Files, formatEstimate(b+d))) + "\n"
+}
+
+// recordPhase stores how long the phase started at m.phaseStarted took.
+func (m model) recordPhase(backup bool) model {
+	if m.opts.simulate || m.phaseStarted.IsZero() {
+		return m
+	}
+	elapsed := time.Since(m.phaseStarted)
+	var bytes, files int64
+	for _, install := range m.selectedInstalls() {
+		bytes += install.Size
+		files += install.Files
+	}
+	if backup {
+		m.throughput = m.throughput.recordBackup(bytes, elapsed)
+	} else {
+		m.throughput = m.throughput.recordDelete(files, elapsed)
+	}
+	if err := saveThroughput(m.paths.Throughput, m.throughput); err != nil && m.logFile != nil {
+		m.logFile.Log("WARN", fmt.Sprintf("Could not save throughput measurements: %v", err))
+	}
+	return m
+}
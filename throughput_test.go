@@ -0,0 +1,75 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThroughputRecordAndPersist(t *testing.T) {
+	var tp throughput
+	if tp.backupRate() != defaultBackupBytesPerSec || tp.deleteRate() != defaultDeleteFilesPerSec {
+		t.Fatalf("Expected defaults before any run is measured")
+	}
+	tp = tp.recordBackup(100<<20, time.Second)
+	tp = tp.recordBackup(300<<20, time.Second)
+	if tp.BackupSamples != 2 || tp.backupRate() != 200<<20 {
+		t.Errorf("Expected the two runs to be blended, got %+v", tp)
+	}
+	tp = tp.recordDelete(1000, 10*time.Millisecond)
+	if tp.DeleteSamples != 0 {
+		t.Errorf("Expected a run too short to time to be ignored")
+	}
+	tp = tp.recordDelete(20000, 2*time.Second)
+
+	path := filepath.Join(t.TempDir(), "state", "throughput.json")
+	if err := saveThroughput(path, tp); err != nil {
+		t.Fatalf("saveThroughput returned error: %v", err)
+	}
+	if loaded := loadThroughput(path); loaded != tp {
+		t.Errorf("Round trip changed the measurements: %+v != %+v", loaded, tp)
+	}
+	if loaded := loadThroughput(filepath.Join(t.TempDir(), "missing.json")); loaded != (throughput{}) {
+		t.Errorf("Expected no measurements from a missing file, got %+v", loaded)
+	}
+}
+
+func TestThroughputEstimate(t *testing.T) {
+	tp := throughput{BackupBytesPerSec: 10 << 20, DeleteFilesPerSec: 1000, BackupSamples: 1, DeleteSamples: 1}
+	item := installationItem{install: GoInstallation{Path: "/usr/local/go", Size: 600 << 20, Files: 15000}}
+	backup, remove := tp.estimate(item, true)
+	if backup != time.Minute || remove != 15*time.Second {
+		t.Errorf("Expected 1m backup and 15s removal, got %v and %v", backup, remove)
+	}
+	if backup, _ := tp.estimate(item, false); backup != 0 {
+		t.Errorf("Expected no backup time without a backup, got %v", backup)
+	}
+	if backup, _ := tp.estimate(cacheItem{size: 1 << 30, files: 10}, true); backup != 0 {
+		t.Errorf("Expected caches never to be backed up, got %v", backup)
+	}
+}
+
+func TestFormatEstimate(t *testing.T) {
+	testCases := map[time.Duration]string{
+		200 * time.Millisecond: "under a second",
+		42 * time.Second:       "~42s",
+		130 * time.Second:      "~2m10s",
+		90 * time.Minute:       "~1h30m",
+	}
+	for d, want := range testCases {
+		if got := formatEstimate(d); got != want {
+			t.Errorf("formatEstimate(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestRenderEstimate(t *testing.T) {
+	m := model{
+		opts:             runOptions{simulate: true},
+		detectedInstalls: []GoInstallation{{Path: "/usr/local/go", Verified: true, Size: 500 << 20, Files: 5000}},
+	}
+	if got := m.renderEstimate(); !strings.Contains(got, "rough guess") || !strings.Contains(got, "backup ~10s") {
+		t.Errorf("Unexpected estimate: %q", got)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// With GOTOOLCHAIN the go command downloads whole toolchains into the module
+// cache as golang.org/toolchain@v0.0.1-go1.22.5.linux-amd64. Each is a
+// complete Go root, found wherever the user's GOMODCACHE points.
+func moduleCacheToolchainDirs() []string {
+	modCache := currentGoEnv().get("GOMODCACHE")
+	if modCache == "" {
+		return nil
+	}
+	return []string{filepath.Join(modCache, "golang.org")}
+}
+
+// toolchainPin is a go.mod or go.work toolchain directive, or a GOTOOLCHAIN
+// setting, naming a specific Go release.
+type toolchainPin struct {
+	File    string // go.mod/go.work path, or "GOTOOLCHAIN"
+	Version string // "1.22.5"
+}
+
+// parseToolchainDirective returns the version a toolchain directive pins, or
+// "" for lines that are not one.
+func parseToolchainDirective(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) != 2 || fields[0] != "toolchain" {
+		return ""
+	}
+	return toolchainVersion(fields[1])
+}
+
+// toolchainVersion turns "go1.22.5", "go1.22.5+auto" or
+// "go1.22.5-custom" into "1.22.5"; "default", "local" and "auto" pin nothing.
+func toolchainVersion(name string) string {
+	name, _, _ = strings.Cut(name, "+")
+	if !strings.HasPrefix(name, "go1") {
+		return ""
+	}
+	version, _, _ := strings.Cut(strings.TrimPrefix(name, "go"), "-")
+	return version
+}
+
+func parseToolchainPins(path string, data []byte) []toolchainPin {
+	var pins []toolchainPin
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		if version := parseToolchainDirective(line); version != "" {
+			pins = append(pins, toolchainPin{File: path, Version: version})
+		}
+	}
+	return pins
+}
+
+// skipScanDir is true for directories that never hold the user's own
+// projects and can be huge, such as the module cache under GOPATH/pkg.
+func skipScanDir(name string) bool {
+	switch name {
+	case "vendor", "node_modules", "testdata", "pkg":
+		return true
+	}
+	return strings.HasPrefix(name, ".") && name != "."
+}
+
+// scanToolchainPins looks for go.mod and go.work files up to maxDepth
+// directories below each root. Unreadable directories are skipped.
+func scanToolchainPins(roots []string, maxDepth int) []toolchainPin {
+	var pins []toolchainPin
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		if seen[root] {
+			continue
+		}
+		seen[root] = true
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				rel, _ := filepath.Rel(root, path)
+				if path != root && (skipScanDir(d.Name()) || strings.Count(rel, string(filepath.Separator)) >= maxDepth) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.Name() != "go.mod" && d.Name() != "go.work" {
+				return nil
+			}
+			if data, err := os.ReadFile(path); err == nil {
+				pins = append(pins, parseToolchainPins(path, data)...)
+			}
+			return nil
+		})
+	}
+	return pins
+}
+
+// findToolchainPins checks GOTOOLCHAIN and the projects in the working
+// directory and each GOPATH's src.
+func findToolchainPins(env goEnv) []toolchainPin {
+	var pins []toolchainPin
+	if version := toolchainVersion(env.get("GOTOOLCHAIN")); version != "" {
+		pins = append(pins, toolchainPin{File: fmt.Sprintf("GOTOOLCHAIN (%s)", env["GOTOOLCHAIN"].Source), Version: version})
+	}
+	var roots []string
+	if wd, err := os.Getwd(); err == nil {
+		roots = append(roots, wd)
+	}
+	for _, gopath := range env.gopaths() {
+		roots = append(roots, filepath.Join(gopath, "src"))
+	}
+	return append(pins, scanToolchainPins(roots, 4)...)
+}
+
+// pinsAffectedBy returns the pins naming a version that installations
+// removes.
+func pinsAffectedBy(pins []toolchainPin, installations []GoInstallation) []toolchainPin {
+	removed := make(map[string]bool)
+	for _, install := range installations {
+		if install.SemVer != "" {
+			removed[install.SemVer] = true
+		}
+	}
+	var affected []toolchainPin
+	for _, pin := range pins {
+		if removed[pin.Version] {
+			affected = append(affected, pin)
+		}
+	}
+	return affected
+}
+
+// renderToolchainPins warns about projects that pin a version in the plan.
+func (m model) renderToolchainPins() string {
+	affected := pinsAffectedBy(m.toolchainPins, m.selectedInstalls())
+	if len(affected) == 0 {
+		return ""
+	}
+	s := warningStyle.Render(fmt.Sprintf("📌 %d project setting(s) pin a Go version being removed:", len(affected))) + "\n"
+	for _, pin := range affected {
+		s += infoStyle.Render(fmt.Sprintf("   • go%s in %s", pin.Version, pin.File)) + "\n"
+	}
+	s += infoStyle.Render("   The go command will download it again the next time they build") + "\n\n"
+	return s
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestToolchainVersion(t *testing.T) {
+	tests := []struct {
+		name, expected string
+	}{
+		{"go1.22.5", "1.22.5"},
+		{"go1.22.5+auto", "1.22.5"},
+		{"go1.21.0-custom", "1.21.0"},
+		{"auto", ""},
+		{"local", ""},
+		{"default", ""},
+	}
+	for _, tt := range tests {
+		if got := toolchainVersion(tt.name); got != tt.expected {
+			t.Errorf("toolchainVersion(%s) = %q, expected %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestScanToolchainPins(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"app/go.mod":                "module app\n\ngo 1.22\n\ntoolchain go1.22.5 // pinned for CI\n",
+		"work/go.work":              "go 1.21\ntoolchain go1.21.13\nuse ./a\n",
+		"lib/go.mod":                "module lib\n\ngo 1.20\n",
+		"app/vendor/dep/go.mod":     "toolchain go1.19.0\n",
+		"a/b/c/d/e/deep/go.mod":     "toolchain go1.18.0\n",
+		".hidden/go.mod":            "toolchain go1.17.0\n",
+		"app/internal/tool/go.work": "toolchain default\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	pins := scanToolchainPins([]string{root, root}, 4)
+	found := make(map[string]string)
+	for _, pin := range pins {
+		rel, _ := filepath.Rel(root, pin.File)
+		found[filepath.ToSlash(rel)] = pin.Version
+	}
+	if len(found) != 2 || found["app/go.mod"] != "1.22.5" || found["work/go.work"] != "1.21.13" {
+		t.Errorf("Unexpected pins: %v", found)
+	}
+
+	affected := pinsAffectedBy(pins, []GoInstallation{{SemVer: "1.21.13"}, {SemVer: "1.23.0"}})
+	if len(affected) != 1 || !strings.HasSuffix(affected[0].File, "go.work") {
+		t.Errorf("Expected only the go.work pin to be affected, got %+v", affected)
+	}
+}
+
+func TestToolchainDetectorUsesGOMODCACHE(t *testing.T) {
+	modCache := t.TempDir()
+	goRoot := filepath.Join(modCache, "golang.org", "toolchain@v0.0.1-go1.21.0.linux-amd64")
+	if err := os.MkdirAll(goRoot, 0755); err != nil {
+		t.Fatalf("Failed to create toolchain: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(goRoot, "VERSION"), []byte("go1.21.0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write VERSION: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(modCache, "golang.org", "x"), 0755); err != nil {
+		t.Fatalf("Failed to create x: %v", err)
+	}
+	t.Setenv("GOMODCACHE", modCache)
+
+	var detector Detector
+	for _, d := range detectorRegistry {
+		if d.Name() == "gotoolchain" {
+			detector = d
+		}
+	}
+	if detector == nil {
+		t.Fatal("Expected a gotoolchain detector")
+	}
+	installations, err := detector.Detect(context.Background())
+	if err != nil {
+		t.Fatalf("Detect returned error: %v", err)
+	}
+	if len(installations) != 1 || installations[0].Path != goRoot || installations[0].SemVer != "1.21.0" {
+		t.Errorf("Expected the cached toolchain at %s, got %+v", goRoot, installations)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Detectors match on names and locations, so they sometimes report
+// directories that only look like Go. Every candidate is checked for the
+// layout a real Go root has; anything that fails a check is unverified and
+// stays out of the plan until the user opts it in.
+
+// goRootChecks records which parts of a Go root were found on disk.
+type goRootChecks struct {
+	versionFile bool   // VERSION starts with "go"
+	binGo       bool   // bin/go is an executable file
+	toolDir     string // pkg/tool/<goos>_<goarch>, empty when missing
+}
+
+func verifyGoRoot(path string) goRootChecks {
+	var checks goRootChecks
+	if data, err := os.ReadFile(filepath.Join(path, "VERSION")); err == nil {
+		checks.versionFile = strings.HasPrefix(strings.TrimSpace(string(data)), "go")
+	}
+
+	goExec := goExecutable(path)
+	if info, err := os.Stat(goExec); err == nil {
+		checks.binGo = isExecutable(goExec, info.Mode())
+	}
+
+	// Any platform's tools count, so cross-compiled roots copied from
+	// another machine are recognised too
+	if entries, err := os.ReadDir(filepath.Join(path, "pkg", "tool")); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() && strings.Contains(entry.Name(), "_") {
+				checks.toolDir = filepath.Join("pkg", "tool", entry.Name())
+				break
+			}
+		}
+	}
+	return checks
+}
+
+func (c goRootChecks) passed() int {
+	n := 0
+	for _, ok := range []bool{c.versionFile, c.binGo, c.toolDir != ""} {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// verified reports whether every check passed.
+func (c goRootChecks) verified() bool {
+	return c.passed() == 3
+}
+
+func (c goRootChecks) confidence() Confidence {
+	switch c.passed() {
+	case 3:
+		return ConfidenceHigh
+	case 0:
+		return ConfidenceLow
+	default:
+		return ConfidenceMedium
+	}
+}
+
+// evidence describes each check for the details pane and reports.
+func (c goRootChecks) evidence() []string {
+	var lines []string
+	if c.versionFile {
+		lines = append(lines, "✓ VERSION file present")
+	} else {
+		lines = append(lines, "✗ no VERSION file")
+	}
+	if c.binGo {
+		lines = append(lines, "✓ bin/go is executable")
+	} else {
+		lines = append(lines, "✗ no executable bin/go")
+	}
+	if c.toolDir != "" {
+		lines = append(lines, fmt.Sprintf("✓ %s present", filepath.ToSlash(c.toolDir)))
+	} else {
+		lines = append(lines, "✗ no pkg/tool/<goos>_<goarch> directory")
+	}
+	return lines
+}
+
+// needsReview lists the unverified installations, which the plan only
+// includes when the user selects them.
+func needsReview(installations []GoInstallation) []GoInstallation {
+	var review []GoInstallation
+	for _, install := range installations {
+		if !install.Verified {
+			review = append(review, install)
+		}
+	}
+	return review
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeGoRoot lays out the parts of a Go root named in parts.
+func fakeGoRoot(t *testing.T, parts ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, part := range parts {
+		switch part {
+		case "VERSION":
+			if err := os.WriteFile(filepath.Join(root, "VERSION"), []byte("go1.22.5\n"), 0644); err != nil {
+				t.Fatalf("Failed to write VERSION: %v", err)
+			}
+		case "bin/go":
+			name := "go"
+			if runtime.GOOS == "windows" {
+				name = "go.exe"
+			}
+			if err := os.MkdirAll(filepath.Join(root, "bin"), 0755); err != nil {
+				t.Fatalf("Failed to create bin: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(root, "bin", name), []byte("#!/bin/sh\n"), 0755); err != nil {
+				t.Fatalf("Failed to write bin/go: %v", err)
+			}
+		case "pkg/tool":
+			if err := os.MkdirAll(filepath.Join(root, "pkg", "tool", "linux_amd64"), 0755); err != nil {
+				t.Fatalf("Failed to create pkg/tool: %v", err)
+			}
+		}
+	}
+	return root
+}
+
+func TestVerifyGoRoot(t *testing.T) {
+	tests := []struct {
+		name       string
+		parts      []string
+		verified   bool
+		confidence Confidence
+	}{
+		{"complete", []string{"VERSION", "bin/go", "pkg/tool"}, true, ConfidenceHigh},
+		{"no tools", []string{"VERSION", "bin/go"}, false, ConfidenceMedium},
+		{"version only", []string{"VERSION"}, false, ConfidenceMedium},
+		{"empty", nil, false, ConfidenceLow},
+	}
+	for _, tt := range tests {
+		checks := verifyGoRoot(fakeGoRoot(t, tt.parts...))
+		if checks.verified() != tt.verified {
+			t.Errorf("verifyGoRoot(%s).verified() = %v, expected %v", tt.name, checks.verified(), tt.verified)
+		}
+		if checks.confidence() != tt.confidence {
+			t.Errorf("verifyGoRoot(%s).confidence() = %s, expected %s", tt.name, checks.confidence(), tt.confidence)
+		}
+	}
+}
+
+func TestVerifyGoRootNonExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("executable bits do not apply on Windows")
+	}
+	root := fakeGoRoot(t, "VERSION", "bin/go", "pkg/tool")
+	if err := os.Chmod(filepath.Join(root, "bin", "go"), 0644); err != nil {
+		t.Fatalf("Failed to chmod: %v", err)
+	}
+	checks := verifyGoRoot(root)
+	if checks.binGo || checks.verified() {
+		t.Errorf("Expected a non-executable bin/go to fail verification")
+	}
+	if evidence := strings.Join(checks.evidence(), "\n"); !strings.Contains(evidence, "✗ no executable bin/go") {
+		t.Errorf("Expected evidence to mention bin/go, got:\n%s", evidence)
+	}
+}
+
+func TestUnverifiedNeedsOptIn(t *testing.T) {
+	m := newListTestModel()
+	m.detectedInstalls = append(m.detectedInstalls, GoInstallation{Path: "/opt/looks-like-go", Version: "unknown version", Source: "other", Size: 50})
+	m.setInstallItems()
+
+	if len(m.selectedInstalls()) != 2 {
+		t.Errorf("Expected the unverified candidate to start outside the plan, got %v", m.selectedInstalls())
+	}
+	if row := m.inventory.Items()[2]; row.check() != "[ ] ❓" || row.version() != "unknown version" {
+		t.Errorf("Unexpected row %q %q", row.check(), row.version())
+	}
+	if review := m.renderNeedsReview(); !strings.Contains(review, "/opt/looks-like-go (low confidence, not in the plan)") {
+		t.Errorf("Expected the candidate in the needs review section, got:\n%s", review)
+	}
+
+	m.inventory.Select(2)
+	m.toggleSelected()
+	if len(m.selectedInstalls()) != 3 {
+		t.Errorf("Expected opting in to add the candidate, got %v", m.selectedInstalls())
+	}
+	if review := m.renderNeedsReview(); !strings.Contains(review, "opted in") {
+		t.Errorf("Expected the candidate to show as opted in, got:\n%s", review)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"cmp"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Sort keys for the installation inventory, cycled with tab on the confirm screen
+const (
+	SortBySource = iota
+	SortByVersion
+	SortBySize
+	SortByDate
+	SortByPath
+	SortByRisk
+)
+
+var sortKeyNames = []string{"source", "version", "size", "install date", "path", "risk"}
+
+// parseGoVersion extracts the semantic version and target platform from the
+// output of `go version`, e.g. "go version go1.22.5 linux/amd64". Fields that
+// cannot be determined are returned empty.
+func parseGoVersion(version string) (semVer, goos, goarch string) {
+	for _, field := range strings.Fields(version) {
+		switch {
+		case strings.HasPrefix(field, "go1") || strings.HasPrefix(field, "go2"):
+			semVer = strings.TrimPrefix(field, "go")
+		case strings.Count(field, "/") == 1 && semVer != "":
+			goos, goarch, _ = strings.Cut(field, "/")
+		}
+	}
+	return semVer, goos, goarch
+}
+
+// compareSemVer orders Go versions numerically ("1.9" < "1.10"). Pre-release
+// suffixes such as "rc1" sort before the release they precede.
+func compareSemVer(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		aNum, aSuffix := splitVersionPart(aParts, i)
+		bNum, bSuffix := splitVersionPart(bParts, i)
+		if aNum != bNum {
+			if aNum < bNum {
+				return -1
+			}
+			return 1
+		}
+		if aSuffix != bSuffix {
+			switch {
+			case aSuffix == "":
+				return 1
+			case bSuffix == "":
+				return -1
+			case aSuffix < bSuffix:
+				return -1
+			default:
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+func splitVersionPart(parts []string, i int) (int, string) {
+	if i >= len(parts) {
+		return 0, ""
+	}
+	part := parts[i]
+	end := 0
+	for end < len(part) && part[end] >= '0' && part[end] <= '9' {
+		end++
+	}
+	num, _ := strconv.Atoi(part[:end])
+	return num, part[end:]
+}
+
+// isOnPath reports whether the go command resolved from PATH lives in goRoot.
+func isOnPath(goRoot string) bool {
+	goExec := goExecutable(goRoot)
+	found, err := exec.LookPath("go")
+	if err != nil {
+		return false
+	}
+	return sameFile(found, goExec)
+}
+
+func sameFile(a, b string) bool {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(aInfo, bInfo)
+}
+
+// compareInstallations is the canonical order of an inventory: the running
+// user's installations, then each other user's, then by source, then newest
+// version first, then path. Detection hands installations over in this order
+// and every other sort falls back to it, so reports and exports come out the
+// same from run to run however the detectors interleave.
+func compareInstallations(a, b GoInstallation) int {
+	if c := strings.Compare(a.User, b.User); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.Source, b.Source); c != 0 {
+		return c
+	}
+	if c := compareSemVer(installSemVer(b), installSemVer(a)); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Path, b.Path)
+}
+
+// installSemVer is install's version for sorting, also for installations
+// rebuilt from a plan or snapshot that only kept the `go version` text.
+func installSemVer(install GoInstallation) string {
+	if install.SemVer != "" {
+		return install.SemVer
+	}
+	semVer, _, _ := parseGoVersion(install.Version)
+	return semVer
+}
+
+// sortInventory puts installations in the canonical order.
+func sortInventory(installations []GoInstallation) {
+	slices.SortStableFunc(installations, compareInstallations)
+}
+
+// sortInstallations orders installations in place by the given sort key,
+// ties in the canonical order.
+func sortInstallations(installations []GoInstallation, sortBy int) {
+	slices.SortStableFunc(installations, func(a, b GoInstallation) int {
+		var c int
+		switch sortBy {
+		case SortByVersion:
+			c = compareSemVer(installSemVer(b), installSemVer(a))
+		case SortBySize:
+			c = cmp.Compare(b.Size, a.Size)
+		case SortByDate:
+			c = b.InstallDate.Compare(a.InstallDate)
+		case SortByPath:
+			c = strings.Compare(a.Path, b.Path)
+		case SortByRisk:
+			c = cmp.Compare(installRisk(b), installRisk(a))
+		}
+		if c != 0 {
+			return c
+		}
+		return compareInstallations(a, b)
+	})
+}
+
+func installPlatform(install GoInstallation) string {
+	if install.GOOS == "" {
+		return "unknown"
+	}
+	return install.GOOS + "/" + install.GOARCH
+}
+
+func onPathLabel(install GoInstallation) string {
+	if install.OnPath {
+		return " | ⭐ On PATH"
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseGoVersion(t *testing.T) {
+	testCases := []struct {
+		input                string
+		semVer, goos, goarch string
+	}{
+		{"go version go1.22.5 linux/amd64", "1.22.5", "linux", "amd64"},
+		{"go version go1.21.0 darwin/arm64", "1.21.0", "darwin", "arm64"},
+		{"go version go1.23rc1 windows/386", "1.23rc1", "windows", "386"},
+		{"go version go1.20", "1.20", "", ""},
+		{"unknown version", "", "", ""},
+	}
+
+	for _, tc := range testCases {
+		semVer, goos, goarch := parseGoVersion(tc.input)
+		if semVer != tc.semVer || goos != tc.goos || goarch != tc.goarch {
+			t.Errorf("parseGoVersion(%q) = (%s, %s, %s), expected (%s, %s, %s)",
+				tc.input, semVer, goos, goarch, tc.semVer, tc.goos, tc.goarch)
+		}
+	}
+}
+
+func TestCompareSemVer(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.22.5", "1.22.5", 0},
+		{"1.9", "1.10", -1},
+		{"1.22.0", "1.21.13", 1},
+		{"1.23rc1", "1.23", -1},
+		{"1.21", "1.21.0", 0},
+		{"", "1.20", -1},
+	}
+
+	for _, tc := range testCases {
+		if result := compareSemVer(tc.a, tc.b); result != tc.expected {
+			t.Errorf("compareSemVer(%s, %s) = %d, expected %d", tc.a, tc.b, result, tc.expected)
+		}
+	}
+}
+
+func TestSortInventoryIsCanonical(t *testing.T) {
+	want := []GoInstallation{
+		{Path: "/home/u/.gvm/gos/go1.22.5", Source: "gvm", SemVer: "1.22.5"},
+		{Path: "/home/u/.gvm/gos/go1.21.13", Source: "gvm", Version: "go version go1.21.13 linux/amd64"},
+		{Path: "/home/u/.gvm/gos/go1.9", Source: "gvm", SemVer: "1.9"},
+		{Path: "/opt/go", Source: "official", SemVer: "1.22.5"},
+		{Path: "/usr/local/go", Source: "official", SemVer: "1.22.5"},
+	}
+	// Every rotation of the input comes out the same
+	for shift := range want {
+		installations := append(append([]GoInstallation(nil), want[shift:]...), want[:shift]...)
+		sortInventory(installations)
+		for i := range want {
+			if installations[i].Path != want[i].Path {
+				t.Fatalf("rotation %d: position %d = %s, expected %s", shift, i, installations[i].Path, want[i].Path)
+			}
+		}
+	}
+}
+
+func TestSortInstallationsBreaksTies(t *testing.T) {
+	installations := []GoInstallation{
+		{Path: "/z", Source: "sdk", SemVer: "1.21.0", Size: 10},
+		{Path: "/b", Source: "official", SemVer: "1.21.0", Size: 10},
+		{Path: "/a", Source: "sdk", SemVer: "1.22.0", Size: 10},
+	}
+	sortInstallations(installations, SortBySize)
+	for i, path := range []string{"/b", "/a", "/z"} {
+		if installations[i].Path != path {
+			t.Errorf("position %d = %s, expected %s", i, installations[i].Path, path)
+		}
+	}
+}
+
+func TestSortInstallations(t *testing.T) {
+	now := time.Now()
+	installations := []GoInstallation{
+		{Path: "/b", Source: "official", SemVer: "1.9", Size: 10, InstallDate: now.Add(-time.Hour)},
+		{Path: "/a", Source: "gvm", SemVer: "1.22.0", Size: 30, InstallDate: now},
+		{Path: "/c", Source: "brew", SemVer: "1.10", Size: 20, InstallDate: now.Add(-2 * time.Hour)},
+	}
+
+	testCases := []struct {
+		sortBy   int
+		expected []string
+	}{
+		{SortBySource, []string{"/c", "/a", "/b"}},
+		{SortByVersion, []string{"/a", "/c", "/b"}},
+		{SortBySize, []string{"/a", "/c", "/b"}},
+		{SortByDate, []string{"/a", "/b", "/c"}},
+		{SortByPath, []string{"/a", "/b", "/c"}},
+	}
+
+	for _, tc := range testCases {
+		sortInstallations(installations, tc.sortBy)
+		for i, path := range tc.expected {
+			if installations[i].Path != path {
+				t.Errorf("sort %s: position %d = %s, expected %s", sortKeyNames[tc.sortBy], i, installations[i].Path, path)
+			}
+		}
+	}
+}
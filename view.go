@@ -0,0 +1,324 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// View runs after every message, and the spinner alone sends ten a second.
+// Most of a screen only changes when something else happens, so View is
+// built from components: the header and each screen's body are cached and
+// painted again only after a message other than a spinner tick, while the
+// spinner line itself is always fresh. On a large inventory that is the
+// difference between redrawing the list ten times a second and not at all.
+
+// styleGeneration changes whenever applyTheme switches the styles, so cached
+// sections painted with the old ones are not reused.
+var styleGeneration atomic.Uint64
+
+// viewKey identifies the inputs a cached section was painted from.
+type viewKey struct {
+	rev    uint64 // model revision, see noteUpdate
+	styles uint64
+	width  int
+	height int
+	state  string
+}
+
+type viewSection struct {
+	key   viewKey
+	out   string
+	valid bool
+}
+
+// viewCache is shared by every copy of a model, as Update returns copies.
+type viewCache struct {
+	mu     sync.Mutex
+	header viewSection
+	body   viewSection
+}
+
+func newViewCache() *viewCache {
+	return &viewCache{}
+}
+
+// noteUpdate bumps the model's revision for every message that can change
+// what is on screen, i.e. everything but spinner ticks.
+func (m model) noteUpdate(msg tea.Msg) model {
+	if _, ok := msg.(spinner.TickMsg); !ok {
+		m.viewRev++
+	}
+	return m
+}
+
+func (m model) viewKey() viewKey {
+	return viewKey{rev: m.viewRev, styles: styleGeneration.Load(), width: m.width, height: m.height, state: m.state}
+}
+
+// cached returns section's output for key, painting it with render when the
+// key changed. Models without a cache, as built in tests, always paint.
+func (m model) cached(section func(*viewCache) *viewSection, key viewKey, render func() string) string {
+	if m.view == nil {
+		return render()
+	}
+	m.view.mu.Lock()
+	defer m.view.mu.Unlock()
+	s := section(m.view)
+	if !s.valid || s.key != key {
+		*s = viewSection{key: key, out: render(), valid: true}
+	}
+	return s.out
+}
+
+func (m model) View() string {
+	var b strings.Builder
+	// The header is the same for the whole run, up to a resize
+	headerKey := viewKey{styles: styleGeneration.Load(), width: m.width}
+	b.WriteString(m.cached(func(c *viewCache) *viewSection { return &c.header }, headerKey, m.renderHeader))
+	b.WriteString(m.renderQuote())
+	b.WriteString(m.renderBody())
+	if m.err != nil && m.state != "complete" {
+		b.WriteString(warningStyle.Render("Error: "+m.err.Error()) + "\n")
+	}
+	return b.String()
+}
+
+func (m model) renderHeader() string {
+	var s string
+	if m.logo != "" {
+		s = renderLogo(m.logo, m.width) + "\n"
+	}
+	s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, subtitleStyle.Render("The Go Uninstaller - Enhanced Security Edition")) + "\n\n"
+	if m.opts.simulate {
+		s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, simulationBanner())
+	}
+	if isOffline() {
+		s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, infoStyle.Render("🔌 OFFLINE - no network access")) + "\n\n"
+	}
+	switch currentScope() {
+	case scopeUser:
+		s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, infoStyle.Render("👤 USER SCOPE - only installations in your home directory")) + "\n\n"
+	case scopeSystem:
+		s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, infoStyle.Render("🖥️ SYSTEM SCOPE - only system-wide installations")) + "\n\n"
+	}
+	return s
+}
+
+// renderBody is the current screen below the header.
+func (m model) renderBody() string {
+	body := func(render func() string) string {
+		return m.cached(func(c *viewCache) *viewSection { return &c.body }, m.viewKey(), render)
+	}
+	switch m.state {
+	// These screens animate on their own, so they are painted every time
+	case "startup_warning":
+		return m.renderStartupWarning()
+	case "setup":
+		return m.renderSetup()
+	case "add_path":
+		return m.renderAddPath()
+	case "browse":
+		return m.browser.View()
+	case "profile_review":
+		return m.renderProfileReview()
+
+	case "loading":
+		return m.renderSpinnerLine("Detecting Go installations...") + body(m.renderDetectorProgress)
+	case "creating_backup":
+		return m.renderSpinnerLine("Creating safety backup...")
+	case "deleting":
+		return m.renderSpinnerLine("Removing Go installations...") + body(m.renderDeleteProgress)
+	case "summary":
+		return body(m.renderSummary)
+	case "confirm":
+		return body(m.renderConfirm)
+	case "review":
+		return body(m.renderReview)
+	case "dry_run_complete":
+		return body(m.renderDryRunComplete)
+	case "complete":
+		return body(m.renderComplete)
+	}
+	return ""
+}
+
+func (m model) renderSpinnerLine(message string) string {
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Center, fmt.Sprintf("%s %s", m.spinner.View(), message)) + "\n"
+}
+
+func (m model) renderConfirm() string {
+	var s string
+	if len(m.detectedInstalls) == 0 {
+		s += warningStyle.Render("No Go installations found!") + "\n"
+		s += "If you believe Go is installed but not detected, please run this tool with admin/sudo privileges.\n"
+		s += "\n" + m.renderDetectorCoverage()
+		s += "\nPress q to quit."
+		return s
+	}
+
+	s += m.renderInventory()
+	s += m.renderRiskSummary()
+	s += m.renderSupportSummary()
+	s += m.renderDuplicateSummary()
+	s += m.renderEstimate()
+	s += m.renderNeedsReview()
+	s += m.renderToolchainPins()
+	s += m.renderProjects()
+	s += m.renderSystemBackup()
+	s += m.renderGopath()
+	s += m.renderDetectorCoverage()
+	s += m.renderPermissions()
+	s += m.renderMode()
+
+	s += "\n" + warningStyle.Render("⚠️  CRITICAL WARNING: This will delete every selected Go installation from your system!") + "\n"
+	s += infoStyle.Render(fmt.Sprintf("📂 Backup location: %s (b to change)", m.backupPath)) + "\n\n"
+	s += m.renderRemoteBackup()
+
+	if warning := m.selfRemovalWarning(); warning != "" {
+		s += warningStyle.Render(warning) + "\n\n"
+	}
+	if len(m.foreignOwners) > 0 {
+		s += warningStyle.Render(fmt.Sprintf("👤 The plan removes files owned by other users: %s", strings.Join(m.foreignOwners, ", "))) + "\n\n"
+	}
+
+	s += m.renderConfirmationStep()
+	s += "\n" + confirmButtonStyle.Render("ENTER") + " to continue, " + cancelButtonStyle.Render("d") + " toggle dry-run, " + cancelButtonStyle.Render("q") + " to quit\n"
+	return s
+}
+
+// renderInventory is the installation table and the details of the one under
+// the cursor.
+func (m model) renderInventory() string {
+	s := highlightStyle.Render(fmt.Sprintf("🔍 Detected %d Go installation(s), %d selected:", len(m.detectedInstalls), len(m.selectedInstalls()))) + "\n"
+	s += infoStyle.Render(fmt.Sprintf("   Sorted by %s (tab to change, ↑/↓ to move, space to select, / to filter, a to add a path, g to include GOPATH)", sortKeyNames[m.sortBy])) + "\n\n"
+	s += m.inventory.View() + "\n\n"
+	if current, ok := m.inventory.SelectedItem(); ok {
+		s += renderInstallDetails(current.install) + m.itemEstimate(current.install) + "\n"
+	}
+	return s
+}
+
+// renderPermissions is the preflight outcome for the current selection.
+func (m model) renderPermissions() string {
+	preflight := m.plannedPreflight()
+	if preflightPassed(preflight) {
+		return successStyle.Render("✅ Permissions check passed for every path") + "\n\n"
+	}
+	s := warningStyle.Render("⚠️  WARNING: Insufficient permissions detected!") + "\n"
+	for _, result := range preflight {
+		if result.ok() {
+			s += successStyle.Render(fmt.Sprintf("   ✅ %s", result.Path)) + "\n"
+		} else {
+			s += warningStyle.Render(fmt.Sprintf("   ❌ %s: %v", result.Path, result.Err)) + "\n"
+		}
+	}
+	if preflightNeedsElevation(preflight) {
+		s += infoStyle.Render("   Run with sudo/admin privileges for complete removal") + "\n\n"
+	} else {
+		s += infoStyle.Render("   Elevation would not help; fix the paths above") + "\n\n"
+	}
+	return s
+}
+
+// renderMode says whether ENTER leads to a dry run or a live removal.
+func (m model) renderMode() string {
+	if lock := liveModeLock(); m.dryRun && lock != "" {
+		return highlightStyle.Render(fmt.Sprintf("🔍 DRY RUN MODE ENABLED - No files will be deleted (%s)", lock)) + "\n"
+	} else if m.dryRun {
+		return highlightStyle.Render("🔍 DRY RUN MODE ENABLED - No files will be deleted") + "\n"
+	}
+	return warningStyle.Render("🔥 LIVE MODE - Files WILL be permanently deleted!") + "\n"
+}
+
+func (m model) renderConfirmationStep() string {
+	offset, total := 0, 3
+	if !m.needsFullConfirmation() {
+		total = 2
+	}
+	if len(m.foreignOwners) > 0 {
+		offset, total = 1, total+1
+	}
+	switch m.confirmationStep {
+	case ConfirmationStepOwnership:
+		return fmt.Sprintf("Step 1/%d: ", total) + m.textInput.View() + "\n"
+	case ConfirmationStepInitial:
+		return fmt.Sprintf("Step %d/%d: ", 1+offset, total) + m.textInput.View() + "\n"
+	case ConfirmationStepHash:
+		return fmt.Sprintf("Step %d/%d: ", 2+offset, total) + m.textInput.View() + "\n"
+	case ConfirmationStepDestroy:
+		return fmt.Sprintf("Step %d/%d: ", 3+offset, total) + m.textInput.View() + "\n"
+	case ConfirmationStepReview:
+		return fmt.Sprintf("Step %d/%d: ", 2+offset, total) + m.textInput.Placeholder + "\n"
+	}
+	return ""
+}
+
+func (m model) renderDryRunComplete() string {
+	dryMsg := successStyle.Render("🔍 DRY RUN COMPLETED")
+	s := lipgloss.PlaceHorizontal(m.width, lipgloss.Center, dryMsg) + "\n\n"
+	s += "The following operations would be performed:\n\n"
+	s += m.renderOperations(m.reviewedPlan)
+	s += "\n" + infoStyle.Render("No files were actually deleted in dry-run mode") + "\n"
+	if m.reportPath != "" {
+		s += infoStyle.Render(fmt.Sprintf("📄 Report: %s", m.reportPath)) + "\n"
+	}
+	return s + "\nPress ENTER or Q to exit\n"
+}
+
+func (m model) renderComplete() string {
+	var s string
+	if m.err != nil {
+		errorMsg := warningStyle.Render("❌ Error: " + m.err.Error())
+		s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, errorMsg) + "\n"
+		hint := "You may need to run this tool with admin/sudo privileges."
+		if isCommandTimeout(m.err) {
+			hint = "⏱  A command stopped responding; check for a hung network mount or a locked package manager."
+		}
+		s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, hint) + "\n"
+		if quote := m.quote("failed"); quote != "" {
+			s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, infoStyle.Italic(true).Render(quote)) + "\n"
+		}
+		s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, fmt.Sprintf("💾 Backup available at: %s", m.backupPath)) + "\n\n"
+		s += m.renderResults()
+		s += renderSnapshotDiff(m.snapshotDiff)
+		return s
+	}
+	if m.farewellFrames != nil {
+		return m.renderFarewell()
+	}
+	if !m.deletionComplete {
+		return ""
+	}
+	successMsg := successStyle.Render("✨ Success! All Go installations have been removed. ✨")
+	confirmMsg := warningStyle.Render(m.quote("complete"))
+	backupMsg := infoStyle.Render(fmt.Sprintf("💾 Backup created at: %s", m.backupPath))
+
+	successBox := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#C3E88D")).
+		Padding(1).
+		Render(successMsg + "\n\n" + confirmMsg + "\n\n" + backupMsg)
+
+	s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, successBox) + "\n\n"
+	s += m.renderResults()
+	s += renderSnapshotDiff(m.snapshotDiff)
+	if m.logFile != nil {
+		s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, fmt.Sprintf("📋 Check logs at %s for detailed information", m.logFile.Dir())) + "\n"
+	}
+	if m.reportPath != "" {
+		s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, fmt.Sprintf("📄 Report: %s", m.reportPath)) + "\n"
+	}
+	for _, result := range m.profileResults {
+		s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, "🔧 "+result) + "\n"
+	}
+	if len(m.profileResults) == 0 {
+		s += lipgloss.PlaceHorizontal(m.width, lipgloss.Center, "🔧 You may need to clean up your PATH environment variable manually.") + "\n"
+	}
+	return s + lipgloss.PlaceHorizontal(m.width, lipgloss.Center, "Press ENTER or Q to exit") + "\n"
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func largeInventoryModel(n int, cache *viewCache) model {
+	m := model{state: "confirm", width: 120, height: 40, inventory: newInstallList(), textInput: textinput.New(), view: cache, logo: fugoASCII, spinner: spinner.New()}
+	for i := 0; i < n; i++ {
+		m.detectedInstalls = append(m.detectedInstalls, GoInstallation{
+			Path: fmt.Sprintf("/home/gopher/sdk/go1.%d.%d", i/10, i%10), SemVer: fmt.Sprintf("1.%d.%d", i/10, i%10),
+			Version: "go version", Source: "sdk", Size: int64(i) << 20, Verified: true,
+		})
+	}
+	m.setInstallItems()
+	return m
+}
+
+func TestViewCacheSkipsSpinnerTicks(t *testing.T) {
+	m := largeInventoryModel(3, newViewCache())
+	painted := 0
+	render := func() string { painted++; return fmt.Sprint(painted) }
+	section := func(c *viewCache) *viewSection { return &c.body }
+
+	m.cached(section, m.viewKey(), render)
+	next, _ := m.Update(spinner.TickMsg{})
+	m = next.(model)
+	m.cached(section, m.viewKey(), render)
+	if painted != 1 {
+		t.Errorf("Expected a spinner tick to reuse the painted body, painted %d times", painted)
+	}
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = next.(model)
+	m.cached(section, m.viewKey(), render)
+	if painted != 2 {
+		t.Errorf("Expected a key to paint the body again, painted %d times", painted)
+	}
+
+	applyTheme("default")
+	m.cached(section, m.viewKey(), render)
+	if painted != 3 {
+		t.Errorf("Expected a theme switch to paint the body again, painted %d times", painted)
+	}
+}
+
+func TestViewMatchesUncached(t *testing.T) {
+	cached := largeInventoryModel(5, newViewCache())
+	uncached := largeInventoryModel(5, nil)
+	for _, msg := range []tea.Msg{spinner.TickMsg{}, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}, spinner.TickMsg{}, tea.WindowSizeMsg{Width: 90, Height: 30}} {
+		next, _ := cached.Update(msg)
+		cached = next.(model)
+		next, _ = uncached.Update(msg)
+		uncached = next.(model)
+		if got, want := cached.View(), uncached.View(); got != want {
+			t.Fatalf("After %T the cached view differs:\n%s\nexpected:\n%s", msg, got, want)
+		}
+	}
+	if !strings.Contains(cached.View(), "4 selected") {
+		t.Errorf("Expected the deselection to show")
+	}
+}
+
+// BenchmarkViewConfirmSpinnerTick is a spinner frame on the confirm screen of
+// a large inventory; BenchmarkViewConfirmUncached is the same frame painted
+// from scratch.
+func BenchmarkViewConfirmSpinnerTick(b *testing.B) {
+	m := largeInventoryModel(200, newViewCache())
+	m.View()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		next, _ := m.Update(spinner.TickMsg{})
+		next.View()
+	}
+}
+
+func BenchmarkViewConfirmUncached(b *testing.B) {
+	m := largeInventoryModel(200, nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		next, _ := m.Update(spinner.TickMsg{})
+		next.View()
+	}
+}
+
+func TestHeaderShowsPolicyOffline(t *testing.T) {
+	setOffline(true)
+	defer setOffline(false)
+	// The machine policy turns offline mode on without --offline
+	m := model{width: 80}
+	if header := m.renderHeader(); !strings.Contains(header, "OFFLINE") {
+		t.Errorf("Expected the offline banner, got:\n%s", header)
+	}
+}
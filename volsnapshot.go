@@ -0,0 +1,303 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// On Btrfs, ZFS, APFS or LVM a file system snapshot of the volume holding an
+// installation takes a second where archiving a 50 GB module cache takes
+// an hour. With `snapshot` in the config, fu-go snapshots each affected
+// volume before a live removal, as well as archiving it, or instead of
+// archiving with backup_policy "snapshot". Snapshots are never removed by
+// fu-go; they are the file system's to list and delete.
+
+// SnapshotConfig selects the snapshot backend in config.json.
+type SnapshotConfig struct {
+	// Backend is "auto" (from each volume's file system), "btrfs", "zfs",
+	// "apfs" or "lvm". Empty takes no snapshots unless backup_policy is
+	// "snapshot", which means "auto".
+	Backend string `json:"backend,omitempty"`
+	// Commands replace the built-in command of a backend. {mount}, {source}
+	// (the device, dataset or volume group/volume) and {name} are
+	// substituted after splitting on spaces, and no shell is involved.
+	Commands map[string]string `json:"commands,omitempty"`
+}
+
+var snapshotBackends = []string{"auto", "btrfs", "zfs", "apfs", "lvm"}
+
+// defaultSnapshotCommands are the commands each backend runs.
+var defaultSnapshotCommands = map[string]string{
+	"btrfs": "btrfs subvolume snapshot -r {mount} {mount}/{name}",
+	"zfs":   "zfs snapshot {source}@{name}",
+	"apfs":  "tmutil localsnapshot",
+	"lvm":   "lvcreate --snapshot --extents 10%ORIGIN --name {name} {source}",
+}
+
+func (c SnapshotConfig) validate() error {
+	if c.Backend != "" && !slices.Contains(snapshotBackends, c.Backend) {
+		return fmt.Errorf("snapshot backend must be one of %s, got %q", strings.Join(snapshotBackends, ", "), c.Backend)
+	}
+	for backend, command := range c.Commands {
+		if _, ok := defaultSnapshotCommands[backend]; !ok {
+			return fmt.Errorf("snapshot commands: unknown backend %q", backend)
+		}
+		if len(strings.Fields(command)) == 0 {
+			return fmt.Errorf("snapshot commands: empty command for %s", backend)
+		}
+	}
+	return nil
+}
+
+// command is the argument list that snapshots v with backend.
+func (c SnapshotConfig) command(backend string, v volume, name string) []string {
+	template, ok := c.Commands[backend]
+	if !ok {
+		template = defaultSnapshotCommands[backend]
+	}
+	replacer := strings.NewReplacer("{mount}", v.mount, "{source}", v.source, "{name}", name)
+	var argv []string
+	for _, field := range strings.Fields(template) {
+		argv = append(argv, replacer.Replace(field))
+	}
+	return argv
+}
+
+// volume is one entry of the mount table.
+type volume struct {
+	source string
+	mount  string
+	fsType string
+}
+
+// The mount table, logical volume lookup and snapshot commands; tests
+// replace them.
+var (
+	mountTable = func() ([]byte, error) { return commandOutput("mount") }
+	// logicalVolume returns "vg/lv" for a device that is an LVM logical
+	// volume.
+	logicalVolume = func(device string) (string, bool) {
+		output, err := commandOutput("lvs", "--noheadings", "-o", "vg_name,lv_name", device)
+		if err != nil {
+			return "", false
+		}
+		fields := strings.Fields(string(output))
+		if len(fields) != 2 {
+			return "", false
+		}
+		return fields[0] + "/" + fields[1], true
+	}
+	runSnapshot = func(argv []string) error {
+		output, err := commandCombinedOutputTimeout(snapshotCommandTimeout, argv[0], argv[1:]...)
+		if err != nil {
+			if text := strings.TrimSpace(string(output)); text != "" {
+				return fmt.Errorf("%v: %s", err, text)
+			}
+			return err
+		}
+		return nil
+	}
+)
+
+// parseMountTable reads the output of mount, in the Linux form
+// "src on /dir type fs (opts)" or the macOS and BSD form
+// "src on /dir (fs, opts)".
+func parseMountTable(data []byte) []volume {
+	var volumes []volume
+	for _, line := range strings.Split(string(data), "\n") {
+		source, rest, ok := strings.Cut(line, " on ")
+		if !ok {
+			continue
+		}
+		v := volume{source: source}
+		if i := strings.LastIndex(rest, " type "); i >= 0 {
+			v.mount = rest[:i]
+			if fields := strings.Fields(rest[i+len(" type "):]); len(fields) > 0 {
+				v.fsType = fields[0]
+			}
+		} else if i := strings.LastIndex(rest, " ("); i >= 0 {
+			v.mount = rest[:i]
+			fsType, _, _ := strings.Cut(rest[i+len(" ("):], ",")
+			v.fsType = strings.TrimSuffix(strings.TrimSpace(fsType), ")")
+		} else {
+			continue
+		}
+		volumes = append(volumes, v)
+	}
+	return volumes
+}
+
+// volumeOf is the volume path lives on: the longest mount point containing
+// it.
+func volumeOf(volumes []volume, path string) (volume, bool) {
+	path = filepath.Clean(path)
+	var best volume
+	found := false
+	for _, v := range volumes {
+		mount := filepath.Clean(v.mount)
+		if mount != path && mount != string(filepath.Separator) && !strings.HasPrefix(path, mount+string(filepath.Separator)) {
+			continue
+		}
+		if !found || len(mount) >= len(filepath.Clean(best.mount)) {
+			best, found = v, true
+		}
+	}
+	return best, found
+}
+
+// snapshotBackend is the backend that can snapshot v, or "" if none, and v
+// with its source in the form that backend names it.
+func snapshotBackend(v volume) (string, volume) {
+	switch v.fsType {
+	case "btrfs", "zfs", "apfs":
+		return v.fsType, v
+	}
+	if strings.HasPrefix(v.source, "/dev/mapper/") || strings.HasPrefix(v.source, "/dev/dm-") {
+		if lv, ok := logicalVolume(v.source); ok {
+			v.source = lv
+			return "lvm", v
+		}
+	}
+	return "", v
+}
+
+// snapshotVolumes snapshots each volume holding one of installs and returns
+// the commands it ran. A volume the backend cannot snapshot is an error
+// unless archived says a tar backup covers it.
+func snapshotVolumes(cfg SnapshotConfig, installs []GoInstallation, archived bool, now time.Time) ([]string, error) {
+	data, err := mountTable()
+	if err != nil {
+		if archived {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read the mount table: %v", err)
+	}
+	volumes := parseMountTable(data)
+	name := "fugo-" + now.Format("20060102-150405")
+	seen := make(map[string]bool)
+	var taken []string
+	for _, install := range installs {
+		path := install.Path
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		v, ok := volumeOf(volumes, path)
+		backend := ""
+		if ok {
+			backend, v = snapshotBackend(v)
+		}
+		if backend == "" || (cfg.Backend != "" && cfg.Backend != "auto" && cfg.Backend != backend) {
+			if archived {
+				continue
+			}
+			if !ok {
+				return taken, fmt.Errorf("cannot snapshot %s: its volume is not in the mount table", install.Path)
+			}
+			return taken, fmt.Errorf("cannot snapshot %s: %s (%s) has no %s snapshots; set backup_policy to always to archive it instead", install.Path, v.mount, v.fsType, snapshotBackendName(cfg.Backend))
+		}
+		argv := cfg.command(backend, v, name)
+		key := strings.Join(argv, " ")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if err := runSnapshot(argv); err != nil {
+			return taken, fmt.Errorf("snapshot of %s failed: %s: %v", v.mount, key, err)
+		}
+		taken = append(taken, key)
+	}
+	return taken, nil
+}
+
+func snapshotBackendName(backend string) string {
+	if backend == "" || backend == "auto" {
+		return "supported"
+	}
+	return backend
+}
+
+// backupsTaken is what backupInstallations took.
+type backupsTaken struct {
+	snapshots []string // snapshot commands run
+	set       string   // backup set directory, "" when nothing was archived
+	pruned    []string // backup sets retention removed
+	freed     int64
+	pruneErr  error           // pruning failed; the backup itself is fine
+	hashing   storeStats      // dedup hashing over every installation
+	moved     map[string]bool // installations a quarantine renamed into the set
+}
+
+// logTo writes what was taken to log.
+func (t backupsTaken) logTo(log func(level, message string)) {
+	for _, snapshot := range t.snapshots {
+		log("SUCCESS", fmt.Sprintf("Snapshot taken: %s", snapshot))
+	}
+	if t.set != "" {
+		log("SUCCESS", fmt.Sprintf("Backup created at: %s", t.set))
+	}
+	if t.hashing.hashed > 0 {
+		message := fmt.Sprintf("Hashed %s in %s (%s/s)", formatBytes(t.hashing.hashed), t.hashing.elapsed.Round(time.Millisecond), formatBytes(int64(t.hashing.hashRate())))
+		if t.hashing.reused > 0 {
+			message += fmt.Sprintf("; %d unchanged files kept their chunks from the last backup", t.hashing.reused)
+		}
+		log("INFO", message)
+	}
+	for _, set := range t.pruned {
+		log("INFO", fmt.Sprintf("Removed backup set %s (backup_retention)", set))
+	}
+	if t.freed > 0 {
+		log("INFO", fmt.Sprintf("Pruning backups freed %s", formatBytes(t.freed)))
+	}
+	if t.pruneErr != nil {
+		log("WARN", fmt.Sprintf("Failed to prune backups: %v", t.pruneErr))
+	}
+}
+
+// backupInstallations takes the backups cfg asks for before installs are
+// removed: snapshots of their volumes, then a backup set in backupDir,
+// after which retention prunes the older sets. Offline, a backupDir on a
+// network file system is refused.
+func backupInstallations(cfg Config, installs []GoInstallation, backupDir string) (backupsTaken, error) {
+	var taken backupsTaken
+	if cfg.archiveBeforeRemoval() && len(installs) > 0 && isOffline() {
+		if fsType := networkFileSystem(backupDir); fsType != "" {
+			return taken, fmt.Errorf("backup directory %s is on %s: %w", backupDir, fsType, errOffline)
+		}
+	}
+	if cfg.snapshotBeforeRemoval() {
+		snapshots, err := snapshotVolumes(cfg.Snapshot, installs, cfg.archiveBeforeRemoval(), time.Now())
+		taken.snapshots = snapshots
+		if err != nil {
+			return taken, err
+		}
+	}
+	if !cfg.archiveBeforeRemoval() || len(installs) == 0 {
+		return taken, nil
+	}
+	var err error
+	if taken.set, err = writeBackupSet(backupDir, cfg.BackupFormat, installs, time.Now()); err != nil {
+		return taken, err
+	}
+	if index, err := readBackupIndex(taken.set); err == nil {
+		for _, entry := range index.Entries {
+			taken.hashing.hashed += entry.Hashed
+			taken.hashing.elapsed += time.Duration(entry.HashMS) * time.Millisecond
+			taken.hashing.reused += entry.Reused
+			if entry.Moved {
+				if taken.moved == nil {
+					taken.moved = make(map[string]bool)
+				}
+				taken.moved[entry.Path] = true
+			}
+		}
+	}
+	taken.pruned, taken.freed, taken.pruneErr = pruneBackupSets(backupDir, cfg.BackupRetention, time.Now())
+	return taken, nil
+}
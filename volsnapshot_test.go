@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMountTable(t *testing.T) {
+	linux := `/dev/nvme0n1p2 on / type btrfs (rw,relatime,subvol=/@)
+tank/home on /home type zfs (rw,xattr,noacl)
+/dev/mapper/vg0-data on /srv/data dir type ext4 (rw)
+proc on /proc type proc (rw,nosuid)`
+	want := []volume{
+		{source: "/dev/nvme0n1p2", mount: "/", fsType: "btrfs"},
+		{source: "tank/home", mount: "/home", fsType: "zfs"},
+		{source: "/dev/mapper/vg0-data", mount: "/srv/data dir", fsType: "ext4"},
+		{source: "proc", mount: "/proc", fsType: "proc"},
+	}
+	if got := parseMountTable([]byte(linux)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Linux mount table parsed as %+v, want %+v", got, want)
+	}
+
+	darwin := `/dev/disk3s1s1 on / (apfs, sealed, local, read-only, journaled)
+/dev/disk3s5 on /System/Volumes/Data (apfs, local, journaled, nobrowse)
+map auto_home on /System/Volumes/Data/home (autofs, automounted, nobrowse)`
+	want = []volume{
+		{source: "/dev/disk3s1s1", mount: "/", fsType: "apfs"},
+		{source: "/dev/disk3s5", mount: "/System/Volumes/Data", fsType: "apfs"},
+		{source: "map auto_home", mount: "/System/Volumes/Data/home", fsType: "autofs"},
+	}
+	if got := parseMountTable([]byte(darwin)); !reflect.DeepEqual(got, want) {
+		t.Errorf("macOS mount table parsed as %+v, want %+v", got, want)
+	}
+}
+
+func TestVolumeOfPicksTheLongestMount(t *testing.T) {
+	volumes := []volume{{mount: "/"}, {mount: "/home"}, {mount: "/home/me/go"}, {mount: "/homework"}}
+	for path, want := range map[string]string{
+		"/usr/local/go":     "/",
+		"/home/me/sdk/go":   "/home",
+		"/home/me/go":       "/home/me/go",
+		"/home/me/go/pkg":   "/home/me/go",
+		"/homework/go1.22":  "/homework",
+		"/home/me/gopher/x": "/home",
+	} {
+		if v, ok := volumeOf(volumes, path); !ok || v.mount != want {
+			t.Errorf("volumeOf(%s) = %q, want %q", path, v.mount, want)
+		}
+	}
+	if _, ok := volumeOf([]volume{{mount: "/home"}}, "/usr"); ok {
+		t.Errorf("Expected no volume for a path outside every mount")
+	}
+}
+
+func TestSnapshotCommandSubstitutesTemplates(t *testing.T) {
+	v := volume{source: "tank/home", mount: "/home/my files"}
+	cfg := SnapshotConfig{}
+	if got := cfg.command("zfs", v, "fugo-1"); !reflect.DeepEqual(got, []string{"zfs", "snapshot", "tank/home@fugo-1"}) {
+		t.Errorf("Default zfs command is %q", got)
+	}
+	if got := cfg.command("btrfs", v, "fugo-1"); got[len(got)-1] != "/home/my files/fugo-1" {
+		t.Errorf("Expected a mount with a space to stay one argument, got %q", got)
+	}
+	cfg.Commands = map[string]string{"zfs": "sudo zfs snapshot -r {source}@{name}"}
+	if got := cfg.command("zfs", v, "fugo-1"); strings.Join(got, " ") != "sudo zfs snapshot -r tank/home@fugo-1" {
+		t.Errorf("Configured zfs command is %q", got)
+	}
+	if err := (SnapshotConfig{Backend: "xfs"}).validate(); err == nil {
+		t.Errorf("Expected an unknown backend to be rejected")
+	}
+	if err := (SnapshotConfig{Commands: map[string]string{"lvm": " "}}).validate(); err == nil {
+		t.Errorf("Expected an empty command to be rejected")
+	}
+}
+
+// fakeSnapshots replaces the mount table and snapshot commands for a test
+// and returns the commands run.
+func fakeSnapshots(t *testing.T, table string) *[]string {
+	t.Helper()
+	origTable, origLV, origRun := mountTable, logicalVolume, runSnapshot
+	t.Cleanup(func() { mountTable, logicalVolume, runSnapshot = origTable, origLV, origRun })
+	mountTable = func() ([]byte, error) { return []byte(table), nil }
+	logicalVolume = func(device string) (string, bool) {
+		if device == "/dev/mapper/vg0-data" {
+			return "vg0/data", true
+		}
+		return "", false
+	}
+	var ran []string
+	runSnapshot = func(argv []string) error {
+		ran = append(ran, strings.Join(argv, " "))
+		return nil
+	}
+	return &ran
+}
+
+func TestBackupInstallationsSnapshotsEachVolumeOnce(t *testing.T) {
+	root := t.TempDir()
+	a, b := filepath.Join(root, "a"), filepath.Join(root, "b")
+	os.MkdirAll(a, 0755)
+	os.MkdirAll(b, 0755)
+	root, _ = filepath.EvalSymlinks(root)
+	ran := fakeSnapshots(t, "/dev/mapper/vg0-data on "+root+" type ext4 (rw)\n")
+	backups := filepath.Join(t.TempDir(), "backups")
+
+	cfg := Config{BackupPolicy: "snapshot"}
+	installs := []GoInstallation{{Path: a}, {Path: b}, {Path: filepath.Join(root, "gone")}}
+	taken, err := backupInstallations(cfg, installs, backups)
+	if err != nil {
+		t.Fatalf("backupInstallations returned error: %v", err)
+	}
+	if len(*ran) != 1 || !strings.HasPrefix((*ran)[0], "lvcreate --snapshot --extents 10%ORIGIN --name fugo-") || !strings.HasSuffix((*ran)[0], " vg0/data") {
+		t.Errorf("Expected one LVM snapshot, ran %q", *ran)
+	}
+	if !reflect.DeepEqual(taken.snapshots, *ran) || taken.set != "" {
+		t.Errorf("Expected only the snapshots taken to be returned, got %+v", taken)
+	}
+	if _, err := os.Stat(backups); !os.IsNotExist(err) {
+		t.Errorf("Expected no tar backup with backup_policy snapshot")
+	}
+
+	// A complement to tar: an ext4 volume is skipped and archived
+	fakeSnapshots(t, "/dev/sda1 on / type ext4 (rw)\n")
+	cfg = Config{Snapshot: SnapshotConfig{Backend: "auto"}}
+	taken, err = backupInstallations(cfg, installs[:1], backups)
+	if err != nil {
+		t.Fatalf("backupInstallations returned error: %v", err)
+	}
+	if index, err := readBackupIndex(taken.set); err != nil || len(index.Entries) != 1 {
+		t.Errorf("Expected a tar backup, got %+v, %v", index, err)
+	}
+}
+
+func TestSnapshotOnlyRefusesUnsupportedVolumes(t *testing.T) {
+	dir := t.TempDir()
+	ran := fakeSnapshots(t, "/dev/sda1 on / type ext4 (rw)\n")
+	_, err := backupInstallations(Config{BackupPolicy: "snapshot"}, []GoInstallation{{Path: dir}}, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "has no supported snapshots") {
+		t.Errorf("Expected an ext4 volume to be refused, got %v", err)
+	}
+	if len(*ran) != 0 {
+		t.Errorf("Expected no snapshot commands, ran %q", *ran)
+	}
+	ran = fakeSnapshots(t, "tank/home on / type zfs (rw)\n")
+	_, err = backupInstallations(Config{BackupPolicy: "snapshot", Snapshot: SnapshotConfig{Backend: "btrfs"}}, []GoInstallation{{Path: dir}}, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "has no btrfs snapshots") {
+		t.Errorf("Expected a zfs volume to be refused by the btrfs backend, got %v", err)
+	}
+	if len(*ran) != 0 {
+		t.Errorf("Expected no snapshot commands, ran %q", *ran)
+	}
+}
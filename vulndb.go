@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The embedded CVE table covers a handful of critical bugs. With vuln_db set,
+// fu-go instead asks the Go vulnerability database (https://vuln.go.dev, or
+// a local mirror of it) for every advisory against the standard library and
+// the toolchain, and shows them as badges on the installations they affect.
+// Entries are cached by their modified time, so later runs only fetch what
+// changed.
+
+const (
+	defaultVulnDB     = "https://vuln.go.dev"
+	vulnFetchParallel = 8
+)
+
+// advisory is one vulnerability database entry affecting an installation.
+type advisory struct {
+	ID      string   `json:"id"`
+	Aliases []string `json:"aliases,omitempty"`
+	Summary string   `json:"summary,omitempty"`
+	Fixed   string   `json:"fixed,omitempty"` // first release with the fix after the installation's, if any
+}
+
+// label is the CVE when there is one, as people search for those.
+func (a advisory) label() string {
+	for _, alias := range a.Aliases {
+		if strings.HasPrefix(alias, "CVE-") {
+			return alias
+		}
+	}
+	return a.ID
+}
+
+// vulnModule is one entry of the database's index/modules.json.
+type vulnModule struct {
+	Path  string `json:"path"`
+	Vulns []struct {
+		ID       string `json:"id"`
+		Modified string `json:"modified"`
+	} `json:"vulns"`
+}
+
+// osvEntry is the part of an OSV record fu-go reads.
+type osvEntry struct {
+	ID       string   `json:"id"`
+	Modified string   `json:"modified"`
+	Aliases  []string `json:"aliases"`
+	Summary  string   `json:"summary"`
+	Affected []struct {
+		Package struct {
+			Name string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string `json:"type"`
+			Events []struct {
+				Introduced string `json:"introduced,omitempty"`
+				Fixed      string `json:"fixed,omitempty"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+}
+
+// vulnModules are the database modules that describe Go itself.
+var vulnModules = map[string]bool{"stdlib": true, "toolchain": true}
+
+// vulnSource reads database files over HTTP or from a local mirror.
+type vulnSource struct {
+	base   string // URL, or directory for a mirror
+	client *http.Client
+}
+
+func newVulnSource(cfg Config) (vulnSource, error) {
+	base := cfg.VulnDB
+	if base == "default" {
+		base = defaultVulnDB
+	}
+	if strings.HasPrefix(base, "https://") || strings.HasPrefix(base, "http://") {
+		client, err := newHTTPClient(cfg, time.Minute)
+		if err != nil {
+			return vulnSource{}, err
+		}
+		return vulnSource{base: strings.TrimSuffix(base, "/"), client: client}, nil
+	}
+	dir, err := localSourceDir(base)
+	if err != nil {
+		return vulnSource{}, fmt.Errorf("vuln_db: %v", err)
+	}
+	return vulnSource{base: dir}, nil
+}
+
+func (s vulnSource) read(name string) ([]byte, error) {
+	if s.client == nil {
+		return os.ReadFile(filepath.Join(s.base, filepath.FromSlash(name)))
+	}
+	resp, err := s.client.Get(s.base + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s/%s: %s", s.base, name, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// fetchGoAdvisories returns every stdlib and toolchain entry, reading
+// unchanged ones from cacheDir.
+func fetchGoAdvisories(source vulnSource, cacheDir string) ([]osvEntry, error) {
+	data, err := source.read("index/modules.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the vulnerability index: %v", err)
+	}
+	var modules []vulnModule
+	if err := json.Unmarshal(data, &modules); err != nil {
+		return nil, fmt.Errorf("invalid vulnerability index: %v", err)
+	}
+	type wanted struct{ id, modified string }
+	var ids []wanted
+	seen := make(map[string]bool)
+	for _, module := range modules {
+		if !vulnModules[module.Path] {
+			continue
+		}
+		for _, vuln := range module.Vulns {
+			if !seen[vuln.ID] {
+				seen[vuln.ID] = true
+				ids = append(ids, wanted{vuln.ID, vuln.Modified})
+			}
+		}
+	}
+
+	entries := make([]osvEntry, len(ids))
+	errs := make([]error, len(ids))
+	sem := make(chan struct{}, vulnFetchParallel)
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id wanted) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			entries[i], errs[i] = fetchOSVEntry(source, cacheDir, id.id, id.modified)
+		}(i, id)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func fetchOSVEntry(source vulnSource, cacheDir, id, modified string) (osvEntry, error) {
+	var entry osvEntry
+	cached := filepath.Join(cacheDir, id+".json")
+	if data, err := os.ReadFile(cached); err == nil && json.Unmarshal(data, &entry) == nil && entry.Modified == modified {
+		return entry, nil
+	}
+	data, err := source.read("ID/" + id + ".json")
+	if err != nil {
+		return entry, fmt.Errorf("failed to read %s: %v", id, err)
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, fmt.Errorf("invalid entry %s: %v", id, err)
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err == nil {
+		os.WriteFile(cached, data, 0644)
+	}
+	return entry, nil
+}
+
+// osvToSemVer turns the database's "1.21.0-rc.1" into fu-go's "1.21.0rc1".
+// "-0", the lowest pre-release, becomes "a", which compareSemVer orders
+// before Go's beta and rc suffixes.
+func osvToSemVer(v string) string {
+	release, pre, found := strings.Cut(v, "-")
+	if !found {
+		return release
+	}
+	if pre == "0" {
+		return release + "a"
+	}
+	return release + strings.ReplaceAll(pre, ".", "")
+}
+
+// canonicalSemVer spells v with all three numbers, as the database does.
+func canonicalSemVer(v goVersion) string {
+	return fmt.Sprintf("%d.%d.%d%s", v.Major, v.Minor, v.Patch, v.Pre)
+}
+
+// affectsVersion reports whether entry applies to the Go release semVer and,
+// if so, the release that fixed it on that line.
+func (e osvEntry) affectsVersion(semVer string) (bool, string) {
+	v, ok := parseSemVer(semVer)
+	if !ok {
+		return false, ""
+	}
+	version := canonicalSemVer(v)
+	for _, affected := range e.Affected {
+		if !vulnModules[affected.Package.Name] {
+			continue
+		}
+		for _, r := range affected.Ranges {
+			if r.Type != "SEMVER" {
+				continue
+			}
+			// Events alternate introduced, fixed, introduced, ... in order
+			introduced := ""
+			for _, event := range r.Events {
+				if event.Introduced != "" {
+					introduced = osvToSemVer(event.Introduced)
+					continue
+				}
+				fixed := osvToSemVer(event.Fixed)
+				if introduced != "" && compareSemVer(version, introduced) >= 0 && compareSemVer(version, fixed) < 0 {
+					return true, fixed
+				}
+				introduced = ""
+			}
+			if introduced != "" && compareSemVer(version, introduced) >= 0 {
+				return true, ""
+			}
+		}
+	}
+	return false, ""
+}
+
+// annotateAdvisories records the database entries affecting each
+// installation, most recent first. The database supersedes the embedded CVE
+// table, so those annotations are dropped.
+func annotateAdvisories(installations []GoInstallation, entries []osvEntry) {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].ID > entries[j].ID })
+	for i := range installations {
+		installations[i].Advisories = nil
+		installations[i].CVEs = nil
+		for _, entry := range entries {
+			if ok, fixed := entry.affectsVersion(installations[i].SemVer); ok {
+				installations[i].Advisories = append(installations[i].Advisories, advisory{ID: entry.ID, Aliases: entry.Aliases, Summary: entry.Summary, Fixed: fixed})
+			}
+		}
+	}
+}
+
+// checkAdvisories annotates installations from the configured vulnerability
+// database. It does nothing unless vuln_db is set.
+func checkAdvisories(cfg Config, installations []GoInstallation) error {
+	if cfg.VulnDB == "" || len(installations) == 0 {
+		return nil
+	}
+	paths, err := resolvePaths(cfg)
+	if err != nil {
+		return err
+	}
+	source, err := newVulnSource(cfg)
+	if err != nil {
+		return err
+	}
+	entries, err := fetchGoAdvisories(source, filepath.Join(paths.Cache, "vulndb"))
+	if err != nil {
+		return err
+	}
+	annotateAdvisories(installations, entries)
+	return nil
+}
+
+// advisoryLabel is the list badge for installations with advisories.
+func advisoryLabel(install GoInstallation) string {
+	if len(install.Advisories) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("🛡️  %d advisories", len(install.Advisories))
+}
+
+// maxAdvisoriesShown keeps a very old toolchain's list from filling the
+// screen.
+const maxAdvisoriesShown = 5
+
+func renderAdvisories(advisories []advisory) string {
+	var s string
+	for i, a := range advisories {
+		if i == maxAdvisoriesShown {
+			s += infoStyle.Render(fmt.Sprintf("        … and %d more", len(advisories)-maxAdvisoriesShown)) + "\n"
+			break
+		}
+		fixed := "no fix on this release line"
+		if a.Fixed != "" {
+			fixed = "fixed in go" + a.Fixed
+		}
+		s += warningStyle.Render(fmt.Sprintf("     🛡️  %s: %s (%s)", a.label(), a.Summary, fixed)) + "\n"
+	}
+	return s
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const testVulnIndex = `[
+  {"path": "stdlib", "vulns": [{"id": "GO-2024-2887", "modified": "2024-06-05T00:00:00Z"}]},
+  {"path": "toolchain", "vulns": [{"id": "GO-2023-2041", "modified": "2023-09-06T00:00:00Z"}]},
+  {"path": "golang.org/x/net", "vulns": [{"id": "GO-2023-1988", "modified": "2023-08-01T00:00:00Z"}]}
+]`
+
+var testVulnEntries = map[string]string{
+	"GO-2024-2887": `{"id": "GO-2024-2887", "modified": "2024-06-05T00:00:00Z", "aliases": ["CVE-2024-24790"],
+  "summary": "Unexpected behavior from Is methods for IPv4-mapped IPv6 addresses in net/netip",
+  "affected": [{"package": {"name": "stdlib"}, "ranges": [{"type": "SEMVER", "events": [
+    {"introduced": "0"}, {"fixed": "1.21.11"}, {"introduced": "1.22.0-0"}, {"fixed": "1.22.4"}]}]}]}`,
+	"GO-2023-2041": `{"id": "GO-2023-2041", "modified": "2023-09-06T00:00:00Z", "aliases": ["CVE-2023-39320"],
+  "summary": "Arbitrary code execution via go.mod toolchain directive in cmd/go",
+  "affected": [{"package": {"name": "toolchain"}, "ranges": [{"type": "SEMVER", "events": [
+    {"introduced": "1.21.0-0"}, {"fixed": "1.21.1"}]}]}]}`,
+}
+
+func writeVulnMirror(t *testing.T) string {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "index"), 0755)
+	os.MkdirAll(filepath.Join(dir, "ID"), 0755)
+	os.WriteFile(filepath.Join(dir, "index", "modules.json"), []byte(testVulnIndex), 0644)
+	for id, entry := range testVulnEntries {
+		os.WriteFile(filepath.Join(dir, "ID", id+".json"), []byte(entry), 0644)
+	}
+	return dir
+}
+
+func TestAffectsVersion(t *testing.T) {
+	source := vulnSource{base: writeVulnMirror(t)}
+	entries, err := fetchGoAdvisories(source, t.TempDir())
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Expected the stdlib and toolchain entries only, got %d (%v)", len(entries), err)
+	}
+	netip, toolchain := entries[0], entries[1]
+	testCases := []struct {
+		entry  osvEntry
+		semVer string
+		want   bool
+		fixed  string
+	}{
+		{netip, "1.22.3", true, "1.22.4"},
+		{netip, "1.22.4", false, ""},
+		{netip, "1.20.14", true, "1.21.11"},
+		{netip, "1.22rc1", true, "1.22.4"},
+		{netip, "1.23.0", false, ""},
+		{toolchain, "1.21.0", true, "1.21.1"},
+		{toolchain, "1.21rc2", true, "1.21.1"},
+		{toolchain, "1.20.5", false, ""},
+		{toolchain, "", false, ""},
+	}
+	for _, tc := range testCases {
+		got, fixed := tc.entry.affectsVersion(tc.semVer)
+		if got != tc.want || fixed != tc.fixed {
+			t.Errorf("%s affects %q = %v %q, want %v %q", tc.entry.ID, tc.semVer, got, fixed, tc.want, tc.fixed)
+		}
+	}
+}
+
+func TestCheckAdvisoriesOverHTTP(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/index/modules.json" {
+			w.Write([]byte(testVulnIndex))
+			return
+		}
+		entry, ok := testVulnEntries[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/ID/"), ".json")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(entry))
+	}))
+	defer server.Close()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	cfg := Config{VulnDB: server.URL}
+	installs := []GoInstallation{{SemVer: "1.21.0", CVEs: []string{"CVE-2024-24790"}}, {SemVer: "1.23.1"}}
+	if err := checkAdvisories(cfg, installs); err != nil {
+		t.Fatalf("checkAdvisories returned error: %v", err)
+	}
+	if len(installs[0].Advisories) != 2 || installs[0].Advisories[0].label() != "CVE-2024-24790" || installs[0].CVEs != nil {
+		t.Errorf("Expected both advisories to replace the embedded table, got %+v", installs[0])
+	}
+	if len(installs[1].Advisories) != 0 {
+		t.Errorf("Expected a current release to be clean, got %+v", installs[1].Advisories)
+	}
+	if label := supportLabel(installs[0]); !strings.Contains(label, "2 advisories") {
+		t.Errorf("Expected an advisory badge, got %q", label)
+	}
+
+	// Unchanged entries come from the cache
+	requests.Store(0)
+	if err := checkAdvisories(cfg, installs); err != nil {
+		t.Fatalf("checkAdvisories returned error: %v", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("Expected only the index to be fetched again, got %d requests", n)
+	}
+
+	setOffline(true)
+	defer setOffline(false)
+	if err := checkAdvisories(cfg, installs); err == nil {
+		t.Errorf("Expected the check to fail offline")
+	}
+}
+
+func TestRenderAdvisories(t *testing.T) {
+	var advisories []advisory
+	for i := 0; i < maxAdvisoriesShown+2; i++ {
+		advisories = append(advisories, advisory{ID: "GO-2024-0001", Summary: "a bug"})
+	}
+	advisories[0].Fixed = "1.22.4"
+	view := renderAdvisories(advisories)
+	if !strings.Contains(view, "fixed in go1.22.4") || !strings.Contains(view, "no fix on this release line") || !strings.Contains(view, "and 2 more") {
+		t.Errorf("Unexpected advisories view:\n%s", view)
+	}
+}
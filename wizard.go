@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// wizardQuestion is one screen of the first-run wizard. The first option is
+// the default.
+type wizardQuestion struct {
+	prompt  string
+	options []string
+	apply   func(cfg *Config, answer string)
+}
+
+var wizardQuestions = []wizardQuestion{
+	{
+		prompt:  "Which mode should fu-go start in?",
+		options: []string{"dry-run", "live"},
+		apply:   func(cfg *Config, answer string) { cfg.DefaultMode = answer },
+	},
+	{
+		prompt:  "Back up installations before removing them?",
+		options: []string{"always", "never"},
+		apply:   func(cfg *Config, answer string) { cfg.BackupPolicy = answer },
+	},
+	{
+		prompt:  "Color theme",
+		options: []string{"default", "mono"},
+		apply:   func(cfg *Config, answer string) { cfg.Theme = answer },
+	},
+	{
+		prompt:  "fu-go sends no telemetry. Logs, reports and backups never leave this machine.",
+		options: []string{"understood"},
+		apply:   func(cfg *Config, answer string) {},
+	},
+}
+
+// handleSetupKey drives the first-run wizard: ↑/↓ pick an option, enter
+// answers, esc skips the remaining questions and keeps their defaults.
+func (m model) handleSetupKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	question := wizardQuestions[m.wizardStep]
+	switch msg.String() {
+	case "ctrl+c", "q":
+		if m.logFile != nil {
+			m.logFile.Log("INFO", "User cancelled operation")
+			m.logFile.Close()
+		}
+		return m, tea.Quit
+	case "up", "left", "k":
+		if m.wizardChoice > 0 {
+			m.wizardChoice--
+		}
+	case "down", "right", "j":
+		if m.wizardChoice < len(question.options)-1 {
+			m.wizardChoice++
+		}
+	case "enter":
+		question.apply(&m.config, question.options[m.wizardChoice])
+		m.wizardStep++
+		m.wizardChoice = 0
+		if m.wizardStep == len(wizardQuestions) {
+			return m.finishSetup()
+		}
+	case "esc":
+		for _, remaining := range wizardQuestions[m.wizardStep:] {
+			remaining.apply(&m.config, remaining.options[0])
+		}
+		return m.finishSetup()
+	}
+	return m, nil
+}
+
+// finishSetup persists the answers so the wizard never runs again, applies
+// them to this run and starts detection.
+func (m model) finishSetup() (tea.Model, tea.Cmd) {
+	m.config.SetupComplete = true
+	m.needsSetup = false
+	if err := saveConfig(m.config); err != nil {
+		if m.logFile != nil {
+			m.logFile.Log("WARN", fmt.Sprintf("Preferences not saved: %v", err))
+		}
+	} else if m.logFile != nil {
+		m.logFile.Log("INFO", fmt.Sprintf("Saved preferences: mode %s, backups %s, theme %s", m.config.DefaultMode, m.config.BackupPolicy, m.config.Theme))
+	}
+	m.dryRun = m.config.startsInDryRun()
+	applyTheme(m.config.Theme)
+	return m.startDetection()
+}
+
+func (m model) renderSetup() string {
+	question := wizardQuestions[m.wizardStep]
+	var s string
+	s += highlightStyle.Render(fmt.Sprintf("👋 First-time setup (%d/%d)", m.wizardStep+1, len(wizardQuestions))) + "\n\n"
+	s += question.prompt + "\n\n"
+	for i, option := range question.options {
+		if i == m.wizardChoice {
+			s += highlightStyle.Render("  ▸ "+option) + "\n"
+		} else {
+			s += "    " + option + "\n"
+		}
+	}
+	s += "\n" + infoStyle.Render("↑/↓ to choose, ENTER to confirm, ESC to keep the defaults. Answers are saved to your config file.") + "\n"
+	return s
+}
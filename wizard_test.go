@@ -0,0 +1,67 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSetupWizardPersistsAnswers(t *testing.T) {
+	skipInAuditBuild(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("AppData", filepath.Join(home, "AppData"))
+
+	m := model{state: "setup", needsSetup: true, dryRun: true}
+	keys := []tea.KeyMsg{
+		{Type: tea.KeyDown},  // mode: live
+		{Type: tea.KeyEnter}, // confirm mode
+		{Type: tea.KeyDown},  // backups: never
+		{Type: tea.KeyEnter},
+		{Type: tea.KeyEnter}, // theme: default
+		{Type: tea.KeyEnter}, // telemetry acknowledgment
+	}
+	var updated tea.Model = m
+	for _, key := range keys {
+		updated, _ = updated.(model).Update(key)
+	}
+	result := updated.(model)
+
+	if result.state != "loading" {
+		t.Errorf("Expected loading state after the wizard, got %s", result.state)
+	}
+	if result.dryRun {
+		t.Error("Expected live mode to be applied to this run")
+	}
+
+	path, _ := configPath()
+	saved, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load saved config: %v", err)
+	}
+	if saved.DefaultMode != "live" || saved.BackupPolicy != "never" || saved.Theme != "default" || !saved.SetupComplete {
+		t.Errorf("Unexpected saved preferences: %+v", saved)
+	}
+}
+
+func TestSetupWizardSkipKeepsDefaults(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("AppData", filepath.Join(home, "AppData"))
+
+	m := model{state: "setup", needsSetup: true}
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	result := updated.(model)
+
+	if result.state != "loading" || !result.dryRun {
+		t.Errorf("Expected dry-run detection after skipping, got state %s dryRun %v", result.state, result.dryRun)
+	}
+	path, _ := configPath()
+	saved, _ := loadConfigFile(path)
+	if !saved.SetupComplete || saved.DefaultMode != "dry-run" {
+		t.Errorf("Expected defaults to be saved, got %+v", saved)
+	}
+}
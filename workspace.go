@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// With project_scan on, fu-go looks for go.mod files under the usual code
+// directories (or project_roots) and shows on the confirm screen how many Go
+// projects the machine holds and when they were last worked on: a reality
+// check before removing the toolchain that builds them.
+
+// defaultProjectRoots are where people tend to keep their code.
+var defaultProjectRoots = []string{"~/code", "~/src", "~/projects", "~/dev", "~/work", "~/repos", "~/go/src"}
+
+const (
+	// projectScanDepth bounds the walk below each root; projects sit a few
+	// levels down, e.g. ~/src/github.com/org/repo/cmd/tool.
+	projectScanDepth = 6
+	// projectActiveWithin is how recently a project must have been touched
+	// to count as active.
+	projectActiveWithin = 90 * 24 * time.Hour
+	maxProjectsShown    = 5
+)
+
+// goProject is a directory with a go.mod, and the newest modification time
+// of its Go sources, go.mod and go.sum.
+type goProject struct {
+	Dir     string
+	Module  string
+	Touched time.Time
+}
+
+// projectRoots returns the configured roots, or the defaults, with ~
+// expanded.
+func projectRoots(cfg Config) []string {
+	roots := cfg.ProjectRoots
+	if len(roots) == 0 {
+		roots = defaultProjectRoots
+	}
+	var expanded []string
+	for _, root := range roots {
+		expanded = append(expanded, filepath.Clean(expandHome(root)))
+	}
+	return expanded
+}
+
+// moduleName reads the module path from a go.mod.
+func moduleName(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// scanProjects finds the Go modules under roots, attributing every .go file
+// to the nearest enclosing module. Missing roots are skipped; a directory
+// under two roots is counted once.
+func scanProjects(roots []string) []goProject {
+	var projects []goProject
+	index := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		if seen[root] {
+			continue
+		}
+		seen[root] = true
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				rel, _ := filepath.Rel(root, path)
+				if path != root && (skipScanDir(d.Name()) || strings.Count(rel, string(filepath.Separator)) >= projectScanDepth) {
+					return filepath.SkipDir
+				}
+				// Register the module on the way in, as its sources can sort
+				// before go.mod
+				if _, ok := index[path]; !ok {
+					if data, err := os.ReadFile(filepath.Join(path, "go.mod")); err == nil {
+						index[path] = len(projects)
+						projects = append(projects, goProject{Dir: path, Module: moduleName(data)})
+					}
+				}
+				return nil
+			}
+			name := d.Name()
+			if name != "go.mod" && name != "go.sum" && !strings.HasSuffix(name, ".go") {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			for owner := filepath.Dir(path); ; owner = filepath.Dir(owner) {
+				if i, ok := index[owner]; ok {
+					if info.ModTime().After(projects[i].Touched) {
+						projects[i].Touched = info.ModTime()
+					}
+					break
+				}
+				if owner == root || owner == filepath.Dir(owner) {
+					break
+				}
+			}
+			return nil
+		})
+	}
+	sort.SliceStable(projects, func(i, j int) bool { return projects[i].Touched.After(projects[j].Touched) })
+	return projects
+}
+
+// projectsScanned carries the workspace scan into Update.
+type projectsScanned struct {
+	roots    []string
+	projects []goProject
+}
+
+// projectScanCmd starts the scan when project_scan is on.
+func (m model) projectScanCmd() tea.Cmd {
+	if !m.config.ProjectScan {
+		return nil
+	}
+	roots := projectRoots(m.config)
+	if m.opts.simulate {
+		return simulatedProjectScanCmd(roots)
+	}
+	return func() tea.Msg {
+		return projectsScanned{roots: roots, projects: scanProjects(roots)}
+	}
+}
+
+func simulatedProjectScanCmd(roots []string) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(simulatedDelay(300*time.Millisecond, 0))
+		now := time.Now()
+		return projectsScanned{roots: roots, projects: []goProject{
+			{Dir: "/home/gopher/code/api", Module: "example.com/api", Touched: now.Add(-26 * time.Hour)},
+			{Dir: "/home/gopher/code/cli", Module: "example.com/cli", Touched: now.Add(-9 * 24 * time.Hour)},
+			{Dir: "/home/gopher/src/aoc2023", Module: "aoc2023", Touched: now.Add(-300 * 24 * time.Hour)},
+		}}
+	}
+}
+
+func (m model) handleProjectsScanned(msg projectsScanned) model {
+	m.projectScan = &msg
+	if m.logFile != nil {
+		m.logFile.Log("INFO", fmt.Sprintf("Found %d Go project(s) under %s", len(msg.projects), strings.Join(msg.roots, ", ")))
+		for _, project := range msg.projects {
+			m.logFile.Log("INFO", fmt.Sprintf("Go project %s (%s), last touched %s", project.Dir, project.Module, project.Touched.Format(time.RFC3339)))
+		}
+	}
+	return m
+}
+
+// activeProjects counts the projects touched within projectActiveWithin.
+func activeProjects(projects []goProject, now time.Time) int {
+	n := 0
+	for _, project := range projects {
+		if now.Sub(project.Touched) < projectActiveWithin {
+			n++
+		}
+	}
+	return n
+}
+
+// touchedAgo phrases a modification time as "today", "3 days ago" or
+// "5 months ago".
+func touchedAgo(touched, now time.Time) string {
+	if touched.IsZero() {
+		return "never"
+	}
+	days := int(now.Sub(touched).Hours() / 24)
+	switch {
+	case days < 1:
+		return "today"
+	case days == 1:
+		return "yesterday"
+	case days < 60:
+		return fmt.Sprintf("%d days ago", days)
+	case days < 730:
+		return fmt.Sprintf("%d months ago", days/30)
+	}
+	return fmt.Sprintf("%d years ago", days/365)
+}
+
+// renderProjects is the "are you sure you're done with Go?" reality check.
+func (m model) renderProjects() string {
+	if !m.config.ProjectScan {
+		return ""
+	}
+	if m.projectScan == nil {
+		return infoStyle.Render("🧑‍💻 Looking for Go projects on this machine...") + "\n\n"
+	}
+	scan := m.projectScan
+	if len(scan.projects) == 0 {
+		return infoStyle.Render(fmt.Sprintf("🧑‍💻 No Go projects under %s", strings.Join(scan.roots, ", "))) + "\n\n"
+	}
+	now := time.Now()
+	active := activeProjects(scan.projects, now)
+	style := infoStyle
+	if active > 0 {
+		style = warningStyle
+	}
+	s := style.Render(fmt.Sprintf("🧑‍💻 %d Go project(s) on this machine, %d touched in the last 90 days. Are you sure you're done with Go?", len(scan.projects), active)) + "\n"
+	for i, project := range scan.projects {
+		if i == maxProjectsShown {
+			s += infoStyle.Render(fmt.Sprintf("   … and %d more", len(scan.projects)-maxProjectsShown)) + "\n"
+			break
+		}
+		name := project.Module
+		if name == "" {
+			name = filepath.Base(project.Dir)
+		}
+		s += infoStyle.Render(fmt.Sprintf("   • %s in %s, last touched %s", name, project.Dir, touchedAgo(project.Touched, now))) + "\n"
+	}
+	return s + "\n"
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScanProjects(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string, modified time.Time) {
+		t.Helper()
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	old := now.Add(-400 * 24 * time.Hour)
+	write("api/go.mod", "module example.com/api\n\ngo 1.22\n", old)
+	// Sorts before go.mod and is the newest file of the project
+	write("api/cmd/server/main.go", "package main\n", now.Add(-2*time.Hour))
+	write("api/tools/go.mod", "module example.com/api/tools\n", old)
+	write("api/tools/gen.go", "package tools\n", old)
+	write("api/vendor/example.com/dep/go.mod", "module example.com/dep\n", now)
+	write("aoc/go.mod", "module aoc\n", old)
+	write("aoc/day1.go", "package main\n", old)
+	write("notes/README.md", "not Go\n", now)
+
+	projects := scanProjects([]string{root, root, filepath.Join(root, "missing")})
+	if len(projects) != 3 {
+		t.Fatalf("Expected three modules, got %+v", projects)
+	}
+	if age := now.Sub(projects[0].Touched); projects[0].Module != "example.com/api" || age < time.Hour || age > 3*time.Hour {
+		t.Errorf("Expected the api module to be the most recently touched, got %+v", projects[0])
+	}
+	for _, project := range projects[1:] {
+		if project.Module != "example.com/api/tools" && project.Module != "aoc" {
+			t.Errorf("Unexpected module %+v", project)
+		}
+	}
+	if got := activeProjects(projects, now); got != 1 {
+		t.Errorf("Expected one active project, got %d", got)
+	}
+}
+
+func TestTouchedAgo(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[time.Duration]string{
+		time.Hour:            "today",
+		30 * time.Hour:       "yesterday",
+		9 * 24 * time.Hour:   "9 days ago",
+		300 * 24 * time.Hour: "10 months ago",
+		900 * 24 * time.Hour: "2 years ago",
+	}
+	for age, want := range tests {
+		if got := touchedAgo(now.Add(-age), now); got != want {
+			t.Errorf("touchedAgo(%s) = %q, expected %q", age, got, want)
+		}
+	}
+	if got := touchedAgo(time.Time{}, now); got != "never" {
+		t.Errorf("Expected a project without sources to be never touched, got %q", got)
+	}
+}
+
+func TestRenderProjects(t *testing.T) {
+	m := model{}
+	if m.projectScanCmd() != nil || m.renderProjects() != "" {
+		t.Fatalf("Expected no scan unless project_scan is on")
+	}
+	m.config.ProjectScan = true
+	m.config.ProjectRoots = []string{t.TempDir()}
+	if m.projectScanCmd() == nil || !strings.Contains(m.renderProjects(), "Looking for Go projects") {
+		t.Fatalf("Expected the scan to start")
+	}
+
+	now := time.Now()
+	m = m.handleProjectsScanned(projectsScanned{roots: m.config.ProjectRoots, projects: []goProject{
+		{Dir: "/src/api", Module: "example.com/api", Touched: now.Add(-26 * time.Hour)},
+		{Dir: "/src/aoc", Touched: now.Add(-400 * 24 * time.Hour)},
+	}})
+	view := m.renderProjects()
+	for _, want := range []string{"2 Go project(s) on this machine, 1 touched in the last 90 days", "example.com/api in /src/api, last touched yesterday", "aoc in /src/aoc"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in %q", want, view)
+		}
+	}
+}
+
+func TestProjectRootsConfig(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	if roots := projectRoots(Config{}); len(roots) != len(defaultProjectRoots) || roots[0] != filepath.Join(home, "code") {
+		t.Errorf("Unexpected default roots %v", roots)
+	}
+	if err := (Config{ProjectRoots: []string{"code"}}).validate(); err == nil {
+		t.Errorf("Expected a relative project root to be rejected")
+	}
+	if err := (Config{ProjectRoots: []string{"~/code", "/srv/src"}}).validate(); err != nil {
+		t.Errorf("Expected absolute and ~ roots to be accepted, got %v", err)
+	}
+}
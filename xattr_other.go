@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !freebsd && !netbsd
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// readXattrs finds nothing here: fu-go cannot read extended attributes on
+// this system.
+func readXattrs(path string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+func writeXattr(path, name string, value []byte) error {
+	return fmt.Errorf("extended attributes cannot be set on %s", runtime.GOOS)
+}
@@ -0,0 +1,47 @@
+//go:build linux || darwin || freebsd || netbsd
+
+package main
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// readXattrs returns the extended attributes of path, not following a
+// symlink, or nil where the file system has none.
+func readXattrs(path string) (map[string][]byte, error) {
+	size, err := unix.Llistxattr(path, nil)
+	if err != nil {
+		if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	buf := make([]byte, size)
+	if size, err = unix.Llistxattr(path, buf); err != nil {
+		return nil, err
+	}
+	attrs := make(map[string][]byte)
+	for _, name := range splitXattrNames(buf[:size]) {
+		n, err := unix.Lgetxattr(path, name, nil)
+		if err != nil {
+			// Removed since it was listed, or not readable by this user
+			continue
+		}
+		value := make([]byte, n)
+		if n, err = unix.Lgetxattr(path, name, value); err != nil {
+			continue
+		}
+		attrs[name] = value[:n]
+	}
+	return attrs, nil
+}
+
+// writeXattr sets one extended attribute of path, not following a symlink.
+func writeXattr(path, name string, value []byte) error {
+	return unix.Lsetxattr(path, name, value, 0)
+}
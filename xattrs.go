@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A toolchain restored on an enforcing SELinux host without its labels,
+// such as /usr/lib/golang on RHEL, comes back as default_t and policy stops
+// anything from running go. So backups record every file's extended
+// attributes, security.selinux among them, and restore sets them again on
+// the staged tree before it is moved into place. Dedup manifests hold them
+// per file; archive backups keep them next to the archive in
+// <name>.xattrs.json, since tar only stores them with flags not every tar
+// has. AppArmor confines by path and needs nothing more than the files
+// going back where they were.
+
+const selinuxXattr = "security.selinux"
+
+// selinuxDir is where an SELinux kernel exposes its state.
+var selinuxDir = "/sys/fs/selinux"
+
+// treeXattrs are the extended attributes of an installation's files, by
+// slash-separated path relative to it ("." for the top).
+type treeXattrs map[string]map[string][]byte
+
+// collectXattrs reads the extended attributes of everything under root.
+func collectXattrs(root string) (treeXattrs, error) {
+	attrs := make(treeXattrs)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		found, err := readXattrs(path)
+		if err != nil {
+			return fmt.Errorf("failed to read the extended attributes of %s: %v", path, err)
+		}
+		if len(found) > 0 {
+			attrs[filepath.ToSlash(rel)] = found
+		}
+		return nil
+	})
+	return attrs, err
+}
+
+// labelled reports whether any file carries an SELinux context.
+func (t treeXattrs) labelled() bool {
+	for _, attrs := range t {
+		if _, ok := attrs[selinuxXattr]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// applyXattrs sets attrs on the tree at root, and returns a line for each
+// attribute that could not be set. Without privileges security.* cannot be
+// set, so those fail on a restore as an ordinary user.
+func applyXattrs(root string, attrs treeXattrs) []string {
+	paths := make([]string, 0, len(attrs))
+	for rel := range attrs {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+	var failed []string
+	for _, rel := range paths {
+		path, err := unpackPath(root, rel)
+		if err != nil {
+			failed = append(failed, err.Error())
+			continue
+		}
+		names := make([]string, 0, len(attrs[rel]))
+		for name := range attrs[rel] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := setXattr(path, name, attrs[rel][name]); err != nil {
+				failed = append(failed, fmt.Sprintf("%s %s: %v", path, name, err))
+			}
+		}
+	}
+	return failed
+}
+
+// setXattr sets an attribute, making a read-only file writable for the
+// moment it takes: user.* attributes need write access to the file.
+func setXattr(path, name string, value []byte) error {
+	err := writeXattr(path, name, value)
+	if err == nil || !os.IsPermission(err) {
+		return err
+	}
+	info, statErr := os.Lstat(path)
+	if statErr != nil || info.Mode()&fs.ModeSymlink != 0 || info.Mode().Perm()&0200 != 0 {
+		return err
+	}
+	if os.Chmod(path, info.Mode().Perm()|0200) != nil {
+		return err
+	}
+	defer os.Chmod(path, info.Mode().Perm())
+	return writeXattr(path, name, value)
+}
+
+// splitXattrNames splits the NUL-terminated names listxattr(2) returns.
+func splitXattrNames(buf []byte) []string {
+	var names []string
+	for _, name := range bytes.Split(buf, []byte{0}) {
+		if len(name) > 0 {
+			names = append(names, string(name))
+		}
+	}
+	return names
+}
+
+// xattrSidecarName is the file next to an archive that holds its tree's
+// extended attributes.
+func xattrSidecarName(archive string) string {
+	return strings.TrimSuffix(archive, ".tar.gz") + ".xattrs.json"
+}
+
+func writeXattrSidecar(path string, attrs treeXattrs) error {
+	data, err := json.Marshal(attrs)
+	if err != nil {
+		return fmt.Errorf("failed to encode extended attributes: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write extended attributes: %v", err)
+	}
+	return nil
+}
+
+func readXattrSidecar(path string) (treeXattrs, error) {
+	var attrs treeXattrs
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extended attributes: %v", err)
+	}
+	if err := json.Unmarshal(data, &attrs); err != nil {
+		return nil, fmt.Errorf("failed to parse extended attributes %s: %v", path, err)
+	}
+	return attrs, nil
+}
+
+// manifestXattrs are the extended attributes a dedup manifest recorded.
+func manifestXattrs(manifest treeManifest) treeXattrs {
+	attrs := make(treeXattrs)
+	for _, file := range manifest.Files {
+		if len(file.Xattrs) > 0 {
+			attrs[file.Path] = file.Xattrs
+		}
+	}
+	return attrs
+}
+
+// selinuxEnabled reports whether this host runs SELinux.
+func selinuxEnabled() bool {
+	return currentPlatform.selinux()
+}
+
+// relabelTree gives path the contexts the loaded policy assigns to it, for
+// a restore whose backup holds none, say because it was made on a host
+// without SELinux.
+func relabelTree(path string) error {
+	if _, err := exec.LookPath("restorecon"); err != nil {
+		return fmt.Errorf("restorecon is not installed; run it on %s to label the restored files", path)
+	}
+	output, err := commandCombinedOutputTimeout(archiveCommandTimeout, "restorecon", "-R", path)
+	if err != nil {
+		if text := strings.TrimSpace(string(output)); text != "" {
+			return fmt.Errorf("restorecon -R %s failed: %v: %s", path, err, text)
+		}
+		return fmt.Errorf("restorecon -R %s failed: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setTestXattr sets a user.* attribute, skipping the test where the file
+// system or platform has no extended attributes.
+func setTestXattr(t *testing.T, path, name, value string) {
+	t.Helper()
+	if err := writeXattr(path, name, []byte(value)); err != nil {
+		t.Skipf("extended attributes are not supported here: %v", err)
+	}
+}
+
+func TestSplitXattrNames(t *testing.T) {
+	got := splitXattrNames([]byte("security.selinux\x00user.fugo\x00"))
+	if want := []string{"security.selinux", "user.fugo"}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got := splitXattrNames(nil); len(got) != 0 {
+		t.Errorf("Expected no names, got %v", got)
+	}
+}
+
+func TestXattrsRoundTrip(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "go")
+	os.MkdirAll(filepath.Join(src, "bin"), 0755)
+	os.WriteFile(filepath.Join(src, "bin", "go"), []byte("go"), 0755)
+	setTestXattr(t, filepath.Join(src, "bin", "go"), "user.fugo.label", "bin_t")
+	setTestXattr(t, src, "user.fugo.label", "usr_t")
+
+	attrs, err := collectXattrs(src)
+	if err != nil {
+		t.Fatalf("collectXattrs returned error: %v", err)
+	}
+	if string(attrs["bin/go"]["user.fugo.label"]) != "bin_t" || string(attrs["."]["user.fugo.label"]) != "usr_t" {
+		t.Fatalf("Unexpected attributes %v", attrs)
+	}
+
+	// A read-only file still gets its attributes back
+	dst := filepath.Join(t.TempDir(), "go")
+	os.MkdirAll(filepath.Join(dst, "bin"), 0755)
+	os.WriteFile(filepath.Join(dst, "bin", "go"), []byte("go"), 0555)
+	if failed := applyXattrs(dst, attrs); len(failed) != 0 {
+		t.Fatalf("applyXattrs failed: %v", failed)
+	}
+	got, _ := readXattrs(filepath.Join(dst, "bin", "go"))
+	if string(got["user.fugo.label"]) != "bin_t" {
+		t.Errorf("Expected the attribute to be restored, got %v", got)
+	}
+	if info, _ := os.Stat(filepath.Join(dst, "bin", "go")); info.Mode().Perm() != 0555 {
+		t.Errorf("Expected the file to be read-only again, got %v", info.Mode())
+	}
+
+	if failed := applyXattrs(dst, treeXattrs{"../etc": {"user.fugo": []byte("x")}}); len(failed) != 1 || !strings.Contains(failed[0], "leaves the installation") {
+		t.Errorf("Expected a path outside the tree to be refused, got %v", failed)
+	}
+}
+
+func TestRestoreKeepsXattrs(t *testing.T) {
+	saved := selinuxDir
+	selinuxDir = t.TempDir() // no enforce file: SELinux is off
+	t.Cleanup(func() { selinuxDir = saved })
+
+	for _, format := range []string{backupFormatArchive, backupFormatDedup, backupFormatQuarantine} {
+		t.Run(format, func(t *testing.T) {
+			root := filepath.Join(t.TempDir(), "go")
+			os.MkdirAll(filepath.Join(root, "bin"), 0755)
+			os.WriteFile(filepath.Join(root, "bin", "go"), []byte("go"), 0755)
+			setTestXattr(t, filepath.Join(root, "bin", "go"), "user.fugo.label", "bin_t")
+			backups := t.TempDir()
+			dir, err := writeBackupSet(backups, format, []GoInstallation{{Path: root}}, time.Now())
+			if err != nil {
+				t.Fatalf("writeBackupSet returned error: %v", err)
+			}
+			set, _ := findBackupSet(backups, filepath.Base(dir))
+			if format == backupFormatArchive && set.index.Entries[0].Xattrs == "" {
+				t.Errorf("Expected the archive entry to name its attributes file")
+			}
+			os.RemoveAll(root)
+
+			outcomes, err := restoreBackupSet(set, restoreOptions{}, time.Now())
+			if err != nil {
+				t.Fatalf("restoreBackupSet returned error: %v", err)
+			}
+			if len(outcomes) != 1 || len(outcomes[0].warnings) != 0 {
+				t.Errorf("Unexpected outcomes %+v", outcomes)
+			}
+			got, _ := readXattrs(filepath.Join(root, "bin", "go"))
+			if string(got["user.fugo.label"]) != "bin_t" {
+				t.Errorf("Expected the attribute to be restored, got %v", got)
+			}
+		})
+	}
+}
+
+func TestRestoreRelabelsUnlabelledBackup(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("SELinux is Linux only")
+	}
+	saved := selinuxDir
+	selinuxDir = t.TempDir()
+	os.WriteFile(filepath.Join(selinuxDir, "enforce"), []byte("1"), 0644)
+	t.Setenv("PATH", t.TempDir()) // no restorecon
+	t.Cleanup(func() { selinuxDir = saved })
+
+	outcome := restoreOutcome{target: "/usr/lib/golang"}
+	outcome.relabel(treeXattrs{"bin/go": {selinuxXattr: []byte("system_u:object_r:bin_t:s0")}})
+	if len(outcome.warnings) != 0 {
+		t.Errorf("Expected a labelled backup to keep its own contexts, got %v", outcome.warnings)
+	}
+	outcome.relabel(nil)
+	if len(outcome.warnings) != 1 || !strings.Contains(outcome.warnings[0], "run it on /usr/lib/golang") {
+		t.Errorf("Expected a warning to run restorecon, got %v", outcome.warnings)
+	}
+}